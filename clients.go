@@ -18,44 +18,183 @@ import (
 	"sync"
 
 	eth2client "github.com/attestantio/go-eth2-client"
-	autoclient "github.com/attestantio/go-eth2-client/auto"
+	"github.com/attestantio/go-eth2-client/http"
 	"github.com/pkg/errors"
-	"github.com/spf13/viper"
+	coalescedeth2client "github.com/wealdtech/chaind/services/eth2client/coalesced"
+	genesisstateeth2client "github.com/wealdtech/chaind/services/eth2client/genesisstate"
+	monitoredeth2client "github.com/wealdtech/chaind/services/eth2client/monitored"
+	proxyeth2client "github.com/wealdtech/chaind/services/eth2client/proxy"
+	sszeth2client "github.com/wealdtech/chaind/services/eth2client/ssz"
+	"github.com/wealdtech/chaind/services/metrics"
 	"github.com/wealdtech/chaind/util"
 )
 
 var clients map[string]eth2client.Service
 var clientsMu sync.Mutex
 
-// fetchClient fetches a client service, instantiating it if required.
-func fetchClient(ctx context.Context, address string) (eth2client.Service, error) {
+// genesisProvider provides genesis information for the chain.
+type genesisProvider interface {
+	eth2client.GenesisProvider
+	eth2client.GenesisTimeProvider
+}
+
+var genesisStates map[string]*genesisstateeth2client.Service
+var genesisStatesMu sync.Mutex
+
+// fetchClient fetches a client service for a network, instantiating it if required.
+func fetchClient(ctx context.Context, network *network, address string, monitor metrics.Service) (eth2client.Service, error) {
 	clientsMu.Lock()
 	defer clientsMu.Unlock()
 	if clients == nil {
 		clients = make(map[string]eth2client.Service)
 	}
 
+	config := network.config
+	// Clients are shared between the modules of a network.
+	key := util.NetworkServiceName(network.name, address)
 	var client eth2client.Service
 	var exists bool
-	if client, exists = clients[address]; !exists {
-		var err error
-		client, err = autoclient.New(ctx,
-			autoclient.WithLogLevel(util.LogLevel("eth2client")),
-			autoclient.WithTimeout(viper.GetDuration("eth2client.timeout")),
-			autoclient.WithAddress(address))
+	if client, exists = clients[key]; !exists {
+		// Clients are shared between modules, so are not tied to the lifetime of the requesting module.
+		clientAddress, err := proxyClientAddress(util.WithoutCancel(ctx), network, address)
+		if err != nil {
+			return nil, err
+		}
+		client, err = http.New(util.WithoutCancel(ctx),
+			http.WithLogLevel(network.logLevel("eth2client")),
+			http.WithTimeout(config.GetDuration("eth2client.timeout")),
+			http.WithAddress(clientAddress),
+			// Requests for validators are batched in to chunks of this many indices or public keys.
+			http.WithIndexChunkSize(config.GetInt("eth2client.index-chunk-size")),
+			http.WithPubKeyChunkSize(config.GetInt("eth2client.pubkey-chunk-size")),
+		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to initiate client")
 		}
+		// Fetch blocks and states in SSZ where possible.
+		if httpClient, isHTTPClient := client.(*http.Service); isHTTPClient && config.GetBool("eth2client.ssz") {
+			client, err = sszeth2client.New(ctx,
+				sszeth2client.WithLogLevel(network.logLevel("eth2client")),
+				sszeth2client.WithNetwork(network.name),
+				sszeth2client.WithHTTPClient(httpClient),
+				sszeth2client.WithTimeout(config.GetDuration("eth2client.timeout")),
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to initiate SSZ client")
+			}
+		}
 		// Confirm that the client provides the required interfaces.
 		if err := confirmClientInterfaces(client); err != nil {
 			return nil, errors.Wrap(err, "missing required interface")
 		}
-		clients[address] = client
+		// Wrap the client to count requests and failures by endpoint.
+		client, err = monitoredeth2client.New(ctx,
+			monitoredeth2client.WithLogLevel(network.logLevel("eth2client")),
+			monitoredeth2client.WithNetwork(network.name),
+			monitoredeth2client.WithMonitor(monitor),
+			monitoredeth2client.WithETH2Client(client),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initiate monitored client")
+		}
+		// Wrap the client to coalesce identical requests from different modules.
+		client, err = coalescedeth2client.New(ctx,
+			coalescedeth2client.WithLogLevel(network.logLevel("eth2client")),
+			coalescedeth2client.WithNetwork(network.name),
+			coalescedeth2client.WithMonitor(monitor),
+			coalescedeth2client.WithETH2Client(client),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initiate coalesced client")
+		}
+		clients[key] = client
 	}
 
 	return client, nil
 }
 
+// proxyClientAddress returns the address through which the client connects to the
+// beacon node.  If the beacon node is a unix domain socket, or is reached through an
+// HTTP or SOCKS proxy, or TLS or authentication is configured for the beacon node, the
+// client connects through a proxy that holds the configuration, otherwise it connects
+// directly.
+func proxyClientAddress(ctx context.Context, network *network, address string) (string, error) {
+	config := network.config
+	clientCert, clientKey, caCert, err := readTLSFiles(config, "eth2client.tls")
+	if err != nil {
+		return "", errors.Wrap(err, "invalid beacon node TLS configuration")
+	}
+	headers, bearerToken, jwtSecret, err := readAuth(config, "eth2client")
+	if err != nil {
+		return "", errors.Wrap(err, "invalid beacon node authentication configuration")
+	}
+	proxyURL := config.GetString("eth2client.proxy")
+	if util.UnixSocketPath(address) == "" && proxyURL == "" &&
+		clientCert == nil && caCert == nil && len(headers) == 0 && bearerToken == "" && jwtSecret == nil {
+		return address, nil
+	}
+
+	proxy, err := proxyeth2client.New(ctx,
+		proxyeth2client.WithLogLevel(network.logLevel("eth2client")),
+		proxyeth2client.WithNetwork(network.name),
+		proxyeth2client.WithAddress(address),
+		proxyeth2client.WithProxyURL(proxyURL),
+		proxyeth2client.WithClientCert(clientCert),
+		proxyeth2client.WithClientKey(clientKey),
+		proxyeth2client.WithCACert(caCert),
+		proxyeth2client.WithHeaders(headers),
+		proxyeth2client.WithBearerToken(bearerToken),
+		proxyeth2client.WithJWTSecret(jwtSecret),
+		proxyeth2client.WithSecrets(secretsService),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to start beacon node proxy")
+	}
+
+	return proxy.Address(), nil
+}
+
+// fetchGenesisProvider fetches the provider of genesis information.  This is the genesis
+// state file if one is configured, otherwise the supplied client.
+func fetchGenesisProvider(ctx context.Context, network *network, client eth2client.Service) (genesisProvider, error) {
+	if network.config.GetString("eth2client.genesis-state") != "" {
+		return fetchGenesisState(ctx, network, resolvePath(network.config.GetString("eth2client.genesis-state")))
+	}
+
+	provider, isProvider := client.(genesisProvider)
+	if !isProvider {
+		return nil, errors.New("client does not provide genesis information")
+	}
+
+	return provider, nil
+}
+
+// fetchGenesisState fetches the genesis state held in a file for a network, reading it if required.
+func fetchGenesisState(ctx context.Context, network *network, path string) (*genesisstateeth2client.Service, error) {
+	genesisStatesMu.Lock()
+	defer genesisStatesMu.Unlock()
+	if genesisStates == nil {
+		genesisStates = make(map[string]*genesisstateeth2client.Service)
+	}
+
+	key := util.NetworkServiceName(network.name, path)
+	genesisState, exists := genesisStates[key]
+	if !exists {
+		var err error
+		genesisState, err = genesisstateeth2client.New(ctx,
+			genesisstateeth2client.WithLogLevel(network.logLevel("eth2client")),
+			genesisstateeth2client.WithNetwork(network.name),
+			genesisstateeth2client.WithPath(path),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load genesis state")
+		}
+		genesisStates[key] = genesisState
+	}
+
+	return genesisState, nil
+}
+
 func confirmClientInterfaces(client eth2client.Service) error {
 	if _, isProvider := client.(eth2client.GenesisTimeProvider); !isProvider {
 		return errors.New("client is not a GenesisTimeProvider")
@@ -0,0 +1,124 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// runtimeStats are runtime statistics served by the debug server.
+type runtimeStats struct {
+	Goroutines   int    `json:"goroutines"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"num_gc"`
+	LastGCPause  uint64 `json:"last_gc_pause_ns"`
+	NextGC       uint64 `json:"next_gc"`
+}
+
+// initDebug starts the debug server, if configured.
+// The debug server provides pprof profiles and runtime statistics, for diagnosing issues such
+// as memory growth during long-running catchups.
+func initDebug(ctx context.Context) error {
+	address := viper.GetString("debug.listen-address")
+	if address == "" {
+		// Fall back to the deprecated profile address.
+		address = viper.GetString("profile-address")
+	}
+	if address == "" {
+		log.Debug().Msg("No debug listen address supplied; debug server not starting")
+		return nil
+	}
+
+	if viper.GetInt("debug.mutex-profile-fraction") < 0 {
+		return errors.New("debug mutex profile fraction cannot be negative")
+	}
+	runtime.SetMutexProfileFraction(viper.GetInt("debug.mutex-profile-fraction"))
+	runtime.SetBlockProfileRate(viper.GetInt("debug.block-profile-rate"))
+
+	// The debug handlers are served on their own mux, so that they are not exposed by other servers.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", handleRuntime)
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Info().Str("listen_address", address).Msg("Starting debug server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Str("listen_address", address).Err(err).Msg("Failed to run debug server")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			log.Debug().Err(err).Msg("Failed to close debug server")
+		}
+	}()
+
+	return nil
+}
+
+// handleRuntime serves runtime statistics.
+func handleRuntime(w http.ResponseWriter, _ *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := &runtimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		HeapIdle:     memStats.HeapIdle,
+		HeapReleased: memStats.HeapReleased,
+		HeapObjects:  memStats.HeapObjects,
+		StackInuse:   memStats.StackInuse,
+		Sys:          memStats.Sys,
+		NumGC:        memStats.NumGC,
+		LastGCPause:  memStats.PauseNs[(memStats.NumGC+255)%256],
+		NextGC:       memStats.NextGC,
+	}
+
+	body, err := json.Marshal(stats)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal runtime statistics")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(body); err != nil {
+		log.Debug().Err(err).Msg("Failed to write runtime statistics")
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// BlockHandler provides interfaces for handling indexed blocks.
+type BlockHandler interface {
+	// OnBlockIndexed is called when a block has been committed to the database.
+	// It is called in slot order, so should return promptly.
+	OnBlockIndexed(ctx context.Context, block *chaindb.Block)
+}
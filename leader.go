@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/leader"
+	postgresqlleader "github.com/wealdtech/chaind/services/leader/postgresql"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/util"
+)
+
+// acquireLeadership blocks until this instance is the leader, if leader election is enabled.
+// It returns nil if leader election is not enabled.
+func acquireLeadership(ctx context.Context, monitor metrics.Service) (leader.Service, error) {
+	if !viper.GetBool("leader-election.enable") {
+		return nil, nil
+	}
+
+	connectionURL, err := resolveSecret(ctx, viper.GetString("chaindb.url"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve database URL")
+	}
+	clientCert, clientKey, caCert, err := readTLSFiles(viper.GetViper(), "chaindb.tls")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid database TLS configuration")
+	}
+
+	leaderSvc, err := postgresqlleader.New(ctx,
+		postgresqlleader.WithLogLevel(util.LogLevel("leader-election")),
+		postgresqlleader.WithMonitor(monitor),
+		postgresqlleader.WithConnectionURL(connectionURL),
+		postgresqlleader.WithClientCert(clientCert),
+		postgresqlleader.WithClientKey(clientKey),
+		postgresqlleader.WithCACert(caCert),
+		postgresqlleader.WithLockID(leaderLockID()),
+		postgresqlleader.WithRetryInterval(viper.GetDuration("leader-election.retry-interval")),
+		postgresqlleader.WithCheckInterval(viper.GetDuration("leader-election.check-interval")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start leader election service")
+	}
+
+	log.Info().Msg("Waiting to become leader")
+	notifySystemd("STATUS=Standby; waiting to become leader")
+	if err := leaderSvc.Acquire(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to become leader")
+	}
+	notifySystemd("STATUS=Leader")
+
+	return leaderSvc, nil
+}
+
+// leaderLockID returns the ID of the advisory lock used for leader election.  Unless
+// configured explicitly it is derived from the database schema, so that deployments
+// using different schemas in the same database elect their leaders independently.
+func leaderLockID() int64 {
+	if lockID := viper.GetInt64("leader-election.lock-id"); lockID != 0 {
+		return lockID
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte("chaind/" + viper.GetString("chaindb.schema")))
+	return int64(hash.Sum64())
+}
@@ -14,35 +14,134 @@
 package main
 
 import (
+	"context"
+	"io"
 	"os"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/errorsink"
+	sentryerrorsink "github.com/wealdtech/chaind/services/errorsink/sentry"
 	"github.com/wealdtech/chaind/util"
 )
 
 // log.
 var log zerolog.Logger
 
+// errorSink is the sink for errors; nil if errors are not reported.
+var errorSink errorsink.Service
+
 // initLogging initialises logging.
 func initLogging() error {
 	// We set the global logging level to trace, because if the global log level is higher than the
 	// local log level the local level is ignored.  It is then overridden for each module.
 	zerolog.SetGlobalLevel(zerolog.TraceLevel)
 
+	var output io.Writer = os.Stderr
 	// Change the output file.
 	if viper.GetString("log-file") != "" {
 		f, err := os.OpenFile(resolvePath(viper.GetString("log-file")), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
 			return errors.Wrap(err, "failed to open log file")
 		}
-		zerologger.Logger = zerologger.Logger.Output(f)
+		output = f
 	}
 
+	// Capture errors in the error sink, if configured.
+	sink, err := startErrorSink()
+	if err != nil {
+		return errors.Wrap(err, "failed to start error sink")
+	}
+	if sink != nil {
+		errorSink = sink
+		output = errorsink.NewLogWriter(output, sink, viper.GetDuration("errors.repeat-interval"))
+	}
+	zerologger.Logger = zerologger.Logger.Output(output)
+
 	// Set the local logger from the global logger.
 	log = zerologger.Logger.With().Logger().Level(util.LogLevel(""))
 
 	return nil
 }
+
+// startErrorSink starts the error sink, if configured.
+func startErrorSink() (errorsink.Service, error) {
+	if viper.GetString("errors.sentry.dsn") == "" {
+		return nil, nil
+	}
+
+	return sentryerrorsink.New(context.Background(),
+		sentryerrorsink.WithLogLevel(util.LogLevel("errors")),
+		sentryerrorsink.WithDSN(viper.GetString("errors.sentry.dsn")),
+		sentryerrorsink.WithEnvironment(viper.GetString("errors.sentry.environment")),
+		sentryerrorsink.WithRelease(ReleaseVersion),
+	)
+}
+
+// serviceLogLevelPaths are the configuration paths of the log levels for services, keyed by service name.
+var serviceLogLevelPaths = map[string]string{
+	"admin":            "admin",
+	"aggregates":       "aggregates",
+	"alerts":           "alerts",
+	"beaconcommittees": "beacon-committees",
+	"blocks":           "blocks",
+	"cache":            "chaindb.cache",
+	"chaindb":          "chaindb",
+	"chaintime":        "chaintime",
+	"dbstats":          "dbstats",
+	"effectiveness":    "effectiveness",
+	"errorsink":        "errors",
+	"eth1deposits":     "eth1deposits",
+	"eth2client":       "eth2client",
+	"filter":           "filters",
+	"finalizer":        "finalizer",
+	"fingerprint":      "fingerprint",
+	"gaps":             "gaps",
+	"gossip":           "gossip",
+	"health":           "health",
+	"hooks":            "hooks",
+	"income":           "income",
+	"leader":           "leader-election",
+	"metrics":          "metrics.prometheus",
+	"peers":            "peers",
+	"priority":         "priority",
+	"proposerduties":   "proposer-duties",
+	"pruner":           "pruner",
+	"relays":           "relays",
+	"scheduler":        "scheduler",
+	"secrets":          "secrets",
+	"spec":             "spec",
+	"summarizer":       "summarizer",
+	"synccommittees":   "sync-committees",
+	"validatorkeys":    "validatorkeys",
+	"validators":       "validators",
+	"verifier":         "verify",
+}
+
+// reloadLogLevels sets the log levels of running services from the current configuration.
+func reloadLogLevels() {
+	for service, path := range serviceLogLevelPaths {
+		level := util.LogLevel(path)
+		if util.SetServiceLogLevel(service, level) {
+			log.Trace().Str("service", service).Str("level", level.String()).Msg("Set log level")
+		}
+	}
+}
+
+// reloadNetworkLogLevels sets the log levels of the running services of a named network
+// from the network's current configuration.
+func reloadNetworkLogLevels(network *network) {
+	if network.name == "" {
+		// Services for an unnamed network are covered by reloadLogLevels().
+		return
+	}
+	for service, path := range serviceLogLevelPaths {
+		level := network.logLevel(path)
+		name := util.NetworkServiceName(network.name, service)
+		if util.SetServiceLogLevel(name, level) {
+			log.Trace().Str("service", name).Str("level", level.String()).Msg("Set log level")
+		}
+	}
+}
@@ -16,44 +16,72 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
-
-	// #nosec G108
-	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/chaind/handlers"
+	"github.com/wealdtech/chaind/services/admin"
+	standardadmin "github.com/wealdtech/chaind/services/admin/standard"
+	standardaggregates "github.com/wealdtech/chaind/services/aggregates/standard"
+	standardalerts "github.com/wealdtech/chaind/services/alerts/standard"
 	standardbeaconcommittees "github.com/wealdtech/chaind/services/beaconcommittees/standard"
 	"github.com/wealdtech/chaind/services/blocks"
 	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/cache"
+	lrucache "github.com/wealdtech/chaind/services/cache/lru"
+	rediscache "github.com/wealdtech/chaind/services/cache/redis"
 	"github.com/wealdtech/chaind/services/chaindb"
+	auditchaindb "github.com/wealdtech/chaind/services/chaindb/audit"
+	dryrunchaindb "github.com/wealdtech/chaind/services/chaindb/dryrun"
 	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
 	"github.com/wealdtech/chaind/services/chaintime"
 	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	standarddbstats "github.com/wealdtech/chaind/services/dbstats/standard"
+	standardeffectiveness "github.com/wealdtech/chaind/services/effectiveness/standard"
 	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
+	genesisstateeth2client "github.com/wealdtech/chaind/services/eth2client/genesisstate"
+	"github.com/wealdtech/chaind/services/filter"
+	celfilter "github.com/wealdtech/chaind/services/filter/cel"
 	standardfinalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	standardfingerprint "github.com/wealdtech/chaind/services/fingerprint/standard"
+	standardgaps "github.com/wealdtech/chaind/services/gaps/standard"
+	standardgossip "github.com/wealdtech/chaind/services/gossip/standard"
+	"github.com/wealdtech/chaind/services/health"
+	standardhealth "github.com/wealdtech/chaind/services/health/standard"
+	"github.com/wealdtech/chaind/services/hooks"
+	standardhooks "github.com/wealdtech/chaind/services/hooks/standard"
+	standardincome "github.com/wealdtech/chaind/services/income/standard"
 	"github.com/wealdtech/chaind/services/metrics"
 	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
 	prometheusmetrics "github.com/wealdtech/chaind/services/metrics/prometheus"
+	statsdmetrics "github.com/wealdtech/chaind/services/metrics/statsd"
+	standardpeers "github.com/wealdtech/chaind/services/peers/standard"
+	"github.com/wealdtech/chaind/services/priority"
+	standardpriority "github.com/wealdtech/chaind/services/priority/standard"
 	standardproposerduties "github.com/wealdtech/chaind/services/proposerduties/standard"
+	standardpruner "github.com/wealdtech/chaind/services/pruner/standard"
+	standardrelays "github.com/wealdtech/chaind/services/relays/standard"
 	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
 	standardspec "github.com/wealdtech/chaind/services/spec/standard"
 	"github.com/wealdtech/chaind/services/summarizer"
 	standardsummarizer "github.com/wealdtech/chaind/services/summarizer/standard"
 	standardsynccommittees "github.com/wealdtech/chaind/services/synccommittees/standard"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+	standardvalidatorkeys "github.com/wealdtech/chaind/services/validatorkeys/standard"
 	standardvalidators "github.com/wealdtech/chaind/services/validators/standard"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
@@ -62,6 +90,9 @@ import (
 // ReleaseVersion is the release version for the code.
 var ReleaseVersion = "0.6.15"
 
+// adminService is the admin service; nil if the admin API is not running.
+var adminService admin.Service
+
 func main() {
 	os.Exit(main2())
 }
@@ -79,6 +110,20 @@ func main2() int {
 		log.Error().Err(err).Msg("Failed to initialise logging")
 		return 1
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			if errorSink != nil {
+				errorSink.CapturePanic(r)
+				errorSink.Flush(2 * time.Second)
+			}
+			panic(r)
+		}
+	}()
+
+	if err := startSecrets(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to start secrets service")
+		return 1
+	}
 
 	// runCommands will not return if a command is run.
 	exit, err := runCommands(ctx)
@@ -95,8 +140,14 @@ func main2() int {
 	logModules()
 	log.Info().Str("version", ReleaseVersion).Msg("Starting chaind")
 
-	if err := initProfiling(); err != nil {
-		log.Error().Err(err).Msg("Failed to initialise profiling")
+	if err := initDebug(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to initialise debug server")
+		return 1
+	}
+
+	stopTracing, err := initTracing(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialise tracing")
 		return 1
 	}
 
@@ -115,26 +166,95 @@ func main2() int {
 	setRelease(ctx, ReleaseVersion)
 	setReady(ctx, false)
 
-	if err := startServices(ctx, monitor); err != nil {
-		log.Error().Err(err).Msg("Failed to initialise services")
+	// The database has its own context, so that it remains available for in-flight transactions
+	// after the main context has been cancelled on shutdown.
+	dbCtx, dbCancel := context.WithCancel(context.Background())
+	defer dbCancel()
+
+	networks, err := configuredNetworks()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain networks")
+		return 1
+	}
+
+	log.Trace().Msg("Starting admin service")
+	if err := startAdmin(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to start admin service")
 		return 1
 	}
+
+	// With leader election enabled, standby instances wait here until they become the leader.
+	leaderSvc, err := acquireLeadership(ctx, monitor)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to acquire leadership")
+		return 1
+	}
+	var leadershipLost <-chan struct{}
+	if leaderSvc != nil {
+		leadershipLost = leaderSvc.Lost()
+	}
+
+	// Networks are started in turn, as services share module-wide state such as metrics.
+	running := make([]*runningServices, 0, len(networks))
+	for _, network := range networks {
+		services, err := startServices(ctx, dbCtx, network, monitor)
+		if err != nil {
+			log := network.logger()
+			log.Error().Err(err).Msg("Failed to initialise services")
+			return 1
+		}
+		running = append(running, services)
+	}
 	setReady(ctx, true)
+	notifySystemd("READY=1")
+	startWatchdog(ctx, running)
 
 	log.Info().Msg("All services operational")
 
 	// Wait for signal.
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP)
+	exitCode := 0
+loop:
 	for {
-		sig := <-sigCh
-		if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
-			break
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				notifySystemd("RELOADING=1")
+				reload(running)
+				notifySystemd("READY=1")
+				continue
+			}
+			if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
+				break loop
+			}
+		case <-leadershipLost:
+			// Another instance may now become the leader, so stop writing.  Exiting with an
+			// error allows a supervisor to restart this instance as a standby.
+			log.Error().Msg("Leadership lost; stopping")
+			exitCode = 1
+			break loop
 		}
 	}
 
 	log.Info().Msg("Stopping chaind")
-	return 0
+	notifySystemd("STOPPING=1")
+	// Cancelling the main context stops new work from being scheduled.
+	cancel()
+	shutdown(running, viper.GetDuration("shutdown-timeout"))
+	if leaderSvc != nil {
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := leaderSvc.Release(releaseCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to release leadership")
+		}
+		releaseCancel()
+	}
+	stopTracing()
+	if errorSink != nil {
+		errorSink.Flush(2 * time.Second)
+	}
+	log.Info().Msg("Stopped chaind")
+	return exitCode
 }
 
 // fetchConfig fetches configuration from various sources.
@@ -143,29 +263,160 @@ func fetchConfig() error {
 	pflag.Bool("version", false, "show version and exit")
 	pflag.String("log-level", "info", "minimum level of messsages to log")
 	pflag.String("log-file", "", "redirect log output to a file")
-	pflag.String("profile-address", "", "Address on which to run Go profile server")
+	pflag.String("profile-address", "", "Address on which to run Go profile server (deprecated; use debug.listen-address)")
+	pflag.String("debug.listen-address", "", "Address on which to serve pprof profiles and runtime statistics")
+	pflag.Int("debug.mutex-profile-fraction", 1, "Fraction of mutex contention events reported in the mutex profile")
+	pflag.Int("debug.block-profile-rate", 0, "Nanoseconds of blocking between samples in the block profile; 0 to disable")
 	pflag.String("tracing-address", "", "Address to which to send tracing data")
+	pflag.Float64("tracing-sample-ratio", 1, "Proportion of traces to send")
+	pflag.String("errors.sentry.dsn", "", "Sentry DSN to which to report errors")
+	pflag.String("errors.sentry.environment", "", "Environment reported to Sentry with errors")
+	pflag.Duration("errors.repeat-interval", 10*time.Minute, "Minimum time between reports of the same repeated error")
 	pflag.String("eth2client.address", "", "Address for beacon node")
+	pflag.String("eth2client.proxy", "", "URL of an HTTP or SOCKS proxy through which to connect to the beacon node")
 	pflag.Duration("eth2client.timeout", 2*time.Minute, "Timeout for beacon node requests")
+	pflag.Bool("eth2client.ssz", true, "Fetch blocks and states from the beacon node in SSZ where supported")
+	pflag.Int("eth2client.index-chunk-size", -1, "Maximum number of validator indices in a single request to the beacon node (-1 to select based on the beacon node)")
+	pflag.Int("eth2client.pubkey-chunk-size", -1, "Maximum number of validator public keys in a single request to the beacon node (-1 to select based on the beacon node)")
+	pflag.String("eth2client.bearer-token", "", "Bearer token sent in the Authorization header of requests to the beacon node")
+	pflag.String("eth2client.jwt-secret", "", "File holding the hex-encoded secret of JSON web tokens sent in the Authorization header of requests to the beacon node")
+	pflag.String("eth2client.genesis-state", "", "File holding the SSZ-encoded genesis state, used for genesis information in place of the beacon node")
+	pflag.String("eth2client.tls.client-cert", "", "Client certificate for mutual TLS to the beacon node")
+	pflag.String("eth2client.tls.client-key", "", "Client key for mutual TLS to the beacon node")
+	pflag.String("eth2client.tls.ca-cert", "", "Certificate authority certificate for TLS to the beacon node, if not signed by the system roots")
+	pflag.Bool("dry-run", false, "Fetch and transform data, but log writes rather than writing to the database")
+	pflag.Duration("shutdown-timeout", 30*time.Second, "Time to wait for in-flight work to complete when shutting down")
+	pflag.Duration("systemd.stall-timeout", 15*time.Minute, "Time a module can be behind the chain without progress before the systemd watchdog is stopped")
+	pflag.StringSlice("alerts.webhooks", nil, "URLs to which to post alerts")
+	pflag.String("alerts.kafka.rest-proxy", "", "URL of the Kafka REST proxy through which to produce alerts")
+	pflag.String("alerts.kafka.topic", "", "Kafka topic to which to produce alerts")
+	pflag.Duration("alerts.timeout", 10*time.Second, "Timeout for sending each alert")
+	pflag.Duration("alerts.max-age", 10*time.Minute, "Maximum age of a block for its slashings to be alerted, or 0 for no limit")
+	pflag.Duration("hooks.timeout", 10*time.Second, "Timeout for each invocation of a hook")
+	pflag.Int("hooks.queue-size", 1024, "Number of events that can wait for their hooks to be invoked")
+	pflag.Int("priority.capacity", 4, "Number of beacon node and database operations shared between live and backfill work")
+	pflag.Int("priority.reserved", 1, "Number of beacon node and database operations reserved for live work")
 	pflag.Bool("blocks.enable", true, "Enable fetching of block-related information")
 	pflag.Int32("blocks.start-slot", -1, "Slot from which to start fetching blocks")
 	pflag.Bool("blocks.refetch", false, "Refetch all blocks even if they are already in the database")
+	pflag.Int("blocks.write-queue-size", 64, "Number of fetched blocks that can be queued for writing to the database")
+	pflag.Int("blocks.writers", 1, "Number of concurrent database writers for blocks")
+	pflag.Int("blocks.commit-batch-size", 1, "Number of slots written in each transaction when catching up")
+	pflag.Duration("blocks.write-latency-target", time.Second, "Time to write each block above which fetching from the beacon node is slowed")
+	pflag.Duration("blocks.max-fetch-delay", 5*time.Second, "Maximum delay between block fetches when the database is behind (0 to disable)")
+	pflag.Bool("blocks.bidirectional", false, "Index forward from the head of the chain whilst syncing backward over missed slots")
+	pflag.Bool("blocks.arrivals.enable", true, "Record the time at which each block is first seen")
+	pflag.Bool("blocks.quarantine.enable", false, "Quarantine blocks that cannot be written rather than stopping until they can")
+	pflag.Bool("blocks.verify-signatures.enable", false, "Verify the signatures of blocks and their attestations before storing them")
+	pflag.Float64("blocks.verify-signatures.attestation-sample-ratio", 1, "Proportion of attestations in each block whose signatures are verified")
+	pflag.Bool("blocks.backfill.enable", false, "Prepare the database for bulk writes whilst catching up at startup")
+	pflag.String("blocks.backfill.foreign-keys", "immediate", "How to check foreign keys whilst backfilling (immediate, deferred or dropped)")
 	pflag.Bool("finalizer.enable", true, "Enable additional information on receipt of finality checkpoint")
 	pflag.Bool("summarizer.enable", true, "Enable summary information")
 	pflag.Bool("summarizer.epochs.enable", true, "Enable summary information for epochs")
 	pflag.Bool("summarizer.blocks.enable", true, "Enable summary information for blocks")
 	pflag.Bool("summarizer.validators.enable", false, "Enable summary information for validators (warning: creates a lot of data)")
+	pflag.Bool("summarizer.validators.epochs.enable", true, "Retain summary information for validators for each epoch")
+	pflag.Duration("summarizer.validators.epochs.retention", 0, "Period for which to retain summary information for validators for each epoch (0 to retain indefinitely)")
+	pflag.Bool("summarizer.validators.days.enable", false, "Enable summary information for validators for each day")
+	pflag.Duration("summarizer.validators.days.retention", 0, "Period for which to retain summary information for validators for each day (0 to retain indefinitely)")
+	pflag.Uint64("summarizer.validators.days.max-per-run", 5, "Maximum number of days of summary information for validators to generate each epoch when catching up")
+	pflag.Bool("aggregates.enable", false, "Enable incremental update of aggregates of the summary tables")
+	pflag.Duration("metrics.statsd.interval", 10*time.Second, "Interval between sending metrics to statsd")
+	pflag.String("metrics.statsd.format", "statsd", "Format of metrics sent to statsd (statsd or datadog)")
+	pflag.String("metrics.pushgateway.job", "chaind", "Job under which commands push metrics to the pushgateway")
+	pflag.Bool("dbstats.enable", false, "Enable periodic collection of database table statistics as metrics")
+	pflag.Duration("dbstats.interval", 15*time.Minute, "Interval between collections of database table statistics")
+	pflag.Uint64("window", 0, "Number of recent epochs of chain data to keep, pruning older data (0 to keep all data)")
+	pflag.Bool("pruner.enable", false, "Enable periodic pruning of tables according to their retention periods")
+	pflag.Duration("pruner.interval", time.Hour, "Interval between prunes of tables")
+	pflag.Bool("gaps.enable", false, "Enable periodic scanning for slots with neither a block nor a missed slot marker")
+	pflag.Duration("gaps.interval", time.Hour, "Interval between scans for gaps")
+	pflag.Uint64("gaps.start-slot", 0, "Slot from which to scan for gaps")
+	pflag.Bool("gaps.refetch", false, "Refetch slots found in gaps")
+	pflag.Int("gaps.refetch-limit", 256, "Maximum number of slots refetched after each scan")
+	pflag.Bool("gossip.enable", false, "Enable capture of unaggregated attestations as they are seen on the network")
+	pflag.Int("gossip.batch-size", 4096, "Number of attestations written in each transaction")
+	pflag.Duration("gossip.flush-interval", 2*time.Second, "Longest time for which captured attestations wait before being written")
+	pflag.Bool("peers.enable", false, "Enable periodic sampling of the clients and versions of the beacon node's peers")
+	pflag.Duration("peers.interval", 5*time.Minute, "Interval between samples of the beacon node's peers")
+	pflag.Bool("fingerprint.enable", false, "Enable inference of the consensus clients of block proposers")
+	pflag.Duration("fingerprint.interval", 5*time.Minute, "Interval between checks for new blocks to fingerprint")
+	pflag.Bool("relays.enable", false, "Enable attribution of execution payloads to the MEV relays that delivered them")
+	pflag.StringSlice("relays.addresses", nil, "Addresses of MEV relays to query for delivered payloads")
+	pflag.Duration("relays.interval", 5*time.Minute, "Interval between checks for new blocks to attribute")
+	pflag.Duration("relays.timeout", 30*time.Second, "Timeout for requests to MEV relays")
+	pflag.Uint64("relays.lag", 64, "Number of slots behind the latest block at which blocks are attributed")
+	pflag.Bool("relays.registrations.enable", false, "Enable collection of the registrations of validators with MEV relays")
+	pflag.StringSlice("relays.registrations.labels", nil, "Labels of the validators whose registrations are collected; all validators if not set")
+	pflag.Duration("relays.registrations.interval", time.Hour, "Interval between collections of the registrations of validators")
+	pflag.Bool("effectiveness.enable", false, "Enable calculation of validator attestation effectiveness from validator summaries")
+	pflag.Duration("effectiveness.interval", 5*time.Minute, "Interval between checks for new validator summaries")
+	pflag.Bool("income.enable", false, "Enable calculation of validator income from validator balances")
+	pflag.Duration("income.interval", 5*time.Minute, "Interval between checks for new validator balances")
 	pflag.Bool("validators.enable", true, "Enable fetching of validator-related information")
 	pflag.Bool("validators.balances.enable", false, "Enable fetching of validator balances (warning: creates a lot of data)")
 	pflag.Bool("beacon-committees.enable", true, "Enable fetching of beacon committee-related information")
+	pflag.Uint64("beacon-committees.commit-batch-size", 1, "Number of epochs written in each transaction when catching up")
 	pflag.Bool("proposer-duties.enable", true, "Enable fetching of proposer duty-related information")
 	pflag.Bool("sync-committees.enable", true, "Enable fetching of sync committee-related information")
 	pflag.Int32("sync-committees.start-period", -1, "Period from which to start fetching sync committees")
 	pflag.Bool("eth1deposits.enable", false, "Enable fetching of Ethereum 1 deposit information")
 	pflag.String("eth1deposits.start-block", "", "Ethereum 1 block from which to start fetching deposits")
+	pflag.Uint64("eth1deposits.confirmations", 12, "Number of Ethereum 1 blocks behind the head from which deposits are fetched")
+	pflag.Bool("eth1deposits.provisional", false, "Fetch deposits up to the head of the Ethereum 1 chain, reconciling them once they have the required confirmations")
 	pflag.String("eth1client.address", "", "Address for Ethereum 1 node")
+	pflag.String("eth1client.proxy", "", "URL of an HTTP or SOCKS proxy through which to connect to the Ethereum 1 node")
+	pflag.String("eth1client.bearer-token", "", "Bearer token sent in the Authorization header of requests to the Ethereum 1 node")
+	pflag.String("eth1client.jwt-secret", "", "File holding the hex-encoded secret of JSON web tokens sent in the Authorization header of requests to the Ethereum 1 node")
+	pflag.String("eth1client.tls.client-cert", "", "Client certificate for mutual TLS to the Ethereum 1 node")
+	pflag.String("eth1client.tls.client-key", "", "Client key for mutual TLS to the Ethereum 1 node")
+	pflag.String("eth1client.tls.ca-cert", "", "Certificate authority certificate for TLS to the Ethereum 1 node, if not signed by the system roots")
 	pflag.String("chaindb.url", "", "URL for database")
+	pflag.String("chaindb.tls.client-cert", "", "Client certificate for mutual TLS to the database")
+	pflag.String("chaindb.tls.client-key", "", "Client key for mutual TLS to the database")
+	pflag.String("chaindb.tls.ca-cert", "", "Certificate authority certificate for TLS to the database, if not signed by the system roots")
 	pflag.Uint("chaindb.max-connections", 16, "maximum number of concurrent database connections")
+	pflag.String("chaindb.storage-profile", "standard", "profile of table storage parameters (standard or none)")
+	pflag.String("chaindb.schema", "", "schema in which to store data, if not the default")
+	pflag.String("chaindb.isolation-level", "read committed", "isolation level of transactions that write chain data (read committed, repeatable read or serializable)")
+	pflag.Duration("chaindb.lock-timeout", 0, "maximum time for transactions that write chain data to wait for a lock (0 for no limit)")
+	pflag.String("chaindb.upgrade.isolation-level", "read committed", "isolation level of schema upgrade transactions (read committed, repeatable read or serializable)")
+	pflag.Duration("chaindb.upgrade.lock-timeout", 0, "maximum time for schema upgrade transactions to wait for a lock (0 for no limit)")
+	pflag.Bool("leader-election.enable", false, "Elect a leader from instances sharing the database, so that only one writes at a time")
+	pflag.Int64("leader-election.lock-id", 0, "ID of the advisory lock held by the leader; if 0 it is derived from the database schema")
+	pflag.Duration("leader-election.retry-interval", 5*time.Second, "Interval between attempts by a standby instance to become the leader")
+	pflag.Duration("leader-election.check-interval", 5*time.Second, "Interval between checks by the leader that it still holds leadership")
+	pflag.Bool("audit.enable", false, "Record each mutation made to the database in an audit log")
+	pflag.String("audit.file", "", "File to which the audit log is appended; if not set the audit log is written to the t_audit_log table")
+	pflag.String("chaindb.cache.type", "none", "type of cache for frequently-read values (none, lru or redis)")
+	pflag.Int("chaindb.cache.size", 16384, "maximum number of entries in the lru cache")
+	pflag.String("chaindb.cache.redis.address", "", "address of the redis server for the redis cache")
+	pflag.Int("chaindb.cache.redis.db", 0, "database number on the redis server for the redis cache")
+	pflag.String("chaindb.cache.redis.key-prefix", "", "prefix for keys in the redis cache (defaults to chaind: followed by the network name, if any)")
+	pflag.String("health.listen-address", "", "Address on which to serve health checks")
+	pflag.Uint64("health.max-lag", 2, "Number of epochs a module can be behind the chain before it is considered unhealthy")
+	pflag.Uint64("health.ready-max-lag", 2, "Number of epochs a module can be behind the chain before chaind is considered not ready")
+	pflag.String("admin.listen-address", "", "Address on which to serve the admin API")
+	pflag.String("admin.token", "", "Bearer token with the admin role for the admin API")
+	pflag.Duration("secrets.refresh-interval", 5*time.Minute, "Interval between refreshes of secrets, to pick up rotated values")
+	pflag.String("secrets.envfile.path", "", "Path to an environment file of KEY=VALUE secrets")
+	pflag.String("secrets.vault.address", "", "Address of the HashiCorp Vault server holding secrets")
+	pflag.String("secrets.vault.token", "", "Token to access the Vault server; defaults to VAULT_TOKEN")
+	pflag.String("secrets.vault.token-file", "", "File holding the token to access the Vault server, re-read on each access")
+	pflag.String("secrets.vault.namespace", "", "Namespace of the Vault server holding secrets")
+	pflag.Duration("secrets.vault.timeout", 30*time.Second, "Timeout for requests to the Vault server")
+	pflag.Bool("secrets.aws.enable", false, "Enable fetching of secrets from AWS Secrets Manager")
+	pflag.String("secrets.aws.region", "", "AWS region of the secrets; defaults to the region in the standard AWS configuration")
+	pflag.Int64("verify.start-slot", -1, "Slot from which to verify data; defaults to 0")
+	pflag.Int64("verify.end-slot", -1, "Slot up to which to verify data; defaults to the latest canonical block")
+	pflag.Int("verify.slots", 32, "Number of slots to sample when verifying data")
+	pflag.Int("verify.validators", 64, "Number of validators to sample when verifying data")
+	pflag.Bool("verify.repair", false, "Repair data that does not match the beacon node when verifying")
+	pflag.Int64("resummarize.start-epoch", -1, "Epoch from which to regenerate summaries")
+	pflag.Int64("resummarize.end-epoch", -1, "Epoch up to which to regenerate summaries")
+	pflag.String("resummarize.start-day", "", "Day (YYYY-MM-DD) from which to regenerate summaries")
+	pflag.String("resummarize.end-day", "", "Day (YYYY-MM-DD) up to which to regenerate summaries")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		return errors.Wrap(err, "failed to bind pflags to viper")
@@ -199,28 +450,15 @@ func fetchConfig() error {
 	return nil
 }
 
-// initProfiling initialises the profiling server.
-func initProfiling() error {
-	profileAddress := viper.GetString("profile-address")
-	if profileAddress != "" {
-		go func() {
-			log.Info().Str("profile_address", profileAddress).Msg("Starting profile server")
-			server := &http.Server{
-				Addr:              profileAddress,
-				ReadHeaderTimeout: 5 * time.Second,
-			}
-			runtime.SetMutexProfileFraction(1)
-			if err := server.ListenAndServe(); err != nil {
-				log.Warn().Str("profile_address", profileAddress).Err(err).Msg("Failed to run profile server")
-			}
-		}()
+// startMonitor starts the metrics service.
+func startMonitor(ctx context.Context) (metrics.Service, error) {
+	if viper.Get("metrics.prometheus.listen-address") != nil && viper.Get("metrics.statsd.address") != nil {
+		return nil, errors.New("only one of metrics.prometheus and metrics.statsd can be configured")
 	}
-	return nil
-}
 
-func startMonitor(ctx context.Context) (metrics.Service, error) {
 	var monitor metrics.Service
-	if viper.Get("metrics.prometheus.listen-address") != nil {
+	switch {
+	case viper.Get("metrics.prometheus.listen-address") != nil:
 		var err error
 		monitor, err = prometheusmetrics.New(ctx,
 			prometheusmetrics.WithLogLevel(util.LogLevel("metrics.prometheus")),
@@ -230,64 +468,364 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 			return nil, errors.Wrap(err, "failed to start prometheus metrics service")
 		}
 		log.Info().Str("listen_address", viper.GetString("metrics.prometheus.listen-address")).Msg("Started prometheus metrics service")
-	} else {
+	case viper.Get("metrics.statsd.address") != nil:
+		var err error
+		monitor, err = statsdmetrics.New(ctx,
+			statsdmetrics.WithLogLevel(util.LogLevel("metrics.statsd")),
+			statsdmetrics.WithAddress(viper.GetString("metrics.statsd.address")),
+			statsdmetrics.WithInterval(viper.GetDuration("metrics.statsd.interval")),
+			statsdmetrics.WithPrefix(viper.GetString("metrics.statsd.prefix")),
+			statsdmetrics.WithFormat(viper.GetString("metrics.statsd.format")),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start statsd metrics service")
+		}
+		log.Info().Str("address", viper.GetString("metrics.statsd.address")).Msg("Started statsd metrics service")
+	default:
 		log.Debug().Msg("No metrics service supplied; monitor not starting")
 		monitor = &nullmetrics.Service{}
 	}
 	return monitor, nil
 }
 
-func startDatabase(ctx context.Context) (chaindb.Service, error) {
+func startCache(ctx context.Context, network *network) (cache.Service, error) {
+	config := network.config
+	switch config.GetString("chaindb.cache.type") {
+	case "", "none":
+		return nil, nil
+	case "lru":
+		log.Trace().Msg("Starting lru cache service")
+		return lrucache.New(ctx,
+			lrucache.WithLogLevel(network.logLevel("chaindb.cache")),
+			lrucache.WithNetwork(network.name),
+			lrucache.WithSize(config.GetInt("chaindb.cache.size")),
+		)
+	case "redis":
+		log.Trace().Msg("Starting redis cache service")
+		password, err := resolveSecret(ctx, config.GetString("chaindb.cache.redis.password"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve redis password")
+		}
+		return rediscache.New(ctx,
+			rediscache.WithLogLevel(network.logLevel("chaindb.cache")),
+			rediscache.WithNetwork(network.name),
+			rediscache.WithAddress(config.GetString("chaindb.cache.redis.address")),
+			rediscache.WithPassword(password),
+			rediscache.WithDB(config.GetInt("chaindb.cache.redis.db")),
+			rediscache.WithKeyPrefix(redisKeyPrefix(network)),
+		)
+	default:
+		return nil, fmt.Errorf("unknown cache type %q", config.GetString("chaindb.cache.type"))
+	}
+}
+
+// redisKeyPrefix returns the prefix for keys in the redis cache.  Unless configured, each
+// named network has its own prefix so that networks sharing a redis database do not read
+// each other's values.
+func redisKeyPrefix(network *network) string {
+	if keyPrefix := network.config.GetString("chaindb.cache.redis.key-prefix"); keyPrefix != "" {
+		return keyPrefix
+	}
+	if network.name == "" {
+		return "chaind:"
+	}
+	return fmt.Sprintf("chaind:%s:", network.name)
+}
+
+// startCacheInvalidation invalidates the cache at the start of each epoch.
+func startCacheInvalidation(
+	ctx context.Context,
+	network *network,
+	cacheSvc cache.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		return chainTime.StartOfEpoch(chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		if err := cacheSvc.Invalidate(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to invalidate cache")
+		}
+	}
+	if err := scheduler.SchedulePeriodicJob(ctx, "cache", "invalidate cache",
+		runtimeFunc,
+		nil,
+		jobFunc,
+		nil,
+	); err != nil {
+		return errors.Wrap(err, "failed to set up periodic invalidation of cache")
+	}
+
+	return nil
+}
+
+func startDatabase(ctx context.Context, network *network, cacheSvc cache.Service, monitor metrics.Service) (chaindb.Service, error) {
+	config := network.config
+	filterSvc, err := startFilter(ctx, network, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start filter service")
+	}
+
+	clientCert, clientKey, caCert, err := readTLSFiles(config, "chaindb.tls")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid database TLS configuration")
+	}
+
 	log.Trace().Msg("Starting chain database service")
 	chainDB, err := postgresqlchaindb.New(ctx,
-		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
-		postgresqlchaindb.WithConnectionURL(viper.GetString("chaindb.url")),
-		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
+		postgresqlchaindb.WithCache(cacheSvc),
+		postgresqlchaindb.WithFilter(filterSvc),
+		postgresqlchaindb.WithSecrets(secretsService),
+		postgresqlchaindb.WithLogLevel(network.logLevel("chaindb")),
+		postgresqlchaindb.WithNetwork(network.name),
+		postgresqlchaindb.WithMonitor(monitor),
+		postgresqlchaindb.WithConnectionURL(config.GetString("chaindb.url")),
+		postgresqlchaindb.WithClientCert(clientCert),
+		postgresqlchaindb.WithClientKey(clientKey),
+		postgresqlchaindb.WithCACert(caCert),
+		postgresqlchaindb.WithMaxConnections(config.GetUint("chaindb.max-connections")),
+		postgresqlchaindb.WithStorageProfile(config.GetString("chaindb.storage-profile")),
+		postgresqlchaindb.WithSchema(config.GetString("chaindb.schema")),
+		postgresqlchaindb.WithIsolationLevel(config.GetString("chaindb.isolation-level")),
+		postgresqlchaindb.WithLockTimeout(config.GetDuration("chaindb.lock-timeout")),
+		postgresqlchaindb.WithUpgradeIsolationLevel(config.GetString("chaindb.upgrade.isolation-level")),
+		postgresqlchaindb.WithUpgradeLockTimeout(config.GetDuration("chaindb.upgrade.lock-timeout")),
+		postgresqlchaindb.WithBackfillForeignKeys(config.GetString("blocks.backfill.foreign-keys")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start chain database service")
 	}
+
+	if config.GetBool("dry-run") {
+		log.Warn().Msg("Dry run; data will not be written to the database, and database schema upgrades will not be carried out")
+		dryRunChainDB, err := dryrunchaindb.New(ctx,
+			dryrunchaindb.WithLogLevel(network.logLevel("chaindb")),
+			dryrunchaindb.WithNetwork(network.name),
+			dryrunchaindb.WithChainDB(chainDB),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start dry run chain database service")
+		}
+		if config.GetBool("audit.enable") {
+			log.Warn().Msg("Dry run; audit log is not recorded as nothing is written")
+		}
+		return dryRunChainDB, nil
+	}
+
+	if config.GetBool("audit.enable") {
+		auditFile := config.GetString("audit.file")
+		if auditFile != "" {
+			auditFile = resolvePath(auditFile)
+		}
+		auditChainDB, err := auditchaindb.New(ctx,
+			auditchaindb.WithLogLevel(network.logLevel("chaindb")),
+			auditchaindb.WithNetwork(network.name),
+			auditchaindb.WithChainDB(chainDB),
+			auditchaindb.WithFile(auditFile),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to start audit chain database service")
+		}
+		return auditChainDB, nil
+	}
+
 	return chainDB, err
 }
 
-func startServices(ctx context.Context, monitor metrics.Service) error {
-	log.Trace().Msg("Checking for schema upgrades")
-	chainDB, err := startDatabase(ctx)
+// setTenants sets the database roles that can only read the data of their own validators.
+// Tenants that are no longer configured lose their access.
+func setTenants(ctx context.Context, config *viper.Viper, chainDB chaindb.Service) error {
+	setter, isSetter := chainDB.(chaindb.TenantsSetter)
+	if !isSetter {
+		return nil
+	}
+
+	roles := make([]string, 0)
+	for role := range config.GetStringMap("chaindb.tenants") {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	tenants := make([]*chaindb.Tenant, 0, len(roles))
+	for _, role := range roles {
+		tenants = append(tenants, &chaindb.Tenant{
+			Role:   role,
+			Labels: config.GetStringSlice(fmt.Sprintf("chaindb.tenants.%s.labels", role)),
+			Tables: config.GetStringSlice(fmt.Sprintf("chaindb.tenants.%s.tables", role)),
+		})
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
 	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := setter.SetTenants(ctx, tenants); err != nil {
+		cancel()
 		return err
 	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
 
-	if _, isUpgrader := chainDB.(*postgresqlchaindb.Service); isUpgrader {
-		requiresRefetch, err := chainDB.(*postgresqlchaindb.Service).Upgrade(ctx)
-		if err != nil {
-			return errors.Wrap(err, "failed to upgrade chain database")
-		}
-		if requiresRefetch {
-			// The upgrade requires us to refetch blocks, so set up the options accordingly.
-			// These will be picked up by the blocks service.
-			viper.Set("blocks.start-slot", 0)
-			viper.Set("blocks.refetch", true)
+	return nil
+}
+
+// startFilter starts the filter service, if any filter expressions are configured.
+func startFilter(ctx context.Context, network *network, monitor metrics.Service) (filter.Service, error) {
+	config := network.config
+	if config.GetString("filters.attestations") == "" &&
+		config.GetString("filters.validator-balances") == "" &&
+		config.GetString("filters.validator-epoch-summaries") == "" {
+		return nil, nil
+	}
+
+	validators := make([]phase0.ValidatorIndex, 0)
+	for _, validator := range config.GetIntSlice("filters.validators") {
+		if validator < 0 {
+			return nil, fmt.Errorf("invalid filter validator %d", validator)
 		}
+		validators = append(validators, phase0.ValidatorIndex(validator))
+	}
+
+	log.Trace().Msg("Starting filter service")
+	return celfilter.New(ctx,
+		celfilter.WithLogLevel(network.logLevel("filters")),
+		celfilter.WithNetwork(network.name),
+		celfilter.WithMonitor(monitor),
+		celfilter.WithValidators(validators),
+		celfilter.WithAttestationsExpression(config.GetString("filters.attestations")),
+		celfilter.WithValidatorBalancesExpression(config.GetString("filters.validator-balances")),
+		celfilter.WithValidatorEpochSummariesExpression(config.GetString("filters.validator-epoch-summaries")),
+	)
+}
+
+// runningServices are the services that are acted upon after startup, when reloading
+// configuration or shutting down.
+type runningServices struct {
+	// network is the network for which the services are running.
+	network *network
+	// chainDB is the chain database, which may have active transactions.
+	chainDB chaindb.Service
+	// activitySem is the activity semaphore shared by the blocks and finalizer modules.
+	activitySem *semaphore.Weighted
+	// modules are the modules that can be started and stopped on configuration reload.
+	modules *moduleManager
+	// health is the health service; nil if not running.
+	health health.Service
+	// chainTime is the chain time service.
+	chainTime chaintime.Service
+}
+
+func startServices(ctx context.Context, dbCtx context.Context, network *network, monitor metrics.Service) (*runningServices, error) {
+	config := network.config
+	if network.name != "" {
+		log.Info().Str("network", network.name).Msg("Starting services for network")
+	}
+
+	if err := checkWindow(config); err != nil {
+		return nil, errors.Wrap(err, "invalid rolling window")
+	}
+
+	cacheSvc, err := startCache(dbCtx, network)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start cache service")
+	}
+
+	log.Trace().Msg("Starting database service")
+	chainDB, err := startDatabase(dbCtx, network, cacheSvc, monitor)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Trace().Msg("Starting Ethereum 2 client service")
-	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
+	eth2Client, err := fetchClient(ctx, network, config.GetString("eth2client.address"), monitor)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("eth2client.address")))
 	}
 	if err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 2 client service")
+		return nil, errors.Wrap(err, "failed to start Ethereum 2 client service")
 	}
 
 	log.Trace().Msg("Starting chain time service")
+	genesisProvider, err := fetchGenesisProvider(ctx, network, eth2Client)
+	if err != nil {
+		return nil, err
+	}
 	chainTime, err := standardchaintime.New(ctx,
-		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
-		standardchaintime.WithGenesisTimeProvider(eth2Client.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithLogLevel(network.logLevel("chaintime")),
+		standardchaintime.WithNetwork(network.name),
+		standardchaintime.WithGenesisTimeProvider(genesisProvider),
 		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
 		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+		standardchaintime.WithStoredForkScheduleProvider(chainDB.(chaindb.ForkScheduleProvider)),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to start chain time service")
+		return nil, errors.Wrap(err, "failed to start chain time service")
+	}
+
+	if cacheSvc != nil {
+		if err := startCacheInvalidation(ctx, network, cacheSvc, chainTime, monitor); err != nil {
+			return nil, errors.Wrap(err, "failed to start cache invalidation")
+		}
+	}
+
+	// Health service is started before the schema upgrade, so that liveness can be
+	// reported whilst a long-running upgrade takes place.
+	log.Trace().Msg("Starting health service")
+	healthSvc, err := startHealth(ctx, network, eth2Client, chainDB, chainTime)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start health service")
+	}
+
+	// Hooks service is started before the schema upgrade, so that it can be notified of the upgrade.
+	log.Trace().Msg("Starting hooks service")
+	hooksSvc, err := startHooks(ctx, network, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start hooks service")
+	}
+
+	log.Trace().Msg("Checking for schema upgrades")
+	upgrader, isUpgrader := chainDB.(*postgresqlchaindb.Service)
+	if auditChainDB, isAudit := chainDB.(*auditchaindb.Service); isAudit {
+		// Upgrades are carried out on the audited database.
+		upgrader, isUpgrader = auditChainDB.Service, true
+	}
+	if isUpgrader {
+		fromVersion, _, err := upgrader.SchemaVersion(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain schema version")
+		}
+		requiresRefetch, err := upgrader.Upgrade(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to upgrade chain database")
+		}
+		if hooksSvc != nil && fromVersion < postgresqlchaindb.RequiredSchemaVersion() {
+			hooksSvc.OnSchemaUpgraded(ctx, fromVersion, postgresqlchaindb.RequiredSchemaVersion(), requiresRefetch)
+		}
+		if requiresRefetch {
+			// The upgrade requires us to refetch blocks, so set up the options accordingly.
+			// These will be picked up by the blocks service.
+			config.Set("blocks.start-slot", 0)
+			config.Set("blocks.refetch", true)
+		}
+	}
+
+	log.Trace().Msg("Setting tenants")
+	if err := setTenants(ctx, config, chainDB); err != nil {
+		return nil, errors.Wrap(err, "failed to set tenants")
+	}
+
+	if healthSvc != nil {
+		healthSvc.SetUpgraded()
 	}
 
 	// Wait for chainstart.
@@ -297,7 +835,7 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		// See if we can obtain spec before the chain starts.  Not all beacon nodes support this,
 		// so don't worry if it fails but do note it so that the service can be started later.
 		log.Trace().Msg("Starting spec service (speculative pre-chain)")
-		if err := startSpec(ctx, eth2Client, chainDB, monitor); err == nil {
+		if err := startSpec(util.WithModule(ctx, "spec"), network, eth2Client, chainDB, chainTime, monitor); err == nil {
 			specServiceStarted = true
 		}
 
@@ -330,67 +868,337 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	// chaindb so it is accessible to other services.
 	if !specServiceStarted {
 		log.Trace().Msg("Starting spec service")
-		if err := startSpec(ctx, eth2Client, chainDB, monitor); err != nil {
-			return errors.Wrap(err, "failed to start spec service")
+		if err := startSpec(util.WithModule(ctx, "spec"), network, eth2Client, chainDB, chainTime, monitor); err != nil {
+			return nil, errors.Wrap(err, "failed to start spec service")
+		}
+	}
+
+	// Modules that can be started and stopped on configuration reload.
+	modules := newModuleManager(ctx, config)
+	// withClient supplies the module with the current Ethereum 2 client.
+	withClient := func(start func(context.Context, eth2client.Service) error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			eth2Client, err := fetchClient(ctx, network, network.config.GetString("eth2client.address"), monitor)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", network.config.GetString("eth2client.address")))
+			}
+			return start(ctx, eth2Client)
 		}
 	}
 
 	// Sync committees service is needed by blocks service.
 	log.Trace().Msg("Starting sync committees service")
-	if err := startSyncCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start sync committees service")
+	if err := modules.add("sync-committees", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startSyncCommittees(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start sync committees service")
 	}
 
 	// Shared activity semaphore for blocks and finalizer, to avoid potential deadlock.
 	activitySem := semaphore.NewWeighted(1)
 
+	// Priority service shares beacon node and database capacity between live and backfill work.
+	log.Trace().Msg("Starting priority service")
+	prioritySvc, err := standardpriority.New(ctx,
+		standardpriority.WithLogLevel(network.logLevel("priority")),
+		standardpriority.WithNetwork(network.name),
+		standardpriority.WithMonitor(monitor),
+		standardpriority.WithCapacity(config.GetInt("priority.capacity")),
+		standardpriority.WithReserved(config.GetInt("priority.reserved")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start priority service")
+	}
+
+	// Validator keys are shared by the modules that map between validator public keys and indices.
+	log.Trace().Msg("Starting validator keys service")
+	validatorKeys, err := standardvalidatorkeys.New(ctx,
+		standardvalidatorkeys.WithLogLevel(network.logLevel("validatorkeys")),
+		standardvalidatorkeys.WithNetwork(network.name),
+		standardvalidatorkeys.WithMonitor(monitor),
+		standardvalidatorkeys.WithChainDB(chainDB),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start validator keys service")
+	}
+
+	log.Trace().Msg("Starting alerts service")
+	slashingHandlers := make([]handlers.SlashingHandler, 0)
+	alerts, err := startAlerts(ctx, network, chainTime, validatorKeys, monitor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start alerts service")
+	}
+	if alerts != nil {
+		slashingHandlers = append(slashingHandlers, alerts)
+	}
+
+	blockHandlers := make([]handlers.BlockHandler, 0)
+	if hooksSvc != nil {
+		blockHandlers = append(blockHandlers, hooksSvc)
+	}
+
 	log.Trace().Msg("Starting blocks service")
-	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, activitySem)
+	blocks, err := startBlocks(util.WithModule(ctx, "blocks"), network, eth2Client, chainDB, chainTime, monitor, activitySem, prioritySvc, slashingHandlers, blockHandlers)
 	if err != nil {
-		return errors.Wrap(err, "failed to start blocks service")
+		return nil, errors.Wrap(err, "failed to start blocks service")
 	}
 
 	var summarizerSvc summarizer.Service
 	if blocks != nil {
 		log.Trace().Msg("Starting summarizer service")
-		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, chainTime, monitor)
+		summarizerSvc, err = startSummarizer(util.WithModule(ctx, "summarizer"), network, eth2Client, chainDB, chainTime, monitor)
 		if err != nil {
-			return errors.Wrap(err, "failed to start summarizer service")
+			return nil, errors.Wrap(err, "failed to start summarizer service")
+		}
+		if module, isModule := summarizerSvc.(admin.Module); isModule {
+			registerAdminModule(ctx, network, "summarizer", module)
 		}
 	}
 
+	log.Trace().Msg("Starting aggregates service")
+	if err := modules.add("aggregates", "", func(ctx context.Context) error {
+		return startAggregates(ctx, network, chainDB, chainTime, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start aggregates service")
+	}
+
+	log.Trace().Msg("Starting database statistics service")
+	if err := modules.add("dbstats", "", func(ctx context.Context) error {
+		return startDBStats(ctx, network, chainDB, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start database statistics service")
+	}
+
+	log.Trace().Msg("Starting pruner service")
+	if err := modules.add("pruner", "", func(ctx context.Context) error {
+		return startPruner(ctx, network, chainDB, chainTime, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start pruner service")
+	}
+
+	log.Trace().Msg("Starting gaps service")
+	if err := modules.add("gaps", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startGaps(ctx, network, eth2Client, chainDB, chainTime, blocks, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start gaps service")
+	}
+
+	log.Trace().Msg("Starting gossip service")
+	if err := modules.add("gossip", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startGossip(ctx, network, eth2Client, chainDB, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start gossip service")
+	}
+
+	log.Trace().Msg("Starting peers service")
+	if err := modules.add("peers", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startPeers(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start peers service")
+	}
+
+	log.Trace().Msg("Starting fingerprint service")
+	if err := modules.add("fingerprint", "", func(ctx context.Context) error {
+		return startFingerprint(ctx, network, chainDB, chainTime, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start fingerprint service")
+	}
+
+	log.Trace().Msg("Starting relays service")
+	if err := modules.add("relays", "", func(ctx context.Context) error {
+		return startRelays(ctx, network, chainDB, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start relays service")
+	}
+
+	log.Trace().Msg("Starting effectiveness service")
+	if err := modules.add("effectiveness", "", func(ctx context.Context) error {
+		return startEffectiveness(ctx, network, chainDB, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start effectiveness service")
+	}
+
+	log.Trace().Msg("Starting income service")
+	if err := modules.add("income", "", func(ctx context.Context) error {
+		return startIncome(ctx, network, chainDB, chainTime, validatorKeys, monitor)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start income service")
+	}
+
 	log.Trace().Msg("Starting finalizer service")
 	finalityHandlers := make([]handlers.FinalityHandler, 0)
 	if summarizerSvc != nil {
 		finalityHandlers = append(finalityHandlers, summarizerSvc.(handlers.FinalityHandler))
 	}
-	if err := startFinalizer(ctx, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem); err != nil {
-		return errors.Wrap(err, "failed to start finalizer service")
+	if hooksSvc != nil {
+		finalityHandlers = append(finalityHandlers, hooksSvc)
+	}
+	finalizer, err := startFinalizer(util.WithModule(ctx, "finalizer"), network, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start finalizer service")
+	}
+	if finalizer != nil {
+		registerAdminModule(ctx, network, "finalizer", finalizer)
 	}
 
 	log.Trace().Msg("Starting validators service")
-	if err := startValidators(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start validators service")
+	if err := modules.add("validators", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startValidators(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start validators service")
 	}
 
 	log.Trace().Msg("Starting beacon committees service")
-	if err := startBeaconCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start beacon committees service")
+	if err := modules.add("beacon-committees", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startBeaconCommittees(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start beacon committees service")
 	}
 
 	log.Trace().Msg("Starting proposer duties service")
-	if err := startProposerDuties(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
-		return errors.Wrap(err, "failed to start proposer duties service")
+	if err := modules.add("proposer-duties", "eth2client.address", withClient(func(ctx context.Context, eth2Client eth2client.Service) error {
+		return startProposerDuties(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	})); err != nil {
+		return nil, errors.Wrap(err, "failed to start proposer duties service")
 	}
 
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
-	if err := startETH1Deposits(ctx, chainDB, monitor); err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
+	if err := modules.add("eth1deposits", "eth1client.address", func(ctx context.Context) error {
+		eth1Deposits, err := startETH1Deposits(ctx, network, chainDB, monitor)
+		if err != nil {
+			return err
+		}
+		if eth1Deposits != nil {
+			registerAdminModule(ctx, network, "eth1deposits", eth1Deposits)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to start Ethereum 1 deposits service")
+	}
+
+	return &runningServices{
+		network:     network,
+		chainDB:     chainDB,
+		activitySem: activitySem,
+		modules:     modules,
+		health:      healthSvc,
+		chainTime:   chainTime,
+	}, nil
+}
+
+func startHealth(
+	ctx context.Context,
+	network *network,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) (
+	health.Service,
+	error,
+) {
+	config := network.config
+	// The systemd watchdog uses the health service to check progress, so start it if required
+	// even if there is no listen address.
+	if config.GetString("health.listen-address") == "" && !watchdogEnabled() {
+		log.Debug().Msg("No health listen address supplied; health service not starting")
+		return nil, nil
+	}
+
+	s, err := standardhealth.New(ctx,
+		standardhealth.WithLogLevel(network.logLevel("health")),
+		standardhealth.WithNetwork(network.name),
+		standardhealth.WithListenAddress(config.GetString("health.listen-address")),
+		standardhealth.WithETH2Client(eth2Client),
+		standardhealth.WithChainDB(chainDB),
+		standardhealth.WithChainTime(chainTime),
+		standardhealth.WithModules(healthModules(config)),
+		standardhealth.WithMaxLag(phase0.Epoch(config.GetUint64("health.max-lag"))),
+		standardhealth.WithReadyMaxLag(phase0.Epoch(config.GetUint64("health.ready-max-lag"))),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create health service")
+	}
+	log.Info().Str("listen_address", config.GetString("health.listen-address")).Msg("Started health service")
+
+	return s, nil
+}
+
+func startAdmin(ctx context.Context) error {
+	if viper.GetString("admin.listen-address") == "" {
+		log.Debug().Msg("No admin listen address supplied; admin service not starting")
+		return nil
+	}
+
+	token, err := resolveSecret(ctx, viper.GetString("admin.token"))
+	if err != nil {
+		return errors.Wrap(err, "failed to resolve admin token")
 	}
 
+	tokens := make(map[string]standardadmin.Role)
+	for name := range viper.GetStringMap("admin.tokens") {
+		role, err := standardadmin.ParseRole(name)
+		if err != nil {
+			return errors.Wrap(err, "invalid admin token role")
+		}
+		for _, roleToken := range viper.GetStringSlice(fmt.Sprintf("admin.tokens.%s", name)) {
+			roleToken, err = resolveSecret(ctx, roleToken)
+			if err != nil {
+				return errors.Wrapf(err, "failed to resolve %s admin token", role)
+			}
+			tokens[roleToken] = role
+		}
+	}
+
+	s, err := standardadmin.New(ctx,
+		standardadmin.WithLogLevel(util.LogLevel("admin")),
+		standardadmin.WithListenAddress(viper.GetString("admin.listen-address")),
+		standardadmin.WithToken(token),
+		standardadmin.WithTokens(tokens),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create admin service")
+	}
+	adminService = s
+	log.Info().Str("listen_address", viper.GetString("admin.listen-address")).Msg("Started admin service")
+
 	return nil
 }
 
+// registerAdminModule registers a module with the admin service, if running, for the lifetime of the context.
+func registerAdminModule(ctx context.Context, network *network, name string, module admin.Module) {
+	if adminService == nil {
+		return
+	}
+	if network.name != "" {
+		name = fmt.Sprintf("%s/%s", network.name, name)
+	}
+	adminService.RegisterModule(name, module)
+	go func() {
+		<-ctx.Done()
+		adminService.DeregisterModule(name, module)
+	}()
+}
+
+// healthModules returns the modules that are enabled, on which the health service reports.
+func healthModules(config *viper.Viper) []string {
+	modules := make([]string, 0)
+	for _, module := range []string{"blocks", "beacon-committees", "proposer-duties", "validators"} {
+		if config.GetBool(fmt.Sprintf("%s.enable", module)) {
+			modules = append(modules, module)
+		}
+	}
+	if config.GetBool("blocks.enable") {
+		// Finalizer and summarizer are only started if blocks are enabled.
+		for _, module := range []string{"finalizer", "summarizer"} {
+			if config.GetBool(fmt.Sprintf("%s.enable", module)) {
+				modules = append(modules, module)
+			}
+		}
+	}
+
+	return modules
+}
+
 func logModules() {
 	buildInfo, ok := debug.ReadBuildInfo()
 	if ok {
@@ -423,33 +1231,84 @@ func resolvePath(path string) string {
 	return filepath.Join(baseDir, path)
 }
 
-func startSpec(
-	ctx context.Context,
-	eth2Client eth2client.Service,
-	chainDB chaindb.Service,
-	monitor metrics.Service,
-) error {
-	var err error
-	if viper.GetString("spec.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("spec.address"))
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("spec.address")))
+// readTLSFiles reads the TLS client certificate, client key and CA certificate
+// configured under the given prefix, for example "eth2client.tls".
+func readTLSFiles(config *viper.Viper, prefix string) ([]byte, []byte, []byte, error) {
+	paths := make([]string, 3)
+	for i, key := range []string{"client-cert", "client-key", "ca-cert"} {
+		if path := config.GetString(fmt.Sprintf("%s.%s", prefix, key)); path != "" {
+			paths[i] = resolvePath(path)
 		}
 	}
 
-	scheduler, err := standardscheduler.New(ctx,
-		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
-		standardscheduler.WithMonitor(monitor))
+	return util.ReadTLSFiles(paths[0], paths[1], paths[2])
+}
+
+// readAuth reads the headers, bearer token and JWT secret used to authenticate
+// with the node configured under the given prefix, for example "eth2client".
+func readAuth(config *viper.Viper, prefix string) (map[string]string, string, []byte, error) {
+	headers := config.GetStringMapString(fmt.Sprintf("%s.headers", prefix))
+	bearerToken := config.GetString(fmt.Sprintf("%s.bearer-token", prefix))
+
+	var jwtSecret []byte
+	if path := config.GetString(fmt.Sprintf("%s.jwt-secret", prefix)); path != "" {
+		data, err := os.ReadFile(resolvePath(path))
+		if err != nil {
+			return nil, "", nil, errors.Wrap(err, "failed to read JWT secret")
+		}
+		jwtSecret, err = util.ParseJWTSecret(data)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	return headers, bearerToken, jwtSecret, nil
+}
+
+func startSpec(
+	ctx context.Context,
+	network *network,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	var err error
+	if config.GetString("spec.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("spec.address"), monitor)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("spec.address")))
+		}
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
 	if err != nil {
 		return errors.Wrap(err, "failed to initialise scheduler")
 	}
 
-	_, err = standardspec.New(ctx,
-		standardspec.WithLogLevel(util.LogLevel("spec")),
+	genesisProvider, err := fetchGenesisProvider(ctx, network, eth2Client)
+	if err != nil {
+		return err
+	}
+	params := []standardspec.Parameter{
+		standardspec.WithLogLevel(network.logLevel("spec")),
+		standardspec.WithNetwork(network.name),
 		standardspec.WithETH2Client(eth2Client),
 		standardspec.WithChainDB(chainDB),
+		standardspec.WithChainTime(chainTime),
 		standardspec.WithScheduler(scheduler),
-	)
+		standardspec.WithGenesisProvider(genesisProvider),
+	}
+	// The validators at genesis are stored if they are available from a genesis state file.
+	if genesisState, isGenesisState := genesisProvider.(*genesisstateeth2client.Service); isGenesisState {
+		params = append(params, standardspec.WithGenesisValidatorsProvider(genesisState))
+	}
+
+	_, err = standardspec.New(ctx, params...)
 	if err != nil {
 		return errors.Wrap(err, "failed to create spec service")
 	}
@@ -457,38 +1316,142 @@ func startSpec(
 	return nil
 }
 
+func startAlerts(
+	ctx context.Context,
+	network *network,
+	chainTime chaintime.Service,
+	validatorKeys validatorkeys.Service,
+	monitor metrics.Service,
+) (
+	*standardalerts.Service,
+	error,
+) {
+	config := network.config
+	if len(config.GetStringSlice("alerts.webhooks")) == 0 && config.GetString("alerts.kafka.rest-proxy") == "" {
+		return nil, nil
+	}
+
+	s, err := standardalerts.New(ctx,
+		standardalerts.WithLogLevel(network.logLevel("alerts")),
+		standardalerts.WithNetwork(network.name),
+		standardalerts.WithMonitor(monitor),
+		standardalerts.WithChainTime(chainTime),
+		standardalerts.WithWebhooks(config.GetStringSlice("alerts.webhooks")),
+		standardalerts.WithKafkaRESTProxy(config.GetString("alerts.kafka.rest-proxy")),
+		standardalerts.WithKafkaTopic(config.GetString("alerts.kafka.topic")),
+		standardalerts.WithTimeout(config.GetDuration("alerts.timeout")),
+		standardalerts.WithMaxAge(config.GetDuration("alerts.max-age")),
+		standardalerts.WithValidatorKeys(validatorKeys),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create alerts service")
+	}
+
+	return s, nil
+}
+
+func startHooks(
+	ctx context.Context,
+	network *network,
+	monitor metrics.Service,
+) (
+	*standardhooks.Service,
+	error,
+) {
+	config := network.config
+	params := []standardhooks.Parameter{
+		standardhooks.WithLogLevel(network.logLevel("hooks")),
+		standardhooks.WithNetwork(network.name),
+		standardhooks.WithMonitor(monitor),
+		standardhooks.WithTimeout(config.GetDuration("hooks.timeout")),
+		standardhooks.WithQueueSize(config.GetInt("hooks.queue-size")),
+	}
+	hooksPresent := false
+	for _, event := range hooks.Events {
+		commands := config.GetStringSlice(fmt.Sprintf("hooks.%s.commands", event))
+		webhooks := config.GetStringSlice(fmt.Sprintf("hooks.%s.webhooks", event))
+		if len(commands) > 0 || len(webhooks) > 0 {
+			hooksPresent = true
+		}
+		params = append(params,
+			standardhooks.WithCommands(event, commands),
+			standardhooks.WithWebhooks(event, webhooks),
+		)
+	}
+	if !hooksPresent {
+		return nil, nil
+	}
+
+	s, err := standardhooks.New(ctx, params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create hooks service")
+	}
+
+	return s, nil
+}
+
 func startBlocks(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 	activitySem *semaphore.Weighted,
+	prioritySvc priority.Service,
+	slashingHandlers []handlers.SlashingHandler,
+	blockHandlers []handlers.BlockHandler,
 ) (
 	blocks.Service,
 	error,
 ) {
-	if !viper.GetBool("blocks.enable") {
+	config := network.config
+	if !config.GetBool("blocks.enable") {
 		return nil, nil
 	}
 
 	var err error
-	if viper.GetString("blocks.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("blocks.address"))
+	if config.GetString("blocks.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("blocks.address"), monitor)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("blocks.address")))
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("blocks.address")))
 		}
 	}
 
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialise scheduler")
+	}
+
 	s, err := standardblocks.New(ctx,
-		standardblocks.WithLogLevel(util.LogLevel("blocks")),
+		standardblocks.WithLogLevel(network.logLevel("blocks")),
+		standardblocks.WithNetwork(network.name),
 		standardblocks.WithMonitor(monitor),
 		standardblocks.WithETH2Client(eth2Client),
 		standardblocks.WithChainTime(chainTime),
 		standardblocks.WithChainDB(chainDB),
-		standardblocks.WithStartSlot(viper.GetInt64("blocks.start-slot")),
-		standardblocks.WithRefetch(viper.GetBool("blocks.refetch")),
+		standardblocks.WithStartSlot(config.GetInt64("blocks.start-slot")),
+		standardblocks.WithRefetch(config.GetBool("blocks.refetch")),
 		standardblocks.WithActivitySem(activitySem),
+		standardblocks.WithPriority(prioritySvc),
+		standardblocks.WithWriteQueueSize(config.GetInt("blocks.write-queue-size")),
+		standardblocks.WithWriters(config.GetInt("blocks.writers")),
+		standardblocks.WithCommitBatchSize(config.GetInt("blocks.commit-batch-size")),
+		standardblocks.WithWriteLatencyTarget(config.GetDuration("blocks.write-latency-target")),
+		standardblocks.WithMaxFetchDelay(config.GetDuration("blocks.max-fetch-delay")),
+		standardblocks.WithBackfill(config.GetBool("blocks.backfill.enable")),
+		standardblocks.WithBidirectional(config.GetBool("blocks.bidirectional")),
+		standardblocks.WithQuarantine(config.GetBool("blocks.quarantine.enable")),
+		standardblocks.WithVerifySignatures(config.GetBool("blocks.verify-signatures.enable")),
+		standardblocks.WithAttestationSampleRatio(config.GetFloat64("blocks.verify-signatures.attestation-sample-ratio")),
+		standardblocks.WithScheduler(scheduler),
+		standardblocks.WithArrivals(config.GetBool("blocks.arrivals.enable")),
+		standardblocks.WithSlashingHandlers(slashingHandlers),
+		standardblocks.WithBlockHandlers(blockHandlers),
+		standardblocks.WithWindow(config.GetUint64("window")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create blocks service")
@@ -499,6 +1462,7 @@ func startBlocks(
 
 func startFinalizer(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
@@ -506,21 +1470,26 @@ func startFinalizer(
 	monitor metrics.Service,
 	finalityHandlers []handlers.FinalityHandler,
 	activitySem *semaphore.Weighted,
-) error {
-	if !viper.GetBool("finalizer.enable") {
-		return nil
+) (
+	*standardfinalizer.Service,
+	error,
+) {
+	config := network.config
+	if !config.GetBool("finalizer.enable") {
+		return nil, nil
 	}
 
 	var err error
-	if viper.GetString("finalizer.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("finalizer.address"))
+	if config.GetString("finalizer.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("finalizer.address"), monitor)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("finalizer.address")))
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("finalizer.address")))
 		}
 	}
 
-	_, err = standardfinalizer.New(ctx,
-		standardfinalizer.WithLogLevel(util.LogLevel("finalizer")),
+	s, err := standardfinalizer.New(ctx,
+		standardfinalizer.WithLogLevel(network.logLevel("finalizer")),
+		standardfinalizer.WithNetwork(network.name),
 		standardfinalizer.WithMonitor(monitor),
 		standardfinalizer.WithETH2Client(eth2Client),
 		standardfinalizer.WithChainTime(chainTime),
@@ -528,16 +1497,18 @@ func startFinalizer(
 		standardfinalizer.WithBlocks(blocks),
 		standardfinalizer.WithFinalityHandlers(finalityHandlers),
 		standardfinalizer.WithActivitySem(activitySem),
+		standardfinalizer.WithWindow(config.GetUint64("window")),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create finalizer service")
+		return nil, errors.Wrap(err, "failed to create finalizer service")
 	}
 
-	return nil
+	return s, nil
 }
 
 func startSummarizer(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
@@ -546,19 +1517,27 @@ func startSummarizer(
 	summarizer.Service,
 	error,
 ) {
-	if !viper.GetBool("summarizer.enable") {
+	config := network.config
+	if !config.GetBool("summarizer.enable") {
 		return nil, nil
 	}
 
 	standardSummarizer, err := standardsummarizer.New(ctx,
-		standardsummarizer.WithLogLevel(util.LogLevel("summarizer")),
+		standardsummarizer.WithLogLevel(network.logLevel("summarizer")),
+		standardsummarizer.WithNetwork(network.name),
 		standardsummarizer.WithMonitor(monitor),
 		standardsummarizer.WithETH2Client(eth2Client),
 		standardsummarizer.WithChainTime(chainTime),
 		standardsummarizer.WithChainDB(chainDB),
-		standardsummarizer.WithEpochSummaries(viper.GetBool("summarizer.epochs.enable")),
-		standardsummarizer.WithBlockSummaries(viper.GetBool("summarizer.blocks.enable")),
-		standardsummarizer.WithValidatorSummaries(viper.GetBool("summarizer.validators.enable")),
+		standardsummarizer.WithEpochSummaries(config.GetBool("summarizer.epochs.enable")),
+		standardsummarizer.WithBlockSummaries(config.GetBool("summarizer.blocks.enable")),
+		standardsummarizer.WithValidatorSummaries(config.GetBool("summarizer.validators.enable")),
+		standardsummarizer.WithValidatorEpochSummaries(config.GetBool("summarizer.validators.epochs.enable")),
+		standardsummarizer.WithValidatorEpochRetention(config.GetDuration("summarizer.validators.epochs.retention")),
+		standardsummarizer.WithValidatorDaySummaries(config.GetBool("summarizer.validators.days.enable")),
+		standardsummarizer.WithWindow(config.GetUint64("window")),
+		standardsummarizer.WithValidatorDayRetention(config.GetDuration("summarizer.validators.days.retention")),
+		standardsummarizer.WithMaxValidatorDaysPerRun(config.GetUint64("summarizer.validators.days.max-per-run")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create summarizer service")
@@ -567,32 +1546,435 @@ func startSummarizer(
 	return standardSummarizer, nil
 }
 
+func startAggregates(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("aggregates.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardaggregates.New(ctx,
+		standardaggregates.WithLogLevel(network.logLevel("aggregates")),
+		standardaggregates.WithNetwork(network.name),
+		standardaggregates.WithMonitor(monitor),
+		standardaggregates.WithChainDB(chainDB),
+		standardaggregates.WithChainTime(chainTime),
+		standardaggregates.WithScheduler(scheduler),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create aggregates service")
+	}
+
+	return nil
+}
+
+func startDBStats(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("dbstats.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standarddbstats.New(ctx,
+		standarddbstats.WithLogLevel(network.logLevel("dbstats")),
+		standarddbstats.WithNetwork(network.name),
+		standarddbstats.WithMonitor(monitor),
+		standarddbstats.WithChainDB(chainDB),
+		standarddbstats.WithScheduler(scheduler),
+		standarddbstats.WithInterval(config.GetDuration("dbstats.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create database statistics service")
+	}
+
+	return nil
+}
+
+// minWindow is the smallest rolling window, in epochs.  The window must hold the
+// epochs that are yet to be finalized and summarized.
+const minWindow = 16
+
+// checkWindow checks that the rolling window, if any, can be used with the enabled modules.
+func checkWindow(config *viper.Viper) error {
+	window := config.GetUint64("window")
+	if window == 0 {
+		return nil
+	}
+	if window < minWindow {
+		return fmt.Errorf("window must be at least %d epochs", minWindow)
+	}
+	if config.GetBool("income.enable") {
+		// Income is calculated from consecutive balances starting at genesis.
+		return errors.New("income cannot be calculated with a rolling window")
+	}
+
+	return nil
+}
+
+func startPruner(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("pruner.enable") && config.GetUint64("window") == 0 {
+		return nil
+	}
+
+	retentions := make(map[string]time.Duration)
+	for table, retention := range config.GetStringMapString("pruner.retention") {
+		duration, err := time.ParseDuration(retention)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("invalid retention for %s", table))
+		}
+		retentions[table] = duration
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardpruner.New(ctx,
+		standardpruner.WithLogLevel(network.logLevel("pruner")),
+		standardpruner.WithNetwork(network.name),
+		standardpruner.WithMonitor(monitor),
+		standardpruner.WithChainDB(chainDB),
+		standardpruner.WithChainTime(chainTime),
+		standardpruner.WithScheduler(scheduler),
+		standardpruner.WithInterval(config.GetDuration("pruner.interval")),
+		standardpruner.WithRetentions(retentions),
+		standardpruner.WithWindow(config.GetUint64("window")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pruner service")
+	}
+
+	return nil
+}
+
+func startGaps(
+	ctx context.Context,
+	network *network,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	blocks blocks.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("gaps.enable") {
+		return nil
+	}
+	if config.GetBool("gaps.refetch") && blocks == nil {
+		return errors.New("gap refetching requires the blocks module to be enabled")
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardgaps.New(ctx,
+		standardgaps.WithLogLevel(network.logLevel("gaps")),
+		standardgaps.WithNetwork(network.name),
+		standardgaps.WithMonitor(monitor),
+		standardgaps.WithETH2Client(eth2Client),
+		standardgaps.WithChainDB(chainDB),
+		standardgaps.WithBlocks(blocks),
+		standardgaps.WithScheduler(scheduler),
+		standardgaps.WithInterval(config.GetDuration("gaps.interval")),
+		standardgaps.WithStartSlot(phase0.Slot(config.GetUint64("gaps.start-slot"))),
+		standardgaps.WithRefetch(config.GetBool("gaps.refetch")),
+		standardgaps.WithRefetchLimit(config.GetInt("gaps.refetch-limit")),
+		standardgaps.WithChainTime(chainTime),
+		standardgaps.WithWindow(config.GetUint64("window")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gaps service")
+	}
+
+	return nil
+}
+
+func startGossip(
+	ctx context.Context,
+	network *network,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("gossip.enable") {
+		return nil
+	}
+
+	_, err := standardgossip.New(ctx,
+		standardgossip.WithLogLevel(network.logLevel("gossip")),
+		standardgossip.WithNetwork(network.name),
+		standardgossip.WithMonitor(monitor),
+		standardgossip.WithETH2Client(eth2Client),
+		standardgossip.WithChainDB(chainDB),
+		standardgossip.WithBatchSize(config.GetInt("gossip.batch-size")),
+		standardgossip.WithFlushInterval(config.GetDuration("gossip.flush-interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gossip service")
+	}
+
+	return nil
+}
+
+func startPeers(
+	ctx context.Context,
+	network *network,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("peers.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardpeers.New(ctx,
+		standardpeers.WithLogLevel(network.logLevel("peers")),
+		standardpeers.WithNetwork(network.name),
+		standardpeers.WithMonitor(monitor),
+		standardpeers.WithETH2Client(eth2Client),
+		standardpeers.WithChainDB(chainDB),
+		standardpeers.WithChainTime(chainTime),
+		standardpeers.WithScheduler(scheduler),
+		standardpeers.WithInterval(config.GetDuration("peers.interval")),
+		standardpeers.WithTimeout(config.GetDuration("eth2client.timeout")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create peers service")
+	}
+
+	return nil
+}
+
+func startFingerprint(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("fingerprint.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardfingerprint.New(ctx,
+		standardfingerprint.WithLogLevel(network.logLevel("fingerprint")),
+		standardfingerprint.WithNetwork(network.name),
+		standardfingerprint.WithMonitor(monitor),
+		standardfingerprint.WithChainDB(chainDB),
+		standardfingerprint.WithChainTime(chainTime),
+		standardfingerprint.WithScheduler(scheduler),
+		standardfingerprint.WithInterval(config.GetDuration("fingerprint.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create fingerprint service")
+	}
+
+	return nil
+}
+
+func startRelays(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("relays.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardrelays.New(ctx,
+		standardrelays.WithLogLevel(network.logLevel("relays")),
+		standardrelays.WithNetwork(network.name),
+		standardrelays.WithMonitor(monitor),
+		standardrelays.WithChainDB(chainDB),
+		standardrelays.WithScheduler(scheduler),
+		standardrelays.WithAddresses(config.GetStringSlice("relays.addresses")),
+		standardrelays.WithInterval(config.GetDuration("relays.interval")),
+		standardrelays.WithTimeout(config.GetDuration("relays.timeout")),
+		standardrelays.WithLag(phase0.Slot(config.GetUint64("relays.lag"))),
+		standardrelays.WithRegistrations(config.GetBool("relays.registrations.enable")),
+		standardrelays.WithRegistrationsLabels(config.GetStringSlice("relays.registrations.labels")),
+		standardrelays.WithRegistrationsInterval(config.GetDuration("relays.registrations.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create relays service")
+	}
+
+	return nil
+}
+
+func startEffectiveness(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("effectiveness.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardeffectiveness.New(ctx,
+		standardeffectiveness.WithLogLevel(network.logLevel("effectiveness")),
+		standardeffectiveness.WithNetwork(network.name),
+		standardeffectiveness.WithMonitor(monitor),
+		standardeffectiveness.WithChainDB(chainDB),
+		standardeffectiveness.WithScheduler(scheduler),
+		standardeffectiveness.WithInterval(config.GetDuration("effectiveness.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create effectiveness service")
+	}
+
+	return nil
+}
+
+func startIncome(
+	ctx context.Context,
+	network *network,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	validatorKeys validatorkeys.Service,
+	monitor metrics.Service,
+) error {
+	config := network.config
+	if !config.GetBool("income.enable") {
+		return nil
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(network.logLevel("scheduler")),
+		standardscheduler.WithNetwork(network.name),
+		standardscheduler.WithMonitor(monitor))
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	_, err = standardincome.New(ctx,
+		standardincome.WithLogLevel(network.logLevel("income")),
+		standardincome.WithNetwork(network.name),
+		standardincome.WithMonitor(monitor),
+		standardincome.WithChainDB(chainDB),
+		standardincome.WithChainTime(chainTime),
+		standardincome.WithScheduler(scheduler),
+		standardincome.WithValidatorKeys(validatorKeys),
+		standardincome.WithInterval(config.GetDuration("income.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create income service")
+	}
+
+	return nil
+}
+
 func startValidators(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("validators.enable") {
+	config := network.config
+	if !config.GetBool("validators.enable") {
 		return nil
 	}
 
 	var err error
-	if viper.GetString("validators.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("validators.address"))
+	if config.GetString("validators.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("validators.address"), monitor)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("validators.address")))
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("validators.address")))
 		}
 	}
 
 	_, err = standardvalidators.New(ctx,
-		standardvalidators.WithLogLevel(util.LogLevel("validators")),
+		standardvalidators.WithLogLevel(network.logLevel("validators")),
+		standardvalidators.WithNetwork(network.name),
 		standardvalidators.WithMonitor(monitor),
 		standardvalidators.WithETH2Client(eth2Client),
 		standardvalidators.WithChainTime(chainTime),
 		standardvalidators.WithChainDB(chainDB),
-		standardvalidators.WithBalances(viper.GetBool("validators.balances.enable")),
+		standardvalidators.WithBalances(config.GetBool("validators.balances.enable")),
+		standardvalidators.WithWindow(config.GetUint64("window")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create validators service")
@@ -603,29 +1985,34 @@ func startValidators(
 
 func startBeaconCommittees(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("beacon-committees.enable") {
+	config := network.config
+	if !config.GetBool("beacon-committees.enable") {
 		return nil
 	}
 
 	var err error
-	if viper.GetString("beacon-committees.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("beacon-committees.address"))
+	if config.GetString("beacon-committees.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("beacon-committees.address"), monitor)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("beacon-committees.address")))
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("beacon-committees.address")))
 		}
 	}
 
 	_, err = standardbeaconcommittees.New(ctx,
-		standardbeaconcommittees.WithLogLevel(util.LogLevel("beacon-committees")),
+		standardbeaconcommittees.WithLogLevel(network.logLevel("beacon-committees")),
+		standardbeaconcommittees.WithNetwork(network.name),
 		standardbeaconcommittees.WithMonitor(monitor),
 		standardbeaconcommittees.WithETH2Client(eth2Client),
 		standardbeaconcommittees.WithChainTime(chainTime),
 		standardbeaconcommittees.WithChainDB(chainDB),
+		standardbeaconcommittees.WithCommitBatchSize(config.GetUint64("beacon-committees.commit-batch-size")),
+		standardbeaconcommittees.WithWindow(config.GetUint64("window")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create beacon committees service")
@@ -636,29 +2023,33 @@ func startBeaconCommittees(
 
 func startProposerDuties(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("proposer-duties.enable") {
+	config := network.config
+	if !config.GetBool("proposer-duties.enable") {
 		return nil
 	}
 
 	var err error
-	if viper.GetString("proposer-duties.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("proposer-duties.address"))
+	if config.GetString("proposer-duties.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("proposer-duties.address"), monitor)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("proposer-duties.address")))
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("proposer-duties.address")))
 		}
 	}
 
 	_, err = standardproposerduties.New(ctx,
-		standardproposerduties.WithLogLevel(util.LogLevel("proposer-duties")),
+		standardproposerduties.WithLogLevel(network.logLevel("proposer-duties")),
+		standardproposerduties.WithNetwork(network.name),
 		standardproposerduties.WithMonitor(monitor),
 		standardproposerduties.WithETH2Client(eth2Client),
 		standardproposerduties.WithChainTime(chainTime),
 		standardproposerduties.WithChainDB(chainDB),
+		standardproposerduties.WithWindow(config.GetUint64("window")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create proposer duties service")
@@ -669,57 +2060,84 @@ func startProposerDuties(
 
 func startETH1Deposits(
 	ctx context.Context,
+	network *network,
 	chainDB chaindb.Service,
 	monitor metrics.Service,
-) error {
-	if !viper.GetBool("eth1deposits.enable") {
-		return nil
+) (
+	*getlogseth1deposits.Service,
+	error,
+) {
+	config := network.config
+	if !config.GetBool("eth1deposits.enable") {
+		return nil, nil
+	}
+
+	clientCert, clientKey, caCert, err := readTLSFiles(config, "eth1client.tls")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Ethereum 1 node TLS configuration")
+	}
+	headers, bearerToken, jwtSecret, err := readAuth(config, "eth1client")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid Ethereum 1 node authentication configuration")
 	}
 
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
-	_, err := getlogseth1deposits.New(ctx,
-		getlogseth1deposits.WithLogLevel(util.LogLevel("eth1deposits.log-level")),
+	s, err := getlogseth1deposits.New(ctx,
+		getlogseth1deposits.WithLogLevel(network.logLevel("eth1deposits")),
+		getlogseth1deposits.WithNetwork(network.name),
 		getlogseth1deposits.WithMonitor(monitor),
 		getlogseth1deposits.WithChainDB(chainDB),
-		getlogseth1deposits.WithConnectionURL(viper.GetString("eth1client.address")),
-		getlogseth1deposits.WithStartBlock(viper.GetString("eth1deposits.start-block")),
+		getlogseth1deposits.WithConnectionURL(config.GetString("eth1client.address")),
+		getlogseth1deposits.WithStartBlock(config.GetString("eth1deposits.start-block")),
+		getlogseth1deposits.WithProxyURL(config.GetString("eth1client.proxy")),
 		getlogseth1deposits.WithETH1DepositsSetter(chainDB.(chaindb.ETH1DepositsSetter)),
-		getlogseth1deposits.WithETH1Confirmations(viper.GetUint64("eth1deposits.confirmations")),
+		getlogseth1deposits.WithETH1Confirmations(config.GetUint64("eth1deposits.confirmations")),
+		getlogseth1deposits.WithProvisional(config.GetBool("eth1deposits.provisional")),
+		getlogseth1deposits.WithClientCert(clientCert),
+		getlogseth1deposits.WithClientKey(clientKey),
+		getlogseth1deposits.WithCACert(caCert),
+		getlogseth1deposits.WithHeaders(headers),
+		getlogseth1deposits.WithBearerToken(bearerToken),
+		getlogseth1deposits.WithJWTSecret(jwtSecret),
+		getlogseth1deposits.WithSecrets(secretsService),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
+		return nil, errors.Wrap(err, "failed to start Ethereum 1 deposits service")
 	}
 
-	return nil
+	return s, nil
 }
 
 func startSyncCommittees(
 	ctx context.Context,
+	network *network,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("sync-committees.enable") {
+	config := network.config
+	if !config.GetBool("sync-committees.enable") {
 		return nil
 	}
 
 	var err error
-	if viper.GetString("sync-committees.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("sync-committees.address"))
+	if config.GetString("sync-committees.address") != "" {
+		eth2Client, err = fetchClient(ctx, network, config.GetString("sync-committees.address"), monitor)
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("sync-committees.address")))
+			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("sync-committees.address")))
 		}
 	}
 
 	_, err = standardsynccommittees.New(ctx,
-		standardsynccommittees.WithLogLevel(util.LogLevel("sync-committees")),
+		standardsynccommittees.WithLogLevel(network.logLevel("sync-committees")),
+		standardsynccommittees.WithNetwork(network.name),
 		standardsynccommittees.WithMonitor(monitor),
 		standardsynccommittees.WithETH2Client(eth2Client),
 		standardsynccommittees.WithChainTime(chainTime),
 		standardsynccommittees.WithChainDB(chainDB),
 		standardsynccommittees.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
-		standardsynccommittees.WithStartPeriod(viper.GetInt64("sync-committees.start-period")),
+		standardsynccommittees.WithStartPeriod(config.GetInt64("sync-committees.start-period")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create sync committees service")
@@ -730,11 +2148,24 @@ func startSyncCommittees(
 
 // runCommands runs commands if required.
 // Returns true if an exit is required.
-func runCommands(_ context.Context) (bool, error) {
+func runCommands(ctx context.Context) (bool, error) {
 	if viper.GetBool("version") {
 		fmt.Printf("%s\n", ReleaseVersion)
 		return true, nil
 	}
 
+	if args := pflag.Args(); len(args) > 0 {
+		switch strings.Join(args, " ") {
+		case "config validate":
+			return true, validateConfig(ctx)
+		case "verify":
+			return true, runBatchCommand(ctx, "verify", verifyDatabase)
+		case "resummarize":
+			return true, runBatchCommand(ctx, "resummarize", resummarizeDatabase)
+		default:
+			return true, fmt.Errorf("unknown command %q", strings.Join(args, " "))
+		}
+	}
+
 	return false, nil
 }
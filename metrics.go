@@ -15,10 +15,15 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
 	"github.com/wealdtech/chaind/services/metrics"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	pushgatewaymetrics "github.com/wealdtech/chaind/services/metrics/pushgateway"
+	"github.com/wealdtech/chaind/util"
 )
 
 var metricsNamespace = "chaind"
@@ -26,6 +31,10 @@ var metricsNamespace = "chaind"
 var releaseMetric *prometheus.GaugeVec
 var readyMetric prometheus.Gauge
 
+var commandDuration prometheus.Gauge
+var commandSuccess prometheus.Gauge
+var commandCompletionTime prometheus.Gauge
+
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if releaseMetric != nil {
 		// Already registered.
@@ -36,10 +45,10 @@ func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 		return nil
 	}
 	switch monitor.Presenter() {
-	case "prometheus":
-		return registerPrometheusMetrics(ctx)
 	case "null":
 		log.Debug().Msg("no metrics will be generated for this module")
+	default:
+		return registerPrometheusMetrics(ctx)
 	}
 	return nil
 }
@@ -96,3 +105,101 @@ func setReady(ctx context.Context, ready bool) {
 		readyMetric.Set(0)
 	}
 }
+
+// runBatchCommand runs a command that exits on completion.  If a pushgateway is
+// configured then the command's metrics, along with its outcome, are pushed to
+// it when the command completes.
+func runBatchCommand(ctx context.Context, command string, runFunc func(context.Context, metrics.Service) error) error {
+	monitor, err := startCommandMonitor(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	err = runFunc(ctx, monitor)
+	if pusher, isPusher := monitor.(metrics.Pusher); isPusher {
+		setCommandOutcome(started, err)
+		if pushErr := pusher.Push(ctx); pushErr != nil {
+			log.Warn().Err(pushErr).Msg("Failed to push metrics")
+		}
+	}
+
+	return err
+}
+
+// startCommandMonitor starts the metrics service for a command.
+func startCommandMonitor(ctx context.Context, command string) (metrics.Service, error) {
+	if viper.Get("metrics.pushgateway.address") == nil {
+		return &nullmetrics.Service{}, nil
+	}
+
+	monitor, err := pushgatewaymetrics.New(ctx,
+		pushgatewaymetrics.WithLogLevel(util.LogLevel("metrics.pushgateway")),
+		pushgatewaymetrics.WithAddress(viper.GetString("metrics.pushgateway.address")),
+		pushgatewaymetrics.WithJob(viper.GetString("metrics.pushgateway.job")),
+		pushgatewaymetrics.WithGrouping("command", command),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start pushgateway metrics service")
+	}
+
+	if err := registerCommandMetrics(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to register command metrics")
+	}
+
+	return monitor, nil
+}
+
+func registerCommandMetrics(_ context.Context) error {
+	if commandDuration != nil {
+		// Already registered.
+		return nil
+	}
+
+	commandDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "command",
+		Name:      "duration_seconds",
+		Help:      "The time taken to run the command.",
+	})
+	if err := prometheus.Register(commandDuration); err != nil {
+		return errors.Wrap(err, "failed to register command_duration_seconds")
+	}
+
+	commandSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "command",
+		Name:      "success",
+		Help:      "1 if the command succeeded, otherwise 0.",
+	})
+	if err := prometheus.Register(commandSuccess); err != nil {
+		return errors.Wrap(err, "failed to register command_success")
+	}
+
+	commandCompletionTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "command",
+		Name:      "completion_time_secs",
+		Help:      "The timestamp at which the command completed.",
+	})
+	if err := prometheus.Register(commandCompletionTime); err != nil {
+		return errors.Wrap(err, "failed to register command_completion_time_secs")
+	}
+
+	return nil
+}
+
+// setCommandOutcome records the outcome of a command.
+func setCommandOutcome(started time.Time, err error) {
+	if commandDuration == nil {
+		return
+	}
+
+	commandDuration.Set(time.Since(started).Seconds())
+	if err == nil {
+		commandSuccess.Set(1)
+	} else {
+		commandSuccess.Set(0)
+	}
+	commandCompletionTime.SetToCurrentTime()
+}
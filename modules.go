@@ -0,0 +1,210 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/util"
+)
+
+// restartRequiredKeys are configuration keys that cannot be changed without restarting chaind.
+var restartRequiredKeys = []string{
+	"dry-run",
+	"audit.enable",
+	"audit.file",
+	"leader-election.enable",
+	"leader-election.lock-id",
+	"chaindb.url",
+	"chaindb.tls.client-cert",
+	"chaindb.tls.client-key",
+	"chaindb.tls.ca-cert",
+	"eth2client.tls.client-cert",
+	"eth2client.tls.client-key",
+	"eth2client.tls.ca-cert",
+	"eth2client.headers",
+	"eth2client.bearer-token",
+	"eth2client.jwt-secret",
+	"eth2client.proxy",
+	"eth2client.ssz",
+	"eth2client.index-chunk-size",
+	"eth2client.pubkey-chunk-size",
+	"chaindb.schema",
+	"chaindb.max-connections",
+	"chaindb.isolation-level",
+	"chaindb.lock-timeout",
+	"chaindb.cache.type",
+	"alerts.webhooks",
+	"alerts.kafka.rest-proxy",
+	"alerts.kafka.topic",
+	"alerts.timeout",
+	"alerts.max-age",
+	"blocks.enable",
+	"blocks.address",
+	"priority.capacity",
+	"priority.reserved",
+	"finalizer.enable",
+	"finalizer.address",
+	"summarizer.enable",
+	"health.listen-address",
+	"admin.listen-address",
+	"admin.token",
+	"admin.tokens",
+	"secrets.refresh-interval",
+	"secrets.envfile.path",
+	"secrets.vault.address",
+	"secrets.vault.token",
+	"secrets.vault.token-file",
+	"secrets.vault.namespace",
+	"secrets.vault.timeout",
+	"secrets.aws.enable",
+	"secrets.aws.region",
+	"debug.listen-address",
+	"errors.sentry.dsn",
+	"metrics.prometheus.listen-address",
+}
+
+// module is a module that can be started and stopped without restarting chaind.
+type module struct {
+	name string
+	// addressKey is the configuration key for the address of the node used by the module.
+	addressKey string
+	start      func(ctx context.Context) error
+	// cancel stops the module; nil if the module is not running.
+	cancel context.CancelFunc
+	// address is the address of the node with which the module was started.
+	address string
+}
+
+// moduleManager starts and stops modules as their configuration changes.
+type moduleManager struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	config  *viper.Viper
+	modules []*module
+	// fixed is the configuration at startup for keys that require a restart to change.
+	fixed map[string]string
+}
+
+// newModuleManager creates a module manager for modules with the given configuration.
+// Modules are stopped when the supplied context is cancelled.
+func newModuleManager(ctx context.Context, config *viper.Viper) *moduleManager {
+	fixed := make(map[string]string, len(restartRequiredKeys))
+	for _, key := range restartRequiredKeys {
+		// Values are compared as formatted strings, so that lists are compared as well as scalars.
+		fixed[key] = fmt.Sprint(config.Get(key))
+	}
+
+	return &moduleManager{
+		ctx:     ctx,
+		config:  config,
+		modules: make([]*module, 0),
+		fixed:   fixed,
+	}
+}
+
+// add adds a module to the manager, starting it if it is enabled.
+// The module is enabled if the configuration key "<name>.enable" is true.
+func (m *moduleManager) add(name string,
+	addressKey string,
+	start func(ctx context.Context) error,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mod := &module{
+		name:       name,
+		addressKey: addressKey,
+		start:      start,
+	}
+	m.modules = append(m.modules, mod)
+
+	if !m.config.GetBool(fmt.Sprintf("%s.enable", name)) {
+		return nil
+	}
+
+	return m.startModule(mod)
+}
+
+// reload starts, stops and restarts modules according to the supplied configuration.
+func (m *moduleManager) reload(config *viper.Viper) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config = config
+	for _, key := range restartRequiredKeys {
+		if fmt.Sprint(m.config.Get(key)) != m.fixed[key] {
+			log.Warn().Str("key", key).Msg("Change to configuration requires a restart to take effect")
+		}
+	}
+
+	for _, mod := range m.modules {
+		enabled := m.config.GetBool(fmt.Sprintf("%s.enable", mod.name))
+		switch {
+		case mod.cancel != nil && !enabled:
+			log.Info().Str("module", mod.name).Msg("Stopping module")
+			m.stopModule(mod)
+		case mod.cancel == nil && enabled:
+			log.Info().Str("module", mod.name).Msg("Starting module")
+			if err := m.startModule(mod); err != nil {
+				log.Error().Str("module", mod.name).Err(err).Msg("Failed to start module")
+			}
+		case mod.cancel != nil && m.moduleAddress(mod) != mod.address:
+			log.Info().Str("module", mod.name).Str("address", m.moduleAddress(mod)).Msg("Restarting module with new address")
+			m.stopModule(mod)
+			if err := m.startModule(mod); err != nil {
+				log.Error().Str("module", mod.name).Err(err).Msg("Failed to restart module")
+			}
+		}
+	}
+}
+
+// startModule starts a module.
+// This assumes that the manager's lock is held.
+func (m *moduleManager) startModule(mod *module) error {
+	ctx, cancel := context.WithCancel(util.WithModule(m.ctx, mod.name))
+	if err := mod.start(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, fmt.Sprintf("failed to start %s", mod.name))
+	}
+	mod.cancel = cancel
+	mod.address = m.moduleAddress(mod)
+
+	return nil
+}
+
+// stopModule stops a module.
+// Transactions that are in progress when the module is stopped are allowed to complete.
+// This assumes that the manager's lock is held.
+func (m *moduleManager) stopModule(mod *module) {
+	mod.cancel()
+	mod.cancel = nil
+	mod.address = ""
+}
+
+// moduleAddress returns the address of the node used by the module.
+// This assumes that the manager's lock is held.
+func (m *moduleManager) moduleAddress(mod *module) string {
+	if mod.addressKey == "" {
+		return ""
+	}
+	if address := m.config.GetString(fmt.Sprintf("%s.address", mod.name)); address != "" {
+		return address
+	}
+	return m.config.GetString(mod.addressKey)
+}
@@ -0,0 +1,149 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/util"
+)
+
+// processKeys are top-level configuration keys that apply to the whole process, so cannot be set
+// for individual networks.
+var processKeys = map[string]bool{
+	"base-dir":             true,
+	"log-level":            true,
+	"log-file":             true,
+	"profile-address":      true,
+	"debug":                true,
+	"tracing-address":      true,
+	"tracing-sample-ratio": true,
+	"errors":               true,
+	"shutdown-timeout":     true,
+	"systemd":              true,
+	"metrics":              true,
+	"admin":                true,
+	"leader-election":      true,
+	"networks":             true,
+}
+
+// network is a chain indexed by chaind, with its own configuration and services.
+type network struct {
+	// name is the name of the network; empty if chaind is indexing a single network.
+	name   string
+	config *viper.Viper
+}
+
+// logger returns a logger that notes the network, if it has a name.
+func (n *network) logger() zerolog.Logger {
+	if n.name == "" {
+		return log
+	}
+	return log.With().Str("network", n.name).Logger()
+}
+
+// logLevel returns the log level for the path in the network's configuration.
+func (n *network) logLevel(path string) zerolog.Level {
+	return util.ConfigLogLevel(n.config, path)
+}
+
+// configuredNetworks returns the networks to index.
+// If no networks are configured then chaind indexes a single network with the top-level
+// configuration.  Otherwise each network's configuration is the top-level configuration
+// overridden by the network's own configuration.
+func configuredNetworks() ([]*network, error) {
+	if !viper.IsSet("networks") {
+		return []*network{{config: viper.GetViper()}}, nil
+	}
+
+	entries, err := cast.ToSliceE(viper.Get("networks"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid networks configuration")
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no networks configured")
+	}
+
+	networks := make([]*network, 0, len(entries))
+	for i, entry := range entries {
+		overrides, err := cast.ToStringMapE(entry)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid configuration for network %d", i))
+		}
+		name := cast.ToString(overrides["name"])
+		if name == "" {
+			return nil, fmt.Errorf("no name for network %d", i)
+		}
+		delete(overrides, "name")
+		for key := range overrides {
+			if processKeys[strings.ToLower(key)] {
+				return nil, fmt.Errorf("network %q cannot set process-wide configuration %q", name, key)
+			}
+		}
+
+		// Settings are obtained for each network, as merging modifies them.
+		base := viper.AllSettings()
+		delete(base, "networks")
+		config := viper.New()
+		if err := config.MergeConfigMap(base); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to apply configuration for network %q", name))
+		}
+		if err := config.MergeConfigMap(overrides); err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to apply configuration for network %q", name))
+		}
+		networks = append(networks, &network{
+			name:   name,
+			config: config,
+		})
+	}
+
+	if err := checkNetworks(networks); err != nil {
+		return nil, err
+	}
+
+	return networks, nil
+}
+
+// checkNetworks checks that networks do not conflict with each other.
+func checkNetworks(networks []*network) error {
+	names := make(map[string]bool)
+	databases := make(map[string]string)
+	healthAddresses := make(map[string]string)
+	for _, network := range networks {
+		if names[network.name] {
+			return fmt.Errorf("duplicate network %q", network.name)
+		}
+		names[network.name] = true
+
+		database := fmt.Sprintf("%s#%s", network.config.GetString("chaindb.url"), network.config.GetString("chaindb.schema"))
+		if existing, exists := databases[database]; exists {
+			return fmt.Errorf("networks %q and %q use the same database and schema", existing, network.name)
+		}
+		databases[database] = network.name
+
+		if address := network.config.GetString("health.listen-address"); address != "" {
+			if existing, exists := healthAddresses[address]; exists {
+				return fmt.Errorf("networks %q and %q use the same health listen address", existing, network.name)
+			}
+			healthAddresses[address] = network.name
+		}
+	}
+
+	return nil
+}
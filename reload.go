@@ -0,0 +1,70 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/spf13/viper"
+)
+
+// reload reloads the configuration, applying changes that can be made without restarting.
+// This covers log levels, enabling and disabling modules, and the beacon node used by modules.
+// Modules keep track of their own progress, so restarting a module does not lose data.
+func reload(running []*runningServices) {
+	log.Info().Msg("Reloading configuration")
+
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			log.Error().Err(err).Msg("Failed to read configuration file; configuration not reloaded")
+			return
+		}
+	}
+
+	networks, err := configuredNetworks()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain networks; configuration not reloaded")
+		return
+	}
+
+	reloadLogLevels()
+
+	configs := make(map[string]*viper.Viper, len(networks))
+	for _, network := range networks {
+		configs[network.name] = network.config
+	}
+	for _, services := range running {
+		config, exists := configs[services.network.name]
+		if !exists {
+			log.Warn().Str("network", services.network.name).Msg("Removal of network requires a restart to take effect")
+			continue
+		}
+		delete(configs, services.network.name)
+		services.network.config = config
+		reloadNetworkLogLevels(services.network)
+
+		if services.modules != nil {
+			services.modules.reload(config)
+		}
+
+		if services.health != nil {
+			if err := services.health.SetModules(healthModules(config)); err != nil {
+				log.Error().Err(err).Msg("Failed to set health modules")
+			}
+		}
+	}
+	for name := range configs {
+		log.Warn().Str("network", name).Msg("Addition of network requires a restart to take effect")
+	}
+
+	log.Info().Msg("Reloaded configuration")
+}
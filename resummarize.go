@@ -0,0 +1,165 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/summarizer"
+	"github.com/wealdtech/chaind/util"
+)
+
+// resummarizeDatabase regenerates the summaries for a range of epochs or days for each network.
+func resummarizeDatabase(ctx context.Context, monitor metrics.Service) error {
+	networks, err := configuredNetworks()
+	if err != nil {
+		return err
+	}
+
+	for _, network := range networks {
+		prefix := ""
+		if network.name != "" {
+			prefix = network.name + "/"
+		}
+		if err := resummarizeNetwork(util.WithModule(ctx, "resummarize"), prefix, network, monitor); err != nil {
+			if network.name != "" {
+				return errors.Wrap(err, fmt.Sprintf("failed to resummarize network %q", network.name))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resummarizeNetwork regenerates the summaries for a single network.
+func resummarizeNetwork(ctx context.Context, prefix string, network *network, monitor metrics.Service) error {
+	config := network.config
+	if !config.GetBool("summarizer.enable") {
+		return errors.New("summarizer is not enabled")
+	}
+
+	cacheSvc, err := startCache(ctx, network)
+	if err != nil {
+		return errors.Wrap(err, "failed to start cache service")
+	}
+	chainDB, err := startDatabase(ctx, network, cacheSvc, monitor)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaCurrent(ctx, chainDB); err != nil {
+		return err
+	}
+
+	eth2Client, err := fetchClient(ctx, network, config.GetString("eth2client.address"), monitor)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("eth2client.address")))
+	}
+
+	genesisProvider, err := fetchGenesisProvider(ctx, network, eth2Client)
+	if err != nil {
+		return err
+	}
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(network.logLevel("chaintime")),
+		standardchaintime.WithNetwork(network.name),
+		standardchaintime.WithGenesisTimeProvider(genesisProvider),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+		standardchaintime.WithStoredForkScheduleProvider(chainDB.(chaindb.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start chain time service")
+	}
+
+	startEpoch, endEpoch, err := resummarizeRange(config, chainTime)
+	if err != nil {
+		return err
+	}
+
+	summarizerSvc, err := startSummarizer(ctx, network, eth2Client, chainDB, chainTime, monitor)
+	if err != nil {
+		return err
+	}
+	resummarizer, isResummarizer := summarizerSvc.(summarizer.Resummarizer)
+	if !isResummarizer {
+		return errors.New("summarizer does not support resummarizing")
+	}
+
+	fmt.Printf("Resummarizing %sepochs %d to %d\n", prefix, startEpoch, endEpoch)
+	if err := resummarizer.Resummarize(ctx, startEpoch, endEpoch); err != nil {
+		return err
+	}
+	fmt.Printf("Resummarized %sepochs %d to %d\n", prefix, startEpoch, endEpoch)
+
+	return nil
+}
+
+// resummarizeRange obtains the range of epochs to resummarize from the configuration.
+// The range can be supplied as epochs or as UTC days, in which case it covers the epochs
+// that start in the days.
+func resummarizeRange(config *viper.Viper, chainTime chaintime.Service) (phase0.Epoch, phase0.Epoch, error) {
+	var startEpoch phase0.Epoch
+	startDay := config.GetString("resummarize.start-day")
+	switch {
+	case config.GetInt64("resummarize.start-epoch") >= 0 && startDay != "":
+		return 0, 0, errors.New("only one of resummarize.start-epoch and resummarize.start-day can be supplied")
+	case config.GetInt64("resummarize.start-epoch") >= 0:
+		startEpoch = phase0.Epoch(config.GetInt64("resummarize.start-epoch"))
+	case startDay != "":
+		day, err := time.Parse("2006-01-02", startDay)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "invalid resummarize.start-day")
+		}
+		startEpoch = chainTime.TimestampToEpoch(day)
+		if chainTime.StartOfEpoch(startEpoch).Before(day) {
+			startEpoch++
+		}
+	default:
+		return 0, 0, errors.New("one of resummarize.start-epoch or resummarize.start-day is required")
+	}
+
+	var endEpoch phase0.Epoch
+	endDay := config.GetString("resummarize.end-day")
+	switch {
+	case config.GetInt64("resummarize.end-epoch") >= 0 && endDay != "":
+		return 0, 0, errors.New("only one of resummarize.end-epoch and resummarize.end-day can be supplied")
+	case config.GetInt64("resummarize.end-epoch") >= 0:
+		endEpoch = phase0.Epoch(config.GetInt64("resummarize.end-epoch"))
+	case endDay != "":
+		day, err := time.Parse("2006-01-02", endDay)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "invalid resummarize.end-day")
+		}
+		endEpoch = chainTime.TimestampToEpoch(day.Add(24*time.Hour - time.Nanosecond))
+	default:
+		return 0, 0, errors.New("one of resummarize.end-epoch or resummarize.end-day is required")
+	}
+
+	if endEpoch < startEpoch {
+		return 0, 0, errors.New("end of range is before start of range")
+	}
+
+	return startEpoch, endEpoch, nil
+}
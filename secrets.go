@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/services/secrets/awssecretsmanager"
+	envfilesecrets "github.com/wealdtech/chaind/services/secrets/envfile"
+	standardsecrets "github.com/wealdtech/chaind/services/secrets/standard"
+	vaultsecrets "github.com/wealdtech/chaind/services/secrets/vault"
+	"github.com/wealdtech/chaind/util"
+)
+
+// secretsService resolves configuration values that refer to secrets.
+var secretsService secrets.Service
+
+// startSecrets starts the secrets service with the configured providers.
+func startSecrets(ctx context.Context) error {
+	providers := make([]secrets.Provider, 0)
+
+	if viper.GetString("secrets.envfile.path") != "" {
+		provider, err := envfilesecrets.New(ctx,
+			envfilesecrets.WithLogLevel(util.LogLevel("secrets.envfile")),
+			envfilesecrets.WithPath(resolvePath(viper.GetString("secrets.envfile.path"))),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to start environment file secrets provider")
+		}
+		providers = append(providers, provider)
+	}
+
+	if viper.GetString("secrets.vault.address") != "" {
+		provider, err := vaultsecrets.New(ctx,
+			vaultsecrets.WithLogLevel(util.LogLevel("secrets.vault")),
+			vaultsecrets.WithAddress(viper.GetString("secrets.vault.address")),
+			vaultsecrets.WithToken(viper.GetString("secrets.vault.token")),
+			vaultsecrets.WithTokenFile(viper.GetString("secrets.vault.token-file")),
+			vaultsecrets.WithNamespace(viper.GetString("secrets.vault.namespace")),
+			vaultsecrets.WithTimeout(viper.GetDuration("secrets.vault.timeout")),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to start Vault secrets provider")
+		}
+		providers = append(providers, provider)
+	}
+
+	if viper.GetBool("secrets.aws.enable") {
+		provider, err := awssecretsmanager.New(ctx,
+			awssecretsmanager.WithLogLevel(util.LogLevel("secrets.aws")),
+			awssecretsmanager.WithRegion(viper.GetString("secrets.aws.region")),
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to start AWS Secrets Manager secrets provider")
+		}
+		providers = append(providers, provider)
+	}
+
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialise scheduler")
+	}
+
+	secretsService, err = standardsecrets.New(ctx,
+		standardsecrets.WithLogLevel(util.LogLevel("secrets")),
+		standardsecrets.WithScheduler(scheduler),
+		standardsecrets.WithProviders(providers),
+		standardsecrets.WithRefreshInterval(viper.GetDuration("secrets.refresh-interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to start secrets service")
+	}
+
+	return nil
+}
+
+// resolveSecret resolves a configuration value that may refer to a secret.
+func resolveSecret(ctx context.Context, value string) (string, error) {
+	if secretsService == nil {
+		return value, nil
+	}
+
+	return secretsService.Resolve(ctx, value)
+}
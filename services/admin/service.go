@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Module is a module that can be controlled through the admin service.
+type Module interface {
+	// Pause pauses the module.  Activity that is in progress is allowed to complete.
+	Pause()
+
+	// Resume resumes the module.
+	Resume()
+
+	// Paused returns true if the module is paused.
+	Paused() bool
+
+	// Trigger runs the module's activity once, regardless of whether the module is paused.
+	Trigger(ctx context.Context) error
+}
+
+// EpochReindexer is a module that can reindex the data for an epoch.
+type EpochReindexer interface {
+	// ReindexEpoch replaces the data held for an epoch with freshly fetched data.
+	ReindexEpoch(ctx context.Context, epoch phase0.Epoch) error
+}
+
+// ModuleState is the state of a module.
+type ModuleState struct {
+	Paused bool `json:"paused"`
+}
+
+// Service is the interface for an admin service.
+type Service interface {
+	// LogLevels returns the log levels of services, keyed by service name.
+	LogLevels(ctx context.Context) map[string]string
+
+	// SetLogLevel sets the log level of a service.
+	SetLogLevel(ctx context.Context, service string, level string) error
+
+	// RegisterModule registers a module so that it can be controlled.
+	RegisterModule(name string, module Module)
+
+	// DeregisterModule deregisters a module, if it is the module registered with the given name.
+	DeregisterModule(name string, module Module)
+
+	// ModuleStates returns the states of modules, keyed by module name.
+	ModuleStates(ctx context.Context) map[string]*ModuleState
+
+	// PauseModule pauses a module.
+	PauseModule(ctx context.Context, name string) error
+
+	// ResumeModule resumes a module.
+	ResumeModule(ctx context.Context, name string) error
+
+	// TriggerModule starts a single run of a module's activity.
+	TriggerModule(ctx context.Context, name string) error
+
+	// ReindexModuleEpoch reindexes the data for an epoch using a module.
+	ReindexModuleEpoch(ctx context.Context, name string, epoch phase0.Epoch) error
+}
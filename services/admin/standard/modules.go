@@ -0,0 +1,239 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/util"
+)
+
+// ErrUnknownModule is returned when an attempt is made to act on a module that is not registered.
+var ErrUnknownModule = errors.New("unknown module")
+
+// ErrReindexNotSupported is returned when an attempt is made to reindex with a module that does not support it.
+var ErrReindexNotSupported = errors.New("module does not support reindexing")
+
+// modulesPath is the path under which modules are controlled.
+const modulesPath = "/modules"
+
+// RegisterModule registers a module so that it can be controlled.
+func (s *Service) RegisterModule(name string, module admin.Module) {
+	s.modulesMu.Lock()
+	s.modules[name] = module
+	s.modulesMu.Unlock()
+	log.Trace().Str("module", name).Msg("Registered module")
+}
+
+// DeregisterModule deregisters a module, if it is the module registered with the given name.
+func (s *Service) DeregisterModule(name string, module admin.Module) {
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	if s.modules[name] == module {
+		delete(s.modules, name)
+		log.Trace().Str("module", name).Msg("Deregistered module")
+	}
+}
+
+// ModuleStates returns the states of modules, keyed by module name.
+func (s *Service) ModuleStates(_ context.Context) map[string]*admin.ModuleState {
+	s.modulesMu.RLock()
+	defer s.modulesMu.RUnlock()
+
+	states := make(map[string]*admin.ModuleState, len(s.modules))
+	for name, module := range s.modules {
+		states[name] = &admin.ModuleState{
+			Paused: module.Paused(),
+		}
+	}
+	return states
+}
+
+// PauseModule pauses a module.
+func (s *Service) PauseModule(_ context.Context, name string) error {
+	module, err := s.module(name)
+	if err != nil {
+		return err
+	}
+	module.Pause()
+	log.Info().Str("module", name).Msg("Paused module")
+
+	return nil
+}
+
+// ResumeModule resumes a module.
+func (s *Service) ResumeModule(_ context.Context, name string) error {
+	module, err := s.module(name)
+	if err != nil {
+		return err
+	}
+	module.Resume()
+	log.Info().Str("module", name).Msg("Resumed module")
+
+	return nil
+}
+
+// TriggerModule starts a single run of a module's activity.
+// The run takes place in the background, and continues after the request has completed.
+func (s *Service) TriggerModule(_ context.Context, name string) error {
+	module, err := s.module(name)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("module", name).Msg("Triggering module")
+	go func() {
+		if err := module.Trigger(util.WithoutCancel(s.ctx)); err != nil {
+			log.Warn().Str("module", name).Err(err).Msg("Triggered run of module failed")
+			return
+		}
+		log.Info().Str("module", name).Msg("Triggered run of module complete")
+	}()
+
+	return nil
+}
+
+// ReindexModuleEpoch reindexes the data for an epoch using a module.
+// The reindex takes place synchronously.
+func (s *Service) ReindexModuleEpoch(ctx context.Context, name string, epoch phase0.Epoch) error {
+	module, err := s.module(name)
+	if err != nil {
+		return err
+	}
+	reindexer, isReindexer := module.(admin.EpochReindexer)
+	if !isReindexer {
+		return errors.Wrap(ErrReindexNotSupported, name)
+	}
+	log.Info().Str("module", name).Uint64("epoch", uint64(epoch)).Msg("Reindexing epoch")
+	if err := reindexer.ReindexEpoch(ctx, epoch); err != nil {
+		return err
+	}
+	log.Info().Str("module", name).Uint64("epoch", uint64(epoch)).Msg("Reindexed epoch")
+
+	return nil
+}
+
+// module returns the module with the given name.
+func (s *Service) module(name string) (admin.Module, error) {
+	s.modulesMu.RLock()
+	defer s.modulesMu.RUnlock()
+
+	module, exists := s.modules[name]
+	if !exists {
+		return nil, errors.Wrap(ErrUnknownModule, name)
+	}
+	return module, nil
+}
+
+// moduleActions are the actions that can be carried out on a module.
+var moduleActions = map[string]func(*Service, context.Context, string) error{
+	"pause":   (*Service).PauseModule,
+	"resume":  (*Service).ResumeModule,
+	"trigger": (*Service).TriggerModule,
+}
+
+// handleModules handles requests for the states of all modules.
+func (s *Service) handleModules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.ModuleStates(r.Context()))
+}
+
+// handleModule handles requests for the state of a single module, and actions on the module.
+// Module names can contain '/', so actions are identified by the final element of the path.
+func (s *Service) handleModule(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, modulesPath+"/")
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		state, exists := s.ModuleStates(r.Context())[path]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	case http.MethodPost:
+		if !permitted(w, r, RoleOperator) {
+			return
+		}
+		lastSlash := strings.LastIndex(path, "/")
+		if lastSlash == -1 {
+			http.NotFound(w, r)
+			return
+		}
+		name := path[:lastSlash]
+		if path[lastSlash+1:] == "reindex" {
+			s.handleReindex(w, r, name)
+			return
+		}
+		action, exists := moduleActions[path[lastSlash+1:]]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		if err := action(s, r.Context(), name); err != nil {
+			if errors.Is(err, ErrUnknownModule) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		status := http.StatusOK
+		if path[lastSlash+1:] == "trigger" {
+			status = http.StatusAccepted
+		}
+		writeJSON(w, status, s.ModuleStates(r.Context())[name])
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPost}, ", "))
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleReindex handles a request to reindex an epoch using a module.
+func (s *Service) handleReindex(w http.ResponseWriter, r *http.Request, name string) {
+	if !permitted(w, r, RoleAdmin) {
+		return
+	}
+	epoch, err := strconv.ParseUint(r.URL.Query().Get("epoch"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid epoch", http.StatusBadRequest)
+		return
+	}
+	if err := s.ReindexModuleEpoch(r.Context(), name, phase0.Epoch(epoch)); err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownModule):
+			http.NotFound(w, r)
+		case errors.Is(err, ErrReindexNotSupported):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, s.ModuleStates(r.Context())[name])
+}
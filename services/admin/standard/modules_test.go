@@ -0,0 +1,321 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin/standard"
+	"go.uber.org/atomic"
+)
+
+// testModule is a module that records the actions carried out on it.
+type testModule struct {
+	paused    atomic.Bool
+	triggered chan struct{}
+}
+
+func newTestModule() *testModule {
+	return &testModule{
+		triggered: make(chan struct{}, 1),
+	}
+}
+
+func (m *testModule) Pause()       { m.paused.Store(true) }
+func (m *testModule) Resume()      { m.paused.Store(false) }
+func (m *testModule) Paused() bool { return m.paused.Load() }
+func (m *testModule) Trigger(_ context.Context) error {
+	m.triggered <- struct{}{}
+	return nil
+}
+
+// testReindexModule is a module that records the epochs it reindexes.
+type testReindexModule struct {
+	*testModule
+	epochs []phase0.Epoch
+}
+
+func (m *testReindexModule) ReindexEpoch(_ context.Context, epoch phase0.Epoch) error {
+	if epoch > 100 {
+		return errors.New("epoch has not been finalized")
+	}
+	m.epochs = append(m.epochs, epoch)
+	return nil
+}
+
+func TestModules(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress("127.0.0.1:0"),
+	)
+	require.NoError(t, err)
+
+	module := newTestModule()
+	s.RegisterModule("test", module)
+	require.False(t, s.ModuleStates(ctx)["test"].Paused)
+
+	require.NoError(t, s.PauseModule(ctx, "test"))
+	require.True(t, s.ModuleStates(ctx)["test"].Paused)
+	require.NoError(t, s.ResumeModule(ctx, "test"))
+	require.False(t, s.ModuleStates(ctx)["test"].Paused)
+
+	require.NoError(t, s.TriggerModule(ctx, "test"))
+	select {
+	case <-module.triggered:
+	case <-time.After(time.Second):
+		require.Fail(t, "module not triggered")
+	}
+
+	require.EqualError(t, s.PauseModule(ctx, "unknown"), "unknown: unknown module")
+	require.EqualError(t, s.ReindexModuleEpoch(ctx, "test", 1), "test: module does not support reindexing")
+
+	reindexModule := &testReindexModule{testModule: newTestModule()}
+	s.RegisterModule("reindex", reindexModule)
+	require.NoError(t, s.ReindexModuleEpoch(ctx, "reindex", 1))
+	require.EqualError(t, s.ReindexModuleEpoch(ctx, "reindex", 200), "epoch has not been finalized")
+	require.Equal(t, []phase0.Epoch{1}, reindexModule.epochs)
+
+	// Deregistering a different module with the same name has no effect.
+	s.DeregisterModule("test", newTestModule())
+	require.Contains(t, s.ModuleStates(ctx), "test")
+	s.DeregisterModule("test", module)
+	require.NotContains(t, s.ModuleStates(ctx), "test")
+}
+
+func TestModulesHTTP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Obtain a free port.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress(address),
+		standard.WithToken("secret"),
+	)
+	require.NoError(t, err)
+	module := newTestModule()
+	s.RegisterModule("mainnet/finalizer", module)
+	reindexModule := &testReindexModule{testModule: newTestModule()}
+	s.RegisterModule("mainnet/reindexer", reindexModule)
+
+	base := fmt.Sprintf("http://%s/modules", address)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// Wait for the server to start.
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		conn, err = net.Dial("tcp", address)
+		if err == nil {
+			require.NoError(t, conn.Close())
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+		paused bool
+	}{
+		{
+			name:   "NoToken",
+			method: http.MethodGet,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "BadToken",
+			method: http.MethodGet,
+			token:  "bad",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "List",
+			method: http.MethodGet,
+			token:  "secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Get",
+			method: http.MethodGet,
+			path:   "/mainnet/finalizer",
+			token:  "secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "GetUnknown",
+			method: http.MethodGet,
+			path:   "/unknown",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Pause",
+			method: http.MethodPost,
+			path:   "/mainnet/finalizer/pause",
+			token:  "secret",
+			status: http.StatusOK,
+			paused: true,
+		},
+		{
+			name:   "Trigger",
+			method: http.MethodPost,
+			path:   "/mainnet/finalizer/trigger",
+			token:  "secret",
+			status: http.StatusAccepted,
+			paused: true,
+		},
+		{
+			name:   "Resume",
+			method: http.MethodPost,
+			path:   "/mainnet/finalizer/resume",
+			token:  "secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "UnknownAction",
+			method: http.MethodPost,
+			path:   "/mainnet/finalizer/stop",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "UnknownModule",
+			method: http.MethodPost,
+			path:   "/unknown/pause",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Reindex",
+			method: http.MethodPost,
+			path:   "/mainnet/reindexer/reindex?epoch=5",
+			token:  "secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "ReindexInvalidEpoch",
+			method: http.MethodPost,
+			path:   "/mainnet/reindexer/reindex?epoch=five",
+			token:  "secret",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ReindexFailed",
+			method: http.MethodPost,
+			path:   "/mainnet/reindexer/reindex?epoch=500",
+			token:  "secret",
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "ReindexNotSupported",
+			method: http.MethodPost,
+			path:   "/mainnet/finalizer/reindex?epoch=5",
+			token:  "secret",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ReindexUnknownModule",
+			method: http.MethodPost,
+			path:   "/unknown/reindex?epoch=5",
+			token:  "secret",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Delete",
+			method: http.MethodDelete,
+			path:   "/mainnet/finalizer",
+			token:  "secret",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, test.method, base+test.path, nil)
+			require.NoError(t, err)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.status == http.StatusOK || test.status == http.StatusAccepted {
+				require.Equal(t, test.paused, module.Paused())
+			}
+		})
+	}
+
+	select {
+	case <-module.triggered:
+	case <-time.After(time.Second):
+		require.Fail(t, "module not triggered")
+	}
+	require.Equal(t, []phase0.Epoch{5}, reindexModule.epochs)
+}
+
+func TestModulesHTTPNoToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress(address),
+	)
+	require.NoError(t, err)
+	module := newTestModule()
+	s.RegisterModule("finalizer", module)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	url := fmt.Sprintf("http://%s/modules/finalizer/pause", address)
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = client.Post(url, "application/json", nil)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	// Module control is not available without a token.
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.False(t, module.Paused())
+}
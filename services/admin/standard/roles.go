@@ -0,0 +1,106 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Role is the role of a token, which decides the requests that it can make.
+// Each role can make the requests of the roles below it.
+type Role int
+
+const (
+	// RoleReadOnly can view log levels and the states of modules.
+	RoleReadOnly Role = iota + 1
+	// RoleOperator can also change log levels, and pause, resume and trigger modules.
+	RoleOperator
+	// RoleAdmin can also reindex data.
+	RoleAdmin
+)
+
+var roleStrings = map[Role]string{
+	RoleReadOnly: "read-only",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+// String returns the string representation of the role.
+func (r Role) String() string {
+	if s, exists := roleStrings[r]; exists {
+		return s
+	}
+	return "unknown"
+}
+
+// ParseRole parses a role from its string representation.
+func ParseRole(input string) (Role, error) {
+	for role, s := range roleStrings {
+		if strings.EqualFold(input, s) {
+			return role, nil
+		}
+	}
+	return 0, errors.Errorf("unknown role %q", input)
+}
+
+type roleContextKey struct{}
+
+// authenticate requires requests to supply a known bearer token, if any are configured,
+// and records the role of the token with the request.
+// If no tokens are configured then requests are treated as read-only, as anyone that can
+// reach the admin API could otherwise change the behaviour of the process.
+func (s *Service) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := RoleReadOnly
+		if len(s.tokens) > 0 {
+			var authenticated bool
+			role, authenticated = s.tokenRole(r)
+			if !authenticated {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), roleContextKey{}, role)))
+	})
+}
+
+// tokenRole returns the role of the bearer token supplied by the request.
+// All tokens are checked, so that the time taken does not reveal which token matched.
+func (s *Service) tokenRole(r *http.Request) (Role, bool) {
+	token := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	var matched Role
+	for candidate, role := range s.tokens {
+		if subtle.ConstantTimeCompare(token, []byte(candidate)) == 1 {
+			matched = role
+		}
+	}
+	return matched, matched != 0
+}
+
+// permitted returns true if the request has at least the given role.
+// If it does not, a forbidden response is written.
+func permitted(w http.ResponseWriter, r *http.Request, required Role) bool {
+	role, _ := r.Context().Value(roleContextKey{}).(Role)
+	if role < required {
+		http.Error(w, "requires the "+required.String()+" role", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,283 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		input string
+		role  standard.Role
+		err   string
+	}{
+		{
+			input: "read-only",
+			role:  standard.RoleReadOnly,
+		},
+		{
+			input: "Operator",
+			role:  standard.RoleOperator,
+		},
+		{
+			input: "admin",
+			role:  standard.RoleAdmin,
+		},
+		{
+			input: "root",
+			err:   `unknown role "root"`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			role, err := standard.ParseRole(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.role, role)
+			}
+		})
+	}
+}
+
+func TestRolesHTTP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress(address),
+		standard.WithToken("admin-secret"),
+		standard.WithTokens(map[string]standard.Role{
+			"reader-secret":   standard.RoleReadOnly,
+			"operator-secret": standard.RoleOperator,
+		}),
+	)
+	require.NoError(t, err)
+	module := newTestModule()
+	s.RegisterModule("finalizer", module)
+	reindexModule := &testReindexModule{testModule: newTestModule()}
+	s.RegisterModule("reindexer", reindexModule)
+	util.ServiceLogger("testroleshttp", "standard", zerolog.InfoLevel)
+
+	base := fmt.Sprintf("http://%s", address)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// Wait for the server to start.
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		conn, err = net.Dial("tcp", address)
+		if err == nil {
+			require.NoError(t, conn.Close())
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		token  string
+		status int
+	}{
+		{
+			name:   "ReaderGetLogLevels",
+			method: http.MethodGet,
+			path:   "/loglevels",
+			token:  "reader-secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "ReaderGetModules",
+			method: http.MethodGet,
+			path:   "/modules",
+			token:  "reader-secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "ReaderSetLogLevel",
+			method: http.MethodPut,
+			path:   "/loglevels/testroleshttp",
+			body:   `{"level":"debug"}`,
+			token:  "reader-secret",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "ReaderPause",
+			method: http.MethodPost,
+			path:   "/modules/finalizer/pause",
+			token:  "reader-secret",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "OperatorSetLogLevel",
+			method: http.MethodPut,
+			path:   "/loglevels/testroleshttp",
+			body:   `{"level":"debug"}`,
+			token:  "operator-secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "OperatorPause",
+			method: http.MethodPost,
+			path:   "/modules/finalizer/pause",
+			token:  "operator-secret",
+			status: http.StatusOK,
+		},
+		{
+			name:   "OperatorReindex",
+			method: http.MethodPost,
+			path:   "/modules/reindexer/reindex?epoch=5",
+			token:  "operator-secret",
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "AdminReindex",
+			method: http.MethodPost,
+			path:   "/modules/reindexer/reindex?epoch=5",
+			token:  "admin-secret",
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = bytes.NewBufferString(test.body)
+			}
+			req, err := http.NewRequestWithContext(ctx, test.method, base+test.path, body)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+
+	require.True(t, module.Paused())
+	require.Equal(t, zerolog.DebugLevel, util.ServiceLogLevels()["testroleshttp"])
+	require.Len(t, reindexModule.epochs, 1)
+}
+
+func TestRolesHTTPNoToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress(address),
+	)
+	require.NoError(t, err)
+	module := newTestModule()
+	s.RegisterModule("finalizer", module)
+	util.ServiceLogger("testrolesnotoken", "standard", zerolog.InfoLevel)
+
+	base := fmt.Sprintf("http://%s", address)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// Wait for the server to start.
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		conn, err = net.Dial("tcp", address)
+		if err == nil {
+			require.NoError(t, conn.Close())
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+
+	// Without tokens requests are read-only.
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{
+			name:   "GetLogLevels",
+			method: http.MethodGet,
+			path:   "/loglevels",
+			status: http.StatusOK,
+		},
+		{
+			name:   "GetModules",
+			method: http.MethodGet,
+			path:   "/modules",
+			status: http.StatusOK,
+		},
+		{
+			name:   "SetLogLevel",
+			method: http.MethodPut,
+			path:   "/loglevels/testrolesnotoken",
+			body:   `{"level":"debug"}`,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Pause",
+			method: http.MethodPost,
+			path:   "/modules/finalizer/pause",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = bytes.NewBufferString(test.body)
+			}
+			req, err := http.NewRequestWithContext(ctx, test.method, base+test.path, body)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+
+	require.False(t, module.Paused())
+	require.Equal(t, zerolog.InfoLevel, util.ServiceLogLevels()["testrolesnotoken"])
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an admin service.
+type Service struct {
+	ctx       context.Context
+	server    *http.Server
+	tokens    map[string]Role
+	modulesMu sync.RWMutex
+	modules   map[string]admin.Module
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// ErrUnknownService is returned when an attempt is made to act on a service that is not running.
+var ErrUnknownService = errors.New("unknown service")
+
+// logLevelsPath is the path under which log levels are served.
+const logLevelsPath = "/loglevels"
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("admin", "standard", parameters.logLevel)
+
+	s := &Service{
+		ctx:     ctx,
+		tokens:  make(map[string]Role, len(parameters.tokens)+1),
+		modules: make(map[string]admin.Module),
+	}
+	for token, role := range parameters.tokens {
+		s.tokens[token] = role
+	}
+	if parameters.token != "" {
+		s.tokens[parameters.token] = RoleAdmin
+	}
+	if len(s.tokens) == 0 {
+		log.Warn().Msg("No admin tokens supplied; admin API is read-only")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(logLevelsPath, s.handleLogLevels)
+	mux.HandleFunc(logLevelsPath+"/", s.handleLogLevel)
+	mux.HandleFunc(modulesPath, s.handleModules)
+	mux.HandleFunc(modulesPath+"/", s.handleModule)
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           s.authenticate(mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run admin server")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		if err := s.server.Close(); err != nil {
+			log.Debug().Err(err).Msg("Failed to close admin server")
+		}
+	}()
+
+	return s, nil
+}
+
+// LogLevels returns the log levels of services, keyed by service name.
+func (*Service) LogLevels(_ context.Context) map[string]string {
+	levels := make(map[string]string)
+	for service, level := range util.ServiceLogLevels() {
+		levels[service] = level.String()
+	}
+	return levels
+}
+
+// SetLogLevel sets the log level of a service.
+func (*Service) SetLogLevel(_ context.Context, service string, level string) error {
+	logLevel, err := util.ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	if !util.SetServiceLogLevel(service, logLevel) {
+		return errors.Wrap(ErrUnknownService, service)
+	}
+	log.Info().Str("target", service).Str("level", logLevel.String()).Msg("Set log level")
+
+	return nil
+}
+
+// logLevelRequest is the body of a request to set a log level.
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// handleLogLevels handles requests for the log levels of all services.
+func (s *Service) handleLogLevels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.LogLevels(r.Context()))
+}
+
+// handleLogLevel handles requests for the log level of a single service.
+func (s *Service) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	// Services for a named network are addressed as <network>/<service>.
+	service := strings.TrimPrefix(r.URL.Path, logLevelsPath+"/")
+	if service == "" || strings.Count(service, "/") > 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		level, exists := s.LogLevels(r.Context())[service]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, &logLevelRequest{Level: level})
+	case http.MethodPut:
+		if !permitted(w, r, RoleOperator) {
+			return
+		}
+		req := &logLevelRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := s.SetLogLevel(r.Context(), service, req.Level); err != nil {
+			if errors.Is(err, ErrUnknownService) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, &logLevelRequest{Level: s.LogLevels(r.Context())[service]})
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPut}, ", "))
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		log.Debug().Err(err).Msg("Failed to write response")
+	}
+}
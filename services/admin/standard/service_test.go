@@ -0,0 +1,240 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ListenAddressMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no listen address specified",
+		},
+		{
+			name: "TokenEmpty",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithTokens(map[string]standard.Role{"": standard.RoleReadOnly}),
+			},
+			err: "problem with parameters: empty token specified",
+		},
+		{
+			name: "RoleInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithTokens(map[string]standard.Role{"secret": standard.Role(0)}),
+			},
+			err: "problem with parameters: invalid role specified",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress("127.0.0.1:0"),
+	)
+	require.NoError(t, err)
+
+	util.ServiceLogger("testsetloglevel", "standard", zerolog.InfoLevel)
+	require.Equal(t, "info", s.LogLevels(ctx)["testsetloglevel"])
+
+	require.NoError(t, s.SetLogLevel(ctx, "testsetloglevel", "debug"))
+	require.Equal(t, "debug", s.LogLevels(ctx)["testsetloglevel"])
+
+	require.EqualError(t, s.SetLogLevel(ctx, "testsetloglevel", "bad"), `unknown log level "bad"`)
+	require.EqualError(t, s.SetLogLevel(ctx, "unknown", "debug"), "unknown: unknown service")
+}
+
+func TestHTTP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Obtain a free port.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithListenAddress(address),
+		standard.WithToken("secret"),
+	)
+	require.NoError(t, err)
+	util.ServiceLogger("testhttp", "standard", zerolog.InfoLevel)
+	util.NetworkServiceLogger("testnet", "testhttp", "standard", zerolog.WarnLevel)
+
+	base := fmt.Sprintf("http://%s/loglevels", address)
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	// Wait for the server to start.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret")
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = client.Do(req)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	levels := make(map[string]string)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&levels))
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "info", levels["testhttp"])
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		level  string
+	}{
+		{
+			name:   "Get",
+			method: http.MethodGet,
+			path:   "/testhttp",
+			status: http.StatusOK,
+			level:  "info",
+		},
+		{
+			name:   "GetNetwork",
+			method: http.MethodGet,
+			path:   "/testnet/testhttp",
+			status: http.StatusOK,
+			level:  "warn",
+		},
+		{
+			name:   "GetUnknown",
+			method: http.MethodGet,
+			path:   "/unknown",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "GetTooDeep",
+			method: http.MethodGet,
+			path:   "/testnet/testhttp/extra",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "PutBadBody",
+			method: http.MethodPut,
+			path:   "/testhttp",
+			body:   "bad",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "PutBadLevel",
+			method: http.MethodPut,
+			path:   "/testhttp",
+			body:   `{"level":"bad"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "PutUnknown",
+			method: http.MethodPut,
+			path:   "/unknown",
+			body:   `{"level":"debug"}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "Put",
+			method: http.MethodPut,
+			path:   "/testhttp",
+			body:   `{"level":"trace"}`,
+			status: http.StatusOK,
+			level:  "trace",
+		},
+		{
+			name:   "Delete",
+			method: http.MethodDelete,
+			path:   "/testhttp",
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body io.Reader
+			if test.body != "" {
+				body = bytes.NewBufferString(test.body)
+			}
+			req, err := http.NewRequestWithContext(ctx, test.method, base+test.path, body)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer secret")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.level != "" {
+				res := make(map[string]string)
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+				require.Equal(t, test.level, res["level"])
+			}
+		})
+	}
+	require.Equal(t, zerolog.TraceLevel, util.ServiceLogLevels()["testhttp"])
+	require.Equal(t, zerolog.WarnLevel, util.ServiceLogLevels()["testnet/testhttp"])
+}
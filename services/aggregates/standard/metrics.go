@@ -0,0 +1,83 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_aggregates"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	latestEpoch *prometheus.GaugeVec
+	updates     *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestEpoch = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch added to each aggregate",
+	}, []string{"aggregate"})
+	if err := registerer.Register(m.latestEpoch); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	m.updates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "updates_total",
+		Help:      "Number of aggregate updates",
+	}, []string{"aggregate", "result"})
+	if err := registerer.Register(m.updates); err != nil {
+		return nil, errors.Wrap(err, "failed to register updates_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorAggregateUpdated(aggregate string, succeeded bool) {
+	if s.metrics.updates != nil {
+		if succeeded {
+			s.metrics.updates.WithLabelValues(aggregate, "succeeded").Inc()
+		} else {
+			s.metrics.updates.WithLabelValues(aggregate, "failed").Inc()
+		}
+	}
+}
+
+func (s *Service) monitorEpochProcessed(aggregate string, epoch phase0.Epoch) {
+	if s.metrics.latestEpoch != nil {
+		s.metrics.latestEpoch.WithLabelValues(aggregate).Set(float64(epoch))
+	}
+}
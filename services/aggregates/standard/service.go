@@ -0,0 +1,167 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// updateEpochs is the maximum number of epochs added to an aggregate in each transaction,
+// to bound the size of transactions when catching up.
+const updateEpochs = 32
+
+// Service is an aggregates service.
+type Service struct {
+	log               zerolog.Logger
+	metrics           *serviceMetrics
+	chainDB           chaindb.Service
+	aggregatesUpdater chaindb.AggregatesUpdater
+	chainTime         chaintime.Service
+	activitySem       *semaphore.Weighted
+	md                *metadata
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "aggregates", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	aggregatesUpdater, isAggregatesUpdater := parameters.chainDB.(chaindb.AggregatesUpdater)
+	if !isAggregatesUpdater {
+		return nil, errors.New("chain DB does not support aggregate updating")
+	}
+
+	s := &Service{
+		log:               log,
+		metrics:           svcMetrics,
+		chainDB:           parameters.chainDB,
+		aggregatesUpdater: aggregatesUpdater,
+		chainTime:         parameters.chainTime,
+		activitySem:       semaphore.NewWeighted(1),
+	}
+
+	s.md, err = s.getMetadata(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	// Update the aggregates at the start of each epoch.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "aggregates", "update aggregates",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic update of aggregates")
+	}
+
+	// Catch up with epochs summarized whilst not running.
+	go s.update(ctx)
+
+	return s, nil
+}
+
+// update adds the epochs summarized since the last update to each aggregate.
+func (s *Service) update(ctx context.Context) {
+	// Only one update runs at a time.
+	if !s.activitySem.TryAcquire(1) {
+		s.log.Debug().Msg("Another update already in progress")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	aggregates, err := s.aggregatesUpdater.Aggregates(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain aggregates")
+		return
+	}
+
+	// Each aggregate is updated in its own transactions, so that a failure of one
+	// does not stop the others from being updated.
+	for _, aggregate := range aggregates {
+		started := time.Now()
+		if err := s.updateAggregate(ctx, aggregate); err != nil {
+			s.log.Error().Str("aggregate", aggregate).Err(err).Msg("Failed to update aggregate")
+			s.monitorAggregateUpdated(aggregate, false)
+			continue
+		}
+		s.log.Trace().Str("aggregate", aggregate).Dur("elapsed", time.Since(started)).Msg("Updated aggregate")
+		s.monitorAggregateUpdated(aggregate, true)
+	}
+}
+
+// updateAggregate adds the epochs summarized since the last update to the given aggregate.
+// The epoch from which to continue is stored in the same transaction as each addition,
+// so that no epoch is added twice.
+func (s *Service) updateAggregate(ctx context.Context, aggregate string) error {
+	for {
+		fromEpoch := s.md.NextEpochs[aggregate]
+
+		ctx, cancel, err := s.chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+
+		nextEpoch, err := s.aggregatesUpdater.UpdateAggregate(ctx, aggregate, fromEpoch, updateEpochs)
+		if err != nil {
+			cancel()
+			return err
+		}
+		if nextEpoch == fromEpoch {
+			// Nothing summarized since the last update.
+			cancel()
+			return nil
+		}
+
+		s.md.NextEpochs[aggregate] = nextEpoch
+		if err := s.setMetadata(ctx, s.md); err != nil {
+			s.md.NextEpochs[aggregate] = fromEpoch
+			cancel()
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		if err := s.chainDB.CommitTx(ctx); err != nil {
+			s.md.NextEpochs[aggregate] = fromEpoch
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction")
+		}
+		s.log.Trace().Str("aggregate", aggregate).Uint64("from_epoch", uint64(fromEpoch)).Uint64("to_epoch", uint64(nextEpoch)).Msg("Added epochs to aggregate")
+		s.monitorEpochProcessed(aggregate, nextEpoch-1)
+	}
+}
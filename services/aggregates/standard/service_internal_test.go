@@ -0,0 +1,85 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"golang.org/x/sync/semaphore"
+)
+
+// updateFromEpochs returns the epochs from which the aggregate was updated.
+func updateFromEpochs(chainDB *mockchaindb.Service) []phase0.Epoch {
+	calls := chainDB.CallsTo("UpdateAggregate")
+	epochs := make([]phase0.Epoch, len(calls))
+	for i, call := range calls {
+		epochs[i] = call.Args[1].(phase0.Epoch)
+	}
+
+	return epochs
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("Aggregates", []string{"t_validator_performance"})
+	s := &Service{
+		log:               zerolog.Nop(),
+		metrics:           &serviceMetrics{},
+		chainDB:           chainDB,
+		aggregatesUpdater: chainDB,
+		activitySem:       semaphore.NewWeighted(1),
+		md: &metadata{
+			NextEpochs: make(map[string]phase0.Epoch),
+		},
+	}
+
+	// The first update adds the epochs summarized so far, and stops when there are no more.
+	chainDB.SetResponse("UpdateAggregate", phase0.Epoch(5))
+	s.update(ctx)
+	require.Equal(t, []phase0.Epoch{0, 5}, updateFromEpochs(chainDB))
+	require.Equal(t, phase0.Epoch(5), s.md.NextEpochs["t_validator_performance"])
+	require.Len(t, chainDB.CallsTo("CommitTx"), 1)
+
+	// The second update only adds the epochs summarized since the first.
+	chainDB.ResetCalls()
+	chainDB.SetResponse("UpdateAggregate", phase0.Epoch(6))
+	s.update(ctx)
+	require.Equal(t, []phase0.Epoch{5, 6}, updateFromEpochs(chainDB))
+	require.Equal(t, phase0.Epoch(6), s.md.NextEpochs["t_validator_performance"])
+	setMetadataCalls := chainDB.CallsTo("SetMetadata")
+	require.Len(t, setMetadataCalls, 1)
+	require.JSONEq(t, `{"next_epochs":{"t_validator_performance":6}}`, string(setMetadataCalls[0].Args[1].([]byte)))
+
+	// If the update is not committed the epochs are added again by the next update.
+	chainDB.ResetCalls()
+	chainDB.SetResponse("UpdateAggregate", phase0.Epoch(7))
+	chainDB.SetError("CommitTx", errors.New("commit failed"))
+	s.update(ctx)
+	require.Equal(t, []phase0.Epoch{6}, updateFromEpochs(chainDB))
+	require.Equal(t, phase0.Epoch(6), s.md.NextEpochs["t_validator_performance"])
+
+	chainDB.ResetCalls()
+	chainDB.SetError("CommitTx", nil)
+	s.update(ctx)
+	require.Equal(t, []phase0.Epoch{6, 7}, updateFromEpochs(chainDB))
+	require.Equal(t, phase0.Epoch(7), s.md.NextEpochs["t_validator_performance"])
+}
@@ -0,0 +1,201 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// slashingAlert is the body of an alert sent when a slashing is indexed.
+type slashingAlert struct {
+	Type               string                  `json:"type"`
+	InclusionSlot      phase0.Slot             `json:"inclusion_slot"`
+	InclusionBlockRoot string                  `json:"inclusion_block_root"`
+	InclusionIndex     uint64                  `json:"inclusion_index"`
+	ValidatorIndices   []phase0.ValidatorIndex `json:"validator_indices"`
+	ValidatorPubKeys   []string                `json:"validator_pubkeys,omitempty"`
+}
+
+// kafkaRecords is the body of a request to produce records with a Kafka REST proxy.
+type kafkaRecords struct {
+	Records []*kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is a single record to produce with a Kafka REST proxy.
+type kafkaRecord struct {
+	Value interface{} `json:"value"`
+}
+
+// OnProposerSlashing is called when a proposer slashing has been indexed.
+func (s *Service) OnProposerSlashing(ctx context.Context, slashing *chaindb.ProposerSlashing) {
+	s.alertSlashing(ctx, &slashingAlert{
+		Type:               "proposer_slashing",
+		InclusionSlot:      slashing.InclusionSlot,
+		InclusionBlockRoot: fmt.Sprintf("%#x", slashing.InclusionBlockRoot),
+		InclusionIndex:     slashing.InclusionIndex,
+		ValidatorIndices:   []phase0.ValidatorIndex{slashing.Header1ProposerIndex},
+	})
+}
+
+// OnAttesterSlashing is called when an attester slashing has been indexed.
+func (s *Service) OnAttesterSlashing(ctx context.Context, slashing *chaindb.AttesterSlashing) {
+	s.alertSlashing(ctx, &slashingAlert{
+		Type:               "attester_slashing",
+		InclusionSlot:      slashing.InclusionSlot,
+		InclusionBlockRoot: fmt.Sprintf("%#x", slashing.InclusionBlockRoot),
+		InclusionIndex:     slashing.InclusionIndex,
+		ValidatorIndices:   slashedIndices(slashing.Attestation1Indices, slashing.Attestation2Indices),
+	})
+}
+
+// alertSlashing sends an alert for a slashing to all configured sinks.
+func (s *Service) alertSlashing(ctx context.Context, alert *slashingAlert) {
+	log := s.log.With().Str("type", alert.Type).Uint64("inclusion_slot", uint64(alert.InclusionSlot)).Logger()
+
+	// Slashings in old blocks, for example when catching up, are not of immediate interest.
+	if s.maxAge > 0 && time.Since(s.chainTime.StartOfSlot(alert.InclusionSlot)) > s.maxAge {
+		log.Trace().Msg("Slashing too old; not alerting")
+		return
+	}
+	if len(alert.ValidatorIndices) == 0 {
+		log.Trace().Msg("Slashing does not slash any validators; not alerting")
+		return
+	}
+	if s.validatorKeys != nil {
+		alert.ValidatorPubKeys = s.validatorPubKeys(ctx, alert.ValidatorIndices)
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal alert")
+		return
+	}
+
+	for _, webhook := range s.webhooks {
+		err := s.post(ctx, webhook, "application/json", bytes.NewReader(body))
+		s.monitorAlertSent("webhook", err == nil)
+		if err != nil {
+			log.Warn().Str("webhook", webhook).Err(err).Msg("Failed to send alert to webhook")
+		}
+	}
+
+	if s.kafkaRESTProxy != "" {
+		err := s.produce(ctx, alert)
+		s.monitorAlertSent("kafka", err == nil)
+		if err != nil {
+			log.Warn().Str("topic", s.kafkaTopic).Err(err).Msg("Failed to send alert to Kafka")
+		}
+	}
+
+	log.Trace().Msg("Alerted slashing")
+}
+
+// validatorPubKeys provides the public keys of the given validators, in the same
+// order.  Alerts are still sent if the public keys cannot be obtained, so failure
+// results in no public keys rather than an error.
+func (s *Service) validatorPubKeys(ctx context.Context, indices []phase0.ValidatorIndex) []string {
+	pubKeys, err := s.validatorKeys.PubKeysByIndex(ctx, indices)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("Failed to obtain public keys of slashed validators")
+		return nil
+	}
+
+	res := make([]string, 0, len(indices))
+	for _, index := range indices {
+		pubKey, exists := pubKeys[index]
+		if !exists {
+			s.log.Debug().Uint64("index", uint64(index)).Msg("Public key of slashed validator not known")
+			return nil
+		}
+		res = append(res, fmt.Sprintf("%#x", pubKey))
+	}
+
+	return res
+}
+
+// produce produces a record holding the alert with the Kafka REST proxy.
+func (s *Service) produce(ctx context.Context, alert interface{}) error {
+	body, err := json.Marshal(&kafkaRecords{
+		Records: []*kafkaRecord{{Value: alert}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal records")
+	}
+
+	return s.post(ctx,
+		fmt.Sprintf("%s/topics/%s", s.kafkaRESTProxy, url.PathEscape(s.kafkaTopic)),
+		"application/vnd.kafka.json.v2+json",
+		bytes.NewReader(body),
+	)
+}
+
+// post sends an HTTP post request.
+func (s *Service) post(ctx context.Context, url string, contentType string, body io.Reader) error {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, url, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create POST request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call POST endpoint")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("POST failed with status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("POST failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	return nil
+}
+
+// slashedIndices provides the indices of the validators slashed by an attester slashing,
+// being those present in both attestations.
+func slashedIndices(indices1 []phase0.ValidatorIndex, indices2 []phase0.ValidatorIndex) []phase0.ValidatorIndex {
+	present := make(map[phase0.ValidatorIndex]bool, len(indices1))
+	for _, index := range indices1 {
+		present[index] = true
+	}
+
+	res := make([]phase0.ValidatorIndex, 0)
+	for _, index := range indices2 {
+		if present[index] {
+			res = append(res, index)
+			delete(present, index)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
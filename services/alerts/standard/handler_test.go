@@ -0,0 +1,189 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/alerts/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	standardvalidatorkeys "github.com/wealdtech/chaind/services/validatorkeys/standard"
+)
+
+type request struct {
+	path        string
+	contentType string
+	body        string
+}
+
+type recorder struct {
+	mu       sync.Mutex
+	requests []*request
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, &request{
+		path:        req.URL.Path,
+		contentType: req.Header.Get("Content-Type"),
+		body:        string(body),
+	})
+	r.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestOnProposerSlashing(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithWebhooks([]string{server.URL + "/alerts"}),
+		standard.WithKafkaRESTProxy(server.URL+"/"),
+		standard.WithKafkaTopic("slashings"),
+		standard.WithMaxAge(0),
+	)
+	require.NoError(t, err)
+
+	s.OnProposerSlashing(ctx, &chaindb.ProposerSlashing{
+		InclusionSlot:        100,
+		InclusionBlockRoot:   phase0.Root{0x01},
+		InclusionIndex:       2,
+		Header1ProposerIndex: 12345,
+		Header2ProposerIndex: 12345,
+	})
+
+	require.Len(t, rec.requests, 2)
+	require.Equal(t, "/alerts", rec.requests[0].path)
+	require.Equal(t, "application/json", rec.requests[0].contentType)
+	require.JSONEq(t, `{"type":"proposer_slashing","inclusion_slot":100,"inclusion_block_root":"0x0100000000000000000000000000000000000000000000000000000000000000","inclusion_index":2,"validator_indices":[12345]}`, rec.requests[0].body)
+	require.Equal(t, "/topics/slashings", rec.requests[1].path)
+	require.Equal(t, "application/vnd.kafka.json.v2+json", rec.requests[1].contentType)
+	require.JSONEq(t, `{"records":[{"value":{"type":"proposer_slashing","inclusion_slot":100,"inclusion_block_root":"0x0100000000000000000000000000000000000000000000000000000000000000","inclusion_index":2,"validator_indices":[12345]}}]}`, rec.requests[1].body)
+}
+
+func TestOnAttesterSlashing(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithWebhooks([]string{server.URL + "/alerts"}),
+		standard.WithMaxAge(0),
+	)
+	require.NoError(t, err)
+
+	s.OnAttesterSlashing(ctx, &chaindb.AttesterSlashing{
+		InclusionSlot:       100,
+		Attestation1Indices: []phase0.ValidatorIndex{1, 5, 7, 9},
+		Attestation2Indices: []phase0.ValidatorIndex{9, 2, 5},
+	})
+
+	require.Len(t, rec.requests, 1)
+	require.JSONEq(t, `{"type":"attester_slashing","inclusion_slot":100,"inclusion_block_root":"0x0000000000000000000000000000000000000000000000000000000000000000","inclusion_index":0,"validator_indices":[5,9]}`, rec.requests[0].body)
+
+	// No validators slashed, so no alert.
+	s.OnAttesterSlashing(ctx, &chaindb.AttesterSlashing{
+		InclusionSlot:       101,
+		Attestation1Indices: []phase0.ValidatorIndex{1, 2},
+		Attestation2Indices: []phase0.ValidatorIndex{3, 4},
+	})
+	require.Len(t, rec.requests, 1)
+}
+
+func TestTooOld(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	// The mock chain time places all slots at the zero time, so they are older than the default max age.
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithWebhooks([]string{server.URL + "/alerts"}),
+	)
+	require.NoError(t, err)
+
+	s.OnProposerSlashing(ctx, &chaindb.ProposerSlashing{
+		InclusionSlot:        100,
+		Header1ProposerIndex: 12345,
+	})
+	require.Len(t, rec.requests, 0)
+}
+
+func TestValidatorPubKeys(t *testing.T) {
+	ctx := context.Background()
+
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("ValidatorsByIndex", map[phase0.ValidatorIndex]*chaindb.Validator{
+		5: {Index: 5, PublicKey: phase0.BLSPubKey{0x05}},
+		9: {Index: 9, PublicKey: phase0.BLSPubKey{0x09}},
+	})
+	validatorKeys, err := standardvalidatorkeys.New(ctx,
+		standardvalidatorkeys.WithLogLevel(zerolog.Disabled),
+		standardvalidatorkeys.WithChainDB(chainDB),
+	)
+	require.NoError(t, err)
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithWebhooks([]string{server.URL + "/alerts"}),
+		standard.WithMaxAge(0),
+		standard.WithValidatorKeys(validatorKeys),
+	)
+	require.NoError(t, err)
+
+	s.OnAttesterSlashing(ctx, &chaindb.AttesterSlashing{
+		InclusionSlot:       100,
+		Attestation1Indices: []phase0.ValidatorIndex{1, 5, 7, 9},
+		Attestation2Indices: []phase0.ValidatorIndex{9, 2, 5},
+	})
+	require.Len(t, rec.requests, 1)
+	require.JSONEq(t, `{"type":"attester_slashing","inclusion_slot":100,"inclusion_block_root":"0x0000000000000000000000000000000000000000000000000000000000000000","inclusion_index":0,"validator_indices":[5,9],"validator_pubkeys":["0x050000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","0x090000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"]}`, rec.requests[0].body)
+
+	// Public keys are left out if any cannot be obtained.
+	s.OnProposerSlashing(ctx, &chaindb.ProposerSlashing{
+		InclusionSlot:        101,
+		Header1ProposerIndex: 12345,
+		Header2ProposerIndex: 12345,
+	})
+	require.Len(t, rec.requests, 2)
+	require.JSONEq(t, `{"type":"proposer_slashing","inclusion_slot":101,"inclusion_block_root":"0x0000000000000000000000000000000000000000000000000000000000000000","inclusion_index":0,"validator_indices":[12345]}`, rec.requests[1].body)
+}
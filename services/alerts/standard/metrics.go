@@ -0,0 +1,66 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_alerts"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	alertsSent *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.alertsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "sent_total",
+		Help:      "Number of alerts sent",
+	}, []string{"sink", "result"})
+	if err := registerer.Register(m.alertsSent); err != nil {
+		return nil, errors.Wrap(err, "failed to register sent_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorAlertSent(sink string, succeeded bool) {
+	if s.metrics.alertsSent != nil {
+		if succeeded {
+			s.metrics.alertsSent.WithLabelValues(sink, "succeeded").Inc()
+		} else {
+			s.metrics.alertsSent.WithLabelValues(sink, "failed").Inc()
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	network        string
+	monitor        metrics.Service
+	chainTime      chaintime.Service
+	webhooks       []string
+	kafkaRESTProxy string
+	kafkaTopic     string
+	timeout        time.Duration
+	maxAge         time.Duration
+	validatorKeys  validatorkeys.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithWebhooks sets the URLs to which alerts are posted.
+func WithWebhooks(webhooks []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.webhooks = webhooks
+	})
+}
+
+// WithKafkaRESTProxy sets the URL of the Kafka REST proxy through which alerts are produced.
+func WithKafkaRESTProxy(proxy string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.kafkaRESTProxy = proxy
+	})
+}
+
+// WithKafkaTopic sets the Kafka topic to which alerts are produced.
+func WithKafkaTopic(topic string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.kafkaTopic = topic
+	})
+}
+
+// WithTimeout sets the timeout for sending each alert.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithMaxAge sets the maximum age of the block containing a slashing for an alert to be sent.
+func WithMaxAge(maxAge time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxAge = maxAge
+	})
+}
+
+// WithValidatorKeys sets the validator keys service, used to add the public keys of
+// slashed validators to alerts.  If not supplied, alerts contain only indices.
+func WithValidatorKeys(validatorKeys validatorkeys.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorKeys = validatorKeys
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  10 * time.Second,
+		maxAge:   10 * time.Minute,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if len(parameters.webhooks) == 0 && parameters.kafkaRESTProxy == "" {
+		return nil, errors.New("no webhooks or Kafka REST proxy specified")
+	}
+	for _, webhook := range parameters.webhooks {
+		if err := checkURL(webhook); err != nil {
+			return nil, fmt.Errorf("invalid webhook %q: %v", webhook, err)
+		}
+	}
+	if parameters.kafkaRESTProxy != "" {
+		if err := checkURL(parameters.kafkaRESTProxy); err != nil {
+			return nil, fmt.Errorf("invalid Kafka REST proxy %q: %v", parameters.kafkaRESTProxy, err)
+		}
+		if parameters.kafkaTopic == "" {
+			return nil, errors.New("no Kafka topic specified")
+		}
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.maxAge < 0 {
+		return nil, errors.New("max age cannot be negative")
+	}
+
+	return &parameters, nil
+}
+
+// checkURL checks that the supplied string is an absolute HTTP or HTTPS URL.
+func checkURL(input string) error {
+	u, err := url.Parse(input)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return errors.New("no host")
+	}
+	return nil
+}
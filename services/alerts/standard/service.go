@@ -0,0 +1,72 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an alerts service.
+type Service struct {
+	log            zerolog.Logger
+	metrics        *serviceMetrics
+	chainTime      chaintime.Service
+	webhooks       []string
+	kafkaRESTProxy string
+	kafkaTopic     string
+	timeout        time.Duration
+	maxAge         time.Duration
+	validatorKeys  validatorkeys.Service
+	client         *http.Client
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "alerts", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:            log,
+		metrics:        svcMetrics,
+		chainTime:      parameters.chainTime,
+		webhooks:       parameters.webhooks,
+		kafkaRESTProxy: strings.TrimSuffix(parameters.kafkaRESTProxy, "/"),
+		kafkaTopic:     parameters.kafkaTopic,
+		timeout:        parameters.timeout,
+		maxAge:         parameters.maxAge,
+		validatorKeys:  parameters.validatorKeys,
+		client:         &http.Client{},
+	}
+
+	return s, nil
+}
@@ -0,0 +1,123 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/alerts/standard"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	chainTime := mockchaintime.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithWebhooks([]string{"http://localhost:8080/alerts"}),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SinksMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no webhooks or Kafka REST proxy specified",
+		},
+		{
+			name: "WebhookInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithWebhooks([]string{"localhost:8080/alerts"}),
+			},
+			err: `problem with parameters: invalid webhook "localhost:8080/alerts": scheme must be http or https`,
+		},
+		{
+			name: "KafkaRESTProxyInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithKafkaRESTProxy("http://"),
+				standard.WithKafkaTopic("slashings"),
+			},
+			err: `problem with parameters: invalid Kafka REST proxy "http://": no host`,
+		},
+		{
+			name: "KafkaTopicMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithKafkaRESTProxy("http://localhost:8082"),
+			},
+			err: "problem with parameters: no Kafka topic specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithWebhooks([]string{"http://localhost:8080/alerts"}),
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "MaxAgeNegative",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithWebhooks([]string{"http://localhost:8080/alerts"}),
+				standard.WithMaxAge(-time.Second),
+			},
+			err: "problem with parameters: max age cannot be negative",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithWebhooks([]string{"http://localhost:8080/alerts"}),
+				standard.WithKafkaRESTProxy("http://localhost:8082"),
+				standard.WithKafkaTopic("slashings"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
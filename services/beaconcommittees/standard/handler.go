@@ -16,6 +16,7 @@ package standard
 import (
 	"context"
 	"fmt"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -40,12 +41,12 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 
 	epoch := s.chainTime.SlotToEpoch(slot)
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
@@ -66,7 +67,8 @@ func (s *Service) OnBeaconChainHeadUpdated(
 // updateBeaconCommitteesForEpoch sets the beacon committee information for the given epoch.
 // This assumes that a database transaction is already in progress.
 func (s *Service) updateBeaconCommitteesForEpoch(ctx context.Context, epoch phase0.Epoch) error {
-	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Updating beacon committees")
+	started := time.Now()
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Msg("Updating beacon committees")
 
 	beaconCommittees, err := s.eth2Client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch)))
 	if err != nil {
@@ -83,7 +85,8 @@ func (s *Service) updateBeaconCommitteesForEpoch(ctx context.Context, epoch phas
 			return errors.Wrap(err, "failed to set beacon committee")
 		}
 	}
-	monitorEpochProcessed(epoch)
+	s.monitorEpochProcessed(epoch)
+	s.monitorProcessingDuration(time.Since(started))
 
 	return nil
 }
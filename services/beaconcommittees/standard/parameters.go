@@ -24,12 +24,15 @@ import (
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	startEpoch int64
+	logLevel        zerolog.Level
+	network         string
+	monitor         metrics.Service
+	eth2Client      eth2client.Service
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	startEpoch      int64
+	commitBatchSize uint64
+	window          uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +53,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -85,11 +95,26 @@ func WithStartEpoch(startEpoch int64) Parameter {
 	})
 }
 
+// WithCommitBatchSize sets the number of epochs written in each transaction when catching up.
+func WithCommitBatchSize(size uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.commitBatchSize = size
+	})
+}
+
+// WithWindow sets the number of recent epochs for which beacon committees are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:   zerolog.GlobalLevel(),
-		startEpoch: -1,
+		logLevel:        zerolog.GlobalLevel(),
+		startEpoch:      -1,
+		commitBatchSize: 1,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -115,6 +140,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.commitBatchSize == 0 {
+		return nil, errors.New("commit batch size must be at least 1")
+	}
 
 	return &parameters, nil
 }
@@ -21,24 +21,25 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
+	log                    zerolog.Logger
+	metrics                *serviceMetrics
 	eth2Client             eth2client.Service
 	chainDB                chaindb.Service
 	beaconCommitteesSetter chaindb.BeaconCommitteesSetter
 	chainTime              chaintime.Service
 	activitySem            *semaphore.Weighted
+	commitBatchSize        uint64
+	window                 uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -47,9 +48,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "beaconcommittees").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "beaconcommittees", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -58,11 +60,15 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support beacon committee setting")
 	}
 	s := &Service{
+		log:                    log,
+		metrics:                svcMetrics,
 		eth2Client:             parameters.eth2Client,
 		chainDB:                parameters.chainDB,
+		window:                 parameters.window,
 		beaconCommitteesSetter: beaconCommitteesSetter,
 		chainTime:              parameters.chainTime,
 		activitySem:            semaphore.NewWeighted(1),
+		commitBatchSize:        parameters.commitBatchSize,
 	}
 
 	// Update to current epoch before starting (in the background).
@@ -75,8 +81,9 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 	// Work out the epoch from which to start.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
+	s.monitorLatestEpoch(md.LatestEpoch)
 	if startEpoch >= 0 {
 		// Explicit requirement to start at a given epoch.
 		md.LatestEpoch = phase0.Epoch(startEpoch)
@@ -84,12 +91,16 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		// We have a definite hit on this being the last processed epoch; increment it to avoid duplication of work.
 		md.LatestEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); md.LatestEpoch < windowStart {
+		// Committees before the rolling window would be pruned, so do not fetch them.
+		md.LatestEpoch = windowStart
+	}
 
-	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
+	s.log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Error().Msg("Failed to obtain activity semaphore; catchup deferred")
+		s.log.Error().Msg("Failed to obtain activity semaphore; catchup deferred")
 		return
 	}
 	s.catchup(ctx, md)
@@ -100,27 +111,34 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
+		s.log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
 
 func (s *Service) catchup(ctx context.Context, md *metadata) {
-	for epoch := md.LatestEpoch; epoch <= s.chainTime.CurrentEpoch(); epoch++ {
-		log := log.With().Uint64("epoch", uint64(epoch)).Logger()
-		// Each update goes in to its own transaction, to make the data available sooner.
+	currentEpoch := s.chainTime.CurrentEpoch()
+	for epoch := md.LatestEpoch; epoch <= currentEpoch; {
+		// Updates are written in batches, each in its own transaction, to make the data available sooner.
+		lastEpoch := epoch + phase0.Epoch(s.commitBatchSize) - 1
+		if lastEpoch > currentEpoch {
+			lastEpoch = currentEpoch
+		}
+		log := s.log.With().Uint64("start_epoch", uint64(epoch)).Uint64("end_epoch", uint64(lastEpoch)).Logger()
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
 			return
 		}
 
-		if err := s.updateBeaconCommitteesForEpoch(ctx, epoch); err != nil {
-			log.Warn().Err(err).Msg("Failed to update beacon committees")
-			cancel()
-			return
+		for ; epoch <= lastEpoch; epoch++ {
+			if err := s.updateBeaconCommitteesForEpoch(ctx, epoch); err != nil {
+				log.Warn().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to update beacon committees")
+				cancel()
+				return
+			}
 		}
 
-		md.LatestEpoch = epoch
+		md.LatestEpoch = lastEpoch
 		if err := s.setMetadata(ctx, md); err != nil {
 			log.Error().Err(err).Msg("Failed to set metadata")
 			cancel()
@@ -17,6 +17,7 @@ import (
 	"context"
 
 	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
 // Service defines a block service.
@@ -25,3 +26,11 @@ type Service interface {
 	// This requires the context to hold an active transaction.
 	OnBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) error
 }
+
+// UnwrittenSlotsProvider provides information about slots that remain to be written
+// below the latest slot written, as happens when syncing backward from the head.
+type UnwrittenSlotsProvider interface {
+	// LowestUnwrittenSlot returns the lowest slot below the latest slot written that has
+	// yet to be written.  The second return value is false if there is no such slot.
+	LowestUnwrittenSlot(ctx context.Context) (phase0.Slot, bool, error)
+}
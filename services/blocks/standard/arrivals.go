@@ -0,0 +1,59 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// OnBlockArrived records the time at which a block was first seen, alongside the
+// start time of its slot.
+func (s *Service) OnBlockArrived(ctx context.Context,
+	slot phase0.Slot,
+	blockRoot phase0.Root,
+	arrivalTime time.Time,
+) {
+	log := s.log.With().Uint64("slot", uint64(slot)).Str("block_root", fmt.Sprintf("%#x", blockRoot)).Logger()
+
+	slotTime := s.chainTime.StartOfSlot(slot)
+	delay := arrivalTime.Sub(slotTime)
+	log.Trace().Dur("delay", delay).Msg("Block arrived")
+	s.monitorBlockArrival(delay)
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to begin transaction for block arrival")
+		return
+	}
+	if err := s.blockArrivalsSetter.SetBlockArrival(ctx, &chaindb.BlockArrival{
+		Slot:        slot,
+		BlockRoot:   blockRoot,
+		SlotTime:    slotTime,
+		ArrivalTime: arrivalTime,
+	}); err != nil {
+		cancel()
+		log.Warn().Err(err).Msg("Failed to set block arrival")
+		return
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		log.Warn().Err(err).Msg("Failed to commit transaction for block arrival")
+		return
+	}
+}
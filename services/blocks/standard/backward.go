@@ -0,0 +1,231 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/util"
+)
+
+// backwardRetryInterval is the time to wait before retrying a failed backward sync.
+var backwardRetryInterval = time.Minute
+
+// startBackward moves the forward sync to the head of the chain, leaving the slots from the
+// latest slot in the metadata up to the head to be written by the backward sync.  Both
+// checkpoints are set in the same transaction, so no slots are lost if chaind stops.
+// On entry the latest slot in the metadata is the first slot that has not been written.
+func (s *Service) startBackward(ctx context.Context, md *metadata) error {
+	headSlot := s.chainTime.CurrentSlot()
+	if headSlot <= md.LatestSlot {
+		// Nothing before the head to write.
+		return nil
+	}
+
+	bmd, err := s.getBackwardMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	// The new range is above any existing ranges, so goes first.
+	bmd.Ranges = append([]*backwardRange{{
+		NextSlot:   headSlot - 1,
+		TargetSlot: md.LatestSlot,
+	}}, bmd.Ranges...)
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.setBackwardMetadata(ctx, bmd); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.setMetadata(ctx, &metadata{LatestSlot: headSlot - 1}); err != nil {
+		cancel()
+		return err
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	s.log.Info().Uint64("from_slot", uint64(headSlot-1)).Uint64("to_slot", uint64(md.LatestSlot)).Msg("Syncing backward from head")
+	md.LatestSlot = headSlot - 1
+	s.monitorBackwardRemaining(bmd.remaining())
+
+	return nil
+}
+
+// syncBackward writes the ranges of slots in the backward metadata, highest first, until all
+// have been written or the context is cancelled.  Failed ranges are retried after a delay.
+func (s *Service) syncBackward(ctx context.Context) {
+	synced := false
+	for {
+		bmd, err := s.getBackwardMetadata(ctx)
+		if err != nil {
+			s.log.Error().Err(err).Msg("Failed to obtain backward metadata")
+		} else {
+			s.monitorBackwardRemaining(bmd.remaining())
+			if len(bmd.Ranges) == 0 {
+				if synced {
+					s.log.Info().Msg("Backward sync complete")
+				}
+				return
+			}
+			synced = true
+			if s.syncBackwardRange(ctx, bmd) {
+				continue
+			}
+		}
+
+		select {
+		case <-time.After(backwardRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncBackwardRange writes the first range of slots in the backward metadata, from the
+// highest slot down.  Returns true if the range was completed.
+func (s *Service) syncBackwardRange(ctx context.Context, md *backwardMetadata) bool {
+	r := md.Ranges[0]
+	if windowStart := s.chainTime.FirstSlotOfEpoch(chaintime.WindowStart(s.chainTime, s.window)); r.TargetSlot < windowStart {
+		if r.NextSlot < windowStart {
+			// The range has moved out of the rolling window, so its blocks would be pruned.
+			md.Ranges = md.Ranges[1:]
+			if err := s.updateBackwardMetadata(util.WithoutCancel(ctx), md); err != nil {
+				s.log.Error().Err(err).Msg("Failed to set backward metadata")
+				return false
+			}
+			return true
+		}
+		r.TargetSlot = windowStart
+	}
+
+	s.log.Debug().Uint64("from_slot", uint64(r.NextSlot)).Uint64("to_slot", uint64(r.TargetSlot)).Msg("Syncing range backward")
+	results, cancel := s.startPipeline(ctx, r.NextSlot, r.TargetSlot, priority.Backfill)
+	defer cancel()
+
+	return s.trackBackwardWrites(ctx, cancel, md, results)
+}
+
+// trackBackwardWrites processes the results of writes for the first range in the backward
+// metadata, lowering its next slot as contiguous slots are written and removing the range once
+// its target slot has been written.  The pipeline is cancelled on the first failure.
+// Returns true if the range was completed.
+func (s *Service) trackBackwardWrites(ctx context.Context,
+	cancel context.CancelFunc,
+	md *backwardMetadata,
+	results <-chan *writeResult,
+) bool {
+	// Progress is persisted even if the pipeline is stopped due to shutdown, so that blocks
+	// written by in-flight transactions are not refetched.
+	ctx = util.WithoutCancel(ctx)
+
+	r := md.Ranges[0]
+	written := make(map[phase0.Slot]bool)
+	completed := false
+	failed := false
+	for result := range results {
+		log := s.log.With().Uint64("slot", uint64(result.slot)).Logger()
+		if result.err != nil {
+			if !failed {
+				log.Warn().Err(result.err).Msg("Failed to write block")
+				failed = true
+				cancel()
+			}
+			continue
+		}
+		if failed {
+			continue
+		}
+		log.Trace().Msg("Updated block")
+		s.monitorBlockProcessed(result.slot)
+		s.monitorProcessingDuration(time.Since(result.started))
+
+		written[result.slot] = true
+		advanced := false
+		for !completed && written[r.NextSlot] {
+			delete(written, r.NextSlot)
+			advanced = true
+			if r.NextSlot == r.TargetSlot {
+				completed = true
+				md.Ranges = md.Ranges[1:]
+				continue
+			}
+			r.NextSlot--
+		}
+		if advanced {
+			if err := s.updateBackwardMetadata(ctx, md); err != nil {
+				log.Error().Err(err).Msg("Failed to set backward metadata")
+				failed = true
+				cancel()
+				continue
+			}
+			s.monitorBackwardRemaining(md.remaining())
+		}
+	}
+
+	return completed && !failed
+}
+
+// LowestUnwrittenSlot returns the lowest slot that remains to be written by the backward sync.
+// The second return value is false if the backward sync has nothing left to write.
+func (s *Service) LowestUnwrittenSlot(ctx context.Context) (phase0.Slot, bool, error) {
+	md, err := s.getBackwardMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(md.Ranges) == 0 {
+		return 0, false, nil
+	}
+
+	// Ranges are highest first.
+	return md.Ranges[len(md.Ranges)-1].TargetSlot, true, nil
+}
+
+// updateBackwardMetadata sets the backward metadata in its own transaction.
+func (s *Service) updateBackwardMetadata(ctx context.Context, md *backwardMetadata) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.setBackwardMetadata(ctx, md); err != nil {
+		cancel()
+		return err
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// remaining returns the number of slots that remain to be written.
+func (md *backwardMetadata) remaining() uint64 {
+	remaining := uint64(0)
+	for _, r := range md.Ranges {
+		remaining += uint64(r.NextSlot-r.TargetSlot) + 1
+	}
+	return remaining
+}
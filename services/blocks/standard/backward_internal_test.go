@@ -0,0 +1,148 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+// metadataDB is a chain database that only holds metadata.
+type metadataDB struct {
+	metadata map[string][]byte
+}
+
+func (d *metadataDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (d *metadataDB) CommitTx(_ context.Context) error {
+	return nil
+}
+
+func (d *metadataDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	d.metadata[key] = value
+	return nil
+}
+
+func (d *metadataDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	return d.metadata[key], nil
+}
+
+func TestTrackBackwardWrites(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		ranges    []*backwardRange
+		written   []phase0.Slot
+		fail      bool
+		failed    phase0.Slot
+		completed bool
+		expected  []*backwardRange
+	}{
+		{
+			name:      "Complete",
+			ranges:    []*backwardRange{{NextSlot: 10, TargetSlot: 8}, {NextSlot: 4, TargetSlot: 0}},
+			written:   []phase0.Slot{10, 9, 8},
+			completed: true,
+			expected:  []*backwardRange{{NextSlot: 4, TargetSlot: 0}},
+		},
+		{
+			name:      "OutOfOrder",
+			ranges:    []*backwardRange{{NextSlot: 10, TargetSlot: 8}},
+			written:   []phase0.Slot{8, 10, 9},
+			completed: true,
+			expected:  nil,
+		},
+		{
+			name:     "Gap",
+			ranges:   []*backwardRange{{NextSlot: 10, TargetSlot: 6}},
+			written:  []phase0.Slot{10, 8, 7, 6},
+			expected: []*backwardRange{{NextSlot: 9, TargetSlot: 6}},
+		},
+		{
+			name:     "Failed",
+			ranges:   []*backwardRange{{NextSlot: 10, TargetSlot: 6}},
+			written:  []phase0.Slot{10, 9, 8, 7, 6},
+			fail:     true,
+			failed:   8,
+			expected: []*backwardRange{{NextSlot: 8, TargetSlot: 6}},
+		},
+		{
+			name:      "Genesis",
+			ranges:    []*backwardRange{{NextSlot: 1, TargetSlot: 0}},
+			written:   []phase0.Slot{1, 0},
+			completed: true,
+			expected:  nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &metadataDB{metadata: make(map[string][]byte)}
+			s := &Service{chainDB: db, metrics: &serviceMetrics{}}
+
+			results := make(chan *writeResult, len(test.written))
+			for _, slot := range test.written {
+				result := &writeResult{slot: slot}
+				if test.fail && slot == test.failed {
+					result.err = errors.New("failed")
+				}
+				results <- result
+			}
+			close(results)
+
+			md := &backwardMetadata{Ranges: test.ranges}
+			completed := s.trackBackwardWrites(ctx, func() {}, md, results)
+			require.Equal(t, test.completed, completed)
+
+			stored := &backwardMetadata{}
+			require.NoError(t, json.Unmarshal(db.metadata[backwardMetadataKey], stored))
+			require.Equal(t, test.expected, stored.Ranges)
+		})
+	}
+}
+
+func TestBackwardMetadataRemaining(t *testing.T) {
+	md := &backwardMetadata{}
+	require.Zero(t, md.remaining())
+
+	md.Ranges = []*backwardRange{{NextSlot: 10, TargetSlot: 8}, {NextSlot: 4, TargetSlot: 0}}
+	require.Equal(t, uint64(8), md.remaining())
+}
+
+func TestLowestUnwrittenSlot(t *testing.T) {
+	ctx := context.Background()
+
+	db := &metadataDB{metadata: make(map[string][]byte)}
+	s := &Service{chainDB: db, metrics: &serviceMetrics{}}
+
+	_, exists, err := s.LowestUnwrittenSlot(ctx)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, s.setBackwardMetadata(ctx, &backwardMetadata{
+		Ranges: []*backwardRange{{NextSlot: 10, TargetSlot: 8}, {NextSlot: 4, TargetSlot: 2}},
+	}))
+	slot, exists, err := s.LowestUnwrittenSlot(ctx)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, phase0.Slot(2), slot)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec"
@@ -26,6 +27,10 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/priority"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -37,15 +42,7 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	// skipcq: RVV-A0005
 	epochTransition bool,
 ) {
-	// Only allow 1 handler to be active.
-	acquired := s.activitySem.TryAcquire(1)
-	if !acquired {
-		log.Debug().Msg("Another handler running")
-		return
-	}
-	defer s.activitySem.Release(1)
-
-	log := log.With().Uint64("slot", uint64(slot)).Str("block_root", fmt.Sprintf("%#x", blockRoot)).Logger()
+	log := s.log.With().Uint64("slot", uint64(slot)).Str("block_root", fmt.Sprintf("%#x", blockRoot)).Logger()
 	log.Trace().
 		Str("state_root", fmt.Sprintf("%#x", stateRoot)).
 		Bool("epoch_transition", epochTransition).
@@ -56,63 +53,152 @@ func (s *Service) OnBeaconChainHeadUpdated(
 		return
 	}
 
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		// Catching up; index the head block alone so that the tip of the chain stays current.
+		log.Trace().Msg("Another handler running; indexing head block")
+		if s.indexHead(ctx, slot) {
+			s.lastHandledBlockRoot = blockRoot
+		}
+		return
+	}
+	defer s.activitySem.Release(1)
+
 	md, err := s.getMetadata(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to obtain metadata")
 		return
 	}
 
-	s.catchup(ctx, md)
+	s.catchup(ctx, md, priority.Live)
 
 	s.lastHandledBlockRoot = blockRoot
-	monitorBlockProcessed(slot)
+	s.monitorBlockProcessed(slot)
 }
 
-func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) error {
-	log := log.With().Uint64("slot", uint64(slot)).Logger()
+// indexHead writes the block at the head of the chain whilst a catchup is running.
+// The block is committed to the main tables in its own transaction, whether or not a
+// backfill is in progress, so is available straight away.  It is written ahead of
+// the metadata, so the catchup does not refetch it when it reaches the slot; if the
+// write fails then the catchup writes the block instead.
+// Returns true if the block was written.
+func (s *Service) indexHead(ctx context.Context, slot phase0.Slot) bool {
+	log := s.log.With().Uint64("slot", uint64(slot)).Logger()
+
+	release, err := s.acquire(ctx, priority.Live)
+	if err != nil {
+		log.Debug().Err(err).Msg("Stopped whilst waiting to index head block")
+		return false
+	}
+	defer release()
+
+	started := time.Now()
+	item, err := s.fetchBlock(ctx, slot, started)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch head block")
+		return false
+	}
+	if item.signedBlock == nil {
+		// Missed slots are left for the catchup to record.
+		return false
+	}
+	if err := s.writeBlockBatch(ctx, []*fetchedBlock{item}); err != nil {
+		log.Warn().Err(err).Msg("Failed to write head block")
+		return false
+	}
+	// The latest block metric tracks the catchup, so is not updated here.
+	log.Trace().Msg("Indexed head block")
+	s.monitorProcessingDuration(time.Since(started))
+
+	return true
+}
+
+// fetchBlockForSlot fetches the block for the given slot from the beacon node.
+// This returns nil if there is no block for the slot, or if the block is already
+// present in the database and refetching is not enabled.  The second return value
+// is true if the beacon node has no block for the slot.
+func (s *Service) fetchBlockForSlot(ctx context.Context, slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, bool, error) {
+	ctx, span := tracer.Start(ctx, "fetchBlockForSlot", trace.WithAttributes(
+		attribute.Int64("slot", int64(slot)),
+	))
+	defer span.End()
+	log := s.log.With().Uint64("slot", uint64(slot)).Logger()
 
 	// Start off by seeing if we already have the block (unless we are re-fetching regardless).
 	if !s.refetch {
 		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
 		if err == nil && len(blocks) > 0 {
 			log.Debug().Msg("Already have this block; not re-fetching")
-			return nil
+			return nil, false, nil
 		}
 	}
 
-	log.Trace().Msg("Updating block for slot")
+	log.Trace().Msg("Fetching block for slot")
 	signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain beacon block for slot")
+		span.SetStatus(codes.Error, err.Error())
+		return nil, false, errors.Wrap(err, "failed to obtain beacon block for slot")
 	}
 	if signedBlock == nil {
 		log.Debug().Msg("No beacon block obtained for slot")
-		return nil
+		return nil, true, nil
 	}
-	return s.OnBlock(ctx, signedBlock)
+	return signedBlock, false, nil
 }
 
 // OnBlock handles a block.
 // This requires the context to hold an active transaction.
+// Blocks passed in by other modules have not been through the fetch stage, so their signatures
+// are verified here, before anything is written.
 func (s *Service) OnBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) error {
+	if s.verifier != nil {
+		if err := s.verifyBlockSignatures(ctx, signedBlock); err != nil {
+			return errors.Wrap(err, "failed to verify block signatures")
+		}
+	}
+	_, err := s.onBlock(ctx, signedBlock)
+	return err
+}
+
+// onBlock handles a block, returning the block as written to the database.
+func (s *Service) onBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) (*chaindb.Block, error) {
+	ctx, span := tracer.Start(ctx, "OnBlock")
+	defer span.End()
+
 	// Update the block in the database.
-	dbBlock, err := s.dbBlock(ctx, signedBlock)
+	transformCtx, transformSpan := tracer.Start(ctx, "dbBlock")
+	dbBlock, err := s.dbBlock(transformCtx, signedBlock)
+	transformSpan.End()
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain database block")
+		span.SetStatus(codes.Error, err.Error())
+		// The block cannot be transformed however many times it is written.
+		return nil, &chaindb.InvalidDataError{Err: errors.Wrap(err, "failed to obtain database block")}
 	}
-	if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
-		return errors.Wrap(err, "failed to set block")
+	span.SetAttributes(attribute.Int64("slot", int64(dbBlock.Slot)))
+
+	storeCtx, storeSpan := tracer.Start(ctx, "SetBlock")
+	err = s.blocksSetter.SetBlock(storeCtx, dbBlock)
+	storeSpan.End()
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, errors.Wrap(err, "failed to set block")
 	}
 	switch signedBlock.Version {
 	case spec.DataVersionPhase0:
-		return s.onBlockPhase0(ctx, signedBlock.Phase0, dbBlock)
+		err = s.onBlockPhase0(ctx, signedBlock.Phase0, dbBlock)
 	case spec.DataVersionAltair:
-		return s.onBlockAltair(ctx, signedBlock.Altair, dbBlock)
+		err = s.onBlockAltair(ctx, signedBlock.Altair, dbBlock)
 	case spec.DataVersionBellatrix:
-		return s.onBlockBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
+		err = s.onBlockBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
 	default:
-		return errors.New("unknown block version")
+		err = errors.New("unknown block version")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dbBlock, nil
 }
 
 func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedBeaconBlock, dbBlock *chaindb.Block) error {
@@ -234,13 +320,31 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 	blockRoot phase0.Root,
 	attestations []*phase0.Attestation,
 ) error {
+	ctx, span := tracer.Start(ctx, "updateAttestationsForBlock", trace.WithAttributes(
+		attribute.Int("attestations", len(attestations)),
+	))
+	defer span.End()
+
+	// Attestations are transformed before any are stored, to allow the time taken by each to be traced.
+	transformCtx, transformSpan := tracer.Start(ctx, "dbAttestations")
+	dbAttestations := make([]*chaindb.Attestation, 0, len(attestations))
 	beaconCommittees := make(map[phase0.Slot]map[phase0.CommitteeIndex]*chaindb.BeaconCommittee)
 	for i, attestation := range attestations {
-		dbAttestation, err := s.dbAttestation(ctx, slot, blockRoot, uint64(i), attestation, beaconCommittees)
+		dbAttestation, err := s.dbAttestation(transformCtx, slot, blockRoot, uint64(i), attestation, beaconCommittees)
 		if err != nil {
+			transformSpan.End()
+			span.SetStatus(codes.Error, err.Error())
 			return errors.Wrap(err, "failed to obtain database attestation")
 		}
-		if err := s.attestationsSetter.SetAttestation(ctx, dbAttestation); err != nil {
+		dbAttestations = append(dbAttestations, dbAttestation)
+	}
+	transformSpan.End()
+
+	storeCtx, storeSpan := tracer.Start(ctx, "SetAttestations")
+	defer storeSpan.End()
+	for _, dbAttestation := range dbAttestations {
+		if err := s.attestationsSetter.SetAttestation(storeCtx, dbAttestation); err != nil {
+			span.SetStatus(codes.Error, err.Error())
 			return errors.Wrap(err, "failed to set attestation")
 		}
 	}
@@ -260,6 +364,9 @@ func (s *Service) updateProposerSlashingsForBlock(ctx context.Context,
 		if err := s.proposerSlashingsSetter.SetProposerSlashing(ctx, dbProposerSlashing); err != nil {
 			return errors.Wrap(err, "failed to set proposer slashing")
 		}
+		for _, slashingHandler := range s.slashingHandlers {
+			go slashingHandler.OnProposerSlashing(ctx, dbProposerSlashing)
+		}
 	}
 	return nil
 }
@@ -277,6 +384,9 @@ func (s *Service) updateAttesterSlashingsForBlock(ctx context.Context,
 		if err := s.attesterSlashingsSetter.SetAttesterSlashing(ctx, dbAttesterSlashing); err != nil {
 			return errors.Wrap(err, "failed to set attester slashing")
 		}
+		for _, slashingHandler := range s.slashingHandlers {
+			go slashingHandler.OnAttesterSlashing(ctx, dbAttesterSlashing)
+		}
 	}
 	return nil
 }
@@ -320,6 +430,9 @@ func (s *Service) updateSyncAggregateForBlock(ctx context.Context,
 	blockRoot phase0.Root,
 	syncAggregate *altair.SyncAggregate,
 ) error {
+	ctx, span := tracer.Start(ctx, "updateSyncAggregateForBlock")
+	defer span.End()
+
 	dbSyncAggregate, err := s.dbSyncAggregate(ctx, slot, blockRoot, syncAggregate)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain database sync aggregate")
@@ -513,7 +626,7 @@ func (s *Service) dbAttestation(
 			}
 		}
 	} else {
-		log.Warn().Int("committee_length", len(committee.Committee)).Uint64("aggregation_bits_length", attestation.AggregationBits.Len()).Msg("Attestation and committee size mismatch")
+		s.log.Warn().Int("committee_length", len(committee.Committee)).Uint64("aggregation_bits_length", attestation.AggregationBits.Len()).Msg("Attestation and committee size mismatch")
 	}
 
 	dbAttestation := &chaindb.Attestation{
@@ -543,12 +656,14 @@ func (s *Service) dbSyncAggregate(
 	period := s.chainTime.SlotToSyncCommitteePeriod(slot)
 	var syncCommittee *chaindb.SyncCommittee
 	var exists bool
+	s.syncCommitteesMu.Lock()
+	defer s.syncCommitteesMu.Unlock()
 	if syncCommittee, exists = s.syncCommittees[period]; !exists {
 		// Fetch the sync committee.
 		var err error
 		syncCommittee, err = s.syncCommitteesProvider.SyncCommittee(ctx, period)
 		if err != nil {
-			log.Warn().Err(err).Uint64("slot", uint64(slot)).Uint64("sync_committee_period", period).Msg("Failed to obtain sync committee period")
+			s.log.Warn().Err(err).Uint64("slot", uint64(slot)).Uint64("sync_committee_period", period).Msg("Failed to obtain sync committee period")
 			return nil, errors.Wrap(err, "failed to obtain sync committee")
 		}
 		s.syncCommittees[period] = syncCommittee
@@ -743,7 +858,7 @@ func (s *Service) beaconCommittee(ctx context.Context,
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to fetch beacon committees")
 	}
-	log.Debug().Uint64("slot", uint64(slot)).Msg("Obtained beacon committees from API")
+	s.log.Debug().Uint64("slot", uint64(slot)).Msg("Obtained beacon committees from API")
 
 	for _, chainBeaconCommittee := range chainBeaconCommittees {
 		newBeaconCommittee := &chaindb.BeaconCommittee{
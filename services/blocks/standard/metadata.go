@@ -56,3 +56,51 @@ func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	}
 	return nil
 }
+
+// backwardMetadata stored about the backward sync of this service.
+// This is held separately from the main metadata, as the forward and backward syncs
+// update their checkpoints independently.
+type backwardMetadata struct {
+	// Ranges are the ranges of slots that remain to be written, highest first.
+	Ranges []*backwardRange `json:"ranges,omitempty"`
+}
+
+// backwardRange is a range of slots to be written by the backward sync.
+type backwardRange struct {
+	// NextSlot is the highest slot in the range yet to be written; all slots above it
+	// in the range have been written.
+	NextSlot phase0.Slot `json:"next_slot"`
+	// TargetSlot is the lowest slot in the range.
+	TargetSlot phase0.Slot `json:"target_slot"`
+}
+
+// backwardMetadataKey is the key for the backward metadata.
+var backwardMetadataKey = "blocks.standard.backward"
+
+// getBackwardMetadata gets backward metadata for this service.
+func (s *Service) getBackwardMetadata(ctx context.Context) (*backwardMetadata, error) {
+	md := &backwardMetadata{}
+	mdJSON, err := s.chainDB.Metadata(ctx, backwardMetadataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch backward metadata")
+	}
+	if mdJSON == nil {
+		return md, nil
+	}
+	if err := json.Unmarshal(mdJSON, md); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal backward metadata")
+	}
+	return md, nil
+}
+
+// setBackwardMetadata sets backward metadata for this service.
+func (s *Service) setBackwardMetadata(ctx context.Context, md *backwardMetadata) error {
+	mdJSON, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal backward metadata")
+	}
+	if err := s.chainDB.SetMetadata(ctx, backwardMetadataKey, mdJSON); err != nil {
+		return errors.Wrap(err, "failed to update backward metadata")
+	}
+	return nil
+}
@@ -15,72 +15,239 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"go.uber.org/atomic"
 )
 
 var metricsNamespace = "chaind_blocks"
 
-var highestSlot phase0.Slot
-var latestBlock prometheus.Gauge
-var blocksProcessed prometheus.Gauge
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	highestSlot        atomic.Uint64
+	latestBlock        prometheus.Gauge
+	blocksProcessed    prometheus.Gauge
+	lagSlots           prometheus.GaugeFunc
+	processingDuration prometheus.Histogram
+	writeQueueDepth    prometheus.Gauge
+	writeQueueFull     prometheus.Counter
+	writeLatency       prometheus.Gauge
+	fetchDelay         prometheus.Gauge
+	backwardRemaining  prometheus.Gauge
+	arrivalDelay       prometheus.Histogram
+	blocksQuarantined  prometheus.Counter
+	signatureFailures  *prometheus.CounterVec
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if latestBlock != nil {
-		// Already registered.
-		return nil
-	}
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service, chainTime chaintime.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network), chainTime)
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
 // skipcq: RVV-B0012
-func registerPrometheusMetrics(ctx context.Context) error {
-	latestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer, chainTime chaintime.Service) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_block",
 		Help:      "Latest block processed",
 	})
-	if err := prometheus.Register(latestBlock); err != nil {
-		return errors.Wrap(err, "failed to register latest_block")
+	if err := registerer.Register(m.latestBlock); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_block")
 	}
 
-	blocksProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.blocksProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "blocks_processed",
 		Help:      "Number of blocks processed",
 	})
-	if err := prometheus.Register(blocksProcessed); err != nil {
-		return errors.Wrap(err, "failed to register blocks_processed")
+	if err := registerer.Register(m.blocksProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register blocks_processed")
+	}
+
+	m.lagSlots = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_slots",
+		Help:      "Number of slots between the current slot and the latest block processed",
+	}, func() float64 {
+		current := chainTime.CurrentSlot()
+		latest := phase0.Slot(m.highestSlot.Load())
+		if latest >= current {
+			return 0
+		}
+		return float64(current - latest)
+	})
+	if err := registerer.Register(m.lagSlots); err != nil {
+		return nil, errors.Wrap(err, "failed to register lag_slots")
+	}
+
+	m.processingDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Time taken to fetch and write a block",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 14),
+	})
+	if err := registerer.Register(m.processingDuration); err != nil {
+		return nil, errors.Wrap(err, "failed to register processing_duration_seconds")
+	}
+
+	m.writeQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "write_queue_depth",
+		Help:      "Number of fetched blocks waiting to be written",
+	})
+	if err := registerer.Register(m.writeQueueDepth); err != nil {
+		return nil, errors.Wrap(err, "failed to register write_queue_depth")
+	}
+
+	m.writeQueueFull = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "write_queue_full_total",
+		Help:      "Number of times fetching waited for space in the write queue",
+	})
+	if err := registerer.Register(m.writeQueueFull); err != nil {
+		return nil, errors.Wrap(err, "failed to register write_queue_full_total")
+	}
+
+	m.writeLatency = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "write_latency_seconds",
+		Help:      "Smoothed time taken to write each block whilst catching up",
+	})
+	if err := registerer.Register(m.writeLatency); err != nil {
+		return nil, errors.Wrap(err, "failed to register write_latency_seconds")
+	}
+
+	m.fetchDelay = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "fetch_delay_seconds",
+		Help:      "Delay before each fetch whilst the database is behind",
+	})
+	if err := registerer.Register(m.fetchDelay); err != nil {
+		return nil, errors.Wrap(err, "failed to register fetch_delay_seconds")
+	}
+
+	m.backwardRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backward_remaining_slots",
+		Help:      "Number of slots remaining to be written by the backward sync",
+	})
+	if err := registerer.Register(m.backwardRemaining); err != nil {
+		return nil, errors.Wrap(err, "failed to register backward_remaining_slots")
+	}
+
+	m.arrivalDelay = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "arrival_delay_seconds",
+		Help:      "Time between the start of a slot and its block being first seen",
+		Buckets:   []float64{0.5, 1, 1.5, 2, 2.5, 3, 3.5, 4, 5, 6, 8, 12},
+	})
+	if err := registerer.Register(m.arrivalDelay); err != nil {
+		return nil, errors.Wrap(err, "failed to register arrival_delay_seconds")
+	}
+
+	m.blocksQuarantined = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "quarantined_total",
+		Help:      "Number of blocks quarantined as they could not be written",
+	})
+	if err := registerer.Register(m.blocksQuarantined); err != nil {
+		return nil, errors.Wrap(err, "failed to register quarantined_total")
 	}
 
-	return nil
+	m.signatureFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "signature_failures_total",
+		Help:      "Number of blocks rejected due to an invalid signature",
+	}, []string{"type"})
+	if err := registerer.Register(m.signatureFailures); err != nil {
+		return nil, errors.Wrap(err, "failed to register signature_failures_total")
+	}
+
+	return m, nil
 }
 
 // monitorLatestBlock sets the latest block without registering an
 // increase in blocks processed.  This does not usually need to be
 // called directly, as it is called as part ofr monitorBlockProcessed.
-func monitorLatestBlock(slot phase0.Slot) {
-	highestSlot = slot
-	if latestBlock != nil {
-		latestBlock.Set(float64(slot))
+func (s *Service) monitorLatestBlock(slot phase0.Slot) {
+	s.metrics.highestSlot.Store(uint64(slot))
+	if s.metrics.latestBlock != nil {
+		s.metrics.latestBlock.Set(float64(slot))
 	}
 }
 
-func monitorBlockProcessed(slot phase0.Slot) {
-	if blocksProcessed != nil {
-		blocksProcessed.Inc()
-		if slot > highestSlot {
-			monitorLatestBlock(slot)
+func (s *Service) monitorBlockProcessed(slot phase0.Slot) {
+	if s.metrics.blocksProcessed != nil {
+		s.metrics.blocksProcessed.Inc()
+		if slot > phase0.Slot(s.metrics.highestSlot.Load()) {
+			s.monitorLatestBlock(slot)
 		}
 	}
 }
+
+func (s *Service) monitorProcessingDuration(duration time.Duration) {
+	if s.metrics.processingDuration != nil {
+		s.metrics.processingDuration.Observe(duration.Seconds())
+	}
+}
+
+func (s *Service) monitorWriteQueueDepth(depth int) {
+	if s.metrics.writeQueueDepth != nil {
+		s.metrics.writeQueueDepth.Set(float64(depth))
+	}
+}
+
+func (s *Service) monitorWriteQueueFull() {
+	if s.metrics.writeQueueFull != nil {
+		s.metrics.writeQueueFull.Inc()
+	}
+}
+
+func (s *Service) monitorWriteLatency(latency time.Duration) {
+	if s.metrics.writeLatency != nil {
+		s.metrics.writeLatency.Set(latency.Seconds())
+	}
+}
+
+func (s *Service) monitorFetchDelay(delay time.Duration) {
+	if s.metrics.fetchDelay != nil {
+		s.metrics.fetchDelay.Set(delay.Seconds())
+	}
+}
+
+func (s *Service) monitorBackwardRemaining(slots uint64) {
+	if s.metrics.backwardRemaining != nil {
+		s.metrics.backwardRemaining.Set(float64(slots))
+	}
+}
+
+func (s *Service) monitorBlockArrival(delay time.Duration) {
+	if s.metrics.arrivalDelay != nil {
+		s.metrics.arrivalDelay.Observe(delay.Seconds())
+	}
+}
+
+func (s *Service) monitorBlockQuarantined() {
+	if s.metrics.blocksQuarantined != nil {
+		s.metrics.blocksQuarantined.Inc()
+	}
+}
+
+func (s *Service) monitorSignatureFailure(signatureType string) {
+	if s.metrics.signatureFailures != nil {
+		s.metrics.signatureFailures.WithLabelValues(signatureType).Inc()
+	}
+}
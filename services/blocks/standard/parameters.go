@@ -15,24 +15,45 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/services/scheduler"
 	"golang.org/x/sync/semaphore"
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	monitor     metrics.Service
-	eth2Client  eth2client.Service
-	chainDB     chaindb.Service
-	chainTime   chaintime.Service
-	startSlot   int64
-	refetch     bool
-	activitySem *semaphore.Weighted
+	logLevel               zerolog.Level
+	network                string
+	monitor                metrics.Service
+	eth2Client             eth2client.Service
+	chainDB                chaindb.Service
+	chainTime              chaintime.Service
+	startSlot              int64
+	refetch                bool
+	activitySem            *semaphore.Weighted
+	priority               priority.Service
+	writeQueueSize         int
+	writers                int
+	commitBatchSize        int
+	writeLatency           time.Duration
+	maxFetchDelay          time.Duration
+	backfill               bool
+	bidirectional          bool
+	arrivals               bool
+	slashingHandlers       []handlers.SlashingHandler
+	blockHandlers          []handlers.BlockHandler
+	window                 uint64
+	quarantine             bool
+	verifySignatures       bool
+	attestationSampleRatio float64
+	scheduler              scheduler.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -53,6 +74,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -102,11 +130,135 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithPriority sets the priority service that shares beacon node and database capacity
+// between indexing the head of the chain and catching up.
+func WithPriority(priority priority.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.priority = priority
+	})
+}
+
+// WithWriteQueueSize sets the number of fetched blocks that can be queued for writing.
+func WithWriteQueueSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.writeQueueSize = size
+	})
+}
+
+// WithWriters sets the number of concurrent database writers.
+func WithWriters(writers int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.writers = writers
+	})
+}
+
+// WithCommitBatchSize sets the number of slots written in each transaction when catching up.
+func WithCommitBatchSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.commitBatchSize = size
+	})
+}
+
+// WithWriteLatencyTarget sets the time to write each block above which fetching is slowed.
+func WithWriteLatencyTarget(latency time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.writeLatency = latency
+	})
+}
+
+// WithMaxFetchDelay sets the maximum delay between fetches when the database is behind.
+// A value of 0 disables slowing of fetches.
+func WithMaxFetchDelay(delay time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxFetchDelay = delay
+	})
+}
+
+// WithBackfill sets the backfill flag for this module.
+// When set, the database is prepared for bulk writes whilst catching up at startup.
+func WithBackfill(backfill bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backfill = backfill
+	})
+}
+
+// WithBidirectional sets whether to index forward from the head of the chain whilst syncing
+// backward over the slots that were missed, rather than catching up from the last slot indexed.
+func WithBidirectional(bidirectional bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bidirectional = bidirectional
+	})
+}
+
+// WithArrivals sets whether to record the time at which each block is first seen.
+func WithArrivals(arrivals bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.arrivals = arrivals
+	})
+}
+
+// WithSlashingHandlers sets the slashing handlers for this module.
+func WithSlashingHandlers(handlers []handlers.SlashingHandler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slashingHandlers = handlers
+	})
+}
+
+// WithBlockHandlers sets the block handlers for this module.
+func WithBlockHandlers(handlers []handlers.BlockHandler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blockHandlers = handlers
+	})
+}
+
+// WithWindow sets the number of recent epochs for which blocks are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
+// WithQuarantine sets whether blocks that cannot be written are quarantined rather than retried.
+func WithQuarantine(quarantine bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.quarantine = quarantine
+	})
+}
+
+// WithVerifySignatures sets whether to verify the signatures of blocks and their attestations
+// before storing them.
+func WithVerifySignatures(verify bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.verifySignatures = verify
+	})
+}
+
+// WithAttestationSampleRatio sets the proportion of attestations in each block whose signatures
+// are verified, from 0 for none to 1 for all.
+func WithAttestationSampleRatio(ratio float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationSampleRatio = ratio
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:  zerolog.GlobalLevel(),
-		startSlot: -1,
+		logLevel:               zerolog.GlobalLevel(),
+		startSlot:              -1,
+		writeQueueSize:         64,
+		writers:                1,
+		commitBatchSize:        1,
+		writeLatency:           time.Second,
+		maxFetchDelay:          5 * time.Second,
+		attestationSampleRatio: 1,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -126,6 +278,24 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.activitySem == nil {
 		return nil, errors.New("no activity semaphore specified")
 	}
+	if parameters.writeQueueSize < 1 {
+		return nil, errors.New("write queue size must be at least 1")
+	}
+	if parameters.writers < 1 {
+		return nil, errors.New("number of writers must be at least 1")
+	}
+	if parameters.commitBatchSize < 1 {
+		return nil, errors.New("commit batch size must be at least 1")
+	}
+	if parameters.backfill && parameters.bidirectional {
+		return nil, errors.New("bidirectional sync cannot be used with backfill")
+	}
+	if parameters.attestationSampleRatio < 0 || parameters.attestationSampleRatio > 1 {
+		return nil, errors.New("attestation sample ratio must be between 0 and 1")
+	}
+	if parameters.quarantine && parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified for quarantine")
+	}
 
 	return &parameters, nil
 }
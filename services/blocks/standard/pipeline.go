@@ -0,0 +1,452 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fetchedBlock is a block that has been fetched from the beacon node and is awaiting writing.
+// signedBlock is nil if there is nothing to write for the slot; missed is true if this is
+// because the beacon node has no block for the slot.  invalid is set if the block failed
+// verification when it was fetched, in which case it is not written.
+type fetchedBlock struct {
+	slot        phase0.Slot
+	signedBlock *spec.VersionedSignedBeaconBlock
+	missed      bool
+	invalid     error
+	started     time.Time
+}
+
+// writeResult is the result of writing a fetched block.
+type writeResult struct {
+	slot    phase0.Slot
+	started time.Time
+	err     error
+}
+
+// catchup fetches and writes blocks from the slot after that in the metadata up to the current slot.
+//
+// Fetching and writing are decoupled: a single fetcher obtains blocks from the beacon node and places them
+// on a bounded queue, from which a pool of writers stores them in the database.  When the queue is full the
+// fetcher blocks until space is available, so a slow database applies backpressure to fetching rather than
+// allowing unbounded growth in memory.
+// Before the queue fills, fetching is also slowed if the queue is mostly full or writes are slower than the
+// target latency, so that the database is not pushed to the point of failing transactions.
+//
+// Writers may complete out of order, so the latest slot in the metadata is only advanced once all prior
+// slots have been written.
+//
+// The chain continues to advance whilst catching up, so once the current slot has been reached the catchup
+// carries on from the slot after that in the metadata to the new current slot, until there is nothing left.
+//
+// Each fetch and each write waits for capacity at the given priority, so that a catchup over historical
+// slots gives way to indexing of the head of the chain.
+func (s *Service) catchup(ctx context.Context, md *metadata, p priority.Priority) {
+	passes := 0
+	var prevLastSlot phase0.Slot
+	for {
+		firstSlot := md.LatestSlot
+		// Increment if not 0 (as we do not differentiate between 0 and unset).
+		if firstSlot > 0 {
+			firstSlot++
+		}
+		lastSlot := s.chainTime.CurrentSlot()
+		if firstSlot > lastSlot || (passes > 0 && lastSlot == prevLastSlot) {
+			return
+		}
+
+		results, cancel := s.startPipeline(ctx, firstSlot, lastSlot, p)
+		s.trackWrites(ctx, cancel, md, firstSlot, results)
+		cancel()
+		if ctx.Err() != nil || md.LatestSlot != lastSlot {
+			// Stopped, or failed to fetch or write a slot; the next catchup will retry.
+			return
+		}
+		passes++
+		prevLastSlot = lastSlot
+	}
+}
+
+// startPipeline starts fetching and writing blocks for the given slot range, in descending
+// order if the last slot is before the first.  The results channel is closed once all writers
+// have finished; the returned function stops the pipeline.
+func (s *Service) startPipeline(ctx context.Context,
+	firstSlot phase0.Slot,
+	lastSlot phase0.Slot,
+	p priority.Priority,
+) (
+	<-chan *writeResult,
+	context.CancelFunc,
+) {
+	pipelineCtx, cancel := context.WithCancel(ctx)
+
+	queue := make(chan *fetchedBlock, s.writeQueueSize)
+	results := make(chan *writeResult, s.writeQueueSize)
+
+	go s.fetchBlocks(pipelineCtx, firstSlot, lastSlot, p, queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.writeBlocks(pipelineCtx, p, queue, results)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, cancel
+}
+
+// fetchBlocks fetches blocks for the given slot range and places them on the queue.
+// Slots are fetched in descending order if the last slot is before the first.
+// The queue is closed when fetching finishes, either due to completion or failure.
+func (s *Service) fetchBlocks(ctx context.Context,
+	firstSlot phase0.Slot,
+	lastSlot phase0.Slot,
+	p priority.Priority,
+	queue chan<- *fetchedBlock,
+) {
+	defer close(queue)
+
+	descending := lastSlot < firstSlot
+	slots := uint64(lastSlot-firstSlot) + 1
+	if descending {
+		slots = uint64(firstSlot-lastSlot) + 1
+	}
+	for i := uint64(0); i < slots; i++ {
+		slot := firstSlot + phase0.Slot(i)
+		if descending {
+			slot = firstSlot - phase0.Slot(i)
+		}
+		if ctx.Err() != nil {
+			// Pipeline has been stopped.
+			return
+		}
+		log := s.log.With().Uint64("slot", uint64(slot)).Logger()
+		delay := s.throttle.next(len(queue), cap(queue))
+		s.monitorFetchDelay(delay)
+		if delay > 0 {
+			log.Trace().Dur("delay", delay).Msg("Database behind; delaying fetch")
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+		started := time.Now()
+		release, err := s.acquire(ctx, p)
+		if err != nil {
+			// Pipeline was stopped whilst waiting.
+			return
+		}
+		item, err := s.fetchBlock(ctx, slot, started)
+		release()
+		if err != nil {
+			if ctx.Err() != nil {
+				// Pipeline was stopped during the fetch.
+				return
+			}
+			log.Warn().Err(err).Msg("Failed to fetch block")
+			return
+		}
+
+		select {
+		case queue <- item:
+		default:
+			// Queue is full; wait for the writers to catch up.
+			log.Trace().Msg("Write queue full; waiting")
+			s.monitorWriteQueueFull()
+			select {
+			case queue <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+		s.monitorWriteQueueDepth(len(queue))
+	}
+}
+
+// fetchBlock fetches the block for the given slot and, if so configured, verifies its signatures.
+// Verification happens here rather than when writing, so that a block that fails verification is
+// rejected before a transaction is opened for it.
+func (s *Service) fetchBlock(ctx context.Context, slot phase0.Slot, started time.Time) (*fetchedBlock, error) {
+	signedBlock, missed, err := s.fetchBlockForSlot(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &fetchedBlock{
+		slot:        slot,
+		signedBlock: signedBlock,
+		missed:      missed,
+		started:     started,
+	}
+	if s.verifier != nil && signedBlock != nil {
+		if err := s.verifyBlockSignatures(ctx, signedBlock); err != nil {
+			var invalidDataErr *chaindb.InvalidDataError
+			if !errors.As(err, &invalidDataErr) {
+				return nil, errors.Wrap(err, "failed to verify block signatures")
+			}
+			item.invalid = err
+		}
+	}
+
+	return item, nil
+}
+
+// writeBlocks writes blocks from the queue to the database until the queue is closed.
+// Blocks are written in batches of up to the commit batch size, with each batch in its own transaction.
+func (s *Service) writeBlocks(ctx context.Context,
+	p priority.Priority,
+	queue <-chan *fetchedBlock,
+	results chan<- *writeResult,
+) {
+	batch := make([]*fetchedBlock, 0, s.commitBatchSize)
+	for item := range queue {
+		s.monitorWriteQueueDepth(len(queue))
+		batch = append(batch, item)
+		if len(batch) == s.commitBatchSize {
+			s.writeBatch(ctx, p, batch, results)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		s.writeBatch(ctx, p, batch, results)
+	}
+}
+
+// writeBatch writes a batch of blocks, sending a result for each.
+func (s *Service) writeBatch(ctx context.Context,
+	p priority.Priority,
+	batch []*fetchedBlock,
+	results chan<- *writeResult,
+) {
+	if ctx.Err() != nil {
+		// Pipeline has been stopped; drop the batch without writing.
+		return
+	}
+	release, err := s.acquire(ctx, p)
+	if err != nil {
+		// Pipeline was stopped whilst waiting.
+		return
+	}
+
+	started := time.Now()
+	err = s.writeBlockBatch(ctx, batch)
+	errs := make([]error, len(batch))
+	if err == nil {
+		s.monitorWriteLatency(s.throttle.observeWrite(time.Since(started), len(batch)))
+	} else if s.quarantineSetter != nil && ctx.Err() == nil {
+		errs = s.writeBatchIsolated(ctx, batch)
+	} else {
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	release()
+	for i, item := range batch {
+		results <- &writeResult{
+			slot:    item.slot,
+			started: item.started,
+			err:     errs[i],
+		}
+	}
+}
+
+// writeBlockBatch writes a batch of fetched blocks to the database in a single transaction.
+// Smaller batches make the data available sooner, and lose less work on failure.
+func (s *Service) writeBlockBatch(ctx context.Context, batch []*fetchedBlock) error {
+	ctx, span := tracer.Start(ctx, "writeBlockBatch", trace.WithAttributes(
+		attribute.Int64("first_slot", int64(batch[0].slot)),
+		attribute.Int64("last_slot", int64(batch[len(batch)-1].slot)),
+	))
+	defer span.End()
+
+	for _, item := range batch {
+		if item.invalid != nil {
+			span.SetStatus(codes.Error, item.invalid.Error())
+			return errors.Wrapf(item.invalid, "failed to verify block for slot %d", item.slot)
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	dbBlocks := make([]*chaindb.Block, 0, len(batch))
+	for _, item := range batch {
+		if item.missed {
+			if err := s.missedSlotsSetter.SetMissedSlot(ctx, item.slot); err != nil {
+				cancel()
+				span.SetStatus(codes.Error, err.Error())
+				return errors.Wrapf(err, "failed to set missed slot %d", item.slot)
+			}
+			continue
+		}
+		if item.signedBlock == nil {
+			// Nothing to write.
+			continue
+		}
+		dbBlock, err := s.onBlock(ctx, item.signedBlock)
+		if err != nil {
+			cancel()
+			span.SetStatus(codes.Error, err.Error())
+			return errors.Wrapf(err, "failed to update block for slot %d", item.slot)
+		}
+		dbBlocks = append(dbBlocks, dbBlock)
+	}
+
+	if s.quarantineSetter != nil {
+		// Blocks that are written are no longer quarantined.
+		slots := make([]phase0.Slot, len(batch))
+		for i, item := range batch {
+			slots[i] = item.slot
+		}
+		if err := s.quarantineSetter.DeleteQuarantinedItems(ctx, quarantineModule, slots); err != nil {
+			cancel()
+			span.SetStatus(codes.Error, err.Error())
+			return errors.Wrap(err, "failed to release quarantined blocks")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		span.SetStatus(codes.Error, err.Error())
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	// Notify handlers only once the blocks are committed.
+	for _, dbBlock := range dbBlocks {
+		for _, blockHandler := range s.blockHandlers {
+			blockHandler.OnBlockIndexed(ctx, dbBlock)
+		}
+	}
+
+	return nil
+}
+
+// backfill catches up as per catchup, with the database prepared for bulk writes.
+func (s *Service) backfill(ctx context.Context, md *metadata) {
+	if err := s.backfiller.BeginBackfill(ctx); err != nil {
+		s.log.Error().Err(err).Msg("Failed to begin backfill; catching up normally")
+		s.catchup(ctx, md, priority.Backfill)
+		return
+	}
+
+	s.catchup(ctx, md, priority.Backfill)
+
+	if err := s.backfiller.EndBackfill(util.WithoutCancel(ctx)); err != nil {
+		s.log.Error().Err(err).Msg("Failed to end backfill")
+	}
+}
+
+// trackWrites processes the results of writes, advancing the metadata as contiguous slots are written.
+// The pipeline is cancelled on the first failure.
+func (s *Service) trackWrites(ctx context.Context,
+	cancel context.CancelFunc,
+	md *metadata,
+	firstSlot phase0.Slot,
+	results <-chan *writeResult,
+) {
+	// Progress is persisted even if the pipeline is stopped due to shutdown, so that blocks
+	// written by in-flight transactions are not refetched.
+	ctx = util.WithoutCancel(ctx)
+
+	nextSlot := firstSlot
+	written := make(map[phase0.Slot]bool)
+	failed := false
+	for result := range results {
+		log := s.log.With().Uint64("slot", uint64(result.slot)).Logger()
+		if result.err != nil {
+			if !failed {
+				log.Warn().Err(result.err).Msg("Failed to write block")
+				failed = true
+				cancel()
+			}
+			continue
+		}
+		if failed {
+			continue
+		}
+		log.Trace().Msg("Updated block")
+		s.monitorBlockProcessed(result.slot)
+		s.monitorProcessingDuration(time.Since(result.started))
+
+		written[result.slot] = true
+		advanced := false
+		for written[nextSlot] {
+			delete(written, nextSlot)
+			md.LatestSlot = nextSlot
+			nextSlot++
+			advanced = true
+		}
+		if advanced {
+			if err := s.updateMetadata(ctx, md); err != nil {
+				log.Error().Err(err).Msg("Failed to set metadata")
+				failed = true
+				cancel()
+				continue
+			}
+		}
+	}
+}
+
+// updateMetadata sets the metadata in its own transaction.
+func (s *Service) updateMetadata(ctx context.Context, md *metadata) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return err
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// acquire waits until work of the given priority can proceed, returning a function
+// to call once the work is complete.
+func (s *Service) acquire(ctx context.Context, p priority.Priority) (func(), error) {
+	if s.priority == nil {
+		return func() {}, nil
+	}
+
+	return s.priority.Acquire(ctx, p)
+}
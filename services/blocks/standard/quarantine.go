@@ -0,0 +1,206 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/priority"
+)
+
+// quarantineModule is the module under which blocks are quarantined.
+const quarantineModule = "blocks"
+
+// quarantinedBlockJSON is the JSON representation of a quarantined block, as per the beacon node API.
+type quarantinedBlockJSON struct {
+	Version string      `json:"version"`
+	Data    interface{} `json:"data"`
+}
+
+// isolatedWriteAttempts is the number of times that a block is written on its own before
+// giving up, if the write fails for a reason that may not recur.
+const isolatedWriteAttempts = 3
+
+// isolatedWriteRetryInterval is the time between attempts to write a block on its own.
+var isolatedWriteRetryInterval = 2 * time.Second
+
+// writeBatchIsolated writes each block of a batch that failed to be written as a whole in its own
+// transaction, so that a single bad block does not hold up the rest.  Blocks that fail to be written
+// on their own because of the block's data are quarantined, and treated as written.  Blocks that
+// fail for other reasons, such as a lost database connection, are retried and if they still fail
+// are left to be written by a later catchup.
+// Returns the error for each block of the batch.
+func (s *Service) writeBatchIsolated(ctx context.Context, batch []*fetchedBlock) []error {
+	errs := make([]error, len(batch))
+	for i, item := range batch {
+		err := s.writeBlockIsolated(ctx, item)
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil || item.signedBlock == nil || !s.writeErrorClassifier.IsDeterministicError(err) {
+			// Stopped, a missed slot for which there is nothing to quarantine, or an error
+			// that is not caused by the block.
+			errs[i] = err
+			continue
+		}
+		if err := s.quarantine(ctx, item.slot, item.signedBlock, err); err != nil {
+			s.log.Error().Uint64("slot", uint64(item.slot)).Err(err).Msg("Failed to quarantine block")
+			errs[i] = err
+			continue
+		}
+		s.log.Warn().Uint64("slot", uint64(item.slot)).Err(err).Msg("Quarantined block that could not be written")
+		s.monitorBlockQuarantined()
+	}
+
+	return errs
+}
+
+// writeBlockIsolated writes a single block in its own transaction, retrying if the write fails
+// for a reason that may not recur.
+func (s *Service) writeBlockIsolated(ctx context.Context, item *fetchedBlock) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.writeBlockBatch(ctx, []*fetchedBlock{item})
+		if err == nil || attempt == isolatedWriteAttempts || s.writeErrorClassifier.IsDeterministicError(err) {
+			return err
+		}
+		s.log.Debug().Uint64("slot", uint64(item.slot)).Int("attempt", attempt).Err(err).Msg("Failed to write block; retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(isolatedWriteRetryInterval):
+		}
+	}
+}
+
+// quarantine records a block that could not be written, along with the reason.
+func (s *Service) quarantine(ctx context.Context,
+	slot phase0.Slot,
+	signedBlock *spec.VersionedSignedBeaconBlock,
+	reason error,
+) error {
+	item := &chaindb.QuarantinedItem{
+		Module:    quarantineModule,
+		Slot:      slot,
+		Timestamp: time.Now(),
+		Reason:    reason.Error(),
+	}
+	if root, err := signedBlock.Root(); err == nil {
+		item.Root = &root
+	}
+	blockJSON := &quarantinedBlockJSON{
+		Version: strings.ToLower(signedBlock.Version.String()),
+	}
+	switch signedBlock.Version {
+	case spec.DataVersionPhase0:
+		blockJSON.Data = signedBlock.Phase0
+	case spec.DataVersionAltair:
+		blockJSON.Data = signedBlock.Altair
+	case spec.DataVersionBellatrix:
+		blockJSON.Data = signedBlock.Bellatrix
+	}
+	data, err := json.Marshal(blockJSON)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal block")
+	}
+	item.Data = data
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.quarantineSetter.SetQuarantinedItem(ctx, item); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set quarantined item")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// reprocessQuarantined refetches quarantined blocks and tries to write them again.  Blocks that
+// are written are removed from quarantine as part of the write; those that fail remain.
+// Each block is reprocessed with backfill priority, so live work is not held up.
+func (s *Service) reprocessQuarantined(ctx context.Context) {
+	items, err := s.quarantineProvider.QuarantinedItems(ctx, quarantineModule)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain quarantined blocks")
+		return
+	}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return
+		}
+		s.reprocessQuarantinedItem(ctx, item)
+	}
+}
+
+// reprocessQuarantinedItem refetches a single quarantined block and tries to write it again.
+func (s *Service) reprocessQuarantinedItem(ctx context.Context, item *chaindb.QuarantinedItem) {
+	log := s.log.With().Uint64("slot", uint64(item.Slot)).Logger()
+
+	release, err := s.acquire(ctx, priority.Backfill)
+	if err != nil {
+		log.Debug().Err(err).Msg("Stopped whilst waiting to reprocess quarantined block")
+		return
+	}
+	defer release()
+
+	fetched, err := s.fetchBlock(ctx, item.Slot, time.Now())
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to refetch quarantined block")
+		return
+	}
+	if fetched.signedBlock == nil && !fetched.missed {
+		// The block has since been written, for example by a refetch.
+		if err := s.releaseQuarantined(ctx, item.Slot); err != nil {
+			log.Warn().Err(err).Msg("Failed to release quarantined block")
+		}
+		return
+	}
+	if err := s.writeBlockBatch(ctx, []*fetchedBlock{fetched}); err != nil {
+		log.Debug().Err(err).Msg("Quarantined block still cannot be written")
+		return
+	}
+	log.Info().Msg("Released quarantined block")
+}
+
+// releaseQuarantined removes a block from quarantine.
+func (s *Service) releaseQuarantined(ctx context.Context, slot phase0.Slot) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := s.quarantineSetter.DeleteQuarantinedItems(ctx, quarantineModule, []phase0.Slot{slot}); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to delete quarantined item")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestWriteBatchIsolated(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetError("SetBlock", errors.New("violates foreign key constraint"))
+	s := &Service{
+		metrics:              &serviceMetrics{},
+		chainDB:              chainDB,
+		blocksSetter:         chainDB,
+		missedSlotsSetter:    chainDB,
+		quarantineProvider:   chainDB,
+		quarantineSetter:     chainDB,
+		writeErrorClassifier: chainDB,
+	}
+
+	batch := []*fetchedBlock{
+		{
+			slot:   1,
+			missed: true,
+		},
+		{
+			slot: 2,
+			signedBlock: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionPhase0,
+				Phase0: &phase0.SignedBeaconBlock{
+					Message: &phase0.BeaconBlock{
+						Slot: 2,
+						Body: &phase0.BeaconBlockBody{
+							ETH1Data: &phase0.ETH1Data{
+								BlockHash: make([]byte, 32),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The missed slot is written, and the block that cannot be written is quarantined.
+	errs := s.writeBatchIsolated(ctx, batch)
+	require.Equal(t, []error{nil, nil}, errs)
+	require.Len(t, chainDB.CallsTo("SetMissedSlot"), 1)
+	calls := chainDB.CallsTo("SetQuarantinedItem")
+	require.Len(t, calls, 1)
+	item := calls[0].Args[0].(*chaindb.QuarantinedItem)
+	require.Equal(t, "blocks", item.Module)
+	require.Equal(t, phase0.Slot(2), item.Slot)
+	require.Contains(t, item.Reason, "violates foreign key constraint")
+	require.NotNil(t, item.Root)
+	require.Contains(t, string(item.Data), `"version":"phase0"`)
+
+	// If the block cannot be quarantined either then the error is returned.
+	chainDB.SetError("SetQuarantinedItem", errors.New("connection refused"))
+	errs = s.writeBatchIsolated(ctx, batch[1:])
+	require.Len(t, errs, 1)
+	require.Error(t, errs[0])
+}
+
+func TestWriteBatchIsolatedTransient(t *testing.T) {
+	ctx := context.Background()
+	isolatedWriteRetryInterval = time.Millisecond
+
+	chainDB := mockchaindb.New()
+	chainDB.SetError("SetBlock", fmt.Errorf("failed to set block: %w", mockchaindb.ErrTransient))
+	s := &Service{
+		metrics:              &serviceMetrics{},
+		chainDB:              chainDB,
+		blocksSetter:         chainDB,
+		missedSlotsSetter:    chainDB,
+		quarantineProvider:   chainDB,
+		quarantineSetter:     chainDB,
+		writeErrorClassifier: chainDB,
+	}
+
+	batch := []*fetchedBlock{
+		{
+			slot: 2,
+			signedBlock: &spec.VersionedSignedBeaconBlock{
+				Version: spec.DataVersionPhase0,
+				Phase0: &phase0.SignedBeaconBlock{
+					Message: &phase0.BeaconBlock{
+						Slot: 2,
+						Body: &phase0.BeaconBlockBody{
+							ETH1Data: &phase0.ETH1Data{
+								BlockHash: make([]byte, 32),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The block is retried, and returned with its error rather than being quarantined.
+	errs := s.writeBatchIsolated(ctx, batch)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], mockchaindb.ErrTransient)
+	require.Len(t, chainDB.CallsTo("SetBlock"), isolatedWriteAttempts)
+	require.Empty(t, chainDB.CallsTo("SetQuarantinedItem"))
+}
+
+func TestWriteBatchIsolatedInvalidData(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	s := &Service{
+		metrics:            &serviceMetrics{},
+		chainDB:            chainDB,
+		blocksSetter:       chainDB,
+		missedSlotsSetter:  chainDB,
+		quarantineProvider: chainDB,
+		quarantineSetter:   chainDB,
+		// Use the database's classifier, which only knows about errors from chaind's own validation
+		// by their type.
+		writeErrorClassifier: &postgresql.Service{},
+	}
+
+	block := func(slot phase0.Slot, blockHashLength int) *spec.VersionedSignedBeaconBlock {
+		return &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionPhase0,
+			Phase0: &phase0.SignedBeaconBlock{
+				Message: &phase0.BeaconBlock{
+					Slot: slot,
+					Body: &phase0.BeaconBlockBody{
+						ETH1Data: &phase0.ETH1Data{
+							BlockHash: make([]byte, blockHashLength),
+						},
+					},
+				},
+			},
+		}
+	}
+	batch := []*fetchedBlock{
+		{
+			// The block hash is the wrong length, so the block root cannot be calculated.
+			slot:        2,
+			signedBlock: block(2, 31),
+		},
+		{
+			slot:        3,
+			signedBlock: block(3, 32),
+		},
+	}
+
+	// The block that chaind rejects is quarantined without retrying, and the later block is written.
+	errs := s.writeBatchIsolated(ctx, batch)
+	require.Equal(t, []error{nil, nil}, errs)
+	calls := chainDB.CallsTo("SetQuarantinedItem")
+	require.Len(t, calls, 1)
+	item := calls[0].Args[0].(*chaindb.QuarantinedItem)
+	require.Equal(t, phase0.Slot(2), item.Slot)
+	require.Contains(t, item.Reason, "failed to obtain database block")
+	setBlockCalls := chainDB.CallsTo("SetBlock")
+	require.Len(t, setBlockCalls, 1)
+	require.Equal(t, phase0.Slot(3), setBlockCalls[0].Args[0].(*chaindb.Block).Slot)
+}
@@ -15,20 +15,27 @@ package standard
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
+	log                      zerolog.Logger
+	metrics                  *serviceMetrics
 	eth2Client               eth2client.Service
 	chainDB                  chaindb.Service
 	blocksSetter             chaindb.BlocksSetter
@@ -38,17 +45,34 @@ type Service struct {
 	syncAggregateSetter      chaindb.SyncAggregateSetter
 	depositsSetter           chaindb.DepositsSetter
 	voluntaryExitsSetter     chaindb.VoluntaryExitsSetter
+	missedSlotsSetter        chaindb.MissedSlotsSetter
+	blockArrivalsSetter      chaindb.BlockArrivalsSetter
 	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
 	syncCommitteesProvider   chaindb.SyncCommitteesProvider
 	chainTime                chaintime.Service
 	refetch                  bool
 	lastHandledBlockRoot     phase0.Root
 	activitySem              *semaphore.Weighted
+	priority                 priority.Service
+	writeQueueSize           int
+	writers                  int
+	commitBatchSize          int
+	throttle                 *throttle
+	backfiller               chaindb.Backfiller
+	bidirectional            bool
+	syncCommitteesMu         sync.Mutex
 	syncCommittees           map[uint64]*chaindb.SyncCommittee
+	slashingHandlers         []handlers.SlashingHandler
+	blockHandlers            []handlers.BlockHandler
+	window                   uint64
+	quarantineProvider       chaindb.QuarantineProvider
+	quarantineSetter         chaindb.QuarantineSetter
+	writeErrorClassifier     chaindb.WriteErrorClassifier
+	verifier                 *signatureVerifier
 }
 
-// module-wide log.
-var log zerolog.Logger
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/blocks/standard")
 
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
@@ -58,9 +82,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "blocks").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "blocks", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -99,6 +124,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support voluntary exit setting")
 	}
 
+	missedSlotsSetter, isMissedSlotsSetter := parameters.chainDB.(chaindb.MissedSlotsSetter)
+	if !isMissedSlotsSetter {
+		return nil, errors.New("chain DB does not support missed slot setting")
+	}
+
 	beaconCommitteesProvider, isBeaconCommitteesProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
 	if !isBeaconCommitteesProvider {
 		return nil, errors.New("chain DB does not support beacon committee providing")
@@ -109,9 +139,51 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support sync committee providing")
 	}
 
+	var blockArrivalsSetter chaindb.BlockArrivalsSetter
+	if parameters.arrivals {
+		var isBlockArrivalsSetter bool
+		blockArrivalsSetter, isBlockArrivalsSetter = parameters.chainDB.(chaindb.BlockArrivalsSetter)
+		if !isBlockArrivalsSetter {
+			return nil, errors.New("chain DB does not support block arrival setting")
+		}
+	}
+
+	var backfiller chaindb.Backfiller
+	if parameters.backfill {
+		var isBackfiller bool
+		backfiller, isBackfiller = parameters.chainDB.(chaindb.Backfiller)
+		if !isBackfiller {
+			return nil, errors.New("chain DB does not support backfill")
+		}
+	}
+
+	var quarantineProvider chaindb.QuarantineProvider
+	var quarantineSetter chaindb.QuarantineSetter
+	var writeErrorClassifier chaindb.WriteErrorClassifier
+	if parameters.quarantine {
+		var isQuarantineProvider, isQuarantineSetter, isWriteErrorClassifier bool
+		quarantineProvider, isQuarantineProvider = parameters.chainDB.(chaindb.QuarantineProvider)
+		quarantineSetter, isQuarantineSetter = parameters.chainDB.(chaindb.QuarantineSetter)
+		writeErrorClassifier, isWriteErrorClassifier = parameters.chainDB.(chaindb.WriteErrorClassifier)
+		if !isQuarantineProvider || !isQuarantineSetter || !isWriteErrorClassifier {
+			return nil, errors.New("chain DB does not support quarantine")
+		}
+	}
+
+	var verifier *signatureVerifier
+	if parameters.verifySignatures {
+		verifier, err = newSignatureVerifier(ctx, parameters.eth2Client, parameters.chainDB, parameters.attestationSampleRatio)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to set up signature verification")
+		}
+	}
+
 	s := &Service{
+		log:                      log,
+		metrics:                  svcMetrics,
 		eth2Client:               parameters.eth2Client,
 		chainDB:                  parameters.chainDB,
+		window:                   parameters.window,
 		blocksSetter:             blocksSetter,
 		attestationsSetter:       attestationsSetter,
 		attesterSlashingsSetter:  attesterSlashingsSetter,
@@ -119,12 +191,27 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		syncAggregateSetter:      syncAggregateSetter,
 		depositsSetter:           depositsSetter,
 		voluntaryExitsSetter:     voluntaryExitsSetter,
+		missedSlotsSetter:        missedSlotsSetter,
+		blockArrivalsSetter:      blockArrivalsSetter,
 		beaconCommitteesProvider: beaconCommitteesProvider,
 		syncCommitteesProvider:   syncCommitteesProvider,
 		chainTime:                parameters.chainTime,
 		refetch:                  parameters.refetch,
 		activitySem:              parameters.activitySem,
+		priority:                 parameters.priority,
+		writeQueueSize:           parameters.writeQueueSize,
+		writers:                  parameters.writers,
+		commitBatchSize:          parameters.commitBatchSize,
+		throttle:                 newThrottle(parameters.writeLatency, parameters.maxFetchDelay),
+		backfiller:               backfiller,
+		bidirectional:            parameters.bidirectional,
 		syncCommittees:           make(map[uint64]*chaindb.SyncCommittee),
+		slashingHandlers:         parameters.slashingHandlers,
+		blockHandlers:            parameters.blockHandlers,
+		quarantineProvider:       quarantineProvider,
+		quarantineSetter:         quarantineSetter,
+		writeErrorClassifier:     writeErrorClassifier,
+		verifier:                 verifier,
 	}
 
 	// Note the current highest processed block for the monitor.
@@ -132,7 +219,43 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain metadata")
 	}
-	monitorLatestBlock(md.LatestSlot)
+	s.monitorLatestBlock(md.LatestSlot)
+
+	if s.quarantineProvider != nil {
+		// Quarantined blocks are retried at the start of each epoch, separately from the
+		// handling of new blocks.
+		runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+			s := data.(*Service)
+			return s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch() + 1), nil
+		}
+		jobFunc := func(ctx context.Context, data interface{}) {
+			s := data.(*Service)
+			s.reprocessQuarantined(ctx)
+		}
+		if err := parameters.scheduler.SchedulePeriodicJob(ctx, "blocks", "reprocess quarantined blocks",
+			runtimeFunc,
+			s,
+			jobFunc,
+			s,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to set up periodic reprocessing of quarantined blocks")
+		}
+	}
+
+	if s.blockArrivalsSetter != nil {
+		if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"block"}, func(event *api.Event) {
+			// Note the time before anything else, as it is the time of arrival.
+			arrivalTime := time.Now()
+			if event.Data == nil {
+				// Happens when the channel shuts down, nothing to worry about.
+				return
+			}
+			eventData := event.Data.(*api.BlockEvent)
+			s.OnBlockArrived(ctx, eventData.Slot, eventData.Block, arrivalTime)
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed to add block arrival handler")
+		}
+	}
 
 	// Update to current epoch before starting (in the background).
 	go s.updateAfterRestart(ctx, parameters.startSlot)
@@ -144,7 +267,7 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 	defer s.activitySem.Release(1)
@@ -154,7 +277,7 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 	if err != nil {
 		// This will exit so not release the semaphore, but it's exiting so we don't care.
 		//nolint:gocritic
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
 	if startSlot >= 0 {
 		// Explicit requirement to start at a given slot.
@@ -163,12 +286,20 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 		// We have a definite hit on this being the last processed slot; increment it to avoid duplication of work.
 		md.LatestSlot++
 	}
+	if windowStart := s.chainTime.FirstSlotOfEpoch(chaintime.WindowStart(s.chainTime, s.window)); md.LatestSlot < windowStart {
+		// Blocks before the rolling window would be pruned, so do not fetch them.
+		md.LatestSlot = windowStart
+	}
 
-	log.Info().Uint64("slot", uint64(md.LatestSlot)).Msg("Catching up from slot")
-	s.catchup(ctx, md)
-	log.Info().Msg("Caught up")
+	if s.bidirectional && startSlot < 0 {
+		// Start from the head, leaving the slots up to it for the backward sync.
+		if err := s.startBackward(ctx, md); err != nil {
+			s.log.Fatal().Err(err).Msg("Failed to start backward sync")
+		}
+	}
 
-	// Set up the handler for new chain head updates.
+	// Set up the handler for new chain head updates before catching up, so that the
+	// head of the chain is indexed whilst catching up.
 	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
 		if event.Data == nil {
 			// Happens when the channel shuts down, nothing to worry about.
@@ -177,45 +308,17 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
-	}
-}
-
-func (s *Service) catchup(ctx context.Context, md *metadata) {
-	firstSlot := md.LatestSlot
-	// Increment if not 0 (as we do not differentiate between 0 and unset).
-	if firstSlot > 0 {
-		firstSlot++
+		s.log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 
-	for slot := firstSlot; slot <= s.chainTime.CurrentSlot(); slot++ {
-		log := log.With().Uint64("slot", uint64(slot)).Logger()
-		// Each update goes in to its own transaction, to make the data available sooner.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
-			return
-		}
-
-		if err := s.updateBlockForSlot(ctx, slot); err != nil {
-			log.Warn().Err(err).Msg("Failed to update block")
-			cancel()
-			return
-		}
-
-		md.LatestSlot = slot
-		if err := s.setMetadata(ctx, md); err != nil {
-			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
-		}
-
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
-			return
-		}
-		log.Trace().Msg("Updated block")
-		monitorBlockProcessed(slot)
+	s.log.Info().Uint64("slot", uint64(md.LatestSlot)).Msg("Catching up from slot")
+	if s.backfiller != nil {
+		s.backfill(ctx, md)
+		// Any backward sync left from an earlier run starts once the backfill has finished.
+		go s.syncBackward(ctx)
+	} else {
+		go s.syncBackward(ctx)
+		s.catchup(ctx, md, priority.Backfill)
 	}
+	s.log.Info().Msg("Caught up")
 }
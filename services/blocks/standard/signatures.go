@@ -0,0 +1,345 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// signatureVerifier holds the chain information and public keys required to verify signatures.
+type signatureVerifier struct {
+	eth2Client             eth2client.Service
+	validatorsProvider     chaindb.ValidatorsProvider
+	attestationSampleRatio float64
+	genesisValidatorsRoot  phase0.Root
+	forkSchedule           []*phase0.Fork
+	proposerDomainType     phase0.DomainType
+	attesterDomainType     phase0.DomainType
+	pubKeysMu              sync.RWMutex
+	pubKeys                map[phase0.ValidatorIndex]e2types.PublicKey
+}
+
+// newSignatureVerifier creates a verifier for the signatures of blocks and their attestations.
+func newSignatureVerifier(ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	attestationSampleRatio float64,
+) (
+	*signatureVerifier,
+	error,
+) {
+	if err := e2types.InitBLS(); err != nil {
+		return nil, errors.Wrap(err, "failed to initialise BLS")
+	}
+
+	genesisProvider, isGenesisProvider := eth2Client.(eth2client.GenesisProvider)
+	if !isGenesisProvider {
+		return nil, errors.New("Ethereum 2 client does not provide genesis")
+	}
+	genesis, err := genesisProvider.Genesis(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain genesis")
+	}
+
+	forkScheduleProvider, isForkScheduleProvider := eth2Client.(eth2client.ForkScheduleProvider)
+	if !isForkScheduleProvider {
+		return nil, errors.New("Ethereum 2 client does not provide fork schedule")
+	}
+	forkSchedule, err := forkScheduleProvider.ForkSchedule(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain fork schedule")
+	}
+	if len(forkSchedule) == 0 {
+		return nil, errors.New("empty fork schedule")
+	}
+	sort.Slice(forkSchedule, func(i int, j int) bool {
+		return forkSchedule[i].Epoch < forkSchedule[j].Epoch
+	})
+
+	specProvider, isSpecProvider := eth2Client.(eth2client.SpecProvider)
+	if !isSpecProvider {
+		return nil, errors.New("Ethereum 2 client does not provide spec")
+	}
+	chainSpec, err := specProvider.Spec(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain spec")
+	}
+	proposerDomainType, err := specDomainType(chainSpec, "DOMAIN_BEACON_PROPOSER")
+	if err != nil {
+		return nil, err
+	}
+	attesterDomainType, err := specDomainType(chainSpec, "DOMAIN_BEACON_ATTESTER")
+	if err != nil {
+		return nil, err
+	}
+
+	// Public keys are taken from the database where possible, but the validators module
+	// may not have stored them yet.
+	validatorsProvider, _ := chainDB.(chaindb.ValidatorsProvider)
+	if _, isValidatorsProvider := eth2Client.(eth2client.ValidatorsProvider); !isValidatorsProvider {
+		return nil, errors.New("Ethereum 2 client does not provide validators")
+	}
+
+	return &signatureVerifier{
+		eth2Client:             eth2Client,
+		validatorsProvider:     validatorsProvider,
+		attestationSampleRatio: attestationSampleRatio,
+		genesisValidatorsRoot:  genesis.GenesisValidatorsRoot,
+		forkSchedule:           forkSchedule,
+		proposerDomainType:     proposerDomainType,
+		attesterDomainType:     attesterDomainType,
+		pubKeys:                make(map[phase0.ValidatorIndex]e2types.PublicKey),
+	}, nil
+}
+
+// specDomainType obtains the named domain type from the chain specification.
+func specDomainType(chainSpec map[string]interface{}, name string) (phase0.DomainType, error) {
+	tmp, exists := chainSpec[name]
+	if !exists {
+		return phase0.DomainType{}, fmt.Errorf("%s not found in spec", name)
+	}
+	domainType, isDomainType := tmp.(phase0.DomainType)
+	if !isDomainType {
+		return phase0.DomainType{}, fmt.Errorf("%s of unexpected type", name)
+	}
+
+	return domainType, nil
+}
+
+// domain returns the signature domain for the given domain type at the given epoch.
+func (v *signatureVerifier) domain(domainType phase0.DomainType, epoch phase0.Epoch) phase0.Domain {
+	forkVersion := v.forkSchedule[0].PreviousVersion
+	for _, fork := range v.forkSchedule {
+		if fork.Epoch > epoch {
+			break
+		}
+		forkVersion = fork.CurrentVersion
+	}
+
+	var domain phase0.Domain
+	copy(domain[:], e2types.Domain(e2types.DomainType(domainType), forkVersion[:], v.genesisValidatorsRoot[:]))
+
+	return domain
+}
+
+// publicKeys returns the public keys of the given validators.
+func (v *signatureVerifier) publicKeys(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]e2types.PublicKey,
+	error,
+) {
+	res := make(map[phase0.ValidatorIndex]e2types.PublicKey, len(indices))
+	missing := make([]phase0.ValidatorIndex, 0)
+	v.pubKeysMu.RLock()
+	for _, index := range indices {
+		if _, exists := res[index]; exists {
+			continue
+		}
+		if pubKey, exists := v.pubKeys[index]; exists {
+			res[index] = pubKey
+		} else {
+			// Mark the index as seen, to avoid duplicates in the missing list.
+			res[index] = nil
+			missing = append(missing, index)
+		}
+	}
+	v.pubKeysMu.RUnlock()
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	pubKeys := make(map[phase0.ValidatorIndex]phase0.BLSPubKey, len(missing))
+	if v.validatorsProvider != nil {
+		validators, err := v.validatorsProvider.ValidatorsByIndex(ctx, missing)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators from database")
+		}
+		for index, validator := range validators {
+			pubKeys[index] = validator.PublicKey
+		}
+	}
+	if len(pubKeys) < len(missing) {
+		unknown := make([]phase0.ValidatorIndex, 0, len(missing)-len(pubKeys))
+		for _, index := range missing {
+			if _, exists := pubKeys[index]; !exists {
+				unknown = append(unknown, index)
+			}
+		}
+		validators, err := v.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, "head", unknown)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators from beacon node")
+		}
+		for index, validator := range validators {
+			pubKeys[index] = validator.Validator.PublicKey
+		}
+	}
+
+	v.pubKeysMu.Lock()
+	defer v.pubKeysMu.Unlock()
+	for _, index := range missing {
+		pubKey, exists := pubKeys[index]
+		if !exists {
+			return nil, fmt.Errorf("no public key for validator %d", index)
+		}
+		key, err := e2types.BLSPublicKeyFromBytes(pubKey[:])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid public key for validator %d", index))
+		}
+		v.pubKeys[index] = key
+		res[index] = key
+	}
+
+	return res, nil
+}
+
+// verifyBlockSignatures verifies the signature of the proposer of a block, and the signatures
+// of a sample of its attestations.  Blocks that fail verification return a chaindb.InvalidDataError;
+// other errors, such as failing to obtain committees or public keys, may not recur.
+func (s *Service) verifyBlockSignatures(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) error {
+	ctx, span := tracer.Start(ctx, "verifyBlockSignatures")
+	defer span.End()
+
+	var slot phase0.Slot
+	var proposerIndex phase0.ValidatorIndex
+	var blockRoot phase0.Root
+	var signature phase0.BLSSignature
+	var attestations []*phase0.Attestation
+	var err error
+	switch signedBlock.Version {
+	case spec.DataVersionPhase0:
+		slot = signedBlock.Phase0.Message.Slot
+		proposerIndex = signedBlock.Phase0.Message.ProposerIndex
+		blockRoot, err = signedBlock.Phase0.Message.HashTreeRoot()
+		signature = signedBlock.Phase0.Signature
+		attestations = signedBlock.Phase0.Message.Body.Attestations
+	case spec.DataVersionAltair:
+		slot = signedBlock.Altair.Message.Slot
+		proposerIndex = signedBlock.Altair.Message.ProposerIndex
+		blockRoot, err = signedBlock.Altair.Message.HashTreeRoot()
+		signature = signedBlock.Altair.Signature
+		attestations = signedBlock.Altair.Message.Body.Attestations
+	case spec.DataVersionBellatrix:
+		slot = signedBlock.Bellatrix.Message.Slot
+		proposerIndex = signedBlock.Bellatrix.Message.ProposerIndex
+		blockRoot, err = signedBlock.Bellatrix.Message.HashTreeRoot()
+		signature = signedBlock.Bellatrix.Signature
+		attestations = signedBlock.Bellatrix.Message.Body.Attestations
+	default:
+		return &chaindb.InvalidDataError{Err: errors.New("unknown block version")}
+	}
+	if err != nil {
+		return &chaindb.InvalidDataError{Err: errors.Wrap(err, "failed to calculate block root")}
+	}
+	if slot == 0 {
+		// The genesis block is not signed.
+		return nil
+	}
+
+	// Obtain the attesting validators of the sampled attestations.
+	beaconCommittees := make(map[phase0.Slot]map[phase0.CommitteeIndex]*chaindb.BeaconCommittee)
+	sampled := make([]*phase0.Attestation, 0, len(attestations))
+	attesters := make([][]phase0.ValidatorIndex, 0, len(attestations))
+	indices := []phase0.ValidatorIndex{proposerIndex}
+	for _, attestation := range attestations {
+		if s.verifier.attestationSampleRatio < 1 && rand.Float64() >= s.verifier.attestationSampleRatio {
+			continue
+		}
+		committee, err := s.beaconCommittee(ctx, attestation.Data.Slot, attestation.Data.Index, beaconCommittees)
+		if err != nil {
+			return err
+		}
+		if committee == nil {
+			return errors.New("no committee obtained")
+		}
+		if len(committee.Committee) != int(attestation.AggregationBits.Len()) {
+			return &chaindb.InvalidDataError{Err: errors.New("attestation and committee size mismatch")}
+		}
+		attestingIndices := make([]phase0.ValidatorIndex, 0, len(committee.Committee))
+		for i := uint64(0); i < attestation.AggregationBits.Len(); i++ {
+			if attestation.AggregationBits.BitAt(i) {
+				attestingIndices = append(attestingIndices, committee.Committee[i])
+			}
+		}
+		sampled = append(sampled, attestation)
+		attesters = append(attesters, attestingIndices)
+		indices = append(indices, attestingIndices...)
+	}
+	span.SetAttributes(attribute.Int("attestations", len(sampled)))
+
+	pubKeys, err := s.verifier.publicKeys(ctx, indices)
+	if err != nil {
+		return err
+	}
+
+	domain := s.verifier.domain(s.verifier.proposerDomainType, s.chainTime.SlotToEpoch(slot))
+	if !verifySignature(signature, blockRoot, domain, []e2types.PublicKey{pubKeys[proposerIndex]}) {
+		s.monitorSignatureFailure("proposer")
+		return &chaindb.InvalidDataError{Err: errors.New("invalid proposer signature")}
+	}
+
+	for i, attestation := range sampled {
+		dataRoot, err := attestation.Data.HashTreeRoot()
+		if err != nil {
+			return &chaindb.InvalidDataError{Err: errors.Wrap(err, "failed to calculate attestation data root")}
+		}
+		attesterPubKeys := make([]e2types.PublicKey, len(attesters[i]))
+		for j, index := range attesters[i] {
+			attesterPubKeys[j] = pubKeys[index]
+		}
+		domain := s.verifier.domain(s.verifier.attesterDomainType, attestation.Data.Target.Epoch)
+		if !verifySignature(attestation.Signature, dataRoot, domain, attesterPubKeys) {
+			s.monitorSignatureFailure("attestation")
+			return &chaindb.InvalidDataError{Err: fmt.Errorf("invalid signature for attestation %d", i)}
+		}
+	}
+
+	return nil
+}
+
+// verifySignature verifies a signature over an object root by the given public keys.
+func verifySignature(signature phase0.BLSSignature,
+	root phase0.Root,
+	domain phase0.Domain,
+	pubKeys []e2types.PublicKey,
+) bool {
+	sig, err := e2types.BLSSignatureFromBytes(signature[:])
+	if err != nil {
+		return false
+	}
+	signingData := &phase0.SigningData{
+		ObjectRoot: root,
+		Domain:     domain,
+	}
+	signingRoot, err := signingData.HashTreeRoot()
+	if err != nil {
+		return false
+	}
+
+	return sig.VerifyAggregateCommon(signingRoot[:], pubKeys)
+}
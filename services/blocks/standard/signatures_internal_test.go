@@ -0,0 +1,219 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package standard
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/testing/mock"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestSignatureDomain(t *testing.T) {
+	var genesisValidatorsRoot phase0.Root
+	tmp, err := hex.DecodeString("4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+	require.NoError(t, err)
+	copy(genesisValidatorsRoot[:], tmp)
+
+	v := &signatureVerifier{
+		genesisValidatorsRoot: genesisValidatorsRoot,
+		forkSchedule: []*phase0.Fork{
+			{
+				PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+				CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+				Epoch:           0,
+			},
+			{
+				PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+				CurrentVersion:  phase0.Version{0x01, 0x00, 0x00, 0x00},
+				Epoch:           74240,
+			},
+		},
+	}
+
+	// Mainnet proposer domain before and after Altair.
+	phase0Domain := v.domain(phase0.DomainType{0x00, 0x00, 0x00, 0x00}, 74239)
+	require.Equal(t, "00000000b5303f2ad2010d699a76c8e62350947421a3e4a979779642cfdb0f66", hex.EncodeToString(phase0Domain[:]))
+	altairDomain := v.domain(phase0.DomainType{0x00, 0x00, 0x00, 0x00}, 74240)
+	require.Equal(t, "00000000afcaaba0efab1ca832a15152469bb09bb84641c405171dfa2d3fb45f", hex.EncodeToString(altairDomain[:]))
+}
+
+func TestVerifySignature(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+
+	domain := phase0.Domain{0x01}
+	root := phase0.Root{0x02}
+	signingData := &phase0.SigningData{
+		ObjectRoot: root,
+		Domain:     domain,
+	}
+	signingRoot, err := signingData.HashTreeRoot()
+	require.NoError(t, err)
+
+	keys := make([]*e2types.BLSPrivateKey, 3)
+	pubKeys := make([]e2types.PublicKey, len(keys))
+	sigs := make([]e2types.Signature, len(keys))
+	for i := range keys {
+		keys[i], err = e2types.GenerateBLSPrivateKey()
+		require.NoError(t, err)
+		pubKeys[i] = keys[i].PublicKey()
+		sigs[i] = keys[i].Sign(signingRoot[:])
+	}
+
+	var signature phase0.BLSSignature
+	copy(signature[:], sigs[0].Marshal())
+	require.True(t, verifySignature(signature, root, domain, pubKeys[:1]))
+	require.False(t, verifySignature(signature, phase0.Root{0x03}, domain, pubKeys[:1]))
+	require.False(t, verifySignature(signature, root, phase0.Domain{0x04}, pubKeys[:1]))
+	require.False(t, verifySignature(signature, root, domain, pubKeys[1:2]))
+
+	var aggregate phase0.BLSSignature
+	copy(aggregate[:], e2types.AggregateSignatures(sigs).Marshal())
+	require.True(t, verifySignature(aggregate, root, domain, pubKeys))
+	require.False(t, verifySignature(aggregate, root, domain, pubKeys[:2]))
+
+	require.False(t, verifySignature(phase0.BLSSignature{}, root, domain, pubKeys[:1]))
+}
+
+// testBlockProvider is a mock beacon node that only provides blocks.
+type testBlockProvider struct {
+	*mock.SignedBeaconBlockProvider
+}
+
+func (testBlockProvider) Name() string {
+	return "test"
+}
+
+func (testBlockProvider) Address() string {
+	return "test"
+}
+
+func TestPipelineInvalidSignature(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, e2types.InitBLS())
+
+	forkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+			Epoch:           0,
+		},
+	}
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider(forkSchedule)),
+	)
+	require.NoError(t, err)
+
+	proposerKey, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	otherKey, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	verifier := &signatureVerifier{
+		attestationSampleRatio: 1,
+		forkSchedule:           forkSchedule,
+		proposerDomainType:     phase0.DomainType{0x00, 0x00, 0x00, 0x00},
+		attesterDomainType:     phase0.DomainType{0x01, 0x00, 0x00, 0x00},
+		pubKeys: map[phase0.ValidatorIndex]e2types.PublicKey{
+			0: proposerKey.PublicKey(),
+		},
+	}
+
+	// The block in slot 3 is not signed by its proposer.
+	blockProvider := testBlockProvider{mock.NewSignedBeaconBlockProvider()}
+	for slot := phase0.Slot(2); slot <= 4; slot++ {
+		block := &phase0.BeaconBlock{
+			Slot: slot,
+			Body: &phase0.BeaconBlockBody{
+				ETH1Data: &phase0.ETH1Data{
+					BlockHash: make([]byte, 32),
+				},
+			},
+		}
+		root, err := block.HashTreeRoot()
+		require.NoError(t, err)
+		signingData := &phase0.SigningData{
+			ObjectRoot: root,
+			Domain:     verifier.domain(verifier.proposerDomainType, 0),
+		}
+		signingRoot, err := signingData.HashTreeRoot()
+		require.NoError(t, err)
+		key := proposerKey
+		if slot == 3 {
+			key = otherKey
+		}
+		signedBlock := &phase0.SignedBeaconBlock{Message: block}
+		copy(signedBlock.Signature[:], key.Sign(signingRoot[:]).Marshal())
+		blockProvider.SetSignedBeaconBlock(fmt.Sprintf("%d", slot), &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionPhase0,
+			Phase0:  signedBlock,
+		})
+	}
+
+	chainDB := mockchaindb.New()
+	s := &Service{
+		metrics:              &serviceMetrics{},
+		eth2Client:           blockProvider,
+		chainDB:              chainDB,
+		chainTime:            chainTime,
+		blocksSetter:         chainDB,
+		missedSlotsSetter:    chainDB,
+		quarantineProvider:   chainDB,
+		quarantineSetter:     chainDB,
+		writeErrorClassifier: &postgresql.Service{},
+		verifier:             verifier,
+		throttle:             newThrottle(0, 0),
+		refetch:              true,
+		writeQueueSize:       3,
+		writers:              1,
+		commitBatchSize:      3,
+	}
+
+	results, cancel := s.startPipeline(ctx, 2, 4, priority.Backfill)
+	defer cancel()
+	for result := range results {
+		require.NoError(t, result.err)
+	}
+
+	// The invalid block is quarantined, and the blocks either side of it are written.
+	calls := chainDB.CallsTo("SetQuarantinedItem")
+	require.Len(t, calls, 1)
+	item := calls[0].Args[0].(*chaindb.QuarantinedItem)
+	require.Equal(t, phase0.Slot(3), item.Slot)
+	require.Contains(t, item.Reason, "invalid proposer signature")
+	setBlockCalls := chainDB.CallsTo("SetBlock")
+	require.Len(t, setBlockCalls, 2)
+	require.Equal(t, phase0.Slot(2), setBlockCalls[0].Args[0].(*chaindb.Block).Slot)
+	require.Equal(t, phase0.Slot(4), setBlockCalls[1].Args[0].(*chaindb.Block).Slot)
+
+	// No transaction is opened to write the invalid block, only to quarantine it.
+	require.Len(t, chainDB.CallsTo("BeginTx"), 3)
+}
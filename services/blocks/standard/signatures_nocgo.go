@@ -0,0 +1,45 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package standard
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// signatureVerifier is not available without cgo, as the BLS library requires it.
+type signatureVerifier struct{}
+
+// newSignatureVerifier returns an error, as signatures cannot be verified without cgo.
+func newSignatureVerifier(_ context.Context,
+	_ eth2client.Service,
+	_ chaindb.Service,
+	_ float64,
+) (
+	*signatureVerifier,
+	error,
+) {
+	return nil, errors.New("signature verification requires chaind to be built with cgo")
+}
+
+// verifyBlockSignatures is never called without cgo, as no verifier can be created.
+func (*Service) verifyBlockSignatures(_ context.Context, _ *spec.VersionedSignedBeaconBlock) error {
+	return errors.New("signature verification requires chaind to be built with cgo")
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"sync"
+	"time"
+)
+
+// minFetchDelay is the smallest non-zero delay between fetches.
+const minFetchDelay = 10 * time.Millisecond
+
+// throttle slows fetching from the beacon node when the database falls behind.
+// The delay before each fetch doubles whilst the write queue is mostly full or
+// writes are slower than the target latency, and halves once the queue has
+// drained and writes are back within the target.
+type throttle struct {
+	mu            sync.Mutex
+	targetLatency time.Duration
+	maxDelay      time.Duration
+	// latency is the smoothed time taken to write each block.
+	latency time.Duration
+	delay   time.Duration
+}
+
+// newThrottle creates a new throttle.  A maximum delay of 0 disables throttling.
+func newThrottle(targetLatency time.Duration, maxDelay time.Duration) *throttle {
+	return &throttle{
+		targetLatency: targetLatency,
+		maxDelay:      maxDelay,
+	}
+}
+
+// observeWrite records the time taken to write a batch of blocks, returning the smoothed
+// time taken to write each block.
+func (t *throttle) observeWrite(duration time.Duration, blocks int) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if blocks > 0 {
+		perBlock := duration / time.Duration(blocks)
+		if t.latency == 0 {
+			t.latency = perBlock
+		} else {
+			t.latency = (7*t.latency + perBlock) / 8
+		}
+	}
+
+	return t.latency
+}
+
+// next returns the delay before the next fetch, given the depth and size of the write queue.
+func (t *throttle) next(depth int, size int) time.Duration {
+	if t.maxDelay == 0 {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slow := t.targetLatency > 0 && t.latency > t.targetLatency
+	switch {
+	case slow || 4*depth >= 3*size:
+		// Database is falling behind.
+		t.delay *= 2
+		if t.delay < minFetchDelay {
+			t.delay = minFetchDelay
+		}
+		if t.delay > t.maxDelay {
+			t.delay = t.maxDelay
+		}
+	case 4*depth <= size:
+		// Database has caught up.
+		t.delay /= 2
+		if t.delay < minFetchDelay {
+			t.delay = 0
+		}
+	}
+	return t.delay
+}
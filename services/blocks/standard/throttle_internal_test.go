@@ -0,0 +1,67 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleQueueDepth(t *testing.T) {
+	throttle := newThrottle(time.Second, 50*time.Millisecond)
+
+	// Queue mostly empty; no delay.
+	require.Zero(t, throttle.next(0, 64))
+
+	// Queue mostly full; delay increases up to the maximum.
+	require.Equal(t, 10*time.Millisecond, throttle.next(48, 64))
+	require.Equal(t, 20*time.Millisecond, throttle.next(48, 64))
+	require.Equal(t, 40*time.Millisecond, throttle.next(60, 64))
+	require.Equal(t, 50*time.Millisecond, throttle.next(64, 64))
+
+	// Queue part full; delay held.
+	require.Equal(t, 50*time.Millisecond, throttle.next(32, 64))
+
+	// Queue drained; delay decreases to nothing.
+	require.Equal(t, 25*time.Millisecond, throttle.next(16, 64))
+	require.Equal(t, 12500*time.Microsecond, throttle.next(0, 64))
+	require.Zero(t, throttle.next(0, 64))
+}
+
+func TestThrottleLatency(t *testing.T) {
+	throttle := newThrottle(100*time.Millisecond, time.Second)
+
+	// Writes within the target.
+	throttle.observeWrite(200*time.Millisecond, 4)
+	require.Zero(t, throttle.next(0, 64))
+
+	// Writes slower than the target delay fetching even with an empty queue.
+	throttle.observeWrite(2*time.Second, 1)
+	require.Equal(t, 10*time.Millisecond, throttle.next(0, 64))
+	require.Equal(t, 20*time.Millisecond, throttle.next(0, 64))
+
+	// Delay falls once writes return within the target.
+	for i := 0; i < 32; i++ {
+		throttle.observeWrite(10*time.Millisecond, 1)
+	}
+	require.Equal(t, 10*time.Millisecond, throttle.next(0, 64))
+}
+
+func TestThrottleDisabled(t *testing.T) {
+	throttle := newThrottle(time.Millisecond, 0)
+	throttle.observeWrite(time.Second, 1)
+	require.Zero(t, throttle.next(64, 64))
+}
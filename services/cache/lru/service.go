@@ -0,0 +1,121 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lru
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an in-process least-recently-used cache.
+type Service struct {
+	log     zerolog.Logger
+	size    int
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// entry is an entry in the cache.
+type entry struct {
+	key   string
+	value []byte
+}
+
+// New creates a new in-process cache.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "cache", "lru", parameters.logLevel)
+
+	return &Service{
+		log:     log,
+		size:    parameters.size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}, nil
+}
+
+// Get obtains the value for the given key.
+// Returns nil if the key is not present in the cache.
+func (s *Service) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, exists := s.entries[key]
+	if !exists {
+		return nil, nil
+	}
+	s.order.MoveToFront(element)
+
+	return element.Value.(*entry).value, nil
+}
+
+// Set sets the value for the given key.
+func (s *Service) Set(_ context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, exists := s.entries[key]; exists {
+		element.Value.(*entry).value = value
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&entry{
+		key:   key,
+		value: value,
+	})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*entry).key)
+	}
+
+	return nil
+}
+
+// Delete removes the given key from the cache.
+func (s *Service) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, exists := s.entries[key]; exists {
+		s.order.Remove(element)
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// Invalidate removes all keys from the cache.
+func (s *Service) Invalidate(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+	s.log.Trace().Msg("Invalidated cache")
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lru_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/cache/lru"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []lru.Parameter
+		err    string
+	}{
+		{
+			name: "Default",
+		},
+		{
+			name: "SizeZero",
+			params: []lru.Parameter{
+				lru.WithSize(0),
+			},
+			err: "problem with parameters: size must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []lru.Parameter{
+				lru.WithSize(10),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := lru.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEviction(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := lru.New(ctx, lru.WithSize(2))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Set(ctx, "a", []byte("1")))
+	require.NoError(t, s.Set(ctx, "b", []byte("2")))
+	// Access a to make b the least recently used.
+	value, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), value)
+	require.NoError(t, s.Set(ctx, "c", []byte("3")))
+
+	value, err = s.Get(ctx, "b")
+	require.NoError(t, err)
+	require.Nil(t, value)
+	value, err = s.Get(ctx, "c")
+	require.NoError(t, err)
+	require.Equal(t, []byte("3"), value)
+
+	require.NoError(t, s.Delete(ctx, "c"))
+	value, err = s.Get(ctx, "c")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	require.NoError(t, s.Invalidate(ctx))
+	value, err = s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
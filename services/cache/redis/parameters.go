@@ -0,0 +1,114 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel  zerolog.Level
+	network   string
+	address   string
+	password  string
+	db        int
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithAddress sets the address of the Redis server.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithPassword sets the password for the Redis server.
+func WithPassword(password string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.password = password
+	})
+}
+
+// WithDB sets the Redis database number.
+func WithDB(db int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.db = db
+	})
+}
+
+// WithKeyPrefix sets the prefix for keys stored in Redis.
+func WithKeyPrefix(keyPrefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.keyPrefix = keyPrefix
+	})
+}
+
+// WithTTL sets the maximum time for which an entry is held.
+func WithTTL(ttl time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ttl = ttl
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:  zerolog.GlobalLevel(),
+		keyPrefix: "chaind:",
+		ttl:       time.Hour,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.ttl <= 0 {
+		return nil, errors.New("TTL must be greater than 0")
+	}
+
+	return &parameters, nil
+}
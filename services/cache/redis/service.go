@@ -0,0 +1,139 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
+)
+
+// Service is a cache backed by Redis.
+// Invalidation is carried out by incrementing a generation counter that forms
+// part of each key, so that it is cheap and shared between all users of the server.
+// Entries from previous generations expire according to their TTL.
+type Service struct {
+	log        zerolog.Logger
+	client     *redis.Client
+	keyPrefix  string
+	ttl        time.Duration
+	generation atomic.Int64
+}
+
+// New creates a new Redis cache.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "cache", "redis", parameters.logLevel)
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     parameters.address,
+		Password: parameters.password,
+		DB:       parameters.db,
+	})
+
+	s := &Service{
+		log:       log,
+		client:    client,
+		keyPrefix: parameters.keyPrefix,
+		ttl:       parameters.ttl,
+	}
+
+	if err := s.refreshGeneration(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to contact redis server")
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; closing client")
+		if err := client.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close client")
+		}
+	}()
+
+	return s, nil
+}
+
+// Get obtains the value for the given key.
+// Returns nil if the key is not present in the cache.
+func (s *Service) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get value")
+	}
+
+	return value, nil
+}
+
+// Set sets the value for the given key.
+func (s *Service) Set(ctx context.Context, key string, value []byte) error {
+	if err := s.client.Set(ctx, s.key(key), value, s.ttl).Err(); err != nil {
+		return errors.Wrap(err, "failed to set value")
+	}
+
+	return nil
+}
+
+// Delete removes the given key from the cache.
+func (s *Service) Delete(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.key(key)).Err(); err != nil {
+		return errors.Wrap(err, "failed to delete value")
+	}
+
+	return nil
+}
+
+// Invalidate removes all keys from the cache.
+func (s *Service) Invalidate(ctx context.Context) error {
+	generation, err := s.client.Incr(ctx, s.generationKey()).Result()
+	if err != nil {
+		return errors.Wrap(err, "failed to increment generation")
+	}
+	s.generation.Store(generation)
+	s.log.Trace().Int64("generation", generation).Msg("Invalidated cache")
+
+	return nil
+}
+
+// refreshGeneration obtains the current generation from the server.
+func (s *Service) refreshGeneration(ctx context.Context) error {
+	generation, err := s.client.Get(ctx, s.generationKey()).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	s.generation.Store(generation)
+
+	return nil
+}
+
+func (s *Service) generationKey() string {
+	return fmt.Sprintf("%sgeneration", s.keyPrefix)
+}
+
+func (s *Service) key(key string) string {
+	return fmt.Sprintf("%s%d:%s", s.keyPrefix, s.generation.Load(), key)
+}
@@ -0,0 +1,32 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import "context"
+
+// Service is the interface for a cache of database values.
+type Service interface {
+	// Get obtains the value for the given key.
+	// Returns nil if the key is not present in the cache.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set sets the value for the given key.
+	Set(ctx context.Context, key string, value []byte) error
+
+	// Delete removes the given key from the cache.
+	Delete(ctx context.Context, key string) error
+
+	// Invalidate removes all keys from the cache.
+	Invalidate(ctx context.Context) error
+}
@@ -0,0 +1,179 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a chain database service that records each mutation made to the underlying
+// database in an audit log.  Entries are only recorded for transactions that are committed.
+type Service struct {
+	log zerolog.Logger
+	*postgresql.Service
+	// fileMu protects writes to the file.
+	fileMu sync.Mutex
+	// file is the file to which entries are appended; nil if entries are written to the database.
+	file *os.File
+}
+
+// pendingEntriesKey is a context tag for the audit entries of the transaction.
+type pendingEntriesKey struct{}
+
+// pendingEntries are the audit entries for mutations made in a transaction.
+type pendingEntries struct {
+	mu      sync.Mutex
+	entries []*chaindb.AuditEntry
+}
+
+// fileEntry is the format of an audit entry in the audit log file.
+type fileEntry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Table     string            `json:"table"`
+	Operation string            `json:"operation"`
+	Keys      map[string]string `json:"keys"`
+	Module    string            `json:"module,omitempty"`
+	Slot      *phase0.Slot      `json:"slot,omitempty"`
+}
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "chaindb", "audit", parameters.logLevel)
+
+	s := &Service{
+		log:     log,
+		Service: parameters.chainDB,
+	}
+
+	if parameters.file != "" {
+		s.file, err = os.OpenFile(parameters.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open audit log file")
+		}
+		log.Info().Str("file", parameters.file).Msg("Recording mutations in audit log file")
+	} else {
+		log.Info().Msg("Recording mutations in audit log table")
+	}
+
+	return s, nil
+}
+
+// BeginTx begins a transaction on the underlying database, ready to record its mutations.
+func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel, err := s.Service.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return context.WithValue(ctx, pendingEntriesKey{}, &pendingEntries{}), cancel, nil
+}
+
+// CommitTx commits the transaction on the underlying database, along with its audit entries.
+// When writing to the database the entries are part of the transaction; when writing to a file
+// the entries are appended once the transaction has committed.
+func (s *Service) CommitTx(ctx context.Context) error {
+	var entries []*chaindb.AuditEntry
+	if pending, ok := ctx.Value(pendingEntriesKey{}).(*pendingEntries); ok {
+		pending.mu.Lock()
+		entries = pending.entries
+		pending.entries = nil
+		pending.mu.Unlock()
+	}
+
+	if s.file == nil && len(entries) > 0 {
+		if err := s.Service.SetAuditEntries(ctx, entries); err != nil {
+			return errors.Wrap(err, "failed to write audit entries")
+		}
+	}
+
+	if err := s.Service.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	if s.file != nil && len(entries) > 0 {
+		if err := s.writeFileEntries(entries); err != nil {
+			// The transaction has been committed, so this cannot be undone; report it loudly.
+			s.log.Error().Err(err).Int("entries", len(entries)).Msg("Failed to write audit entries to file")
+		}
+	}
+
+	return nil
+}
+
+// record records a mutation made in the transaction in the context.
+func (s *Service) record(ctx context.Context, table string, operation string, keys map[string]string, slot *phase0.Slot) {
+	pending, ok := ctx.Value(pendingEntriesKey{}).(*pendingEntries)
+	if !ok {
+		// Mutations outside of a transaction fail, so this should not happen.
+		s.log.Warn().Str("table", table).Msg("Mutation outside of audited transaction; not recorded")
+		return
+	}
+
+	entry := &chaindb.AuditEntry{
+		Timestamp: time.Now(),
+		Table:     table,
+		Operation: operation,
+		Keys:      keys,
+		Module:    util.Module(ctx),
+		Slot:      slot,
+	}
+	pending.mu.Lock()
+	pending.entries = append(pending.entries, entry)
+	pending.mu.Unlock()
+}
+
+// writeFileEntries appends entries to the audit log file, one JSON object per line.
+func (s *Service) writeFileEntries(entries []*chaindb.AuditEntry) error {
+	data := make([]byte, 0)
+	for _, entry := range entries {
+		line, err := json.Marshal(&fileEntry{
+			Timestamp: entry.Timestamp,
+			Table:     entry.Table,
+			Operation: entry.Operation,
+			Keys:      entry.Keys,
+			Module:    entry.Module,
+			Slot:      entry.Slot,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal audit entry")
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if _, err := s.file.Write(data); err != nil {
+		return errors.Wrap(err, "failed to write audit entries")
+	}
+
+	return nil
+}
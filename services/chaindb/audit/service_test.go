@@ -0,0 +1,118 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/audit"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		params []audit.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []audit.Parameter{
+				audit.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "FileBad",
+			params: []audit.Parameter{
+				audit.WithLogLevel(zerolog.Disabled),
+				audit.WithChainDB(&postgresql.Service{}),
+				audit.WithFile(filepath.Join(dir, "missing", "audit.log")),
+			},
+			err: "failed to open audit log file: open " + filepath.Join(dir, "missing", "audit.log") + ": no such file or directory",
+		},
+		{
+			name: "Table",
+			params: []audit.Parameter{
+				audit.WithLogLevel(zerolog.Disabled),
+				audit.WithChainDB(&postgresql.Service{}),
+			},
+		},
+		{
+			name: "File",
+			params: []audit.Parameter{
+				audit.WithLogLevel(zerolog.Disabled),
+				audit.WithChainDB(&postgresql.Service{}),
+				audit.WithFile(filepath.Join(dir, "audit.log")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := audit.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// The audit log file is created if it does not exist.
+	_, err := os.Stat(filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+}
+
+func TestInterfaces(t *testing.T) {
+	s := &audit.Service{}
+
+	require.Implements(t, (*chaindb.Service)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.Backfiller)(nil), s)
+	require.Implements(t, (*chaindb.BeaconCommitteesSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockArrivalsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlocksProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlocksSetter)(nil), s)
+	require.Implements(t, (*chaindb.Drainer)(nil), s)
+	require.Implements(t, (*chaindb.GossipAttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockClientsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockRelaysSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorRegistrationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.QuarantineSetter)(nil), s)
+	require.Implements(t, (*chaindb.WriteErrorClassifier)(nil), s)
+	require.Implements(t, (*chaindb.ETH1DepositsDeleter)(nil), s)
+	require.Implements(t, (*chaindb.TenantsSetter)(nil), s)
+	require.Implements(t, (*chaindb.PeerCountsSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
+}
+
+func TestWriteWithoutTransaction(t *testing.T) {
+	ctx := context.Background()
+	s := &audit.Service{
+		Service: &postgresql.Service{},
+	}
+
+	require.ErrorIs(t, s.SetBlock(ctx, &chaindb.Block{}), postgresql.ErrNoTransaction)
+	require.ErrorIs(t, s.SetMetadata(ctx, "test", []byte("{}")), postgresql.ErrNoTransaction)
+}
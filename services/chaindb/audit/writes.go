@@ -0,0 +1,688 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// operationUpsert is the operation for rows that are inserted, or updated if already present.
+const operationUpsert = "upsert"
+
+// operationDelete is the operation for rows that are deleted.
+const operationDelete = "delete"
+
+// inclusionKeys returns the keys for items identified by their position in a block.
+func inclusionKeys(slot phase0.Slot, blockRoot phase0.Root, index uint64) map[string]string {
+	return map[string]string{
+		"inclusion_slot":       strconv.FormatUint(uint64(slot), 10),
+		"inclusion_block_root": fmt.Sprintf("%#x", blockRoot),
+		"inclusion_index":      strconv.FormatUint(index, 10),
+	}
+}
+
+// SetAttestation sets an attestation.
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	if err := s.Service.SetAttestation(ctx, attestation); err != nil {
+		return err
+	}
+	s.record(ctx, "t_attestations", operationUpsert,
+		inclusionKeys(attestation.InclusionSlot, attestation.InclusionBlockRoot, attestation.InclusionIndex),
+		&attestation.InclusionSlot)
+	return nil
+}
+
+// SetAttesterSlashing sets an attester slashing.
+func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
+	if err := s.Service.SetAttesterSlashing(ctx, attesterSlashing); err != nil {
+		return err
+	}
+	s.record(ctx, "t_attester_slashings", operationUpsert,
+		inclusionKeys(attesterSlashing.InclusionSlot, attesterSlashing.InclusionBlockRoot, attesterSlashing.InclusionIndex),
+		&attesterSlashing.InclusionSlot)
+	return nil
+}
+
+// SetBeaconCommittee sets a beacon committee.
+func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	if err := s.Service.SetBeaconCommittee(ctx, beaconCommittee); err != nil {
+		return err
+	}
+	s.record(ctx, "t_beacon_committees", operationUpsert, map[string]string{
+		"slot":  strconv.FormatUint(uint64(beaconCommittee.Slot), 10),
+		"index": strconv.FormatUint(uint64(beaconCommittee.Index), 10),
+	}, &beaconCommittee.Slot)
+	return nil
+}
+
+// SetBlock sets a block.
+func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	if err := s.Service.SetBlock(ctx, block); err != nil {
+		return err
+	}
+	s.record(ctx, "t_blocks", operationUpsert, map[string]string{
+		"root": fmt.Sprintf("%#x", block.Root),
+	}, &block.Slot)
+	if block.ExecutionPayload != nil {
+		s.record(ctx, "t_block_execution_payloads", operationUpsert, map[string]string{
+			"block_root": fmt.Sprintf("%#x", block.Root),
+		}, &block.Slot)
+	}
+	return nil
+}
+
+// SetBlockSummary sets a block summary.
+func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
+	if err := s.Service.SetBlockSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.record(ctx, "t_block_summaries", operationUpsert, map[string]string{
+		"slot": strconv.FormatUint(uint64(summary.Slot), 10),
+	}, &summary.Slot)
+	return nil
+}
+
+// SetChainSpecValue sets the value of the provided key.
+func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
+	if err := s.Service.SetChainSpecValue(ctx, key, value); err != nil {
+		return err
+	}
+	s.record(ctx, "t_chain_spec", operationUpsert, map[string]string{
+		"key": key,
+	}, nil)
+	return nil
+}
+
+// SetChainSpecValueFromEpoch sets the value of the provided key from the given epoch.
+func (s *Service) SetChainSpecValueFromEpoch(ctx context.Context, key string, value interface{}, epoch phase0.Epoch) error {
+	if err := s.Service.SetChainSpecValueFromEpoch(ctx, key, value, epoch); err != nil {
+		return err
+	}
+	s.record(ctx, "t_chain_spec", operationUpsert, map[string]string{
+		"key":             key,
+		"effective_epoch": fmt.Sprintf("%d", epoch),
+	}, nil)
+	return nil
+}
+
+// SetDeposit sets a deposit.
+func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
+	if err := s.Service.SetDeposit(ctx, deposit); err != nil {
+		return err
+	}
+	s.record(ctx, "t_deposits", operationUpsert,
+		inclusionKeys(deposit.InclusionSlot, deposit.InclusionBlockRoot, deposit.InclusionIndex),
+		&deposit.InclusionSlot)
+	return nil
+}
+
+// SetEpochSummary sets an epoch summary.
+func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
+	if err := s.Service.SetEpochSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.record(ctx, "t_epoch_summaries", operationUpsert, map[string]string{
+		"epoch": strconv.FormatUint(uint64(summary.Epoch), 10),
+	}, nil)
+	return nil
+}
+
+// SetETH1Deposit sets an Ethereum 1 deposit.
+func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
+	if err := s.Service.SetETH1Deposit(ctx, deposit); err != nil {
+		return err
+	}
+	s.record(ctx, "t_eth1_deposits", operationUpsert, map[string]string{
+		"deposit_index": strconv.FormatUint(deposit.DepositIndex, 10),
+	}, nil)
+	return nil
+}
+
+// DeleteETH1Deposits deletes the Ethereum 1 deposits made in the given range of blocks, inclusive.
+func (s *Service) DeleteETH1Deposits(ctx context.Context, startBlock uint64, endBlock uint64) error {
+	if err := s.Service.DeleteETH1Deposits(ctx, startBlock, endBlock); err != nil {
+		return err
+	}
+	s.record(ctx, "t_eth1_deposits", operationDelete, map[string]string{
+		"start_block": strconv.FormatUint(startBlock, 10),
+		"end_block":   strconv.FormatUint(endBlock, 10),
+	}, nil)
+	return nil
+}
+
+// SetForkSchedule sets the fork schedule.
+func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
+	if err := s.Service.SetForkSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	// The fork schedule is replaced in its entirety.
+	s.record(ctx, "t_fork_schedule", "replace", map[string]string{
+		"forks": strconv.Itoa(len(schedule)),
+	}, nil)
+	return nil
+}
+
+// SetGenesis sets the genesis information.
+func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
+	if err := s.Service.SetGenesis(ctx, genesis); err != nil {
+		return err
+	}
+	s.record(ctx, "t_genesis", operationUpsert, map[string]string{
+		"validators_root": fmt.Sprintf("%#x", genesis.GenesisValidatorsRoot),
+	}, nil)
+	return nil
+}
+
+// SetMetadata sets a metadata key to a JSON value.
+func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) error {
+	if err := s.Service.SetMetadata(ctx, key, value); err != nil {
+		return err
+	}
+	s.record(ctx, "t_metadata", operationUpsert, map[string]string{
+		"key": key,
+	}, nil)
+	return nil
+}
+
+// SetProposerDuty sets a proposer duty.
+func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	if err := s.Service.SetProposerDuty(ctx, proposerDuty); err != nil {
+		return err
+	}
+	s.record(ctx, "t_proposer_duties", operationUpsert, map[string]string{
+		"slot": strconv.FormatUint(uint64(proposerDuty.Slot), 10),
+	}, &proposerDuty.Slot)
+	return nil
+}
+
+// SetProposerSlashing sets a proposer slashing.
+func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
+	if err := s.Service.SetProposerSlashing(ctx, proposerSlashing); err != nil {
+		return err
+	}
+	s.record(ctx, "t_proposer_slashings", operationUpsert,
+		inclusionKeys(proposerSlashing.InclusionSlot, proposerSlashing.InclusionBlockRoot, proposerSlashing.InclusionIndex),
+		&proposerSlashing.InclusionSlot)
+	return nil
+}
+
+// SetSyncAggregate sets the sync aggregate.
+func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
+	if err := s.Service.SetSyncAggregate(ctx, syncAggregate); err != nil {
+		return err
+	}
+	s.record(ctx, "t_sync_aggregates", operationUpsert, map[string]string{
+		"inclusion_slot":       strconv.FormatUint(uint64(syncAggregate.InclusionSlot), 10),
+		"inclusion_block_root": fmt.Sprintf("%#x", syncAggregate.InclusionBlockRoot),
+	}, &syncAggregate.InclusionSlot)
+	return nil
+}
+
+// SetBlockArrival records the arrival of a block.
+func (s *Service) SetBlockArrival(ctx context.Context, arrival *chaindb.BlockArrival) error {
+	if err := s.Service.SetBlockArrival(ctx, arrival); err != nil {
+		return err
+	}
+	s.record(ctx, "t_block_arrivals", operationUpsert, map[string]string{
+		"block_root": fmt.Sprintf("%#x", arrival.BlockRoot),
+	}, &arrival.Slot)
+	return nil
+}
+
+// SetBlockClients records the likely clients of the proposers of blocks.
+func (s *Service) SetBlockClients(ctx context.Context, clients []*chaindb.BlockClient) error {
+	if err := s.Service.SetBlockClients(ctx, clients); err != nil {
+		return err
+	}
+	for i := range clients {
+		s.record(ctx, "t_block_clients", operationUpsert, map[string]string{
+			"block_root": fmt.Sprintf("%#x", clients[i].BlockRoot),
+		}, &clients[i].Slot)
+	}
+	return nil
+}
+
+// SetBlockRelays records the relays that delivered the execution payloads of blocks.
+func (s *Service) SetBlockRelays(ctx context.Context, relays []*chaindb.BlockRelays) error {
+	if err := s.Service.SetBlockRelays(ctx, relays); err != nil {
+		return err
+	}
+	for i := range relays {
+		s.record(ctx, "t_block_execution_payloads", operationUpsert, map[string]string{
+			"block_root": fmt.Sprintf("%#x", relays[i].BlockRoot),
+		}, nil)
+	}
+	return nil
+}
+
+// SetValidatorRegistrations records the registrations of validators with relays.
+func (s *Service) SetValidatorRegistrations(ctx context.Context, registrations []*chaindb.ValidatorRegistration) error {
+	if err := s.Service.SetValidatorRegistrations(ctx, registrations); err != nil {
+		return err
+	}
+	for i := range registrations {
+		s.record(ctx, "t_validator_registrations", operationUpsert, map[string]string{
+			"validator_index": fmt.Sprintf("%d", registrations[i].ValidatorIndex),
+			"relay":           registrations[i].Relay,
+			"timestamp":       registrations[i].Timestamp.UTC().Format(time.RFC3339),
+		}, nil)
+	}
+	return nil
+}
+
+// SetQuarantinedItem records data that failed to be stored.
+func (s *Service) SetQuarantinedItem(ctx context.Context, item *chaindb.QuarantinedItem) error {
+	if err := s.Service.SetQuarantinedItem(ctx, item); err != nil {
+		return err
+	}
+	s.record(ctx, "t_quarantine", operationUpsert, map[string]string{
+		"module": item.Module,
+		"slot":   fmt.Sprintf("%d", item.Slot),
+	}, &item.Slot)
+	return nil
+}
+
+// DeleteQuarantinedItems removes the quarantined items of a module for the given slots.
+func (s *Service) DeleteQuarantinedItems(ctx context.Context, module string, slots []phase0.Slot) error {
+	if err := s.Service.DeleteQuarantinedItems(ctx, module, slots); err != nil {
+		return err
+	}
+	for i := range slots {
+		s.record(ctx, "t_quarantine", operationDelete, map[string]string{
+			"module": module,
+			"slot":   fmt.Sprintf("%d", slots[i]),
+		}, &slots[i])
+	}
+	return nil
+}
+
+// SetGossipAttestations records attestations seen on the network.
+// A single entry is recorded for each slot, as recording each attestation would
+// make the audit log as large as the table itself.
+func (s *Service) SetGossipAttestations(ctx context.Context, attestations []*chaindb.GossipAttestation) error {
+	if err := s.Service.SetGossipAttestations(ctx, attestations); err != nil {
+		return err
+	}
+	rows := make(map[phase0.Slot]int)
+	slots := make([]phase0.Slot, 0)
+	for _, attestation := range attestations {
+		if _, exists := rows[attestation.Slot]; !exists {
+			slots = append(slots, attestation.Slot)
+		}
+		rows[attestation.Slot]++
+	}
+	for i := range slots {
+		s.record(ctx, "t_gossip_attestations", "insert", map[string]string{
+			"slot": strconv.FormatUint(uint64(slots[i]), 10),
+			"rows": strconv.Itoa(rows[slots[i]]),
+		}, &slots[i])
+	}
+	return nil
+}
+
+// SetPeerCounts records peer counts.
+func (s *Service) SetPeerCounts(ctx context.Context, counts []*chaindb.PeerCount) error {
+	if err := s.Service.SetPeerCounts(ctx, counts); err != nil {
+		return err
+	}
+	rows := make(map[time.Time]int)
+	timestamps := make([]time.Time, 0)
+	for _, count := range counts {
+		if _, exists := rows[count.Timestamp]; !exists {
+			timestamps = append(timestamps, count.Timestamp)
+		}
+		rows[count.Timestamp]++
+	}
+	for _, timestamp := range timestamps {
+		s.record(ctx, "t_peer_counts", operationUpsert, map[string]string{
+			"timestamp": timestamp.UTC().Format(time.RFC3339),
+			"rows":      strconv.Itoa(rows[timestamp]),
+		}, nil)
+	}
+	return nil
+}
+
+// SetMissedSlot marks a slot as not having a block.
+func (s *Service) SetMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	if err := s.Service.SetMissedSlot(ctx, slot); err != nil {
+		return err
+	}
+	s.record(ctx, "t_missed_slots", "insert", map[string]string{
+		"slot": strconv.FormatUint(uint64(slot), 10),
+	}, &slot)
+	return nil
+}
+
+// SetSyncCommittee sets a sync committee.
+func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
+	if err := s.Service.SetSyncCommittee(ctx, syncCommittee); err != nil {
+		return err
+	}
+	s.record(ctx, "t_sync_committees", operationUpsert, map[string]string{
+		"period": strconv.FormatUint(syncCommittee.Period, 10),
+	}, nil)
+	return nil
+}
+
+// SetValidator sets a validator.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	if err := s.Service.SetValidator(ctx, validator); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validators", operationUpsert, map[string]string{
+		"index": strconv.FormatUint(uint64(validator.Index), 10),
+	}, nil)
+	return nil
+}
+
+// SetValidatorBalance sets a validator balance.
+func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) error {
+	if err := s.Service.SetValidatorBalance(ctx, balance); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_balances", operationUpsert, map[string]string{
+		"validator_index": strconv.FormatUint(uint64(balance.Index), 10),
+		"epoch":           strconv.FormatUint(uint64(balance.Epoch), 10),
+	}, nil)
+	return nil
+}
+
+// SetValidatorBalances sets multiple validator balances.
+// A single entry is recorded for each epoch, as recording each balance would
+// make the audit log as large as the table itself.
+func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.ValidatorBalance) error {
+	if err := s.Service.SetValidatorBalances(ctx, balances); err != nil {
+		return err
+	}
+	rows := make(map[phase0.Epoch]int)
+	epochs := make([]phase0.Epoch, 0)
+	for _, balance := range balances {
+		if _, exists := rows[balance.Epoch]; !exists {
+			epochs = append(epochs, balance.Epoch)
+		}
+		rows[balance.Epoch]++
+	}
+	for _, epoch := range epochs {
+		s.record(ctx, "t_validator_balances", "insert", map[string]string{
+			"epoch": strconv.FormatUint(uint64(epoch), 10),
+			"rows":  strconv.Itoa(rows[epoch]),
+		}, nil)
+	}
+	return nil
+}
+
+// SetValidatorEpochSummary sets a validator epoch summary.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	if err := s.Service.SetValidatorEpochSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_epoch_summaries", operationUpsert, map[string]string{
+		"validator_index": strconv.FormatUint(uint64(summary.Index), 10),
+		"epoch":           strconv.FormatUint(uint64(summary.Epoch), 10),
+	}, nil)
+	return nil
+}
+
+// SetValidatorEpochSummaries sets multiple validator epoch summaries.
+// A single entry is recorded for each epoch, as recording each summary would
+// make the audit log as large as the table itself.
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	if err := s.Service.SetValidatorEpochSummaries(ctx, summaries); err != nil {
+		return err
+	}
+	rows := make(map[phase0.Epoch]int)
+	epochs := make([]phase0.Epoch, 0)
+	for _, summary := range summaries {
+		if _, exists := rows[summary.Epoch]; !exists {
+			epochs = append(epochs, summary.Epoch)
+		}
+		rows[summary.Epoch]++
+	}
+	for _, epoch := range epochs {
+		s.record(ctx, "t_validator_epoch_summaries", "insert", map[string]string{
+			"epoch": strconv.FormatUint(uint64(epoch), 10),
+			"rows":  strconv.Itoa(rows[epoch]),
+		}, nil)
+	}
+	return nil
+}
+
+// PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given epoch.
+func (s *Service) PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch) error {
+	if err := s.Service.PruneValidatorEpochSummaries(ctx, to); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_epoch_summaries", operationDelete, map[string]string{
+		"to_epoch": strconv.FormatUint(uint64(to), 10),
+	}, nil)
+	return nil
+}
+
+// PruneTable removes the rows of the given table for epochs up to (but not including) the given epoch.
+func (s *Service) PruneTable(ctx context.Context, name string, epoch phase0.Epoch, slot phase0.Slot) (int64, error) {
+	rows, err := s.Service.PruneTable(ctx, name, epoch, slot)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, name, operationDelete, map[string]string{
+		"to_epoch": strconv.FormatUint(uint64(epoch), 10),
+		"rows":     strconv.FormatInt(rows, 10),
+	}, nil)
+	return rows, nil
+}
+
+// SetValidatorDaySummaries sets multiple validator day summaries.
+func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*chaindb.ValidatorDaySummary) error {
+	if err := s.Service.SetValidatorDaySummaries(ctx, summaries); err != nil {
+		return err
+	}
+	rows := make(map[time.Time]int)
+	days := make([]time.Time, 0)
+	for _, summary := range summaries {
+		if _, exists := rows[summary.StartTimestamp]; !exists {
+			days = append(days, summary.StartTimestamp)
+		}
+		rows[summary.StartTimestamp]++
+	}
+	for _, day := range days {
+		s.record(ctx, "t_validator_day_summaries", operationUpsert, map[string]string{
+			"start_timestamp": day.UTC().Format(time.RFC3339),
+			"rows":            strconv.Itoa(rows[day]),
+		}, nil)
+	}
+	return nil
+}
+
+// PruneValidatorDaySummaries prunes validator day summaries up to (but not including) the given timestamp.
+func (s *Service) PruneValidatorDaySummaries(ctx context.Context, to time.Time) error {
+	if err := s.Service.PruneValidatorDaySummaries(ctx, to); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_day_summaries", operationDelete, map[string]string{
+		"to_timestamp": to.UTC().Format(time.RFC3339),
+	}, nil)
+	return nil
+}
+
+// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	if err := s.Service.DeleteEpochSummaries(ctx, startEpoch, endEpoch); err != nil {
+		return err
+	}
+	s.record(ctx, "t_epoch_summaries", operationDelete, epochRangeKeys(startEpoch, endEpoch), nil)
+	return nil
+}
+
+// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+func (s *Service) DeleteBlockSummaries(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	if err := s.Service.DeleteBlockSummaries(ctx, startSlot, endSlot); err != nil {
+		return err
+	}
+	s.record(ctx, "t_block_summaries", operationDelete, map[string]string{
+		"start_slot": strconv.FormatUint(uint64(startSlot), 10),
+		"end_slot":   strconv.FormatUint(uint64(endSlot), 10),
+	}, nil)
+	return nil
+}
+
+// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteValidatorEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	if err := s.Service.DeleteValidatorEpochSummaries(ctx, startEpoch, endEpoch); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_epoch_summaries", operationDelete, epochRangeKeys(startEpoch, endEpoch), nil)
+	return nil
+}
+
+// DeleteValidatorDaySummaries deletes the validator day summaries for days starting in the given range
+// of timestamps, inclusive.
+func (s *Service) DeleteValidatorDaySummaries(ctx context.Context, startTimestamp time.Time, endTimestamp time.Time) error {
+	if err := s.Service.DeleteValidatorDaySummaries(ctx, startTimestamp, endTimestamp); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_day_summaries", operationDelete, map[string]string{
+		"start_timestamp": startTimestamp.UTC().Format(time.RFC3339),
+		"end_timestamp":   endTimestamp.UTC().Format(time.RFC3339),
+	}, nil)
+	return nil
+}
+
+// epochRangeKeys returns the keys for rows identified by a range of epochs.
+func epochRangeKeys(startEpoch phase0.Epoch, endEpoch phase0.Epoch) map[string]string {
+	return map[string]string{
+		"start_epoch": strconv.FormatUint(uint64(startEpoch), 10),
+		"end_epoch":   strconv.FormatUint(uint64(endEpoch), 10),
+	}
+}
+
+// SetValidatorEffectiveness sets multiple validator effectiveness scores.
+func (s *Service) SetValidatorEffectiveness(ctx context.Context, effectiveness []*chaindb.ValidatorEffectiveness) error {
+	if err := s.Service.SetValidatorEffectiveness(ctx, effectiveness); err != nil {
+		return err
+	}
+	rows := make(map[phase0.Epoch]int)
+	epochs := make([]phase0.Epoch, 0)
+	for _, score := range effectiveness {
+		if _, exists := rows[score.Epoch]; !exists {
+			epochs = append(epochs, score.Epoch)
+		}
+		rows[score.Epoch]++
+	}
+	for _, epoch := range epochs {
+		s.record(ctx, "t_validator_effectiveness", operationUpsert, map[string]string{
+			"epoch": strconv.FormatUint(uint64(epoch), 10),
+			"rows":  strconv.Itoa(rows[epoch]),
+		}, nil)
+	}
+	return nil
+}
+
+// SetValidatorIncome sets multiple validator incomes.
+func (s *Service) SetValidatorIncome(ctx context.Context, income []*chaindb.ValidatorIncome) error {
+	if err := s.Service.SetValidatorIncome(ctx, income); err != nil {
+		return err
+	}
+	rows := make(map[phase0.Epoch]int)
+	epochs := make([]phase0.Epoch, 0)
+	for _, item := range income {
+		if _, exists := rows[item.Epoch]; !exists {
+			epochs = append(epochs, item.Epoch)
+		}
+		rows[item.Epoch]++
+	}
+	for _, epoch := range epochs {
+		s.record(ctx, "t_validator_income", operationUpsert, map[string]string{
+			"epoch": strconv.FormatUint(uint64(epoch), 10),
+			"rows":  strconv.Itoa(rows[epoch]),
+		}, nil)
+	}
+	return nil
+}
+
+// SetValidatorLabels sets the labels of a validator, replacing any existing labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, index phase0.ValidatorIndex, labels []string) error {
+	if err := s.Service.SetValidatorLabels(ctx, index, labels); err != nil {
+		return err
+	}
+	s.record(ctx, "t_validator_labels", operationUpsert, map[string]string{
+		"validator_index": strconv.FormatUint(uint64(index), 10),
+		"labels":          strings.Join(labels, ","),
+	}, nil)
+	return nil
+}
+
+// SetTenants sets the tenants, replacing any existing tenants.
+func (s *Service) SetTenants(ctx context.Context, tenants []*chaindb.Tenant) error {
+	if err := s.Service.SetTenants(ctx, tenants); err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		s.record(ctx, "t_tenants", operationUpsert, map[string]string{
+			"role":   tenant.Role,
+			"labels": strings.Join(tenant.Labels, ","),
+			"tables": strings.Join(tenant.Tables, ","),
+		}, nil)
+	}
+	return nil
+}
+
+// SetVoluntaryExit sets a voluntary exit.
+func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
+	if err := s.Service.SetVoluntaryExit(ctx, voluntaryExit); err != nil {
+		return err
+	}
+	s.record(ctx, "t_voluntary_exits", operationUpsert,
+		inclusionKeys(voluntaryExit.InclusionSlot, voluntaryExit.InclusionBlockRoot, voluntaryExit.InclusionIndex),
+		&voluntaryExit.InclusionSlot)
+	return nil
+}
+
+// UpdateAggregate adds up to the given number of summarized epochs, from the given epoch
+// onwards, to the given aggregate.
+func (s *Service) UpdateAggregate(ctx context.Context, name string, fromEpoch phase0.Epoch, epochs uint64) (phase0.Epoch, error) {
+	toEpoch, err := s.Service.UpdateAggregate(ctx, name, fromEpoch, epochs)
+	if err != nil {
+		return 0, err
+	}
+	if toEpoch != fromEpoch {
+		keys := map[string]string{
+			"from_epoch": strconv.FormatUint(uint64(fromEpoch), 10),
+			"to_epoch":   strconv.FormatUint(uint64(toEpoch), 10),
+		}
+		s.record(ctx, name, operationUpsert, keys, nil)
+	}
+	return toEpoch, nil
+}
+
+// DeleteSlotData deletes blocks, along with their contents, and missed slot markers in the given slot range.
+func (s *Service) DeleteSlotData(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	if err := s.Service.DeleteSlotData(ctx, startSlot, endSlot); err != nil {
+		return err
+	}
+	keys := map[string]string{
+		"start_slot": strconv.FormatUint(uint64(startSlot), 10),
+		"end_slot":   strconv.FormatUint(uint64(endSlot), 10),
+	}
+	s.record(ctx, "t_blocks", "delete", keys, &startSlot)
+	s.record(ctx, "t_missed_slots", "delete", keys, &startSlot)
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a chain database service that reads from the underlying database, but logs
+// writes rather than carrying them out.  Transactions are always rolled back.
+type Service struct {
+	log zerolog.Logger
+	*postgresql.Service
+}
+
+// txCancel is a context tag for the function that rolls back the transaction.
+type txCancel struct{}
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "chaindb", "dryrun", parameters.logLevel)
+
+	return &Service{
+		log:     log,
+		Service: parameters.chainDB,
+	}, nil
+}
+
+// BeginTx begins a transaction on the underlying database, so that reads are consistent.
+func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel, err := s.Service.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return context.WithValue(ctx, txCancel{}, cancel), cancel, nil
+}
+
+// CommitTx rolls back the transaction, as nothing is written in a dry run.
+func (s *Service) CommitTx(ctx context.Context) error {
+	cancel, ok := ctx.Value(txCancel{}).(context.CancelFunc)
+	if !ok {
+		return postgresql.ErrNoTransaction
+	}
+	s.log.Trace().Msg("Dry run; rolling back transaction instead of committing")
+	cancel()
+
+	return nil
+}
@@ -0,0 +1,618 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// write returns a log event for an item that would be written.
+// It returns an error if the write is not inside a transaction, as it would for a real write.
+func (s *Service) write(ctx context.Context, item string) (*zerolog.Event, error) {
+	if _, ok := ctx.Value(txCancel{}).(context.CancelFunc); !ok {
+		return nil, postgresql.ErrNoTransaction
+	}
+
+	return s.log.Info().Str("item", item), nil
+}
+
+// SetAttestation logs the attestation that would be written.
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	e, err := s.write(ctx, "attestation")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).
+		Uint64("inclusion_index", attestation.InclusionIndex).
+		Uint64("slot", uint64(attestation.Slot)).
+		Uint64("committee_index", uint64(attestation.CommitteeIndex)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetAttesterSlashing logs the attester slashing that would be written.
+func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
+	e, err := s.write(ctx, "attester slashing")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(attesterSlashing.InclusionSlot)).
+		Uint64("inclusion_index", attesterSlashing.InclusionIndex).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBeaconCommittee logs the beacon committee that would be written.
+func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	e, err := s.write(ctx, "beacon committee")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(beaconCommittee.Slot)).
+		Uint64("index", uint64(beaconCommittee.Index)).
+		Int("validators", len(beaconCommittee.Committee)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBlock logs the block that would be written.
+func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	e, err := s.write(ctx, "block")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(block.Slot)).
+		Str("root", fmt.Sprintf("%#x", block.Root)).
+		Str("parent_root", fmt.Sprintf("%#x", block.ParentRoot)).
+		Bool("execution_payload", block.ExecutionPayload != nil).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBlockSummary logs the block summary that would be written.
+func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
+	e, err := s.write(ctx, "block summary")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(summary.Slot)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetChainSpecValue logs the chain specification value that would be written.
+func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
+	e, err := s.write(ctx, "chain spec value")
+	if err != nil {
+		return err
+	}
+	e.Str("key", key).
+		Str("value", fmt.Sprintf("%v", value)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetChainSpecValueFromEpoch logs the chain specification value that would be written.
+func (s *Service) SetChainSpecValueFromEpoch(ctx context.Context, key string, value interface{}, epoch phase0.Epoch) error {
+	e, err := s.write(ctx, "chain spec value")
+	if err != nil {
+		return err
+	}
+	e.Str("key", key).
+		Str("value", fmt.Sprintf("%v", value)).
+		Uint64("effective_epoch", uint64(epoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetDeposit logs the deposit that would be written.
+func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
+	e, err := s.write(ctx, "deposit")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(deposit.InclusionSlot)).
+		Uint64("inclusion_index", deposit.InclusionIndex).
+		Str("validator_pubkey", fmt.Sprintf("%#x", deposit.ValidatorPubKey)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetEpochSummary logs the epoch summary that would be written.
+func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
+	e, err := s.write(ctx, "epoch summary")
+	if err != nil {
+		return err
+	}
+	e.Uint64("epoch", uint64(summary.Epoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetETH1Deposit logs the Ethereum 1 deposit that would be written.
+func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
+	e, err := s.write(ctx, "Ethereum 1 deposit")
+	if err != nil {
+		return err
+	}
+	e.Uint64("block_number", deposit.ETH1BlockNumber).
+		Uint64("deposit_index", deposit.DepositIndex).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteETH1Deposits logs the Ethereum 1 deposits that would be deleted.
+func (s *Service) DeleteETH1Deposits(ctx context.Context, startBlock uint64, endBlock uint64) error {
+	e, err := s.write(ctx, "Ethereum 1 deposits deletion")
+	if err != nil {
+		return err
+	}
+	e.Uint64("start_block", startBlock).
+		Uint64("end_block", endBlock).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetForkSchedule logs the fork schedule that would be written.
+func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
+	e, err := s.write(ctx, "fork schedule")
+	if err != nil {
+		return err
+	}
+	e.Int("forks", len(schedule)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetGenesis logs the genesis information that would be written.
+func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
+	e, err := s.write(ctx, "genesis")
+	if err != nil {
+		return err
+	}
+	e.Time("genesis_time", genesis.GenesisTime).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetMetadata logs the metadata that would be written.
+func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) error {
+	e, err := s.write(ctx, "metadata")
+	if err != nil {
+		return err
+	}
+	e.Str("key", key).
+		RawJSON("value", value).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetProposerDuty logs the proposer duty that would be written.
+func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	e, err := s.write(ctx, "proposer duty")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(proposerDuty.Slot)).
+		Uint64("validator_index", uint64(proposerDuty.ValidatorIndex)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetProposerSlashing logs the proposer slashing that would be written.
+func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
+	e, err := s.write(ctx, "proposer slashing")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(proposerSlashing.InclusionSlot)).
+		Uint64("inclusion_index", proposerSlashing.InclusionIndex).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetSyncAggregate logs the sync aggregate that would be written.
+func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
+	e, err := s.write(ctx, "sync aggregate")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(syncAggregate.InclusionSlot)).
+		Int("validators", len(syncAggregate.Indices)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBlockArrival logs the block arrival that would be written.
+func (s *Service) SetBlockArrival(ctx context.Context, arrival *chaindb.BlockArrival) error {
+	e, err := s.write(ctx, "block arrival")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(arrival.Slot)).
+		Str("block_root", fmt.Sprintf("%#x", arrival.BlockRoot)).
+		Dur("delay", arrival.ArrivalTime.Sub(arrival.SlotTime)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBlockClients logs the block clients that would be written.
+func (s *Service) SetBlockClients(ctx context.Context, clients []*chaindb.BlockClient) error {
+	e, err := s.write(ctx, "block clients")
+	if err != nil {
+		return err
+	}
+	e.Int("blocks", len(clients)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetBlockRelays logs the block relays that would be written.
+func (s *Service) SetBlockRelays(ctx context.Context, relays []*chaindb.BlockRelays) error {
+	e, err := s.write(ctx, "block relays")
+	if err != nil {
+		return err
+	}
+	e.Int("blocks", len(relays)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorRegistrations logs the validator registrations that would be written.
+func (s *Service) SetValidatorRegistrations(ctx context.Context, registrations []*chaindb.ValidatorRegistration) error {
+	e, err := s.write(ctx, "validator registrations")
+	if err != nil {
+		return err
+	}
+	e.Int("registrations", len(registrations)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetQuarantinedItem logs the quarantined item that would be written.
+func (s *Service) SetQuarantinedItem(ctx context.Context, item *chaindb.QuarantinedItem) error {
+	e, err := s.write(ctx, "quarantined item")
+	if err != nil {
+		return err
+	}
+	e.Str("module", item.Module).
+		Uint64("slot", uint64(item.Slot)).
+		Str("reason", item.Reason).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteQuarantinedItems logs the quarantined items that would be deleted.
+func (s *Service) DeleteQuarantinedItems(ctx context.Context, module string, slots []phase0.Slot) error {
+	e, err := s.write(ctx, "quarantined items")
+	if err != nil {
+		return err
+	}
+	e.Str("module", module).
+		Int("slots", len(slots)).
+		Msg("Dry run; not deleting")
+	return nil
+}
+
+// SetGossipAttestations logs the gossip attestations that would be written.
+func (s *Service) SetGossipAttestations(ctx context.Context, attestations []*chaindb.GossipAttestation) error {
+	e, err := s.write(ctx, "gossip attestations")
+	if err != nil {
+		return err
+	}
+	e.Int("attestations", len(attestations)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetPeerCounts logs the peer counts that would be written.
+func (s *Service) SetPeerCounts(ctx context.Context, counts []*chaindb.PeerCount) error {
+	e, err := s.write(ctx, "peer counts")
+	if err != nil {
+		return err
+	}
+	e.Int("counts", len(counts)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetMissedSlot logs the missed slot that would be written.
+func (s *Service) SetMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	e, err := s.write(ctx, "missed slot")
+	if err != nil {
+		return err
+	}
+	e.Uint64("slot", uint64(slot)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteSlotData logs the slot data that would be deleted.
+func (s *Service) DeleteSlotData(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	e, err := s.write(ctx, "slot data deletion")
+	if err != nil {
+		return err
+	}
+	e.Uint64("start_slot", uint64(startSlot)).
+		Uint64("end_slot", uint64(endSlot)).
+		Msg("Dry run; not deleting")
+	return nil
+}
+
+// SetSyncCommittee logs the sync committee that would be written.
+func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
+	e, err := s.write(ctx, "sync committee")
+	if err != nil {
+		return err
+	}
+	e.Uint64("period", syncCommittee.Period).
+		Int("validators", len(syncCommittee.Committee)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidator logs the validator that would be written.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	e, err := s.write(ctx, "validator")
+	if err != nil {
+		return err
+	}
+	e.Uint64("index", uint64(validator.Index)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorBalance logs the validator balance that would be written.
+func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) error {
+	e, err := s.write(ctx, "validator balance")
+	if err != nil {
+		return err
+	}
+	e.Uint64("index", uint64(balance.Index)).
+		Uint64("epoch", uint64(balance.Epoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorBalances logs the validator balances that would be written.
+func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.ValidatorBalance) error {
+	e, err := s.write(ctx, "validator balances")
+	if err != nil {
+		return err
+	}
+	e.Int("balances", len(balances)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorEpochSummary logs the validator epoch summary that would be written.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	e, err := s.write(ctx, "validator epoch summary")
+	if err != nil {
+		return err
+	}
+	e.Uint64("index", uint64(summary.Index)).
+		Uint64("epoch", uint64(summary.Epoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorEpochSummaries logs the validator epoch summaries that would be written.
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	e, err := s.write(ctx, "validator epoch summaries")
+	if err != nil {
+		return err
+	}
+	e.Int("summaries", len(summaries)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// PruneValidatorEpochSummaries logs the validator epoch summaries that would be pruned.
+func (s *Service) PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch) error {
+	e, err := s.write(ctx, "validator epoch summaries pruning")
+	if err != nil {
+		return err
+	}
+	e.Uint64("to_epoch", uint64(to)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// PruneTable logs the table that would be pruned.
+func (s *Service) PruneTable(ctx context.Context, name string, epoch phase0.Epoch, _ phase0.Slot) (int64, error) {
+	e, err := s.write(ctx, "table pruning")
+	if err != nil {
+		return 0, err
+	}
+	e.Str("table", name).
+		Uint64("to_epoch", uint64(epoch)).
+		Msg("Dry run; not writing")
+	return 0, nil
+}
+
+// SetValidatorDaySummaries logs the validator day summaries that would be written.
+func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*chaindb.ValidatorDaySummary) error {
+	e, err := s.write(ctx, "validator day summaries")
+	if err != nil {
+		return err
+	}
+	e.Int("summaries", len(summaries)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// PruneValidatorDaySummaries logs the validator day summaries that would be pruned.
+func (s *Service) PruneValidatorDaySummaries(ctx context.Context, to time.Time) error {
+	e, err := s.write(ctx, "validator day summaries pruning")
+	if err != nil {
+		return err
+	}
+	e.Time("to_timestamp", to).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteEpochSummaries logs the epoch summaries that would be deleted.
+func (s *Service) DeleteEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	e, err := s.write(ctx, "epoch summaries deletion")
+	if err != nil {
+		return err
+	}
+	e.Uint64("start_epoch", uint64(startEpoch)).
+		Uint64("end_epoch", uint64(endEpoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteBlockSummaries logs the block summaries that would be deleted.
+func (s *Service) DeleteBlockSummaries(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	e, err := s.write(ctx, "block summaries deletion")
+	if err != nil {
+		return err
+	}
+	e.Uint64("start_slot", uint64(startSlot)).
+		Uint64("end_slot", uint64(endSlot)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteValidatorEpochSummaries logs the validator epoch summaries that would be deleted.
+func (s *Service) DeleteValidatorEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	e, err := s.write(ctx, "validator epoch summaries deletion")
+	if err != nil {
+		return err
+	}
+	e.Uint64("start_epoch", uint64(startEpoch)).
+		Uint64("end_epoch", uint64(endEpoch)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// DeleteValidatorDaySummaries logs the validator day summaries that would be deleted.
+func (s *Service) DeleteValidatorDaySummaries(ctx context.Context, startTimestamp time.Time, endTimestamp time.Time) error {
+	e, err := s.write(ctx, "validator day summaries deletion")
+	if err != nil {
+		return err
+	}
+	e.Time("start_timestamp", startTimestamp).
+		Time("end_timestamp", endTimestamp).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorEffectiveness logs the validator effectiveness scores that would be written.
+func (s *Service) SetValidatorEffectiveness(ctx context.Context, effectiveness []*chaindb.ValidatorEffectiveness) error {
+	e, err := s.write(ctx, "validator effectiveness")
+	if err != nil {
+		return err
+	}
+	e.Int("scores", len(effectiveness)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorIncome logs the validator incomes that would be written.
+func (s *Service) SetValidatorIncome(ctx context.Context, income []*chaindb.ValidatorIncome) error {
+	e, err := s.write(ctx, "validator income")
+	if err != nil {
+		return err
+	}
+	e.Int("incomes", len(income)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetValidatorLabels logs the validator labels that would be written.
+func (s *Service) SetValidatorLabels(ctx context.Context, index phase0.ValidatorIndex, labels []string) error {
+	e, err := s.write(ctx, "validator labels")
+	if err != nil {
+		return err
+	}
+	e.Uint64("validator_index", uint64(index)).
+		Strs("labels", labels).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// SetTenants logs the tenants that would be written.
+func (s *Service) SetTenants(ctx context.Context, tenants []*chaindb.Tenant) error {
+	for _, tenant := range tenants {
+		e, err := s.write(ctx, "tenant")
+		if err != nil {
+			return err
+		}
+		e.Str("role", tenant.Role).
+			Strs("labels", tenant.Labels).
+			Strs("tables", tenant.Tables).
+			Msg("Dry run; not writing")
+	}
+	return nil
+}
+
+// SetVoluntaryExit logs the voluntary exit that would be written.
+func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
+	e, err := s.write(ctx, "voluntary exit")
+	if err != nil {
+		return err
+	}
+	e.Uint64("inclusion_slot", uint64(voluntaryExit.InclusionSlot)).
+		Uint64("validator_index", uint64(voluntaryExit.ValidatorIndex)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// UpdateAggregate logs the aggregate that would be updated.  As nothing is written,
+// no epochs are added.
+func (s *Service) UpdateAggregate(ctx context.Context, name string, fromEpoch phase0.Epoch, epochs uint64) (phase0.Epoch, error) {
+	e, err := s.write(ctx, "aggregate")
+	if err != nil {
+		return 0, err
+	}
+	e.Str("name", name).
+		Uint64("from_epoch", uint64(fromEpoch)).
+		Uint64("epochs", epochs).
+		Msg("Dry run; not updating")
+	return fromEpoch, nil
+}
+
+// SetAuditEntries logs the number of audit entries that would be written.
+func (s *Service) SetAuditEntries(ctx context.Context, entries []*chaindb.AuditEntry) error {
+	e, err := s.write(ctx, "audit entries")
+	if err != nil {
+		return err
+	}
+	e.Int("entries", len(entries)).
+		Msg("Dry run; not writing")
+	return nil
+}
+
+// BeginBackfill does nothing, as nothing is written in a dry run.
+func (s *Service) BeginBackfill(_ context.Context) error {
+	s.log.Info().Msg("Dry run; not preparing database for backfill")
+	return nil
+}
+
+// EndBackfill does nothing, as nothing is written in a dry run.
+func (*Service) EndBackfill(_ context.Context) error {
+	return nil
+}
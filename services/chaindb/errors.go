@@ -0,0 +1,31 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+// InvalidDataError is returned when chaind itself rejects data before it reaches the database,
+// for example a block whose roots cannot be calculated.  Writing the same data again fails in
+// the same way, so write error classifiers treat it as deterministic.
+type InvalidDataError struct {
+	Err error
+}
+
+// Error returns the message of the underlying error.
+func (e *InvalidDataError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *InvalidDataError) Unwrap() error {
+	return e.Err
+}
@@ -49,4 +49,9 @@ type ValidatorSummaryFilter struct {
 	// ValidatorIndices is the list of validator indices for which to obtain summaries.
 	// If nil then no filter is applied
 	ValidatorIndices *[]phase0.ValidatorIndex
+
+	// Labels is the list of labels for which to obtain summaries; summaries are obtained
+	// for validators with any of the labels.
+	// If nil then no filter is applied
+	Labels []string
 }
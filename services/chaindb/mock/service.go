@@ -15,352 +15,728 @@ package chaindb
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
-type service struct{}
+// Call is a call made to the mock chain database.
+type Call struct {
+	// Method is the name of the method called.
+	Method string
+	// Args are the arguments to the method, excluding the context.
+	Args []interface{}
+}
+
+// Service is a mock chain database.  It records all calls made to it, and
+// returns canned responses and errors set with SetResponse() and SetError().
+// Methods without a canned response return their zero value.
+type Service struct {
+	mu        sync.Mutex
+	calls     []*Call
+	responses map[string]interface{}
+	errors    map[string]error
+}
 
 // New creates a new mock chain database.
-func New() chaindb.Service {
-	return &service{}
+func New() *Service {
+	return &Service{
+		responses: map[string]interface{}{
+			"ChainSpec": map[string]interface{}{
+				"ALTAIR_FORK_EPOCH":                        uint64(74240),
+				"ALTAIR_FORK_VERSION":                      phase0.Version{0x01, 0x00, 0x00, 0x00},
+				"BASE_REWARD_FACTOR":                       uint64(64),
+				"BELLATRIX_FORK_EPOCH":                     uint64(18446744073709551615),
+				"BELLATRIX_FORK_VERSION":                   phase0.Version{0x02, 0x00, 0x00, 0x00},
+				"BLS_WITHDRAWAL_PREFIX":                    []byte{0x00},
+				"CHURN_LIMIT_QUOTIENT":                     uint64(65536),
+				"CONFIG_NAME":                              "mainnet",
+				"DEPOSIT_CHAIN_ID":                         1,
+				"DEPOSIT_CONTRACT_ADDRESS":                 []byte{0x00, 0x00, 0x00, 0x00, 0x21, 0x9a, 0xb5, 0x40, 0x35, 0x6c, 0xBB, 0x83, 0x9C, 0xbe, 0x05, 0x30, 0x3d, 0x77, 0x05, 0xFa},
+				"DEPOSIT_NETWORK_ID":                       1,
+				"DOMAIN_AGGREGATE_AND_PROOF":               phase0.DomainType{0x06, 0x00, 0x00, 0x00},
+				"DOMAIN_BEACON_ATTESTER":                   phase0.DomainType{0x01, 0x00, 0x00, 0x00},
+				"DOMAIN_BEACON_PROPOSER":                   phase0.DomainType{0x00, 0x00, 0x00, 0x00},
+				"DOMAIN_CONTRIBUTION_AND_PROOF":            phase0.DomainType{0x09, 0x00, 0x00, 0x00},
+				"DOMAIN_DEPOSIT":                           phase0.DomainType{0x03, 0x00, 0x00, 0x00},
+				"DOMAIN_RANDAO":                            phase0.DomainType{0x02, 0x00, 0x00, 0x00},
+				"DOMAIN_SELECTION_PROOF":                   phase0.DomainType{0x05, 0x00, 0x00, 0x00},
+				"DOMAIN_SYNC_COMMITTEE":                    phase0.DomainType{0x07, 0x00, 0x00, 0x00},
+				"DOMAIN_SYNC_COMMITTEE_SELECTION_PROOF":    phase0.DomainType{0x08, 0x00, 0x00, 0x00},
+				"DOMAIN_VOLUNTARY_EXIT":                    phase0.DomainType{0x04, 0x00, 0x00, 0x00},
+				"EFFECTIVE_BALANCE_INCREMENT":              uint64(1000000000),
+				"EJECTION_BALANCE":                         uint64(16000000000),
+				"EPOCHS_PER_ETH1_VOTING_PERIOD":            uint64(64),
+				"EPOCHS_PER_HISTORICAL_VECTOR":             uint64(65536),
+				"EPOCHS_PER_RANDOM_SUBNET_SUBSCRIPTION":    uint64(256),
+				"EPOCHS_PER_SLASHINGS_VECTOR":              uint64(8192),
+				"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":         uint64(256),
+				"ETH1_FOLLOW_DISTANCE":                     uint64(2048),
+				"GENESIS_DELAY":                            604800 * time.Second,
+				"GENESIS_FORK_VERSION":                     phase0.Version{0x00, 0x00, 0x00, 0x00},
+				"HISTORICAL_ROOTS_LIMIT":                   uint64(16777216),
+				"HYSTERESIS_DOWNWARD_MULTIPLIER":           uint64(1),
+				"HYSTERESIS_QUOTIENT":                      uint64(4),
+				"HYSTERESIS_UPWARD_MULTIPLIER":             uint64(5),
+				"INACTIVITY_PENALTY_QUOTIENT":              uint64(67108864),
+				"INACTIVITY_PENALTY_QUOTIENT_ALTAIR":       uint64(50331648),
+				"INACTIVITY_PENALTY_QUOTIENT_MERGE":        uint64(16777216),
+				"INACTIVITY_SCORE_BIAS":                    uint64(4),
+				"INACTIVITY_SCORE_RECOVERY_RATE":           uint64(16),
+				"MAX_ATTESTATIONS":                         uint64(128),
+				"MAX_ATTESTER_SLASHINGS":                   uint64(2),
+				"MAX_COMMITTEES_PER_SLOT":                  uint64(64),
+				"MAX_DEPOSITS":                             uint64(16),
+				"MAX_EFFECTIVE_BALANCE":                    uint64(32000000000),
+				"MAX_PROPOSER_SLASHINGS":                   uint64(16),
+				"MAX_SEED_LOOKAHEAD":                       uint64(4),
+				"MAX_VALIDATORS_PER_COMMITTEE":             uint64(2048),
+				"MAX_VOLUNTARY_EXITS":                      uint64(16),
+				"MIN_ANCHOR_POW_BLOCK_DIFFICULTY":          uint64(4294967296),
+				"MIN_ATTESTATION_INCLUSION_DELAY":          uint64(1),
+				"MIN_DEPOSIT_AMOUNT":                       uint64(1000000000),
+				"MIN_EPOCHS_TO_INACTIVITY_PENALTY":         uint64(4),
+				"MIN_GENESIS_ACTIVE_VALIDATOR_COUNT":       uint64(16384),
+				"MIN_GENESIS_TIME":                         time.Unix(1606824000, 0),
+				"MIN_PER_EPOCH_CHURN_LIMIT":                uint64(4),
+				"MIN_SEED_LOOKAHEAD":                       uint64(1),
+				"MIN_SLASHING_PENALTY_QUOTIENT":            uint64(128),
+				"MIN_SLASHING_PENALTY_QUOTIENT_ALTAIR":     uint64(64),
+				"MIN_SLASHING_PENALTY_QUOTIENT_MERGE":      uint64(32),
+				"MIN_SYNC_COMMITTEE_PARTICIPANTS":          uint64(1),
+				"MIN_VALIDATOR_WITHDRAWABILITY_DELAY":      uint64(256),
+				"PRESET_BASE":                              "mainnet",
+				"PROPORTIONAL_SLASHING_MULTIPLIER":         uint64(1),
+				"PROPORTIONAL_SLASHING_MULTIPLIER_ALTAIR":  uint64(2),
+				"PROPORTIONAL_SLASHING_MULTIPLIER_MERGE":   uint64(3),
+				"PROPOSER_REWARD_QUOTIENT":                 uint64(8),
+				"PROPOSER_WEIGHT":                          uint64(8),
+				"RANDOM_SUBNETS_PER_VALIDATOR":             uint64(1),
+				"SAFE_SLOTS_TO_UPDATE_JUSTIFIED":           uint64(8),
+				"SECONDS_PER_ETH1_BLOCK":                   14 * time.Second,
+				"SECONDS_PER_SLOT":                         12 * time.Second,
+				"SHARDING_FORK_EPOCH":                      uint64(18446744073709551615),
+				"SHARDING_FORK_VERSION":                    phase0.Version{0x03, 0x00, 0x00, 0x00},
+				"SHARD_COMMITTEE_PERIOD":                   uint64(256),
+				"SHUFFLE_ROUND_COUNT":                      uint64(90),
+				"SLOTS_PER_EPOCH":                          uint64(32),
+				"SLOTS_PER_HISTORICAL_ROOT":                uint64(8192),
+				"SYNC_COMMITTEE_SIZE":                      uint64(512),
+				"SYNC_COMMITTEE_SUBNET_COUNT":              uint64(4),
+				"SYNC_REWARD_WEIGHT":                       uint64(2),
+				"TARGET_AGGREGATORS_PER_COMMITTEE":         uint64(16),
+				"TARGET_AGGREGATORS_PER_SYNC_SUBCOMMITTEE": uint64(16),
+				"TARGET_COMMITTEE_SIZE":                    uint64(128),
+				"TERMINAL_BLOCK_HASH":                      []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+				"TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH":     uint64(18446744073709551615),
+				"TERMINAL_TOTAL_DIFFICULTY":                uint64(0),
+				"TIMELY_HEAD_FLAG_INDEX":                   []byte{0x02},
+				"TIMELY_HEAD_WEIGHT":                       uint64(14),
+				"TIMELY_SOURCE_FLAG_INDEX":                 []byte{0x00},
+				"TIMELY_SOURCE_WEIGHT":                     uint64(14),
+				"TIMELY_TARGET_FLAG_INDEX":                 []byte{0x01},
+				"TIMELY_TARGET_WEIGHT":                     uint64(26),
+				"TRANSITION_TOTAL_DIFFICULTY":              uint64(0),
+				"VALIDATOR_REGISTRY_LIMIT":                 uint64(1099511627776),
+				"WEIGHT_DENOMINATOR":                       uint64(64),
+				"WHISTLEBLOWER_REWARD_QUOTIENT":            uint64(512),
+			},
+			"ValidatorEffectiveness":        map[phase0.ValidatorIndex]float64{},
+			"ValidatorEffectivenessByLabel": map[string]float64{},
+			"ValidatorIncome":               map[phase0.ValidatorIndex]*chaindb.AggregateValidatorIncome{},
+			"ValidatorIncomeByLabel":        map[string]*chaindb.AggregateLabelIncome{},
+			"WindowedValidatorIncome":       []*chaindb.AggregateWindowIncome{},
+		},
+		errors: make(map[string]error),
+	}
+}
+
+// SetResponse sets the response returned by calls to the given method.
+// The response must be of the method's return type.
+func (s *Service) SetResponse(method string, response interface{}) {
+	s.mu.Lock()
+	s.responses[method] = response
+	s.mu.Unlock()
+}
+
+// SetError sets the error returned by calls to the given method; nil clears it.
+func (s *Service) SetError(method string, err error) {
+	s.mu.Lock()
+	if err == nil {
+		delete(s.errors, method)
+	} else {
+		s.errors[method] = err
+	}
+	s.mu.Unlock()
+}
+
+// Calls returns all calls made to the mock, in the order in which they were made.
+func (s *Service) Calls() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]*Call, len(s.calls))
+	copy(calls, s.calls)
+
+	return calls
+}
+
+// CallsTo returns the calls made to the given method, in the order in which they were made.
+func (s *Service) CallsTo(method string) []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]*Call, 0)
+	for _, call := range s.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// ResetCalls clears the recorded calls.  Responses and errors are retained.
+func (s *Service) ResetCalls() {
+	s.mu.Lock()
+	s.calls = nil
+	s.mu.Unlock()
+}
+
+// call records a call and returns the canned response and error for the method.
+func (s *Service) call(method string, args ...interface{}) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, &Call{
+		Method: method,
+		Args:   args,
+	})
+
+	return s.responses[method], s.errors[method]
 }
 
 // AttestationsForBlock fetches all attestations made for the given block.
-func (s *service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
-	return nil, nil
+func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsForBlock", blockRoot)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
 }
 
 // AttestationsInBlock fetches all attestations contained in the given block.
-func (s *service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
-	return nil, nil
+func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsInBlock", blockRoot)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
 }
 
 // AttestationsForSlotRange fetches all attestations made for the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // attestations for slots 2 and 3.
-func (s *service) AttestationsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
-	return nil, nil
+func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsForSlotRange", startSlot, endSlot)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
 }
 
 // AttestationsInSlotRange fetches all attestations made in the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // attestations in slots 2 and 3.
-func (s *service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
-	return nil, nil
+func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsInSlotRange", startSlot, endSlot)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
+}
+
+// AttestationsForValidator fetches all attestations made for the given slot range that include the given validator.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// attestations for slots 2 and 3.
+func (s *Service) AttestationsForValidator(ctx context.Context, index phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsForValidator", index, startSlot, endSlot)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
+}
+
+// AttestationsForCommittee fetches all attestations made by the given committee at the given slot.
+func (s *Service) AttestationsForCommittee(ctx context.Context, slot phase0.Slot, committeeIndex phase0.CommitteeIndex) ([]*chaindb.Attestation, error) {
+	response, err := s.call("AttestationsForCommittee", slot, committeeIndex)
+	value, _ := response.([]*chaindb.Attestation)
+
+	return value, err
 }
 
 // IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
-func (s *service) IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
-	return nil, nil
+func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	response, err := s.call("IndeterminateAttestationSlots", minSlot, maxSlot)
+	value, _ := response.([]phase0.Slot)
+
+	return value, err
+}
+
+// ForEachAttestationInSlotRange calls the supplied function for each attestation made in the given slot range.
+func (s *Service) ForEachAttestationInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, fn func(*chaindb.Attestation) error) error {
+	response, err := s.call("ForEachAttestationInSlotRange", startSlot, endSlot)
+	if err != nil {
+		return err
+	}
+	attestations, _ := response.([]*chaindb.Attestation)
+	for _, attestation := range attestations {
+		if err := fn(attestation); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // SetAttestation sets an attestation.
-func (s *service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
-	return nil
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	_, err := s.call("SetAttestation", attestation)
+
+	return err
 }
 
 // AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
-	return nil, nil
+func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
+	response, err := s.call("AttesterSlashingsForSlotRange", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.AttesterSlashing)
+
+	return value, err
 }
 
 // AttesterSlashingsForValidator fetches all attester slashings made for the given validator.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *service) AttesterSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.AttesterSlashing, error) {
-	return nil, nil
+func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.AttesterSlashing, error) {
+	response, err := s.call("AttesterSlashingsForValidator", index)
+	value, _ := response.([]*chaindb.AttesterSlashing)
+
+	return value, err
 }
 
 // SetAttesterSlashing sets an attester slashing.
-func (s *service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
-	return nil
+func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
+	_, err := s.call("SetAttesterSlashing", attesterSlashing)
+
+	return err
 }
 
 // BeaconCommitteeBySlotAndIndex fetches the beacon committee with the given slot and index.
-func (s *service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0.Slot, index phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
-	return nil, nil
+func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0.Slot, index phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
+	response, err := s.call("BeaconCommitteeBySlotAndIndex", slot, index)
+	value, _ := response.(*chaindb.BeaconCommittee)
+
+	return value, err
 }
 
 // AttesterDuties fetches the attester duties at the given slot range for the given validator indices.
-func (s *service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*chaindb.AttesterDuty, error) {
-	return nil, nil
+func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*chaindb.AttesterDuty, error) {
+	response, err := s.call("AttesterDuties", startSlot, endSlot, validatorIndices)
+	value, _ := response.([]*chaindb.AttesterDuty)
+
+	return value, err
 }
 
 // SetBeaconCommittee sets a beacon committee.
-func (s *service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
-	return nil
+func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	_, err := s.call("SetBeaconCommittee", beaconCommittee)
+
+	return err
 }
 
 // BlocksBySlot fetches all blocks with the given slot.
-func (s *service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
-	return nil, nil
+func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksBySlot", slot)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
 }
 
 // BlocksForSlotRange fetches all blocks with the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // blocks duties for slots 2 and 3.
-func (s *service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
-	return nil, nil
+func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksForSlotRange", startSlot, endSlot)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
+}
+
+// BlocksForTimestampRange fetches all blocks whose slots start in the given time range.
+func (s *Service) BlocksForTimestampRange(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksForTimestampRange", start, end)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
+}
+
+// BlocksForProposerIndex fetches all blocks proposed by the given validator in the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// blocks for slots 2 and 3.
+func (s *Service) BlocksForProposerIndex(ctx context.Context, index phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksForProposerIndex", index, startSlot, endSlot)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
+}
+
+// FeeRecipientChanges fetches the changes in fee recipient between successive blocks proposed by each of the given validators.
+func (s *Service) FeeRecipientChanges(ctx context.Context, validators []phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.FeeRecipientChange, error) {
+	response, err := s.call("FeeRecipientChanges", validators, startSlot, endSlot)
+	value, _ := response.([]*chaindb.FeeRecipientChange)
+
+	return value, err
 }
 
 // BlockByRoot fetches the block with the given root.
-func (s *service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
-	return nil, nil
+func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
+	response, err := s.call("BlockByRoot", root)
+	value, _ := response.(*chaindb.Block)
+
+	return value, err
 }
 
 // BlocksByParentRoot fetches the blocks with the given parent root.
-func (s *service) BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*chaindb.Block, error) {
-	return nil, nil
+func (s *Service) BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksByParentRoot", root)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
+}
+
+// BlockByExecutionBlockHash fetches the block containing the execution payload with the given hash.
+func (s *Service) BlockByExecutionBlockHash(ctx context.Context, hash [32]byte) (*chaindb.Block, error) {
+	response, err := s.call("BlockByExecutionBlockHash", hash)
+	value, _ := response.(*chaindb.Block)
+
+	return value, err
+}
+
+// BlocksByExecutionBlockNumber fetches the blocks containing execution payloads with the given block number.
+func (s *Service) BlocksByExecutionBlockNumber(ctx context.Context, number uint64) ([]*chaindb.Block, error) {
+	response, err := s.call("BlocksByExecutionBlockNumber", number)
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
 }
 
 // EmptySlots fetches the slots in the given range without a block in the database.
-func (s *service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
-	return nil, nil
+func (s *Service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	response, err := s.call("EmptySlots", minSlot, maxSlot)
+	value, _ := response.([]phase0.Slot)
+
+	return value, err
 }
 
 // LatestBlocks fetches the blocks with the highest slot number in the database.
-func (s *service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
-	return nil, nil
+func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
+	response, err := s.call("LatestBlocks")
+	value, _ := response.([]*chaindb.Block)
+
+	return value, err
 }
 
 // IndeterminateBlocks fetches the blocks in the given range that do not have a canonical status.
-func (s *service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Root, error) {
-	return nil, nil
+func (s *Service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Root, error) {
+	response, err := s.call("IndeterminateBlocks", minSlot, maxSlot)
+	value, _ := response.([]phase0.Root)
+
+	return value, err
 }
 
 // CanonicalBlockPresenceForSlotRange returns a boolean for each slot in the range for the presence
 // of a canonical block.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // presence duties for slots 2 and 3.
-func (s *service) CanonicalBlockPresenceForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]bool, error) {
-	return nil, nil
+func (s *Service) CanonicalBlockPresenceForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]bool, error) {
+	response, err := s.call("CanonicalBlockPresenceForSlotRange", minSlot, maxSlot)
+	value, _ := response.([]bool)
+
+	return value, err
 }
 
 // LatestCanonicalBlock returns the slot of the latest canonical block known in the database.
-func (s *service) LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error) {
-	return 0, nil
+func (s *Service) LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error) {
+	response, err := s.call("LatestCanonicalBlock")
+	value, _ := response.(phase0.Slot)
+
+	return value, err
+}
+
+// ForEachBlockInSlotRange calls the supplied function for each block in the given slot range.
+func (s *Service) ForEachBlockInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, fn func(*chaindb.Block) error) error {
+	response, err := s.call("ForEachBlockInSlotRange", startSlot, endSlot)
+	if err != nil {
+		return err
+	}
+	blocks, _ := response.([]*chaindb.Block)
+	for _, block := range blocks {
+		if err := fn(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // SetBlock sets a block.
-func (s *service) SetBlock(ctx context.Context, block *chaindb.Block) error {
-	return nil
+func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	_, err := s.call("SetBlock", block)
+
+	return err
 }
 
 // Spec provides the spec information of the chain.
-func (s *service) Spec(ctx context.Context) (map[string]interface{}, error) {
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
 	return s.ChainSpec(ctx)
 }
 
 // ChainSpec fetches all chain specification values.
-func (s *service) ChainSpec(ctx context.Context) (map[string]interface{}, error) {
-	return map[string]interface{}{
-		"ALTAIR_FORK_EPOCH":                        uint64(74240),
-		"ALTAIR_FORK_VERSION":                      phase0.Version{0x01, 0x00, 0x00, 0x00},
-		"BASE_REWARD_FACTOR":                       uint64(64),
-		"BELLATRIX_FORK_EPOCH":                     uint64(18446744073709551615),
-		"BELLATRIX_FORK_VERSION":                   phase0.Version{0x02, 0x00, 0x00, 0x00},
-		"BLS_WITHDRAWAL_PREFIX":                    []byte{0x00},
-		"CHURN_LIMIT_QUOTIENT":                     uint64(65536),
-		"CONFIG_NAME":                              "mainnet",
-		"DEPOSIT_CHAIN_ID":                         1,
-		"DEPOSIT_CONTRACT_ADDRESS":                 []byte{0x00, 0x00, 0x00, 0x00, 0x21, 0x9a, 0xb5, 0x40, 0x35, 0x6c, 0xBB, 0x83, 0x9C, 0xbe, 0x05, 0x30, 0x3d, 0x77, 0x05, 0xFa},
-		"DEPOSIT_NETWORK_ID":                       1,
-		"DOMAIN_AGGREGATE_AND_PROOF":               phase0.DomainType{0x06, 0x00, 0x00, 0x00},
-		"DOMAIN_BEACON_ATTESTER":                   phase0.DomainType{0x01, 0x00, 0x00, 0x00},
-		"DOMAIN_BEACON_PROPOSER":                   phase0.DomainType{0x00, 0x00, 0x00, 0x00},
-		"DOMAIN_CONTRIBUTION_AND_PROOF":            phase0.DomainType{0x09, 0x00, 0x00, 0x00},
-		"DOMAIN_DEPOSIT":                           phase0.DomainType{0x03, 0x00, 0x00, 0x00},
-		"DOMAIN_RANDAO":                            phase0.DomainType{0x02, 0x00, 0x00, 0x00},
-		"DOMAIN_SELECTION_PROOF":                   phase0.DomainType{0x05, 0x00, 0x00, 0x00},
-		"DOMAIN_SYNC_COMMITTEE":                    phase0.DomainType{0x07, 0x00, 0x00, 0x00},
-		"DOMAIN_SYNC_COMMITTEE_SELECTION_PROOF":    phase0.DomainType{0x08, 0x00, 0x00, 0x00},
-		"DOMAIN_VOLUNTARY_EXIT":                    phase0.DomainType{0x04, 0x00, 0x00, 0x00},
-		"EFFECTIVE_BALANCE_INCREMENT":              uint64(1000000000),
-		"EJECTION_BALANCE":                         uint64(16000000000),
-		"EPOCHS_PER_ETH1_VOTING_PERIOD":            uint64(64),
-		"EPOCHS_PER_HISTORICAL_VECTOR":             uint64(65536),
-		"EPOCHS_PER_RANDOM_SUBNET_SUBSCRIPTION":    uint64(256),
-		"EPOCHS_PER_SLASHINGS_VECTOR":              uint64(8192),
-		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD":         uint64(256),
-		"ETH1_FOLLOW_DISTANCE":                     uint64(2048),
-		"GENESIS_DELAY":                            604800 * time.Second,
-		"GENESIS_FORK_VERSION":                     phase0.Version{0x00, 0x00, 0x00, 0x00},
-		"HISTORICAL_ROOTS_LIMIT":                   uint64(16777216),
-		"HYSTERESIS_DOWNWARD_MULTIPLIER":           uint64(1),
-		"HYSTERESIS_QUOTIENT":                      uint64(4),
-		"HYSTERESIS_UPWARD_MULTIPLIER":             uint64(5),
-		"INACTIVITY_PENALTY_QUOTIENT":              uint64(67108864),
-		"INACTIVITY_PENALTY_QUOTIENT_ALTAIR":       uint64(50331648),
-		"INACTIVITY_PENALTY_QUOTIENT_MERGE":        uint64(16777216),
-		"INACTIVITY_SCORE_BIAS":                    uint64(4),
-		"INACTIVITY_SCORE_RECOVERY_RATE":           uint64(16),
-		"MAX_ATTESTATIONS":                         uint64(128),
-		"MAX_ATTESTER_SLASHINGS":                   uint64(2),
-		"MAX_COMMITTEES_PER_SLOT":                  uint64(64),
-		"MAX_DEPOSITS":                             uint64(16),
-		"MAX_EFFECTIVE_BALANCE":                    uint64(32000000000),
-		"MAX_PROPOSER_SLASHINGS":                   uint64(16),
-		"MAX_SEED_LOOKAHEAD":                       uint64(4),
-		"MAX_VALIDATORS_PER_COMMITTEE":             uint64(2048),
-		"MAX_VOLUNTARY_EXITS":                      uint64(16),
-		"MIN_ANCHOR_POW_BLOCK_DIFFICULTY":          uint64(4294967296),
-		"MIN_ATTESTATION_INCLUSION_DELAY":          uint64(1),
-		"MIN_DEPOSIT_AMOUNT":                       uint64(1000000000),
-		"MIN_EPOCHS_TO_INACTIVITY_PENALTY":         uint64(4),
-		"MIN_GENESIS_ACTIVE_VALIDATOR_COUNT":       uint64(16384),
-		"MIN_GENESIS_TIME":                         time.Unix(1606824000, 0),
-		"MIN_PER_EPOCH_CHURN_LIMIT":                uint64(4),
-		"MIN_SEED_LOOKAHEAD":                       uint64(1),
-		"MIN_SLASHING_PENALTY_QUOTIENT":            uint64(128),
-		"MIN_SLASHING_PENALTY_QUOTIENT_ALTAIR":     uint64(64),
-		"MIN_SLASHING_PENALTY_QUOTIENT_MERGE":      uint64(32),
-		"MIN_SYNC_COMMITTEE_PARTICIPANTS":          uint64(1),
-		"MIN_VALIDATOR_WITHDRAWABILITY_DELAY":      uint64(256),
-		"PRESET_BASE":                              "mainnet",
-		"PROPORTIONAL_SLASHING_MULTIPLIER":         uint64(1),
-		"PROPORTIONAL_SLASHING_MULTIPLIER_ALTAIR":  uint64(2),
-		"PROPORTIONAL_SLASHING_MULTIPLIER_MERGE":   uint64(3),
-		"PROPOSER_REWARD_QUOTIENT":                 uint64(8),
-		"PROPOSER_WEIGHT":                          uint64(8),
-		"RANDOM_SUBNETS_PER_VALIDATOR":             uint64(1),
-		"SAFE_SLOTS_TO_UPDATE_JUSTIFIED":           uint64(8),
-		"SECONDS_PER_ETH1_BLOCK":                   14 * time.Second,
-		"SECONDS_PER_SLOT":                         12 * time.Second,
-		"SHARDING_FORK_EPOCH":                      uint64(18446744073709551615),
-		"SHARDING_FORK_VERSION":                    phase0.Version{0x03, 0x00, 0x00, 0x00},
-		"SHARD_COMMITTEE_PERIOD":                   uint64(256),
-		"SHUFFLE_ROUND_COUNT":                      uint64(90),
-		"SLOTS_PER_EPOCH":                          uint64(32),
-		"SLOTS_PER_HISTORICAL_ROOT":                uint64(8192),
-		"SYNC_COMMITTEE_SIZE":                      uint64(512),
-		"SYNC_COMMITTEE_SUBNET_COUNT":              uint64(4),
-		"SYNC_REWARD_WEIGHT":                       uint64(2),
-		"TARGET_AGGREGATORS_PER_COMMITTEE":         uint64(16),
-		"TARGET_AGGREGATORS_PER_SYNC_SUBCOMMITTEE": uint64(16),
-		"TARGET_COMMITTEE_SIZE":                    uint64(128),
-		"TERMINAL_BLOCK_HASH":                      []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
-		"TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH":     uint64(18446744073709551615),
-		"TERMINAL_TOTAL_DIFFICULTY":                uint64(0),
-		"TIMELY_HEAD_FLAG_INDEX":                   []byte{0x02},
-		"TIMELY_HEAD_WEIGHT":                       uint64(14),
-		"TIMELY_SOURCE_FLAG_INDEX":                 []byte{0x00},
-		"TIMELY_SOURCE_WEIGHT":                     uint64(14),
-		"TIMELY_TARGET_FLAG_INDEX":                 []byte{0x01},
-		"TIMELY_TARGET_WEIGHT":                     uint64(26),
-		"TRANSITION_TOTAL_DIFFICULTY":              uint64(0),
-		"VALIDATOR_REGISTRY_LIMIT":                 uint64(1099511627776),
-		"WEIGHT_DENOMINATOR":                       uint64(64),
-		"WHISTLEBLOWER_REWARD_QUOTIENT":            uint64(512),
-	}, nil
+func (s *Service) ChainSpec(ctx context.Context) (map[string]interface{}, error) {
+	response, err := s.call("ChainSpec")
+	value, _ := response.(map[string]interface{})
+
+	return value, err
 }
 
 // ChainSpecValue fetches a chain specification value given its key.
-func (s *service) ChainSpecValue(ctx context.Context, key string) (interface{}, error) {
-	return nil, nil
+func (s *Service) ChainSpecValue(ctx context.Context, key string) (interface{}, error) {
+	response, err := s.call("ChainSpecValue", key)
+	value, _ := response.(interface{})
+
+	return value, err
+}
+
+// ChainSpecAtEpoch fetches all chain specification values as they applied at the given epoch.
+func (s *Service) ChainSpecAtEpoch(ctx context.Context, epoch phase0.Epoch) (map[string]interface{}, error) {
+	response, err := s.call("ChainSpecAtEpoch", epoch)
+	value, _ := response.(map[string]interface{})
+
+	return value, err
+}
+
+// ChainSpecValueAtEpoch fetches a chain specification value given its key, as it applied at the given epoch.
+func (s *Service) ChainSpecValueAtEpoch(ctx context.Context, key string, epoch phase0.Epoch) (interface{}, error) {
+	return s.call("ChainSpecValueAtEpoch", key, epoch)
 }
 
 // SetChainSpecValue sets the value of the provided key.
-func (s *service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
-	return nil
+func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
+	_, err := s.call("SetChainSpecValue", key, value)
+
+	return err
+}
+
+// SetChainSpecValueFromEpoch sets the value of the provided key from the given epoch.
+func (s *Service) SetChainSpecValueFromEpoch(ctx context.Context, key string, value interface{}, epoch phase0.Epoch) error {
+	_, err := s.call("SetChainSpecValueFromEpoch", key, value, epoch)
+
+	return err
 }
 
 // ForkSchedule provides details of past and future changes in the chain's fork version.
-func (s *service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
-	return nil, nil
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	response, err := s.call("ForkSchedule")
+	value, _ := response.([]*phase0.Fork)
+
+	return value, err
 }
 
 // SetForkSchedule sets the fork schedule.
-func (s *service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
-	return nil
+func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
+	_, err := s.call("SetForkSchedule", schedule)
+
+	return err
 }
 
 // Genesis fetches genesis values.
-func (s *service) Genesis(ctx context.Context) (*api.Genesis, error) {
-	return nil, nil
+func (s *Service) Genesis(ctx context.Context) (*api.Genesis, error) {
+	response, err := s.call("Genesis")
+	value, _ := response.(*api.Genesis)
+
+	return value, err
 }
 
 // SetGenesis sets the genesis information.
-func (s *service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
-	return nil
+func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
+	_, err := s.call("SetGenesis", genesis)
+
+	return err
 }
 
 // ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
-func (s *service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
-	return nil, nil
+func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
+	response, err := s.call("ETH1DepositsByPublicKey", pubKeys)
+	value, _ := response.([]*chaindb.ETH1Deposit)
+
+	return value, err
+}
+
+// ETH1DepositsForBlockRange fetches all Ethereum 1 deposits made in the given Ethereum 1 block range.
+func (s *Service) ETH1DepositsForBlockRange(ctx context.Context, startBlock uint64, endBlock uint64) ([]*chaindb.ETH1Deposit, error) {
+	response, err := s.call("ETH1DepositsForBlockRange", startBlock, endBlock)
+	value, _ := response.([]*chaindb.ETH1Deposit)
+
+	return value, err
+}
+
+// ETH1DepositTotalsByDay provides the number and total amount of Ethereum 1 deposits for each UTC day
+// in the given time range.
+func (s *Service) ETH1DepositTotalsByDay(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.ETH1DepositDayTotal, error) {
+	response, err := s.call("ETH1DepositTotalsByDay", start, end)
+	value, _ := response.([]*chaindb.ETH1DepositDayTotal)
+
+	return value, err
+}
+
+// ETH1DepositTotalsBySender provides the number and total amount of Ethereum 1 deposits for each sender
+// in the given time range.
+func (s *Service) ETH1DepositTotalsBySender(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.ETH1DepositSenderTotal, error) {
+	response, err := s.call("ETH1DepositTotalsBySender", start, end)
+	value, _ := response.([]*chaindb.ETH1DepositSenderTotal)
+
+	return value, err
 }
 
 // SetETH1Deposit sets an Ethereum 1 deposit.
-func (s *service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
-	return nil
+func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
+	_, err := s.call("SetETH1Deposit", deposit)
+
+	return err
+}
+
+// DeleteETH1Deposits deletes the Ethereum 1 deposits made in the given range of blocks, inclusive.
+func (s *Service) DeleteETH1Deposits(ctx context.Context, startBlock uint64, endBlock uint64) error {
+	_, err := s.call("DeleteETH1Deposits", startBlock, endBlock)
+
+	return err
 }
 
 // ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // proposer duties for slots 2 and 3.
-func (s *service) ProposerDutiesForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.ProposerDuty, error) {
-	return nil, nil
+func (s *Service) ProposerDutiesForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.ProposerDuty, error) {
+	response, err := s.call("ProposerDutiesForSlotRange", startSlot, endSlot)
+	value, _ := response.([]*chaindb.ProposerDuty)
+
+	return value, err
 }
 
 // ProposerDutiesForValidator provides all proposer duties for the given validator index.
-func (s *service) ProposerDutiesForValidator(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
-	return nil, nil
+func (s *Service) ProposerDutiesForValidator(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
+	response, err := s.call("ProposerDutiesForValidator", proposer)
+	value, _ := response.([]*chaindb.ProposerDuty)
+
+	return value, err
 }
 
 // SetProposerDuty sets a proposer duty.
-func (s *service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
-	return nil
+func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	_, err := s.call("SetProposerDuty", proposerDuty)
+
+	return err
 }
 
 // ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
-	return nil, nil
+func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
+	response, err := s.call("ProposerSlashingsForSlotRange", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.ProposerSlashing)
+
+	return value, err
 }
 
 // ProposerSlashingsForValidator fetches all proposer slashings made for the given validator.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *service) ProposerSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.ProposerSlashing, error) {
-	return nil, nil
+func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.ProposerSlashing, error) {
+	response, err := s.call("ProposerSlashingsForValidator", index)
+	value, _ := response.([]*chaindb.ProposerSlashing)
+
+	return value, err
 }
 
 // SetProposerSlashing sets an proposer slashing.
-func (s *service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
-	return nil
+func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
+	_, err := s.call("SetProposerSlashing", proposerSlashing)
+
+	return err
 }
 
 // SyncAggregateForBlock provides the sync aggregate for the supplied block root.
-func (s *service) SyncAggregateForBlock(ctx context.Context, blockRoot phase0.Root) (*chaindb.SyncAggregate, error) {
-	return nil, nil
+func (s *Service) SyncAggregateForBlock(ctx context.Context, blockRoot phase0.Root) (*chaindb.SyncAggregate, error) {
+	response, err := s.call("SyncAggregateForBlock", blockRoot)
+	value, _ := response.(*chaindb.SyncAggregate)
+
+	return value, err
 }
 
 // SetSyncAggregate sets the sync aggregate.
-func (s *service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
-	return nil
+func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
+	_, err := s.call("SetSyncAggregate", syncAggregate)
+
+	return err
 }
 
 // Validators fetches all validators.
-func (s *service) Validators(ctx context.Context) ([]*chaindb.Validator, error) {
-	return nil, nil
+func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error) {
+	response, err := s.call("Validators")
+	value, _ := response.([]*chaindb.Validator)
+
+	return value, err
 }
 
 // ValidatorsByPublicKey fetches all validators matching the given public keys.
 // This is a common starting point for external entities to query specific validators, as they should
 // always have the public key at a minimum, hence the return map keyed by public key.
-func (s *service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
-	return nil, nil
+func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	response, err := s.call("ValidatorsByPublicKey", pubKeys)
+	value, _ := response.(map[phase0.BLSPubKey]*chaindb.Validator)
+
+	return value, err
 }
 
 // ValidatorsByIndex fetches all validators matching the given indices.
-func (s *service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
-	return nil, nil
+func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	response, err := s.call("ValidatorsByIndex", indices)
+	value, _ := response.(map[phase0.ValidatorIndex]*chaindb.Validator)
+
+	return value, err
+}
+
+// ValidatorStatuses fetches the current statuses of all validators matching the given indices or public keys.
+func (s *Service) ValidatorStatuses(ctx context.Context, indices []phase0.ValidatorIndex, pubKeys []phase0.BLSPubKey) ([]*chaindb.ValidatorStatus, error) {
+	response, err := s.call("ValidatorStatuses", indices, pubKeys)
+	value, _ := response.([]*chaindb.ValidatorStatus)
+
+	return value, err
+}
+
+// ValidatorBalancesByEpoch fetches all validator balances for the given epoch.
+func (s *Service) ValidatorBalancesByEpoch(
+	ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	response, err := s.call("ValidatorBalancesByEpoch", epoch)
+	value, _ := response.([]*chaindb.ValidatorBalance)
+
+	return value, err
 }
 
 // ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
-func (s *service) ValidatorBalancesByIndexAndEpoch(
+func (s *Service) ValidatorBalancesByIndexAndEpoch(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	epoch phase0.Epoch,
@@ -368,13 +744,16 @@ func (s *service) ValidatorBalancesByIndexAndEpoch(
 	map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("ValidatorBalancesByIndexAndEpoch", indices, epoch)
+	value, _ := response.(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance)
+
+	return value, err
 }
 
 // ValidatorBalancesByIndexAndEpochRange fetches the validator balances for the given validators and epoch range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
 // balances for epochs 2 and 3.
-func (s *service) ValidatorBalancesByIndexAndEpochRange(
+func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	startEpoch phase0.Epoch,
@@ -383,11 +762,14 @@ func (s *service) ValidatorBalancesByIndexAndEpochRange(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("ValidatorBalancesByIndexAndEpochRange", indices, startEpoch, endEpoch)
+	value, _ := response.(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance)
+
+	return value, err
 }
 
 // ValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
-func (s *service) ValidatorBalancesByIndexAndEpochs(
+func (s *Service) ValidatorBalancesByIndexAndEpochs(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	epochs []phase0.Epoch,
@@ -395,11 +777,14 @@ func (s *service) ValidatorBalancesByIndexAndEpochs(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("ValidatorBalancesByIndexAndEpochs", indices, epochs)
+	value, _ := response.(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance)
+
+	return value, err
 }
 
 // AggregateValidatorBalancesByIndexAndEpoch fetches the aggregate validator balances for the given validators and epoch.
-func (s *service) AggregateValidatorBalancesByIndexAndEpoch(
+func (s *Service) AggregateValidatorBalancesByIndexAndEpoch(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	epoch phase0.Epoch,
@@ -407,14 +792,17 @@ func (s *service) AggregateValidatorBalancesByIndexAndEpoch(
 	*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("AggregateValidatorBalancesByIndexAndEpoch", indices, epoch)
+	value, _ := response.(*chaindb.AggregateValidatorBalance)
+
+	return value, err
 }
 
 // AggregateValidatorBalancesByIndexAndEpochRange fetches the aggregate validator balances for the given validators and
 // epoch range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
 // balances for epochs 2 and 3.
-func (s *service) AggregateValidatorBalancesByIndexAndEpochRange(
+func (s *Service) AggregateValidatorBalancesByIndexAndEpochRange(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	startEpoch phase0.Epoch,
@@ -423,11 +811,14 @@ func (s *service) AggregateValidatorBalancesByIndexAndEpochRange(
 	[]*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("AggregateValidatorBalancesByIndexAndEpochRange", indices, startEpoch, endEpoch)
+	value, _ := response.([]*chaindb.AggregateValidatorBalance)
+
+	return value, err
 }
 
 // AggregateValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
-func (s *service) AggregateValidatorBalancesByIndexAndEpochs(
+func (s *Service) AggregateValidatorBalancesByIndexAndEpochs(
 	ctx context.Context,
 	indices []phase0.ValidatorIndex,
 	epochs []phase0.Epoch,
@@ -435,111 +826,639 @@ func (s *service) AggregateValidatorBalancesByIndexAndEpochs(
 	[]*chaindb.AggregateValidatorBalance,
 	error,
 ) {
-	return nil, nil
+	response, err := s.call("AggregateValidatorBalancesByIndexAndEpochs", indices, epochs)
+	value, _ := response.([]*chaindb.AggregateValidatorBalance)
+
+	return value, err
 }
 
-// SetValidator sets a validator.
-func (s *service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+// ForEachValidatorBalanceInEpochRange calls the supplied function for each validator balance in the given epoch range.
+func (s *Service) ForEachValidatorBalanceInEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch, fn func(*chaindb.ValidatorBalance) error) error {
+	response, err := s.call("ForEachValidatorBalanceInEpochRange", startEpoch, endEpoch)
+	if err != nil {
+		return err
+	}
+	balances, _ := response.([]*chaindb.ValidatorBalance)
+	for _, balance := range balances {
+		if err := fn(balance); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// SetValidator sets a validator.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	_, err := s.call("SetValidator", validator)
+
+	return err
+}
+
 // SetValidatorBalance sets a validator balance.
-func (s *service) SetValidatorBalance(ctx context.Context, validatorBalance *chaindb.ValidatorBalance) error {
-	return nil
+func (s *Service) SetValidatorBalance(ctx context.Context, validatorBalance *chaindb.ValidatorBalance) error {
+	_, err := s.call("SetValidatorBalance", validatorBalance)
+
+	return err
 }
 
 // SetValidatorBalances sets multiple validator balances.
-func (s *service) SetValidatorBalances(ctx context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
-	return nil
+func (s *Service) SetValidatorBalances(ctx context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
+	_, err := s.call("SetValidatorBalances", validatorBalances)
+
+	return err
 }
 
 // DepositsByPublicKey fetches deposits for a given set of validator public keys.
-func (s *service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
-	return nil, nil
+func (s *Service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
+	response, err := s.call("DepositsByPublicKey", pubKeys)
+	value, _ := response.(map[phase0.BLSPubKey][]*chaindb.Deposit)
+
+	return value, err
 }
 
 // DepositsForSlotRange fetches all deposits made in the given slot range.
 // It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
-func (s *service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
-	return nil, nil
+func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
+	response, err := s.call("DepositsForSlotRange", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.Deposit)
+
+	return value, err
+}
+
+// DepositsForWithdrawalCredentials fetches all deposits with the given withdrawal credentials made in the given slot range.
+// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) DepositsForWithdrawalCredentials(ctx context.Context, withdrawalCredentials []byte, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
+	response, err := s.call("DepositsForWithdrawalCredentials", withdrawalCredentials, minSlot, maxSlot)
+	value, _ := response.([]*chaindb.Deposit)
+
+	return value, err
 }
 
 // SetDeposit sets a deposit.
-func (s *service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
-	return nil
+func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
+	_, err := s.call("SetDeposit", deposit)
+
+	return err
 }
 
 // SetVoluntaryExit sets a voluntary exit.
-func (s *service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
-	return nil
+func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
+	_, err := s.call("SetVoluntaryExit", voluntaryExit)
+
+	return err
 }
 
 // SetValidatorEpochSummary sets a validator epoch summary.
-func (s *service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
-	return nil
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	_, err := s.call("SetValidatorEpochSummary", summary)
+
+	return err
 }
 
 // SetValidatorEpochSummaries sets multiple validator epoch summaries.
-func (s *service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
-	return nil
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	_, err := s.call("SetValidatorEpochSummaries", summaries)
+
+	return err
+}
+
+// PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given epoch.
+func (s *Service) PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch) error {
+	_, err := s.call("PruneValidatorEpochSummaries", to)
+
+	return err
+}
+
+// SetValidatorDaySummaries sets multiple validator day summaries.
+func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*chaindb.ValidatorDaySummary) error {
+	_, err := s.call("SetValidatorDaySummaries", summaries)
+
+	return err
+}
+
+// PruneValidatorDaySummaries prunes validator day summaries up to (but not including) the given timestamp.
+func (s *Service) PruneValidatorDaySummaries(ctx context.Context, to time.Time) error {
+	_, err := s.call("PruneValidatorDaySummaries", to)
+
+	return err
+}
+
+// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	_, err := s.call("DeleteEpochSummaries", startEpoch, endEpoch)
+
+	return err
+}
+
+// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+func (s *Service) DeleteBlockSummaries(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	_, err := s.call("DeleteBlockSummaries", startSlot, endSlot)
+
+	return err
+}
+
+// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteValidatorEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	_, err := s.call("DeleteValidatorEpochSummaries", startEpoch, endEpoch)
+
+	return err
+}
+
+// DeleteValidatorDaySummaries deletes the validator day summaries for days starting in the given range
+// of timestamps, inclusive.
+func (s *Service) DeleteValidatorDaySummaries(ctx context.Context, startTimestamp time.Time, endTimestamp time.Time) error {
+	_, err := s.call("DeleteValidatorDaySummaries", startTimestamp, endTimestamp)
+
+	return err
 }
 
 // BlockSummaryForSlot obtains the summary of a block for a given slot.
-func (s *service) BlockSummaryForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
-	return nil, nil
+func (s *Service) BlockSummaryForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
+	response, err := s.call("BlockSummaryForSlot", slot)
+	value, _ := response.(*chaindb.BlockSummary)
+
+	return value, err
 }
 
 // ValidatorSummaries provides summaries according to the filter.
-func (s *service) ValidatorSummaries(ctx context.Context, filter *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
-	return nil, nil
+func (s *Service) ValidatorSummaries(ctx context.Context, filter *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
+	response, err := s.call("ValidatorSummaries", filter)
+	value, _ := response.([]*chaindb.ValidatorEpochSummary)
+
+	return value, err
+}
+
+// SetValidatorEffectiveness sets multiple validator effectiveness scores.
+func (s *Service) SetValidatorEffectiveness(ctx context.Context, effectiveness []*chaindb.ValidatorEffectiveness) error {
+	_, err := s.call("SetValidatorEffectiveness", effectiveness)
+
+	return err
+}
+
+// ValidatorEffectiveness provides the mean effectiveness of validators over a range of epochs.
+func (s *Service) ValidatorEffectiveness(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]float64,
+	error,
+) {
+	response, err := s.call("ValidatorEffectiveness", validators, startEpoch, endEpoch)
+	value, _ := response.(map[phase0.ValidatorIndex]float64)
+
+	return value, err
+}
+
+// SetValidatorIncome sets multiple validator incomes.
+func (s *Service) SetValidatorIncome(ctx context.Context, income []*chaindb.ValidatorIncome) error {
+	_, err := s.call("SetValidatorIncome", income)
+
+	return err
+}
+
+// ValidatorIncome provides the income of validators over a range of epochs.
+func (s *Service) ValidatorIncome(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]*chaindb.AggregateValidatorIncome,
+	error,
+) {
+	response, err := s.call("ValidatorIncome", validators, startEpoch, endEpoch)
+	value, _ := response.(map[phase0.ValidatorIndex]*chaindb.AggregateValidatorIncome)
+
+	return value, err
+}
+
+// ValidatorEffectivenessByLabel provides the mean effectiveness of the validators with each label over a range of epochs.
+func (s *Service) ValidatorEffectivenessByLabel(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[string]float64,
+	error,
+) {
+	response, err := s.call("ValidatorEffectivenessByLabel", labels, startEpoch, endEpoch)
+	value, _ := response.(map[string]float64)
+
+	return value, err
+}
+
+// ValidatorIncomeByLabel provides the income of the validators with each label over a range of epochs.
+func (s *Service) ValidatorIncomeByLabel(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[string]*chaindb.AggregateLabelIncome,
+	error,
+) {
+	response, err := s.call("ValidatorIncomeByLabel", labels, startEpoch, endEpoch)
+	value, _ := response.(map[string]*chaindb.AggregateLabelIncome)
+
+	return value, err
+}
+
+// WindowedValidatorIncome provides the income of validators over consecutive windows of epochs.
+func (s *Service) WindowedValidatorIncome(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	windowEpochs uint64,
+) (
+	[]*chaindb.AggregateWindowIncome,
+	error,
+) {
+	response, err := s.call("WindowedValidatorIncome", labels, startEpoch, endEpoch, windowEpochs)
+	value, _ := response.([]*chaindb.AggregateWindowIncome)
+
+	return value, err
+}
+
+// SetValidatorLabels sets the labels of a validator, replacing any existing labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, index phase0.ValidatorIndex, labels []string) error {
+	_, err := s.call("SetValidatorLabels", index, labels)
+
+	return err
+}
+
+// SetTenants sets the tenants, replacing any existing tenants.
+func (s *Service) SetTenants(ctx context.Context, tenants []*chaindb.Tenant) error {
+	_, err := s.call("SetTenants", tenants)
+
+	return err
+}
+
+// ValidatorLabels provides the labels of validators.
+func (s *Service) ValidatorLabels(ctx context.Context, validators []phase0.ValidatorIndex) (map[phase0.ValidatorIndex][]string, error) {
+	response, err := s.call("ValidatorLabels", validators)
+	value, _ := response.(map[phase0.ValidatorIndex][]string)
+
+	return value, err
+}
+
+// ValidatorsByLabel provides the indices of validators with any of the given labels.
+func (s *Service) ValidatorsByLabel(ctx context.Context, labels []string) ([]phase0.ValidatorIndex, error) {
+	response, err := s.call("ValidatorsByLabel", labels)
+	value, _ := response.([]phase0.ValidatorIndex)
+
+	return value, err
+}
+
+// ValidatorSetChanges provides the changes to the validator set between two epochs.
+func (s *Service) ValidatorSetChanges(ctx context.Context, fromEpoch phase0.Epoch, toEpoch phase0.Epoch) (*chaindb.ValidatorSetChanges, error) {
+	response, err := s.call("ValidatorSetChanges", fromEpoch, toEpoch)
+	value, _ := response.(*chaindb.ValidatorSetChanges)
+
+	return value, err
+}
+
+// ValidatorsByWithdrawalAddress fetches the indices of all validators with withdrawal
+// credentials that pay to the given execution address.
+func (s *Service) ValidatorsByWithdrawalAddress(ctx context.Context, address bellatrix.ExecutionAddress) ([]phase0.ValidatorIndex, error) {
+	response, err := s.call("ValidatorsByWithdrawalAddress", address)
+	value, _ := response.([]phase0.ValidatorIndex)
+
+	return value, err
+}
+
+// ValidatorsByWithdrawalCredentials fetches the indices of all validators with the given
+// withdrawal credentials.
+func (s *Service) ValidatorsByWithdrawalCredentials(ctx context.Context, withdrawalCredentials []byte) ([]phase0.ValidatorIndex, error) {
+	response, err := s.call("ValidatorsByWithdrawalCredentials", withdrawalCredentials)
+	value, _ := response.([]phase0.ValidatorIndex)
+
+	return value, err
+}
+
+// BlocksPerDay provides the number of canonical blocks for each UTC day in the given time range.
+func (s *Service) BlocksPerDay(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.DailyBlocks, error) {
+	response, err := s.call("BlocksPerDay", start, end)
+	value, _ := response.([]*chaindb.DailyBlocks)
+
+	return value, err
+}
+
+// AverageAttestationsPerBlock provides the mean number of attestations included in canonical blocks in the given slot range.
+func (s *Service) AverageAttestationsPerBlock(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (float64, error) {
+	response, err := s.call("AverageAttestationsPerBlock", startSlot, endSlot)
+	value, _ := response.(float64)
+
+	return value, err
+}
+
+// ParticipationForEpochRange provides the participation for each summarized epoch in the given epoch range.
+func (s *Service) ParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochParticipation, error) {
+	response, err := s.call("ParticipationForEpochRange", startEpoch, endEpoch)
+	value, _ := response.([]*chaindb.EpochParticipation)
+
+	return value, err
 }
 
 // ValidatorSummariesForEpoch obtains all summaries for a given epoch.
-func (s *service) ValidatorSummariesForEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
-	return nil, nil
+func (s *Service) ValidatorSummariesForEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
+	response, err := s.call("ValidatorSummariesForEpoch", epoch)
+	value, _ := response.([]*chaindb.ValidatorEpochSummary)
+
+	return value, err
 }
 
 // ValidatorSummaryForEpoch obtains the summary of a validator for a given epoch.
-func (s *service) ValidatorSummaryForEpoch(ctx context.Context, index phase0.ValidatorIndex, epoch phase0.Epoch) (*chaindb.ValidatorEpochSummary, error) {
-	return nil, nil
+func (s *Service) ValidatorSummaryForEpoch(ctx context.Context, index phase0.ValidatorIndex, epoch phase0.Epoch) (*chaindb.ValidatorEpochSummary, error) {
+	response, err := s.call("ValidatorSummaryForEpoch", index, epoch)
+	value, _ := response.(*chaindb.ValidatorEpochSummary)
+
+	return value, err
 }
 
 // SetBlockSummary sets a block summary.
-func (s *service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
-	return nil
+func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
+	_, err := s.call("SetBlockSummary", summary)
+
+	return err
 }
 
 // SetEpochSummary sets an epoch summary.
-func (s *service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
-	return nil
+func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
+	_, err := s.call("SetEpochSummary", summary)
+
+	return err
 }
 
 // SyncCommittee provides a sync committee for the given sync committee period.
-func (s *service) SyncCommittee(ctx context.Context, period uint64) (*chaindb.SyncCommittee, error) {
-	return nil, nil
+func (s *Service) SyncCommittee(ctx context.Context, period uint64) (*chaindb.SyncCommittee, error) {
+	response, err := s.call("SyncCommittee", period)
+	value, _ := response.(*chaindb.SyncCommittee)
+
+	return value, err
+}
+
+// SetBlockArrival records the arrival of a block.
+func (s *Service) SetBlockArrival(ctx context.Context, arrival *chaindb.BlockArrival) error {
+	_, err := s.call("SetBlockArrival", arrival)
+
+	return err
+}
+
+// BlockArrivals fetches the arrivals of blocks in the given slot range.
+func (s *Service) BlockArrivals(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.BlockArrival, error) {
+	response, err := s.call("BlockArrivals", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.BlockArrival)
+
+	return value, err
+}
+
+// SetBlockClients records the likely clients of the proposers of blocks.
+func (s *Service) SetBlockClients(ctx context.Context, clients []*chaindb.BlockClient) error {
+	_, err := s.call("SetBlockClients", clients)
+
+	return err
+}
+
+// SetBlockRelays records the relays that delivered the execution payloads of blocks.
+func (s *Service) SetBlockRelays(ctx context.Context, relays []*chaindb.BlockRelays) error {
+	_, err := s.call("SetBlockRelays", relays)
+
+	return err
+}
+
+// ValidatorRegistrations fetches the registrations of validators with relays.
+func (s *Service) ValidatorRegistrations(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	from time.Time,
+	to time.Time,
+) (
+	[]*chaindb.ValidatorRegistration,
+	error,
+) {
+	response, err := s.call("ValidatorRegistrations", validators, from, to)
+	value, _ := response.([]*chaindb.ValidatorRegistration)
+
+	return value, err
+}
+
+// SetValidatorRegistrations records the registrations of validators with relays.
+func (s *Service) SetValidatorRegistrations(ctx context.Context, registrations []*chaindb.ValidatorRegistration) error {
+	_, err := s.call("SetValidatorRegistrations", registrations)
+
+	return err
+}
+
+// QuarantinedItems fetches the quarantined items of the given module.
+func (s *Service) QuarantinedItems(ctx context.Context, module string) ([]*chaindb.QuarantinedItem, error) {
+	response, err := s.call("QuarantinedItems", module)
+	value, _ := response.([]*chaindb.QuarantinedItem)
+
+	return value, err
+}
+
+// SetQuarantinedItem records data that failed to be stored.
+func (s *Service) SetQuarantinedItem(ctx context.Context, item *chaindb.QuarantinedItem) error {
+	_, err := s.call("SetQuarantinedItem", item)
+
+	return err
+}
+
+// DeleteQuarantinedItems removes the quarantined items of a module for the given slots.
+func (s *Service) DeleteQuarantinedItems(ctx context.Context, module string, slots []phase0.Slot) error {
+	_, err := s.call("DeleteQuarantinedItems", module, slots)
+
+	return err
+}
+
+// ErrTransient is an error that the mock does not consider to be deterministic.
+var ErrTransient = errors.New("transient error")
+
+// IsDeterministicError returns true unless the error is, or wraps, ErrTransient.
+func (*Service) IsDeterministicError(err error) bool {
+	return !errors.Is(err, ErrTransient)
+}
+
+// BlockClients fetches the likely clients of the proposers of blocks in the given slot range.
+func (s *Service) BlockClients(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.BlockClient, error) {
+	response, err := s.call("BlockClients", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.BlockClient)
+
+	return value, err
+}
+
+// ClientShares fetches the number of canonical blocks proposed by each client in each epoch.
+func (s *Service) ClientShares(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch, minConfidence float64) ([]*chaindb.ClientShare, error) {
+	response, err := s.call("ClientShares", startEpoch, endEpoch, minConfidence)
+	value, _ := response.([]*chaindb.ClientShare)
+
+	return value, err
+}
+
+// SetGossipAttestations records attestations seen on the network.
+func (s *Service) SetGossipAttestations(ctx context.Context, attestations []*chaindb.GossipAttestation) error {
+	_, err := s.call("SetGossipAttestations", attestations)
+
+	return err
+}
+
+// GossipAttestations fetches the attestations seen on the network for the given slot range.
+func (s *Service) GossipAttestations(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.GossipAttestation, error) {
+	response, err := s.call("GossipAttestations", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.GossipAttestation)
+
+	return value, err
+}
+
+// SetPeerCounts records peer counts.
+func (s *Service) SetPeerCounts(ctx context.Context, counts []*chaindb.PeerCount) error {
+	_, err := s.call("SetPeerCounts", counts)
+
+	return err
+}
+
+// PeerCounts fetches the peer counts taken in the given time range.
+func (s *Service) PeerCounts(ctx context.Context, from time.Time, to time.Time) ([]*chaindb.PeerCount, error) {
+	response, err := s.call("PeerCounts", from, to)
+	value, _ := response.([]*chaindb.PeerCount)
+
+	return value, err
+}
+
+// SetMissedSlot marks a slot as not having a block.
+func (s *Service) SetMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	_, err := s.call("SetMissedSlot", slot)
+
+	return err
+}
+
+// MissedSlots fetches the missed slots in the given range.
+func (s *Service) MissedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.MissedSlot, error) {
+	response, err := s.call("MissedSlots", minSlot, maxSlot)
+	value, _ := response.([]*chaindb.MissedSlot)
+
+	return value, err
+}
+
+// UnaccountedSlots fetches the slots in the given range that have neither a block nor
+// a missed slot marker in the database.
+func (s *Service) UnaccountedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	response, err := s.call("UnaccountedSlots", minSlot, maxSlot)
+	value, _ := response.([]phase0.Slot)
+
+	return value, err
+}
+
+// DeleteSlotData deletes blocks, along with their contents, and missed slot markers in the given slot range.
+func (s *Service) DeleteSlotData(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	_, err := s.call("DeleteSlotData", startSlot, endSlot)
+
+	return err
 }
 
 // SetSyncCommittee sets a sync committee.
-func (s *service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
-	return nil
+func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
+	_, err := s.call("SetSyncCommittee", syncCommittee)
+
+	return err
+}
+
+// Aggregates provides the names of the aggregates managed by the database.
+func (s *Service) Aggregates(ctx context.Context) ([]string, error) {
+	response, err := s.call("Aggregates")
+	value, _ := response.([]string)
+
+	return value, err
+}
+
+// UpdateAggregate adds up to the given number of summarized epochs, from the given epoch
+// onwards, to the given aggregate.
+func (s *Service) UpdateAggregate(ctx context.Context, name string, fromEpoch phase0.Epoch, epochs uint64) (phase0.Epoch, error) {
+	response, err := s.call("UpdateAggregate", name, fromEpoch, epochs)
+	value, _ := response.(phase0.Epoch)
+
+	return value, err
+}
+
+// TableStats provides statistics about the tables managed by the database.
+func (s *Service) TableStats(ctx context.Context) ([]*chaindb.TableStats, error) {
+	response, err := s.call("TableStats")
+	value, _ := response.([]*chaindb.TableStats)
+
+	return value, err
+}
+
+// PrunableTables provides the names of the tables that can be pruned.
+func (s *Service) PrunableTables(ctx context.Context) ([]string, error) {
+	response, err := s.call("PrunableTables")
+	value, _ := response.([]string)
+
+	return value, err
+}
+
+// PruneTable removes the rows of the given table for epochs up to (but not including) the given epoch.
+func (s *Service) PruneTable(ctx context.Context, name string, epoch phase0.Epoch, slot phase0.Slot) (int64, error) {
+	response, err := s.call("PruneTable", name, epoch, slot)
+	value, _ := response.(int64)
+
+	return value, err
+}
+
+// SetAuditEntries records mutations in the audit log.
+func (s *Service) SetAuditEntries(ctx context.Context, entries []*chaindb.AuditEntry) error {
+	_, err := s.call("SetAuditEntries", entries)
+
+	return err
+}
+
+// BeginBackfill prepares the database for backfill.
+func (s *Service) BeginBackfill(ctx context.Context) error {
+	_, err := s.call("BeginBackfill")
+
+	return err
+}
+
+// EndBackfill restores the database after backfill.
+func (s *Service) EndBackfill(ctx context.Context) error {
+	_, err := s.call("EndBackfill")
+
+	return err
+}
+
+// Drain waits for active read-write transactions to complete.
+func (s *Service) Drain(ctx context.Context) error {
+	_, err := s.call("Drain")
+
+	return err
 }
 
 // BeginTx begins a transaction.
-func (s *service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
-	return nil, nil, nil
+func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	_, err := s.call("BeginTx")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, func() {}, nil
 }
 
 // CommitTx commits a transaction.
-func (s *service) CommitTx(ctx context.Context) error {
-	return nil
+func (s *Service) CommitTx(ctx context.Context) error {
+	_, err := s.call("CommitTx")
+
+	return err
 }
 
 // SetMetadata sets a metadata key to a JSON value.
-func (s *service) SetMetadata(ctx context.Context, key string, value []byte) error {
-	return nil
+func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) error {
+	_, err := s.call("SetMetadata", key, value)
+
+	return err
 }
 
 // Metadata obtains the JSON value from a metadata key.
-func (s *service) Metadata(ctx context.Context, key string) ([]byte, error) {
-	return nil, nil
+func (s *Service) Metadata(ctx context.Context, key string) ([]byte, error) {
+	response, err := s.call("Metadata", key)
+	value, _ := response.([]byte)
+
+	return value, err
 }
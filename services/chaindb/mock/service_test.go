@@ -0,0 +1,117 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+func TestInterfaces(t *testing.T) {
+	s := mockchaindb.New()
+	require.Implements(t, (*chaindb.Service)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsStreamer)(nil), s)
+	require.Implements(t, (*chaindb.Backfiller)(nil), s)
+	require.Implements(t, (*chaindb.BlockArrivalsProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlockArrivalsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlocksProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlocksSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlocksStreamer)(nil), s)
+	require.Implements(t, (*chaindb.Drainer)(nil), s)
+	require.Implements(t, (*chaindb.GossipAttestationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.GossipAttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockClientsProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlockClientsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockRelaysSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorRegistrationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorRegistrationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.QuarantineProvider)(nil), s)
+	require.Implements(t, (*chaindb.QuarantineSetter)(nil), s)
+	require.Implements(t, (*chaindb.WriteErrorClassifier)(nil), s)
+	require.Implements(t, (*chaindb.ETH1DepositsDeleter)(nil), s)
+	require.Implements(t, (*chaindb.TenantsSetter)(nil), s)
+	require.Implements(t, (*chaindb.PeerCountsProvider)(nil), s)
+	require.Implements(t, (*chaindb.PeerCountsSetter)(nil), s)
+	require.Implements(t, (*chaindb.AggregatesUpdater)(nil), s)
+	require.Implements(t, (*chaindb.TablePruner)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorBalancesStreamer)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorsProvider)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
+}
+
+func TestCalls(t *testing.T) {
+	ctx := context.Background()
+	s := mockchaindb.New()
+
+	block := &chaindb.Block{Slot: 1}
+	require.NoError(t, s.SetBlock(ctx, block))
+	require.NoError(t, s.SetMissedSlot(ctx, 2))
+	require.NoError(t, s.SetBlock(ctx, &chaindb.Block{Slot: 3}))
+
+	calls := s.Calls()
+	require.Len(t, calls, 3)
+	require.Equal(t, "SetMissedSlot", calls[1].Method)
+	require.Equal(t, []interface{}{phase0.Slot(2)}, calls[1].Args)
+
+	calls = s.CallsTo("SetBlock")
+	require.Len(t, calls, 2)
+	require.Equal(t, block, calls[0].Args[0])
+
+	s.ResetCalls()
+	require.Empty(t, s.Calls())
+}
+
+func TestResponses(t *testing.T) {
+	ctx := context.Background()
+	s := mockchaindb.New()
+
+	// Defaults.
+	blocks, err := s.BlocksBySlot(ctx, 1)
+	require.NoError(t, err)
+	require.Nil(t, blocks)
+	spec, err := s.ChainSpec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), spec["SLOTS_PER_EPOCH"])
+
+	// Canned response.
+	s.SetResponse("BlocksBySlot", []*chaindb.Block{{Slot: 1}})
+	blocks, err = s.BlocksBySlot(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	// Canned response for a streamer.
+	var streamed []*chaindb.Block
+	s.SetResponse("ForEachBlockInSlotRange", []*chaindb.Block{{Slot: 1}, {Slot: 2}})
+	require.NoError(t, s.ForEachBlockInSlotRange(ctx, 1, 3, func(block *chaindb.Block) error {
+		streamed = append(streamed, block)
+		return nil
+	}))
+	require.Len(t, streamed, 2)
+
+	// Canned errors.
+	s.SetError("SetBlock", errors.New("mock error"))
+	require.EqualError(t, s.SetBlock(ctx, &chaindb.Block{}), "mock error")
+	_, _, err = s.BeginTx(ctx)
+	require.NoError(t, err)
+	s.SetError("SetBlock", nil)
+	require.NoError(t, s.SetBlock(ctx, &chaindb.Block{}))
+}
@@ -0,0 +1,204 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// aggregate is an aggregate of a summary table.
+type aggregate struct {
+	name string
+	// source is the summary table from which the aggregate is calculated.
+	source string
+	// updateSQL adds the epochs in the range [$1, $2) of the source to the aggregate.
+	updateSQL string
+}
+
+// aggregates are the aggregates managed by the database.
+var aggregates = []*aggregate{
+	{
+		name:   "t_validator_performance",
+		source: "t_validator_epoch_summaries",
+		// Totals for each validator are added to, so only the new epochs are read.
+		updateSQL: `
+INSERT INTO t_validator_performance(f_validator_index
+                                   ,f_epochs
+                                   ,f_proposer_duties
+                                   ,f_proposals_included
+                                   ,f_attestations_included
+                                   ,f_attestations_target_correct
+                                   ,f_attestations_head_correct
+                                   ,f_total_inclusion_delay)
+SELECT f_validator_index
+      ,COUNT(*)
+      ,SUM(f_proposer_duties)
+      ,SUM(f_proposals_included)
+      ,COUNT(*) FILTER (WHERE f_attestation_included)
+      ,COUNT(*) FILTER (WHERE f_attestation_target_correct)
+      ,COUNT(*) FILTER (WHERE f_attestation_head_correct)
+      ,COALESCE(SUM(f_attestation_inclusion_delay), 0)
+FROM t_validator_epoch_summaries
+WHERE f_epoch >= $1
+  AND f_epoch < $2
+GROUP BY f_validator_index
+ON CONFLICT (f_validator_index) DO
+UPDATE
+SET f_epochs = t_validator_performance.f_epochs + excluded.f_epochs
+   ,f_proposer_duties = t_validator_performance.f_proposer_duties + excluded.f_proposer_duties
+   ,f_proposals_included = t_validator_performance.f_proposals_included + excluded.f_proposals_included
+   ,f_attestations_included = t_validator_performance.f_attestations_included + excluded.f_attestations_included
+   ,f_attestations_target_correct = t_validator_performance.f_attestations_target_correct + excluded.f_attestations_target_correct
+   ,f_attestations_head_correct = t_validator_performance.f_attestations_head_correct + excluded.f_attestations_head_correct
+   ,f_total_inclusion_delay = t_validator_performance.f_total_inclusion_delay + excluded.f_total_inclusion_delay
+`,
+	},
+	{
+		name:   "t_daily_aggregates",
+		source: "t_epoch_summaries",
+		// Averages cannot be added to, so the days containing the new epochs are recalculated
+		// from their epochs up to the end of the range.  The lower bound on the epochs read
+		// is the start of the day containing the first new epoch.
+		updateSQL: `
+WITH params AS (
+  SELECT EXTRACT(EPOCH FROM f_time)::BIGINT AS f_genesis
+        ,(SELECT f_value::BIGINT FROM t_chain_spec WHERE f_key = 'SECONDS_PER_SLOT' ORDER BY f_effective_epoch DESC LIMIT 1) *
+         slots_per_epoch() AS f_epoch_duration
+  FROM t_genesis
+)
+,days AS (
+  SELECT DISTINCT (TO_TIMESTAMP(params.f_genesis + f_epoch * params.f_epoch_duration) AT TIME ZONE 'UTC')::DATE AS f_day
+  FROM t_epoch_summaries
+      ,params
+  WHERE f_epoch >= $1
+    AND f_epoch < $2
+)
+INSERT INTO t_daily_aggregates(f_day
+                              ,f_start_epoch
+                              ,f_end_epoch
+                              ,f_active_validators
+                              ,f_active_balance
+                              ,f_participation_rate
+                              ,f_canonical_blocks
+                              ,f_proposer_slashings
+                              ,f_attester_slashings
+                              ,f_deposits
+                              ,f_exiting_validators)
+SELECT (TO_TIMESTAMP(params.f_genesis + f_epoch * params.f_epoch_duration) AT TIME ZONE 'UTC')::DATE
+      ,MIN(f_epoch)
+      ,MAX(f_epoch)
+      ,AVG(f_active_validators)::BIGINT
+      ,AVG(f_active_balance)::BIGINT
+      ,SUM(f_attesting_balance)::FLOAT8 / NULLIF(SUM(f_active_balance), 0)
+      ,SUM(f_canonical_blocks)
+      ,SUM(f_proposer_slashings)
+      ,SUM(f_attester_slashings)
+      ,SUM(f_deposits)
+      ,SUM(f_exiting_validators)
+FROM t_epoch_summaries
+    ,params
+WHERE f_epoch >= $1 - 86400 / params.f_epoch_duration - 1
+  AND f_epoch < $2
+  AND (TO_TIMESTAMP(params.f_genesis + f_epoch * params.f_epoch_duration) AT TIME ZONE 'UTC')::DATE IN (SELECT f_day FROM days)
+GROUP BY 1
+ON CONFLICT (f_day) DO
+UPDATE
+SET f_start_epoch = excluded.f_start_epoch
+   ,f_end_epoch = excluded.f_end_epoch
+   ,f_active_validators = excluded.f_active_validators
+   ,f_active_balance = excluded.f_active_balance
+   ,f_participation_rate = excluded.f_participation_rate
+   ,f_canonical_blocks = excluded.f_canonical_blocks
+   ,f_proposer_slashings = excluded.f_proposer_slashings
+   ,f_attester_slashings = excluded.f_attester_slashings
+   ,f_deposits = excluded.f_deposits
+   ,f_exiting_validators = excluded.f_exiting_validators
+`,
+	},
+}
+
+// Aggregates provides the names of the aggregates managed by the database.
+func (s *Service) Aggregates(_ context.Context) ([]string, error) {
+	names := make([]string, len(aggregates))
+	for i, aggregate := range aggregates {
+		names[i] = aggregate.name
+	}
+
+	return names, nil
+}
+
+// UpdateAggregate adds up to the given number of summarized epochs, from the given epoch
+// onwards, to the given aggregate.
+// It returns the epoch from which to continue, which is the given epoch if no epochs
+// have been summarized since.
+func (s *Service) UpdateAggregate(ctx context.Context, name string, fromEpoch phase0.Epoch, epochs uint64) (phase0.Epoch, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return 0, ErrNoTransaction
+	}
+
+	var agg *aggregate
+	for _, aggregate := range aggregates {
+		if aggregate.name == name {
+			agg = aggregate
+			break
+		}
+	}
+	if agg == nil {
+		return 0, fmt.Errorf("unknown aggregate %q", name)
+	}
+	if epochs == 0 {
+		return fromEpoch, nil
+	}
+
+	// Summaries for each epoch are written in a single transaction, so the epochs that
+	// have been summarized are those up to the latest epoch in the source.  Summaries
+	// may not start at the given epoch, in which case the range starts at the first.
+	var firstEpoch sql.NullInt64
+	var lastEpoch sql.NullInt64
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`
+SELECT MIN(f_epoch)
+      ,MAX(f_epoch)
+FROM %s
+WHERE f_epoch >= $1
+`, agg.source),
+		fromEpoch,
+	).Scan(&firstEpoch, &lastEpoch); err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to obtain summarized epochs of %s", agg.source))
+	}
+	if !firstEpoch.Valid {
+		// Nothing summarized since.
+		return fromEpoch, nil
+	}
+	toEpoch := phase0.Epoch(firstEpoch.Int64) + phase0.Epoch(epochs)
+	if toEpoch > phase0.Epoch(lastEpoch.Int64)+1 {
+		toEpoch = phase0.Epoch(lastEpoch.Int64) + 1
+	}
+
+	tag, err := tx.Exec(ctx, agg.updateSQL,
+		fromEpoch,
+		toEpoch,
+	)
+	s.monitorWrite(agg.name, int(tag.RowsAffected()), err)
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to update %s", agg.name))
+	}
+
+	return toEpoch, nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestUpdateAggregate(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	tx := s.tx(ctx)
+
+	// Use a validator and epochs well beyond those of any real data.
+	index := phase0.ValidatorIndex(0x7fffff00)
+	epoch := phase0.Epoch(0x7fffff00)
+	_, err = tx.Exec(ctx, "DELETE FROM t_validator_performance WHERE f_validator_index = $1", index)
+	require.NoError(t, err)
+
+	setSummary := func(epoch phase0.Epoch) {
+		require.NoError(t, s.SetValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{
+			Index:               index,
+			Epoch:               epoch,
+			ProposerDuties:      1,
+			ProposalsIncluded:   1,
+			AttestationIncluded: true,
+		}))
+	}
+	proposerDuties := func() (int64, int64) {
+		var epochs int64
+		var duties int64
+		require.NoError(t, tx.QueryRow(ctx, "SELECT f_epochs, f_proposer_duties FROM t_validator_performance WHERE f_validator_index = $1", index).Scan(&epochs, &duties))
+		return epochs, duties
+	}
+
+	// Nothing summarized from the epoch.
+	next, err := s.UpdateAggregate(ctx, "t_validator_performance", epoch, 32)
+	require.NoError(t, err)
+	require.Equal(t, epoch, next)
+
+	setSummary(epoch)
+	setSummary(epoch + 1)
+	next, err = s.UpdateAggregate(ctx, "t_validator_performance", epoch, 32)
+	require.NoError(t, err)
+	require.Equal(t, epoch+2, next)
+	epochs, duties := proposerDuties()
+	require.Equal(t, int64(2), epochs)
+	require.Equal(t, int64(2), duties)
+
+	// An epoch already added is not read again, so changing its summary does not change
+	// the aggregate; only the new epoch is added.
+	_, err = tx.Exec(ctx, "UPDATE t_validator_epoch_summaries SET f_proposer_duties = 5 WHERE f_validator_index = $1 AND f_epoch = $2", index, epoch)
+	require.NoError(t, err)
+	setSummary(epoch + 2)
+	next, err = s.UpdateAggregate(ctx, "t_validator_performance", next, 32)
+	require.NoError(t, err)
+	require.Equal(t, epoch+3, next)
+	epochs, duties = proposerDuties()
+	require.Equal(t, int64(3), epochs)
+	require.Equal(t, int64(3), duties)
+
+	// Updating with nothing new leaves the aggregate alone.
+	next, err = s.UpdateAggregate(ctx, "t_validator_performance", next, 32)
+	require.NoError(t, err)
+	require.Equal(t, epoch+3, next)
+	epochs, duties = proposerDuties()
+	require.Equal(t, int64(3), epochs)
+	require.Equal(t, int64(3), duties)
+
+	_, err = s.UpdateAggregate(ctx, "t_unknown", next, 32)
+	require.EqualError(t, err, `unknown aggregate "t_unknown"`)
+}
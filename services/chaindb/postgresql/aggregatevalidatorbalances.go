@@ -37,43 +37,17 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpoch(
 		return &chaindb.AggregateValidatorBalance{}, nil
 	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, cancel, err := s.BeginTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
-		}
-		tx = s.tx(ctx)
-		defer cancel()
-	}
-
-	var balance phase0.Gwei
-	var effectiveBalance phase0.Gwei
-
-	err := tx.QueryRow(ctx, fmt.Sprintf(`
-      SELECT SUM(f_balance)
-            ,SUM(f_effective_balance)
-      FROM t_validator_balances
-      JOIN (VALUES %s)
-        AS x(id)
-        ON x.id = t_validator_balances.f_validator_index
-      WHERE f_epoch = $1`, fastIndices(validatorIndices)),
-		uint64(epoch),
-	).Scan(
-		&balance,
-		&effectiveBalance,
-	)
+	aggregateBalances, err := s.aggregateValidatorBalances(ctx, validatorIndices, []phase0.Epoch{epoch})
 	if err != nil {
 		return nil, err
 	}
-
-	aggregateBalance := &chaindb.AggregateValidatorBalance{
-		Epoch:            epoch,
-		Balance:          balance,
-		EffectiveBalance: effectiveBalance,
+	if len(aggregateBalances) == 0 {
+		return &chaindb.AggregateValidatorBalance{
+			Epoch: epoch,
+		}, nil
 	}
 
-	return aggregateBalance, nil
+	return aggregateBalances[0], nil
 }
 
 // AggregateValidatorBalancesByIndexAndEpochRange fetches the aggregate validator balances for the given validators and
@@ -93,51 +67,7 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpochRange(
 		return []*chaindb.AggregateValidatorBalance{}, nil
 	}
 
-	tx := s.tx(ctx)
-	if tx == nil {
-		ctx, cancel, err := s.BeginTx(ctx)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to begin transaction")
-		}
-		tx = s.tx(ctx)
-		defer cancel()
-	}
-
-	rows, err := tx.Query(ctx, fmt.Sprintf(`
-      SELECT f_epoch
-            ,SUM(f_balance)
-            ,SUM(f_effective_balance)
-      FROM t_validator_balances
-      JOIN (VALUES %s)
-        AS x(id)
-        ON x.id = t_validator_balances.f_validator_index
-      WHERE f_epoch >= $1
-        AND f_epoch < $2
-      GROUP BY f_epoch
-      ORDER BY f_epoch`, fastIndices(validatorIndices)),
-		uint64(startEpoch),
-		uint64(endEpoch),
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	aggregateBalances := make([]*chaindb.AggregateValidatorBalance, 0, int(endEpoch-startEpoch))
-	for rows.Next() {
-		aggregateBalance := &chaindb.AggregateValidatorBalance{}
-		err := rows.Scan(
-			&aggregateBalance.Epoch,
-			&aggregateBalance.Balance,
-			&aggregateBalance.EffectiveBalance,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		aggregateBalances = append(aggregateBalances, aggregateBalance)
-	}
-
-	return aggregateBalances, nil
+	return s.aggregateValidatorBalances(ctx, validatorIndices, epochRange(startEpoch, endEpoch))
 }
 
 // AggregateValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
@@ -153,6 +83,18 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpochs(
 		return []*chaindb.AggregateValidatorBalance{}, nil
 	}
 
+	return s.aggregateValidatorBalances(ctx, validatorIndices, sortedEpochs(epochs))
+}
+
+// aggregateValidatorBalances sums the balances of the given validators at the given epochs,
+// which must be in increasing order.  Epochs without balances are omitted.
+func (s *Service) aggregateValidatorBalances(ctx context.Context,
+	validatorIndices []phase0.ValidatorIndex,
+	epochs []phase0.Epoch,
+) (
+	[]*chaindb.AggregateValidatorBalance,
+	error,
+) {
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, cancel, err := s.BeginTx(ctx)
@@ -163,39 +105,21 @@ func (s *Service) AggregateValidatorBalancesByIndexAndEpochs(
 		defer cancel()
 	}
 
-	dbEpochs := make([]uint64, len(epochs))
-	for i, epoch := range epochs {
-		dbEpochs[i] = uint64(epoch)
-	}
-	rows, err := tx.Query(ctx, fmt.Sprintf(`
-      SELECT f_epoch
-            ,SUM(f_balance)
-            ,SUM(f_effective_balance)
-      FROM t_validator_balances
-      JOIN (VALUES %s)
-        AS x(id)
-        ON x.id = t_validator_balances.f_validator_index
-      WHERE f_epoch = ANY($1)
-      ORDER BY f_epoch`, fastIndices(validatorIndices)),
-		dbEpochs,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
 	aggregateBalances := make([]*chaindb.AggregateValidatorBalance, 0, len(epochs))
-	for rows.Next() {
-		aggregateBalance := &chaindb.AggregateValidatorBalance{}
-		err := rows.Scan(
-			&aggregateBalance.Epoch,
-			&aggregateBalance.Balance,
-			&aggregateBalance.EffectiveBalance,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
+	err := s.forEachReconstructedBalance(ctx, tx, validatorIndices, epochs, func(validatorBalance *chaindb.ValidatorBalance) error {
+		// Balances are provided in epoch order.
+		if len(aggregateBalances) == 0 || aggregateBalances[len(aggregateBalances)-1].Epoch != validatorBalance.Epoch {
+			aggregateBalances = append(aggregateBalances, &chaindb.AggregateValidatorBalance{
+				Epoch: validatorBalance.Epoch,
+			})
 		}
-		aggregateBalances = append(aggregateBalances, aggregateBalance)
+		aggregateBalance := aggregateBalances[len(aggregateBalances)-1]
+		aggregateBalance.Balance += validatorBalance.Balance
+		aggregateBalance.EffectiveBalance += validatorBalance.EffectiveBalance
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return aggregateBalances, nil
@@ -18,6 +18,7 @@ import (
 	"database/sql"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
@@ -29,6 +30,10 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		return ErrNoTransaction
 	}
 
+	if s.filter != nil && !s.filter.IncludeAttestation(ctx, attestation) {
+		return nil
+	}
+
 	var canonical sql.NullBool
 	if attestation.Canonical != nil {
 		canonical.Valid = true
@@ -44,6 +49,9 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		headCorrect.Valid = true
 		headCorrect.Bool = *attestation.HeadCorrect
 	}
+	// Aggregation indices are not stored, as they are expanded from the aggregation bits and
+	// the relevant beacon committee when read.  Indices stored by earlier releases are kept on
+	// update if the data from which they were derived is unchanged.
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_attestations(f_inclusion_slot
                                 ,f_inclusion_block_root
@@ -51,7 +59,6 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
                                 ,f_slot
                                 ,f_committee_index
                                 ,f_aggregation_bits
-                                ,f_aggregation_indices
                                 ,f_beacon_block_root
                                 ,f_source_epoch
                                 ,f_source_root
@@ -60,14 +67,19 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
                                 ,f_canonical
                                 ,f_target_correct
                                 ,f_head_correct
+                                ,f_inclusion_epoch
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$1::BIGINT / slots_per_epoch())
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_slot = excluded.f_slot
          ,f_committee_index = excluded.f_committee_index
          ,f_aggregation_bits = excluded.f_aggregation_bits
-         ,f_aggregation_indices = excluded.f_aggregation_indices
+         ,f_aggregation_indices = CASE WHEN t_attestations.f_slot = excluded.f_slot
+                                        AND t_attestations.f_committee_index = excluded.f_committee_index
+                                        AND t_attestations.f_aggregation_bits = excluded.f_aggregation_bits
+                                       THEN t_attestations.f_aggregation_indices
+                                  END
          ,f_beacon_block_root = excluded.f_beacon_block_root
          ,f_source_epoch = excluded.f_source_epoch
          ,f_source_root = excluded.f_source_root
@@ -76,6 +88,7 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
          ,f_canonical = excluded.f_canonical
          ,f_target_correct = excluded.f_target_correct
          ,f_head_correct = excluded.f_head_correct
+         ,f_inclusion_epoch = excluded.f_inclusion_epoch
 	  `,
 		attestation.InclusionSlot,
 		attestation.InclusionBlockRoot[:],
@@ -83,7 +96,6 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		attestation.Slot,
 		attestation.CommitteeIndex,
 		attestation.AggregationBits,
-		attestation.AggregationIndices,
 		attestation.BeaconBlockRoot[:],
 		attestation.SourceEpoch,
 		attestation.SourceRoot[:],
@@ -93,6 +105,7 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		targetCorrect,
 		headCorrect,
 	)
+	s.monitorWrite("t_attestations", 1, err)
 
 	return err
 }
@@ -116,7 +129,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
-            ,f_aggregation_indices
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
             ,f_beacon_block_root
             ,f_source_epoch
             ,f_source_root
@@ -139,55 +152,10 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := attestationFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
-		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -213,7 +181,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
-            ,f_aggregation_indices
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
             ,f_beacon_block_root
             ,f_source_epoch
             ,f_source_root
@@ -236,55 +204,10 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := attestationFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
-		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -312,7 +235,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
-            ,f_aggregation_indices
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
             ,f_beacon_block_root
             ,f_source_epoch
             ,f_source_root
@@ -337,55 +260,10 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := attestationFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
-		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
-		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
-		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
-		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -413,7 +291,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
-            ,f_aggregation_indices
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
             ,f_beacon_block_root
             ,f_source_epoch
             ,f_source_root
@@ -438,54 +316,146 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 	attestations := make([]*chaindb.Attestation, 0)
 
 	for rows.Next() {
-		attestation := &chaindb.Attestation{}
-		var inclusionBlockRoot []byte
-		var aggregationIndices []uint64
-		var beaconBlockRoot []byte
-		var sourceRoot []byte
-		var targetRoot []byte
-		var canonical sql.NullBool
-		var targetCorrect sql.NullBool
-		var headCorrect sql.NullBool
-		err := rows.Scan(
-			&attestation.InclusionSlot,
-			&inclusionBlockRoot,
-			&attestation.InclusionIndex,
-			&attestation.Slot,
-			&attestation.CommitteeIndex,
-			&attestation.AggregationBits,
-			&aggregationIndices,
-			&beaconBlockRoot,
-			&attestation.SourceEpoch,
-			&sourceRoot,
-			&attestation.TargetEpoch,
-			&targetRoot,
-			&canonical,
-			&targetCorrect,
-			&headCorrect,
-		)
+		attestation, err := attestationFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
-		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
-		for i := range aggregationIndices {
-			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+		attestations = append(attestations, attestation)
+	}
+
+	return attestations, nil
+}
+
+// AttestationsForValidator fetches all attestations made for the given slot range that include the given validator.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// attestations for slots 2 and 3.
+func (s *Service) AttestationsForValidator(ctx context.Context,
+	index phase0.ValidatorIndex,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.Attestation,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
 		}
-		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
-		copy(attestation.SourceRoot[:], sourceRoot)
-		copy(attestation.TargetRoot[:], targetRoot)
-		if canonical.Valid {
-			val := canonical.Bool
-			attestation.Canonical = &val
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	// Aggregation indices are not stored, so find the committees containing the validator
+	// (using the index on committee members) and check the validator's bit in each attestation.
+	// Attestations written by earlier releases may have stored indices, which are used instead.
+	rows, err := tx.Query(ctx, `
+      WITH committees AS (
+        SELECT f_slot
+              ,f_index
+              ,ARRAY_POSITION(f_committee, $1::BIGINT) - 1 AS f_position
+              ,CARDINALITY(f_committee) AS f_size
+        FROM t_beacon_committees
+        WHERE f_slot >= $2
+          AND f_slot < $3
+          AND f_committee @> ARRAY[$1::BIGINT]
+      )
+      SELECT t_attestations.f_inclusion_slot
+            ,t_attestations.f_inclusion_block_root
+            ,t_attestations.f_inclusion_index
+            ,t_attestations.f_slot
+            ,t_attestations.f_committee_index
+            ,t_attestations.f_aggregation_bits
+            ,COALESCE(t_attestations.f_aggregation_indices,attestation_aggregation_indices(t_attestations.f_aggregation_bits,t_attestations.f_slot,t_attestations.f_committee_index))
+            ,t_attestations.f_beacon_block_root
+            ,t_attestations.f_source_epoch
+            ,t_attestations.f_source_root
+            ,t_attestations.f_target_epoch
+            ,t_attestations.f_target_root
+            ,t_attestations.f_canonical
+            ,t_attestations.f_target_correct
+            ,t_attestations.f_head_correct
+      FROM t_attestations
+      LEFT JOIN committees ON t_attestations.f_slot = committees.f_slot
+                          AND t_attestations.f_committee_index = committees.f_index
+      WHERE t_attestations.f_slot >= $2
+        AND t_attestations.f_slot < $3
+        AND (t_attestations.f_aggregation_indices @> ARRAY[$1::BIGINT]
+             OR (t_attestations.f_aggregation_indices IS NULL
+                 AND bitlist_len(t_attestations.f_aggregation_bits) = committees.f_size
+                 AND GET_BIT(t_attestations.f_aggregation_bits, committees.f_position) = 1))
+      ORDER BY t_attestations.f_inclusion_slot
+	          ,t_attestations.f_inclusion_index`,
+		index,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.Attestation, 0)
+
+	for rows.Next() {
+		attestation, err := attestationFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		if targetCorrect.Valid {
-			val := targetCorrect.Bool
-			attestation.TargetCorrect = &val
+		attestations = append(attestations, attestation)
+	}
+
+	return attestations, nil
+}
+
+// AttestationsForCommittee fetches all attestations made by the given committee at the given slot.
+func (s *Service) AttestationsForCommittee(ctx context.Context, slot phase0.Slot, committeeIndex phase0.CommitteeIndex) ([]*chaindb.Attestation, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
 		}
-		if headCorrect.Valid {
-			val := headCorrect.Bool
-			attestation.HeadCorrect = &val
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_inclusion_index
+            ,f_slot
+            ,f_committee_index
+            ,f_aggregation_bits
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
+            ,f_beacon_block_root
+            ,f_source_epoch
+            ,f_source_root
+            ,f_target_epoch
+            ,f_target_root
+            ,f_canonical
+            ,f_target_correct
+            ,f_head_correct
+      FROM t_attestations
+      WHERE f_slot = $1
+        AND f_committee_index = $2
+      ORDER BY f_inclusion_slot
+	          ,f_inclusion_index`,
+		slot,
+		committeeIndex,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.Attestation, 0)
+
+	for rows.Next() {
+		attestation, err := attestationFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		attestations = append(attestations, attestation)
 	}
@@ -533,3 +503,126 @@ func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot pha
 
 	return slots, nil
 }
+
+// ForEachAttestationInSlotRange calls the supplied function for each attestation made in the given slot range,
+// streaming the attestations from the database rather than holding them all in memory.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// attestations in slots 2 and 3.
+// Iteration stops if the function returns an error, and the error is returned.
+func (s *Service) ForEachAttestationInSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	fn func(*chaindb.Attestation) error,
+) error {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_inclusion_index
+            ,f_slot
+            ,f_committee_index
+            ,f_aggregation_bits
+            ,COALESCE(f_aggregation_indices,attestation_aggregation_indices(f_aggregation_bits,f_slot,f_committee_index))
+            ,f_beacon_block_root
+            ,f_source_epoch
+            ,f_source_root
+            ,f_target_epoch
+            ,f_target_root
+            ,f_canonical
+            ,f_target_correct
+            ,f_head_correct
+      FROM t_attestations
+      WHERE f_inclusion_slot >= $1
+        AND f_inclusion_slot < $2
+      ORDER BY f_inclusion_slot
+	          ,f_inclusion_index`,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attestation, err := attestationFromRow(rows)
+		if err != nil {
+			return errors.Wrap(err, "failed to scan row")
+		}
+		if err := fn(attestation); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// attestationFromRow converts a SQL row in to an attestation.
+func attestationFromRow(rows pgx.Rows) (*chaindb.Attestation, error) {
+	attestation := &chaindb.Attestation{}
+	var inclusionBlockRoot []byte
+	var aggregationIndices []uint64
+	var beaconBlockRoot []byte
+	var sourceRoot []byte
+	var targetRoot []byte
+	var canonical sql.NullBool
+	var targetCorrect sql.NullBool
+	var headCorrect sql.NullBool
+	err := rows.Scan(
+		&attestation.InclusionSlot,
+		&inclusionBlockRoot,
+		&attestation.InclusionIndex,
+		&attestation.Slot,
+		&attestation.CommitteeIndex,
+		&attestation.AggregationBits,
+		&aggregationIndices,
+		&beaconBlockRoot,
+		&attestation.SourceEpoch,
+		&sourceRoot,
+		&attestation.TargetEpoch,
+		&targetRoot,
+		&canonical,
+		&targetCorrect,
+		&headCorrect,
+	)
+	if err != nil {
+		return nil, err
+	}
+	copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+	if aggregationIndices == nil {
+		// Neither stored nor obtainable from the beacon committee, so any indices returned would be wrong.
+		return nil, errors.Errorf("no aggregation indices for attestation at slot %d committee %d; beacon committee missing", attestation.Slot, attestation.CommitteeIndex)
+	}
+	attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
+	for i := range aggregationIndices {
+		attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+	}
+	copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
+	copy(attestation.SourceRoot[:], sourceRoot)
+	copy(attestation.TargetRoot[:], targetRoot)
+	if canonical.Valid {
+		val := canonical.Bool
+		attestation.Canonical = &val
+	}
+	if targetCorrect.Valid {
+		val := targetCorrect.Bool
+		attestation.TargetCorrect = &val
+	}
+	if headCorrect.Valid {
+		val := headCorrect.Bool
+		attestation.HeadCorrect = &val
+	}
+
+	return attestation, nil
+}
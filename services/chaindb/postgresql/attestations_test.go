@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestAttestationsForValidator(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Fetch a block so we can set the attestation's inclusion block root.
+	blocks, err := s.BlocksBySlot(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	slot := phase0.Slot(0xfffff0)
+	require.NoError(t, s.SetBeaconCommittee(ctx, &chaindb.BeaconCommittee{
+		Slot:      slot,
+		Index:     0,
+		Committee: []phase0.ValidatorIndex{0xfffff5, 0xfffff7, 0xfffff9},
+	}))
+	// Aggregation bits show the first and third committee members attesting.
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{
+		InclusionSlot:      0,
+		InclusionBlockRoot: blocks[0].Root,
+		InclusionIndex:     0xfffff0,
+		Slot:               slot,
+		CommitteeIndex:     0,
+		AggregationBits:    []byte{0x0d},
+	}))
+
+	tests := []struct {
+		name      string
+		index     phase0.ValidatorIndex
+		startSlot phase0.Slot
+		endSlot   phase0.Slot
+		expected  int
+	}{
+		{
+			name:      "Included",
+			index:     0xfffff9,
+			startSlot: slot,
+			endSlot:   slot + 1,
+			expected:  1,
+		},
+		{
+			name:      "NotIncluded",
+			index:     0xfffff7,
+			startSlot: slot,
+			endSlot:   slot + 1,
+			expected:  0,
+		},
+		{
+			name:      "NotInCommittee",
+			index:     0xfffff6,
+			startSlot: slot,
+			endSlot:   slot + 1,
+			expected:  0,
+		},
+		{
+			name:      "OutOfRange",
+			index:     0xfffff9,
+			startSlot: slot + 1,
+			endSlot:   slot + 2,
+			expected:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attestations, err := s.AttestationsForValidator(ctx, test.index, test.startSlot, test.endSlot)
+			require.NoError(t, err)
+			require.Len(t, attestations, test.expected)
+			for _, attestation := range attestations {
+				require.Contains(t, attestation.AggregationIndices, test.index)
+			}
+		})
+	}
+}
+
+func TestAttestationsForCommittee(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Fetch a block so we can set the attestations' inclusion block root.
+	blocks, err := s.BlocksBySlot(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	slot := phase0.Slot(0xfffff0)
+	for i, committeeIndex := range []phase0.CommitteeIndex{0, 1, 1} {
+		require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{
+			InclusionSlot:      0,
+			InclusionBlockRoot: blocks[0].Root,
+			InclusionIndex:     0xfffff0 + uint64(i),
+			Slot:               slot,
+			CommitteeIndex:     committeeIndex,
+			AggregationBits:    []byte{0x0d},
+		}))
+	}
+
+	attestations, err := s.AttestationsForCommittee(ctx, slot, 0)
+	require.NoError(t, err)
+	require.Len(t, attestations, 1)
+
+	attestations, err = s.AttestationsForCommittee(ctx, slot, 1)
+	require.NoError(t, err)
+	require.Len(t, attestations, 2)
+	for _, attestation := range attestations {
+		require.Equal(t, phase0.CommitteeIndex(1), attestation.CommitteeIndex)
+	}
+
+	attestations, err = s.AttestationsForCommittee(ctx, slot, 2)
+	require.NoError(t, err)
+	require.Len(t, attestations, 0)
+}
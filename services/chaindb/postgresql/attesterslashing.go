@@ -95,6 +95,7 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		attesterSlashing.Attestation2TargetRoot[:],
 		attesterSlashing.Attestation2Signature[:],
 	)
+	s.monitorWrite("t_attester_slashings", 1, err)
 
 	return err
 }
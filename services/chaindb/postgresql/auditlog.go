@@ -0,0 +1,58 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetAuditEntries records mutations in the audit log.
+func (s *Service) SetAuditEntries(ctx context.Context, entries []*chaindb.AuditEntry) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_audit_log"},
+		[]string{
+			"f_timestamp",
+			"f_table",
+			"f_operation",
+			"f_keys",
+			"f_module",
+			"f_slot",
+		},
+		pgx.CopyFromSlice(len(entries), func(i int) ([]interface{}, error) {
+			var slot *uint64
+			if entries[i].Slot != nil {
+				tmp := uint64(*entries[i].Slot)
+				slot = &tmp
+			}
+			return []interface{}{
+				entries[i].Timestamp,
+				entries[i].Table,
+				entries[i].Operation,
+				entries[i].Keys,
+				entries[i].Module,
+				slot,
+			}, nil
+		}))
+	s.monitorWrite("t_audit_log", len(entries), err)
+
+	return err
+}
@@ -0,0 +1,43 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// BeginBackfill prepares the database for the bulk writes of initial sync, deferring or
+// dropping foreign keys until the backfill ends if so configured.
+func (s *Service) BeginBackfill(ctx context.Context) error {
+	// Restore anything left over from a previous run before relaxing foreign keys again.
+	if err := s.restoreForeignKeys(ctx); err != nil {
+		return errors.Wrap(err, "failed to restore foreign keys")
+	}
+	if err := s.relaxForeignKeys(ctx); err != nil {
+		return errors.Wrap(err, "failed to relax foreign keys")
+	}
+
+	return nil
+}
+
+// EndBackfill restores any foreign keys that were altered for the backfill.
+func (s *Service) EndBackfill(ctx context.Context) error {
+	if err := s.restoreForeignKeys(ctx); err != nil {
+		return errors.Wrap(err, "failed to restore foreign keys")
+	}
+
+	return nil
+}
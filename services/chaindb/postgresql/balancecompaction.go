@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// balanceCompactionKey is the metadata key for the validator balances yet to be packed.
+// This is stored so that packing carries on where it left off if chaind stops before it ends.
+const balanceCompactionKey = "chaindb.validator_balances.compaction"
+
+// balanceCompaction is the range of epochs of validator balances yet to be packed.
+type balanceCompaction struct {
+	NextEpoch phase0.Epoch `json:"next_epoch"`
+	EndEpoch  phase0.Epoch `json:"end_epoch"`
+}
+
+// compactValidatorBalances packs the validator balances written before they were
+// stored as runs, if any.  Each interval is packed in its own transaction along with
+// the progress, so that writes are not held up whilst packing takes place.
+func (s *Service) compactValidatorBalances(ctx context.Context) {
+	data, err := s.Metadata(ctx, balanceCompactionKey)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain validator balances compaction metadata")
+		return
+	}
+	if data == nil {
+		// Nothing to pack.
+		return
+	}
+	compaction := &balanceCompaction{}
+	if err := json.Unmarshal(data, compaction); err != nil {
+		s.log.Error().Err(err).Msg("Failed to unmarshal validator balances compaction metadata")
+		return
+	}
+
+	s.log.Info().Uint64("next_epoch", uint64(compaction.NextEpoch)).Uint64("end_epoch", uint64(compaction.EndEpoch)).Msg("Packing validator balances")
+	for compaction.NextEpoch < compaction.EndEpoch {
+		if err := s.compactValidatorBalancesInterval(ctx, compaction); err != nil {
+			s.log.Error().Uint64("next_epoch", uint64(compaction.NextEpoch)).Err(err).Msg("Failed to pack validator balances; will continue on restart")
+			return
+		}
+	}
+	s.log.Info().Msg("Packed validator balances")
+}
+
+// compactValidatorBalancesInterval packs the next interval of validator balances, and
+// records the progress in the same transaction.
+func (s *Service) compactValidatorBalancesInterval(ctx context.Context, compaction *balanceCompaction) error {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	tx := s.tx(ctx)
+
+	if err := s.packBalanceInterval(ctx, tx, compaction.NextEpoch); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to pack interval")
+	}
+
+	compaction.NextEpoch += balanceRunInterval
+	if compaction.NextEpoch >= compaction.EndEpoch {
+		if _, err := tx.Exec(ctx, "DELETE FROM t_metadata WHERE f_key = $1", balanceCompactionKey); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to remove validator balances compaction metadata")
+		}
+	} else {
+		data, err := json.Marshal(compaction)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to marshal validator balances compaction")
+		}
+		if err := s.SetMetadata(ctx, balanceCompactionKey, data); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator balances compaction metadata")
+		}
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
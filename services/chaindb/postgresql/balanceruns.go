@@ -0,0 +1,700 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Validator balances are stored as runs.  Each row holds a validator's balance and
+// effective balance at its epoch, and the change in balance from that value at each
+// of the following consecutive epochs as a 32-bit integer.  Runs do not cross
+// intervals of balanceRunInterval epochs, and a run also ends before an epoch for
+// which the validator's balance is not stored, at which its effective balance
+// changes, or at which the change in its balance does not fit in 32 bits.  Usually
+// this leaves a single row for each validator for each interval.
+//
+// Balances for the latest interval, known as the open interval, are written as runs
+// of a single epoch.  When balances are first written for a later interval the open
+// interval is packed, replacing its rows with the fewest runs that hold them.
+const balanceRunInterval = phase0.Epoch(32)
+
+// balancesMetadataKey is the metadata key for the state of validator balances.
+const balancesMetadataKey = "chaindb.validator_balances"
+
+// balancesMetadata is the state of validator balances.
+type balancesMetadata struct {
+	// OpenEpoch is the first epoch of the open interval.  Intervals before this
+	// have been packed.
+	OpenEpoch phase0.Epoch `json:"open_epoch"`
+}
+
+// balanceRunColumns are the columns of the validator balances table, in the order
+// provided by balanceRun.values().
+var balanceRunColumns = []string{
+	"f_validator_index",
+	"f_epoch",
+	"f_balance",
+	"f_effective_balance",
+	"f_balance_changes",
+}
+
+// balanceRunStart returns the first epoch of the interval that contains the given epoch.
+func balanceRunStart(epoch phase0.Epoch) phase0.Epoch {
+	return epoch - epoch%balanceRunInterval
+}
+
+// balanceRun is a run of a validator's balances, as held in a row of the validator balances table.
+type balanceRun struct {
+	index            phase0.ValidatorIndex
+	epoch            phase0.Epoch
+	balance          phase0.Gwei
+	effectiveBalance phase0.Gwei
+	// changes are the changes in balance from balance at each of the following epochs.
+	changes []int32
+}
+
+// newBalanceRun creates a run that starts with the given balance.
+func newBalanceRun(balance *chaindb.ValidatorBalance) *balanceRun {
+	return &balanceRun{
+		index:            balance.Index,
+		epoch:            balance.Epoch,
+		balance:          balance.Balance,
+		effectiveBalance: balance.EffectiveBalance,
+		changes:          []int32{},
+	}
+}
+
+// endEpoch returns the last epoch of the run.
+func (r *balanceRun) endEpoch() phase0.Epoch {
+	return r.epoch + phase0.Epoch(len(r.changes))
+}
+
+// covers returns true if the run holds the balance at the given epoch.
+func (r *balanceRun) covers(epoch phase0.Epoch) bool {
+	return epoch >= r.epoch && epoch <= r.endEpoch()
+}
+
+// balanceAt returns the balance at the given epoch, which must be covered by the run.
+func (r *balanceRun) balanceAt(epoch phase0.Epoch) *chaindb.ValidatorBalance {
+	balance := r.balance
+	if epoch > r.epoch {
+		balance = phase0.Gwei(int64(balance) + int64(r.changes[epoch-r.epoch-1]))
+	}
+
+	return &chaindb.ValidatorBalance{
+		Index:            r.index,
+		Epoch:            epoch,
+		Balance:          balance,
+		EffectiveBalance: r.effectiveBalance,
+	}
+}
+
+// extend adds the given balance, which must be for the epoch after the end of the run,
+// to the run.  It returns false if the balance cannot be held by the run.
+func (r *balanceRun) extend(balance *chaindb.ValidatorBalance) bool {
+	if balanceRunStart(balance.Epoch) != balanceRunStart(r.epoch) ||
+		balance.EffectiveBalance != r.effectiveBalance {
+		return false
+	}
+	change := int64(balance.Balance) - int64(r.balance)
+	if change < math.MinInt32 || change > math.MaxInt32 {
+		return false
+	}
+	r.changes = append(r.changes, int32(change))
+
+	return true
+}
+
+// values returns the values of the run for writing to the database.
+func (r *balanceRun) values() []interface{} {
+	changes := r.changes
+	if changes == nil {
+		// The column is not nullable.
+		changes = []int32{}
+	}
+
+	return []interface{}{r.index, r.epoch, r.balance, r.effectiveBalance, changes}
+}
+
+// equal returns true if the runs are the same.
+func (r *balanceRun) equal(other *balanceRun) bool {
+	if r.index != other.index ||
+		r.epoch != other.epoch ||
+		r.balance != other.balance ||
+		r.effectiveBalance != other.effectiveBalance ||
+		len(r.changes) != len(other.changes) {
+		return false
+	}
+	for i := range r.changes {
+		if r.changes[i] != other.changes[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeBalanceRuns encodes the balances of a single validator, in increasing epoch order, as runs.
+func encodeBalanceRuns(balances []*chaindb.ValidatorBalance) []*balanceRun {
+	runs := make([]*balanceRun, 0, 1)
+	var run *balanceRun
+	for _, balance := range balances {
+		if run != nil && balance.Epoch == run.endEpoch()+1 && run.extend(balance) {
+			continue
+		}
+		run = newBalanceRun(balance)
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// decodeBalanceRuns decodes the runs of a single validator, in increasing epoch order, in to balances.
+func decodeBalanceRuns(runs []*balanceRun) []*chaindb.ValidatorBalance {
+	balances := make([]*chaindb.ValidatorBalance, 0, balanceRunInterval)
+	for _, run := range runs {
+		for epoch := run.epoch; epoch <= run.endEpoch(); epoch++ {
+			balances = append(balances, run.balanceAt(epoch))
+		}
+	}
+
+	return balances
+}
+
+// rewriteBalanceRuns returns the runs of a single validator with the given balance
+// added, replacing any existing balance at its epoch.
+func rewriteBalanceRuns(runs []*balanceRun, balance *chaindb.ValidatorBalance) []*balanceRun {
+	existing := decodeBalanceRuns(runs)
+	balances := make([]*chaindb.ValidatorBalance, 0, len(existing)+1)
+	added := false
+	for _, existingBalance := range existing {
+		if !added && existingBalance.Epoch >= balance.Epoch {
+			balances = append(balances, balance)
+			added = true
+		}
+		if existingBalance.Epoch != balance.Epoch {
+			balances = append(balances, existingBalance)
+		}
+	}
+	if !added {
+		balances = append(balances, balance)
+	}
+
+	return encodeBalanceRuns(balances)
+}
+
+// equalBalanceRuns returns true if the two sets of runs are the same.
+func equalBalanceRuns(a []*balanceRun, b []*balanceRun) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].equal(b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setBalances sets validator balances.  Balances for epochs in packed intervals are
+// merged in to the existing runs, and those for the open interval are written as runs
+// of a single epoch by the supplied function.
+func (s *Service) setBalances(ctx context.Context,
+	tx pgx.Tx,
+	balances []*chaindb.ValidatorBalance,
+	write func(context.Context, pgx.Tx, []*chaindb.ValidatorBalance) error,
+) error {
+	included := balances
+	if s.filter != nil {
+		included = make([]*chaindb.ValidatorBalance, 0, len(balances))
+		for _, balance := range balances {
+			if s.filter.IncludeValidatorBalance(ctx, balance) {
+				included = append(included, balance)
+			}
+		}
+	}
+	if len(included) == 0 {
+		return nil
+	}
+
+	md, err := s.balancesMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Work in epoch order, so that the open interval only moves forward.
+	sorted := make([]*chaindb.ValidatorBalance, len(included))
+	copy(sorted, included)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Epoch < sorted[j].Epoch
+	})
+
+	open := make([]*chaindb.ValidatorBalance, 0, len(sorted))
+	for start := 0; start < len(sorted); {
+		epoch := sorted[start].Epoch
+		end := start
+		for end < len(sorted) && sorted[end].Epoch == epoch {
+			end++
+		}
+
+		switch {
+		case epoch < md.OpenEpoch:
+			if err := s.rewriteBalances(ctx, tx, epoch, sorted[start:end]); err != nil {
+				return err
+			}
+		case balanceRunStart(epoch) > md.OpenEpoch:
+			// Moving on to a later interval, so the open interval is complete.
+			if err := write(ctx, tx, open); err != nil {
+				return err
+			}
+			open = open[:0]
+			if err := s.packBalanceInterval(ctx, tx, md.OpenEpoch); err != nil {
+				return err
+			}
+			md.OpenEpoch = balanceRunStart(epoch)
+			if err := s.setBalancesMetadata(ctx, md); err != nil {
+				return err
+			}
+			open = append(open, sorted[start:end]...)
+		default:
+			open = append(open, sorted[start:end]...)
+		}
+		start = end
+	}
+
+	return write(ctx, tx, open)
+}
+
+// copyBalances writes balances as runs of a single epoch.
+func (s *Service) copyBalances(ctx context.Context, tx pgx.Tx, balances []*chaindb.ValidatorBalance) error {
+	runs := make([]*balanceRun, len(balances))
+	for i, balance := range balances {
+		runs[i] = newBalanceRun(balance)
+	}
+
+	return s.copyBalanceRuns(ctx, tx, runs)
+}
+
+// copyBalanceRuns writes runs to the validator balances table.
+func (s *Service) copyBalanceRuns(ctx context.Context, tx pgx.Tx, runs []*balanceRun) error {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"t_validator_balances"},
+		balanceRunColumns,
+		pgx.CopyFromSlice(len(runs), func(i int) ([]interface{}, error) {
+			return runs[i].values(), nil
+		}))
+	if err != nil {
+		s.monitorWriteFailure("t_validator_balances")
+		return err
+	}
+	s.monitorRowsWritten("t_validator_balances", len(runs))
+
+	return nil
+}
+
+// rewriteBalances sets the balances for an epoch in a packed interval.  The runs of
+// each validator for the interval are read and encoded again with the new balance, so
+// that the balances at the other epochs of the interval are unchanged.
+func (s *Service) rewriteBalances(ctx context.Context,
+	tx pgx.Tx,
+	epoch phase0.Epoch,
+	balances []*chaindb.ValidatorBalance,
+) error {
+	startEpoch := balanceRunStart(epoch)
+	indices := make([]uint64, len(balances))
+	for i, balance := range balances {
+		indices[i] = uint64(balance.Index)
+	}
+
+	existing := make(map[phase0.ValidatorIndex][]*balanceRun, len(balances))
+	rows, err := tx.Query(ctx, `
+      SELECT f_validator_index
+            ,f_epoch
+            ,f_balance
+            ,f_effective_balance
+            ,f_balance_changes
+      FROM t_validator_balances
+      WHERE f_validator_index = ANY($1)
+        AND f_epoch >= $2
+        AND f_epoch < $3
+      ORDER BY f_validator_index
+              ,f_epoch`,
+		indices,
+		startEpoch,
+		startEpoch+balanceRunInterval,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain balance runs")
+	}
+	for rows.Next() {
+		run, err := balanceRunFromRow(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		existing[run.index] = append(existing[run.index], run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	changed := make([]uint64, 0, len(balances))
+	runs := make([]*balanceRun, 0, len(balances))
+	for _, balance := range balances {
+		rewritten := rewriteBalanceRuns(existing[balance.Index], balance)
+		if equalBalanceRuns(rewritten, existing[balance.Index]) {
+			continue
+		}
+		changed = append(changed, uint64(balance.Index))
+		runs = append(runs, rewritten...)
+		existing[balance.Index] = rewritten
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+      DELETE FROM t_validator_balances
+      WHERE f_validator_index = ANY($1)
+        AND f_epoch >= $2
+        AND f_epoch < $3`,
+		changed,
+		startEpoch,
+		startEpoch+balanceRunInterval,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_balances")
+		return errors.Wrap(err, "failed to remove balance runs")
+	}
+
+	return s.copyBalanceRuns(ctx, tx, runs)
+}
+
+// packBalanceInterval replaces the rows of the interval that starts at the given epoch
+// with the fewest runs that hold the same balances.
+func (s *Service) packBalanceInterval(ctx context.Context, tx pgx.Tx, startEpoch phase0.Epoch) error {
+	rows, err := tx.Query(ctx, `
+      SELECT f_validator_index
+            ,f_epoch
+            ,f_balance
+            ,f_effective_balance
+            ,f_balance_changes
+      FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch < $2
+      ORDER BY f_validator_index
+              ,f_epoch`,
+		startEpoch,
+		startEpoch+balanceRunInterval,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain balances to pack")
+	}
+
+	// Rows are read one validator at a time, so only the packed runs are held.
+	rowsRead := 0
+	runs := make([]*balanceRun, 0)
+	validatorRuns := make([]*balanceRun, 0, balanceRunInterval)
+	for rows.Next() {
+		run, err := balanceRunFromRow(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		rowsRead++
+		if len(validatorRuns) > 0 && validatorRuns[0].index != run.index {
+			runs = append(runs, encodeBalanceRuns(decodeBalanceRuns(validatorRuns))...)
+			validatorRuns = validatorRuns[:0]
+		}
+		validatorRuns = append(validatorRuns, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(validatorRuns) > 0 {
+		runs = append(runs, encodeBalanceRuns(decodeBalanceRuns(validatorRuns))...)
+	}
+
+	if len(runs) == rowsRead {
+		// Packing only ever merges rows, so the rows are already packed.
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+      DELETE FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch < $2`,
+		startEpoch,
+		startEpoch+balanceRunInterval,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_balances")
+		return errors.Wrap(err, "failed to remove packed balances")
+	}
+
+	return s.copyBalanceRuns(ctx, tx, runs)
+}
+
+// balancesMetadata obtains the state of validator balances.
+func (s *Service) balancesMetadata(ctx context.Context) (*balancesMetadata, error) {
+	md := &balancesMetadata{}
+	data, err := s.Metadata(ctx, balancesMetadataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain balances metadata")
+	}
+	if data == nil {
+		return md, nil
+	}
+	if err := json.Unmarshal(data, md); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal balances metadata")
+	}
+
+	return md, nil
+}
+
+// setBalancesMetadata sets the state of validator balances.
+func (s *Service) setBalancesMetadata(ctx context.Context, md *balancesMetadata) error {
+	data, err := json.Marshal(md)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal balances metadata")
+	}
+	if err := s.SetMetadata(ctx, balancesMetadataKey, data); err != nil {
+		return errors.Wrap(err, "failed to set balances metadata")
+	}
+
+	return nil
+}
+
+// latestBalanceEpoch returns the latest epoch for which balances are stored, and false
+// if there are none.
+func (s *Service) latestBalanceEpoch(ctx context.Context, tx pgx.Tx) (phase0.Epoch, bool, error) {
+	var maxEpoch sql.NullInt64
+	if err := tx.QueryRow(ctx, "SELECT MAX(f_epoch) FROM t_validator_balances").Scan(&maxEpoch); err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain latest balance epoch")
+	}
+	if !maxEpoch.Valid {
+		return 0, false, nil
+	}
+
+	md, err := s.balancesMetadata(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if phase0.Epoch(maxEpoch.Int64) >= md.OpenEpoch {
+		// Rows in the open interval are runs of a single epoch.
+		return phase0.Epoch(maxEpoch.Int64), true, nil
+	}
+
+	// The latest balances are in a packed interval, so may be held by a run that
+	// started before the latest run.
+	startEpoch := balanceRunStart(phase0.Epoch(maxEpoch.Int64))
+	var latestEpoch uint64
+	if err := tx.QueryRow(ctx, `
+      SELECT MAX(f_epoch + CARDINALITY(f_balance_changes))
+      FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch < $2`,
+		startEpoch,
+		startEpoch+balanceRunInterval,
+	).Scan(&latestEpoch); err != nil {
+		return 0, false, errors.Wrap(err, "failed to obtain latest balance epoch")
+	}
+
+	return phase0.Epoch(latestEpoch), true, nil
+}
+
+// forEachReconstructedBalance reconstructs the balances of the given validators,
+// or all validators if validatorIndices is nil, at the given epochs, which must be
+// in increasing order.  The supplied function is called for each balance in epoch
+// and then validator index order.
+func (*Service) forEachReconstructedBalance(ctx context.Context,
+	tx pgx.Tx,
+	validatorIndices []phase0.ValidatorIndex,
+	epochs []phase0.Epoch,
+	fn func(*chaindb.ValidatorBalance) error,
+) error {
+	if len(epochs) == 0 {
+		return nil
+	}
+
+	query := `
+      SELECT f_validator_index
+            ,f_epoch
+            ,f_balance
+            ,f_effective_balance
+            ,f_balance_changes
+      FROM t_validator_balances
+      WHERE f_epoch = ANY($1)
+      ORDER BY f_epoch
+              ,f_validator_index`
+	if validatorIndices != nil {
+		query = fmt.Sprintf(`
+      SELECT f_validator_index
+            ,f_epoch
+            ,f_balance
+            ,f_effective_balance
+            ,f_balance_changes
+      FROM t_validator_balances
+      JOIN (VALUES %s)
+        AS x(id)
+        ON x.id = t_validator_balances.f_validator_index
+      WHERE f_epoch = ANY($1)
+      ORDER BY f_epoch
+              ,f_validator_index`, fastIndices(validatorIndices))
+	}
+	rows, err := tx.Query(ctx, query, balanceWindow(epochs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	reconstructor := newBalanceReconstructor(epochs, fn)
+	for rows.Next() {
+		run, err := balanceRunFromRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := reconstructor.apply(run); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return reconstructor.finish()
+}
+
+// balanceReconstructor reconstructs balances from runs supplied in epoch and
+// validator index order.
+type balanceReconstructor struct {
+	epochs []phase0.Epoch
+	next   int
+	// runs are the latest run of each validator.
+	runs    map[phase0.ValidatorIndex]*balanceRun
+	indices []phase0.ValidatorIndex
+	sorted  bool
+	fn      func(*chaindb.ValidatorBalance) error
+}
+
+// newBalanceReconstructor creates a reconstructor that provides balances at the given epochs.
+func newBalanceReconstructor(epochs []phase0.Epoch, fn func(*chaindb.ValidatorBalance) error) *balanceReconstructor {
+	return &balanceReconstructor{
+		epochs:  epochs,
+		runs:    make(map[phase0.ValidatorIndex]*balanceRun),
+		indices: make([]phase0.ValidatorIndex, 0),
+		sorted:  true,
+		fn:      fn,
+	}
+}
+
+// apply applies a run to the reconstruction.
+func (r *balanceReconstructor) apply(run *balanceRun) error {
+	// Provide the balances for epochs before this run, as all runs that could
+	// cover them have been applied.
+	if err := r.provide(run.epoch, false); err != nil {
+		return err
+	}
+
+	if _, exists := r.runs[run.index]; !exists {
+		r.indices = append(r.indices, run.index)
+		r.sorted = false
+	}
+	r.runs[run.index] = run
+
+	return nil
+}
+
+// finish provides the balances for the remaining epochs.
+func (r *balanceReconstructor) finish() error {
+	return r.provide(0, true)
+}
+
+// provide provides the balances for epochs before the given epoch, or all remaining epochs if all is true.
+func (r *balanceReconstructor) provide(epoch phase0.Epoch, all bool) error {
+	for ; r.next < len(r.epochs) && (all || r.epochs[r.next] < epoch); r.next++ {
+		if !r.sorted {
+			sort.Slice(r.indices, func(i, j int) bool {
+				return r.indices[i] < r.indices[j]
+			})
+			r.sorted = true
+		}
+		target := r.epochs[r.next]
+		for _, index := range r.indices {
+			run := r.runs[index]
+			if !run.covers(target) {
+				continue
+			}
+			if err := r.fn(run.balanceAt(target)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// balanceWindow returns the epochs at which the runs that hold the balances at the
+// given epochs, which must be in increasing order, can start.
+func balanceWindow(epochs []phase0.Epoch) []uint64 {
+	window := make([]uint64, 0, len(epochs))
+	next := phase0.Epoch(0)
+	for _, epoch := range epochs {
+		start := balanceRunStart(epoch)
+		if start < next {
+			start = next
+		}
+		for windowEpoch := start; windowEpoch <= epoch; windowEpoch++ {
+			window = append(window, uint64(windowEpoch))
+		}
+		if epoch+1 > next {
+			next = epoch + 1
+		}
+	}
+
+	return window
+}
+
+// balanceRunFromRow converts a SQL row in to a balance run.
+func balanceRunFromRow(rows pgx.Rows) (*balanceRun, error) {
+	run := &balanceRun{}
+	err := rows.Scan(
+		&run.index,
+		&run.epoch,
+		&run.balance,
+		&run.effectiveBalance,
+		&run.changes,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to scan row")
+	}
+
+	return run, nil
+}
@@ -0,0 +1,353 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestBalanceWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		epochs []phase0.Epoch
+		window []uint64
+	}{
+		{
+			name:   "Empty",
+			window: []uint64{},
+		},
+		{
+			name:   "IntervalStart",
+			epochs: []phase0.Epoch{64},
+			window: []uint64{64},
+		},
+		{
+			name:   "Single",
+			epochs: []phase0.Epoch{67},
+			window: []uint64{64, 65, 66, 67},
+		},
+		{
+			name:   "Overlapping",
+			epochs: []phase0.Epoch{65, 67, 97},
+			window: []uint64{64, 65, 66, 67, 96, 97},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.window, balanceWindow(test.epochs))
+		})
+	}
+}
+
+// balances creates balances for a validator from the given epoch.
+func balances(index phase0.ValidatorIndex, epoch phase0.Epoch, effectiveBalance phase0.Gwei, values ...phase0.Gwei) []*chaindb.ValidatorBalance {
+	res := make([]*chaindb.ValidatorBalance, len(values))
+	for i, value := range values {
+		res[i] = &chaindb.ValidatorBalance{
+			Index:            index,
+			Epoch:            epoch + phase0.Epoch(i),
+			Balance:          value,
+			EffectiveBalance: effectiveBalance,
+		}
+	}
+
+	return res
+}
+
+func TestEncodeBalanceRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances []*chaindb.ValidatorBalance
+		runs     []*balanceRun
+	}{
+		{
+			name:     "Single",
+			balances: balances(1, 40, 32000000000, 32000001000),
+			runs: []*balanceRun{
+				{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{}},
+			},
+		},
+		{
+			name:     "Run",
+			balances: balances(1, 40, 32000000000, 32000001000, 32000002000, 31999999000),
+			runs: []*balanceRun{
+				{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{1000, -2000}},
+			},
+		},
+		{
+			name:     "IntervalEnd",
+			balances: balances(1, 62, 32000000000, 32000001000, 32000002000, 32000003000),
+			runs: []*balanceRun{
+				{index: 1, epoch: 62, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{1000}},
+				{index: 1, epoch: 64, balance: 32000003000, effectiveBalance: 32000000000, changes: []int32{}},
+			},
+		},
+		{
+			name: "Gap",
+			balances: append(balances(1, 40, 32000000000, 32000001000, 32000002000),
+				balances(1, 43, 32000000000, 32000004000)...),
+			runs: []*balanceRun{
+				{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{1000}},
+				{index: 1, epoch: 43, balance: 32000004000, effectiveBalance: 32000000000, changes: []int32{}},
+			},
+		},
+		{
+			name: "EffectiveBalanceChange",
+			balances: append(balances(1, 40, 32000000000, 32000001000, 32000002000),
+				balances(1, 42, 31000000000, 31000000000, 31000001000)...),
+			runs: []*balanceRun{
+				{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{1000}},
+				{index: 1, epoch: 42, balance: 31000000000, effectiveBalance: 31000000000, changes: []int32{1000}},
+			},
+		},
+		{
+			name:     "ChangeOverflow",
+			balances: balances(1, 40, 32000000000, 32000001000, 34200000000, 34200001000),
+			runs: []*balanceRun{
+				{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{}},
+				{index: 1, epoch: 41, balance: 34200000000, effectiveBalance: 32000000000, changes: []int32{1000}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runs := encodeBalanceRuns(test.balances)
+			require.Equal(t, test.runs, runs)
+			require.Equal(t, test.balances, decodeBalanceRuns(runs))
+		})
+	}
+}
+
+func TestRewriteBalanceRuns(t *testing.T) {
+	runs := encodeBalanceRuns(balances(1, 40, 32000000000, 32000001000, 32000002000, 32000003000))
+
+	// Rewriting an epoch leaves the balances of the following epochs alone.
+	rewritten := rewriteBalanceRuns(runs, &chaindb.ValidatorBalance{Index: 1, Epoch: 41, Balance: 32000005000, EffectiveBalance: 32000000000})
+	require.Equal(t, balances(1, 40, 32000000000, 32000001000, 32000005000, 32000003000), decodeBalanceRuns(rewritten))
+	require.False(t, equalBalanceRuns(runs, rewritten))
+
+	// Rewriting an epoch with the same balance leaves the runs alone.
+	rewritten = rewriteBalanceRuns(runs, &chaindb.ValidatorBalance{Index: 1, Epoch: 42, Balance: 32000003000, EffectiveBalance: 32000000000})
+	require.True(t, equalBalanceRuns(runs, rewritten))
+
+	// Writing an epoch that fills a gap joins the runs either side of it.
+	runs = encodeBalanceRuns(append(balances(1, 40, 32000000000, 32000001000),
+		balances(1, 42, 32000000000, 32000003000)...))
+	require.Len(t, runs, 2)
+	rewritten = rewriteBalanceRuns(runs, &chaindb.ValidatorBalance{Index: 1, Epoch: 41, Balance: 32000002000, EffectiveBalance: 32000000000})
+	require.Equal(t, []*balanceRun{
+		{index: 1, epoch: 40, balance: 32000001000, effectiveBalance: 32000000000, changes: []int32{1000, 2000}},
+	}, rewritten)
+
+	// Writing the first balance for a validator creates a run.
+	rewritten = rewriteBalanceRuns(nil, &chaindb.ValidatorBalance{Index: 2, Epoch: 41, Balance: 32000000000, EffectiveBalance: 32000000000})
+	require.Equal(t, []*balanceRun{
+		{index: 2, epoch: 41, balance: 32000000000, effectiveBalance: 32000000000, changes: []int32{}},
+	}, rewritten)
+}
+
+func TestBalanceReconstructor(t *testing.T) {
+	runs := []*balanceRun{
+		{index: 1, epoch: 32, balance: 32000000000, effectiveBalance: 32000000000, changes: []int32{1000, 2000, 3000}},
+		{index: 2, epoch: 32, balance: 31000000000, effectiveBalance: 31000000000, changes: []int32{}},
+		{index: 2, epoch: 34, balance: 31000002000, effectiveBalance: 31000000000, changes: []int32{1000}},
+		{index: 1, epoch: 64, balance: 32000004000, effectiveBalance: 32000000000, changes: []int32{}},
+	}
+
+	provided := make([]*chaindb.ValidatorBalance, 0)
+	reconstructor := newBalanceReconstructor([]phase0.Epoch{33, 35, 64}, func(balance *chaindb.ValidatorBalance) error {
+		provided = append(provided, balance)
+		return nil
+	})
+	for _, run := range runs {
+		require.NoError(t, reconstructor.apply(run))
+	}
+	require.NoError(t, reconstructor.finish())
+
+	// Validator 2 has no balance at epoch 33, and neither has a balance at epoch 64.
+	require.Equal(t, []*chaindb.ValidatorBalance{
+		{Index: 1, Epoch: 33, Balance: 32000001000, EffectiveBalance: 32000000000},
+		{Index: 1, Epoch: 35, Balance: 32000003000, EffectiveBalance: 32000000000},
+		{Index: 2, Epoch: 35, Balance: 31000003000, EffectiveBalance: 31000000000},
+		{Index: 1, Epoch: 64, Balance: 32000004000, EffectiveBalance: 32000000000},
+	}, provided)
+}
+
+// PostgreSQL storage layout, for the default 8KiB pages.
+const (
+	pgPageSize         = 8192
+	pgPageHeader       = 24
+	pgLinePointer      = 4
+	pgHeapTupleHeader  = 24
+	pgIndexTupleHeader = 8
+	pgBTreeSpecial     = 16
+	// pgBTreeLeafFill is the percentage of each leaf page used by an index.
+	pgBTreeLeafFill = 90
+	// pgPostingTID is the size of each entry after the first with the same key in a
+	// deduplicated index.
+	pgPostingTID = 6
+)
+
+// pgAlign aligns a length to 8 bytes.
+func pgAlign(length int) int {
+	return (length + 7) &^ 7
+}
+
+// pgHeapBytes returns the size of the heap that holds tuples of the given lengths.
+func pgHeapBytes(tupleLengths []int) int {
+	pages := 1
+	used := pgPageHeader
+	for _, length := range tupleLengths {
+		size := pgAlign(length) + pgLinePointer
+		if used+size > pgPageSize {
+			pages++
+			used = pgPageHeader
+		}
+		used += size
+	}
+
+	return pages * pgPageSize
+}
+
+// pgIndexBytes returns the size of the leaf pages of a b-tree index with the given
+// number of entries of each key length.  Entries beyond the first with the same key
+// are stored in a posting list if the index is deduplicated.
+func pgIndexBytes(entries int, keyLength int, duplicates int) int {
+	entryLength := pgAlign(pgIndexTupleHeader+keyLength) + pgLinePointer
+	usable := (pgPageSize - pgPageHeader - pgBTreeSpecial) * pgBTreeLeafFill / 100
+	bytes := (entries-duplicates)*entryLength + duplicates*pgPostingTID
+
+	return (bytes/usable + 1) * pgPageSize
+}
+
+// pgIntArrayLength returns the stored length of a one-dimensional INTEGER[] with the given number of elements.
+func pgIntArrayLength(elements int) int {
+	// Header, number of dimensions, data offset and element type.
+	length := 16
+	if elements > 0 {
+		// Dimension and lower bound, and the elements.
+		length += 8 + 4*elements
+	}
+	if length-3 <= 127 {
+		// Short varlena header.
+		length -= 3
+	}
+
+	return length
+}
+
+// simulateBalances simulates the balances of validators over the given epochs.
+func simulateBalances(validators int, epochs int) [][]*chaindb.ValidatorBalance {
+	rng := rand.New(rand.NewSource(1))
+	res := make([][]*chaindb.ValidatorBalance, validators)
+	for i := range res {
+		balance := phase0.Gwei(32000000000 + rng.Int63n(50000000))
+		effectiveBalance := phase0.Gwei(32000000000)
+		offline := rng.Intn(50) == 0
+		exitEpoch := epochs
+		if rng.Intn(100) == 0 {
+			exitEpoch = rng.Intn(epochs)
+		}
+		slashedEpoch := -1
+		if rng.Intn(500) == 0 {
+			slashedEpoch = rng.Intn(epochs)
+		}
+		// Withdrawal sweeps pass each validator every few days.
+		withdrawalOffset := rng.Intn(1024)
+
+		res[i] = make([]*chaindb.ValidatorBalance, 0, exitEpoch)
+		for epoch := 0; epoch < exitEpoch; epoch++ {
+			switch {
+			case epoch == slashedEpoch:
+				balance -= 1000000000
+			case offline || rng.Intn(100) == 0:
+				balance -= phase0.Gwei(10000 + rng.Int63n(5000))
+			default:
+				balance += phase0.Gwei(10000 + rng.Int63n(5000))
+			}
+			if rng.Intn(1000) == 0 {
+				// Proposal reward.
+				balance += phase0.Gwei(30000000 + rng.Int63n(20000000))
+			}
+			if epoch%1024 == withdrawalOffset && balance > 32000000000 {
+				balance = 32000000000
+			}
+			// Effective balance hysteresis.
+			if balance+250000000 < effectiveBalance || balance > effectiveBalance+1250000000 {
+				effectiveBalance = balance - balance%1000000000
+				if effectiveBalance > 32000000000 {
+					effectiveBalance = 32000000000
+				}
+			}
+			res[i] = append(res[i], &chaindb.ValidatorBalance{
+				Index:            phase0.ValidatorIndex(i),
+				Epoch:            phase0.Epoch(epoch),
+				Balance:          balance,
+				EffectiveBalance: effectiveBalance,
+			})
+		}
+	}
+
+	return res
+}
+
+// TestBalanceRunsSize compares the size of the validator balances table and its indices
+// when balances are held as runs to that when each balance is held in its own row.  The
+// sizes are calculated from the PostgreSQL storage layout, with all intervals packed.
+func TestBalanceRunsSize(t *testing.T) {
+	validators := 1000
+	epochs := 1024
+	simulated := simulateBalances(validators, epochs)
+
+	balanceCount := 0
+	runs := make([]*balanceRun, 0)
+	for _, validatorBalances := range simulated {
+		balanceCount += len(validatorBalances)
+		runs = append(runs, encodeBalanceRuns(validatorBalances)...)
+		require.Equal(t, validatorBalances, decodeBalanceRuns(encodeBalanceRuns(validatorBalances)))
+	}
+
+	// Each row is four BIGINTs, indexed by (validator, epoch) and by epoch.
+	rowLengths := make([]int, balanceCount)
+	for i := range rowLengths {
+		rowLengths[i] = pgHeapTupleHeader + 4*8
+	}
+	rowsHeap := pgHeapBytes(rowLengths)
+	rowsIndices := pgIndexBytes(balanceCount, 16, 0) + pgIndexBytes(balanceCount, 8, balanceCount-epochs)
+
+	// Each run is four BIGINTs and an INTEGER[] of changes, with the same indices.
+	runLengths := make([]int, len(runs))
+	runStarts := make(map[phase0.Epoch]bool)
+	for i, run := range runs {
+		runLengths[i] = pgHeapTupleHeader + 4*8 + pgIntArrayLength(len(run.changes))
+		runStarts[run.epoch] = true
+	}
+	runsHeap := pgHeapBytes(runLengths)
+	runsIndices := pgIndexBytes(len(runs), 16, 0) + pgIndexBytes(len(runs), 8, len(runs)-len(runStarts))
+
+	rowsTotal := rowsHeap + rowsIndices
+	runsTotal := runsHeap + runsIndices
+	t.Logf("%d balances of %d validators over %d epochs", balanceCount, validators, epochs)
+	t.Logf("Rows: %d rows, %d heap bytes, %d index bytes, %.1f bytes per balance", balanceCount, rowsHeap, rowsIndices, float64(rowsTotal)/float64(balanceCount))
+	t.Logf("Runs: %d rows, %d heap bytes, %d index bytes, %.1f bytes per balance", len(runs), runsHeap, runsIndices, float64(runsTotal)/float64(balanceCount))
+	t.Logf("Ratio: %.1f", float64(rowsTotal)/float64(runsTotal))
+	require.Greater(t, float64(rowsTotal)/float64(runsTotal), 10.0)
+}
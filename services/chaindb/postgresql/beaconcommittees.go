@@ -31,16 +31,19 @@ func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chain
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_beacon_committees(f_slot
                                      ,f_index
-                                     ,f_committee)
-      VALUES($1,$2,$3)
+                                     ,f_committee
+                                     ,f_epoch)
+      VALUES($1,$2,$3,$1::BIGINT / slots_per_epoch())
       ON CONFLICT (f_slot,f_index) DO
       UPDATE
       SET f_committee = excluded.f_committee
+         ,f_epoch = excluded.f_epoch
 		 `,
 		beaconCommittee.Slot,
 		beaconCommittee.Index,
 		beaconCommittee.Committee,
 	)
+	s.monitorWrite("t_beacon_committees", 1, err)
 
 	return err
 }
@@ -0,0 +1,103 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockArrival records the arrival of a block.  If the block's arrival has
+// already been recorded then the earlier arrival is kept.
+func (s *Service) SetBlockArrival(ctx context.Context, arrival *chaindb.BlockArrival) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_block_arrivals(f_slot
+                                  ,f_block_root
+                                  ,f_slot_time
+                                  ,f_arrival_time)
+      VALUES($1,$2,$3,$4)
+      ON CONFLICT (f_block_root) DO
+      UPDATE
+      SET f_arrival_time = LEAST(t_block_arrivals.f_arrival_time, excluded.f_arrival_time)
+		 `,
+		arrival.Slot,
+		arrival.BlockRoot[:],
+		arrival.SlotTime,
+		arrival.ArrivalTime,
+	)
+	s.monitorWrite("t_block_arrivals", 1, err)
+
+	return err
+}
+
+// BlockArrivals fetches the arrivals of blocks in the given slot range.
+// Ranges are inclusive of start and end.
+func (s *Service) BlockArrivals(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.BlockArrival, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_block_root
+            ,f_slot_time
+            ,f_arrival_time
+      FROM t_block_arrivals
+      WHERE f_slot >= $1
+        AND f_slot <= $2
+      ORDER BY f_slot
+              ,f_arrival_time`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arrivals := make([]*chaindb.BlockArrival, 0)
+	for rows.Next() {
+		arrival := &chaindb.BlockArrival{}
+		var blockRoot []byte
+		err := rows.Scan(
+			&arrival.Slot,
+			&blockRoot,
+			&arrival.SlotTime,
+			&arrival.ArrivalTime,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(arrival.BlockRoot[:], blockRoot)
+		arrivals = append(arrivals, arrival)
+	}
+
+	return arrivals, nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestBlockArrivals(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	slotTime := time.Unix(1600000000, 0).UTC()
+	arrival := &chaindb.BlockArrival{
+		Slot: 1,
+		BlockRoot: phase0.Root{
+			0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		},
+		SlotTime:    slotTime,
+		ArrivalTime: slotTime.Add(2 * time.Second),
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetBlockArrival(ctx, arrival), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetBlockArrival(ctx, arrival))
+
+	// A later arrival of the same block does not replace the first.
+	later := *arrival
+	later.ArrivalTime = slotTime.Add(5 * time.Second)
+	require.NoError(t, s.SetBlockArrival(ctx, &later))
+
+	arrivals, err := s.BlockArrivals(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, arrivals, 1)
+	require.Equal(t, arrival.BlockRoot, arrivals[0].BlockRoot)
+	require.True(t, arrival.ArrivalTime.Equal(arrivals[0].ArrivalTime))
+}
@@ -0,0 +1,199 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockClients records the likely clients of the proposers of blocks.
+func (s *Service) SetBlockClients(ctx context.Context, clients []*chaindb.BlockClient) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	slots := make([]int64, len(clients))
+	epochs := make([]int64, len(clients))
+	blockRoots := make([][]byte, len(clients))
+	proposerIndices := make([]int64, len(clients))
+	names := make([]string, len(clients))
+	confidences := make([]float64, len(clients))
+	signals := make([]string, len(clients))
+	for i, client := range clients {
+		slots[i] = int64(client.Slot)
+		epochs[i] = int64(client.Epoch)
+		blockRoots[i] = client.BlockRoot[:]
+		proposerIndices[i] = int64(client.ProposerIndex)
+		names[i] = client.Client
+		confidences[i] = client.Confidence
+		signals[i] = client.Signal
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_block_clients(f_slot
+                           ,f_epoch
+                           ,f_block_root
+                           ,f_proposer_index
+                           ,f_client
+                           ,f_confidence
+                           ,f_signal)
+SELECT * FROM UNNEST($1::BIGINT[],$2::BIGINT[],$3::BYTEA[],$4::BIGINT[],$5::TEXT[],$6::FLOAT8[],$7::TEXT[])
+ON CONFLICT (f_block_root) DO
+UPDATE
+SET f_client = excluded.f_client
+   ,f_confidence = excluded.f_confidence
+   ,f_signal = excluded.f_signal
+`,
+		slots,
+		epochs,
+		blockRoots,
+		proposerIndices,
+		names,
+		confidences,
+		signals,
+	)
+	s.monitorWrite("t_block_clients", len(clients), err)
+
+	return err
+}
+
+// BlockClients fetches the likely clients of the proposers of blocks in the given slot range.
+// Ranges are inclusive of start and end.
+func (s *Service) BlockClients(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.BlockClient, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_epoch
+            ,f_block_root
+            ,f_proposer_index
+            ,f_client
+            ,f_confidence
+            ,f_signal
+      FROM t_block_clients
+      WHERE f_slot >= $1
+        AND f_slot <= $2
+      ORDER BY f_slot
+              ,f_block_root`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := make([]*chaindb.BlockClient, 0)
+	for rows.Next() {
+		client := &chaindb.BlockClient{}
+		var blockRoot []byte
+		err := rows.Scan(
+			&client.Slot,
+			&client.Epoch,
+			&blockRoot,
+			&client.ProposerIndex,
+			&client.Client,
+			&client.Confidence,
+			&client.Signal,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(client.BlockRoot[:], blockRoot)
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// ClientShares fetches the number of canonical blocks proposed by each client in each epoch
+// of the given range, counting only those clients inferred with at least the given confidence.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4
+// will provide shares for epochs 2 and 3.
+func (s *Service) ClientShares(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	minConfidence float64,
+) (
+	[]*chaindb.ClientShare,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Blocks whose canonical state is yet to be decided are counted, as otherwise
+	// recent epochs would show no blocks at all.
+	rows, err := tx.Query(ctx, `
+      SELECT t_block_clients.f_epoch
+            ,t_block_clients.f_client
+            ,COUNT(*)
+      FROM t_block_clients
+      JOIN t_blocks ON t_blocks.f_root = t_block_clients.f_block_root
+      WHERE t_block_clients.f_epoch >= $1
+        AND t_block_clients.f_epoch < $2
+        AND t_block_clients.f_confidence >= $3
+        AND t_blocks.f_canonical IS NOT FALSE
+      GROUP BY t_block_clients.f_epoch
+              ,t_block_clients.f_client
+      ORDER BY t_block_clients.f_epoch
+              ,t_block_clients.f_client`,
+		startEpoch,
+		endEpoch,
+		minConfidence,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := make([]*chaindb.ClientShare, 0)
+	for rows.Next() {
+		share := &chaindb.ClientShare{}
+		err := rows.Scan(
+			&share.Epoch,
+			&share.Client,
+			&share.Blocks,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, nil
+}
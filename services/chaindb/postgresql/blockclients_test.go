@@ -0,0 +1,81 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestBlockClients(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	block := &chaindb.Block{
+		Slot:          33,
+		ProposerIndex: 2,
+		Root: phase0.Root{
+			0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		},
+		Graffiti:      []byte("Lighthouse/v3.1.0-aa022f4"),
+		ETH1BlockHash: make([]byte, 32),
+	}
+	client := &chaindb.BlockClient{
+		Slot:          block.Slot,
+		Epoch:         1,
+		BlockRoot:     block.Root,
+		ProposerIndex: block.ProposerIndex,
+		Client:        "lighthouse",
+		Confidence:    0.95,
+		Signal:        "graffiti",
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetBlockClients(ctx, []*chaindb.BlockClient{client}), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetBlock(ctx, block))
+	require.NoError(t, s.SetBlockClients(ctx, []*chaindb.BlockClient{client}))
+
+	clients, err := s.BlockClients(ctx, 33, 33)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	require.Equal(t, client, clients[0])
+
+	shares, err := s.ClientShares(ctx, 1, 2, 0.5)
+	require.NoError(t, err)
+	require.Len(t, shares, 1)
+	require.Equal(t, "lighthouse", shares[0].Client)
+	require.Equal(t, uint64(1), shares[0].Blocks)
+
+	// Clients below the confidence threshold are not counted.
+	shares, err = s.ClientShares(ctx, 1, 2, 0.99)
+	require.NoError(t, err)
+	require.Len(t, shares, 0)
+}
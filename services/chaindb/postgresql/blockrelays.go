@@ -0,0 +1,63 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetBlockRelays records the relays that delivered the execution payloads of blocks.
+func (s *Service) SetBlockRelays(ctx context.Context, relays []*chaindb.BlockRelays) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	blockRoots := make([][]byte, len(relays))
+	// Relays are passed as comma-separated lists, as arrays of arrays must all be the same length.
+	names := make([]string, len(relays))
+	builderPubKeys := make([][]byte, len(relays))
+	values := make([]*string, len(relays))
+	for i, blockRelays := range relays {
+		blockRoots[i] = blockRelays.BlockRoot[:]
+		names[i] = strings.Join(blockRelays.Relays, ",")
+		if blockRelays.BuilderPubKey != nil {
+			builderPubKeys[i] = blockRelays.BuilderPubKey[:]
+		}
+		if blockRelays.Value != nil {
+			value := blockRelays.Value.String()
+			values[i] = &value
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+UPDATE t_block_execution_payloads
+SET f_relays = STRING_TO_ARRAY(x.f_relays, ',')
+   ,f_builder_pubkey = x.f_builder_pubkey
+   ,f_value = x.f_value::NUMERIC
+FROM UNNEST($1::BYTEA[],$2::TEXT[],$3::BYTEA[],$4::TEXT[]) AS x(f_block_root, f_relays, f_builder_pubkey, f_value)
+WHERE t_block_execution_payloads.f_block_root = x.f_block_root
+`,
+		blockRoots,
+		names,
+		builderPubKeys,
+		values,
+	)
+	s.monitorWrite("t_block_execution_payloads", len(relays), err)
+
+	return err
+}
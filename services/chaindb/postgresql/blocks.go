@@ -16,8 +16,12 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
@@ -47,8 +51,9 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
                           ,f_eth1_block_hash
                           ,f_eth1_deposit_count
                           ,f_eth1_deposit_root
+                          ,f_epoch
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$1::BIGINT / slots_per_epoch())
       ON CONFLICT (f_root) DO
       UPDATE
       SET f_slot = excluded.f_slot
@@ -62,6 +67,7 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
          ,f_eth1_block_hash = excluded.f_eth1_block_hash
          ,f_eth1_deposit_count = excluded.f_eth1_deposit_count
          ,f_eth1_deposit_root = excluded.f_eth1_deposit_root
+         ,f_epoch = excluded.f_epoch
 	  `,
 		block.Slot,
 		block.ProposerIndex,
@@ -76,8 +82,12 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		block.ETH1DepositCount,
 		block.ETH1DepositRoot[:],
 	); err != nil {
+		s.monitorWriteFailure("t_blocks")
 		return err
 	}
+	s.monitorRowsWritten("t_blocks", 1)
+
+	s.afterCommit(ctx, func() { s.cacheDelete(ctx, latestBlocksCacheKey) })
 
 	// Also set execution payload (will return without error if payload is not set).
 	return s.setExecutionPayload(ctx, block)
@@ -263,6 +273,292 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 	return blocks, nil
 }
 
+// BlocksForTimestampRange fetches all blocks whose slots start in the given time range.
+// Ranges are inclusive of start and exclusive of end.  Times before genesis are treated as genesis.
+func (s *Service) BlocksForTimestampRange(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.Block, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	var genesis time.Time
+	var slotDuration *uint64
+	err = tx.QueryRow(ctx, `
+SELECT f_time
+      ,(SELECT f_value::BIGINT FROM t_chain_spec WHERE f_key = 'SECONDS_PER_SLOT')
+FROM t_genesis
+`).Scan(
+		&genesis,
+		&slotDuration,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// No genesis, so no blocks.
+			return []*chaindb.Block{}, nil
+		}
+		return nil, err
+	}
+	if slotDuration == nil || *slotDuration == 0 {
+		return nil, errors.New("slot duration not known")
+	}
+
+	return s.BlocksForSlotRange(ctx,
+		timestampToSlotCeil(genesis, *slotDuration, start),
+		timestampToSlotCeil(genesis, *slotDuration, end),
+	)
+}
+
+// timestampToSlotCeil provides the first slot that starts at or after the given timestamp.
+func timestampToSlotCeil(genesis time.Time, slotDuration uint64, timestamp time.Time) phase0.Slot {
+	if !timestamp.After(genesis) {
+		return 0
+	}
+	secondsSinceGenesis := uint64(timestamp.Sub(genesis).Seconds())
+	slot := secondsSinceGenesis / slotDuration
+	if !genesis.Add(time.Duration(slot*slotDuration) * time.Second).Equal(timestamp) {
+		slot++
+	}
+	return phase0.Slot(slot)
+}
+
+// BlocksForProposerIndex fetches all blocks proposed by the given validator in the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// blocks for slots 2 and 3.
+func (s *Service) BlocksForProposerIndex(ctx context.Context,
+	index phase0.ValidatorIndex,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.Block,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_proposer_index
+            ,f_root
+            ,f_graffiti
+            ,f_randao_reveal
+            ,f_body_root
+            ,f_parent_root
+            ,f_state_root
+            ,f_canonical
+            ,f_eth1_block_hash
+            ,f_eth1_deposit_count
+            ,f_eth1_deposit_root
+      FROM t_blocks
+      WHERE f_proposer_index = $1
+        AND f_slot >= $2
+        AND f_slot < $3
+      ORDER BY f_slot`,
+		index,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := make([]*chaindb.Block, 0)
+	for rows.Next() {
+		block := &chaindb.Block{}
+		var blockRoot []byte
+		var randaoReveal []byte
+		var bodyRoot []byte
+		var parentRoot []byte
+		var stateRoot []byte
+		var canonical sql.NullBool
+		var eth1DepositRoot []byte
+		err := rows.Scan(
+			&block.Slot,
+			&block.ProposerIndex,
+			&blockRoot,
+			&block.Graffiti,
+			&randaoReveal,
+			&bodyRoot,
+			&parentRoot,
+			&stateRoot,
+			&canonical,
+			&block.ETH1BlockHash,
+			&block.ETH1DepositCount,
+			&eth1DepositRoot,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(block.Root[:], blockRoot)
+		copy(block.RANDAOReveal[:], randaoReveal)
+		copy(block.BodyRoot[:], bodyRoot)
+		copy(block.ParentRoot[:], parentRoot)
+		copy(block.StateRoot[:], stateRoot)
+		if canonical.Valid {
+			val := canonical.Bool
+			block.Canonical = &val
+		}
+		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		blocks = append(blocks, block)
+	}
+
+	// Add execution payload to the blocks where available.
+	for _, block := range blocks {
+		block.ExecutionPayload, err = s.executionPayload(ctx, tx, block.Root)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return blocks, nil
+}
+
+// FeeRecipientChanges fetches the changes in fee recipient between successive blocks proposed by each of the
+// given validators, where the later block is in the given slot range.  If validators is nil then changes for all
+// validators are returned.  Only blocks that are canonical or undefined are considered.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) FeeRecipientChanges(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.FeeRecipientChange,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	// Blocks before the start slot are included in the window so that a change at the start slot is found.
+	queryBuilder.WriteString(`
+SELECT f_proposer_index
+      ,f_slot
+      ,f_root
+      ,f_fee_recipient
+      ,f_previous_slot
+      ,f_previous_fee_recipient
+FROM (
+  SELECT t_blocks.f_proposer_index
+        ,t_blocks.f_slot
+        ,t_blocks.f_root
+        ,t_block_execution_payloads.f_fee_recipient
+        ,LAG(t_blocks.f_slot) OVER proposer AS f_previous_slot
+        ,LAG(t_block_execution_payloads.f_fee_recipient) OVER proposer AS f_previous_fee_recipient
+  FROM t_blocks
+  JOIN t_block_execution_payloads
+    ON t_block_execution_payloads.f_block_root = t_blocks.f_root
+  WHERE t_blocks.f_slot < $2
+    AND (t_blocks.f_canonical IS NULL OR t_blocks.f_canonical = true)`)
+	queryVals = append(queryVals, startSlot, endSlot)
+
+	if validators != nil {
+		queryBuilder.WriteString(`
+    AND t_blocks.f_proposer_index = ANY($3)`)
+		queryVals = append(queryVals, validators)
+	}
+
+	queryBuilder.WriteString(`
+  WINDOW proposer AS (PARTITION BY t_blocks.f_proposer_index ORDER BY t_blocks.f_slot)
+) AS blocks
+WHERE f_slot >= $1
+  AND f_previous_fee_recipient IS NOT NULL
+  AND f_previous_fee_recipient <> f_fee_recipient
+ORDER BY f_slot
+        ,f_proposer_index`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*chaindb.FeeRecipientChange, 0)
+	for rows.Next() {
+		change := &chaindb.FeeRecipientChange{}
+		var root []byte
+		var feeRecipient []byte
+		var previousFeeRecipient []byte
+		if err := rows.Scan(
+			&change.ProposerIndex,
+			&change.Slot,
+			&root,
+			&feeRecipient,
+			&change.PreviousSlot,
+			&previousFeeRecipient,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(change.Root[:], root)
+		copy(change.FeeRecipient[:], feeRecipient)
+		copy(change.PreviousFeeRecipient[:], previousFeeRecipient)
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// forEachBlockChunkSize is the number of slots' worth of blocks fetched at a time when iterating over blocks.
+var forEachBlockChunkSize = phase0.Slot(1024)
+
+// ForEachBlockInSlotRange calls the supplied function for each block in the given slot range,
+// fetching the blocks from the database in chunks rather than holding them all in memory.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// blocks for slots 2 and 3.
+// Iteration stops if the function returns an error, and the error is returned.
+func (s *Service) ForEachBlockInSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	fn func(*chaindb.Block) error,
+) error {
+	for chunkStart := startSlot; chunkStart < endSlot; chunkStart += forEachBlockChunkSize {
+		chunkEnd := chunkStart + forEachBlockChunkSize
+		if chunkEnd > endSlot {
+			chunkEnd = endSlot
+		}
+		blocks, err := s.BlocksForSlotRange(ctx, chunkStart, chunkEnd)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			if err := fn(block); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // BlockByRoot fetches the block with the given root.
 func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
 	var err error
@@ -339,6 +635,95 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 	return block, nil
 }
 
+// BlockByExecutionBlockHash fetches the block containing the execution payload with the given hash.
+// It returns nil if there is no such block.
+func (s *Service) BlockByExecutionBlockHash(ctx context.Context, hash [32]byte) (*chaindb.Block, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	var blockRoot []byte
+	err = tx.QueryRow(ctx, `
+      SELECT f_block_root
+      FROM t_block_execution_payloads
+      WHERE f_block_hash = $1`,
+		hash[:],
+	).Scan(
+		&blockRoot,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var root phase0.Root
+	copy(root[:], blockRoot)
+
+	return s.BlockByRoot(ctx, root)
+}
+
+// BlocksByExecutionBlockNumber fetches the blocks containing execution payloads with the given block number.
+// There can be more than one such block if the chain has forked.
+func (s *Service) BlocksByExecutionBlockNumber(ctx context.Context, number uint64) ([]*chaindb.Block, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_block_root
+      FROM t_block_execution_payloads
+      WHERE f_block_number = $1`,
+		number,
+	)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]phase0.Root, 0)
+	for rows.Next() {
+		var blockRoot []byte
+		if err := rows.Scan(&blockRoot); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		var root phase0.Root
+		copy(root[:], blockRoot)
+		roots = append(roots, root)
+	}
+	rows.Close()
+
+	blocks := make([]*chaindb.Block, 0, len(roots))
+	for _, root := range roots {
+		block, err := s.BlockByRoot(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i int, j int) bool {
+		return blocks[i].Slot < blocks[j].Slot
+	})
+
+	return blocks, nil
+}
+
 // CanonicalBlockPresenceForSlotRange returns a boolean for each slot in the range for the presence
 // of a canonical block.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
@@ -558,11 +943,21 @@ func (s *Service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot,
 	return indeterminateRoots, nil
 }
 
+// latestBlocksCacheKey is the cache key for the latest blocks.
+var latestBlocksCacheKey = "latest_blocks"
+
 // LatestBlocks fetches the blocks with the highest slot number for in the database.
 func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 	var err error
 
+	blocks := make([]*chaindb.Block, 0)
+	if s.cacheGet(ctx, latestBlocksCacheKey, &blocks) {
+		return blocks, nil
+	}
+
 	tx := s.tx(ctx)
+	// Only cache the results if they are not from within a transaction.
+	cacheable := tx == nil
 	if tx == nil {
 		ctx, err = s.beginROTx(ctx)
 		if err != nil {
@@ -592,8 +987,6 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 	}
 	defer rows.Close()
 
-	blocks := make([]*chaindb.Block, 0)
-
 	for rows.Next() {
 		block := &chaindb.Block{}
 		var blockRoot []byte
@@ -644,6 +1037,10 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 		}
 	}
 
+	if cacheable {
+		s.cacheSet(ctx, latestBlocksCacheKey, blocks)
+	}
+
 	return blocks, nil
 }
 
@@ -15,8 +15,10 @@ package postgresql_test
 
 import (
 	"context"
+	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
@@ -109,3 +111,167 @@ func TestIndeterminateBlocks(t *testing.T) {
 	require.NotNil(t, dbBlock.Canonical)
 	require.True(t, *dbBlock.Canonical)
 }
+
+func TestBlocksForProposerIndex(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	proposer := phase0.ValidatorIndex(0xfffff1)
+	for i, slot := range []phase0.Slot{0xfffff0, 0xfffff1, 0xfffff2} {
+		block := &chaindb.Block{
+			Slot:          slot,
+			ProposerIndex: proposer,
+			Root:          phase0.Root{0xf0, byte(i)},
+		}
+		if slot == 0xfffff1 {
+			// A block from another proposer.
+			block.ProposerIndex = proposer + 1
+		}
+		require.NoError(t, s.SetBlock(ctx, block))
+	}
+
+	blocks, err := s.BlocksForProposerIndex(ctx, proposer, 0xfffff0, 0xfffff3)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	require.Equal(t, phase0.Slot(0xfffff0), blocks[0].Slot)
+	require.Equal(t, phase0.Slot(0xfffff2), blocks[1].Slot)
+
+	blocks, err = s.BlocksForProposerIndex(ctx, proposer, 0xfffff1, 0xfffff2)
+	require.NoError(t, err)
+	require.Len(t, blocks, 0)
+}
+
+func TestBlocksByExecutionBlock(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	block := &chaindb.Block{
+		Slot: 0xfffff0,
+		Root: phase0.Root{0xf1},
+		ExecutionPayload: &chaindb.ExecutionPayload{
+			BlockNumber:   0xfffff0,
+			BlockHash:     [32]byte{0xe1},
+			BaseFeePerGas: big.NewInt(7),
+		},
+	}
+	require.NoError(t, s.SetBlock(ctx, block))
+
+	dbBlock, err := s.BlockByExecutionBlockHash(ctx, [32]byte{0xe1})
+	require.NoError(t, err)
+	require.NotNil(t, dbBlock)
+	require.Equal(t, block.Root, dbBlock.Root)
+
+	dbBlock, err = s.BlockByExecutionBlockHash(ctx, [32]byte{0xe2})
+	require.NoError(t, err)
+	require.Nil(t, dbBlock)
+
+	dbBlocks, err := s.BlocksByExecutionBlockNumber(ctx, 0xfffff0)
+	require.NoError(t, err)
+	require.Len(t, dbBlocks, 1)
+	require.Equal(t, block.Root, dbBlocks[0].Root)
+
+	dbBlocks, err = s.BlocksByExecutionBlockNumber(ctx, 0xfffff1)
+	require.NoError(t, err)
+	require.Len(t, dbBlocks, 0)
+}
+
+func TestFeeRecipientChanges(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	proposer := phase0.ValidatorIndex(0xfffff1)
+	for i, feeRecipient := range [][20]byte{{0x01}, {0x01}, {0x02}} {
+		require.NoError(t, s.SetBlock(ctx, &chaindb.Block{
+			Slot:          phase0.Slot(0xfffff0 + i),
+			ProposerIndex: proposer,
+			Root:          phase0.Root{0xf2, byte(i)},
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				FeeRecipient:  feeRecipient,
+				BlockNumber:   uint64(0xfffff0 + i),
+				BlockHash:     [32]byte{0xe2, byte(i)},
+				BaseFeePerGas: big.NewInt(7),
+			},
+		}))
+	}
+
+	changes, err := s.FeeRecipientChanges(ctx, []phase0.ValidatorIndex{proposer}, 0xfffff0, 0xfffff3)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, phase0.Slot(0xfffff2), changes[0].Slot)
+	require.Equal(t, phase0.Slot(0xfffff1), changes[0].PreviousSlot)
+	require.Equal(t, [20]byte{0x02}, changes[0].FeeRecipient)
+	require.Equal(t, [20]byte{0x01}, changes[0].PreviousFeeRecipient)
+
+	// The change is found even if the previous block is before the range.
+	changes, err = s.FeeRecipientChanges(ctx, []phase0.ValidatorIndex{proposer}, 0xfffff2, 0xfffff3)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+
+	changes, err = s.FeeRecipientChanges(ctx, []phase0.ValidatorIndex{proposer}, 0xfffff0, 0xfffff2)
+	require.NoError(t, err)
+	require.Len(t, changes, 0)
+}
+
+func TestBlocksForTimestampRange(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	genesis, err := s.Genesis(ctx)
+	require.NoError(t, err)
+	if genesis == nil {
+		t.Skip("genesis not available")
+	}
+	tmp, err := s.ChainSpecValue(ctx, "SECONDS_PER_SLOT")
+	require.NoError(t, err)
+	slotDuration, isDuration := tmp.(time.Duration)
+	require.True(t, isDuration)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	block := &chaindb.Block{
+		Slot: 0xfffff0,
+		Root: phase0.Root{0xf1},
+	}
+	require.NoError(t, s.SetBlock(ctx, block))
+	startOfSlot := genesis.GenesisTime.Add(time.Duration(block.Slot) * slotDuration)
+
+	blocks, err := s.BlocksForTimestampRange(ctx, startOfSlot, startOfSlot.Add(slotDuration))
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Equal(t, block.Root, blocks[0].Root)
+
+	// A range starting part way through the slot does not include the block.
+	blocks, err = s.BlocksForTimestampRange(ctx, startOfSlot.Add(time.Second), startOfSlot.Add(slotDuration))
+	require.NoError(t, err)
+	require.Len(t, blocks, 0)
+}
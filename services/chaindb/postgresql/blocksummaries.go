@@ -48,6 +48,7 @@ func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSum
 		summary.VotesForBlock,
 		summary.ParentDistance,
 	)
+	s.monitorWrite("t_block_summaries", 1, err)
 
 	return err
 }
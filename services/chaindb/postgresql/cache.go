@@ -0,0 +1,70 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// cacheGet obtains a value from the cache, returning true if it was found.
+// The cache is only consulted outside of transactions, to avoid returning
+// data that does not reflect changes made within the transaction.
+func (s *Service) cacheGet(ctx context.Context, key string, value interface{}) bool {
+	if s.cache == nil || s.tx(ctx) != nil {
+		return false
+	}
+
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.log.Debug().Str("key", key).Err(err).Msg("Failed to obtain value from cache")
+		return false
+	}
+	if data == nil {
+		return false
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		s.log.Debug().Str("key", key).Err(err).Msg("Failed to unmarshal value from cache")
+		return false
+	}
+
+	return true
+}
+
+// cacheSet sets a value in the cache.
+func (s *Service) cacheSet(ctx context.Context, key string, value interface{}) {
+	if s.cache == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.log.Debug().Str("key", key).Err(err).Msg("Failed to marshal value for cache")
+		return
+	}
+	if err := s.cache.Set(ctx, key, data); err != nil {
+		s.log.Debug().Str("key", key).Err(err).Msg("Failed to set value in cache")
+	}
+}
+
+// cacheDelete removes a value from the cache.
+func (s *Service) cacheDelete(ctx context.Context, key string) {
+	if s.cache == nil {
+		return
+	}
+
+	if err := s.cache.Delete(ctx, key); err != nil {
+		s.log.Debug().Str("key", key).Err(err).Msg("Failed to delete value from cache")
+	}
+}
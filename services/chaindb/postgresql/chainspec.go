@@ -26,42 +26,123 @@ import (
 )
 
 // SetChainSpecValue sets the value of the provided key.
+// The value is effective from genesis.
 func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
 	}
 
-	var dbVal string
-	switch v := value.(type) {
-	case phase0.Slot, phase0.Epoch, phase0.CommitteeIndex, phase0.ValidatorIndex, phase0.Gwei:
-		dbVal = fmt.Sprintf("%d", v)
-	case phase0.Root, phase0.Version, phase0.DomainType, phase0.ForkDigest, phase0.Domain, phase0.BLSPubKey, phase0.BLSSignature, []byte:
-		dbVal = fmt.Sprintf("%#x", v)
-	case time.Duration:
-		dbVal = fmt.Sprintf("%d", int(v.Seconds()))
-	case time.Time:
-		dbVal = fmt.Sprintf("%d", v.Unix())
-	default:
-		dbVal = fmt.Sprintf("%v", v)
-	}
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_chain_spec(f_key
-                              ,f_value)
-      VALUES($1,$2)
-      ON CONFLICT (f_key) DO
+                              ,f_value
+                              ,f_effective_epoch)
+      VALUES($1,$2,0)
+      ON CONFLICT (f_key,f_effective_epoch) DO
       UPDATE
       SET f_value = excluded.f_value
       `,
 		key,
-		dbVal,
+		specToDBVal(value),
 	)
+	if err != nil {
+		s.monitorWriteFailure("t_chain_spec")
+		return err
+	}
+	s.monitorRowsWritten("t_chain_spec", 1)
+
+	s.afterCommit(ctx, func() { s.cacheDelete(ctx, chainSpecCacheKey) })
+
+	return nil
+}
+
+// SetChainSpecValueFromEpoch sets the value of the provided key from the given epoch.
+// If the value is the same as that already in effect at the epoch nothing is written.
+func (s *Service) SetChainSpecValueFromEpoch(ctx context.Context, key string, value interface{}, epoch phase0.Epoch) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	tag, err := tx.Exec(ctx, `
+      INSERT INTO t_chain_spec(f_key
+                              ,f_value
+                              ,f_effective_epoch)
+      SELECT $1::TEXT
+            ,$2::TEXT
+            ,$3::BIGINT
+      WHERE $2::TEXT IS DISTINCT FROM (
+        SELECT f_value
+        FROM t_chain_spec
+        WHERE f_key = $1::TEXT
+          AND f_effective_epoch <= $3::BIGINT
+        ORDER BY f_effective_epoch DESC
+        LIMIT 1
+      )
+      ON CONFLICT (f_key,f_effective_epoch) DO
+      UPDATE
+      SET f_value = excluded.f_value
+      `,
+		key,
+		specToDBVal(value),
+		epoch,
+	)
+	if err != nil {
+		s.monitorWriteFailure("t_chain_spec")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		// Value unchanged, so nothing written.
+		return nil
+	}
+	s.monitorRowsWritten("t_chain_spec", 1)
+
+	s.afterCommit(ctx, func() { s.cacheDelete(ctx, chainSpecCacheKey) })
 
-	return err
+	return nil
 }
 
+// specToDBVal turns a spec value in to a database value.
+func specToDBVal(value interface{}) string {
+	switch v := value.(type) {
+	case phase0.Slot, phase0.Epoch, phase0.CommitteeIndex, phase0.ValidatorIndex, phase0.Gwei:
+		return fmt.Sprintf("%d", v)
+	case phase0.Root, phase0.Version, phase0.DomainType, phase0.ForkDigest, phase0.Domain, phase0.BLSPubKey, phase0.BLSSignature, []byte:
+		return fmt.Sprintf("%#x", v)
+	case time.Duration:
+		return fmt.Sprintf("%d", int(v.Seconds()))
+	case time.Time:
+		return fmt.Sprintf("%d", v.Unix())
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// chainSpecCacheKey is the cache key for the chain specification.
+var chainSpecCacheKey = "chain_spec"
+
 // ChainSpec fetches all chain specification values.
 func (s *Service) ChainSpec(ctx context.Context) (map[string]interface{}, error) {
+	dbVals := make(map[string]string)
+	if !s.cacheGet(ctx, chainSpecCacheKey, &dbVals) {
+		var err error
+		dbVals, err = s.chainSpecDBVals(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.cacheSet(ctx, chainSpecCacheKey, dbVals)
+	}
+
+	spec := make(map[string]interface{}, len(dbVals))
+	for key, dbVal := range dbVals {
+		spec[key] = dbValToSpec(ctx, key, dbVal)
+	}
+
+	return spec, nil
+}
+
+// chainSpecDBVals fetches the database values of the chain specification.
+func (s *Service) chainSpecDBVals(ctx context.Context) (map[string]string, error) {
 	var err error
 
 	tx := s.tx(ctx)
@@ -74,11 +155,14 @@ func (s *Service) ChainSpec(ctx context.Context) (map[string]interface{}, error)
 		defer s.commitROTx(ctx)
 	}
 
-	spec := make(map[string]interface{})
+	dbVals := make(map[string]string)
 	rows, err := tx.Query(ctx, `
-      SELECT f_key
+      SELECT DISTINCT ON (f_key)
+             f_key
             ,f_value
       FROM t_chain_spec
+      ORDER BY f_key
+              ,f_effective_epoch DESC
 	  `)
 	if err != nil {
 		return nil, err
@@ -96,10 +180,10 @@ func (s *Service) ChainSpec(ctx context.Context) (map[string]interface{}, error)
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 
-		spec[key] = dbValToSpec(ctx, key, dbVal)
+		dbVals[key] = dbVal
 	}
 
-	return spec, nil
+	return dbVals, nil
 }
 
 // ChainSpecValue fetches a chain specification value given its key.
@@ -119,6 +203,8 @@ func (s *Service) ChainSpecValue(ctx context.Context, key string) (interface{},
       SELECT f_value
       FROM t_chain_spec
 	  WHERE f_key = $1
+	  ORDER BY f_effective_epoch DESC
+	  LIMIT 1
 	  `, key).Scan(&dbVal)
 	if err != nil {
 		return nil, err
@@ -127,6 +213,82 @@ func (s *Service) ChainSpecValue(ctx context.Context, key string) (interface{},
 	return dbValToSpec(ctx, key, dbVal), nil
 }
 
+// ChainSpecAtEpoch fetches all chain specification values as they applied at the given epoch.
+func (s *Service) ChainSpecAtEpoch(ctx context.Context, epoch phase0.Epoch) (map[string]interface{}, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT DISTINCT ON (f_key)
+             f_key
+            ,f_value
+      FROM t_chain_spec
+      WHERE f_effective_epoch <= $1
+      ORDER BY f_key
+              ,f_effective_epoch DESC
+	  `, epoch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spec := make(map[string]interface{})
+	for rows.Next() {
+		var key string
+		var dbVal string
+		err := rows.Scan(
+			&key,
+			&dbVal,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+
+		spec[key] = dbValToSpec(ctx, key, dbVal)
+	}
+
+	return spec, nil
+}
+
+// ChainSpecValueAtEpoch fetches a chain specification value given its key, as it applied at the given epoch.
+func (s *Service) ChainSpecValueAtEpoch(ctx context.Context, key string, epoch phase0.Epoch) (interface{}, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	var dbVal string
+	err = tx.QueryRow(ctx, `
+      SELECT f_value
+      FROM t_chain_spec
+	  WHERE f_key = $1
+	    AND f_effective_epoch <= $2
+	  ORDER BY f_effective_epoch DESC
+	  LIMIT 1
+	  `, key, epoch).Scan(&dbVal)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbValToSpec(ctx, key, dbVal), nil
+}
+
 // dbValToSpec turns a database value in to a spec value.
 func dbValToSpec(ctx context.Context, key string, val string) interface{} {
 	// Handle domains.
@@ -115,3 +115,103 @@ func TestChainSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestSetChainSpecValueFromEpoch(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_VALUE", "1", 10), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetChainSpecValue(ctx, "TEST_VERSIONED_VALUE", uint64(1)))
+
+	// Setting the same value does not create a new version.
+	require.NoError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_VERSIONED_VALUE", uint64(1), 10))
+	val, err := s.ChainSpecValue(ctx, "TEST_VERSIONED_VALUE")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), val)
+
+	// Setting a different value provides the new value.
+	require.NoError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_VERSIONED_VALUE", uint64(2), 20))
+	val, err = s.ChainSpecValue(ctx, "TEST_VERSIONED_VALUE")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), val)
+
+	spec, err := s.ChainSpec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), spec["TEST_VERSIONED_VALUE"])
+}
+
+func TestChainSpecAtEpoch(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetChainSpecValue(ctx, "TEST_VERSIONED_VALUE", uint64(1)))
+	require.NoError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_VERSIONED_VALUE", uint64(2), 10))
+	require.NoError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_VERSIONED_VALUE", uint64(3), 20))
+	require.NoError(t, s.SetChainSpecValueFromEpoch(ctx, "TEST_LATER_VALUE", uint64(4), 15))
+
+	tests := []struct {
+		name  string
+		epoch phase0.Epoch
+		val   interface{}
+		later interface{}
+	}{
+		{
+			name:  "Genesis",
+			epoch: 0,
+			val:   uint64(1),
+		},
+		{
+			name:  "BeforeChange",
+			epoch: 9,
+			val:   uint64(1),
+		},
+		{
+			name:  "AtChange",
+			epoch: 10,
+			val:   uint64(2),
+		},
+		{
+			name:  "AfterChange",
+			epoch: 15,
+			val:   uint64(2),
+			later: uint64(4),
+		},
+		{
+			name:  "Latest",
+			epoch: 1000,
+			val:   uint64(3),
+			later: uint64(4),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, err := s.ChainSpecValueAtEpoch(ctx, "TEST_VERSIONED_VALUE", test.epoch)
+			require.NoError(t, err)
+			require.Equal(t, test.val, val)
+
+			spec, err := s.ChainSpecAtEpoch(ctx, test.epoch)
+			require.NoError(t, err)
+			require.Equal(t, test.val, spec["TEST_VERSIONED_VALUE"])
+			require.Equal(t, test.later, spec["TEST_LATER_VALUE"])
+		})
+	}
+}
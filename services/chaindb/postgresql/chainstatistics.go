@@ -0,0 +1,175 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// BlocksPerDay provides the number of canonical blocks for each UTC day in the given time range.
+// Ranges are inclusive of start and exclusive of end.  Days without canonical blocks are not returned.
+func (s *Service) BlocksPerDay(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.DailyBlocks, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Slot bounds are calculated up front so that the blocks index can be used.
+	rows, err := tx.Query(ctx, `
+WITH params AS (
+  SELECT EXTRACT(EPOCH FROM f_time)::BIGINT AS f_genesis
+        ,(SELECT f_value::BIGINT FROM t_chain_spec WHERE f_key = 'SECONDS_PER_SLOT') AS f_slot_duration
+  FROM t_genesis
+), bounds AS (
+  SELECT GREATEST(0, CEIL(($1::BIGINT - f_genesis)::NUMERIC / f_slot_duration))::BIGINT AS f_start_slot
+        ,GREATEST(0, CEIL(($2::BIGINT - f_genesis)::NUMERIC / f_slot_duration))::BIGINT AS f_end_slot
+  FROM params
+)
+SELECT (TO_TIMESTAMP(params.f_genesis + f_slot * params.f_slot_duration) AT TIME ZONE 'UTC')::DATE AS f_day
+      ,COUNT(*)
+FROM t_blocks
+    ,params
+WHERE f_slot >= (SELECT f_start_slot FROM bounds)
+  AND f_slot < (SELECT f_end_slot FROM bounds)
+  AND f_canonical = true
+GROUP BY f_day
+ORDER BY f_day
+`,
+		start.Unix(),
+		end.Unix(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dailyBlocks := make([]*chaindb.DailyBlocks, 0)
+	for rows.Next() {
+		daily := &chaindb.DailyBlocks{}
+		if err := rows.Scan(
+			&daily.Day,
+			&daily.CanonicalBlocks,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		daily.Day = time.Date(daily.Day.Year(), daily.Day.Month(), daily.Day.Day(), 0, 0, 0, 0, time.UTC)
+		dailyBlocks = append(dailyBlocks, daily)
+	}
+
+	return dailyBlocks, nil
+}
+
+// AverageAttestationsPerBlock provides the mean number of attestations included in canonical blocks in the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// the average for blocks in slots 2 and 3.
+func (s *Service) AverageAttestationsPerBlock(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (float64, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	var average float64
+	err = tx.QueryRow(ctx, `
+SELECT COALESCE(
+  (SELECT COUNT(*)
+   FROM t_attestations
+   WHERE f_inclusion_slot >= $1
+     AND f_inclusion_slot < $2
+     AND f_canonical = true)::FLOAT8 /
+  NULLIF((SELECT COUNT(*)
+          FROM t_blocks
+          WHERE f_slot >= $1
+            AND f_slot < $2
+            AND f_canonical = true), 0)
+, 0)
+`,
+		startSlot,
+		endSlot,
+	).Scan(&average)
+	if err != nil {
+		return 0, err
+	}
+
+	return average, nil
+}
+
+// ParticipationForEpochRange provides the participation for each summarized epoch in the given epoch range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// participation for epochs 2 and 3.
+func (s *Service) ParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochParticipation, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_epoch
+      ,COALESCE(f_attesting_balance::FLOAT8 / NULLIF(f_active_balance, 0), 0)
+      ,COALESCE(f_target_correct_balance::FLOAT8 / NULLIF(f_active_balance, 0), 0)
+      ,COALESCE(f_head_correct_balance::FLOAT8 / NULLIF(f_active_balance, 0), 0)
+FROM t_epoch_summaries
+WHERE f_epoch >= $1
+  AND f_epoch < $2
+ORDER BY f_epoch
+`,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participations := make([]*chaindb.EpochParticipation, 0)
+	for rows.Next() {
+		participation := &chaindb.EpochParticipation{}
+		if err := rows.Scan(
+			&participation.Epoch,
+			&participation.ParticipationRate,
+			&participation.TargetCorrectRate,
+			&participation.HeadCorrectRate,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		participations = append(participations, participation)
+	}
+
+	return participations, nil
+}
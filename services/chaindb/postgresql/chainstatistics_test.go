@@ -0,0 +1,60 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestChainStatistics(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	dailyBlocks, err := s.BlocksPerDay(ctx, time.Unix(0, 0), time.Now())
+	require.NoError(t, err)
+	for i := range dailyBlocks {
+		require.Equal(t, time.UTC, dailyBlocks[i].Day.Location())
+		require.Positive(t, dailyBlocks[i].CanonicalBlocks)
+		if i > 0 {
+			require.True(t, dailyBlocks[i].Day.After(dailyBlocks[i-1].Day))
+		}
+	}
+
+	average, err := s.AverageAttestationsPerBlock(ctx, 0, 1024)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, average, float64(0))
+
+	// An empty range has no blocks.
+	average, err = s.AverageAttestationsPerBlock(ctx, 1024, 1024)
+	require.NoError(t, err)
+	require.Equal(t, float64(0), average)
+
+	participations, err := s.ParticipationForEpochRange(ctx, 0, 10)
+	require.NoError(t, err)
+	for _, participation := range participations {
+		require.GreaterOrEqual(t, participation.ParticipationRate, participation.TargetCorrectRate)
+		require.LessOrEqual(t, participation.ParticipationRate, float64(1))
+	}
+}
@@ -49,6 +49,7 @@ func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) erro
 		deposit.WithdrawalCredentials,
 		deposit.Amount,
 	)
+	s.monitorWrite("t_deposits", 1, err)
 
 	return err
 }
@@ -175,3 +176,71 @@ func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot,
 
 	return deposits, nil
 }
+
+// DepositsForWithdrawalCredentials fetches all deposits with the given withdrawal credentials made in the given slot range.
+// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) DepositsForWithdrawalCredentials(ctx context.Context,
+	withdrawalCredentials []byte,
+	minSlot phase0.Slot,
+	maxSlot phase0.Slot,
+) (
+	[]*chaindb.Deposit,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_inclusion_index
+            ,f_validator_pubkey
+            ,f_withdrawal_credentials
+            ,f_amount
+      FROM t_deposits
+      WHERE f_withdrawal_credentials = $1
+        AND f_inclusion_slot >= $2
+        AND f_inclusion_slot < $3
+        AND f_inclusion_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot >= $2 AND f_slot < $3 AND (f_canonical IS NULL OR f_canonical = true))
+      ORDER BY f_inclusion_slot
+              ,f_inclusion_index`,
+		withdrawalCredentials,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := make([]*chaindb.Deposit, 0)
+	for rows.Next() {
+		deposit := &chaindb.Deposit{}
+		var inclusionBlockRoot []byte
+		var validatorPubKey []byte
+		err := rows.Scan(
+			&deposit.InclusionSlot,
+			&inclusionBlockRoot,
+			&deposit.InclusionIndex,
+			&validatorPubKey,
+			&deposit.WithdrawalCredentials,
+			&deposit.Amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(deposit.InclusionBlockRoot[:], inclusionBlockRoot)
+		copy(deposit.ValidatorPubKey[:], validatorPubKey)
+
+		deposits = append(deposits, deposit)
+	}
+
+	return deposits, nil
+}
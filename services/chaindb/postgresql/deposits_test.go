@@ -160,4 +160,14 @@ func TestDeposits(t *testing.T) {
 	})
 	require.NoError(t, err)
 	require.Len(t, deposits, 2)
+
+	// Fetch the deposits by withdrawal credentials.
+	credentialsDeposits, err := s.DepositsForWithdrawalCredentials(ctx, []byte{0x0c, 0x0d, 0x0e, 0x0f}, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, credentialsDeposits, 1)
+	require.Equal(t, deposit.ValidatorPubKey, credentialsDeposits[0].ValidatorPubKey)
+
+	credentialsDeposits, err = s.DepositsForWithdrawalCredentials(ctx, []byte{0x0c, 0x0d, 0x0e, 0x0f}, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, credentialsDeposits, 0)
 }
@@ -0,0 +1,146 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// epochColumnsFillKey is the metadata key for the epoch columns yet to be filled.
+// This is stored so that filling carries on where it left off if chaind stops before it ends.
+const epochColumnsFillKey = "chaindb.epoch_columns.fill"
+
+// epochColumnsFillEpochs is the number of epochs of rows filled in each transaction.
+const epochColumnsFillEpochs = 32
+
+// epochColumnFill is the range of slots of a table whose epoch column is yet to be filled.
+type epochColumnFill struct {
+	NextSlot uint64 `json:"next_slot"`
+	EndSlot  uint64 `json:"end_slot"`
+}
+
+// fillEpochColumns fills the epoch columns of rows written before the columns were added,
+// if any.  Each batch of rows is filled in its own transaction along with the progress,
+// so that writes are not held up whilst filling takes place.
+func (s *Service) fillEpochColumns(ctx context.Context) {
+	data, err := s.Metadata(ctx, epochColumnsFillKey)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain epoch columns fill metadata")
+		return
+	}
+	if data == nil {
+		// Nothing to fill.
+		return
+	}
+	fill := make(map[string]*epochColumnFill)
+	if err := json.Unmarshal(data, &fill); err != nil {
+		s.log.Error().Err(err).Msg("Failed to unmarshal epoch columns fill metadata")
+		return
+	}
+
+	var slotsPerEpoch sql.NullInt64
+	if err := s.pool.QueryRow(ctx, "SELECT slots_per_epoch()").Scan(&slotsPerEpoch); err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain slots per epoch")
+		return
+	}
+	if !slotsPerEpoch.Valid || slotsPerEpoch.Int64 <= 0 {
+		s.log.Warn().Msg("Chain specification not available; epoch columns will be filled on restart")
+		return
+	}
+
+	for _, epochColumn := range epochColumns {
+		progress, exists := fill[epochColumn.table]
+		if !exists {
+			continue
+		}
+		s.log.Info().Str("table", epochColumn.table).Uint64("next_slot", progress.NextSlot).Uint64("end_slot", progress.EndSlot).Msg("Filling epoch column")
+		for progress.NextSlot < progress.EndSlot {
+			toSlot := progress.NextSlot + uint64(slotsPerEpoch.Int64)*epochColumnsFillEpochs
+			if toSlot > progress.EndSlot {
+				toSlot = progress.EndSlot
+			}
+			if err := s.fillEpochColumnBatch(ctx, fill, epochColumn.table, epochColumn.column, epochColumn.slotColumn, uint64(slotsPerEpoch.Int64), toSlot); err != nil {
+				s.log.Error().Str("table", epochColumn.table).Uint64("next_slot", progress.NextSlot).Err(err).Msg("Failed to fill epoch column; will continue on restart")
+				return
+			}
+		}
+		s.log.Info().Str("table", epochColumn.table).Msg("Filled epoch column")
+	}
+}
+
+// fillEpochColumnBatch fills the epoch column of a table for rows up to the given slot,
+// and records the progress in the same transaction.
+func (s *Service) fillEpochColumnBatch(ctx context.Context,
+	fill map[string]*epochColumnFill,
+	table string,
+	column string,
+	slotColumn string,
+	slotsPerEpoch uint64,
+	toSlot uint64,
+) error {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	tx := s.tx(ctx)
+
+	progress := fill[table]
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+UPDATE %[1]s
+SET %[2]s = %[3]s / $1
+WHERE %[3]s >= $2
+  AND %[3]s < $3
+  AND %[2]s IS NULL
+`, table, column, slotColumn),
+		slotsPerEpoch,
+		progress.NextSlot,
+		toSlot,
+	); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to fill epoch column")
+	}
+
+	progress.NextSlot = toSlot
+	if progress.NextSlot >= progress.EndSlot {
+		delete(fill, table)
+	}
+	if len(fill) == 0 {
+		if _, err := tx.Exec(ctx, "DELETE FROM t_metadata WHERE f_key = $1", epochColumnsFillKey); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to remove epoch columns fill metadata")
+		}
+	} else {
+		data, err := json.Marshal(fill)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to marshal epoch columns fill")
+		}
+		if err := s.SetMetadata(ctx, epochColumnsFillKey, data); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set epoch columns fill metadata")
+		}
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -46,8 +46,10 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
                                    ,f_attester_slashings
                                    ,f_deposits
                                    ,f_exiting_validators
-                                   ,f_canonical_blocks)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
+                                   ,f_canonical_blocks
+                                   ,f_sync_committee_participations
+                                   ,f_sync_committee_misses)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22)
       ON CONFLICT (f_epoch) DO
       UPDATE
       SET f_activation_queue_length = excluded.f_activation_queue_length
@@ -69,6 +71,8 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
          ,f_deposits = excluded.f_deposits
          ,f_exiting_validators = excluded.f_exiting_validators
          ,f_canonical_blocks = excluded.f_canonical_blocks
+         ,f_sync_committee_participations = excluded.f_sync_committee_participations
+         ,f_sync_committee_misses = excluded.f_sync_committee_misses
 		 `,
 		summary.Epoch,
 		summary.ActivationQueueLength,
@@ -90,7 +94,10 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
 		summary.Deposits,
 		summary.ExitingValidators,
 		summary.CanonicalBlocks,
+		summary.SyncCommitteeParticipations,
+		summary.SyncCommitteeMisses,
 	)
+	s.monitorWrite("t_epoch_summaries", 1, err)
 
 	return err
 }
@@ -0,0 +1,48 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// deterministicErrorClasses are the classes of PostgreSQL error codes that are caused by the
+// data being written, and so recur if the same data is written again.
+var deterministicErrorClasses = map[string]bool{
+	// Data exception, for example a value out of range.
+	"22": true,
+	// Integrity constraint violation, for example a missing foreign key.
+	"23": true,
+	// Program limit exceeded, for example a row that is too large.
+	"54": true,
+}
+
+// IsDeterministicError returns true if the error was caused by the data being written, either
+// because the database rejected it or because chaind rejected it before it reached the database.
+// Other errors, such as lost connections, are not deterministic.
+func (*Service) IsDeterministicError(err error) bool {
+	var invalidDataErr *chaindb.InvalidDataError
+	if errors.As(err, &invalidDataErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || len(pgErr.Code) < 2 {
+		return false
+	}
+
+	return deterministicErrorClasses[pgErr.Code[:2]]
+}
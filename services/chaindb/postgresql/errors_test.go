@@ -0,0 +1,88 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestIsDeterministicError(t *testing.T) {
+	s := &postgresql.Service{}
+
+	tests := []struct {
+		name          string
+		err           error
+		deterministic bool
+	}{
+		{
+			name: "NotDatabase",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "Context",
+			err:  context.DeadlineExceeded,
+		},
+		{
+			name:          "ForeignKey",
+			err:           &pgconn.PgError{Code: "23503"},
+			deterministic: true,
+		},
+		{
+			name:          "ForeignKeyWrapped",
+			err:           pkgerrors.Wrap(&pgconn.PgError{Code: "23503"}, "failed to set block"),
+			deterministic: true,
+		},
+		{
+			name:          "OutOfRange",
+			err:           &pgconn.PgError{Code: "22003"},
+			deterministic: true,
+		},
+		{
+			name:          "InvalidData",
+			err:           &chaindb.InvalidDataError{Err: errors.New("failed to calculate block root")},
+			deterministic: true,
+		},
+		{
+			name:          "InvalidDataWrapped",
+			err:           pkgerrors.Wrap(&chaindb.InvalidDataError{Err: errors.New("failed to calculate block root")}, "failed to update block"),
+			deterministic: true,
+		},
+		{
+			name: "Deadlock",
+			err:  &pgconn.PgError{Code: "40P01"},
+		},
+		{
+			name: "LockTimeout",
+			err:  &pgconn.PgError{Code: "55P03"},
+		},
+		{
+			name: "AdminShutdown",
+			err:  &pgconn.PgError{Code: "57P01"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.deterministic, s.IsDeterministicError(test.err))
+		})
+	}
+}
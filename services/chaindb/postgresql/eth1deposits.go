@@ -15,8 +15,10 @@ package postgresql
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
@@ -74,10 +76,16 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 		deposit.Signature[:],
 		deposit.Amount,
 	)
+	s.monitorWrite("t_eth1_deposits", 1, err)
 
 	return err
 }
 
+// DeleteETH1Deposits deletes the Ethereum 1 deposits made in the given range of blocks, inclusive.
+func (s *Service) DeleteETH1Deposits(ctx context.Context, startBlock uint64, endBlock uint64) error {
+	return s.deleteRange(ctx, "t_eth1_deposits", "f_eth1_block_number", startBlock, endBlock)
+}
+
 // ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
 func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
 	tx := s.tx(ctx)
@@ -123,32 +131,197 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 
 	deposits := make([]*chaindb.ETH1Deposit, 0)
 	for rows.Next() {
-		deposit := &chaindb.ETH1Deposit{}
-		var validatorPubKey []byte
-		var signature []byte
-		err := rows.Scan(
-			&deposit.ETH1BlockNumber,
-			&deposit.ETH1BlockHash,
-			&deposit.ETH1BlockTimestamp,
-			&deposit.ETH1TxHash,
-			&deposit.ETH1LogIndex,
-			&deposit.ETH1Sender,
-			&deposit.ETH1Recipient,
-			&deposit.ETH1GasUsed,
-			&deposit.ETH1GasPrice,
-			&deposit.DepositIndex,
-			&validatorPubKey,
-			&deposit.WithdrawalCredentials,
-			&signature,
-			&deposit.Amount,
-		)
+		deposit, err := eth1DepositFromRow(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	return deposits, nil
+}
+
+// ETH1DepositsForBlockRange fetches all Ethereum 1 deposits made in the given Ethereum 1 block range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startBlock 2 and endBlock 4 will provide
+// deposits made in blocks 2 and 3.
+func (s *Service) ETH1DepositsForBlockRange(ctx context.Context, startBlock uint64, endBlock uint64) ([]*chaindb.ETH1Deposit, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_eth1_block_number
+            ,f_eth1_block_hash
+            ,f_eth1_block_timestamp
+            ,f_eth1_tx_hash
+            ,f_eth1_log_index
+            ,f_eth1_sender
+            ,f_eth1_recipient
+            ,f_eth1_gas_used
+            ,f_eth1_gas_price
+            ,f_deposit_index
+            ,f_validator_pubkey
+            ,f_withdrawal_credentials
+            ,f_signature
+            ,f_amount
+      FROM t_eth1_deposits
+      WHERE f_eth1_block_number >= $1
+        AND f_eth1_block_number < $2
+      ORDER BY f_eth1_block_number
+              ,f_eth1_log_index
+	  `,
+		startBlock,
+		endBlock,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for rows.Next() {
+		deposit, err := eth1DepositFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
-		copy(deposit.ValidatorPubKey[:], validatorPubKey)
-		copy(deposit.Signature[:], signature)
 		deposits = append(deposits, deposit)
 	}
 
 	return deposits, nil
 }
+
+// ETH1DepositTotalsByDay provides the number and total amount of Ethereum 1 deposits for each UTC day
+// in the given time range.
+// Ranges are inclusive of start and exclusive of end.  Days without deposits are not returned.
+func (s *Service) ETH1DepositTotalsByDay(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.ETH1DepositDayTotal, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT DATE_TRUNC('day', f_eth1_block_timestamp AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS f_day
+            ,COUNT(*)
+            ,SUM(f_amount)::BIGINT
+      FROM t_eth1_deposits
+      WHERE f_eth1_block_timestamp >= $1
+        AND f_eth1_block_timestamp < $2
+      GROUP BY f_day
+      ORDER BY f_day
+	  `,
+		start,
+		end,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]*chaindb.ETH1DepositDayTotal, 0)
+	for rows.Next() {
+		total := &chaindb.ETH1DepositDayTotal{}
+		err := rows.Scan(
+			&total.Day,
+			&total.Deposits,
+			&total.Amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		total.Day = total.Day.UTC()
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
+// ETH1DepositTotalsBySender provides the number and total amount of Ethereum 1 deposits for each sender
+// in the given time range, ordered by decreasing amount.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ETH1DepositTotalsBySender(ctx context.Context, start time.Time, end time.Time) ([]*chaindb.ETH1DepositSenderTotal, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_eth1_sender
+            ,COUNT(*)
+            ,SUM(f_amount)::BIGINT AS f_total
+      FROM t_eth1_deposits
+      WHERE f_eth1_block_timestamp >= $1
+        AND f_eth1_block_timestamp < $2
+      GROUP BY f_eth1_sender
+      ORDER BY f_total DESC
+              ,f_eth1_sender
+	  `,
+		start,
+		end,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]*chaindb.ETH1DepositSenderTotal, 0)
+	for rows.Next() {
+		total := &chaindb.ETH1DepositSenderTotal{}
+		err := rows.Scan(
+			&total.Sender,
+			&total.Deposits,
+			&total.Amount,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
+// eth1DepositFromRow converts a SQL row in to an Ethereum 1 deposit.
+func eth1DepositFromRow(rows pgx.Rows) (*chaindb.ETH1Deposit, error) {
+	deposit := &chaindb.ETH1Deposit{}
+	var validatorPubKey []byte
+	var signature []byte
+	err := rows.Scan(
+		&deposit.ETH1BlockNumber,
+		&deposit.ETH1BlockHash,
+		&deposit.ETH1BlockTimestamp,
+		&deposit.ETH1TxHash,
+		&deposit.ETH1LogIndex,
+		&deposit.ETH1Sender,
+		&deposit.ETH1Recipient,
+		&deposit.ETH1GasUsed,
+		&deposit.ETH1GasPrice,
+		&deposit.DepositIndex,
+		&validatorPubKey,
+		&deposit.WithdrawalCredentials,
+		&signature,
+		&deposit.Amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	copy(deposit.ValidatorPubKey[:], validatorPubKey)
+	copy(deposit.Signature[:], signature)
+
+	return deposit, nil
+}
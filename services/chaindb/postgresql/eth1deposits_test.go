@@ -227,4 +227,33 @@ func TestETH1Deposits(t *testing.T) {
 	})
 	require.NoError(t, err)
 	require.Len(t, deposits, 2)
+
+	// Fetch the deposits by block range.
+	deposits, err = s.ETH1DepositsForBlockRange(ctx, 100, 200)
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	require.Equal(t, eth1Deposit.DepositIndex, deposits[0].DepositIndex)
+
+	deposits, err = s.ETH1DepositsForBlockRange(ctx, 123, 457)
+	require.NoError(t, err)
+	require.Len(t, deposits, 2)
+
+	// Fetch totals.
+	dayTotals, err := s.ETH1DepositTotalsByDay(ctx, time.Unix(1590000000, 0), time.Unix(1590000001, 0))
+	require.NoError(t, err)
+	require.Len(t, dayTotals, 1)
+	require.Equal(t, time.Date(2020, 5, 20, 0, 0, 0, 0, time.UTC), dayTotals[0].Day)
+	require.Equal(t, 1, dayTotals[0].Deposits)
+	require.Equal(t, phase0.Gwei(32000000000), dayTotals[0].Amount)
+
+	senderTotals, err := s.ETH1DepositTotalsBySender(ctx, time.Unix(1590000000, 0), time.Unix(1600000001, 0))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(senderTotals), 2)
+
+	// Delete the later deposit.
+	require.NoError(t, s.DeleteETH1Deposits(ctx, 124, 456))
+	deposits, err = s.ETH1DepositsForBlockRange(ctx, 123, 457)
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	require.Equal(t, eth1Deposit.DepositIndex, deposits[0].DepositIndex)
 }
@@ -98,6 +98,7 @@ SET f_block_number = excluded.f_block_number
 		block.ExecutionPayload.Timestamp,
 		extraData,
 	)
+	s.monitorWrite("t_block_execution_payloads", 1, err)
 
 	return err
 }
@@ -0,0 +1,223 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// backfillForeignKeyModes are the ways in which foreign key checks can be carried out during backfill.
+var backfillForeignKeyModes = map[string]bool{
+	// immediate checks foreign keys as each row is written, as normal.
+	"immediate": true,
+	// deferred checks foreign keys when each transaction commits.
+	"deferred": true,
+	// dropped removes foreign keys for the duration of the backfill, then restores and validates them.
+	// Foreign keys that cascade deletes are left in place, as data is removed through them.
+	"dropped": true,
+}
+
+// backfillForeignKeysKey is the metadata key for foreign keys altered for backfill.
+// This is stored so that the foreign keys can be restored if chaind stops before the backfill ends.
+const backfillForeignKeysKey = "chaindb.backfill.foreign_keys"
+
+// backfillForeignKeys are the foreign keys altered for backfill.
+type backfillForeignKeys struct {
+	Mode        string        `json:"mode"`
+	ForeignKeys []*foreignKey `json:"foreign_keys"`
+}
+
+// foreignKey is a foreign key constraint.
+type foreignKey struct {
+	Table      string `json:"table"`
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+	// Cascades is true if the foreign key removes or alters rows when the row it references is removed.
+	Cascades bool `json:"-"`
+}
+
+// relaxForeignKeys defers or drops foreign keys according to the backfill foreign key mode.
+func (s *Service) relaxForeignKeys(ctx context.Context) error {
+	if s.backfillForeignKeys == "immediate" {
+		return nil
+	}
+
+	ctx, cancel, err := s.beginUpgradeTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	tx := s.tx(ctx)
+
+	allForeignKeys, err := s.foreignKeys(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	foreignKeys := make([]*foreignKey, 0, len(allForeignKeys))
+	for _, fk := range allForeignKeys {
+		if s.backfillForeignKeys == "dropped" && fk.Cascades {
+			// Deletes of blocks and slots rely on cascading to remove dependent rows.
+			continue
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	for _, fk := range foreignKeys {
+		var stmt string
+		switch s.backfillForeignKeys {
+		case "deferred":
+			stmt = fmt.Sprintf("ALTER TABLE %s ALTER CONSTRAINT %s DEFERRABLE INITIALLY DEFERRED", fk.Table, pgx.Identifier{fk.Name}.Sanitize())
+		case "dropped":
+			stmt = fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", fk.Table, pgx.Identifier{fk.Name}.Sanitize())
+		}
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			cancel()
+			return errors.Wrap(err, fmt.Sprintf("failed to alter foreign key %s on %s", fk.Name, fk.Table))
+		}
+	}
+
+	data, err := json.Marshal(&backfillForeignKeys{
+		Mode:        s.backfillForeignKeys,
+		ForeignKeys: foreignKeys,
+	})
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to marshal foreign keys")
+	}
+	if err := s.SetMetadata(ctx, backfillForeignKeysKey, data); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set foreign keys metadata")
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	s.log.Info().Str("mode", s.backfillForeignKeys).Int("foreign_keys", len(foreignKeys)).Msg("Relaxed foreign keys for backfill")
+
+	return nil
+}
+
+// restoreForeignKeys restores foreign keys that were altered for backfill, if any.
+// Dropped foreign keys are recreated without checking existing rows, and then validated separately,
+// so that writes are not blocked whilst validation takes place.
+func (s *Service) restoreForeignKeys(ctx context.Context) error {
+	data, err := s.Metadata(ctx, backfillForeignKeysKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain foreign keys metadata")
+	}
+	if data == nil {
+		// Nothing to restore.
+		return nil
+	}
+	altered := &backfillForeignKeys{}
+	if err := json.Unmarshal(data, altered); err != nil {
+		return errors.Wrap(err, "failed to unmarshal foreign keys metadata")
+	}
+
+	txCtx, cancel, err := s.beginUpgradeTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	tx := s.tx(txCtx)
+
+	for _, fk := range altered.ForeignKeys {
+		var stmt string
+		switch altered.Mode {
+		case "deferred":
+			stmt = fmt.Sprintf("ALTER TABLE %s ALTER CONSTRAINT %s NOT DEFERRABLE", fk.Table, pgx.Identifier{fk.Name}.Sanitize())
+		case "dropped":
+			stmt = fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s NOT VALID", fk.Table, pgx.Identifier{fk.Name}.Sanitize(), fk.Definition)
+		default:
+			cancel()
+			return fmt.Errorf("unknown foreign key mode %q", altered.Mode)
+		}
+		if _, err := tx.Exec(txCtx, stmt); err != nil {
+			cancel()
+			return errors.Wrap(err, fmt.Sprintf("failed to restore foreign key %s on %s", fk.Name, fk.Table))
+		}
+	}
+
+	if _, err := tx.Exec(txCtx, "DELETE FROM t_metadata WHERE f_key = $1", backfillForeignKeysKey); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to remove foreign keys metadata")
+	}
+
+	if err := s.CommitTx(txCtx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	s.log.Info().Str("mode", altered.Mode).Int("foreign_keys", len(altered.ForeignKeys)).Msg("Restored foreign keys after backfill")
+
+	if altered.Mode != "dropped" {
+		return nil
+	}
+
+	// Validation only takes a lock that allows concurrent writes, so runs outside of a transaction.
+	invalid := 0
+	for _, fk := range altered.ForeignKeys {
+		s.log.Info().Str("table", fk.Table).Str("foreign_key", fk.Name).Msg("Validating foreign key")
+		if _, err := s.pool.Exec(ctx, fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", fk.Table, pgx.Identifier{fk.Name}.Sanitize())); err != nil {
+			// The constraint remains in place for new rows, so carry on with the others.
+			s.log.Error().Str("table", fk.Table).Str("foreign_key", fk.Name).Err(err).Msg("Failed to validate foreign key")
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d foreign keys failed validation", invalid)
+	}
+
+	return nil
+}
+
+// foreignKeys returns the non-deferrable foreign keys in the current schema.
+// This requires the context to hold an active transaction.
+func (s *Service) foreignKeys(ctx context.Context) ([]*foreignKey, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT conrelid::regclass::TEXT
+      ,conname
+      ,pg_get_constraintdef(oid)
+      ,confdeltype NOT IN ('a','r')
+FROM pg_constraint
+WHERE contype = 'f'
+  AND NOT condeferrable
+  AND connamespace = (SELECT oid FROM pg_namespace WHERE nspname = current_schema())
+ORDER BY conrelid::regclass::TEXT, conname
+`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain foreign keys")
+	}
+	defer rows.Close()
+
+	foreignKeys := make([]*foreignKey, 0)
+	for rows.Next() {
+		fk := &foreignKey{}
+		if err := rows.Scan(&fk.Table, &fk.Name, &fk.Definition, &fk.Cascades); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		foreignKeys = append(foreignKeys, fk)
+	}
+
+	return foreignKeys, nil
+}
@@ -32,9 +32,11 @@ func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork)
       TRUNCATE TABLE t_fork_schedule
     `)
 	if err != nil {
+		s.monitorWriteFailure("t_fork_schedule")
 		return err
 	}
 
+	rows := 0
 	for i, fork := range schedule {
 		if i == 0 && fork.Epoch != 0 {
 			// Create a synthetic genesis fork.
@@ -48,8 +50,10 @@ func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork)
 				fork.PreviousVersion[:],
 			)
 			if err != nil {
+				s.monitorWriteFailure("t_fork_schedule")
 				return err
 			}
+			rows++
 		}
 		_, err := tx.Exec(ctx, `
       INSERT INTO t_fork_schedule(f_epoch
@@ -61,11 +65,14 @@ func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork)
 			fork.CurrentVersion[:],
 		)
 		if err != nil {
+			s.monitorWriteFailure("t_fork_schedule")
 			return err
 		}
+		rows++
 	}
+	s.monitorRowsWritten("t_fork_schedule", rows)
 
-	return err
+	return nil
 }
 
 // ForkSchedule provides details of past and future changes in the chain's fork version.
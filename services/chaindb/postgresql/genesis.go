@@ -42,6 +42,7 @@ func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
 		genesis.GenesisTime,
 		genesis.GenesisForkVersion[:],
 	)
+	s.monitorWrite("t_genesis", 1, err)
 
 	return err
 }
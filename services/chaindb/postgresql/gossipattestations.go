@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetGossipAttestations records attestations seen on the network.  If an attestation
+// has already been recorded then the earlier arrival is kept.
+func (s *Service) SetGossipAttestations(ctx context.Context, attestations []*chaindb.GossipAttestation) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	slots := make([]int64, len(attestations))
+	committeeIndices := make([]int64, len(attestations))
+	aggregationBits := make([][]byte, len(attestations))
+	beaconBlockRoots := make([][]byte, len(attestations))
+	sourceEpochs := make([]int64, len(attestations))
+	sourceRoots := make([][]byte, len(attestations))
+	targetEpochs := make([]int64, len(attestations))
+	targetRoots := make([][]byte, len(attestations))
+	arrivalTimes := make([]time.Time, len(attestations))
+	for i, attestation := range attestations {
+		slots[i] = int64(attestation.Slot)
+		committeeIndices[i] = int64(attestation.CommitteeIndex)
+		aggregationBits[i] = attestation.AggregationBits
+		beaconBlockRoots[i] = attestation.BeaconBlockRoot[:]
+		sourceEpochs[i] = int64(attestation.SourceEpoch)
+		sourceRoots[i] = attestation.SourceRoot[:]
+		targetEpochs[i] = int64(attestation.TargetEpoch)
+		targetRoots[i] = attestation.TargetRoot[:]
+		arrivalTimes[i] = attestation.ArrivalTime
+	}
+
+	// Attestations are supplied in order of arrival, so the first of any duplicates is kept.
+	tag, err := tx.Exec(ctx, `
+INSERT INTO t_gossip_attestations(f_slot
+                                 ,f_committee_index
+                                 ,f_aggregation_bits
+                                 ,f_beacon_block_root
+                                 ,f_source_epoch
+                                 ,f_source_root
+                                 ,f_target_epoch
+                                 ,f_target_root
+                                 ,f_arrival_time)
+SELECT * FROM UNNEST($1::BIGINT[],$2::BIGINT[],$3::BYTEA[],$4::BYTEA[],$5::BIGINT[],$6::BYTEA[],$7::BIGINT[],$8::BYTEA[],$9::TIMESTAMPTZ[])
+ON CONFLICT (f_slot,f_committee_index,f_aggregation_bits,f_beacon_block_root,f_source_root,f_target_root) DO NOTHING
+`,
+		slots,
+		committeeIndices,
+		aggregationBits,
+		beaconBlockRoots,
+		sourceEpochs,
+		sourceRoots,
+		targetEpochs,
+		targetRoots,
+		arrivalTimes,
+	)
+	if err != nil {
+		s.monitorWriteFailure("t_gossip_attestations")
+		return err
+	}
+	s.monitorRowsWritten("t_gossip_attestations", int(tag.RowsAffected()))
+
+	return nil
+}
+
+// GossipAttestations fetches the attestations seen on the network for the given slot range.
+// Ranges are inclusive of start and end.
+func (s *Service) GossipAttestations(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.GossipAttestation, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_committee_index
+            ,f_aggregation_bits
+            ,f_beacon_block_root
+            ,f_source_epoch
+            ,f_source_root
+            ,f_target_epoch
+            ,f_target_root
+            ,f_arrival_time
+      FROM t_gossip_attestations
+      WHERE f_slot >= $1
+        AND f_slot <= $2
+      ORDER BY f_slot
+              ,f_committee_index
+              ,f_arrival_time`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.GossipAttestation, 0)
+	for rows.Next() {
+		attestation := &chaindb.GossipAttestation{}
+		var beaconBlockRoot []byte
+		var sourceRoot []byte
+		var targetRoot []byte
+		err := rows.Scan(
+			&attestation.Slot,
+			&attestation.CommitteeIndex,
+			&attestation.AggregationBits,
+			&beaconBlockRoot,
+			&attestation.SourceEpoch,
+			&sourceRoot,
+			&attestation.TargetEpoch,
+			&targetRoot,
+			&attestation.ArrivalTime,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
+		copy(attestation.SourceRoot[:], sourceRoot)
+		copy(attestation.TargetRoot[:], targetRoot)
+		attestations = append(attestations, attestation)
+	}
+
+	return attestations, nil
+}
@@ -38,6 +38,7 @@ func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) err
 		key,
 		value,
 	)
+	s.monitorWrite("t_metadata", 1, err)
 
 	return err
 }
@@ -0,0 +1,179 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetMissedSlot marks a slot as not having a block.
+func (s *Service) SetMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// The proposer index is taken from proposer duties if they are present; if not, it is
+	// filled in when the duties are set.
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_missed_slots(f_slot
+                                ,f_proposer_index)
+      VALUES($1,(SELECT f_validator_index FROM t_proposer_duties WHERE f_slot = $1))
+      ON CONFLICT (f_slot) DO
+      UPDATE
+      SET f_proposer_index = COALESCE(excluded.f_proposer_index, t_missed_slots.f_proposer_index)
+		 `,
+		slot,
+	)
+	s.monitorWrite("t_missed_slots", 1, err)
+
+	return err
+}
+
+// MissedSlots fetches the missed slots in the given range.
+// Ranges are inclusive of start and end.
+func (s *Service) MissedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.MissedSlot, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_proposer_index
+      FROM t_missed_slots
+      WHERE f_slot >= $1
+        AND f_slot <= $2
+      ORDER BY f_slot`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missedSlots := make([]*chaindb.MissedSlot, 0)
+	for rows.Next() {
+		missedSlot := &chaindb.MissedSlot{}
+		var proposerIndex sql.NullInt64
+		err := rows.Scan(
+			&missedSlot.Slot,
+			&proposerIndex,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if proposerIndex.Valid {
+			index := phase0.ValidatorIndex(proposerIndex.Int64)
+			missedSlot.ProposerIndex = &index
+		}
+		missedSlots = append(missedSlots, missedSlot)
+	}
+
+	return missedSlots, nil
+}
+
+// UnaccountedSlots fetches the slots in the given range that have neither a block nor
+// a missed slot marker in the database.
+// Ranges are inclusive of start and end.
+func (s *Service) UnaccountedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT slot
+      FROM generate_series($1::BIGINT,$2::BIGINT,1) slot
+      WHERE NOT EXISTS (SELECT 1 FROM t_blocks WHERE t_blocks.f_slot = slot)
+        AND NOT EXISTS (SELECT 1 FROM t_missed_slots WHERE t_missed_slots.f_slot = slot)
+      ORDER BY slot`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := make([]phase0.Slot, 0)
+	for rows.Next() {
+		slot := phase0.Slot(0)
+		err := rows.Scan(&slot)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, nil
+}
+
+// DeleteSlotData deletes blocks, along with their contents, and missed slot markers in the given slot range.
+// Blocks known to be non-canonical are retained.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) DeleteSlotData(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Block contents are removed by cascade.
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_blocks
+WHERE f_slot >= $1
+  AND f_slot < $2
+  AND f_canonical IS NOT FALSE
+`,
+		startSlot,
+		endSlot,
+	); err != nil {
+		s.monitorWriteFailure("t_blocks")
+		return errors.Wrap(err, "failed to delete blocks")
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_missed_slots
+WHERE f_slot >= $1
+  AND f_slot < $2
+`,
+		startSlot,
+		endSlot,
+	); err != nil {
+		s.monitorWriteFailure("t_missed_slots")
+		return errors.Wrap(err, "failed to delete missed slots")
+	}
+
+	return nil
+}
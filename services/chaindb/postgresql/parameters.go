@@ -15,12 +15,20 @@ package postgresql
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/cache"
+	"github.com/wealdtech/chaind/services/filter"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/secrets"
 )
 
 type parameters struct {
 	logLevel       zerolog.Level
+	network        string
+	monitor        metrics.Service
 	connectionURL  string
 	server         string
 	port           int32
@@ -30,6 +38,17 @@ type parameters struct {
 	clientKey      []byte
 	caCert         []byte
 	maxConnections uint
+	storageProfile string
+	schema         string
+	cache          cache.Service
+	filter         filter.Service
+	secrets        secrets.Service
+	// Transaction behavior for ingestion and upgrade transactions.
+	isolationLevel        string
+	lockTimeout           time.Duration
+	upgradeIsolationLevel string
+	upgradeLockTimeout    time.Duration
+	backfillForeignKeys   string
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +69,20 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
 // WithConnectionURL sets the connection URL for this module.
 // Deprecated.  Use the individual Server/User/Port/... functions.
 func WithConnectionURL(connectionURL string) Parameter {
@@ -114,11 +147,88 @@ func WithMaxConnections(maxConnections uint) Parameter {
 	})
 }
 
+// WithSchema sets the schema in which to store data, allowing multiple chains to share a database.
+func WithSchema(schema string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.schema = schema
+	})
+}
+
+// WithStorageProfile sets the profile of table storage parameters applied by the upgrader.
+func WithStorageProfile(storageProfile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storageProfile = storageProfile
+	})
+}
+
+// WithCache sets the cache for frequently-read values.
+func WithCache(cache cache.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.cache = cache
+	})
+}
+
+// WithFilter sets the filter that decides which attestations, balances and summaries are stored.
+func WithFilter(filter filter.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.filter = filter
+	})
+}
+
+// WithSecrets sets the secrets service used to resolve the connection URL and
+// password, if they refer to secrets.
+func WithSecrets(secrets secrets.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.secrets = secrets
+	})
+}
+
+// WithIsolationLevel sets the isolation level of transactions that write chain data.
+func WithIsolationLevel(isolationLevel string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.isolationLevel = isolationLevel
+	})
+}
+
+// WithLockTimeout sets the maximum time that transactions that write chain data wait for a lock.
+// 0 waits indefinitely.
+func WithLockTimeout(lockTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lockTimeout = lockTimeout
+	})
+}
+
+// WithUpgradeIsolationLevel sets the isolation level of schema initialization and upgrade transactions.
+func WithUpgradeIsolationLevel(isolationLevel string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.upgradeIsolationLevel = isolationLevel
+	})
+}
+
+// WithUpgradeLockTimeout sets the maximum time that schema initialization and upgrade transactions wait for a lock.
+// 0 waits indefinitely.
+func WithUpgradeLockTimeout(lockTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.upgradeLockTimeout = lockTimeout
+	})
+}
+
+// WithBackfillForeignKeys sets how foreign keys are checked whilst backfilling.
+func WithBackfillForeignKeys(mode string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backfillForeignKeys = mode
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:       zerolog.GlobalLevel(),
-		maxConnections: 16,
+		logLevel:              zerolog.GlobalLevel(),
+		maxConnections:        16,
+		storageProfile:        "standard",
+		isolationLevel:        "read committed",
+		upgradeIsolationLevel: "read committed",
+		backfillForeignKeys:   "immediate",
 	}
 	for _, p := range params {
 		if params != nil {
@@ -126,6 +236,25 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	if _, exists := storageProfiles[parameters.storageProfile]; !exists {
+		return nil, fmt.Errorf("unknown storage profile %q", parameters.storageProfile)
+	}
+	if _, exists := isolationLevels[parameters.isolationLevel]; !exists {
+		return nil, fmt.Errorf("unknown isolation level %q", parameters.isolationLevel)
+	}
+	if parameters.lockTimeout < 0 {
+		return nil, errors.New("lock timeout cannot be negative")
+	}
+	if _, exists := isolationLevels[parameters.upgradeIsolationLevel]; !exists {
+		return nil, fmt.Errorf("unknown upgrade isolation level %q", parameters.upgradeIsolationLevel)
+	}
+	if parameters.upgradeLockTimeout < 0 {
+		return nil, errors.New("upgrade lock timeout cannot be negative")
+	}
+	if !backfillForeignKeyModes[parameters.backfillForeignKeys] {
+		return nil, fmt.Errorf("unknown backfill foreign key mode %q", parameters.backfillForeignKeys)
+	}
+
 	if parameters.connectionURL != "" {
 		// Allow deprecated connection URL.
 		return &parameters, nil
@@ -0,0 +1,119 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetPeerCounts records peer counts.
+func (s *Service) SetPeerCounts(ctx context.Context, counts []*chaindb.PeerCount) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	timestamps := make([]time.Time, len(counts))
+	epochs := make([]int64, len(counts))
+	clients := make([]string, len(counts))
+	versions := make([]string, len(counts))
+	peers := make([]int64, len(counts))
+	for i, count := range counts {
+		timestamps[i] = count.Timestamp
+		epochs[i] = int64(count.Epoch)
+		clients[i] = count.Client
+		versions[i] = count.Version
+		peers[i] = int64(count.Count)
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_peer_counts(f_timestamp
+                         ,f_epoch
+                         ,f_client
+                         ,f_version
+                         ,f_count)
+SELECT * FROM UNNEST($1::TIMESTAMPTZ[],$2::BIGINT[],$3::TEXT[],$4::TEXT[],$5::INTEGER[])
+ON CONFLICT (f_timestamp,f_client,f_version) DO
+UPDATE
+SET f_epoch = excluded.f_epoch
+   ,f_count = excluded.f_count
+`,
+		timestamps,
+		epochs,
+		clients,
+		versions,
+		peers,
+	)
+	s.monitorWrite("t_peer_counts", len(counts), err)
+
+	return err
+}
+
+// PeerCounts fetches the peer counts taken in the given time range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) PeerCounts(ctx context.Context, from time.Time, to time.Time) ([]*chaindb.PeerCount, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_timestamp
+            ,f_epoch
+            ,f_client
+            ,f_version
+            ,f_count
+      FROM t_peer_counts
+      WHERE f_timestamp >= $1
+        AND f_timestamp < $2
+      ORDER BY f_timestamp
+              ,f_client
+              ,f_version`,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]*chaindb.PeerCount, 0)
+	for rows.Next() {
+		count := &chaindb.PeerCount{}
+		err := rows.Scan(
+			&count.Timestamp,
+			&count.Epoch,
+			&count.Client,
+			&count.Version,
+			&count.Count,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestPeerCounts(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	timestamp := time.Unix(1600000000, 0).UTC()
+	counts := []*chaindb.PeerCount{
+		{
+			Timestamp: timestamp,
+			Epoch:     10,
+			Client:    "lighthouse",
+			Version:   "v3.1.0",
+			Count:     20,
+		},
+		{
+			Timestamp: timestamp,
+			Epoch:     10,
+			Client:    "prysm",
+			Version:   "v3.1.1",
+			Count:     30,
+		},
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetPeerCounts(ctx, counts), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetPeerCounts(ctx, counts))
+
+	// Setting a count again replaces it.
+	updated := *counts[0]
+	updated.Count = 25
+	require.NoError(t, s.SetPeerCounts(ctx, []*chaindb.PeerCount{&updated}))
+
+	fetched, err := s.PeerCounts(ctx, timestamp, timestamp.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, fetched, 2)
+	require.Equal(t, "lighthouse", fetched[0].Client)
+	require.Equal(t, uint32(25), fetched[0].Count)
+	require.Equal(t, "prysm", fetched[1].Client)
+
+	// End of range is exclusive.
+	fetched, err = s.PeerCounts(ctx, timestamp.Add(-time.Second), timestamp)
+	require.NoError(t, err)
+	require.Len(t, fetched, 0)
+}
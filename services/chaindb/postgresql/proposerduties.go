@@ -30,15 +30,35 @@ func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.Pro
 
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_proposer_duties(f_slot
-                                   ,f_validator_index)
-      VALUES($1,$2)
+                                   ,f_validator_index
+                                   ,f_epoch)
+      VALUES($1,$2,$1::BIGINT / slots_per_epoch())
       ON CONFLICT (f_slot) DO
       UPDATE
       SET f_validator_index = excluded.f_validator_index
+         ,f_epoch = excluded.f_epoch
 		 `,
 		proposerDuty.Slot,
 		proposerDuty.ValidatorIndex,
 	)
+	if err != nil {
+		s.monitorWriteFailure("t_proposer_duties")
+		return err
+	}
+	s.monitorRowsWritten("t_proposer_duties", 1)
+
+	// The slot may already have been marked as missed without a proposer.
+	_, err = tx.Exec(ctx, `
+      UPDATE t_missed_slots
+      SET f_proposer_index = $2
+      WHERE f_slot = $1
+		 `,
+		proposerDuty.Slot,
+		proposerDuty.ValidatorIndex,
+	)
+	if err != nil {
+		s.monitorWriteFailure("t_missed_slots")
+	}
 
 	return err
 }
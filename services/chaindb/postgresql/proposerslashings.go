@@ -83,6 +83,7 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 		proposerSlashing.Header2BodyRoot[:],
 		proposerSlashing.Header2Signature[:],
 	)
+	s.monitorWrite("t_proposer_slashings", 1, err)
 
 	return err
 }
@@ -0,0 +1,102 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// prunableTable is a table that can be pruned by age.
+type prunableTable struct {
+	name string
+	// column is the column that holds the slot or epoch of each row.
+	column string
+	// slot is true if column holds a slot, and false if it holds an epoch.
+	slot bool
+}
+
+// prunableTables are the tables that can be pruned.  The tables that describe the
+// validator set are not prunable, as later data depends on them.  Validator summaries
+// are pruned by the summarizer.  Pruning blocks also removes the data included in
+// them.
+var prunableTables = []*prunableTable{
+	{name: "t_attestations", column: "f_inclusion_slot", slot: true},
+	{name: "t_beacon_committees", column: "f_slot", slot: true},
+	{name: "t_block_arrivals", column: "f_slot", slot: true},
+	{name: "t_block_clients", column: "f_slot", slot: true},
+	{name: "t_block_summaries", column: "f_slot", slot: true},
+	{name: "t_blocks", column: "f_slot", slot: true},
+	{name: "t_epoch_summaries", column: "f_epoch"},
+	{name: "t_gossip_attestations", column: "f_slot", slot: true},
+	{name: "t_missed_slots", column: "f_slot", slot: true},
+	{name: "t_peer_counts", column: "f_epoch"},
+	{name: "t_proposer_duties", column: "f_slot", slot: true},
+	{name: "t_sync_aggregates", column: "f_inclusion_slot", slot: true},
+	{name: "t_validator_balances", column: "f_epoch"},
+	{name: "t_validator_effectiveness", column: "f_epoch"},
+	{name: "t_validator_income", column: "f_epoch"},
+}
+
+// PrunableTables provides the names of the tables that can be pruned.
+func (*Service) PrunableTables(_ context.Context) ([]string, error) {
+	tables := make([]string, len(prunableTables))
+	for i := range prunableTables {
+		tables[i] = prunableTables[i].name
+	}
+
+	return tables, nil
+}
+
+// PruneTable removes the rows of the given table for epochs up to (but not including) the given epoch,
+// which starts at the given slot, returning the number of rows removed.
+func (s *Service) PruneTable(ctx context.Context, name string, epoch phase0.Epoch, slot phase0.Slot) (int64, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return 0, ErrNoTransaction
+	}
+
+	var table *prunableTable
+	for i := range prunableTables {
+		if prunableTables[i].name == name {
+			table = prunableTables[i]
+			break
+		}
+	}
+	if table == nil {
+		return 0, fmt.Errorf("unknown prunable table %q", name)
+	}
+
+	if table.name == "t_validator_balances" {
+		// Runs can hold balances up to the end of their interval, so only prune whole intervals.
+		epoch = balanceRunStart(epoch)
+	}
+
+	var to interface{} = epoch
+	if table.slot {
+		to = slot
+	}
+
+	// Table and column names are from the fixed list above, so are safe to interpolate.
+	tag, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s < $1", table.name, table.column), to)
+	if err != nil {
+		s.monitorWriteFailure(table.name)
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to prune %s", table.name))
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetQuarantinedItem records data that failed to be stored, replacing any item already
+// quarantined by the same module for the same slot.
+func (s *Service) SetQuarantinedItem(ctx context.Context, item *chaindb.QuarantinedItem) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var root []byte
+	if item.Root != nil {
+		root = item.Root[:]
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_quarantine(f_module
+                        ,f_slot
+                        ,f_root
+                        ,f_timestamp
+                        ,f_reason
+                        ,f_data)
+VALUES($1,$2,$3,$4,$5,$6)
+ON CONFLICT (f_module,f_slot) DO
+UPDATE
+SET f_root = excluded.f_root
+   ,f_timestamp = excluded.f_timestamp
+   ,f_reason = excluded.f_reason
+   ,f_data = excluded.f_data
+`,
+		item.Module,
+		item.Slot,
+		root,
+		item.Timestamp,
+		item.Reason,
+		item.Data,
+	)
+	s.monitorWrite("t_quarantine", 1, err)
+
+	return err
+}
+
+// DeleteQuarantinedItems removes the quarantined items of a module for the given slots.
+func (s *Service) DeleteQuarantinedItems(ctx context.Context, module string, slots []phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	dbSlots := make([]int64, len(slots))
+	for i, slot := range slots {
+		dbSlots[i] = int64(slot)
+	}
+
+	_, err := tx.Exec(ctx, `
+DELETE FROM t_quarantine
+WHERE f_module = $1
+  AND f_slot = ANY($2)
+`,
+		module,
+		dbSlots,
+	)
+
+	return err
+}
+
+// QuarantinedItems fetches the quarantined items of the given module, ordered by slot.
+// If module is empty then the quarantined items of all modules are returned.
+func (s *Service) QuarantinedItems(ctx context.Context, module string) ([]*chaindb.QuarantinedItem, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_module
+            ,f_slot
+            ,f_root
+            ,f_timestamp
+            ,f_reason
+            ,f_data
+      FROM t_quarantine
+      WHERE ($1 = '' OR f_module = $1)
+      ORDER BY f_slot
+              ,f_module`,
+		module,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*chaindb.QuarantinedItem, 0)
+	for rows.Next() {
+		item := &chaindb.QuarantinedItem{}
+		var root []byte
+		err := rows.Scan(
+			&item.Module,
+			&item.Slot,
+			&root,
+			&item.Timestamp,
+			&item.Reason,
+			&item.Data,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if root != nil {
+			item.Root = &phase0.Root{}
+			copy(item.Root[:], root)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
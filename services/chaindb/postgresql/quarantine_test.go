@@ -0,0 +1,72 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestQuarantine(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	item := &chaindb.QuarantinedItem{
+		Module:    "blocks",
+		Slot:      12345,
+		Root:      &phase0.Root{0x01},
+		Timestamp: time.Unix(1663000000, 0),
+		Reason:    "failed to set block",
+		Data:      []byte(`{"version":"bellatrix"}`),
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetQuarantinedItem(ctx, item), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetQuarantinedItem(ctx, item))
+	// Quarantining the same slot again replaces the item.
+	item.Reason = "failed to set attestation"
+	require.NoError(t, s.SetQuarantinedItem(ctx, item))
+
+	items, err := s.QuarantinedItems(ctx, "blocks")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "failed to set attestation", items[0].Reason)
+	require.Equal(t, item.Root, items[0].Root)
+
+	items, err = s.QuarantinedItems(ctx, "other")
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+
+	require.NoError(t, s.DeleteQuarantinedItems(ctx, "blocks", []phase0.Slot{12345}))
+	items, err = s.QuarantinedItems(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, items, 0)
+}
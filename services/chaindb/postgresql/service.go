@@ -16,7 +16,6 @@ package postgresql
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"strings"
 
@@ -26,16 +25,33 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/cache"
+	"github.com/wealdtech/chaind/services/filter"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 )
 
 // Service is a chain database service.
 type Service struct {
-	pool *pgxpool.Pool
+	log            zerolog.Logger
+	metrics        *serviceMetrics
+	pool           *pgxpool.Pool
+	storageProfile string
+	cache          cache.Service
+	// filter decides which entities are stored; nil stores everything.
+	filter filter.Service
+	// activeTxs is the number of active read-write transactions.
+	activeTxs int32
+	// Transaction behavior for ingestion and upgrade transactions.
+	txOptions        *txOptions
+	upgradeTxOptions *txOptions
+	// backfillForeignKeys is how foreign keys are checked whilst backfilling.
+	backfillForeignKeys string
 }
 
-// module-wide log.
-var log zerolog.Logger
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/chaindb/postgresql")
 
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
@@ -45,16 +61,46 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaindb").Str("impl", "postgresql").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "chaindb", "postgresql", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	connectionURL := parameters.connectionURL
+	password := parameters.password
+	if parameters.secrets != nil {
+		connectionURL, err = parameters.secrets.Resolve(ctx, connectionURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve connection URL")
+		}
+		password, err = parameters.secrets.Resolve(ctx, password)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to resolve password")
+		}
+	}
 
 	var pool *pgxpool.Pool
-	if parameters.connectionURL != "" {
+	if connectionURL != "" {
 		// Use deprecated connection URL method.
-		config, err := pgxpool.ParseConfig(parameters.connectionURL)
+		config, err := pgxpool.ParseConfig(connectionURL)
 		if err != nil {
 			return nil, errors.Wrap(err, "invalid connection URL")
 		}
 		config.MaxConns = int32(parameters.maxConnections)
+		setSchema(config, parameters.schema)
+		if parameters.caCert != nil || parameters.clientCert != nil {
+			// Certificates supplied separately from the URL replace any TLS
+			// configuration in the URL, and require TLS.
+			tlsConfig, err := util.TLSConfig(config.ConnConfig.Host, parameters.clientCert, parameters.clientKey, parameters.caCert)
+			if err != nil {
+				return nil, err
+			}
+			config.ConnConfig.TLSConfig = tlsConfig
+			config.ConnConfig.Fallbacks = nil
+		}
+		config.BeforeConnect = refreshCredentials(parameters.secrets, parameters.connectionURL, parameters.password)
 		pool, err = pgxpool.ConnectConfig(context.Background(), config)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to connect to database")
@@ -65,8 +111,8 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 			fmt.Sprintf("host=%s", parameters.server),
 			fmt.Sprintf("user=%s", parameters.user),
 		)
-		if parameters.password != "" {
-			dsnItems = append(dsnItems, fmt.Sprintf("password=%s", parameters.password))
+		if password != "" {
+			dsnItems = append(dsnItems, fmt.Sprintf("password=%s", password))
 		}
 		dsnItems = append(dsnItems, fmt.Sprintf("port=%d", parameters.port))
 
@@ -75,24 +121,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 			dsnItems = append(dsnItems, "sslmode=verify-full")
 
 			// Add TLS configuration.
-			tlsConfig = &tls.Config{
-				ServerName: parameters.server,
-				MinVersion: tls.VersionTLS13,
-			}
-			if parameters.clientCert != nil {
-				clientPair, err := tls.X509KeyPair(parameters.clientCert, parameters.clientKey)
-				if err != nil {
-					return nil, errors.Wrap(err, "failed to create client certificate")
-				}
-				tlsConfig.Certificates = []tls.Certificate{clientPair}
-			}
-			if parameters.caCert != nil {
-				rootCAs := x509.NewCertPool()
-				if !rootCAs.AppendCertsFromPEM(parameters.caCert) {
-					return nil, errors.New("failed to append root CA certificates")
-				}
-				tlsConfig.RootCAs = rootCAs
+			tlsConfig, err = util.TLSConfig(parameters.server, parameters.clientCert, parameters.clientKey, parameters.caCert)
+			if err != nil {
+				return nil, err
 			}
+			tlsConfig.MinVersion = tls.VersionTLS13
 		}
 
 		dsnItems = append(dsnItems, fmt.Sprintf("pool_max_conns=%d", parameters.maxConnections))
@@ -103,6 +136,8 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 		config.AfterConnect = registerCustomTypes
 		config.ConnConfig.TLSConfig = tlsConfig
+		setSchema(config, parameters.schema)
+		config.BeforeConnect = refreshCredentials(parameters.secrets, parameters.connectionURL, parameters.password)
 
 		pool, err = pgxpool.ConnectConfig(context.Background(), config)
 		if err != nil {
@@ -110,6 +145,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 	}
 
+	if parameters.schema != "" {
+		if _, err := pool.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{parameters.schema}.Sanitize())); err != nil {
+			pool.Close()
+			return nil, errors.Wrap(err, "failed to create schema")
+		}
+	}
+
 	go func() {
 		<-ctx.Done()
 		log.Trace().Msg("Context done; closing pool")
@@ -117,12 +159,67 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}()
 
 	s := &Service{
-		pool: pool,
+		log:            log,
+		metrics:        svcMetrics,
+		pool:           pool,
+		storageProfile: parameters.storageProfile,
+		cache:          parameters.cache,
+		filter:         parameters.filter,
+		txOptions: &txOptions{
+			isoLevel:    isolationLevels[parameters.isolationLevel],
+			lockTimeout: parameters.lockTimeout,
+		},
+		upgradeTxOptions: &txOptions{
+			isoLevel:    isolationLevels[parameters.upgradeIsolationLevel],
+			lockTimeout: parameters.upgradeLockTimeout,
+		},
+		backfillForeignKeys: parameters.backfillForeignKeys,
 	}
 
 	return s, nil
 }
 
+// refreshCredentials provides a hook that sets the credentials of each new
+// connection from the current values of any referenced secrets, so that rotated
+// credentials are used without a restart.  Existing connections are unaffected.
+func refreshCredentials(secretsSvc secrets.Service, connectionURL string, password string) func(context.Context, *pgx.ConnConfig) error {
+	if secretsSvc == nil || (!secrets.IsReference(connectionURL) && !secrets.IsReference(password)) {
+		return nil
+	}
+
+	return func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		if secrets.IsReference(connectionURL) {
+			url, err := secretsSvc.Resolve(ctx, connectionURL)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve connection URL")
+			}
+			config, err := pgx.ParseConfig(url)
+			if err != nil {
+				return errors.Wrap(err, "invalid connection URL")
+			}
+			connConfig.User = config.User
+			connConfig.Password = config.Password
+		}
+		if secrets.IsReference(password) {
+			resolved, err := secretsSvc.Resolve(ctx, password)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve password")
+			}
+			connConfig.Password = resolved
+		}
+
+		return nil
+	}
+}
+
+// setSchema sets the schema used by connections, if supplied.
+func setSchema(config *pgxpool.Config, schema string) {
+	if schema == "" {
+		return
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = pgx.Identifier{schema}.Sanitize()
+}
+
 // skipcq: RVV-B0012
 func registerCustomTypes(ctx context.Context, conn *pgx.Conn) error {
 	conn.ConnInfo().RegisterDataType(pgtype.DataType{
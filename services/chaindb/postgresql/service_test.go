@@ -17,6 +17,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,14 +27,42 @@ import (
 
 func TestService(t *testing.T) {
 	tests := []struct {
-		name          string
-		connectionURL string
-		err           string
+		name           string
+		connectionURL  string
+		storageProfile string
+		isolationLevel string
+		lockTimeout    time.Duration
+		foreignKeys    string
+		err            string
 	}{
 		{
 			name: "ServerMissing",
 			err:  "problem with parameters: no server specified",
 		},
+		{
+			name:           "StorageProfileUnknown",
+			connectionURL:  os.Getenv("CHAINDB_URL"),
+			storageProfile: "unknown",
+			err:            `problem with parameters: unknown storage profile "unknown"`,
+		},
+		{
+			name:           "IsolationLevelUnknown",
+			connectionURL:  os.Getenv("CHAINDB_URL"),
+			isolationLevel: "read uncommitted",
+			err:            `problem with parameters: unknown isolation level "read uncommitted"`,
+		},
+		{
+			name:          "LockTimeoutNegative",
+			connectionURL: os.Getenv("CHAINDB_URL"),
+			lockTimeout:   -1 * time.Second,
+			err:           "problem with parameters: lock timeout cannot be negative",
+		},
+		{
+			name:          "BackfillForeignKeysUnknown",
+			connectionURL: os.Getenv("CHAINDB_URL"),
+			foreignKeys:   "disabled",
+			err:           `problem with parameters: unknown backfill foreign key mode "disabled"`,
+		},
 		{
 			name:          "Good",
 			connectionURL: os.Getenv("CHAINDB_URL"),
@@ -43,9 +72,22 @@ func TestService(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.Background()
-			_, err := postgresql.New(ctx,
+			params := []postgresql.Parameter{
 				postgresql.WithConnectionURL(test.connectionURL),
-			)
+			}
+			if test.storageProfile != "" {
+				params = append(params, postgresql.WithStorageProfile(test.storageProfile))
+			}
+			if test.isolationLevel != "" {
+				params = append(params, postgresql.WithIsolationLevel(test.isolationLevel))
+			}
+			if test.lockTimeout != 0 {
+				params = append(params, postgresql.WithLockTimeout(test.lockTimeout))
+			}
+			if test.foreignKeys != "" {
+				params = append(params, postgresql.WithBackfillForeignKeys(test.foreignKeys))
+			}
+			_, err := postgresql.New(ctx, params...)
 			if test.err != "" {
 				assert.EqualError(t, err, test.err)
 			} else {
@@ -63,14 +105,35 @@ func TestInterfaces(t *testing.T) {
 	require.Implements(t, (*chaindb.Service)(nil), s)
 	require.Implements(t, (*chaindb.AttestationsProvider)(nil), s)
 	require.Implements(t, (*chaindb.AttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsStreamer)(nil), s)
+	require.Implements(t, (*chaindb.Backfiller)(nil), s)
+	require.Implements(t, (*chaindb.Drainer)(nil), s)
+	require.Implements(t, (*chaindb.GossipAttestationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.GossipAttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockClientsProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlockClientsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockRelaysSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorRegistrationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorRegistrationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.QuarantineProvider)(nil), s)
+	require.Implements(t, (*chaindb.QuarantineSetter)(nil), s)
+	require.Implements(t, (*chaindb.WriteErrorClassifier)(nil), s)
+	require.Implements(t, (*chaindb.ETH1DepositsDeleter)(nil), s)
+	require.Implements(t, (*chaindb.TenantsSetter)(nil), s)
+	require.Implements(t, (*chaindb.PeerCountsProvider)(nil), s)
+	require.Implements(t, (*chaindb.PeerCountsSetter)(nil), s)
 	require.Implements(t, (*chaindb.AttesterSlashingsSetter)(nil), s)
 	require.Implements(t, (*chaindb.BeaconCommitteesProvider)(nil), s)
 	require.Implements(t, (*chaindb.BeaconCommitteesSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockArrivalsProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlockArrivalsSetter)(nil), s)
 	require.Implements(t, (*chaindb.BlocksProvider)(nil), s)
 	require.Implements(t, (*chaindb.BlocksSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlocksStreamer)(nil), s)
 	require.Implements(t, (*chaindb.ProposerDutiesSetter)(nil), s)
 	require.Implements(t, (*chaindb.ProposerSlashingsSetter)(nil), s)
 	require.Implements(t, (*chaindb.ValidatorsProvider)(nil), s)
 	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorBalancesStreamer)(nil), s)
 	require.Implements(t, (*chaindb.VoluntaryExitsSetter)(nil), s)
 }
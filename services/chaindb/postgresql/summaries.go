@@ -0,0 +1,78 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	return s.deleteRange(ctx, "t_epoch_summaries", "f_epoch", uint64(startEpoch), uint64(endEpoch))
+}
+
+// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+func (s *Service) DeleteBlockSummaries(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	return s.deleteRange(ctx, "t_block_summaries", "f_slot", uint64(startSlot), uint64(endSlot))
+}
+
+// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+func (s *Service) DeleteValidatorEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	return s.deleteRange(ctx, "t_validator_epoch_summaries", "f_epoch", uint64(startEpoch), uint64(endEpoch))
+}
+
+// DeleteValidatorDaySummaries deletes the validator day summaries for days starting in the given range
+// of timestamps, inclusive.
+func (s *Service) DeleteValidatorDaySummaries(ctx context.Context, startTimestamp time.Time, endTimestamp time.Time) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_day_summaries
+WHERE f_start_timestamp >= $1
+  AND f_start_timestamp <= $2
+`,
+		startTimestamp,
+		endTimestamp,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_day_summaries")
+		return err
+	}
+
+	return nil
+}
+
+// deleteRange deletes the rows of a table with values of the given field in the given range, inclusive.
+func (s *Service) deleteRange(ctx context.Context, table string, field string, start uint64, end uint64) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Table and field names are not user-supplied, so are safe to include in the query.
+	if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE "+field+" >= $1 AND "+field+" <= $2",
+		start,
+		end,
+	); err != nil {
+		s.monitorWriteFailure(table)
+		return err
+	}
+
+	return nil
+}
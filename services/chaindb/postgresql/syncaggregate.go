@@ -16,6 +16,9 @@ package postgresql
 import (
 	"context"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
@@ -43,6 +46,54 @@ func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.S
 		syncAggregate.Bits,
 		syncAggregate.Indices,
 	)
+	s.monitorWrite("t_sync_aggregates", 1, err)
 
 	return err
 }
+
+// SyncAggregateForBlock provides the sync aggregate for the supplied block root.
+func (s *Service) SyncAggregateForBlock(ctx context.Context, blockRoot phase0.Root) (*chaindb.SyncAggregate, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	syncAggregate := &chaindb.SyncAggregate{}
+	var inclusionBlockRoot []byte
+	var indices []uint64
+
+	err := tx.QueryRow(ctx, `
+      SELECT f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_bits
+            ,f_indices
+      FROM t_sync_aggregates
+      WHERE f_inclusion_block_root = $1
+`,
+		blockRoot[:],
+	).Scan(
+		&syncAggregate.InclusionSlot,
+		&inclusionBlockRoot,
+		&syncAggregate.Bits,
+		&indices,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// Means there is no sync aggregate; this is fine.
+			return nil, nil
+		}
+		return nil, err
+	}
+	copy(syncAggregate.InclusionBlockRoot[:], inclusionBlockRoot)
+	syncAggregate.Indices = make([]phase0.ValidatorIndex, len(indices))
+	for i := range indices {
+		syncAggregate.Indices[i] = phase0.ValidatorIndex(indices[i])
+	}
+
+	return syncAggregate, nil
+}
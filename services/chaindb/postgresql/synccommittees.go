@@ -39,6 +39,7 @@ func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.S
 		syncCommittee.Period,
 		syncCommittee.Committee,
 	)
+	s.monitorWrite("t_sync_committees", 1, err)
 
 	return err
 }
@@ -0,0 +1,73 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// TableStats provides statistics about the tables managed by the database.
+// Row counts are estimates maintained by PostgreSQL's statistics collector,
+// as counting the rows of the larger tables is too expensive to carry out regularly.
+func (s *Service) TableStats(ctx context.Context) ([]*chaindb.TableStats, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT relname
+            ,n_live_tup
+            ,pg_table_size(relid)
+            ,pg_indexes_size(relid)
+      FROM pg_stat_user_tables
+      WHERE schemaname = current_schema()
+        AND relname LIKE 't\_%'
+      ORDER BY relname`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]*chaindb.TableStats, 0)
+	for rows.Next() {
+		tableStats := &chaindb.TableStats{}
+		err := rows.Scan(
+			&tableStats.Name,
+			&tableStats.Rows,
+			&tableStats.TableSize,
+			&tableStats.IndexesSize,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		stats = append(stats, tableStats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain table statistics")
+	}
+
+	return stats, nil
+}
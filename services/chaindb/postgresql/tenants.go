@@ -0,0 +1,161 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// tenantValidatorColumns are the tables with data about individual validators, with the
+// column holding the validator index.  Tenants can only read the rows of their validators.
+var tenantValidatorColumns = map[string]string{
+	"t_validators":                "f_index",
+	"t_validator_balances":        "f_validator_index",
+	"t_validator_epoch_summaries": "f_validator_index",
+	"t_validator_day_summaries":   "f_validator_index",
+	"t_validator_effectiveness":   "f_validator_index",
+	"t_validator_income":          "f_validator_index",
+	"t_validator_labels":          "f_validator_index",
+	"t_validator_registrations":   "f_validator_index",
+	"t_proposer_duties":           "f_validator_index",
+	"t_voluntary_exits":           "f_validator_index",
+}
+
+// SetTenants sets the tenants, replacing any existing tenants.  Each tenant can read only
+// the given tables, which must have the tenant policy, and only the rows of validators
+// with the given labels.
+func (s *Service) SetTenants(ctx context.Context, tenants []*chaindb.Tenant) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var schema string
+	if err := tx.QueryRow(ctx, "SELECT current_schema()").Scan(&schema); err != nil {
+		return errors.Wrap(err, "failed to obtain schema")
+	}
+	schemaIdentifier := pgx.Identifier{schema}.Sanitize()
+
+	for _, tenant := range tenants {
+		if tenant.Role == "" {
+			return errors.New("tenant has no role")
+		}
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)", tenant.Role).Scan(&exists); err != nil {
+			return errors.Wrap(err, "failed to check tenant role")
+		}
+		if !exists {
+			return fmt.Errorf("tenant role %q does not exist", tenant.Role)
+		}
+		for _, table := range tenant.Tables {
+			// Only tables with the tenant policy can be granted, as tenants could
+			// otherwise read the data of all validators.  This excludes views and
+			// materialized views, which are not subject to row-level security.
+			var relkind string
+			var hasPolicy bool
+			err := tx.QueryRow(ctx, `
+SELECT c.relkind::TEXT
+      ,EXISTS(SELECT 1 FROM pg_policy WHERE polrelid = c.oid AND polname = 'p_tenants')
+FROM pg_class c
+WHERE c.oid = to_regclass($1)
+`, pgx.Identifier{schema, table}.Sanitize()).Scan(&relkind, &hasPolicy)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("tenant table %q does not exist", table)
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to check tenant table")
+			}
+			if relkind != "r" && relkind != "p" {
+				return fmt.Errorf("tenant table %q is not a table", table)
+			}
+			if !hasPolicy {
+				return fmt.Errorf("tenant table %q has no tenant policy", table)
+			}
+		}
+	}
+
+	// Access is revoked from both existing and new tenants before being granted, so
+	// that tables removed from a tenant can no longer be read.
+	rows, err := tx.Query(ctx, "SELECT f_role FROM t_tenants")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain existing tenants")
+	}
+	roles := make([]string, 0)
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			rows.Close()
+			return errors.Wrap(err, "failed to scan row")
+		}
+		roles = append(roles, role)
+	}
+	rows.Close()
+	for _, tenant := range tenants {
+		roles = append(roles, tenant.Role)
+	}
+	for _, role := range roles {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1)", role).Scan(&exists); err != nil {
+			return errors.Wrap(err, "failed to check tenant role")
+		}
+		if !exists {
+			// Role has been dropped since it was a tenant.
+			continue
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("REVOKE ALL ON ALL TABLES IN SCHEMA %s FROM %s", schemaIdentifier, pgx.Identifier{role}.Sanitize())); err != nil {
+			return errors.Wrap(err, "failed to revoke tenant access")
+		}
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM t_tenants"); err != nil {
+		s.monitorWriteFailure("t_tenants")
+		return err
+	}
+
+	for _, tenant := range tenants {
+		labels := tenant.Labels
+		if labels == nil {
+			labels = make([]string, 0)
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_tenants(f_role
+                     ,f_labels)
+VALUES($1,$2)
+`,
+			tenant.Role,
+			labels,
+		); err != nil {
+			s.monitorWriteFailure("t_tenants")
+			return err
+		}
+
+		role := pgx.Identifier{tenant.Role}.Sanitize()
+		if _, err := tx.Exec(ctx, fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schemaIdentifier, role)); err != nil {
+			return errors.Wrap(err, "failed to grant tenant access to schema")
+		}
+		for _, table := range tenant.Tables {
+			if _, err := tx.Exec(ctx, fmt.Sprintf("GRANT SELECT ON %s TO %s", pgx.Identifier{table}.Sanitize(), role)); err != nil {
+				return errors.Wrap(err, "failed to grant tenant access to table")
+			}
+		}
+	}
+	s.monitorRowsWritten("t_tenants", len(tenants))
+
+	return nil
+}
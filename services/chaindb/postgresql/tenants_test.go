@@ -0,0 +1,127 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestSetTenants(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetTenants(ctx, nil), postgresql.ErrNoTransaction.Error())
+
+	tests := []struct {
+		name    string
+		tenants []*chaindb.Tenant
+		err     string
+	}{
+		{
+			name: "RoleMissing",
+			tenants: []*chaindb.Tenant{
+				{
+					Labels: []string{"pool-A"},
+					Tables: []string{"t_validators"},
+				},
+			},
+			err: "tenant has no role",
+		},
+		{
+			name: "RoleUnknown",
+			tenants: []*chaindb.Tenant{
+				{
+					Role:   "chaind_test_unknown_role",
+					Labels: []string{"pool-A"},
+					Tables: []string{"t_validators"},
+				},
+			},
+			err: `tenant role "chaind_test_unknown_role" does not exist`,
+		},
+		{
+			name: "TableUnknown",
+			tenants: []*chaindb.Tenant{
+				{
+					Role:   "pg_monitor",
+					Labels: []string{"pool-A"},
+					Tables: []string{"t_unknown"},
+				},
+			},
+			err: `tenant table "t_unknown" does not exist`,
+		},
+		{
+			name: "TableNoPolicy",
+			tenants: []*chaindb.Tenant{
+				{
+					Role:   "pg_monitor",
+					Labels: []string{"pool-A"},
+					Tables: []string{"t_blocks"},
+				},
+			},
+			err: `tenant table "t_blocks" has no tenant policy`,
+		},
+		{
+			name: "AggregateNoPolicy",
+			tenants: []*chaindb.Tenant{
+				{
+					Role:   "pg_monitor",
+					Labels: []string{"pool-A"},
+					Tables: []string{"t_validator_performance"},
+				},
+			},
+			err: `tenant table "t_validator_performance" has no tenant policy`,
+		},
+		{
+			name: "View",
+			tenants: []*chaindb.Tenant{
+				{
+					Role:   "pg_monitor",
+					Labels: []string{"pool-A"},
+					Tables: []string{"v_blocks"},
+				},
+			},
+			err: `tenant table "v_blocks" is not a table`,
+		},
+		{
+			name: "Empty",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel, err := s.BeginTx(ctx)
+			require.NoError(t, err)
+			defer cancel()
+
+			err = s.SetTenants(ctx, test.tenants)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,10 +17,14 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/codes"
 )
 
 var (
@@ -35,6 +39,33 @@ type Tx struct{}
 // TxID is a context tag for the transaction TxID.
 type TxID struct{}
 
+// txRelease is a context tag for the function that releases the transaction from the active count.
+type txRelease struct{}
+
+// txStateKey is a context tag for the state of a read-write transaction.
+type txStateKey struct{}
+
+// txState is state held for the lifetime of a read-write transaction.
+type txState struct {
+	mu sync.Mutex
+	// onCommit are functions called once the transaction has committed.
+	onCommit []func()
+}
+
+// isolationLevels are the transaction isolation levels that can be configured, keyed by name.
+var isolationLevels = map[string]pgx.TxIsoLevel{
+	"read committed":  pgx.ReadCommitted,
+	"repeatable read": pgx.RepeatableRead,
+	"serializable":    pgx.Serializable,
+}
+
+// txOptions are the options applied to read-write transactions.
+type txOptions struct {
+	isoLevel pgx.TxIsoLevel
+	// lockTimeout is the maximum time that statements in the transaction wait for a lock; 0 waits indefinitely.
+	lockTimeout time.Duration
+}
+
 func init() {
 	// We seed math.rand here so that we can obtain different IDs for requests.
 	// This is purely used as a way to match request and response entries in logs, so there is no
@@ -44,29 +75,67 @@ func init() {
 
 // BeginTx begins a transaction on the database.
 // The transaction can be rolled back by invoking the cancel function.
+// Statements in the transaction are bounded by the supplied context, however committing and rolling
+// back the transaction are not, so that in-flight transactions always finish cleanly when shutting down.
 func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return s.beginTx(ctx, s.txOptions)
+}
+
+// beginUpgradeTx begins a transaction on the database for schema initialization or upgrade.
+// It behaves as BeginTx, but with the options configured for upgrades.
+func (s *Service) beginUpgradeTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return s.beginTx(ctx, s.upgradeTxOptions)
+}
+
+// beginTx begins a read-write transaction on the database with the given options.
+func (s *Service) beginTx(ctx context.Context, opts *txOptions) (context.Context, context.CancelFunc, error) {
 	// #nosec G404
 	id := fmt.Sprintf("%02x", rand.Int31())
-	log := log.With().Str("id", id).Logger()
+	log := s.log.With().Str("id", id).Logger()
 
-	ctx, cancel := context.WithCancel(ctx)
-	tx, err := s.pool.Begin(ctx)
+	// The span covers obtaining a connection from the pool, so shows contention for connections.
+	_, span := tracer.Start(ctx, "BeginTx")
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: opts.isoLevel})
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
 		log.Trace().Err(err).Str("trace", fmt.Sprintf("+%v", errors.Wrap(err, "stack"))).Msg("Failed to begin transaction")
-		cancel()
 		return nil, nil, errors.Wrap(err, "failed to begin transaction")
 	}
+	span.End()
+
+	if opts.lockTimeout > 0 {
+		// SET LOCAL only lasts until the end of the transaction, so does not leak to other users of the connection.
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL lock_timeout = %d", opts.lockTimeout.Milliseconds())); err != nil {
+			if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+				log.Debug().Err(rollbackErr).Msg("Failed to rollback transaction")
+			}
+			return nil, nil, errors.Wrap(err, "failed to set lock timeout")
+		}
+	}
 
+	atomic.AddInt32(&s.activeTxs, 1)
+	var releaseOnce sync.Once
+	release := func() {
+		releaseOnce.Do(func() {
+			atomic.AddInt32(&s.activeTxs, -1)
+		})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	ctx = context.WithValue(ctx, &Tx{}, tx)
 	ctx = context.WithValue(ctx, &TxID{}, id)
+	ctx = context.WithValue(ctx, &txRelease{}, release)
+	ctx = context.WithValue(ctx, &txStateKey{}, &txState{})
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction started")
 	return ctx, func() {
-		if err := tx.Rollback(ctx); err != nil {
+		if err := tx.Rollback(util.WithoutCancel(ctx)); err != nil {
 			log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to rollback transaction")
 			log.Warn().Err(err).Msg("Failed to rollback transaction")
 		}
 		log.Debug().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Rolled back transaction")
+		release()
 		cancel()
 	}, nil
 }
@@ -76,7 +145,7 @@ func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelF
 func (s *Service) beginROTx(ctx context.Context) (context.Context, error) {
 	// #nosec G404
 	id := fmt.Sprintf("%02x", rand.Int31())
-	log := log.With().Str("id", id).Logger()
+	log := s.log.With().Str("id", id).Logger()
 
 	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
 	if err != nil {
@@ -117,7 +186,7 @@ func (s *Service) txID(ctx context.Context) string {
 
 // CommitTx commits a transaction on the ops datastore.
 func (s *Service) CommitTx(ctx context.Context) error {
-	log := log.With().Str("id", s.txID(ctx)).Logger()
+	log := s.log.With().Str("id", s.txID(ctx)).Logger()
 
 	if ctx == nil {
 		log.Debug().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("No context")
@@ -130,19 +199,66 @@ func (s *Service) CommitTx(ctx context.Context) error {
 		return errors.New("no transaction")
 	}
 
-	err := tx.Commit(ctx)
+	_, span := tracer.Start(ctx, "CommitTx")
+	err := tx.Commit(util.WithoutCancel(ctx))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	// The transaction is finished regardless of the result of the commit.
+	if release, ok := ctx.Value(&txRelease{}).(func()); ok {
+		release()
+	}
 	if err != nil {
 		log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to commit")
 		return err
 	}
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction committed")
+
+	if state := s.txState(ctx); state != nil {
+		state.mu.Lock()
+		onCommit := state.onCommit
+		state.onCommit = nil
+		state.mu.Unlock()
+		for _, fn := range onCommit {
+			fn()
+		}
+	}
+
 	return nil
 }
 
+// txState returns the state of the read-write transaction; nil if no transaction.
+func (s *Service) txState(ctx context.Context) *txState {
+	if ctx == nil {
+		return nil
+	}
+
+	if state, ok := ctx.Value(&txStateKey{}).(*txState); ok {
+		return state
+	}
+	return nil
+}
+
+// afterCommit calls the supplied function once the transaction in the context has
+// committed.  The function is not called if the transaction is rolled back.  If there
+// is no read-write transaction then the function is called immediately.
+func (s *Service) afterCommit(ctx context.Context, fn func()) {
+	state := s.txState(ctx)
+	if state == nil {
+		fn()
+		return
+	}
+
+	state.mu.Lock()
+	state.onCommit = append(state.onCommit, fn)
+	state.mu.Unlock()
+}
+
 // commitROTx commits a read-only transaction on the ops datastore.
 func (s *Service) commitROTx(ctx context.Context) {
-	log := log.With().Str("id", s.txID(ctx)).Logger()
+	log := s.log.With().Str("id", s.txID(ctx)).Logger()
 
 	if ctx == nil {
 		log.Debug().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("No context")
@@ -163,3 +279,22 @@ func (s *Service) commitROTx(ctx context.Context) {
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction committed")
 }
+
+// Drain waits for active read-write transactions to complete.
+// It returns an error if transactions are still active when the context is done.
+func (s *Service) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		active := atomic.LoadInt32(&s.activeTxs)
+		if active == 0 {
+			return nil
+		}
+		s.log.Trace().Int32("active", active).Msg("Waiting for transactions to complete")
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%d transactions still active", active)
+		case <-ticker.C:
+		}
+	}
+}
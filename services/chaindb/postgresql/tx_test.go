@@ -0,0 +1,57 @@
+// Copyright © 2022 Weald Technology Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// No transactions, so should drain immediately.
+	require.NoError(t, s.Drain(ctx))
+
+	parentCtx, parentCancel := context.WithCancel(ctx)
+	txCtx, cancel, err := s.BeginTx(parentCtx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Transaction is active, so should fail to drain.
+	drainCtx, drainCancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer drainCancel()
+	require.Error(t, s.Drain(drainCtx))
+
+	// Cancelling the parent context should cancel statements in the transaction, but still allow it to commit.
+	parentCancel()
+	require.Error(t, txCtx.Err())
+	require.NoError(t, s.CommitTx(txCtx))
+
+	// Transaction is complete, so should drain.
+	require.NoError(t, s.Drain(ctx))
+
+	// Should not be able to begin a transaction with a cancelled context.
+	_, _, err = s.BeginTx(parentCtx)
+	require.Error(t, err)
+}
@@ -15,8 +15,10 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -27,7 +29,7 @@ type schemaMetadata struct {
 	Version uint64 `json:"version"`
 }
 
-var currentVersion = uint64(8)
+var currentVersion = uint64(37)
 
 type upgrade struct {
 	requiresRefetch bool
@@ -89,6 +91,155 @@ var upgrades = map[uint64]*upgrade{
 			addTimestamp,
 		},
 	},
+	9: {
+		funcs: []func(context.Context, *Service) error{
+			createBitlistFunctions,
+		},
+	},
+	10: {
+		funcs: []func(context.Context, *Service) error{
+			createAggregates,
+		},
+	},
+	11: {
+		funcs: []func(context.Context, *Service) error{
+			createSlotsPerEpochFunction,
+			addEpochColumns,
+		},
+	},
+	12: {
+		funcs: []func(context.Context, *Service) error{
+			createAuditLog,
+		},
+	},
+	13: {
+		funcs: []func(context.Context, *Service) error{
+			createMissedSlots,
+		},
+	},
+	14: {
+		funcs: []func(context.Context, *Service) error{
+			addMissedSlotProposers,
+		},
+	},
+	15: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorEffectiveness,
+		},
+	},
+	16: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorIncome,
+		},
+	},
+	17: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorLabels,
+		},
+	},
+	18: {
+		funcs: []func(context.Context, *Service) error{
+			addValidatorPercentiles,
+		},
+	},
+	19: {
+		funcs: []func(context.Context, *Service) error{
+			addDepositsWithdrawalCredentialsIndex,
+		},
+	},
+	20: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorDaySummaries,
+		},
+	},
+	21: {
+		funcs: []func(context.Context, *Service) error{
+			addSyncCommitteeSummaries,
+		},
+	},
+	22: {
+		funcs: []func(context.Context, *Service) error{
+			addBeaconCommitteesMembersIndex,
+		},
+	},
+	23: {
+		funcs: []func(context.Context, *Service) error{
+			addBlocksProposerIndexIndex,
+		},
+	},
+	24: {
+		funcs: []func(context.Context, *Service) error{
+			addETH1DepositsRangeIndices,
+		},
+	},
+	25: {
+		funcs: []func(context.Context, *Service) error{
+			addAttestationsCommitteeIndex,
+		},
+	},
+	26: {
+		funcs: []func(context.Context, *Service) error{
+			addExecutionPayloadsIndices,
+		},
+	},
+	27: {
+		funcs: []func(context.Context, *Service) error{
+			addChainSpecEffectiveEpoch,
+		},
+	},
+	28: {
+		funcs: []func(context.Context, *Service) error{
+			addValidatorBalanceRuns,
+			createValidatorBalancesFunction,
+		},
+	},
+	29: {
+		funcs: []func(context.Context, *Service) error{
+			createFinalityStatusViews,
+		},
+	},
+	30: {
+		funcs: []func(context.Context, *Service) error{
+			createBlockArrivals,
+		},
+	},
+	31: {
+		funcs: []func(context.Context, *Service) error{
+			createGossipAttestations,
+		},
+	},
+	32: {
+		funcs: []func(context.Context, *Service) error{
+			createPeerCounts,
+		},
+	},
+	33: {
+		funcs: []func(context.Context, *Service) error{
+			createBlockClients,
+		},
+	},
+	34: {
+		funcs: []func(context.Context, *Service) error{
+			createTenants,
+		},
+	},
+	35: {
+		funcs: []func(context.Context, *Service) error{
+			addExecutionPayloadRelays,
+		},
+	},
+	36: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorRegistrations,
+			// Add the tenant policy to the new table.
+			createTenants,
+		},
+	},
+	37: {
+		funcs: []func(context.Context, *Service) error{
+			createQuarantine,
+		},
+	},
 }
 
 // Upgrade upgrades the database.
@@ -103,32 +254,43 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		return s.Init(ctx)
 	}
 
+	// Foreign keys may have been left altered if chaind stopped part way through a backfill.
+	if err := s.restoreForeignKeys(ctx); err != nil {
+		return false, errors.Wrap(err, "failed to restore foreign keys")
+	}
+
 	version, err := s.version(ctx)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to obtain version")
 	}
 
-	log.Trace().Uint64("current_version", version).Uint64("required_version", currentVersion).Msg("Checking if database upgrade is required")
+	s.log.Trace().Uint64("current_version", version).Uint64("required_version", currentVersion).Msg("Checking if database upgrade is required")
 	if version == currentVersion {
-		// Nothing to do.
+		// No schema changes, but the storage profile may have changed.
+		if err := s.updateStorageProfile(ctx); err != nil {
+			return false, err
+		}
+		// Filling and packing may have been stopped part way through.
+		go s.fillEpochColumns(ctx)
+		go s.compactValidatorBalances(ctx)
 		return false, nil
 	}
 	if version > currentVersion {
-		log.Warn().Msg("This release is running an older version than that in the database, please upgrade to the latest release")
+		s.log.Warn().Msg("This release is running an older version than that in the database, please upgrade to the latest release")
 		return false, nil
 	}
 
-	ctx, cancel, err := s.BeginTx(ctx)
+	ctx, cancel, err := s.beginUpgradeTx(ctx)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to begin upgrade transaction")
 	}
 
 	requiresRefetch := false
 	for i := version + 1; i <= currentVersion; i++ {
-		log.Info().Uint64("target_version", i).Msg("Upgrading database")
+		s.log.Info().Uint64("target_version", i).Msg("Upgrading database")
 		if upgrade, exists := upgrades[i]; exists {
 			for i, upgradeFunc := range upgrade.funcs {
-				log.Info().Int("current", i+1).Int("total", len(upgrade.funcs)).Msg("Running upgrade function")
+				s.log.Info().Int("current", i+1).Int("total", len(upgrade.funcs)).Msg("Running upgrade function")
 				if err := upgradeFunc(ctx, s); err != nil {
 					cancel()
 					return false, errors.Wrap(err, "failed to upgrade")
@@ -138,6 +300,11 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		}
 	}
 
+	if err := applyStorageProfile(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to apply storage profile")
+	}
+
 	if err := s.setVersion(ctx, currentVersion); err != nil {
 		cancel()
 		return false, errors.Wrap(err, "failed to set latest schema version")
@@ -148,11 +315,36 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		return false, errors.Wrap(err, "failed to commit upgrade transaction")
 	}
 
-	log.Info().Msg("Upgrade complete")
+	s.log.Info().Msg("Upgrade complete")
+
+	// Epoch columns of existing rows are filled, and existing validator balances
+	// packed, outside of the upgrade transaction.
+	go s.fillEpochColumns(ctx)
+	go s.compactValidatorBalances(ctx)
 
 	return requiresRefetch, nil
 }
 
+// updateStorageProfile applies the storage profile in its own transaction.
+func (s *Service) updateStorageProfile(ctx context.Context) error {
+	ctx, cancel, err := s.beginUpgradeTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin storage profile transaction")
+	}
+
+	if err := applyStorageProfile(ctx, s); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to apply storage profile")
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit storage profile transaction")
+	}
+
+	return nil
+}
+
 // validatorsEpochNull allows epochs in the t_validators table to be NULL.
 func validatorsEpochNull(ctx context.Context, s *Service) error {
 	tx := s.tx(ctx)
@@ -542,6 +734,30 @@ func (s *Service) version(ctx context.Context) (uint64, error) {
 	return metadata.Version, nil
 }
 
+// RequiredSchemaVersion returns the version of the schema required by this release.
+func RequiredSchemaVersion() uint64 {
+	return currentVersion
+}
+
+// SchemaVersion returns the version of the schema in the database, without upgrading it.
+// It also returns false if the database does not yet contain any tables.
+func (s *Service) SchemaVersion(ctx context.Context) (uint64, bool, error) {
+	initialised, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return 0, false, errors.Wrap(err, "failed to check presence of tables")
+	}
+	if !initialised {
+		return 0, false, nil
+	}
+
+	version, err := s.version(ctx)
+	if err != nil {
+		return 0, true, errors.Wrap(err, "failed to obtain version")
+	}
+
+	return version, true, nil
+}
+
 // setVersion sets the version of the schema.
 func (s *Service) setVersion(ctx context.Context, version uint64) error {
 	tx := s.tx(ctx)
@@ -767,19 +983,19 @@ WHERE indexname = 'i_sync_aggregates_1'
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain sync aggregates index information")
 	}
-	log.Info().Uint64("good_indices", goodIndices).Msg("Found number of good indices")
+	s.log.Info().Uint64("good_indices", goodIndices).Msg("Found number of good indices")
 
 	if goodIndices == 1 {
 		// The index is already good.
 		return nil
 	}
 
-	log.Info().Msg("Dropping existing index")
+	s.log.Info().Msg("Dropping existing index")
 	if _, err := tx.Exec(ctx, `DROP INDEX IF EXISTS i_sync_aggregates_1`); err != nil {
 		return errors.Wrap(err, "failed to drop i_sync_aggregates_1 index")
 	}
 
-	log.Info().Msg("Creating new index")
+	s.log.Info().Msg("Creating new index")
 	if _, err := tx.Exec(ctx, `
 CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f_inclusion_block_root)
 `); err != nil {
@@ -791,7 +1007,7 @@ CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f
 
 // Init initialises the database.
 func (s *Service) Init(ctx context.Context) (bool, error) {
-	ctx, cancel, err := s.BeginTx(ctx)
+	ctx, cancel, err := s.beginUpgradeTx(ctx)
 	if err != nil {
 		return false, errors.Wrap(err, "failed to begin initial tables transaction")
 	}
@@ -810,10 +1026,13 @@ CREATE TABLE t_metadata (
 CREATE UNIQUE INDEX i_metadata_1 ON t_metadata(f_key);
 
 -- t_chain_spec contains the specification of the chain to which the rest of
--- the tables relate.
+-- the tables relate.  Each value applies from its effective epoch until the
+-- effective epoch of the next value for the same key.
 CREATE TABLE t_chain_spec (
-  f_key TEXT NOT NULL PRIMARY KEY
+  f_key TEXT NOT NULL
  ,f_value TEXT NOT NULL
+ ,f_effective_epoch BIGINT NOT NULL DEFAULT 0
+ ,PRIMARY KEY (f_key, f_effective_epoch)
 );
 
 -- t_genesis contains the genesis parameters of the chain.
@@ -859,10 +1078,13 @@ CREATE TABLE t_blocks (
  ,f_eth1_block_hash    BYTEA NOT NULL
  ,f_eth1_deposit_count BIGINT NOT NULL
  ,f_eth1_deposit_root  BYTEA NOT NULL
+ ,f_epoch              BIGINT
 );
 CREATE UNIQUE INDEX i_blocks_1 ON t_blocks(f_slot,f_root);
 CREATE UNIQUE INDEX i_blocks_2 ON t_blocks(f_root);
 CREATE INDEX i_blocks_3 ON t_blocks(f_parent_root);
+CREATE INDEX i_blocks_4 ON t_blocks USING BRIN (f_epoch) WITH (pages_per_range = 32);
+CREATE INDEX i_blocks_5 ON t_blocks(f_proposer_index, f_slot);
 
 -- t_block_execution_payloads is a subtable for t_blocks.
 CREATE TABLE t_block_execution_payloads (
@@ -881,6 +1103,8 @@ CREATE TABLE t_block_execution_payloads (
  ,f_extra_data       BYTEA
  ,f_timestamp        BIGINT NOT NULL
 );
+CREATE INDEX i_block_execution_payloads_1 ON t_block_execution_payloads(f_block_hash);
+CREATE INDEX i_block_execution_payloads_2 ON t_block_execution_payloads(f_block_number);
 
 -- t_beacon_committees contains all beacon committees.
 -- N.B. in the case of a chain re-org the committees can alter.
@@ -888,16 +1112,21 @@ CREATE TABLE t_beacon_committees (
   f_slot BIGINT NOT NULL
  ,f_index BIGINT NOT NULL
  ,f_committee BIGINT[] NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_epoch BIGINT
 );
 CREATE UNIQUE INDEX i_beacon_committees_1 ON t_beacon_committees(f_slot, f_index);
+CREATE INDEX i_beacon_committees_2 ON t_beacon_committees USING BRIN (f_epoch) WITH (pages_per_range = 32);
+CREATE INDEX i_beacon_committees_3 ON t_beacon_committees USING GIN (f_committee);
 
 -- t_proposer_duties contains all proposer duties.
 -- N.B. in the case of a chain re-org the duties can alter.
 CREATE TABLE t_proposer_duties (
   f_slot BIGINT NOT NULL
  ,f_validator_index BIGINT NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_epoch BIGINT
 );
 CREATE UNIQUE INDEX i_proposer_duties_1 ON t_proposer_duties(f_slot);
+CREATE INDEX i_proposer_duties_2 ON t_proposer_duties USING BRIN (f_epoch) WITH (pages_per_range = 32);
 
 -- t_attestations contains all attestations included in blocks.
 CREATE TABLE t_attestations (
@@ -907,7 +1136,7 @@ CREATE TABLE t_attestations (
  ,f_slot                 BIGINT NOT NULL
  ,f_committee_index      BIGINT NOT NULL
  ,f_aggregation_bits     BYTEA NOT NULL
- ,f_aggregation_indices  BIGINT[] -- REFERENCES t_validators(f_index)
+ ,f_aggregation_indices  BIGINT[] -- only written by releases before schema version 9
  ,f_beacon_block_root    BYTEA NOT NULL -- we don't reference this because the block may not exist in the canonical chain
  ,f_source_epoch         BIGINT NOT NULL
  ,f_source_root          BYTEA NOT NULL
@@ -916,10 +1145,14 @@ CREATE TABLE t_attestations (
  ,f_canonical            BOOL
  ,f_target_correct       BOOL
  ,f_head_correct         BOOL
+ ,f_inclusion_epoch      BIGINT
 );
 CREATE UNIQUE INDEX i_attestations_1 ON t_attestations(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_attestations_2 ON t_attestations(f_slot);
 CREATE INDEX i_attestations_3 ON t_attestations(f_beacon_block_root);
+CREATE INDEX i_attestations_4 ON t_attestations USING BRIN (f_inclusion_epoch) WITH (pages_per_range = 32);
+CREATE INDEX i_attestations_5 ON t_attestations(f_slot,f_committee_index);
+`+bitlistFunctionsSQL+`
 
 -- t_sync_aggregates contains the sync committee aggregates included in blocks.
 CREATE TABLE t_sync_aggregates (
@@ -999,6 +1232,7 @@ CREATE TABLE t_deposits (
 );
 CREATE UNIQUE INDEX i_deposits_1 ON t_deposits(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_deposits_2 ON t_deposits(f_validator_pubkey,f_inclusion_slot);
+CREATE INDEX i_deposits_3 ON t_deposits(f_withdrawal_credentials);
 
 -- t_eth1_deposits stores information about each Ethereum 1 deposit that has occurred for the deposit contract.
 CREATE TABLE t_eth1_deposits (
@@ -1022,13 +1256,17 @@ CREATE INDEX i_eth1_deposits_2 ON t_eth1_deposits(f_validator_pubkey);
 CREATE INDEX i_eth1_deposits_3 ON t_eth1_deposits(f_withdrawal_credentials);
 CREATE INDEX i_eth1_deposits_4 ON t_eth1_deposits(f_eth1_sender);
 CREATE INDEX i_eth1_deposits_5 ON t_eth1_deposits(f_eth1_recipient);
+CREATE INDEX i_eth1_deposits_6 ON t_eth1_deposits(f_eth1_block_number);
+CREATE INDEX i_eth1_deposits_7 ON t_eth1_deposits(f_eth1_block_timestamp);
 
--- t_validator_balances contains per-epoch balances.
+-- t_validator_balances contains per-epoch balances, stored as runs of consecutive
+-- epochs.  f_balance_changes holds the change from f_balance at each following epoch.
 CREATE TABLE t_validator_balances (
   f_validator_index   BIGINT NOT NULL REFERENCES t_validators(f_index) ON DELETE CASCADE
  ,f_epoch             BIGINT NOT NULL
  ,f_balance           BIGINT NOT NULL
  ,f_effective_balance BIGINT NOT NULL
+ ,f_balance_changes   INTEGER[] NOT NULL DEFAULT '{}'
 );
 CREATE UNIQUE INDEX i_validator_balances_1 ON t_validator_balances(f_validator_index, f_epoch);
 CREATE INDEX i_validator_balances_2 ON t_validator_balances(f_epoch);
@@ -1095,6 +1333,116 @@ CREATE UNIQUE INDEX IF NOT EXISTS i_sync_committees_1 ON t_sync_committees(f_per
 		return false, errors.Wrap(err, "failed to create initial tables")
 	}
 
+	if err := createAuditLog(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create audit log")
+	}
+
+	if err := createMissedSlots(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create missed slots")
+	}
+
+	if err := addMissedSlotProposers(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to add missed slot proposers")
+	}
+
+	if err := createValidatorEffectiveness(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator effectiveness")
+	}
+
+	if err := createValidatorIncome(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator income")
+	}
+
+	if err := createValidatorLabels(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator labels")
+	}
+
+	if err := addValidatorPercentiles(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to add validator percentiles")
+	}
+
+	if err := createValidatorDaySummaries(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator day summaries")
+	}
+
+	if err := addSyncCommitteeSummaries(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to add sync committee summaries")
+	}
+
+	if err := createBlockArrivals(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create block arrivals")
+	}
+
+	if err := createGossipAttestations(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create gossip attestations")
+	}
+
+	if err := createPeerCounts(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create peer counts")
+	}
+
+	if err := createBlockClients(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create block clients")
+	}
+
+	if err := createValidatorRegistrations(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator registrations")
+	}
+
+	if err := createTenants(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create tenants")
+	}
+
+	if err := addExecutionPayloadRelays(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to add execution payload relays")
+	}
+
+	if err := createQuarantine(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create quarantine")
+	}
+
+	if err := createValidatorBalancesFunction(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create validator balances function")
+	}
+
+	if err := createSlotsPerEpochFunction(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create slots per epoch function")
+	}
+
+	if err := createFinalityStatusViews(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create finality status views")
+	}
+
+	if err := createAggregates(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to create aggregates")
+	}
+
+	if err := applyStorageProfile(ctx, s); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to apply storage profile")
+	}
+
 	if err := s.setVersion(ctx, currentVersion); err != nil {
 		cancel()
 		return false, errors.Wrap(err, "failed to set initial schema version")
@@ -1301,3 +1649,912 @@ ALTER COLUMN f_timestamp DROP DEFAULT
 
 	return nil
 }
+
+// bitlistFunctionsSQL contains the definitions of the functions that expand
+// SSZ bitlists stored as BYTEA.
+const bitlistFunctionsSQL = `
+-- bitlist_len returns the number of bits in an SSZ bitlist, excluding its
+-- length delimiter.  The delimiter is the highest set bit of the last byte.
+CREATE OR REPLACE FUNCTION bitlist_len(bits BYTEA) RETURNS INTEGER AS $$
+  SELECT COALESCE(MAX((length(bits)-1)*8+i), 0)
+  FROM generate_series(0, 7) AS i
+  WHERE CASE WHEN length(bits) > 0 THEN get_bit(bits, (length(bits)-1)*8+i) = 1 ELSE false END
+$$ LANGUAGE SQL IMMUTABLE STRICT;
+
+-- bitlist_positions returns the positions of the set bits in an SSZ bitlist.
+CREATE OR REPLACE FUNCTION bitlist_positions(bits BYTEA) RETURNS INTEGER[] AS $$
+  SELECT ARRAY(
+    SELECT i
+    FROM generate_series(0, bitlist_len(bits)-1) AS i
+    WHERE get_bit(bits, i) = 1
+    ORDER BY i
+  )
+$$ LANGUAGE SQL IMMUTABLE STRICT;
+
+-- attestation_aggregation_indices returns the indices of the validators that
+-- took part in an attestation, or NULL if the beacon committee for the
+-- attestation is not known or does not match the aggregation bits.
+CREATE OR REPLACE FUNCTION attestation_aggregation_indices(bits BYTEA, slot BIGINT, committee_index BIGINT) RETURNS BIGINT[] AS $$
+  SELECT ARRAY(
+    SELECT f_committee[pos+1]
+    FROM UNNEST(bitlist_positions(bits)) AS pos
+    ORDER BY pos
+  )
+  FROM t_beacon_committees
+  WHERE f_slot = slot
+    AND f_index = committee_index
+    AND CARDINALITY(f_committee) = bitlist_len(bits)
+$$ LANGUAGE SQL STABLE STRICT;
+`
+
+// createBitlistFunctions creates the functions to expand bitlists.
+func createBitlistFunctions(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, bitlistFunctionsSQL); err != nil {
+		return errors.Wrap(err, "failed to create bitlist functions")
+	}
+
+	return nil
+}
+
+// createAggregates creates the tables holding aggregates of the summary tables, which
+// are updated incrementally as epochs are summarized.
+func createAggregates(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_performance contains lifetime performance data for each validator.
+CREATE TABLE IF NOT EXISTS t_validator_performance (
+  f_validator_index             BIGINT NOT NULL
+ ,f_epochs                      BIGINT NOT NULL
+ ,f_proposer_duties             BIGINT NOT NULL
+ ,f_proposals_included          BIGINT NOT NULL
+ ,f_attestations_included       BIGINT NOT NULL
+ ,f_attestations_target_correct BIGINT NOT NULL
+ ,f_attestations_head_correct   BIGINT NOT NULL
+ ,f_total_inclusion_delay       BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_performance_1 ON t_validator_performance(f_validator_index);
+
+-- t_daily_aggregates contains per-day aggregates of the epoch summaries.
+CREATE TABLE IF NOT EXISTS t_daily_aggregates (
+  f_day                 DATE NOT NULL
+ ,f_start_epoch         BIGINT NOT NULL
+ ,f_end_epoch           BIGINT NOT NULL
+ ,f_active_validators   BIGINT NOT NULL
+ ,f_active_balance      BIGINT NOT NULL
+ ,f_participation_rate  FLOAT8
+ ,f_canonical_blocks    BIGINT NOT NULL
+ ,f_proposer_slashings  BIGINT NOT NULL
+ ,f_attester_slashings  BIGINT NOT NULL
+ ,f_deposits            BIGINT NOT NULL
+ ,f_exiting_validators  BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_daily_aggregates_1 ON t_daily_aggregates(f_day);
+`); err != nil {
+		return errors.Wrap(err, "failed to create aggregates")
+	}
+
+	return nil
+}
+
+// epochColumns are the epoch columns of slot-based tables, along with the slot column
+// from which each is derived and the name of its index.
+var epochColumns = []struct {
+	table      string
+	column     string
+	slotColumn string
+	index      string
+}{
+	{"t_blocks", "f_epoch", "f_slot", "i_blocks_4"},
+	{"t_beacon_committees", "f_epoch", "f_slot", "i_beacon_committees_2"},
+	{"t_proposer_duties", "f_epoch", "f_slot", "i_proposer_duties_2"},
+	{"t_attestations", "f_inclusion_epoch", "f_inclusion_slot", "i_attestations_4"},
+}
+
+// addEpochColumns adds epoch columns to slot-based tables, along with BRIN indices on
+// them.  Rows in these tables are written in slot order, so BRIN indices allow
+// epoch-scoped queries to prune efficiently at a fraction of the size of a b-tree index.
+//
+// The columns are nullable so that adding them does not rewrite the tables.  Rows
+// written from now on have their epoch set when written, and existing rows are filled
+// in batches after the upgrade; the range of slots to fill is recorded here.
+func addEpochColumns(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	fill := make(map[string]*epochColumnFill)
+	for _, epochColumn := range epochColumns {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %s
+ADD COLUMN IF NOT EXISTS %s BIGINT
+`, epochColumn.table, epochColumn.column)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to add %s to %s", epochColumn.column, epochColumn.table))
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS %s ON %s USING BRIN (%s) WITH (pages_per_range = 32)
+`, epochColumn.index, epochColumn.table, epochColumn.column)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to create %s", epochColumn.index))
+		}
+
+		var endSlot uint64
+		if err := tx.QueryRow(ctx, fmt.Sprintf(`
+SELECT COALESCE(MAX(%s) + 1, 0)
+FROM %s
+`, epochColumn.slotColumn, epochColumn.table)).Scan(&endSlot); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain end slot of %s", epochColumn.table))
+		}
+		if endSlot > 0 {
+			fill[epochColumn.table] = &epochColumnFill{
+				EndSlot: endSlot,
+			}
+		}
+	}
+
+	if len(fill) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(fill)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal epoch columns fill")
+	}
+	if err := s.SetMetadata(ctx, epochColumnsFillKey, data); err != nil {
+		return errors.Wrap(err, "failed to set epoch columns fill metadata")
+	}
+
+	return nil
+}
+
+// createSlotsPerEpochFunction creates a function that returns the number of slots per
+// epoch from the chain specification, so that queries can convert between epochs and
+// slots without hard-coding the value.
+func createSlotsPerEpochFunction(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- slots_per_epoch returns the latest number of slots per epoch in the chain
+-- specification, or NULL if the chain specification has yet to be obtained.
+CREATE OR REPLACE FUNCTION slots_per_epoch() RETURNS BIGINT AS $$
+  SELECT f_value::BIGINT
+  FROM t_chain_spec
+  WHERE f_key = 'SLOTS_PER_EPOCH'
+  ORDER BY f_effective_epoch DESC
+  LIMIT 1
+$$ LANGUAGE SQL STABLE;
+`); err != nil {
+		return errors.Wrap(err, "failed to create slots per epoch function")
+	}
+
+	return nil
+}
+
+// createAuditLog creates the audit log table.  Rows in the audit log cannot be
+// updated or removed once written.
+func createAuditLog(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_audit_log contains mutations made by chaind, if auditing is enabled.
+CREATE TABLE IF NOT EXISTS t_audit_log (
+  f_id        BIGSERIAL PRIMARY KEY
+ ,f_timestamp TIMESTAMPTZ NOT NULL
+ ,f_table     TEXT NOT NULL
+ ,f_operation TEXT NOT NULL
+ ,f_keys      JSONB NOT NULL
+ ,f_module    TEXT NOT NULL
+ ,f_slot      BIGINT
+);
+CREATE INDEX IF NOT EXISTS i_audit_log_1 ON t_audit_log(f_timestamp);
+CREATE INDEX IF NOT EXISTS i_audit_log_2 ON t_audit_log(f_slot);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 't_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_modify ON t_audit_log;
+CREATE TRIGGER audit_log_no_modify
+BEFORE UPDATE OR DELETE ON t_audit_log
+FOR EACH ROW EXECUTE PROCEDURE audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON t_audit_log;
+CREATE TRIGGER audit_log_no_truncate
+BEFORE TRUNCATE ON t_audit_log
+FOR EACH STATEMENT EXECUTE PROCEDURE audit_log_append_only();
+`); err != nil {
+		return errors.Wrap(err, "failed to create audit log")
+	}
+
+	return nil
+}
+
+// createMissedSlots creates the missed slots table.  Slots between the earliest and
+// latest blocks that do not have a block are assumed to have been missed, as the
+// blocks module has already processed them.
+func createMissedSlots(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_missed_slots contains slots for which the beacon node reported no block.
+CREATE TABLE IF NOT EXISTS t_missed_slots (
+  f_slot BIGINT NOT NULL PRIMARY KEY
+);
+
+INSERT INTO t_missed_slots(f_slot)
+SELECT slot
+FROM generate_series((SELECT COALESCE(MIN(f_slot),0) FROM t_blocks),(SELECT COALESCE(MAX(f_slot),-1) FROM t_blocks)) slot
+WHERE NOT EXISTS (SELECT 1 FROM t_blocks WHERE t_blocks.f_slot = slot)
+ON CONFLICT (f_slot) DO NOTHING;
+`); err != nil {
+		return errors.Wrap(err, "failed to create missed slots")
+	}
+
+	return nil
+}
+
+// addMissedSlotProposers adds the index of the validator that was due to propose to
+// missed slots, populating it from proposer duties that are already present.
+func addMissedSlotProposers(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_missed_slots
+ADD COLUMN IF NOT EXISTS f_proposer_index BIGINT; -- REFERENCES t_validators(f_index)
+
+CREATE INDEX IF NOT EXISTS i_missed_slots_1 ON t_missed_slots(f_proposer_index);
+
+UPDATE t_missed_slots
+SET f_proposer_index = t_proposer_duties.f_validator_index
+FROM t_proposer_duties
+WHERE t_proposer_duties.f_slot = t_missed_slots.f_slot
+  AND t_missed_slots.f_proposer_index IS NULL;
+`); err != nil {
+		return errors.Wrap(err, "failed to add proposer index to missed slots")
+	}
+
+	return nil
+}
+
+// createValidatorEffectiveness creates the validator effectiveness table.
+func createValidatorEffectiveness(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_effectiveness contains the attestation effectiveness of validators for each epoch.
+CREATE TABLE IF NOT EXISTS t_validator_effectiveness (
+  f_validator_index BIGINT NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_epoch           BIGINT NOT NULL
+ ,f_effectiveness   FLOAT8 NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_effectiveness_1 ON t_validator_effectiveness(f_validator_index, f_epoch);
+CREATE INDEX IF NOT EXISTS i_validator_effectiveness_2 ON t_validator_effectiveness(f_epoch);
+`); err != nil {
+		return errors.Wrap(err, "failed to create validator effectiveness table")
+	}
+
+	return nil
+}
+
+// createValidatorIncome creates the validator income table.
+func createValidatorIncome(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_income contains the income of validators for each epoch.
+CREATE TABLE IF NOT EXISTS t_validator_income (
+  f_validator_index   BIGINT NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_epoch             BIGINT NOT NULL
+ ,f_income            BIGINT NOT NULL
+ ,f_effective_balance BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_income_1 ON t_validator_income(f_validator_index, f_epoch);
+CREATE INDEX IF NOT EXISTS i_validator_income_2 ON t_validator_income(f_epoch);
+`); err != nil {
+		return errors.Wrap(err, "failed to create validator income table")
+	}
+
+	return nil
+}
+
+// createValidatorLabels creates the validator labels table.
+func createValidatorLabels(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_labels contains operator-defined labels for validators.
+CREATE TABLE IF NOT EXISTS t_validator_labels (
+  f_validator_index BIGINT NOT NULL -- REFERENCES t_validators(f_index)
+ ,f_label           TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_labels_1 ON t_validator_labels(f_validator_index, f_label);
+CREATE INDEX IF NOT EXISTS i_validator_labels_2 ON t_validator_labels(f_label);
+`); err != nil {
+		return errors.Wrap(err, "failed to create validator labels table")
+	}
+
+	return nil
+}
+
+// addValidatorPercentiles adds percentiles to the validator effectiveness and income tables.
+func addValidatorPercentiles(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Percentiles are not calculated for existing rows, so are nullable.
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_effectiveness ADD COLUMN IF NOT EXISTS f_percentile FLOAT8;
+ALTER TABLE t_validator_income ADD COLUMN IF NOT EXISTS f_percentile FLOAT8;
+`); err != nil {
+		return errors.Wrap(err, "failed to add validator percentiles")
+	}
+
+	return nil
+}
+
+// addDepositsWithdrawalCredentialsIndex adds an index to allow lookup of deposits by withdrawal credentials.
+func addDepositsWithdrawalCredentialsIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_deposits_3 ON t_deposits(f_withdrawal_credentials)"); err != nil {
+		return errors.Wrap(err, "failed to create deposits index (3)")
+	}
+
+	return nil
+}
+
+// createValidatorDaySummaries creates the validator day summaries table.
+func createValidatorDaySummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_day_summaries contains summaries of validators for each day.
+CREATE TABLE IF NOT EXISTS t_validator_day_summaries (
+  f_validator_index              BIGINT NOT NULL
+ ,f_start_timestamp              TIMESTAMPTZ NOT NULL
+ ,f_proposer_duties              INTEGER NOT NULL
+ ,f_proposals_included           INTEGER NOT NULL
+ ,f_attestation_duties           INTEGER NOT NULL
+ ,f_attestations_included        INTEGER NOT NULL
+ ,f_attestations_target_correct  INTEGER NOT NULL
+ ,f_attestations_head_correct    INTEGER NOT NULL
+ ,f_attestations_inclusion_delay FLOAT(4) NOT NULL
+ ,f_attestations_source_timely   INTEGER NOT NULL
+ ,f_attestations_target_timely   INTEGER NOT NULL
+ ,f_attestations_head_timely     INTEGER NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_day_summaries_1 ON t_validator_day_summaries(f_validator_index, f_start_timestamp);
+CREATE INDEX IF NOT EXISTS i_validator_day_summaries_2 ON t_validator_day_summaries(f_start_timestamp);
+-- Allow validator epoch summaries to be pruned efficiently.
+CREATE INDEX IF NOT EXISTS i_validator_epoch_summaries_2 ON t_validator_epoch_summaries(f_epoch);
+`); err != nil {
+		return errors.Wrap(err, "failed to create validator day summaries table")
+	}
+
+	return nil
+}
+
+// addSyncCommitteeSummaries adds sync committee participation to the summary tables.
+func addSyncCommitteeSummaries(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, table := range []string{"t_epoch_summaries", "t_validator_epoch_summaries", "t_validator_day_summaries"} {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %s
+ADD COLUMN IF NOT EXISTS f_sync_committee_participations INTEGER NOT NULL DEFAULT 0
+,ADD COLUMN IF NOT EXISTS f_sync_committee_misses INTEGER NOT NULL DEFAULT 0
+`, table)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to add sync committee columns to %s", table))
+		}
+	}
+
+	return nil
+}
+
+// addBeaconCommitteesMembersIndex adds an index on the members of beacon committees, to allow
+// the attestations for a validator to be found without scanning all committees.
+func addBeaconCommitteesMembersIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_beacon_committees_3 ON t_beacon_committees USING GIN (f_committee)"); err != nil {
+		return errors.Wrap(err, "failed to create beacon committees index (3)")
+	}
+
+	return nil
+}
+
+// addBlocksProposerIndexIndex adds an index to allow lookup of blocks by proposer.
+func addBlocksProposerIndexIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_blocks_5 ON t_blocks(f_proposer_index, f_slot)"); err != nil {
+		return errors.Wrap(err, "failed to create blocks index (5)")
+	}
+
+	return nil
+}
+
+// addETH1DepositsRangeIndices adds indices to allow selection of Ethereum 1 deposits by block number and timestamp.
+func addETH1DepositsRangeIndices(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_eth1_deposits_6 ON t_eth1_deposits(f_eth1_block_number)"); err != nil {
+		return errors.Wrap(err, "failed to create Ethereum 1 deposits index 6")
+	}
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_eth1_deposits_7 ON t_eth1_deposits(f_eth1_block_timestamp)"); err != nil {
+		return errors.Wrap(err, "failed to create Ethereum 1 deposits index 7")
+	}
+
+	return nil
+}
+
+// addAttestationsCommitteeIndex adds an index to allow lookup of attestations by committee.
+func addAttestationsCommitteeIndex(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_attestations_5 ON t_attestations(f_slot,f_committee_index)"); err != nil {
+		return errors.Wrap(err, "failed to create attestations index (5)")
+	}
+
+	return nil
+}
+
+// addExecutionPayloadsIndices adds indices to allow lookup of blocks by execution block hash and number.
+func addExecutionPayloadsIndices(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_block_execution_payloads_1 ON t_block_execution_payloads(f_block_hash)"); err != nil {
+		return errors.Wrap(err, "failed to create block execution payloads index (1)")
+	}
+	if _, err := tx.Exec(ctx, "CREATE INDEX IF NOT EXISTS i_block_execution_payloads_2 ON t_block_execution_payloads(f_block_number)"); err != nil {
+		return errors.Wrap(err, "failed to create block execution payloads index (2)")
+	}
+
+	return nil
+}
+
+// addChainSpecEffectiveEpoch adds the effective epoch to chain specification values,
+// allowing values that change over the life of the chain to be versioned.
+func addChainSpecEffectiveEpoch(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "ALTER TABLE t_chain_spec ADD COLUMN IF NOT EXISTS f_effective_epoch BIGINT NOT NULL DEFAULT 0"); err != nil {
+		return errors.Wrap(err, "failed to add chain spec effective epoch")
+	}
+	if _, err := tx.Exec(ctx, "ALTER TABLE t_chain_spec DROP CONSTRAINT IF EXISTS t_chain_spec_pkey"); err != nil {
+		return errors.Wrap(err, "failed to drop chain spec primary key")
+	}
+	if _, err := tx.Exec(ctx, "ALTER TABLE t_chain_spec ADD PRIMARY KEY (f_key, f_effective_epoch)"); err != nil {
+		return errors.Wrap(err, "failed to add chain spec primary key")
+	}
+
+	return nil
+}
+
+// addValidatorBalanceRuns allows validator balances to be stored as runs of consecutive
+// epochs.  The new column has a constant default, so adding it does not rewrite the
+// table; existing rows are runs of a single epoch.  They are packed after the upgrade,
+// up to the interval that holds the latest balances, which becomes the open interval;
+// the range of epochs to pack is recorded here.
+func addValidatorBalanceRuns(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, "ALTER TABLE t_validator_balances ADD COLUMN IF NOT EXISTS f_balance_changes INTEGER[] NOT NULL DEFAULT '{}'"); err != nil {
+		return errors.Wrap(err, "failed to add validator balance changes")
+	}
+
+	var minEpoch sql.NullInt64
+	var maxEpoch sql.NullInt64
+	if err := tx.QueryRow(ctx, "SELECT MIN(f_epoch), MAX(f_epoch) FROM t_validator_balances").Scan(&minEpoch, &maxEpoch); err != nil {
+		return errors.Wrap(err, "failed to obtain validator balance epochs")
+	}
+	if !maxEpoch.Valid {
+		return nil
+	}
+
+	md := &balancesMetadata{
+		OpenEpoch: balanceRunStart(phase0.Epoch(maxEpoch.Int64)),
+	}
+	if err := s.setBalancesMetadata(ctx, md); err != nil {
+		return err
+	}
+
+	compaction := &balanceCompaction{
+		NextEpoch: balanceRunStart(phase0.Epoch(minEpoch.Int64)),
+		EndEpoch:  md.OpenEpoch,
+	}
+	if compaction.NextEpoch >= compaction.EndEpoch {
+		return nil
+	}
+	data, err := json.Marshal(compaction)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal validator balances compaction")
+	}
+	if err := s.SetMetadata(ctx, balanceCompactionKey, data); err != nil {
+		return errors.Wrap(err, "failed to set validator balances compaction metadata")
+	}
+
+	return nil
+}
+
+// finalityStatusSQL calculates the status of a row from its canonical state, which is
+// 'provisional' until the finalizer has decided whether the row is canonical and
+// 'confirmed' thereafter.
+var finalityStatusSQL = "CASE WHEN f_canonical IS NULL THEN 'provisional' ELSE 'confirmed' END"
+
+// finalityStatusViews are the views that add the finality status to each row of their tables.
+var finalityStatusViews = []struct {
+	view  string
+	table string
+}{
+	{"v_blocks", "t_blocks"},
+	{"v_attestations", "t_attestations"},
+}
+
+// createFinalityStatusViews creates views of blocks and attestations with the finality
+// status of each row.  The status is calculated when the views are read rather than
+// stored, so it does not need to be updated as the finalizer decides canonical state.
+func createFinalityStatusViews(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, view := range finalityStatusViews {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+CREATE OR REPLACE VIEW %s AS
+SELECT %s.*
+      ,%s AS f_status
+FROM %s
+`, view.view, view.table, finalityStatusSQL, view.table)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to create %s", view.view))
+		}
+	}
+
+	return nil
+}
+
+// createBlockArrivals creates the block arrivals table.  There is no foreign key to
+// t_blocks, as blocks are seen before they are written and can be seen without
+// ever being written.
+func createBlockArrivals(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_block_arrivals contains the time at which each block was first seen.
+CREATE TABLE IF NOT EXISTS t_block_arrivals (
+  f_slot         BIGINT NOT NULL
+ ,f_block_root   BYTEA NOT NULL
+ ,f_slot_time    TIMESTAMPTZ NOT NULL
+ ,f_arrival_time TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_arrivals_1 ON t_block_arrivals(f_block_root);
+CREATE INDEX IF NOT EXISTS i_block_arrivals_2 ON t_block_arrivals(f_slot);
+`); err != nil {
+		return errors.Wrap(err, "failed to create block arrivals")
+	}
+
+	return nil
+}
+
+// createGossipAttestations creates the gossip attestations table.
+func createGossipAttestations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_gossip_attestations contains unaggregated attestations as first seen on the network.
+CREATE TABLE IF NOT EXISTS t_gossip_attestations (
+  f_slot              BIGINT NOT NULL
+ ,f_committee_index   BIGINT NOT NULL
+ ,f_aggregation_bits  BYTEA NOT NULL
+ ,f_beacon_block_root BYTEA NOT NULL
+ ,f_source_epoch      BIGINT NOT NULL
+ ,f_source_root       BYTEA NOT NULL
+ ,f_target_epoch      BIGINT NOT NULL
+ ,f_target_root       BYTEA NOT NULL
+ ,f_arrival_time      TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_gossip_attestations_1 ON t_gossip_attestations(f_slot,f_committee_index,f_aggregation_bits,f_beacon_block_root,f_source_root,f_target_root);
+`); err != nil {
+		return errors.Wrap(err, "failed to create gossip attestations")
+	}
+
+	return nil
+}
+
+// createPeerCounts creates the peer counts table.
+func createPeerCounts(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_peer_counts contains the number of peers of the beacon node running each client version over time.
+CREATE TABLE IF NOT EXISTS t_peer_counts (
+  f_timestamp TIMESTAMPTZ NOT NULL
+ ,f_epoch     BIGINT NOT NULL
+ ,f_client    TEXT NOT NULL
+ ,f_version   TEXT NOT NULL
+ ,f_count     INTEGER NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_peer_counts_1 ON t_peer_counts(f_timestamp,f_client,f_version);
+CREATE INDEX IF NOT EXISTS i_peer_counts_2 ON t_peer_counts(f_epoch);
+`); err != nil {
+		return errors.Wrap(err, "failed to create peer counts")
+	}
+
+	return nil
+}
+
+// createBlockClients creates the block clients table.
+func createBlockClients(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_block_clients contains the likely consensus client of the proposer of each block.
+CREATE TABLE IF NOT EXISTS t_block_clients (
+  f_slot           BIGINT NOT NULL
+ ,f_epoch          BIGINT NOT NULL
+ ,f_block_root     BYTEA NOT NULL
+ ,f_proposer_index BIGINT NOT NULL
+ ,f_client         TEXT NOT NULL
+ ,f_confidence     FLOAT8 NOT NULL
+ ,f_signal         TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_block_clients_1 ON t_block_clients(f_block_root);
+CREATE INDEX IF NOT EXISTS i_block_clients_2 ON t_block_clients(f_slot);
+CREATE INDEX IF NOT EXISTS i_block_clients_3 ON t_block_clients(f_epoch);
+`); err != nil {
+		return errors.Wrap(err, "failed to create block clients")
+	}
+
+	return nil
+}
+
+// createTenants creates the tenants table, and the row-level security policies that restrict
+// tenants to the rows of their validators.
+func createTenants(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_tenants contains the database roles that can only read the data of validators with the given labels.
+CREATE TABLE IF NOT EXISTS t_tenants (
+  f_role   TEXT NOT NULL PRIMARY KEY
+ ,f_labels TEXT[] NOT NULL
+);
+
+-- tenant_validators returns the indices of the validators that a role can read if it is
+-- a tenant, or NULL if it is not.  It runs with the privileges of its owner, so tenants
+-- do not require access to the tenants and labels tables.
+CREATE OR REPLACE FUNCTION tenant_validators(tenant_role NAME) RETURNS BIGINT[] AS $$
+  SELECT CASE WHEN t_tenants.f_role IS NULL THEN NULL
+              ELSE ARRAY(
+                SELECT DISTINCT f_validator_index
+                FROM t_validator_labels
+                WHERE f_label = ANY(t_tenants.f_labels)
+              )
+         END
+  FROM (SELECT 1) AS d
+  LEFT JOIN t_tenants ON t_tenants.f_role = tenant_role
+$$ LANGUAGE SQL STABLE SECURITY DEFINER SET search_path FROM CURRENT;
+`); err != nil {
+		return errors.Wrap(err, "failed to create tenants table")
+	}
+
+	tables := make([]string, 0, len(tenantValidatorColumns))
+	for table := range tenantValidatorColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		// The owner of the table, which is chaind, is not subject to the policy.
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+ALTER TABLE %[1]s ENABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS p_tenants ON %[1]s;
+CREATE POLICY p_tenants ON %[1]s USING (
+  (SELECT tenant_validators(current_user)) IS NULL
+  OR %[2]s = ANY((SELECT tenant_validators(current_user)))
+);
+`, table, tenantValidatorColumns[table])); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to create tenant policy on %s", table))
+		}
+	}
+
+	return nil
+}
+
+// addExecutionPayloadRelays adds the relays that delivered execution payloads to the
+// t_block_execution_payloads table.
+func addExecutionPayloadRelays(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+ADD COLUMN IF NOT EXISTS f_relays TEXT[]
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_relays to block execution payloads table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+ADD COLUMN IF NOT EXISTS f_builder_pubkey BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_builder_pubkey to block execution payloads table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_block_execution_payloads
+ADD COLUMN IF NOT EXISTS f_value NUMERIC
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_value to block execution payloads table")
+	}
+
+	return nil
+}
+
+// createValidatorRegistrations creates the validator registrations table.
+func createValidatorRegistrations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_validator_registrations contains the registrations of validators with relays.
+CREATE TABLE IF NOT EXISTS t_validator_registrations (
+  f_validator_index BIGINT NOT NULL
+ ,f_relay           TEXT NOT NULL
+ ,f_timestamp       TIMESTAMPTZ NOT NULL
+ ,f_fee_recipient   BYTEA NOT NULL
+ ,f_gas_limit       BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_registrations_1 ON t_validator_registrations(f_validator_index,f_relay,f_timestamp);
+CREATE INDEX IF NOT EXISTS i_validator_registrations_2 ON t_validator_registrations(f_timestamp);
+`); err != nil {
+		return errors.Wrap(err, "failed to create validator registrations")
+	}
+
+	return nil
+}
+
+// createQuarantine creates the quarantine table.
+func createQuarantine(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+-- t_quarantine contains data that failed to be stored, along with the reason for the failure.
+CREATE TABLE IF NOT EXISTS t_quarantine (
+  f_module    TEXT NOT NULL
+ ,f_slot      BIGINT NOT NULL
+ ,f_root      BYTEA
+ ,f_timestamp TIMESTAMPTZ NOT NULL
+ ,f_reason    TEXT NOT NULL
+ ,f_data      JSONB NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS i_quarantine_1 ON t_quarantine(f_module,f_slot);
+`); err != nil {
+		return errors.Wrap(err, "failed to create quarantine")
+	}
+
+	return nil
+}
+
+// createValidatorBalancesFunction creates a function that provides full validator
+// balances from the runs in which they are stored.  The function runs with the rights
+// of its caller, so row-level security on the balances table applies to it.
+func createValidatorBalancesFunction(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+-- validator_balances returns the balance and effective balance of the given validators
+-- at each stored epoch from start_epoch up to but not including end_epoch.  Runs do not
+-- cross intervals of %[1]d epochs, so only runs that start in the interval containing
+-- start_epoch or later are read.
+CREATE OR REPLACE FUNCTION validator_balances(validator_indices BIGINT[], start_epoch BIGINT, end_epoch BIGINT)
+RETURNS TABLE(f_validator_index BIGINT, f_epoch BIGINT, f_balance BIGINT, f_effective_balance BIGINT)
+LANGUAGE SQL STABLE
+AS $$
+SELECT b.f_validator_index
+      ,b.f_epoch + o.i
+      ,b.f_balance + COALESCE(b.f_balance_changes[o.i], 0)
+      ,b.f_effective_balance
+FROM t_validator_balances AS b
+CROSS JOIN LATERAL generate_series(GREATEST(start_epoch - b.f_epoch, 0)
+                                  ,LEAST(end_epoch - 1 - b.f_epoch, CARDINALITY(b.f_balance_changes))) AS o(i)
+WHERE b.f_validator_index = ANY(validator_indices)
+  AND b.f_epoch >= start_epoch - start_epoch %% %[1]d
+  AND b.f_epoch < end_epoch
+ORDER BY 2, 1
+$$
+`, balanceRunInterval)); err != nil {
+		return errors.Wrap(err, "failed to create validator balances function")
+	}
+
+	return nil
+}
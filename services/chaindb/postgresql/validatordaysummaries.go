@@ -0,0 +1,134 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorDaySummaries sets multiple validator day summaries.
+func (s *Service) SetValidatorDaySummaries(ctx context.Context, summaries []*chaindb.ValidatorDaySummary) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	indices := make([]int64, len(summaries))
+	startTimestamps := make([]time.Time, len(summaries))
+	proposerDuties := make([]int32, len(summaries))
+	proposalsIncluded := make([]int32, len(summaries))
+	attestationDuties := make([]int32, len(summaries))
+	attestationsIncluded := make([]int32, len(summaries))
+	attestationsTargetCorrect := make([]int32, len(summaries))
+	attestationsHeadCorrect := make([]int32, len(summaries))
+	attestationsInclusionDelay := make([]float64, len(summaries))
+	attestationsSourceTimely := make([]int32, len(summaries))
+	attestationsTargetTimely := make([]int32, len(summaries))
+	attestationsHeadTimely := make([]int32, len(summaries))
+	syncCommitteeParticipations := make([]int32, len(summaries))
+	syncCommitteeMisses := make([]int32, len(summaries))
+	for i := range summaries {
+		indices[i] = int64(summaries[i].Index)
+		startTimestamps[i] = summaries[i].StartTimestamp
+		proposerDuties[i] = int32(summaries[i].ProposerDuties)
+		proposalsIncluded[i] = int32(summaries[i].ProposalsIncluded)
+		attestationDuties[i] = int32(summaries[i].AttestationDuties)
+		attestationsIncluded[i] = int32(summaries[i].AttestationsIncluded)
+		attestationsTargetCorrect[i] = int32(summaries[i].AttestationsTargetCorrect)
+		attestationsHeadCorrect[i] = int32(summaries[i].AttestationsHeadCorrect)
+		attestationsInclusionDelay[i] = summaries[i].AttestationsInclusionDelay
+		attestationsSourceTimely[i] = int32(summaries[i].AttestationsSourceTimely)
+		attestationsTargetTimely[i] = int32(summaries[i].AttestationsTargetTimely)
+		attestationsHeadTimely[i] = int32(summaries[i].AttestationsHeadTimely)
+		syncCommitteeParticipations[i] = int32(summaries[i].SyncCommitteeParticipations)
+		syncCommitteeMisses[i] = int32(summaries[i].SyncCommitteeMisses)
+	}
+
+	// Summaries may be recalculated, so upsert rather than copy.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_day_summaries(f_validator_index
+                                     ,f_start_timestamp
+                                     ,f_proposer_duties
+                                     ,f_proposals_included
+                                     ,f_attestation_duties
+                                     ,f_attestations_included
+                                     ,f_attestations_target_correct
+                                     ,f_attestations_head_correct
+                                     ,f_attestations_inclusion_delay
+                                     ,f_attestations_source_timely
+                                     ,f_attestations_target_timely
+                                     ,f_attestations_head_timely
+                                     ,f_sync_committee_participations
+                                     ,f_sync_committee_misses)
+SELECT * FROM UNNEST($1::BIGINT[],$2::TIMESTAMPTZ[],$3::INTEGER[],$4::INTEGER[],$5::INTEGER[],$6::INTEGER[],$7::INTEGER[],$8::INTEGER[],$9::FLOAT4[],$10::INTEGER[],$11::INTEGER[],$12::INTEGER[],$13::INTEGER[],$14::INTEGER[])
+ON CONFLICT (f_validator_index,f_start_timestamp) DO
+UPDATE
+SET f_proposer_duties = excluded.f_proposer_duties
+   ,f_proposals_included = excluded.f_proposals_included
+   ,f_attestation_duties = excluded.f_attestation_duties
+   ,f_attestations_included = excluded.f_attestations_included
+   ,f_attestations_target_correct = excluded.f_attestations_target_correct
+   ,f_attestations_head_correct = excluded.f_attestations_head_correct
+   ,f_attestations_inclusion_delay = excluded.f_attestations_inclusion_delay
+   ,f_attestations_source_timely = excluded.f_attestations_source_timely
+   ,f_attestations_target_timely = excluded.f_attestations_target_timely
+   ,f_attestations_head_timely = excluded.f_attestations_head_timely
+   ,f_sync_committee_participations = excluded.f_sync_committee_participations
+   ,f_sync_committee_misses = excluded.f_sync_committee_misses
+`,
+		indices,
+		startTimestamps,
+		proposerDuties,
+		proposalsIncluded,
+		attestationDuties,
+		attestationsIncluded,
+		attestationsTargetCorrect,
+		attestationsHeadCorrect,
+		attestationsInclusionDelay,
+		attestationsSourceTimely,
+		attestationsTargetTimely,
+		attestationsHeadTimely,
+		syncCommitteeParticipations,
+		syncCommitteeMisses,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_day_summaries")
+		return err
+	}
+	s.monitorRowsWritten("t_validator_day_summaries", 1)
+
+	return nil
+}
+
+// PruneValidatorDaySummaries prunes validator day summaries up to (but not including) the given timestamp.
+func (s *Service) PruneValidatorDaySummaries(ctx context.Context, to time.Time) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_day_summaries
+WHERE f_start_timestamp < $1
+`,
+		to,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_day_summaries")
+		return err
+	}
+
+	return nil
+}
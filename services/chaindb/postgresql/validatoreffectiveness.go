@@ -0,0 +1,198 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorEffectiveness sets multiple validator effectiveness scores.
+func (s *Service) SetValidatorEffectiveness(ctx context.Context, effectiveness []*chaindb.ValidatorEffectiveness) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	indices := make([]int64, len(effectiveness))
+	epochs := make([]int64, len(effectiveness))
+	scores := make([]float64, len(effectiveness))
+	percentiles := make([]float64, len(effectiveness))
+	for i := range effectiveness {
+		indices[i] = int64(effectiveness[i].Index)
+		epochs[i] = int64(effectiveness[i].Epoch)
+		scores[i] = effectiveness[i].Effectiveness
+		percentiles[i] = effectiveness[i].Percentile
+	}
+
+	// Scores may be recalculated, so upsert rather than copy.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_effectiveness(f_validator_index
+                                     ,f_epoch
+                                     ,f_effectiveness
+                                     ,f_percentile)
+SELECT * FROM UNNEST($1::BIGINT[],$2::BIGINT[],$3::FLOAT8[],$4::FLOAT8[])
+ON CONFLICT (f_validator_index,f_epoch) DO
+UPDATE
+SET f_effectiveness = excluded.f_effectiveness
+   ,f_percentile = excluded.f_percentile
+`,
+		indices,
+		epochs,
+		scores,
+		percentiles,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_effectiveness")
+		return err
+	}
+	s.monitorRowsWritten("t_validator_effectiveness", len(indices))
+
+	return nil
+}
+
+// ValidatorEffectiveness provides the mean effectiveness of validators over a range of epochs,
+// keyed by validator index.  If validators is nil then all validators are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) ValidatorEffectiveness(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]float64,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,AVG(f_effectiveness)
+FROM t_validator_effectiveness
+WHERE f_epoch >= $1
+  AND f_epoch <= $2`)
+	queryVals = append(queryVals, startEpoch, endEpoch)
+
+	if validators != nil {
+		queryBuilder.WriteString(`
+  AND f_validator_index = ANY($3)`)
+		queryVals = append(queryVals, validators)
+	}
+
+	queryBuilder.WriteString(`
+GROUP BY f_validator_index`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	effectiveness := make(map[phase0.ValidatorIndex]float64)
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		var score float64
+		if err := rows.Scan(&index, &score); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		effectiveness[index] = score
+	}
+
+	return effectiveness, nil
+}
+
+// ValidatorEffectivenessByLabel provides the mean effectiveness of the validators with each
+// label over a range of epochs, keyed by label.  If labels is nil then all labels are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) ValidatorEffectivenessByLabel(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[string]float64,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT t_validator_labels.f_label
+      ,AVG(t_validator_effectiveness.f_effectiveness)
+FROM t_validator_effectiveness
+JOIN t_validator_labels
+  ON t_validator_labels.f_validator_index = t_validator_effectiveness.f_validator_index
+WHERE t_validator_effectiveness.f_epoch >= $1
+  AND t_validator_effectiveness.f_epoch <= $2`)
+	queryVals = append(queryVals, startEpoch, endEpoch)
+
+	if labels != nil {
+		queryBuilder.WriteString(`
+  AND t_validator_labels.f_label = ANY($3)`)
+		queryVals = append(queryVals, labels)
+	}
+
+	queryBuilder.WriteString(`
+GROUP BY t_validator_labels.f_label`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	effectiveness := make(map[string]float64)
+	for rows.Next() {
+		var label string
+		var score float64
+		if err := rows.Scan(&label, &score); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		effectiveness[label] = score
+	}
+
+	return effectiveness, nil
+}
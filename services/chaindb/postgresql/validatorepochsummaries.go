@@ -33,6 +33,16 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 		return ErrNoTransaction
 	}
 
+	if s.filter != nil {
+		filtered := make([]*chaindb.ValidatorEpochSummary, 0, len(summaries))
+		for _, summary := range summaries {
+			if s.filter.IncludeValidatorEpochSummary(ctx, summary) {
+				filtered = append(filtered, summary)
+			}
+		}
+		summaries = filtered
+	}
+
 	// Create a savepoint in case the copy fails.
 	nestedTx, err := tx.Begin(ctx)
 	if err != nil {
@@ -53,6 +63,8 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 			"f_attestation_source_timely",
 			"f_attestation_target_timely",
 			"f_attestation_head_timely",
+			"f_sync_committee_participations",
+			"f_sync_committee_misses",
 		},
 		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
 			return []interface{}{
@@ -67,6 +79,8 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 				summaries[i].AttestationSourceTimely,
 				summaries[i].AttestationTargetTimely,
 				summaries[i].AttestationHeadTimely,
+				summaries[i].SyncCommitteeParticipations,
+				summaries[i].SyncCommitteeMisses,
 			}, nil
 		}))
 
@@ -74,15 +88,16 @@ func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*c
 		if err := nestedTx.Commit(ctx); err != nil {
 			return errors.Wrap(err, "failed to commit nested transaction")
 		}
+		s.monitorRowsWritten("t_validator_epoch_summaries", len(summaries))
 	} else {
 		if err := nestedTx.Rollback(ctx); err != nil {
 			return errors.Wrap(err, "failed to roll back nested transaction")
 		}
 
-		log.Debug().Err(err).Msg("Failed to copy insert blocks; applying one at a time")
+		s.log.Debug().Err(err).Msg("Failed to copy insert blocks; applying one at a time")
 		for _, summary := range summaries {
 			if err := s.SetValidatorEpochSummary(ctx, summary); err != nil {
-				log.Info().Msg("Failure to insert individual summary")
+				s.log.Info().Msg("Failure to insert individual summary")
 				return err
 			}
 		}
@@ -101,6 +116,10 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		return ErrNoTransaction
 	}
 
+	if s.filter != nil && !s.filter.IncludeValidatorEpochSummary(ctx, summary) {
+		return nil
+	}
+
 	var attestationTargetCorrect sql.NullBool
 	var attestationHeadCorrect sql.NullBool
 	var attestationInclusionDelay sql.NullInt32
@@ -144,8 +163,10 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
                               ,f_attestation_inclusion_delay
                               ,f_attestation_source_timely
                               ,f_attestation_target_timely
-                              ,f_attestation_head_timely)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+                              ,f_attestation_head_timely
+                              ,f_sync_committee_participations
+                              ,f_sync_committee_misses)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
       ON CONFLICT (f_validator_index,f_epoch) DO
       UPDATE
       SET f_proposer_duties = excluded.f_proposer_duties
@@ -157,6 +178,8 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
          ,f_attestation_source_timely = excluded.f_attestation_source_timely
          ,f_attestation_target_timely = excluded.f_attestation_target_timely
          ,f_attestation_head_timely = excluded.f_attestation_head_timely
+         ,f_sync_committee_participations = excluded.f_sync_committee_participations
+         ,f_sync_committee_misses = excluded.f_sync_committee_misses
 		 `,
 		summary.Index,
 		summary.Epoch,
@@ -169,11 +192,34 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationSourceTimely,
 		attestationTargetTimely,
 		attestationHeadTimely,
+		summary.SyncCommitteeParticipations,
+		summary.SyncCommitteeMisses,
 	)
+	s.monitorWrite("t_validator_epoch_summaries", 1, err)
 
 	return err
 }
 
+// PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given epoch.
+func (s *Service) PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_epoch_summaries
+WHERE f_epoch < $1
+`,
+		to,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_epoch_summaries")
+		return err
+	}
+
+	return nil
+}
+
 // ValidatorSummaries provides summaries according to the filter.
 func (s *Service) ValidatorSummaries(ctx context.Context, filter *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
 	tx := s.tx(ctx)
@@ -202,6 +248,8 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_sync_committee_participations
+      ,f_sync_committee_misses
 FROM t_validator_epoch_summaries`)
 
 	wherestr := "WHERE"
@@ -217,12 +265,20 @@ FROM t_validator_epoch_summaries`)
 		queryVals = append(queryVals, *filter.To)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
 	}
 
 	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
 		queryVals = append(queryVals, *filter.ValidatorIndices)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_validator_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Labels != nil {
+		queryVals = append(queryVals, filter.Labels)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_validator_index IN (SELECT f_validator_index FROM t_validator_labels WHERE f_label = ANY($%d))`, wherestr, len(queryVals)))
 	}
 
 	switch filter.Order {
@@ -270,6 +326,8 @@ LIMIT $%d`, len(queryVals)))
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
+			&summary.SyncCommitteeParticipations,
+			&summary.SyncCommitteeMisses,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -336,6 +394,8 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_sync_committee_participations
+      ,f_sync_committee_misses
 FROM t_validator_epoch_summaries
 WHERE f_epoch = $1
 ORDER BY f_validator_index
@@ -369,6 +429,8 @@ ORDER BY f_validator_index
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
+			&summary.SyncCommitteeParticipations,
+			&summary.SyncCommitteeMisses,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -441,6 +503,8 @@ SELECT f_validator_index
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
+      ,f_sync_committee_participations
+      ,f_sync_committee_misses
 FROM t_validator_epoch_summaries
 WHERE f_validator_index = $1
   AND f_epoch = $2
@@ -459,6 +523,8 @@ WHERE f_validator_index = $1
 		&attestationSourceTimely,
 		&attestationTargetTimely,
 		&attestationHeadTimely,
+		&summary.SyncCommitteeParticipations,
+		&summary.SyncCommitteeMisses,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to scan row")
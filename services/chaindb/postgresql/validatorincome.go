@@ -0,0 +1,323 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorIncome sets multiple validator incomes.
+func (s *Service) SetValidatorIncome(ctx context.Context, income []*chaindb.ValidatorIncome) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	indices := make([]int64, len(income))
+	epochs := make([]int64, len(income))
+	amounts := make([]int64, len(income))
+	effectiveBalances := make([]int64, len(income))
+	percentiles := make([]float64, len(income))
+	for i := range income {
+		indices[i] = int64(income[i].Index)
+		epochs[i] = int64(income[i].Epoch)
+		amounts[i] = income[i].Income
+		effectiveBalances[i] = int64(income[i].EffectiveBalance)
+		percentiles[i] = income[i].Percentile
+	}
+
+	// Income may be recalculated, so upsert rather than copy.
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_income(f_validator_index
+                              ,f_epoch
+                              ,f_income
+                              ,f_effective_balance
+                              ,f_percentile)
+SELECT * FROM UNNEST($1::BIGINT[],$2::BIGINT[],$3::BIGINT[],$4::BIGINT[],$5::FLOAT8[])
+ON CONFLICT (f_validator_index,f_epoch) DO
+UPDATE
+SET f_income = excluded.f_income
+   ,f_effective_balance = excluded.f_effective_balance
+   ,f_percentile = excluded.f_percentile
+`,
+		indices,
+		epochs,
+		amounts,
+		effectiveBalances,
+		percentiles,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_income")
+		return err
+	}
+	s.monitorRowsWritten("t_validator_income", len(indices))
+
+	return nil
+}
+
+// ValidatorIncome provides the income of validators over a range of epochs,
+// keyed by validator index.  If validators is nil then all validators are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) ValidatorIncome(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]*chaindb.AggregateValidatorIncome,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_validator_index
+      ,SUM(f_income)::BIGINT
+      ,AVG(f_effective_balance)::BIGINT
+      ,COUNT(*)
+FROM t_validator_income
+WHERE f_epoch >= $1
+  AND f_epoch <= $2`)
+	queryVals = append(queryVals, startEpoch, endEpoch)
+
+	if validators != nil {
+		queryBuilder.WriteString(`
+  AND f_validator_index = ANY($3)`)
+		queryVals = append(queryVals, validators)
+	}
+
+	queryBuilder.WriteString(`
+GROUP BY f_validator_index`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	income := make(map[phase0.ValidatorIndex]*chaindb.AggregateValidatorIncome)
+	for rows.Next() {
+		aggregate := &chaindb.AggregateValidatorIncome{}
+		if err := rows.Scan(
+			&aggregate.Index,
+			&aggregate.Income,
+			&aggregate.EffectiveBalance,
+			&aggregate.Epochs,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		income[aggregate.Index] = aggregate
+	}
+
+	return income, nil
+}
+
+// ValidatorIncomeByLabel provides the income of the validators with each label over a
+// range of epochs, keyed by label.  If labels is nil then all labels are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) ValidatorIncomeByLabel(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[string]*chaindb.AggregateLabelIncome,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT t_validator_labels.f_label
+      ,SUM(t_validator_income.f_income)::BIGINT
+      ,AVG(t_validator_income.f_effective_balance)::BIGINT
+      ,COUNT(*)
+FROM t_validator_income
+JOIN t_validator_labels
+  ON t_validator_labels.f_validator_index = t_validator_income.f_validator_index
+WHERE t_validator_income.f_epoch >= $1
+  AND t_validator_income.f_epoch <= $2`)
+	queryVals = append(queryVals, startEpoch, endEpoch)
+
+	if labels != nil {
+		queryBuilder.WriteString(`
+  AND t_validator_labels.f_label = ANY($3)`)
+		queryVals = append(queryVals, labels)
+	}
+
+	queryBuilder.WriteString(`
+GROUP BY t_validator_labels.f_label`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	income := make(map[string]*chaindb.AggregateLabelIncome)
+	for rows.Next() {
+		aggregate := &chaindb.AggregateLabelIncome{}
+		if err := rows.Scan(
+			&aggregate.Label,
+			&aggregate.Income,
+			&aggregate.EffectiveBalance,
+			&aggregate.ValidatorEpochs,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		income[aggregate.Label] = aggregate
+	}
+
+	return income, nil
+}
+
+// WindowedValidatorIncome provides the income of validators over consecutive windows of
+// the given number of epochs within a range of epochs, for all validators and for the validators
+// with each of the given labels.  If labels is nil then only the income for all validators is provided.
+// Ranges are inclusive of start and end; the final window is shorter if the range is not a multiple
+// of the window.  A window of 0 epochs covers the entire range.
+func (s *Service) WindowedValidatorIncome(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	windowEpochs uint64,
+) (
+	[]*chaindb.AggregateWindowIncome,
+	error,
+) {
+	income := make([]*chaindb.AggregateWindowIncome, 0)
+	if endEpoch < startEpoch {
+		return income, nil
+	}
+	if windowEpochs == 0 {
+		windowEpochs = uint64(endEpoch-startEpoch) + 1
+	}
+
+	var err error
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	// Effective balances are summed as floating point values, as the sum over a long range can overflow a BIGINT.
+	queryBuilder.WriteString(`
+SELECT '' AS f_label
+      ,(f_epoch - $1::BIGINT) / $3::BIGINT AS f_window
+      ,SUM(f_income)::BIGINT
+      ,SUM(f_effective_balance)::FLOAT8
+      ,COUNT(*)
+FROM t_validator_income
+WHERE f_epoch >= $1
+  AND f_epoch <= $2
+GROUP BY f_window`)
+	queryVals = append(queryVals, startEpoch, endEpoch, windowEpochs)
+
+	if labels != nil {
+		queryBuilder.WriteString(`
+UNION ALL
+SELECT t_validator_labels.f_label
+      ,(t_validator_income.f_epoch - $1::BIGINT) / $3::BIGINT AS f_window
+      ,SUM(t_validator_income.f_income)::BIGINT
+      ,SUM(t_validator_income.f_effective_balance)::FLOAT8
+      ,COUNT(*)
+FROM t_validator_income
+JOIN t_validator_labels
+  ON t_validator_labels.f_validator_index = t_validator_income.f_validator_index
+WHERE t_validator_income.f_epoch >= $1
+  AND t_validator_income.f_epoch <= $2
+  AND t_validator_labels.f_label = ANY($4)
+GROUP BY t_validator_labels.f_label
+        ,f_window`)
+		queryVals = append(queryVals, labels)
+	}
+
+	queryBuilder.WriteString(`
+ORDER BY f_label
+        ,f_window`)
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		aggregate := &chaindb.AggregateWindowIncome{}
+		var window uint64
+		var effectiveBalance float64
+		if err := rows.Scan(
+			&aggregate.Label,
+			&window,
+			&aggregate.Income,
+			&effectiveBalance,
+			&aggregate.ValidatorEpochs,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		aggregate.StartEpoch = startEpoch + phase0.Epoch(window*windowEpochs)
+		aggregate.EndEpoch = aggregate.StartEpoch + phase0.Epoch(windowEpochs) - 1
+		if aggregate.EndEpoch > endEpoch {
+			aggregate.EndEpoch = endEpoch
+		}
+		if aggregate.ValidatorEpochs > 0 {
+			aggregate.EffectiveBalance = phase0.Gwei(effectiveBalance / float64(aggregate.ValidatorEpochs))
+		}
+		income = append(income, aggregate)
+	}
+
+	return income, nil
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// SetValidatorLabels sets the labels of a validator, replacing any existing labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, index phase0.ValidatorIndex, labels []string) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, label := range labels {
+		if label == "" {
+			return errors.New("empty label")
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+DELETE FROM t_validator_labels
+WHERE f_validator_index = $1
+`,
+		index,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_labels")
+		return err
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_labels(f_validator_index
+                              ,f_label)
+SELECT $1, UNNEST($2::TEXT[])
+ON CONFLICT (f_validator_index,f_label) DO NOTHING
+`,
+		index,
+		labels,
+	); err != nil {
+		s.monitorWriteFailure("t_validator_labels")
+		return err
+	}
+	s.monitorRowsWritten("t_validator_labels", len(labels))
+
+	return nil
+}
+
+// ValidatorLabels provides the labels of validators, keyed by validator index.
+// If validators is nil then all labelled validators are returned.
+func (s *Service) ValidatorLabels(ctx context.Context, validators []phase0.ValidatorIndex) (map[phase0.ValidatorIndex][]string, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	query := `
+SELECT f_validator_index
+      ,f_label
+FROM t_validator_labels`
+	queryVals := make([]interface{}, 0)
+	if validators != nil {
+		query += `
+WHERE f_validator_index = ANY($1)`
+		queryVals = append(queryVals, validators)
+	}
+	query += `
+ORDER BY f_validator_index, f_label`
+
+	rows, err := tx.Query(ctx,
+		query,
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[phase0.ValidatorIndex][]string)
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		var label string
+		if err := rows.Scan(&index, &label); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		labels[index] = append(labels[index], label)
+	}
+
+	return labels, nil
+}
+
+// ValidatorsByLabel provides the indices of validators with any of the given labels.
+func (s *Service) ValidatorsByLabel(ctx context.Context, labels []string) ([]phase0.ValidatorIndex, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT DISTINCT f_validator_index
+FROM t_validator_labels
+WHERE f_label = ANY($1)
+ORDER BY f_validator_index`,
+		labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	validators := make([]phase0.ValidatorIndex, 0)
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		if err := rows.Scan(&index); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		validators = append(validators, index)
+	}
+
+	return validators, nil
+}
@@ -0,0 +1,140 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidatorRegistrations records the registrations of validators with relays.  Registrations
+// that have already been recorded are ignored.
+func (s *Service) SetValidatorRegistrations(ctx context.Context, registrations []*chaindb.ValidatorRegistration) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	validatorIndices := make([]int64, len(registrations))
+	relays := make([]string, len(registrations))
+	timestamps := make([]time.Time, len(registrations))
+	feeRecipients := make([][]byte, len(registrations))
+	gasLimits := make([]int64, len(registrations))
+	for i, registration := range registrations {
+		validatorIndices[i] = int64(registration.ValidatorIndex)
+		relays[i] = registration.Relay
+		timestamps[i] = registration.Timestamp
+		feeRecipient := registration.FeeRecipient
+		feeRecipients[i] = feeRecipient[:]
+		gasLimits[i] = int64(registration.GasLimit)
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_validator_registrations(f_validator_index
+                                     ,f_relay
+                                     ,f_timestamp
+                                     ,f_fee_recipient
+                                     ,f_gas_limit)
+SELECT * FROM UNNEST($1::BIGINT[],$2::TEXT[],$3::TIMESTAMPTZ[],$4::BYTEA[],$5::BIGINT[])
+ON CONFLICT (f_validator_index,f_relay,f_timestamp) DO NOTHING
+`,
+		validatorIndices,
+		relays,
+		timestamps,
+		feeRecipients,
+		gasLimits,
+	)
+	s.monitorWrite("t_validator_registrations", len(registrations), err)
+
+	return err
+}
+
+// ValidatorRegistrations fetches the registrations of the given validators with relays that were
+// made in the given time range, ordered by validator and time.  If validators is nil then the
+// registrations of all validators are returned.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ValidatorRegistrations(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	from time.Time,
+	to time.Time,
+) (
+	[]*chaindb.ValidatorRegistration,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	var validatorIndices []int64
+	if validators != nil {
+		validatorIndices = make([]int64, len(validators))
+		for i, validator := range validators {
+			validatorIndices[i] = int64(validator)
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_validator_index
+            ,f_relay
+            ,f_timestamp
+            ,f_fee_recipient
+            ,f_gas_limit
+      FROM t_validator_registrations
+      WHERE ($1::BIGINT[] IS NULL OR f_validator_index = ANY($1))
+        AND f_timestamp >= $2
+        AND f_timestamp < $3
+      ORDER BY f_validator_index
+              ,f_timestamp
+              ,f_relay`,
+		validatorIndices,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registrations := make([]*chaindb.ValidatorRegistration, 0)
+	for rows.Next() {
+		registration := &chaindb.ValidatorRegistration{}
+		var feeRecipient []byte
+		err := rows.Scan(
+			&registration.ValidatorIndex,
+			&registration.Relay,
+			&registration.Timestamp,
+			&feeRecipient,
+			&registration.GasLimit,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(registration.FeeRecipient[:], feeRecipient)
+		registrations = append(registrations, registration)
+	}
+
+	return registrations, nil
+}
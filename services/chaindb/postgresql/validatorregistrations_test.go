@@ -0,0 +1,82 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestValidatorRegistrations(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	timestamp := time.Unix(1663000000, 0)
+	registrations := []*chaindb.ValidatorRegistration{
+		{
+			ValidatorIndex: 1,
+			Relay:          "relay1.example.com",
+			Timestamp:      timestamp,
+			FeeRecipient:   [20]byte{0x01},
+			GasLimit:       30000000,
+		},
+		{
+			ValidatorIndex: 1,
+			Relay:          "relay1.example.com",
+			Timestamp:      timestamp.Add(time.Hour),
+			FeeRecipient:   [20]byte{0x02},
+			GasLimit:       30000000,
+		},
+		{
+			ValidatorIndex: 2,
+			Relay:          "relay2.example.com",
+			Timestamp:      timestamp,
+			FeeRecipient:   [20]byte{0x03},
+			GasLimit:       25000000,
+		},
+	}
+
+	// Try to set outside of a transaction; should fail.
+	require.EqualError(t, s.SetValidatorRegistrations(ctx, registrations), postgresql.ErrNoTransaction.Error())
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetValidatorRegistrations(ctx, registrations))
+	// Setting again should be ignored.
+	require.NoError(t, s.SetValidatorRegistrations(ctx, registrations))
+
+	res, err := s.ValidatorRegistrations(ctx, nil, timestamp, timestamp.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	res, err = s.ValidatorRegistrations(ctx, []phase0.ValidatorIndex{1}, timestamp, timestamp.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, [20]byte{0x01}, res[0].FeeRecipient)
+	require.True(t, timestamp.Equal(res[0].Timestamp))
+}
@@ -18,8 +18,8 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
-	"strings"
 
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
@@ -86,8 +86,14 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
 		withdrawableEpoch,
 		validator.EffectiveBalance,
 	)
+	s.monitorWrite("t_validators", 1, err)
+	if err != nil {
+		return err
+	}
 
-	return err
+	s.afterCommit(ctx, func() { s.cacheDelete(ctx, validatorCacheKey(validator.Index)) })
+
+	return nil
 }
 
 // SetValidatorBalance sets a validator's balance.
@@ -97,24 +103,34 @@ func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.Vali
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	return s.setBalances(ctx, tx, []*chaindb.ValidatorBalance{balance}, s.upsertBalances)
+}
+
+// upsertBalances writes balances as runs of a single epoch, replacing any existing balances.
+func (s *Service) upsertBalances(ctx context.Context, tx pgx.Tx, balances []*chaindb.ValidatorBalance) error {
+	for _, balance := range balances {
+		_, err := tx.Exec(ctx, `
       INSERT INTO t_validator_balances(f_validator_index
                                       ,f_epoch
                                       ,f_balance
-                                      ,f_effective_balance)
-      VALUES($1,$2,$3,$4)
+                                      ,f_effective_balance
+                                      ,f_balance_changes)
+      VALUES($1,$2,$3,$4,$5)
       ON CONFLICT (f_validator_index, f_epoch) DO
       UPDATE
       SET f_balance = excluded.f_balance
          ,f_effective_balance = excluded.f_effective_balance
+         ,f_balance_changes = excluded.f_balance_changes
 		 `,
-		balance.Index,
-		balance.Epoch,
-		balance.Balance,
-		balance.EffectiveBalance,
-	)
+			newBalanceRun(balance).values()...,
+		)
+		s.monitorWrite("t_validator_balances", 1, err)
+		if err != nil {
+			return err
+		}
+	}
 
-	return err
+	return nil
 }
 
 // SetValidatorBalances sets multiple validator balances.
@@ -124,23 +140,7 @@ func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.
 		return ErrNoTransaction
 	}
 
-	_, err := tx.CopyFrom(ctx,
-		pgx.Identifier{"t_validator_balances"},
-		[]string{
-			"f_validator_index",
-			"f_epoch",
-			"f_balance",
-			"f_effective_balance",
-		},
-		pgx.CopyFromSlice(len(balances), func(i int) ([]interface{}, error) {
-			return []interface{}{
-				balances[i].Index,
-				balances[i].Epoch,
-				balances[i].Balance,
-				balances[i].EffectiveBalance,
-			}, nil
-		}))
-	return err
+	return s.setBalances(ctx, tx, balances, s.copyBalances)
 }
 
 // Validators fetches all validators.
@@ -237,7 +237,25 @@ func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BL
 
 // ValidatorsByIndex fetches all validators matching the given indices.
 func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	validators := make(map[phase0.ValidatorIndex]*chaindb.Validator)
+
+	// Obtain what we can from the cache.
+	uncachedIndices := make([]phase0.ValidatorIndex, 0, len(indices))
+	for _, index := range indices {
+		validator := &chaindb.Validator{}
+		if s.cacheGet(ctx, validatorCacheKey(index), validator) {
+			validators[index] = validator
+		} else {
+			uncachedIndices = append(uncachedIndices, index)
+		}
+	}
+	if len(uncachedIndices) == 0 {
+		return validators, nil
+	}
+
 	tx := s.tx(ctx)
+	// Only cache the results if they are not from within a transaction.
+	cacheable := tx == nil
 	if tx == nil {
 		ctx, cancel, err := s.BeginTx(ctx)
 		if err != nil {
@@ -260,31 +278,33 @@ func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.Valida
       WHERE f_index = ANY($1)
       ORDER BY f_index
 	  `,
-		indices,
+		uncachedIndices,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	validators := make(map[phase0.ValidatorIndex]*chaindb.Validator)
 	for rows.Next() {
 		validator, err := validatorFromRow(rows)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		validators[validator.Index] = validator
+		if cacheable {
+			s.cacheSet(ctx, validatorCacheKey(validator.Index), validator)
+		}
 	}
 
 	return validators, nil
 }
 
-// ValidatorBalancesByEpoch fetches the validator balances for the given epoch.
-func (s *Service) ValidatorBalancesByEpoch(
-	ctx context.Context,
-	epoch phase0.Epoch,
+// ValidatorStatuses fetches the current statuses of all validators matching the given indices or public keys.
+func (s *Service) ValidatorStatuses(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+	pubKeys []phase0.BLSPubKey,
 ) (
-	[]*chaindb.ValidatorBalance,
+	[]*chaindb.ValidatorStatus,
 	error,
 ) {
 	tx := s.tx(ctx)
@@ -297,37 +317,218 @@ func (s *Service) ValidatorBalancesByEpoch(
 		defer cancel()
 	}
 
+	sqlPubKeys := make([][]byte, len(pubKeys))
+	for i := range pubKeys {
+		sqlPubKeys[i] = pubKeys[i][:]
+	}
+
 	rows, err := tx.Query(ctx, `
-      SELECT f_validator_index
-            ,f_epoch
-            ,f_balance
+      SELECT f_public_key
+            ,f_index
+            ,f_slashed
+            ,f_activation_eligibility_epoch
+            ,f_activation_epoch
+            ,f_exit_epoch
+            ,f_withdrawable_epoch
             ,f_effective_balance
-      FROM t_validator_balances
-      WHERE f_epoch = $1::BIGINT
-      ORDER BY f_validator_index`,
-		uint64(epoch),
+      FROM t_validators
+      WHERE f_index = ANY($1)
+         OR f_public_key = ANY($2)
+      ORDER BY f_index
+	  `,
+		indices,
+		sqlPubKeys,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	validatorBalances := make([]*chaindb.ValidatorBalance, 0)
-
+	statuses := make([]*chaindb.ValidatorStatus, 0, len(indices)+len(pubKeys))
 	for rows.Next() {
-		validatorBalance, err := validatorBalanceFromRow(rows)
+		validator, err := validatorFromRow(rows)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
+			return nil, err
 		}
+		statuses = append(statuses, &chaindb.ValidatorStatus{
+			Validator: validator,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return statuses, nil
+	}
+
+	epoch, hasBalances, err := s.latestBalanceEpoch(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	validatorIndices := make([]phase0.ValidatorIndex, len(statuses))
+	for i, status := range statuses {
+		validatorIndices[i] = status.Validator.Index
+	}
+	balances := make(map[phase0.ValidatorIndex]phase0.Gwei, len(statuses))
+	if hasBalances {
+		err := s.forEachReconstructedBalance(ctx, tx, validatorIndices, []phase0.Epoch{epoch}, func(validatorBalance *chaindb.ValidatorBalance) error {
+			balances[validatorBalance.Index] = validatorBalance.Balance
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	credentials, err := s.firstDepositWithdrawalCredentials(ctx, tx, statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		validator := status.Validator
+		status.Epoch = epoch
+		status.Balance = balances[validator.Index]
+		status.State = apiv1.ValidatorToState(&phase0.Validator{
+			ActivationEligibilityEpoch: validator.ActivationEligibilityEpoch,
+			ActivationEpoch:            validator.ActivationEpoch,
+			ExitEpoch:                  validator.ExitEpoch,
+			WithdrawableEpoch:          validator.WithdrawableEpoch,
+			Slashed:                    validator.Slashed,
+		}, epoch, farFutureEpoch)
+		if status.State == apiv1.ValidatorStateWithdrawalPossible && hasBalances && status.Balance == 0 {
+			status.State = apiv1.ValidatorStateWithdrawalDone
+		}
+		status.WithdrawalCredentials = credentials[validator.PublicKey]
+	}
+
+	return statuses, nil
+}
+
+// firstDepositWithdrawalCredentials fetches the withdrawal credentials of the first deposit for each validator.
+// Deposits included in canonical blocks are used in preference, falling back to Ethereum 1 deposits for
+// validators without one, such as those in the genesis state.
+func (*Service) firstDepositWithdrawalCredentials(ctx context.Context,
+	tx pgx.Tx,
+	statuses []*chaindb.ValidatorStatus,
+) (
+	map[phase0.BLSPubKey][]byte,
+	error,
+) {
+	sqlPubKeys := make([][]byte, len(statuses))
+	for i, status := range statuses {
+		sqlPubKeys[i] = status.Validator.PublicKey[:]
+	}
+
+	credentials := make(map[phase0.BLSPubKey][]byte, len(statuses))
+	for _, query := range []string{`
+      SELECT DISTINCT ON (f_validator_pubkey)
+             f_validator_pubkey
+            ,f_withdrawal_credentials
+      FROM t_deposits
+      WHERE f_validator_pubkey = ANY($1)
+        AND EXISTS(SELECT 1 FROM t_blocks WHERE f_root = f_inclusion_block_root AND (f_canonical IS NULL OR f_canonical = true))
+      ORDER BY f_validator_pubkey
+              ,f_inclusion_slot
+              ,f_inclusion_index`, `
+      SELECT DISTINCT ON (f_validator_pubkey)
+             f_validator_pubkey
+            ,f_withdrawal_credentials
+      FROM t_eth1_deposits
+      WHERE f_validator_pubkey = ANY($1)
+      ORDER BY f_validator_pubkey
+              ,f_deposit_index`,
+	} {
+		rows, err := tx.Query(ctx, query, sqlPubKeys)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var pubKey []byte
+			var withdrawalCredentials []byte
+			if err := rows.Scan(&pubKey, &withdrawalCredentials); err != nil {
+				rows.Close()
+				return nil, errors.Wrap(err, "failed to scan row")
+			}
+			var key phase0.BLSPubKey
+			copy(key[:], pubKey)
+			if _, exists := credentials[key]; !exists {
+				credentials[key] = withdrawalCredentials
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return credentials, nil
+}
+
+// validatorCacheKey is the cache key for a validator.
+func validatorCacheKey(index phase0.ValidatorIndex) string {
+	return fmt.Sprintf("validator:%d", index)
+}
+
+// ValidatorBalancesByEpoch fetches the validator balances for the given epoch.
+func (s *Service) ValidatorBalancesByEpoch(
+	ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorBalance,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	validatorBalances := make([]*chaindb.ValidatorBalance, 0)
+	err := s.forEachReconstructedBalance(ctx, tx, nil, []phase0.Epoch{epoch}, func(validatorBalance *chaindb.ValidatorBalance) error {
 		validatorBalances = append(validatorBalances, validatorBalance)
 		if uint64(validatorBalance.Index) != uint64(len(validatorBalances)-1) {
 			panic(fmt.Sprintf("bad index %d with len %d", validatorBalance.Index, len(validatorBalances)))
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return validatorBalances, nil
 }
 
+// ForEachValidatorBalanceInEpochRange calls the supplied function for each validator balance in the given epoch range,
+// streaming the balances from the database rather than holding them all in memory.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+// Iteration stops if the function returns an error, and the error is returned.
+func (s *Service) ForEachValidatorBalanceInEpochRange(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	fn func(*chaindb.ValidatorBalance) error,
+) error {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	return s.forEachReconstructedBalance(ctx, tx, nil, epochRange(startEpoch, endEpoch), fn)
+}
+
 // ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
 func (s *Service) ValidatorBalancesByIndexAndEpoch(
 	ctx context.Context,
@@ -337,6 +538,11 @@ func (s *Service) ValidatorBalancesByIndexAndEpoch(
 	map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
 	error,
 ) {
+	validatorBalances := make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance, len(validatorIndices))
+	if len(validatorIndices) == 0 {
+		return validatorBalances, nil
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, cancel, err := s.BeginTx(ctx)
@@ -347,32 +553,13 @@ func (s *Service) ValidatorBalancesByIndexAndEpoch(
 		defer cancel()
 	}
 
-	rows, err := tx.Query(ctx, `
-      SELECT f_validator_index
-            ,f_epoch
-            ,f_balance
-            ,f_effective_balance
-      FROM t_validator_balances
-      WHERE f_epoch = $2::BIGINT
-        AND f_validator_index = ANY($1)
-      ORDER BY f_validator_index`,
-		validatorIndices,
-		uint64(epoch),
-	)
+	err := s.forEachReconstructedBalance(ctx, tx, validatorIndices, []phase0.Epoch{epoch}, func(validatorBalance *chaindb.ValidatorBalance) error {
+		validatorBalances[validatorBalance.Index] = validatorBalance
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	validatorBalances := make(map[phase0.ValidatorIndex]*chaindb.ValidatorBalance, len(validatorIndices))
-
-	for rows.Next() {
-		validatorBalance, err := validatorBalanceFromRow(rows)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		validatorBalances[validatorBalance.Index] = validatorBalance
-	}
 
 	return validatorBalances, nil
 }
@@ -389,6 +576,11 @@ func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
+	validatorBalances := make(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, len(validatorIndices))
+	if len(validatorIndices) == 0 {
+		return validatorBalances, nil
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, cancel, err := s.BeginTx(ctx)
@@ -399,49 +591,16 @@ func (s *Service) ValidatorBalancesByIndexAndEpochRange(
 		defer cancel()
 	}
 
-	// Sort the validator indices.
-	sort.Slice(validatorIndices, func(i, j int) bool {
-		return validatorIndices[i] < validatorIndices[j]
-	})
-
-	// Create an array for the validator indices.  This gives us higher performance for our query.
-	indices := make([]string, len(validatorIndices))
-	for i, validatorIndex := range validatorIndices {
-		indices[i] = fmt.Sprintf("(%d)", validatorIndex)
-	}
-
-	rows, err := tx.Query(ctx, fmt.Sprintf(`
-      SELECT f_validator_index
-            ,f_epoch
-            ,f_balance
-            ,f_effective_balance
-      FROM t_validator_balances
-      JOIN (VALUES %s)
-        AS x(id)
-        ON x.id = t_validator_balances.f_validator_index
-      WHERE f_epoch >= $1
-        AND f_epoch < $2
-      ORDER BY f_validator_index
-              ,f_epoch`, strings.Join(indices, ",")),
-		uint64(startEpoch),
-		uint64(endEpoch),
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	validatorBalances := make(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, len(validatorIndices))
-	for rows.Next() {
-		validatorBalance, err := validatorBalanceFromRow(rows)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
+	err := s.forEachReconstructedBalance(ctx, tx, validatorIndices, epochRange(startEpoch, endEpoch), func(validatorBalance *chaindb.ValidatorBalance) error {
 		_, exists := validatorBalances[validatorBalance.Index]
 		if !exists {
 			validatorBalances[validatorBalance.Index] = make([]*chaindb.ValidatorBalance, 0, endEpoch+1-startEpoch)
 		}
 		validatorBalances[validatorBalance.Index] = append(validatorBalances[validatorBalance.Index], validatorBalance)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// If a validator is not present until after the beginning of the range, for example we ask for epochs 5->10 and
@@ -462,6 +621,11 @@ func (s *Service) ValidatorBalancesByIndexAndEpochs(
 	map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance,
 	error,
 ) {
+	validatorBalances := make(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, len(validatorIndices))
+	if len(validatorIndices) == 0 {
+		return validatorBalances, nil
+	}
+
 	tx := s.tx(ctx)
 	if tx == nil {
 		ctx, cancel, err := s.BeginTx(ctx)
@@ -472,54 +636,49 @@ func (s *Service) ValidatorBalancesByIndexAndEpochs(
 		defer cancel()
 	}
 
-	// Sort the validator indices.
-	sort.Slice(validatorIndices, func(i, j int) bool {
-		return validatorIndices[i] < validatorIndices[j]
+	err := s.forEachReconstructedBalance(ctx, tx, validatorIndices, sortedEpochs(epochs), func(validatorBalance *chaindb.ValidatorBalance) error {
+		_, exists := validatorBalances[validatorBalance.Index]
+		if !exists {
+			validatorBalances[validatorBalance.Index] = make([]*chaindb.ValidatorBalance, 0, len(epochs))
+		}
+		validatorBalances[validatorBalance.Index] = append(validatorBalances[validatorBalance.Index], validatorBalance)
+		return nil
 	})
-
-	// Create an array for the validator indices.  This gives us higher performance for our query.
-	indices := make([]string, len(validatorIndices))
-	for i, validatorIndex := range validatorIndices {
-		indices[i] = fmt.Sprintf("(%d)", validatorIndex)
+	if err != nil {
+		return nil, err
 	}
 
-	dbEpochs := make([]uint64, len(epochs))
-	for i, epoch := range epochs {
-		dbEpochs[i] = uint64(epoch)
+	return validatorBalances, nil
+}
+
+// epochRange returns the epochs from start up to but not including end.
+func epochRange(startEpoch phase0.Epoch, endEpoch phase0.Epoch) []phase0.Epoch {
+	if endEpoch <= startEpoch {
+		return []phase0.Epoch{}
 	}
-	rows, err := tx.Query(ctx, fmt.Sprintf(`
-      SELECT f_validator_index
-            ,f_epoch
-            ,f_balance
-            ,f_effective_balance
-      FROM t_validator_balances
-	  JOIN (VALUES %s)
-	           AS x(id)
-	           ON x.id = t_validator_balances.f_validator_index
-      WHERE f_epoch = ANY($1)
-      ORDER BY f_validator_index
-              ,f_epoch`, strings.Join(indices, ",")),
-		dbEpochs,
-	)
-	if err != nil {
-		return nil, err
+	epochs := make([]phase0.Epoch, 0, endEpoch-startEpoch)
+	for epoch := startEpoch; epoch < endEpoch; epoch++ {
+		epochs = append(epochs, epoch)
 	}
-	defer rows.Close()
 
-	validatorBalances := make(map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, len(validatorIndices))
-	for rows.Next() {
-		validatorBalance, err := validatorBalanceFromRow(rows)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to scan row")
-		}
-		_, exists := validatorBalances[validatorBalance.Index]
-		if !exists {
-			validatorBalances[validatorBalance.Index] = make([]*chaindb.ValidatorBalance, 0, len(epochs))
+	return epochs
+}
+
+// sortedEpochs returns the given epochs in increasing order without duplicates.
+func sortedEpochs(epochs []phase0.Epoch) []phase0.Epoch {
+	res := make([]phase0.Epoch, len(epochs))
+	copy(res, epochs)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	unique := res[:0]
+	for _, epoch := range res {
+		if len(unique) == 0 || epoch != unique[len(unique)-1] {
+			unique = append(unique, epoch)
 		}
-		validatorBalances[validatorBalance.Index] = append(validatorBalances[validatorBalance.Index], validatorBalance)
 	}
 
-	return validatorBalances, nil
+	return unique
 }
 
 func padValidatorBalances(validatorBalances map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, entries int, startEpoch phase0.Epoch) error {
@@ -592,18 +751,3 @@ func validatorFromRow(rows pgx.Rows) (*chaindb.Validator, error) {
 
 	return validator, nil
 }
-
-// validatorBalanceFromRow converts a SQL row in to a validator balance.
-func validatorBalanceFromRow(rows pgx.Rows) (*chaindb.ValidatorBalance, error) {
-	validatorBalance := &chaindb.ValidatorBalance{}
-	err := rows.Scan(
-		&validatorBalance.Index,
-		&validatorBalance.Epoch,
-		&validatorBalance.Balance,
-		&validatorBalance.EffectiveBalance,
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to scan row")
-	}
-	return validatorBalance, nil
-}
@@ -84,6 +84,13 @@ func TestSetValidators(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, res, 1)
 	require.Equal(t, validator2, res[validator2.PublicKey])
+
+	// Fetch the statuses of both validators, one by index and one by public key.
+	statuses, err := s.ValidatorStatuses(ctx, []phase0.ValidatorIndex{validator1.Index}, []phase0.BLSPubKey{validator2.PublicKey})
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, validator1, statuses[0].Validator)
+	require.Equal(t, validator2, statuses[1].Validator)
 }
 
 func TestValidators(t *testing.T) {
@@ -97,3 +104,39 @@ func TestValidators(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, len(validators) > 0)
 }
+
+func TestSetValidatorBalanceRewrite(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	index := phase0.ValidatorIndex(0x7fffffff)
+	for epoch := phase0.Epoch(0x7ffffff8); epoch < 0x7ffffffb; epoch++ {
+		require.NoError(t, s.SetValidatorBalance(ctx, &chaindb.ValidatorBalance{
+			Index:            index,
+			Epoch:            epoch,
+			Balance:          32000000000 + phase0.Gwei(epoch-0x7ffffff8)*1000,
+			EffectiveBalance: 32000000000,
+		}))
+	}
+
+	// Rewriting an earlier epoch leaves the balances of the following epochs unchanged.
+	require.NoError(t, s.SetValidatorBalance(ctx, &chaindb.ValidatorBalance{
+		Index:            index,
+		Epoch:            0x7ffffff9,
+		Balance:          31000000000,
+		EffectiveBalance: 31000000000,
+	}))
+	balances, err := s.ValidatorBalancesByIndexAndEpochRange(ctx, []phase0.ValidatorIndex{index}, 0x7ffffff8, 0x7ffffffb)
+	require.NoError(t, err)
+	require.Len(t, balances[index], 3)
+	require.Equal(t, phase0.Gwei(31000000000), balances[index][1].Balance)
+	require.Equal(t, phase0.Gwei(32000002000), balances[index][2].Balance)
+	require.Equal(t, phase0.Gwei(32000000000), balances[index][2].EffectiveBalance)
+}
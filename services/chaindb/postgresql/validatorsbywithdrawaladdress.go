@@ -0,0 +1,89 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// ValidatorsByWithdrawalAddress fetches the indices of all validators with withdrawal
+// credentials that pay to the given execution address.
+func (s *Service) ValidatorsByWithdrawalAddress(ctx context.Context, address bellatrix.ExecutionAddress) ([]phase0.ValidatorIndex, error) {
+	return s.ValidatorsByWithdrawalCredentials(ctx, executionWithdrawalCredentials(address))
+}
+
+// ValidatorsByWithdrawalCredentials fetches the indices of all validators with the given
+// withdrawal credentials.
+func (s *Service) ValidatorsByWithdrawalCredentials(ctx context.Context, withdrawalCredentials []byte) ([]phase0.ValidatorIndex, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Withdrawal credentials are set by the first deposit for a validator; those in
+	// later deposits are ignored by the chain, so only consider the first.
+	rows, err := tx.Query(ctx, `
+WITH first_deposits AS (
+  SELECT DISTINCT ON (t_deposits.f_validator_pubkey) t_deposits.f_validator_pubkey
+        ,t_deposits.f_withdrawal_credentials
+  FROM t_deposits
+  JOIN t_blocks ON t_deposits.f_inclusion_block_root = t_blocks.f_root
+  WHERE t_deposits.f_validator_pubkey IN (SELECT f_validator_pubkey FROM t_deposits WHERE f_withdrawal_credentials = $1)
+    AND t_blocks.f_canonical IS NOT FALSE
+  ORDER BY t_deposits.f_validator_pubkey, t_deposits.f_inclusion_slot, t_deposits.f_inclusion_index
+)
+SELECT t_validators.f_index
+FROM t_validators
+JOIN first_deposits ON t_validators.f_public_key = first_deposits.f_validator_pubkey
+WHERE first_deposits.f_withdrawal_credentials = $1
+ORDER BY t_validators.f_index`,
+		withdrawalCredentials,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	indices := make([]phase0.ValidatorIndex, 0)
+	for rows.Next() {
+		var index uint64
+		if err := rows.Scan(&index); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		indices = append(indices, phase0.ValidatorIndex(index))
+	}
+
+	return indices, nil
+}
+
+// executionWithdrawalCredentials provides the withdrawal credentials that pay to the
+// given execution address.
+func executionWithdrawalCredentials(address bellatrix.ExecutionAddress) []byte {
+	credentials := make([]byte, 32)
+	credentials[0] = 0x01
+	copy(credentials[12:], address[:])
+
+	return credentials
+}
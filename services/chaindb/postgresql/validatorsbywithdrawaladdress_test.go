@@ -0,0 +1,105 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestValidatorsByWithdrawalAddress(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	// Fetch a block so we can set the deposits' block root.
+	blocks, err := s.BlocksBySlot(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	address := bellatrix.ExecutionAddress{0xa0, 0xa1, 0xa2, 0xa3}
+	credentials := make([]byte, 32)
+	credentials[0] = 0x01
+	copy(credentials[12:], address[:])
+	otherCredentials := make([]byte, 32)
+	otherCredentials[0] = 0x01
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	// Validator 1 pays to the address.
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{
+		PublicKey: phase0.BLSPubKey{0xb1},
+		Index:     0xfffff1,
+	}))
+	require.NoError(t, s.SetDeposit(ctx, &chaindb.Deposit{
+		InclusionSlot:         0,
+		InclusionBlockRoot:    blocks[0].Root,
+		InclusionIndex:        0xfffff1,
+		ValidatorPubKey:       phase0.BLSPubKey{0xb1},
+		WithdrawalCredentials: credentials,
+		Amount:                32000000000,
+	}))
+
+	// Validator 2 pays elsewhere; its later deposit with the address is ignored.
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{
+		PublicKey: phase0.BLSPubKey{0xb2},
+		Index:     0xfffff2,
+	}))
+	require.NoError(t, s.SetDeposit(ctx, &chaindb.Deposit{
+		InclusionSlot:         0,
+		InclusionBlockRoot:    blocks[0].Root,
+		InclusionIndex:        0xfffff2,
+		ValidatorPubKey:       phase0.BLSPubKey{0xb2},
+		WithdrawalCredentials: otherCredentials,
+		Amount:                32000000000,
+	}))
+	require.NoError(t, s.SetDeposit(ctx, &chaindb.Deposit{
+		InclusionSlot:         0,
+		InclusionBlockRoot:    blocks[0].Root,
+		InclusionIndex:        0xfffff3,
+		ValidatorPubKey:       phase0.BLSPubKey{0xb2},
+		WithdrawalCredentials: credentials,
+		Amount:                1000000000,
+	}))
+
+	indices, err := s.ValidatorsByWithdrawalAddress(ctx, address)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.ValidatorIndex{0xfffff1}, indices)
+
+	indices, err = s.ValidatorsByWithdrawalAddress(ctx, bellatrix.ExecutionAddress{0xff})
+	require.NoError(t, err)
+	require.Empty(t, indices)
+
+	// Full withdrawal credentials.
+	indices, err = s.ValidatorsByWithdrawalCredentials(ctx, credentials)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.ValidatorIndex{0xfffff1}, indices)
+
+	indices, err = s.ValidatorsByWithdrawalCredentials(ctx, otherCredentials)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.ValidatorIndex{0xfffff2}, indices)
+}
@@ -0,0 +1,141 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ValidatorSetChanges provides the changes to the validator set after fromEpoch, up to
+// and including toEpoch.
+func (s *Service) ValidatorSetChanges(ctx context.Context, fromEpoch phase0.Epoch, toEpoch phase0.Epoch) (*chaindb.ValidatorSetChanges, error) {
+	if toEpoch < fromEpoch {
+		return nil, errors.New("to epoch before from epoch")
+	}
+
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	changes := &chaindb.ValidatorSetChanges{}
+
+	changes.Activations, err = validatorSetChanges(ctx, tx, `
+SELECT f_index
+      ,f_activation_epoch
+FROM t_validators
+WHERE f_activation_epoch > $1
+  AND f_activation_epoch <= $2
+ORDER BY f_activation_epoch, f_index`,
+		fromEpoch,
+		toEpoch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain activations")
+	}
+
+	changes.Exits, err = validatorSetChanges(ctx, tx, `
+SELECT f_index
+      ,f_exit_epoch
+FROM t_validators
+WHERE f_exit_epoch > $1
+  AND f_exit_epoch <= $2
+ORDER BY f_exit_epoch, f_index`,
+		fromEpoch,
+		toEpoch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain exits")
+	}
+
+	// A validator can be included in more than one slashing, so use the earliest.
+	// Slashed validators are those with proposer indices in proposer slashings, and
+	// those present in both attestations of attester slashings.
+	changes.Slashings, err = validatorSetChanges(ctx, tx, `
+SELECT f_validator_index
+      ,MIN(f_epoch)
+FROM (
+  SELECT t_proposer_slashings.f_header_1_proposer_index AS f_validator_index
+        ,t_blocks.f_slot / slots_per_epoch() AS f_epoch
+  FROM t_proposer_slashings
+  JOIN t_blocks
+    ON t_blocks.f_root = t_proposer_slashings.f_inclusion_block_root
+  WHERE t_blocks.f_slot >= ($1::BIGINT + 1) * slots_per_epoch()
+    AND t_blocks.f_slot < ($2::BIGINT + 1) * slots_per_epoch()
+    AND t_blocks.f_canonical IS NOT FALSE
+  UNION ALL
+  SELECT slashed.f_validator_index
+        ,t_blocks.f_slot / slots_per_epoch() AS f_epoch
+  FROM t_attester_slashings
+  JOIN t_blocks
+    ON t_blocks.f_root = t_attester_slashings.f_inclusion_block_root
+  CROSS JOIN LATERAL (
+    SELECT UNNEST(t_attester_slashings.f_attestation_1_indices)
+    INTERSECT
+    SELECT UNNEST(t_attester_slashings.f_attestation_2_indices)
+  ) AS slashed(f_validator_index)
+  WHERE t_blocks.f_slot >= ($1::BIGINT + 1) * slots_per_epoch()
+    AND t_blocks.f_slot < ($2::BIGINT + 1) * slots_per_epoch()
+    AND t_blocks.f_canonical IS NOT FALSE
+) AS slashings
+GROUP BY f_validator_index
+ORDER BY MIN(f_epoch), f_validator_index`,
+		fromEpoch,
+		toEpoch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain slashings")
+	}
+
+	return changes, nil
+}
+
+// validatorSetChanges runs a query that returns validator indices and epochs.
+func validatorSetChanges(ctx context.Context,
+	tx pgx.Tx,
+	query string,
+	args ...interface{},
+) (
+	[]*chaindb.ValidatorSetChange,
+	error,
+) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]*chaindb.ValidatorSetChange, 0)
+	for rows.Next() {
+		change := &chaindb.ValidatorSetChange{}
+		if err := rows.Scan(&change.Index, &change.Epoch); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
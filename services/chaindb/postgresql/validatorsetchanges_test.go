@@ -0,0 +1,73 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestValidatorSetChanges(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{
+		PublicKey:                  phase0.BLSPubKey{0x01},
+		Index:                      1,
+		EffectiveBalance:           32000000000,
+		ActivationEligibilityEpoch: 1,
+		ActivationEpoch:            2,
+		ExitEpoch:                  3,
+		WithdrawableEpoch:          4,
+	}))
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{
+		PublicKey:                  phase0.BLSPubKey{0x02},
+		Index:                      2,
+		ActivationEligibilityEpoch: 0xffffffffffffffff,
+		ActivationEpoch:            0xffffffffffffffff,
+		ExitEpoch:                  0xffffffffffffffff,
+		WithdrawableEpoch:          0xffffffffffffffff,
+	}))
+
+	_, err = s.ValidatorSetChanges(ctx, 3, 2)
+	require.EqualError(t, err, "to epoch before from epoch")
+
+	changes, err := s.ValidatorSetChanges(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ValidatorSetChange{{Index: 1, Epoch: 2}}, changes.Activations)
+	require.Empty(t, changes.Exits)
+
+	changes, err = s.ValidatorSetChanges(ctx, 2, 3)
+	require.NoError(t, err)
+	require.Empty(t, changes.Activations)
+	require.Equal(t, []*chaindb.ValidatorSetChange{{Index: 1, Epoch: 3}}, changes.Exits)
+
+	changes, err = s.ValidatorSetChanges(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, changes.Activations, 1)
+	require.Len(t, changes.Exits, 1)
+}
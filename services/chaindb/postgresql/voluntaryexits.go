@@ -45,6 +45,7 @@ func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.V
 		voluntaryExit.ValidatorIndex,
 		voluntaryExit.Epoch,
 	)
+	s.monitorWrite("t_voluntary_exits", 1, err)
 
 	return err
 }
@@ -15,8 +15,10 @@ package chaindb
 
 import (
 	"context"
+	"time"
 
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
@@ -38,10 +40,45 @@ type AttestationsProvider interface {
 	// attestations in slots 2 and 3.
 	AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Attestation, error)
 
+	// AttestationsForValidator fetches all attestations made for the given slot range that include the given validator.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// attestations for slots 2 and 3.
+	AttestationsForValidator(ctx context.Context, index phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Attestation, error)
+
+	// AttestationsForCommittee fetches all attestations made by the given committee at the given slot.
+	AttestationsForCommittee(ctx context.Context, slot phase0.Slot, committeeIndex phase0.CommitteeIndex) ([]*Attestation, error)
+
 	// IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
 	IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
 }
 
+// Backfiller defines functions to prepare the database for backfill.
+type Backfiller interface {
+	// BeginBackfill prepares the database for the bulk writes of initial sync.
+	BeginBackfill(ctx context.Context) error
+
+	// EndBackfill restores the database after the backfill has finished.
+	EndBackfill(ctx context.Context) error
+}
+
+// Drainer defines functions to drain active transactions.
+type Drainer interface {
+	// Drain waits for active read-write transactions to complete.
+	// It returns an error if transactions are still active when the context is done.
+	Drain(ctx context.Context) error
+}
+
+// AttestationsStreamer defines functions to stream attestations.
+type AttestationsStreamer interface {
+	// ForEachAttestationInSlotRange calls the supplied function for each attestation made in the given slot range,
+	// streaming the attestations from the database rather than holding them all in memory.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// attestations in slots 2 and 3.
+	// Iteration stops if the function returns an error, and the error is returned.
+	// The function should not access the database within the same transaction.
+	ForEachAttestationInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, fn func(*Attestation) error) error
+}
+
 // AttestationsSetter defines functions to create and update attestations.
 type AttestationsSetter interface {
 	// SetAttestation sets an attestation.
@@ -90,12 +127,35 @@ type BlocksProvider interface {
 	// blocks duties for slots 2 and 3.
 	BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Block, error)
 
+	// BlocksForTimestampRange fetches all blocks whose slots start in the given time range.
+	// Ranges are inclusive of start and exclusive of end.  Times before genesis are treated as genesis.
+	BlocksForTimestampRange(ctx context.Context, start time.Time, end time.Time) ([]*Block, error)
+
+	// BlocksForProposerIndex fetches all blocks proposed by the given validator in the given slot range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// blocks for slots 2 and 3.
+	BlocksForProposerIndex(ctx context.Context, index phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Block, error)
+
+	// FeeRecipientChanges fetches the changes in fee recipient between successive blocks proposed by each of the
+	// given validators, where the later block is in the given slot range.  If validators is nil then changes for all
+	// validators are returned.  Only blocks that are canonical or undefined are considered.
+	// Ranges are inclusive of start and exclusive of end.
+	FeeRecipientChanges(ctx context.Context, validators []phase0.ValidatorIndex, startSlot phase0.Slot, endSlot phase0.Slot) ([]*FeeRecipientChange, error)
+
 	// BlockByRoot fetches the block with the given root.
 	BlockByRoot(ctx context.Context, root phase0.Root) (*Block, error)
 
 	// BlocksByParentRoot fetches the blocks with the given parent root.
 	BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*Block, error)
 
+	// BlockByExecutionBlockHash fetches the block containing the execution payload with the given hash.
+	// It returns nil if there is no such block.
+	BlockByExecutionBlockHash(ctx context.Context, hash [32]byte) (*Block, error)
+
+	// BlocksByExecutionBlockNumber fetches the blocks containing execution payloads with the given block number.
+	// There can be more than one such block if the chain has forked.
+	BlocksByExecutionBlockNumber(ctx context.Context, number uint64) ([]*Block, error)
+
 	// EmptySlots fetches the slots in the given range without a block in the database.
 	EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
 
@@ -115,12 +175,162 @@ type BlocksProvider interface {
 	LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error)
 }
 
+// BlocksStreamer defines functions to stream blocks.
+type BlocksStreamer interface {
+	// ForEachBlockInSlotRange calls the supplied function for each block in the given slot range,
+	// without holding all of the blocks in memory.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// blocks for slots 2 and 3.
+	// Iteration stops if the function returns an error, and the error is returned.
+	ForEachBlockInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, fn func(*Block) error) error
+}
+
 // BlocksSetter defines functions to create and update blocks.
 type BlocksSetter interface {
 	// SetBlock sets a block.
 	SetBlock(ctx context.Context, block *Block) error
 }
 
+// BlockArrivalsProvider defines functions to access block arrivals.
+type BlockArrivalsProvider interface {
+	// BlockArrivals fetches the arrivals of blocks in the given slot range.
+	// Ranges are inclusive of start and end.
+	BlockArrivals(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*BlockArrival, error)
+}
+
+// BlockArrivalsSetter defines functions to record block arrivals.
+type BlockArrivalsSetter interface {
+	// SetBlockArrival records the arrival of a block.  If the block's arrival has
+	// already been recorded then the earlier arrival is kept.
+	SetBlockArrival(ctx context.Context, arrival *BlockArrival) error
+}
+
+// BlockClientsProvider defines functions to access the likely clients of block proposers.
+type BlockClientsProvider interface {
+	// BlockClients fetches the likely clients of the proposers of blocks in the given slot range.
+	// Ranges are inclusive of start and end.
+	BlockClients(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*BlockClient, error)
+
+	// ClientShares fetches the number of canonical blocks proposed by each client in each epoch
+	// of the given range, counting only those clients inferred with at least the given confidence.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4
+	// will provide shares for epochs 2 and 3.
+	ClientShares(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch, minConfidence float64) ([]*ClientShare, error)
+}
+
+// BlockClientsSetter defines functions to record the likely clients of block proposers.
+type BlockClientsSetter interface {
+	// SetBlockClients records the likely clients of the proposers of blocks.
+	SetBlockClients(ctx context.Context, clients []*BlockClient) error
+}
+
+// BlockRelaysSetter defines functions to record the relays that delivered the execution payloads of blocks.
+type BlockRelaysSetter interface {
+	// SetBlockRelays records the relays that delivered the execution payloads of blocks.
+	SetBlockRelays(ctx context.Context, relays []*BlockRelays) error
+}
+
+// ValidatorRegistrationsProvider defines functions to access the registrations of validators with relays.
+type ValidatorRegistrationsProvider interface {
+	// ValidatorRegistrations fetches the registrations of the given validators with relays that were
+	// made in the given time range, ordered by validator and time.  If validators is nil then the
+	// registrations of all validators are returned.
+	// Ranges are inclusive of start and exclusive of end.
+	ValidatorRegistrations(ctx context.Context,
+		validators []phase0.ValidatorIndex,
+		from time.Time,
+		to time.Time,
+	) (
+		[]*ValidatorRegistration,
+		error,
+	)
+}
+
+// ValidatorRegistrationsSetter defines functions to record the registrations of validators with relays.
+type ValidatorRegistrationsSetter interface {
+	// SetValidatorRegistrations records the registrations of validators with relays.  Registrations
+	// that have already been recorded are ignored.
+	SetValidatorRegistrations(ctx context.Context, registrations []*ValidatorRegistration) error
+}
+
+// QuarantineProvider defines functions to access data that failed to be stored.
+type QuarantineProvider interface {
+	// QuarantinedItems fetches the quarantined items of the given module, ordered by slot.
+	// If module is empty then the quarantined items of all modules are returned.
+	QuarantinedItems(ctx context.Context, module string) ([]*QuarantinedItem, error)
+}
+
+// QuarantineSetter defines functions to record data that failed to be stored.
+type QuarantineSetter interface {
+	// SetQuarantinedItem records data that failed to be stored, replacing any item already
+	// quarantined by the same module for the same slot.
+	SetQuarantinedItem(ctx context.Context, item *QuarantinedItem) error
+
+	// DeleteQuarantinedItems removes the quarantined items of a module for the given slots.
+	DeleteQuarantinedItems(ctx context.Context, module string, slots []phase0.Slot) error
+}
+
+// WriteErrorClassifier defines functions to classify errors returned when writing data.
+type WriteErrorClassifier interface {
+	// IsDeterministicError returns true if the error was caused by the data being written,
+	// so writing the same data again will fail in the same way.  Other errors, such as
+	// lost connections or lock timeouts, may not recur.
+	IsDeterministicError(err error) bool
+}
+
+// GossipAttestationsProvider defines functions to access attestations seen on the network.
+type GossipAttestationsProvider interface {
+	// GossipAttestations fetches the attestations seen on the network for the given slot range.
+	// Ranges are inclusive of start and end.
+	GossipAttestations(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*GossipAttestation, error)
+}
+
+// GossipAttestationsSetter defines functions to record attestations seen on the network.
+type GossipAttestationsSetter interface {
+	// SetGossipAttestations records attestations seen on the network.  If an attestation
+	// has already been recorded then the earlier arrival is kept.
+	SetGossipAttestations(ctx context.Context, attestations []*GossipAttestation) error
+}
+
+// PeerCountsProvider defines functions to access peer counts.
+type PeerCountsProvider interface {
+	// PeerCounts fetches the peer counts taken in the given time range.
+	// Ranges are inclusive of start and exclusive of end.
+	PeerCounts(ctx context.Context, from time.Time, to time.Time) ([]*PeerCount, error)
+}
+
+// PeerCountsSetter defines functions to record peer counts.
+type PeerCountsSetter interface {
+	// SetPeerCounts records peer counts.
+	SetPeerCounts(ctx context.Context, counts []*PeerCount) error
+}
+
+// MissedSlotsProvider defines functions to access missed slots.
+type MissedSlotsProvider interface {
+	// MissedSlots fetches the missed slots in the given range.
+	// Ranges are inclusive of start and end.
+	MissedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*MissedSlot, error)
+
+	// UnaccountedSlots fetches the slots in the given range that have neither a block nor
+	// a missed slot marker in the database.
+	// Ranges are inclusive of start and end.
+	UnaccountedSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
+}
+
+// MissedSlotsSetter defines functions to mark missed slots.
+type MissedSlotsSetter interface {
+	// SetMissedSlot marks a slot as not having a block.
+	SetMissedSlot(ctx context.Context, slot phase0.Slot) error
+}
+
+// SlotDataDeleter defines functions to delete data for a range of slots.
+type SlotDataDeleter interface {
+	// DeleteSlotData deletes blocks, along with their contents, and missed slot markers in the given slot range.
+	// Blocks known to be non-canonical are retained.
+	// Ranges are inclusive of start and exclusive of end.
+	DeleteSlotData(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error
+}
+
 // ChainSpecProvider defines functions to access chain specification.
 type ChainSpecProvider interface {
 	// ChainSpec fetches all chain specification values.
@@ -128,12 +338,22 @@ type ChainSpecProvider interface {
 
 	// ChainSpecValue fetches a chain specification value given its key.
 	ChainSpecValue(ctx context.Context, key string) (interface{}, error)
+
+	// ChainSpecAtEpoch fetches all chain specification values as they applied at the given epoch.
+	ChainSpecAtEpoch(ctx context.Context, epoch phase0.Epoch) (map[string]interface{}, error)
+
+	// ChainSpecValueAtEpoch fetches a chain specification value given its key, as it applied at the given epoch.
+	ChainSpecValueAtEpoch(ctx context.Context, key string, epoch phase0.Epoch) (interface{}, error)
 }
 
 // ChainSpecSetter defines functions to create and update chain specification.
 type ChainSpecSetter interface {
 	// SetChainSpecValue sets the value of the provided key.
 	SetChainSpecValue(ctx context.Context, key string, value interface{}) error
+
+	// SetChainSpecValueFromEpoch sets the value of the provided key from the given epoch.
+	// If the value is the same as that already in effect at the epoch nothing is written.
+	SetChainSpecValueFromEpoch(ctx context.Context, key string, value interface{}, epoch phase0.Epoch) error
 }
 
 // ForkScheduleProvider defines functions to access fork schedule information.
@@ -164,6 +384,21 @@ type GenesisSetter interface {
 type ETH1DepositsProvider interface {
 	// ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
 	ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*ETH1Deposit, error)
+
+	// ETH1DepositsForBlockRange fetches all Ethereum 1 deposits made in the given Ethereum 1 block range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startBlock 2 and endBlock 4 will provide
+	// deposits made in blocks 2 and 3.
+	ETH1DepositsForBlockRange(ctx context.Context, startBlock uint64, endBlock uint64) ([]*ETH1Deposit, error)
+
+	// ETH1DepositTotalsByDay provides the number and total amount of Ethereum 1 deposits for each UTC day
+	// in the given time range.
+	// Ranges are inclusive of start and exclusive of end.  Days without deposits are not returned.
+	ETH1DepositTotalsByDay(ctx context.Context, start time.Time, end time.Time) ([]*ETH1DepositDayTotal, error)
+
+	// ETH1DepositTotalsBySender provides the number and total amount of Ethereum 1 deposits for each sender
+	// in the given time range, ordered by decreasing amount.
+	// Ranges are inclusive of start and exclusive of end.
+	ETH1DepositTotalsBySender(ctx context.Context, start time.Time, end time.Time) ([]*ETH1DepositSenderTotal, error)
 }
 
 // ETH1DepositsSetter defines functions to create and update Ethereum 1 deposits.
@@ -172,6 +407,12 @@ type ETH1DepositsSetter interface {
 	SetETH1Deposit(ctx context.Context, deposit *ETH1Deposit) error
 }
 
+// ETH1DepositsDeleter defines functions to delete Ethereum 1 deposits, so that they can be refetched.
+type ETH1DepositsDeleter interface {
+	// DeleteETH1Deposits deletes the Ethereum 1 deposits made in the given range of blocks, inclusive.
+	DeleteETH1Deposits(ctx context.Context, startBlock uint64, endBlock uint64) error
+}
+
 // ProposerDutiesProvider defines functions to access proposer duties.
 type ProposerDutiesProvider interface {
 	// ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
@@ -231,6 +472,9 @@ type ValidatorsProvider interface {
 	// ValidatorsByIndex fetches all validators matching the given indices.
 	ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*Validator, error)
 
+	// ValidatorStatuses fetches the current statuses of all validators matching the given indices or public keys.
+	ValidatorStatuses(ctx context.Context, indices []phase0.ValidatorIndex, pubKeys []phase0.BLSPubKey) ([]*ValidatorStatus, error)
+
 	// ValidatorBalancesByEpoch fetches all validator balances for the given epoch.
 	ValidatorBalancesByEpoch(
 		ctx context.Context,
@@ -311,6 +555,17 @@ type AggregateValidatorBalancesProvider interface {
 	)
 }
 
+// ValidatorBalancesStreamer defines functions to stream validator balances.
+type ValidatorBalancesStreamer interface {
+	// ForEachValidatorBalanceInEpochRange calls the supplied function for each validator balance in the given epoch range,
+	// streaming the balances from the database rather than holding them all in memory.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// balances for epochs 2 and 3.
+	// Iteration stops if the function returns an error, and the error is returned.
+	// The function should not access the database within the same transaction.
+	ForEachValidatorBalanceInEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch, fn func(*ValidatorBalance) error) error
+}
+
 // ValidatorsSetter defines functions to create and update validator information.
 type ValidatorsSetter interface {
 	// SetValidator sets a validator.
@@ -323,6 +578,41 @@ type ValidatorsSetter interface {
 	SetValidatorBalances(ctx context.Context, validatorBalances []*ValidatorBalance) error
 }
 
+// ValidatorSetChangesProvider defines functions to fetch changes to the validator set.
+type ValidatorSetChangesProvider interface {
+	// ValidatorSetChanges provides the changes to the validator set after fromEpoch, up to
+	// and including toEpoch.
+	ValidatorSetChanges(ctx context.Context, fromEpoch phase0.Epoch, toEpoch phase0.Epoch) (*ValidatorSetChanges, error)
+}
+
+// ValidatorsByWithdrawalAddressProvider defines functions to fetch validators by withdrawal address or credentials.
+type ValidatorsByWithdrawalAddressProvider interface {
+	// ValidatorsByWithdrawalAddress fetches the indices of all validators with withdrawal
+	// credentials that pay to the given execution address.
+	ValidatorsByWithdrawalAddress(ctx context.Context, address bellatrix.ExecutionAddress) ([]phase0.ValidatorIndex, error)
+
+	// ValidatorsByWithdrawalCredentials fetches the indices of all validators with the given
+	// withdrawal credentials.
+	ValidatorsByWithdrawalCredentials(ctx context.Context, withdrawalCredentials []byte) ([]phase0.ValidatorIndex, error)
+}
+
+// ChainStatisticsProvider defines functions to obtain aggregate statistics about the chain.
+type ChainStatisticsProvider interface {
+	// BlocksPerDay provides the number of canonical blocks for each UTC day in the given time range.
+	// Ranges are inclusive of start and exclusive of end.  Days without canonical blocks are not returned.
+	BlocksPerDay(ctx context.Context, start time.Time, end time.Time) ([]*DailyBlocks, error)
+
+	// AverageAttestationsPerBlock provides the mean number of attestations included in canonical blocks in the given slot range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// the average for blocks in slots 2 and 3.
+	AverageAttestationsPerBlock(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (float64, error)
+
+	// ParticipationForEpochRange provides the participation for each summarized epoch in the given epoch range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// participation for epochs 2 and 3.
+	ParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*EpochParticipation, error)
+}
+
 // DepositsProvider defines functions to access deposits.
 type DepositsProvider interface {
 	// DepositsByPublicKey fetches deposits for a given set of validator public keys.
@@ -331,6 +621,10 @@ type DepositsProvider interface {
 	// DepositsForSlotRange fetches all deposits made in the given slot range.
 	// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
 	DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*Deposit, error)
+
+	// DepositsForWithdrawalCredentials fetches all deposits with the given withdrawal credentials made in the given slot range.
+	// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
+	DepositsForWithdrawalCredentials(ctx context.Context, withdrawalCredentials []byte, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*Deposit, error)
 }
 
 // DepositsSetter defines functions to create and update deposits.
@@ -354,12 +648,148 @@ type ValidatorEpochSummariesSetter interface {
 	SetValidatorEpochSummaries(ctx context.Context, summaries []*ValidatorEpochSummary) error
 }
 
+// ValidatorDaySummariesSetter defines functions to create and update validator day summaries.
+type ValidatorDaySummariesSetter interface {
+	// SetValidatorDaySummaries sets multiple validator day summaries.
+	SetValidatorDaySummaries(ctx context.Context, summaries []*ValidatorDaySummary) error
+}
+
+// ValidatorSummariesPruner defines functions to prune validator summaries.
+type ValidatorSummariesPruner interface {
+	// PruneValidatorEpochSummaries prunes validator epoch summaries up to (but not including) the given epoch.
+	PruneValidatorEpochSummaries(ctx context.Context, to phase0.Epoch) error
+
+	// PruneValidatorDaySummaries prunes validator day summaries up to (but not including) the given timestamp.
+	PruneValidatorDaySummaries(ctx context.Context, to time.Time) error
+}
+
+// SummariesDeleter defines functions to delete summaries, so that they can be regenerated.
+type SummariesDeleter interface {
+	// DeleteEpochSummaries deletes the epoch summaries for the given range of epochs, inclusive.
+	DeleteEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error
+
+	// DeleteBlockSummaries deletes the block summaries for the given range of slots, inclusive.
+	DeleteBlockSummaries(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error
+
+	// DeleteValidatorEpochSummaries deletes the validator epoch summaries for the given range of epochs, inclusive.
+	DeleteValidatorEpochSummaries(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error
+
+	// DeleteValidatorDaySummaries deletes the validator day summaries for days starting in the given range
+	// of timestamps, inclusive.
+	DeleteValidatorDaySummaries(ctx context.Context, startTimestamp time.Time, endTimestamp time.Time) error
+}
+
 // BlockSummariesProvider defines functions to fetch block summaries.
 type BlockSummariesProvider interface {
 	// BlockSummaryForSlot obtains the summary of a block for a given slot.
 	BlockSummaryForSlot(ctx context.Context, slot phase0.Slot) (*BlockSummary, error)
 }
 
+// ValidatorEffectivenessSetter defines functions to set validator effectiveness.
+type ValidatorEffectivenessSetter interface {
+	// SetValidatorEffectiveness sets multiple validator effectiveness scores.
+	SetValidatorEffectiveness(ctx context.Context, effectiveness []*ValidatorEffectiveness) error
+}
+
+// ValidatorEffectivenessProvider defines functions to fetch validator effectiveness.
+type ValidatorEffectivenessProvider interface {
+	// ValidatorEffectiveness provides the mean effectiveness of validators over a range of epochs,
+	// keyed by validator index.  If validators is nil then all validators are returned.
+	// Ranges are inclusive of start and end.
+	ValidatorEffectiveness(ctx context.Context,
+		validators []phase0.ValidatorIndex,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[phase0.ValidatorIndex]float64,
+		error,
+	)
+
+	// ValidatorEffectivenessByLabel provides the mean effectiveness of the validators with each
+	// label over a range of epochs, keyed by label.  If labels is nil then all labels are returned.
+	// Ranges are inclusive of start and end.
+	ValidatorEffectivenessByLabel(ctx context.Context,
+		labels []string,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[string]float64,
+		error,
+	)
+}
+
+// ValidatorIncomeSetter defines functions to set validator income.
+type ValidatorIncomeSetter interface {
+	// SetValidatorIncome sets multiple validator incomes.
+	SetValidatorIncome(ctx context.Context, income []*ValidatorIncome) error
+}
+
+// ValidatorIncomeProvider defines functions to fetch validator income.
+type ValidatorIncomeProvider interface {
+	// ValidatorIncome provides the income of validators over a range of epochs,
+	// keyed by validator index.  If validators is nil then all validators are returned.
+	// Ranges are inclusive of start and end.
+	ValidatorIncome(ctx context.Context,
+		validators []phase0.ValidatorIndex,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[phase0.ValidatorIndex]*AggregateValidatorIncome,
+		error,
+	)
+
+	// ValidatorIncomeByLabel provides the income of the validators with each label over a
+	// range of epochs, keyed by label.  If labels is nil then all labels are returned.
+	// Ranges are inclusive of start and end.
+	ValidatorIncomeByLabel(ctx context.Context,
+		labels []string,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[string]*AggregateLabelIncome,
+		error,
+	)
+
+	// WindowedValidatorIncome provides the income of validators over consecutive windows of
+	// the given number of epochs within a range of epochs, for all validators and for the validators
+	// with each of the given labels.  If labels is nil then only the income for all validators is provided.
+	// Ranges are inclusive of start and end; the final window is shorter if the range is not a multiple
+	// of the window.
+	WindowedValidatorIncome(ctx context.Context,
+		labels []string,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+		windowEpochs uint64,
+	) (
+		[]*AggregateWindowIncome,
+		error,
+	)
+}
+
+// ValidatorLabelsSetter defines functions to set validator labels.
+type ValidatorLabelsSetter interface {
+	// SetValidatorLabels sets the labels of a validator, replacing any existing labels.
+	SetValidatorLabels(ctx context.Context, index phase0.ValidatorIndex, labels []string) error
+}
+
+// ValidatorLabelsProvider defines functions to fetch validator labels.
+type ValidatorLabelsProvider interface {
+	// ValidatorLabels provides the labels of validators, keyed by validator index.
+	// If validators is nil then all labelled validators are returned.
+	ValidatorLabels(ctx context.Context, validators []phase0.ValidatorIndex) (map[phase0.ValidatorIndex][]string, error)
+
+	// ValidatorsByLabel provides the indices of validators with any of the given labels.
+	ValidatorsByLabel(ctx context.Context, labels []string) ([]phase0.ValidatorIndex, error)
+}
+
+// TenantsSetter defines functions to set the data that tenants can read.
+type TenantsSetter interface {
+	// SetTenants sets the tenants, replacing any existing tenants.  Each tenant can read only
+	// the given tables, which must have data about individual validators, and only the rows
+	// of validators with the given labels.
+	SetTenants(ctx context.Context, tenants []*Tenant) error
+}
+
 // ValidatorEpochSummariesProvider defines functions to fetch validator epoch summaries.
 type ValidatorEpochSummariesProvider interface {
 	// ValidatorSummaries provides summaries according to the filter.
@@ -396,6 +826,40 @@ type SyncCommitteesSetter interface {
 	SetSyncCommittee(ctx context.Context, syncCommittee *SyncCommittee) error
 }
 
+// AggregatesUpdater defines functions to update aggregates of the summary tables.
+type AggregatesUpdater interface {
+	// Aggregates provides the names of the aggregates managed by the database.
+	Aggregates(ctx context.Context) ([]string, error)
+
+	// UpdateAggregate adds up to the given number of summarized epochs, from the given epoch
+	// onwards, to the given aggregate.
+	// It returns the epoch from which to continue, which is the given epoch if no epochs
+	// have been summarized since.
+	UpdateAggregate(ctx context.Context, name string, fromEpoch phase0.Epoch, epochs uint64) (phase0.Epoch, error)
+}
+
+// TableStatsProvider defines functions to provide statistics about database tables.
+type TableStatsProvider interface {
+	// TableStats provides statistics about the tables managed by the database.
+	TableStats(ctx context.Context) ([]*TableStats, error)
+}
+
+// TablePruner defines functions to prune old rows from tables.
+type TablePruner interface {
+	// PrunableTables provides the names of the tables that can be pruned.
+	PrunableTables(ctx context.Context) ([]string, error)
+
+	// PruneTable removes the rows of the given table for epochs up to (but not including) the given epoch,
+	// which starts at the given slot, returning the number of rows removed.
+	PruneTable(ctx context.Context, name string, epoch phase0.Epoch, slot phase0.Slot) (int64, error)
+}
+
+// AuditEntriesSetter defines functions to record mutations in the audit log.
+type AuditEntriesSetter interface {
+	// SetAuditEntries records mutations in the audit log.
+	SetAuditEntries(ctx context.Context, entries []*AuditEntry) error
+}
+
 // Service defines a minimal chain database service.
 type Service interface {
 	// BeginTx begins a transaction.
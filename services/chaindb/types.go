@@ -17,6 +17,7 @@ import (
 	"math/big"
 	"time"
 
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
@@ -58,6 +59,18 @@ type ValidatorBalance struct {
 	EffectiveBalance phase0.Gwei
 }
 
+// ValidatorStatus holds the current status of a validator.
+type ValidatorStatus struct {
+	Validator *Validator
+	// Epoch is the latest epoch for which balances are held, at which the state and balance are given.
+	Epoch   phase0.Epoch
+	State   apiv1.ValidatorState
+	Balance phase0.Gwei
+	// WithdrawalCredentials are the withdrawal credentials of the validator's first deposit, or nil if
+	// the deposit is not held.
+	WithdrawalCredentials []byte
+}
+
 // AggregateValidatorBalance holds aggreated information about validators' balances at a given epoch.
 type AggregateValidatorBalance struct {
 	Epoch            phase0.Epoch
@@ -78,6 +91,81 @@ type ProposerDuty struct {
 	ValidatorIndex phase0.ValidatorIndex
 }
 
+// MissedSlot holds information for a slot without a block.
+type MissedSlot struct {
+	Slot phase0.Slot
+	// ProposerIndex is the validator that was due to propose; nil if proposer duties for the slot are not known.
+	ProposerIndex *phase0.ValidatorIndex
+}
+
+// BlockArrival holds the time at which a block was first seen.
+type BlockArrival struct {
+	Slot      phase0.Slot
+	BlockRoot phase0.Root
+	// SlotTime is the time at which the block's slot started.
+	SlotTime time.Time
+	// ArrivalTime is the time at which the block was first seen.
+	ArrivalTime time.Time
+}
+
+// BlockRelays holds the relays that delivered the execution payload of a block.
+type BlockRelays struct {
+	BlockRoot phase0.Root
+	// Relays are the names of the relays that delivered the payload; empty if the payload was built locally.
+	Relays []string
+	// BuilderPubKey is the public key of the builder of the payload; nil if the payload was built locally.
+	BuilderPubKey *phase0.BLSPubKey
+	// Value is the value of the payload to the proposer, in Wei, as reported by the relays; nil if the
+	// payload was built locally.
+	Value *big.Int
+}
+
+// ValidatorRegistration holds a validator's registration with a relay, as used by the relay
+// when building payloads for the validator's blocks.
+type ValidatorRegistration struct {
+	ValidatorIndex phase0.ValidatorIndex
+	// Relay is the name of the relay with which the validator registered.
+	Relay string
+	// Timestamp is the time of the registration, as signed by the validator.
+	Timestamp    time.Time
+	FeeRecipient [20]byte
+	GasLimit     uint64
+}
+
+// QuarantinedItem holds data that failed to be stored, along with the reason for the failure,
+// so that it can be inspected and reprocessed.
+type QuarantinedItem struct {
+	// Module is the name of the module that failed to store the data.
+	Module string
+	Slot   phase0.Slot
+	// Root is the root of the data, if known.
+	Root      *phase0.Root
+	Timestamp time.Time
+	Reason    string
+	// Data is the JSON representation of the data as received.
+	Data []byte
+}
+
+// BlockClient holds the likely consensus client of the proposer of a block.
+type BlockClient struct {
+	Slot          phase0.Slot
+	Epoch         phase0.Epoch
+	BlockRoot     phase0.Root
+	ProposerIndex phase0.ValidatorIndex
+	Client        string
+	// Confidence is the confidence in the client, from 0 to 1.
+	Confidence float64
+	// Signal is the signal from which the client was inferred.
+	Signal string
+}
+
+// ClientShare holds the number of blocks proposed by a client in an epoch.
+type ClientShare struct {
+	Epoch  phase0.Epoch
+	Client string
+	Blocks uint64
+}
+
 // AttesterDuty holds information for attester duties.
 type AttesterDuty struct {
 	Slot           phase0.Slot
@@ -106,6 +194,41 @@ type Attestation struct {
 	HeadCorrect        *bool
 }
 
+// GossipAttestation holds an unaggregated attestation as first seen on the network.
+type GossipAttestation struct {
+	Slot            phase0.Slot
+	CommitteeIndex  phase0.CommitteeIndex
+	AggregationBits []byte
+	BeaconBlockRoot phase0.Root
+	SourceEpoch     phase0.Epoch
+	SourceRoot      phase0.Root
+	TargetEpoch     phase0.Epoch
+	TargetRoot      phase0.Root
+	// ArrivalTime is the time at which the attestation was first seen.
+	ArrivalTime time.Time
+}
+
+// PeerCount holds the number of peers of the beacon node running a given client at a point in time.
+type PeerCount struct {
+	Timestamp time.Time
+	// Epoch is the epoch at the time of the count.
+	Epoch   phase0.Epoch
+	Client  string
+	Version string
+	Count   uint32
+}
+
+// Tenant holds the data that a database role can read, for serving multiple customers
+// from a single database.
+type Tenant struct {
+	// Role is the database role with which the tenant connects.
+	Role string
+	// Labels are the validator labels whose validators the tenant can read.
+	Labels []string
+	// Tables are the tables that the tenant can read.
+	Tables []string
+}
+
 // SyncAggregate holds information about a sync aggregate included in a block.
 type SyncAggregate struct {
 	InclusionSlot      phase0.Slot
@@ -142,6 +265,20 @@ type ETH1Deposit struct {
 	Amount                phase0.Gwei
 }
 
+// ETH1DepositDayTotal holds the number and total amount of Ethereum 1 deposits for a day.
+type ETH1DepositDayTotal struct {
+	Day      time.Time
+	Deposits int
+	Amount   phase0.Gwei
+}
+
+// ETH1DepositSenderTotal holds the number and total amount of Ethereum 1 deposits for a sender.
+type ETH1DepositSenderTotal struct {
+	Sender   []byte
+	Deposits int
+	Amount   phase0.Gwei
+}
+
 // VoluntaryExit holds information about a voluntary exit included in a block.
 type VoluntaryExit struct {
 	InclusionSlot      phase0.Slot
@@ -210,6 +347,107 @@ type ValidatorEpochSummary struct {
 	AttestationSourceTimely   *bool
 	AttestationTargetTimely   *bool
 	AttestationHeadTimely     *bool
+	// Sync committee counts are per slot, and zero if not in a sync committee.
+	SyncCommitteeParticipations int
+	SyncCommitteeMisses         int
+}
+
+// ValidatorDaySummary provides a summary of a validator's operations for a day.
+type ValidatorDaySummary struct {
+	Index                       phase0.ValidatorIndex
+	StartTimestamp              time.Time
+	ProposerDuties              int
+	ProposalsIncluded           int
+	AttestationDuties           int
+	AttestationsIncluded        int
+	AttestationsTargetCorrect   int
+	AttestationsHeadCorrect     int
+	AttestationsInclusionDelay  float64
+	AttestationsSourceTimely    int
+	AttestationsTargetTimely    int
+	AttestationsHeadTimely      int
+	SyncCommitteeParticipations int
+	SyncCommitteeMisses         int
+}
+
+// ValidatorEffectiveness holds the attestation effectiveness of a validator for an epoch.
+type ValidatorEffectiveness struct {
+	Index phase0.ValidatorIndex
+	Epoch phase0.Epoch
+	// Effectiveness is between 0, for an attestation that was not included, and 1, for a
+	// correct attestation included at the earliest opportunity.
+	Effectiveness float64
+	// Percentile is the percent rank of the effectiveness against that of all validators
+	// for the epoch, between 0 and 1.
+	Percentile float64
+}
+
+// ValidatorIncome holds the income of a validator for an epoch.
+type ValidatorIncome struct {
+	Index phase0.ValidatorIndex
+	Epoch phase0.Epoch
+	// Income is the change in the validator's balance from the start of this epoch to the
+	// start of the next, excluding deposits.  It is negative if the validator was penalised.
+	Income int64
+	// EffectiveBalance is the effective balance of the validator at the start of the epoch.
+	EffectiveBalance phase0.Gwei
+	// Percentile is the percent rank of the income relative to effective balance against
+	// that of all validators for the epoch, between 0 and 1.
+	Percentile float64
+}
+
+// AggregateValidatorIncome holds the income of a validator over a range of epochs.
+type AggregateValidatorIncome struct {
+	Index  phase0.ValidatorIndex
+	Income int64
+	// EffectiveBalance is the mean effective balance of the validator over the epochs.
+	EffectiveBalance phase0.Gwei
+	// Epochs is the number of epochs for which income is present.
+	Epochs uint64
+}
+
+// AggregateLabelIncome holds the income of the validators with a label over a range of epochs.
+type AggregateLabelIncome struct {
+	Label  string
+	Income int64
+	// EffectiveBalance is the mean effective balance of the validators over the epochs.
+	EffectiveBalance phase0.Gwei
+	// ValidatorEpochs is the number of validator epochs for which income is present.
+	ValidatorEpochs uint64
+}
+
+// AggregateWindowIncome holds the income of a set of validators over a window of epochs.
+type AggregateWindowIncome struct {
+	// Label is the label of the validators, or empty for all validators.
+	Label string
+	// StartEpoch and EndEpoch are the first and last epochs of the window.
+	StartEpoch phase0.Epoch
+	EndEpoch   phase0.Epoch
+	Income     int64
+	// EffectiveBalance is the mean effective balance of the validators over the epochs.
+	EffectiveBalance phase0.Gwei
+	// ValidatorEpochs is the number of validator epochs for which income is present.
+	ValidatorEpochs uint64
+}
+
+// ValidatorSetChange holds a change to the validator set for a single validator.
+type ValidatorSetChange struct {
+	Index phase0.ValidatorIndex
+	// Epoch is the epoch at which the change took, or will take, effect.
+	Epoch phase0.Epoch
+}
+
+// ValidatorSetChanges holds the changes to the validator set between two epochs.
+// Withdrawal credentials cannot be changed on the chains that chaind currently supports,
+// so there are no credential changes.
+type ValidatorSetChanges struct {
+	// Activations are validators that became active, at their activation epoch.
+	Activations []*ValidatorSetChange
+	// Exits are validators that exited, at their exit epoch.
+	Exits []*ValidatorSetChange
+	// Slashings are validators that were slashed, at the epoch of the block that
+	// included their first slashing.
+	Slashings []*ValidatorSetChange
 }
 
 // BlockSummary provides a summary of an epoch.
@@ -243,6 +481,23 @@ type EpochSummary struct {
 	Deposits                      int
 	ExitingValidators             int
 	CanonicalBlocks               int
+	SyncCommitteeParticipations   int
+	SyncCommitteeMisses           int
+}
+
+// DailyBlocks holds the number of canonical blocks for a day.
+type DailyBlocks struct {
+	Day             time.Time
+	CanonicalBlocks int
+}
+
+// EpochParticipation holds the participation for an epoch, as the fraction of the
+// active effective balance that attested, and that attested to the correct target and head.
+type EpochParticipation struct {
+	Epoch             phase0.Epoch
+	ParticipationRate float64
+	TargetCorrectRate float64
+	HeadCorrectRate   float64
 }
 
 // SyncCommittee holds information for sync committees.
@@ -268,3 +523,41 @@ type ExecutionPayload struct {
 	BlockHash     [32]byte
 	// No transactions.
 }
+
+// FeeRecipientChange holds a change in the fee recipient of the blocks proposed by a validator.
+type FeeRecipientChange struct {
+	ProposerIndex phase0.ValidatorIndex
+	// Slot and Root are those of the first block with the new fee recipient.
+	Slot         phase0.Slot
+	Root         phase0.Root
+	FeeRecipient [20]byte
+	// PreviousSlot is the slot of the validator's previous block, with the previous fee recipient.
+	PreviousSlot         phase0.Slot
+	PreviousFeeRecipient [20]byte
+}
+
+// TableStats holds statistics about a database table.
+type TableStats struct {
+	Name string
+	// Rows is an estimate of the number of live rows in the table.
+	Rows int64
+	// TableSize is the on-disk size of the table in bytes, including TOAST data.
+	TableSize int64
+	// IndexesSize is the on-disk size of the table's indices in bytes.
+	IndexesSize int64
+}
+
+// AuditEntry records a mutation made to the database.
+type AuditEntry struct {
+	Timestamp time.Time
+	// Table is the table that was mutated.
+	Table string
+	// Operation is the type of mutation, for example "upsert".
+	Operation string
+	// Keys identify the mutated rows within the table.
+	Keys map[string]string
+	// Module is the module that triggered the mutation; empty if unknown.
+	Module string
+	// Slot is the slot to which the mutation relates; nil if not related to a slot.
+	Slot *phase0.Slot
+}
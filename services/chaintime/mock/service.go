@@ -81,11 +81,26 @@ func (s *service) TimestampToEpoch(timestamp time.Time) phase0.Epoch {
 	return 0
 }
 
+// TimestampRangeToSlotRange provides the range of slots that start in the given time range.
+func (s *service) TimestampRangeToSlotRange(start time.Time, end time.Time) (phase0.Slot, phase0.Slot) {
+	return 0, 0
+}
+
 // FirstEpochOfSyncPeriod provides the first epoch of the given sync period.
 func (s *service) FirstEpochOfSyncPeriod(period uint64) phase0.Epoch {
 	return 0
 }
 
+// LastEpochOfSyncPeriod provides the last epoch of the given sync period.
+func (s *service) LastEpochOfSyncPeriod(period uint64) phase0.Epoch {
+	return 0
+}
+
+// FirstSlotOfSyncPeriod provides the first slot of the given sync period.
+func (s *service) FirstSlotOfSyncPeriod(period uint64) phase0.Slot {
+	return 0
+}
+
 // AltairInitialEpoch provides the epoch at which the Altair hard fork takes place.
 func (s *service) AltairInitialEpoch() phase0.Epoch {
 	return 0
@@ -95,3 +110,23 @@ func (s *service) AltairInitialEpoch() phase0.Epoch {
 func (s *service) AltairInitialSyncCommitteePeriod() uint64 {
 	return 0
 }
+
+// ForkInitialEpoch provides the epoch at which the given fork takes place.
+func (s *service) ForkInitialEpoch(fork chaintime.Fork) phase0.Epoch {
+	return 0
+}
+
+// ForkInitialSlot provides the slot at which the given fork takes place.
+func (s *service) ForkInitialSlot(fork chaintime.Fork) phase0.Slot {
+	return 0
+}
+
+// ForkAtEpoch provides the fork active at the given epoch.
+func (s *service) ForkAtEpoch(epoch phase0.Epoch) chaintime.Fork {
+	return chaintime.ForkPhase0
+}
+
+// ForkAtSlot provides the fork active at the given slot.
+func (s *service) ForkAtSlot(slot phase0.Slot) chaintime.Fork {
+	return chaintime.ForkPhase0
+}
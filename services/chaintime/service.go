@@ -19,6 +19,22 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
+// Fork is the name of a hard fork of the chain.
+type Fork string
+
+const (
+	// ForkPhase0 is the genesis fork.
+	ForkPhase0 Fork = "phase0"
+	// ForkAltair is the Altair hard fork.
+	ForkAltair Fork = "altair"
+	// ForkBellatrix is the Bellatrix hard fork.
+	ForkBellatrix Fork = "bellatrix"
+	// ForkCapella is the Capella hard fork.
+	ForkCapella Fork = "capella"
+	// ForkDeneb is the Deneb hard fork.
+	ForkDeneb Fork = "deneb"
+)
+
 // Service provides a number of functions for calculating chain-related times.
 type Service interface {
 	// GenesisTime provides the time of the chain's genesis.
@@ -45,10 +61,38 @@ type Service interface {
 	TimestampToSlot(timestamp time.Time) phase0.Slot
 	// TimestampToEpoch provides the epoch of the given timestamp.
 	TimestampToEpoch(timestamp time.Time) phase0.Epoch
+	// TimestampRangeToSlotRange provides the range of slots that start in the given time range.
+	// Ranges are inclusive of start and exclusive of end.  Times before genesis are treated as genesis.
+	TimestampRangeToSlotRange(start time.Time, end time.Time) (phase0.Slot, phase0.Slot)
 	// FirstEpochOfSyncPeriod provides the first epoch of the given sync period.
 	FirstEpochOfSyncPeriod(period uint64) phase0.Epoch
+	// LastEpochOfSyncPeriod provides the last epoch of the given sync period.
+	LastEpochOfSyncPeriod(period uint64) phase0.Epoch
+	// FirstSlotOfSyncPeriod provides the first slot of the given sync period.
+	FirstSlotOfSyncPeriod(period uint64) phase0.Slot
 	// AltairInitialEpoch provides the epoch at which the Altair hard fork takes place.
 	AltairInitialEpoch() phase0.Epoch
 	// AltairInitialSyncCommitteePeriod provides the sync committee period in which the Altair hard fork takes place.
 	AltairInitialSyncCommitteePeriod() uint64
+	// ForkInitialEpoch provides the epoch at which the given fork takes place.
+	// Forks that are not scheduled provide the far future epoch.
+	ForkInitialEpoch(fork Fork) phase0.Epoch
+	// ForkInitialSlot provides the slot at which the given fork takes place.
+	// Forks that are not scheduled provide the far future slot.
+	ForkInitialSlot(fork Fork) phase0.Slot
+	// ForkAtEpoch provides the fork active at the given epoch.
+	ForkAtEpoch(epoch phase0.Epoch) Fork
+	// ForkAtSlot provides the fork active at the given slot.
+	ForkAtSlot(slot phase0.Slot) Fork
+}
+
+// WindowStart provides the first epoch of a rolling window of the given number of epochs that
+// ends at the current epoch.  It returns 0 if the window is 0 or extends back to genesis.
+func WindowStart(chainTime Service, window uint64) phase0.Epoch {
+	currentEpoch := chainTime.CurrentEpoch()
+	if window == 0 || uint64(currentEpoch) < window {
+		return 0
+	}
+
+	return currentEpoch + 1 - phase0.Epoch(window)
 }
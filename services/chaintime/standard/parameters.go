@@ -17,13 +17,16 @@ import (
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
 )
 
 type parameters struct {
 	logLevel             zerolog.Level
+	network              string
 	genesisTimeProvider  eth2client.GenesisTimeProvider
 	specProvider         eth2client.SpecProvider
 	forkScheduleProvider eth2client.ForkScheduleProvider
+	storedForkSchedule   chaindb.ForkScheduleProvider
 }
 
 // Parameter is the interface for service parameters.
@@ -44,6 +47,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithGenesisTimeProvider sets the genesis time provider.
 func WithGenesisTimeProvider(provider eth2client.GenesisTimeProvider) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -65,6 +75,14 @@ func WithForkScheduleProvider(provider eth2client.ForkScheduleProvider) Paramete
 	})
 }
 
+// WithStoredForkScheduleProvider sets the provider of the fork schedule stored in the database.
+// If the stored fork schedule is available it is used in preference to that of the fork schedule provider.
+func WithStoredForkScheduleProvider(provider chaindb.ForkScheduleProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storedForkSchedule = provider
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -22,21 +22,33 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service provides chain time services.
 type Service struct {
+	log                          zerolog.Logger
 	genesisTime                  time.Time
 	slotDuration                 time.Duration
 	slotsPerEpoch                uint64
 	epochsPerSyncCommitteePeriod uint64
+	forkEpochs                   map[chaintime.Fork]phase0.Epoch
 	altairForkEpoch              phase0.Epoch
-	bellatrixForkEpoch           phase0.Epoch
 }
 
-// module-wide log.
-var log zerolog.Logger
+// farFutureEpoch is the epoch of forks that are not scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// forks are the forks of the chain in the order in which they take place.
+var forks = []chaintime.Fork{
+	chaintime.ForkPhase0,
+	chaintime.ForkAltair,
+	chaintime.ForkBellatrix,
+	chaintime.ForkCapella,
+	chaintime.ForkDeneb,
+}
 
 // New creates a new controller.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
@@ -46,7 +58,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "chaintime").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "chaintime", "standard", parameters.logLevel)
 
 	genesisTime, err := parameters.genesisTimeProvider.GenesisTime(ctx)
 	if err != nil {
@@ -86,27 +98,26 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		epochsPerSyncCommitteePeriod = tmp2
 	}
 
-	altairForkEpoch, err := fetchAltairForkEpoch(ctx, parameters.forkScheduleProvider)
+	forkEpochs, err := fetchForkEpochs(ctx, log, parameters.storedForkSchedule, parameters.forkScheduleProvider)
 	if err != nil {
-		// Set to far future epoch.
-		altairForkEpoch = 0xffffffffffffffff
+		// Treat all forks after genesis as in the far future.
+		forkEpochs = map[chaintime.Fork]phase0.Epoch{
+			chaintime.ForkPhase0: 0,
+		}
 	}
-	log.Trace().Uint64("epoch", uint64(altairForkEpoch)).Msg("Obtained Altair fork epoch")
-	bellatrixForkEpoch, err := fetchBellatrixForkEpoch(ctx, parameters.forkScheduleProvider)
-	if err != nil {
-		// Set to far future epoch.
-		bellatrixForkEpoch = 0xffffffffffffffff
+	for fork, epoch := range forkEpochs {
+		log.Trace().Str("fork", string(fork)).Uint64("epoch", uint64(epoch)).Msg("Obtained fork epoch")
 	}
-	log.Trace().Uint64("epoch", uint64(bellatrixForkEpoch)).Msg("Obtained Bellatrix fork epoch")
 
 	s := &Service{
+		log:                          log,
 		genesisTime:                  genesisTime,
 		slotDuration:                 slotDuration,
 		slotsPerEpoch:                slotsPerEpoch,
 		epochsPerSyncCommitteePeriod: epochsPerSyncCommitteePeriod,
-		altairForkEpoch:              altairForkEpoch,
-		bellatrixForkEpoch:           bellatrixForkEpoch,
+		forkEpochs:                   forkEpochs,
 	}
+	s.altairForkEpoch = s.ForkInitialEpoch(chaintime.ForkAltair)
 
 	return s, nil
 }
@@ -182,6 +193,24 @@ func (s *Service) TimestampToEpoch(timestamp time.Time) phase0.Epoch {
 	return phase0.Epoch(secondsSinceGenesis / uint64(s.slotDuration.Seconds()) / s.slotsPerEpoch)
 }
 
+// TimestampRangeToSlotRange provides the range of slots that start in the given time range.
+// Ranges are inclusive of start and exclusive of end.  Times before genesis are treated as genesis.
+func (s *Service) TimestampRangeToSlotRange(start time.Time, end time.Time) (phase0.Slot, phase0.Slot) {
+	return s.firstSlotFrom(start), s.firstSlotFrom(end)
+}
+
+// firstSlotFrom provides the first slot that starts at or after the given timestamp.
+func (s *Service) firstSlotFrom(timestamp time.Time) phase0.Slot {
+	if !timestamp.After(s.genesisTime) {
+		return 0
+	}
+	slot := s.TimestampToSlot(timestamp)
+	if s.StartOfSlot(slot).Before(timestamp) {
+		slot++
+	}
+	return slot
+}
+
 // FirstEpochOfSyncPeriod provides the first epoch of the given sync period.
 // Note that epochs before the sync committee period will provide the Altair hard fork epoch.
 func (s *Service) FirstEpochOfSyncPeriod(period uint64) phase0.Epoch {
@@ -192,6 +221,17 @@ func (s *Service) FirstEpochOfSyncPeriod(period uint64) phase0.Epoch {
 	return epoch
 }
 
+// LastEpochOfSyncPeriod provides the last epoch of the given sync period.
+func (s *Service) LastEpochOfSyncPeriod(period uint64) phase0.Epoch {
+	return phase0.Epoch((period+1)*s.epochsPerSyncCommitteePeriod - 1)
+}
+
+// FirstSlotOfSyncPeriod provides the first slot of the given sync period.
+// Note that slots before the sync committee period will provide the first slot of the Altair hard fork.
+func (s *Service) FirstSlotOfSyncPeriod(period uint64) phase0.Slot {
+	return s.FirstSlotOfEpoch(s.FirstEpochOfSyncPeriod(period))
+}
+
 // AltairInitialEpoch provides the epoch at which the Altair hard fork takes place.
 func (s *Service) AltairInitialEpoch() phase0.Epoch {
 	return s.altairForkEpoch
@@ -202,29 +242,71 @@ func (s *Service) AltairInitialSyncCommitteePeriod() uint64 {
 	return uint64(s.altairForkEpoch) / s.epochsPerSyncCommitteePeriod
 }
 
-func fetchAltairForkEpoch(ctx context.Context, provider eth2client.ForkScheduleProvider) (phase0.Epoch, error) {
-	forkSchedule, err := provider.ForkSchedule(ctx)
-	if err != nil {
-		return 0, err
+// ForkInitialEpoch provides the epoch at which the given fork takes place.
+// Forks that are not scheduled provide the far future epoch.
+func (s *Service) ForkInitialEpoch(fork chaintime.Fork) phase0.Epoch {
+	epoch, exists := s.forkEpochs[fork]
+	if !exists {
+		return farFutureEpoch
 	}
-	forkVersion := 0
-	for i := range forkSchedule {
-		if bytes.Equal(forkSchedule[i].CurrentVersion[:], forkSchedule[i].PreviousVersion[:]) {
-			// This is the genesis fork; ignore it.
-			continue
-		}
-		forkVersion++
-		if forkVersion == 1 {
-			return forkSchedule[i].Epoch, nil
+	return epoch
+}
+
+// ForkInitialSlot provides the slot at which the given fork takes place.
+// Forks that are not scheduled provide the far future slot.
+func (s *Service) ForkInitialSlot(fork chaintime.Fork) phase0.Slot {
+	epoch := s.ForkInitialEpoch(fork)
+	if uint64(epoch) > uint64(farFutureEpoch)/s.slotsPerEpoch {
+		return phase0.Slot(0xffffffffffffffff)
+	}
+	return s.FirstSlotOfEpoch(epoch)
+}
+
+// ForkAtEpoch provides the fork active at the given epoch.
+func (s *Service) ForkAtEpoch(epoch phase0.Epoch) chaintime.Fork {
+	fork := chaintime.ForkPhase0
+	for _, candidate := range forks {
+		if forkEpoch, exists := s.forkEpochs[candidate]; exists && forkEpoch <= epoch {
+			fork = candidate
 		}
 	}
-	return 0, errors.New("no altair fork obtained")
+	return fork
 }
 
-func fetchBellatrixForkEpoch(ctx context.Context, provider eth2client.ForkScheduleProvider) (phase0.Epoch, error) {
-	forkSchedule, err := provider.ForkSchedule(ctx)
-	if err != nil {
-		return 0, err
+// ForkAtSlot provides the fork active at the given slot.
+func (s *Service) ForkAtSlot(slot phase0.Slot) chaintime.Fork {
+	return s.ForkAtEpoch(s.SlotToEpoch(slot))
+}
+
+// fetchForkEpochs fetches the epochs of the forks in the fork schedule, preferring
+// the stored fork schedule if it is available.
+// Forks are named by their position in the schedule, as the schedule does not
+// contain fork names.
+func fetchForkEpochs(ctx context.Context,
+	log zerolog.Logger,
+	storedProvider chaindb.ForkScheduleProvider,
+	provider eth2client.ForkScheduleProvider,
+) (
+	map[chaintime.Fork]phase0.Epoch,
+	error,
+) {
+	var forkSchedule []*phase0.Fork
+	if storedProvider != nil {
+		var err error
+		forkSchedule, err = storedProvider.ForkSchedule(ctx)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to obtain stored fork schedule")
+		}
+	}
+	if len(forkSchedule) == 0 {
+		var err error
+		forkSchedule, err = provider.ForkSchedule(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	forkEpochs := map[chaintime.Fork]phase0.Epoch{
+		chaintime.ForkPhase0: 0,
 	}
 	forkVersion := 0
 	for i := range forkSchedule {
@@ -233,9 +315,12 @@ func fetchBellatrixForkEpoch(ctx context.Context, provider eth2client.ForkSchedu
 			continue
 		}
 		forkVersion++
-		if forkVersion == 2 {
-			return forkSchedule[i].Epoch, nil
+		if forkVersion >= len(forks) {
+			log.Warn().Uint64("epoch", uint64(forkSchedule[i].Epoch)).Msg("Unknown fork in fork schedule; ignoring")
+			continue
 		}
+		forkEpochs[forks[forkVersion]] = forkSchedule[i].Epoch
 	}
-	return 0, errors.New("no bellatrix fork obtained")
+
+	return forkEpochs, nil
 }
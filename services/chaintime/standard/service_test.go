@@ -21,6 +21,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/chaintime/standard"
 	"github.com/wealdtech/chaind/testing/mock"
@@ -256,3 +257,195 @@ func TestTimestampToEpoch(t *testing.T) {
 		})
 	}
 }
+
+func TestForks(t *testing.T) {
+	ctx := context.Background()
+	forkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+			Epoch:           0,
+		},
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x01, 0x00, 0x00, 0x00},
+			Epoch:           10,
+		},
+		{
+			PreviousVersion: phase0.Version{0x01, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x02, 0x00, 0x00, 0x00},
+			Epoch:           20,
+		},
+	}
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standard.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standard.WithForkScheduleProvider(mock.NewForkScheduleProvider(forkSchedule)),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, phase0.Epoch(0), s.ForkInitialEpoch(chaintime.ForkPhase0))
+	require.Equal(t, phase0.Epoch(10), s.ForkInitialEpoch(chaintime.ForkAltair))
+	require.Equal(t, phase0.Epoch(20), s.ForkInitialEpoch(chaintime.ForkBellatrix))
+	require.Equal(t, phase0.Epoch(0xffffffffffffffff), s.ForkInitialEpoch(chaintime.ForkDeneb))
+	require.Equal(t, phase0.Slot(640), s.ForkInitialSlot(chaintime.ForkBellatrix))
+	require.Equal(t, phase0.Slot(0xffffffffffffffff), s.ForkInitialSlot(chaintime.ForkDeneb))
+	require.Equal(t, phase0.Epoch(10), s.AltairInitialEpoch())
+
+	require.Equal(t, chaintime.ForkPhase0, s.ForkAtEpoch(9))
+	require.Equal(t, chaintime.ForkAltair, s.ForkAtEpoch(10))
+	require.Equal(t, chaintime.ForkBellatrix, s.ForkAtEpoch(1000))
+	require.Equal(t, chaintime.ForkAltair, s.ForkAtSlot(639))
+	require.Equal(t, chaintime.ForkBellatrix, s.ForkAtSlot(640))
+}
+
+func TestStoredForkSchedule(t *testing.T) {
+	ctx := context.Background()
+	liveForkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+			Epoch:           0,
+		},
+	}
+	storedForkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+			Epoch:           0,
+		},
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x01, 0x00, 0x00, 0x00},
+			Epoch:           5,
+		},
+	}
+	chainDB := mockchaindb.New()
+
+	// Stored fork schedule not yet available.
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standard.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standard.WithForkScheduleProvider(mock.NewForkScheduleProvider(liveForkSchedule)),
+		standard.WithStoredForkScheduleProvider(chainDB),
+	)
+	require.NoError(t, err)
+	require.Equal(t, chaintime.ForkPhase0, s.ForkAtEpoch(5))
+
+	// Stored fork schedule available.
+	chainDB.SetResponse("ForkSchedule", storedForkSchedule)
+	s, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standard.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standard.WithForkScheduleProvider(mock.NewForkScheduleProvider(liveForkSchedule)),
+		standard.WithStoredForkScheduleProvider(chainDB),
+	)
+	require.NoError(t, err)
+	require.Equal(t, chaintime.ForkAltair, s.ForkAtEpoch(5))
+}
+
+func TestSyncPeriods(t *testing.T) {
+	s, _, _, _, _, err := createService(time.Now())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		period     uint64
+		firstEpoch phase0.Epoch
+		lastEpoch  phase0.Epoch
+		firstSlot  phase0.Slot
+	}{
+		{
+			name:       "PreAltair",
+			period:     0,
+			firstEpoch: 10,
+			lastEpoch:  255,
+			firstSlot:  320,
+		},
+		{
+			name:       "First",
+			period:     1,
+			firstEpoch: 256,
+			lastEpoch:  511,
+			firstSlot:  8192,
+		},
+		{
+			name:       "Later",
+			period:     10,
+			firstEpoch: 2560,
+			lastEpoch:  2815,
+			firstSlot:  81920,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.firstEpoch, s.FirstEpochOfSyncPeriod(test.period))
+			require.Equal(t, test.lastEpoch, s.LastEpochOfSyncPeriod(test.period))
+			require.Equal(t, test.firstSlot, s.FirstSlotOfSyncPeriod(test.period))
+			require.Equal(t, test.period, s.EpochToSyncCommitteePeriod(test.lastEpoch))
+			require.Equal(t, test.period, s.SlotToSyncCommitteePeriod(s.FirstSlotOfEpoch(test.lastEpoch+1)-1))
+		})
+	}
+}
+
+func TestTimestampRangeToSlotRange(t *testing.T) {
+	genesisTime := time.Unix(1606824023, 0)
+	s, _, _, _, _, err := createService(genesisTime)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       time.Time
+		startSlot phase0.Slot
+		endSlot   phase0.Slot
+	}{
+		{
+			name:      "BeforeGenesis",
+			start:     genesisTime.Add(-time.Hour),
+			end:       genesisTime.Add(-time.Minute),
+			startSlot: 0,
+			endSlot:   0,
+		},
+		{
+			name:      "SpanningGenesis",
+			start:     genesisTime.Add(-time.Hour),
+			end:       genesisTime.Add(24 * time.Second),
+			startSlot: 0,
+			endSlot:   2,
+		},
+		{
+			name:      "SlotBoundaries",
+			start:     genesisTime.Add(12 * time.Second),
+			end:       genesisTime.Add(36 * time.Second),
+			startSlot: 1,
+			endSlot:   3,
+		},
+		{
+			name:      "MidSlot",
+			start:     genesisTime.Add(13 * time.Second),
+			end:       genesisTime.Add(36*time.Second + 500*time.Millisecond),
+			startSlot: 2,
+			endSlot:   4,
+		},
+		{
+			name:      "Day",
+			start:     time.Date(2020, 12, 2, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2020, 12, 3, 0, 0, 0, 0, time.UTC),
+			startSlot: 3599,
+			endSlot:   10799,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			startSlot, endSlot := s.TimestampRangeToSlotRange(test.start, test.end)
+			require.Equal(t, test.startSlot, startSlot)
+			require.Equal(t, test.endSlot, endSlot)
+		})
+	}
+}
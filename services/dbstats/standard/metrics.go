@@ -0,0 +1,105 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_dbstats"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	tableRows   *prometheus.GaugeVec
+	tableSize   *prometheus.GaugeVec
+	indexesSize *prometheus.GaugeVec
+	collections *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.tableRows = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "table_rows",
+		Help:      "Estimated number of rows in the table",
+	}, []string{"table"})
+	if err := registerer.Register(m.tableRows); err != nil {
+		return nil, errors.Wrap(err, "failed to register table_rows")
+	}
+
+	m.tableSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "table_size_bytes",
+		Help:      "On-disk size of the table, excluding indices",
+	}, []string{"table"})
+	if err := registerer.Register(m.tableSize); err != nil {
+		return nil, errors.Wrap(err, "failed to register table_size_bytes")
+	}
+
+	m.indexesSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "indexes_size_bytes",
+		Help:      "On-disk size of the indices of the table",
+	}, []string{"table"})
+	if err := registerer.Register(m.indexesSize); err != nil {
+		return nil, errors.Wrap(err, "failed to register indexes_size_bytes")
+	}
+
+	m.collections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "collections_total",
+		Help:      "Number of collections of database statistics",
+	}, []string{"result"})
+	if err := registerer.Register(m.collections); err != nil {
+		return nil, errors.Wrap(err, "failed to register collections_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorTableStats(stats *chaindb.TableStats) {
+	if s.metrics.tableRows != nil {
+		s.metrics.tableRows.WithLabelValues(stats.Name).Set(float64(stats.Rows))
+		s.metrics.tableSize.WithLabelValues(stats.Name).Set(float64(stats.TableSize))
+		s.metrics.indexesSize.WithLabelValues(stats.Name).Set(float64(stats.IndexesSize))
+	}
+}
+
+func (s *Service) monitorCollection(succeeded bool) {
+	if s.metrics.collections != nil {
+		if succeeded {
+			s.metrics.collections.WithLabelValues("succeeded").Inc()
+		} else {
+			s.metrics.collections.WithLabelValues("failed").Inc()
+		}
+	}
+}
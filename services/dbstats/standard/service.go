@@ -0,0 +1,99 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a database statistics service.
+type Service struct {
+	log                zerolog.Logger
+	metrics            *serviceMetrics
+	tableStatsProvider chaindb.TableStatsProvider
+	interval           time.Duration
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "dbstats", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:                log,
+		metrics:            svcMetrics,
+		tableStatsProvider: parameters.chainDB.(chaindb.TableStatsProvider),
+		interval:           parameters.interval,
+	}
+
+	// Collect immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.collect(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "dbstats", "collect database statistics",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic collection of database statistics")
+	}
+	go s.collect(ctx)
+
+	return s, nil
+}
+
+// collect collects statistics for the database tables.
+func (s *Service) collect(ctx context.Context) {
+	started := time.Now()
+	stats, err := s.tableStatsProvider.TableStats(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain table statistics")
+		s.monitorCollection(false)
+		return
+	}
+
+	for _, tableStats := range stats {
+		s.log.Trace().
+			Str("table", tableStats.Name).
+			Int64("rows", tableStats.Rows).
+			Int64("table_size", tableStats.TableSize).
+			Int64("indexes_size", tableStats.IndexesSize).
+			Msg("Obtained table statistics")
+		s.monitorTableStats(tableStats)
+	}
+	s.log.Trace().Int("tables", len(stats)).Dur("elapsed", time.Since(started)).Msg("Collected table statistics")
+	s.monitorCollection(true)
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_effectiveness"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	latestEpoch     prometheus.Gauge
+	epochsProcessed prometheus.Counter
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch for which effectiveness has been calculated",
+	})
+	if err := registerer.Register(m.latestEpoch); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	m.epochsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "epochs_processed_total",
+		Help:      "Number of epochs for which effectiveness has been calculated",
+	})
+	if err := registerer.Register(m.epochsProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register epochs_processed_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorEpochProcessed(epoch phase0.Epoch) {
+	if s.metrics.epochsProcessed != nil {
+		s.metrics.epochsProcessed.Inc()
+	}
+	if s.metrics.latestEpoch != nil {
+		s.metrics.latestEpoch.Set(float64(epoch))
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a validator effectiveness service.
+type Service struct {
+	log                             zerolog.Logger
+	metrics                         *serviceMetrics
+	chainDB                         chaindb.Service
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	validatorEffectivenessSetter    chaindb.ValidatorEffectivenessSetter
+	interval                        time.Duration
+	updateMu                        sync.Mutex
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "effectiveness", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:                             log,
+		metrics:                         svcMetrics,
+		chainDB:                         parameters.chainDB,
+		validatorEpochSummariesProvider: parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider),
+		validatorEffectivenessSetter:    parameters.chainDB.(chaindb.ValidatorEffectivenessSetter),
+		interval:                        parameters.interval,
+	}
+
+	// Update immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "effectiveness", "calculate validator effectiveness",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic effectiveness calculation")
+	}
+	go s.update(ctx)
+
+	return s, nil
+}
+
+// update calculates effectiveness for all epochs with validator summaries that have not yet been processed.
+func (s *Service) update(ctx context.Context) {
+	if !s.updateMu.TryLock() {
+		s.log.Debug().Msg("Update already in progress")
+		return
+	}
+	defer s.updateMu.Unlock()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	latestSummaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+		Limit: 1,
+		Order: chaindb.OrderLatest,
+	})
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain latest validator summary")
+		return
+	}
+	if len(latestSummaries) == 0 {
+		s.log.Trace().Msg("No validator summaries; nothing to do")
+		return
+	}
+	latestEpoch := latestSummaries[0].Epoch
+
+	for epoch := md.NextEpoch; epoch <= latestEpoch; epoch++ {
+		if ctx.Err() != nil {
+			return
+		}
+		summaries, err := s.validatorEpochSummariesProvider.ValidatorSummariesForEpoch(ctx, epoch)
+		if err != nil {
+			s.log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to obtain validator summaries")
+			return
+		}
+		if len(summaries) == 0 {
+			// Validator summaries do not necessarily start at genesis, so skip to the next epoch that has them.
+			nextSummaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+				Limit: 1,
+				From:  &epoch,
+			})
+			if err != nil {
+				s.log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to obtain next validator summary")
+				return
+			}
+			if len(nextSummaries) == 0 {
+				return
+			}
+			s.log.Trace().Uint64("epoch", uint64(epoch)).Uint64("next_epoch", uint64(nextSummaries[0].Epoch)).Msg("No validator summaries; skipping")
+			epoch = nextSummaries[0].Epoch - 1
+			continue
+		}
+
+		if err := s.updateEpoch(ctx, md, epoch, summaries); err != nil {
+			s.log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to update effectiveness")
+			return
+		}
+		s.monitorEpochProcessed(epoch)
+		s.log.Trace().Uint64("epoch", uint64(epoch)).Int("validators", len(summaries)).Msg("Updated effectiveness")
+	}
+}
+
+// updateEpoch stores the effectiveness of validators for an epoch, along with the metadata, in a single transaction.
+func (s *Service) updateEpoch(ctx context.Context,
+	md *metadata,
+	epoch phase0.Epoch,
+	summaries []*chaindb.ValidatorEpochSummary,
+) error {
+	scores := make([]*chaindb.ValidatorEffectiveness, len(summaries))
+	for i, summary := range summaries {
+		scores[i] = &chaindb.ValidatorEffectiveness{
+			Index:         summary.Index,
+			Epoch:         summary.Epoch,
+			Effectiveness: effectiveness(summary),
+		}
+	}
+	setEffectivenessPercentiles(scores)
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.validatorEffectivenessSetter.SetValidatorEffectiveness(ctx, scores); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator effectiveness")
+	}
+
+	md.NextEpoch = epoch + 1
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// effectiveness calculates the attestation effectiveness of a validator from its epoch summary.
+// An attestation that was not included scores 0.  An included attestation scores the reciprocal
+// of its inclusion delay, scaled by the proportion of its source, target and head votes that
+// were correct.
+func effectiveness(summary *chaindb.ValidatorEpochSummary) float64 {
+	if !summary.AttestationIncluded ||
+		summary.AttestationInclusionDelay == nil ||
+		*summary.AttestationInclusionDelay < 1 {
+		return 0
+	}
+
+	// The source vote of an included attestation is always correct.
+	correct := 1
+	if summary.AttestationTargetCorrect != nil && *summary.AttestationTargetCorrect {
+		correct++
+	}
+	if summary.AttestationHeadCorrect != nil && *summary.AttestationHeadCorrect {
+		correct++
+	}
+
+	return float64(correct) / 3 / float64(*summary.AttestationInclusionDelay)
+}
+
+// setEffectivenessPercentiles ranks the effectiveness of each validator against the others.
+func setEffectivenessPercentiles(scores []*chaindb.ValidatorEffectiveness) {
+	values := make([]float64, len(scores))
+	for i := range scores {
+		values[i] = scores[i].Effectiveness
+	}
+	for i, percentile := range util.PercentRanks(values) {
+		scores[i].Percentile = percentile
+	}
+}
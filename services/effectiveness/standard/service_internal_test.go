@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestEffectiveness(t *testing.T) {
+	yes := true
+	no := false
+	one := 1
+	two := 2
+
+	tests := []struct {
+		name     string
+		summary  *chaindb.ValidatorEpochSummary
+		expected float64
+	}{
+		{
+			name:     "NotIncluded",
+			summary:  &chaindb.ValidatorEpochSummary{},
+			expected: 0,
+		},
+		{
+			name: "DelayMissing",
+			summary: &chaindb.ValidatorEpochSummary{
+				AttestationIncluded: true,
+			},
+			expected: 0,
+		},
+		{
+			name: "Perfect",
+			summary: &chaindb.ValidatorEpochSummary{
+				AttestationIncluded:       true,
+				AttestationInclusionDelay: &one,
+				AttestationTargetCorrect:  &yes,
+				AttestationHeadCorrect:    &yes,
+			},
+			expected: 1,
+		},
+		{
+			name: "Late",
+			summary: &chaindb.ValidatorEpochSummary{
+				AttestationIncluded:       true,
+				AttestationInclusionDelay: &two,
+				AttestationTargetCorrect:  &yes,
+				AttestationHeadCorrect:    &yes,
+			},
+			expected: 0.5,
+		},
+		{
+			name: "HeadIncorrect",
+			summary: &chaindb.ValidatorEpochSummary{
+				AttestationIncluded:       true,
+				AttestationInclusionDelay: &one,
+				AttestationTargetCorrect:  &yes,
+				AttestationHeadCorrect:    &no,
+			},
+			expected: 2.0 / 3,
+		},
+		{
+			name: "LateAndIncorrect",
+			summary: &chaindb.ValidatorEpochSummary{
+				AttestationIncluded:       true,
+				AttestationInclusionDelay: &two,
+				AttestationTargetCorrect:  &no,
+				AttestationHeadCorrect:    &no,
+			},
+			expected: 1.0 / 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, effectiveness(test.summary), 1e-9)
+		})
+	}
+}
+
+func TestSetEffectivenessPercentiles(t *testing.T) {
+	scores := []*chaindb.ValidatorEffectiveness{
+		{Index: 1, Effectiveness: 0.5},
+		{Index: 2, Effectiveness: 1},
+		{Index: 3, Effectiveness: 0},
+		{Index: 4, Effectiveness: 1},
+	}
+	setEffectivenessPercentiles(scores)
+
+	require.InDelta(t, 1.0/3, scores[0].Percentile, 1e-9)
+	require.InDelta(t, 2.0/3, scores[1].Percentile, 1e-9)
+	require.InDelta(t, 0.0, scores[2].Percentile, 1e-9)
+	require.InDelta(t, 2.0/3, scores[3].Percentile, 1e-9)
+}
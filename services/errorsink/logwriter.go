@@ -0,0 +1,112 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fatalFlushTimeout is the time to wait for errors to be sent before a fatal log entry exits the process.
+const fatalFlushTimeout = 2 * time.Second
+
+// occurrence tracks the occurrences of a repeated error.
+type occurrence struct {
+	lastCaptured time.Time
+	repeats      uint64
+}
+
+// LogWriter is a log writer that passes log entries to an underlying writer, and captures entries at
+// error level and above in an error sink.
+// Errors are identified by their module and message.  An error is captured the first time it occurs;
+// repeats of the error are counted, and captured at most once per repeat interval.
+type LogWriter struct {
+	next           io.Writer
+	sink           Service
+	repeatInterval time.Duration
+	mu             sync.Mutex
+	occurrences    map[string]*occurrence
+}
+
+// NewLogWriter creates a new log writer.
+func NewLogWriter(next io.Writer, sink Service, repeatInterval time.Duration) *LogWriter {
+	return &LogWriter{
+		next:           next,
+		sink:           sink,
+		repeatInterval: repeatInterval,
+		occurrences:    make(map[string]*occurrence),
+	}
+}
+
+// Write writes a log entry to the underlying writer.
+func (w *LogWriter) Write(p []byte) (int, error) {
+	return w.next.Write(p)
+}
+
+// WriteLevel writes a log entry to the underlying writer, capturing it if it is an error.
+func (w *LogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level >= zerolog.ErrorLevel && level != zerolog.NoLevel && level != zerolog.Disabled {
+		w.capture(level, p)
+	}
+	return w.next.Write(p)
+}
+
+// capture captures a log entry in the sink.
+func (w *LogWriter) capture(level zerolog.Level, p []byte) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(p, &fields); err != nil {
+		// Not a JSON entry; capture it as-is.
+		fields = map[string]interface{}{
+			zerolog.MessageFieldName: string(p),
+		}
+	}
+	module, _ := fields["service"].(string)
+	message, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, "service")
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+
+	if level >= zerolog.FatalLevel {
+		// The process is about to exit, so capture regardless of repeats and wait for it to be sent.
+		w.sink.CaptureError(module, message, fields, 1)
+		w.sink.Flush(fatalFlushTimeout)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s", module, message)
+	now := time.Now()
+	w.mu.Lock()
+	occ, exists := w.occurrences[key]
+	if !exists {
+		occ = &occurrence{}
+		w.occurrences[key] = occ
+	}
+	occ.repeats++
+	if exists && now.Sub(occ.lastCaptured) < w.repeatInterval {
+		w.mu.Unlock()
+		return
+	}
+	repeats := occ.repeats
+	occ.repeats = 0
+	occ.lastCaptured = now
+	w.mu.Unlock()
+
+	w.sink.CaptureError(module, message, fields, repeats)
+}
@@ -0,0 +1,115 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorsink_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/errorsink"
+)
+
+type capturedError struct {
+	module  string
+	message string
+	fields  map[string]interface{}
+	repeats uint64
+}
+
+type testSink struct {
+	mu      sync.Mutex
+	errors  []*capturedError
+	flushes int
+}
+
+func (s *testSink) CaptureError(module string, message string, fields map[string]interface{}, repeats uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, &capturedError{
+		module:  module,
+		message: message,
+		fields:  fields,
+		repeats: repeats,
+	})
+}
+
+func (*testSink) CapturePanic(_ interface{}) {}
+
+func (s *testSink) Flush(_ time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+	return true
+}
+
+func TestLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &testSink{}
+	writer := errorsink.NewLogWriter(&buf, sink, 100*time.Millisecond)
+	log := zerolog.New(writer).With().Str("service", "blocks").Logger()
+
+	// Entries below error level are not captured.
+	log.Warn().Msg("warning")
+	require.Len(t, sink.errors, 0)
+	require.Contains(t, buf.String(), "warning")
+
+	// Errors are captured with their module and fields.
+	log.Error().Uint64("slot", 5).Str("error", "bad").Msg("Failed to write block")
+	require.Len(t, sink.errors, 1)
+	require.Equal(t, "blocks", sink.errors[0].module)
+	require.Equal(t, "Failed to write block", sink.errors[0].message)
+	require.Equal(t, "bad", sink.errors[0].fields["error"])
+	require.Equal(t, float64(5), sink.errors[0].fields["slot"])
+	require.NotContains(t, sink.errors[0].fields, "level")
+	require.Equal(t, uint64(1), sink.errors[0].repeats)
+
+	// Repeats within the interval are counted but not captured.
+	log.Error().Uint64("slot", 6).Msg("Failed to write block")
+	log.Error().Uint64("slot", 7).Msg("Failed to write block")
+	require.Len(t, sink.errors, 1)
+
+	// Different errors are captured.
+	log.Error().Msg("Failed to set metadata")
+	require.Len(t, sink.errors, 2)
+
+	// Repeats after the interval are captured, with the number of repeats.
+	time.Sleep(150 * time.Millisecond)
+	log.Error().Uint64("slot", 8).Msg("Failed to write block")
+	require.Len(t, sink.errors, 3)
+	require.Equal(t, uint64(3), sink.errors[2].repeats)
+
+	// All entries are passed to the underlying writer.
+	require.Equal(t, 6, bytes.Count(buf.Bytes(), []byte("\n")))
+	require.Equal(t, 0, sink.flushes)
+}
+
+func TestLogWriterFatal(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &testSink{}
+	writer := errorsink.NewLogWriter(&buf, sink, time.Hour)
+
+	_, err := writer.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","service":"finalizer","message":"Failed"}`+"\n"))
+	require.NoError(t, err)
+	_, err = writer.WriteLevel(zerolog.FatalLevel, []byte(`{"level":"fatal","service":"finalizer","message":"Failed"}`+"\n"))
+	require.NoError(t, err)
+
+	// Fatal errors are always captured, and flushed.
+	require.Len(t, sink.errors, 2)
+	require.Equal(t, "finalizer", sink.errors[0].module)
+	require.Equal(t, 2, sink.flushes)
+}
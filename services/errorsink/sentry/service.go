@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sentrygo "github.com/getsentry/sentry-go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an error sink that sends errors to Sentry.
+type Service struct {
+	hub *sentrygo.Hub
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("errorsink", "sentry", parameters.logLevel)
+
+	client, err := sentrygo.NewClient(sentrygo.ClientOptions{
+		Dsn:         parameters.dsn,
+		Environment: parameters.environment,
+		Release:     parameters.release,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sentry client")
+	}
+
+	s := &Service{
+		hub: sentrygo.NewHub(client, sentrygo.NewScope()),
+	}
+
+	return s, nil
+}
+
+// CaptureError captures an error from a module.
+// repeats is the number of times the error has occurred since it was last captured.
+func (s *Service) CaptureError(module string, message string, fields map[string]interface{}, repeats uint64) {
+	event := sentrygo.NewEvent()
+	event.Level = sentrygo.LevelError
+	event.Message = message
+	if module != "" {
+		event.Tags["module"] = module
+	}
+	if errStr, isString := fields["error"].(string); isString {
+		event.Exception = []sentrygo.Exception{{
+			Type:  message,
+			Value: errStr,
+		}}
+	}
+	for k, v := range fields {
+		event.Extra[k] = v
+	}
+	if repeats > 1 {
+		event.Extra["repeats"] = repeats
+	}
+	// Group errors by module and message, rather than the details of the error.
+	event.Fingerprint = []string{module, message}
+
+	if id := s.hub.CaptureEvent(event); id == nil {
+		log.Trace().Str("module", module).Str("message", message).Msg("Error not captured")
+	}
+}
+
+// CapturePanic captures a recovered panic.
+func (s *Service) CapturePanic(value interface{}) {
+	if id := s.hub.Recover(value); id == nil {
+		log.Trace().Str("panic", fmt.Sprintf("%v", value)).Msg("Panic not captured")
+	}
+}
+
+// Flush waits until captured errors have been sent, or the timeout expires.
+// It returns true if all errors were sent.
+func (s *Service) Flush(timeout time.Duration) bool {
+	return s.hub.Flush(timeout)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sentry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/errorsink"
+	"github.com/wealdtech/chaind/services/errorsink/sentry"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []sentry.Parameter
+		err    string
+	}{
+		{
+			name: "DSNMissing",
+			params: []sentry.Parameter{
+				sentry.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no DSN specified",
+		},
+		{
+			name: "DSNInvalid",
+			params: []sentry.Parameter{
+				sentry.WithLogLevel(zerolog.Disabled),
+				sentry.WithDSN("invalid"),
+			},
+			err: "failed to create sentry client: [Sentry] DsnParseError: invalid scheme",
+		},
+		{
+			name: "Good",
+			params: []sentry.Parameter{
+				sentry.WithLogLevel(zerolog.Disabled),
+				sentry.WithDSN("https://public@sentry.example.com/1"),
+				sentry.WithEnvironment("test"),
+				sentry.WithRelease("1.0.0"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := sentry.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Implements(t, (*errorsink.Service)(nil), s)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	ctx := context.Background()
+
+	// The DSN refers to a port with no listener, so events are captured but fail to send.
+	s, err := sentry.New(ctx,
+		sentry.WithLogLevel(zerolog.Disabled),
+		sentry.WithDSN("https://public@localhost:1/1"),
+	)
+	require.NoError(t, err)
+
+	s.CaptureError("blocks", "Failed to write block", map[string]interface{}{"error": "bad", "slot": 5}, 2)
+	s.CapturePanic("test panic")
+	s.Flush(10 * time.Millisecond)
+}
@@ -0,0 +1,32 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorsink
+
+import (
+	"time"
+)
+
+// Service is the interface for an error sink, which receives errors for reporting to operators.
+type Service interface {
+	// CaptureError captures an error from a module.
+	// repeats is the number of times the error has occurred since it was last captured.
+	CaptureError(module string, message string, fields map[string]interface{}, repeats uint64)
+
+	// CapturePanic captures a recovered panic.
+	CapturePanic(value interface{})
+
+	// Flush waits until captured errors have been sent, or the timeout expires.
+	// It returns true if all errors were sent.
+	Flush(timeout time.Duration) bool
+}
@@ -0,0 +1,68 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type blockByNumberResponse struct {
+	Result *blockByNumberBlockResponse `json:"result"`
+}
+type blockByNumberBlockResponse struct {
+	Hash string `json:"hash"`
+}
+
+// blockHashByNumber fetches the hash of the canonical block with the given number.
+// It returns nil if the client does not have the block.
+func (s *Service) blockHashByNumber(ctx context.Context, blockNumber uint64) ([]byte, error) {
+	reference, err := url.Parse("")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+	url := s.base.ResolveReference(reference).String()
+
+	reqBody := bytes.NewBuffer([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByNumber","params":["%#x",false],"id":1901}`, blockNumber)))
+	respBodyReader, err := s.post(ctx, url, reqBody)
+	if err != nil {
+		s.log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		return nil, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return nil, errors.New("empty response")
+	}
+
+	var response blockByNumberResponse
+	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if response.Result == nil {
+		return nil, nil
+	}
+
+	hash, err := hex.DecodeString(strings.TrimPrefix(response.Result.Hash, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid hash")
+	}
+
+	return hash, nil
+}
@@ -39,7 +39,7 @@ func (s *Service) blockNumber(ctx context.Context) (uint64, error) {
 	reqBody := bytes.NewBuffer([]byte(`{"jsonrpc":"2.0","method":"eth_blockNumber","params":[],"id":1901}`))
 	respBodyReader, err := s.post(ctx, url, reqBody)
 	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		s.log.Trace().Str("url", url).Err(err).Msg("Request failed")
 		return 0, errors.Wrap(err, "request failed")
 	}
 	if respBodyReader == nil {
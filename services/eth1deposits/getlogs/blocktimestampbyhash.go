@@ -44,7 +44,7 @@ func (s *Service) blockTimestampByHash(ctx context.Context, blockHash []byte) (t
 	reqBody := bytes.NewBuffer([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getBlockByHash","params":["%#x",false],"id":1901}`, blockHash)))
 	respBodyReader, err := s.post(ctx, url, reqBody)
 	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		s.log.Trace().Str("url", url).Err(err).Msg("Request failed")
 		return time.Time{}, errors.Wrap(err, "request failed")
 	}
 	if respBodyReader == nil {
@@ -0,0 +1,44 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+)
+
+// Pause stops the service from periodically fetching deposits.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+	s.log.Info().Msg("Paused")
+}
+
+// Resume allows the service to periodically fetch deposits again.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+	s.log.Info().Msg("Resumed")
+}
+
+// Paused returns true if the service is paused.
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
+
+// Trigger fetches deposits up to the latest confirmed block,
+// regardless of whether the service is paused.
+func (s *Service) Trigger(ctx context.Context) error {
+	s.log.Info().Msg("Triggered")
+	s.checkLatestBlock(ctx)
+
+	return nil
+}
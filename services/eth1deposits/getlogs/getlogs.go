@@ -38,7 +38,7 @@ func (s *Service) getLogs(ctx context.Context, startBlock uint64, endBlock uint6
 	reqBody := bytes.NewBuffer([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_getLogs","params":[{"address":["%#x"],"topics":["0x649bbc62d0e31342afea4e5cd82d4049e7e1ee912fc0889aa790803be39038c5"],"fromBlock":"%#x","toBlock":"%#x"}],"id":11}`, s.depositContractAddress, startBlock, endBlock)))
 	respBodyReader, err := s.post(ctx, url, reqBody)
 	if err != nil {
-		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		s.log.Trace().Str("url", url).Err(err).Msg("Request failed")
 		return nil, errors.Wrap(err, "request failed")
 	}
 	if respBodyReader == nil {
@@ -49,7 +49,7 @@ func (s *Service) getLogs(ctx context.Context, startBlock uint64, endBlock uint6
 	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
 		return nil, errors.Wrap(err, "invalid response")
 	}
-	log.Trace().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Int("logs", len(response.Result)).Msg("Obtained logs")
+	s.log.Trace().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Int("logs", len(response.Result)).Msg("Obtained logs")
 
 	return response.Result, nil
 }
@@ -66,7 +66,7 @@ func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock
 			cancel()
 			return errors.Wrap(err, "failed to set ETH1 deposit")
 		}
-		log.Trace().Uint64("deposit_index", deposit.DepositIndex).Msg("Processed deposit")
+		s.log.Trace().Uint64("deposit_index", deposit.DepositIndex).Msg("Processed deposit")
 	}
 
 	if err := s.eth1DepositsSetter.(chaindb.Service).CommitTx(ctx); err != nil {
@@ -75,7 +75,7 @@ func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock
 	}
 
 	for block := startBlock; block < endBlock; block++ {
-		monitorBlockProcessed(block)
+		s.monitorBlockProcessed(block)
 	}
 
 	return nil
@@ -84,7 +84,7 @@ func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock
 func (s *Service) handleMissed(ctx context.Context, md *metadata) {
 	failed := 0
 	for i := 0; i < len(md.MissedBlocks); i++ {
-		log := log.With().Uint64("block", md.MissedBlocks[i]).Logger()
+		log := s.log.With().Uint64("block", md.MissedBlocks[i]).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
 )
 
 func init() {
@@ -37,7 +38,7 @@ func init() {
 // post sends an HTTP post request and returns the body.
 func (s *Service) post(ctx context.Context, endpoint string, body io.Reader) (io.Reader, error) {
 	// #nosec G404
-	log := log.With().Str("id", fmt.Sprintf("%02x", rand.Int31())).Logger()
+	log := s.log.With().Str("id", fmt.Sprintf("%02x", rand.Int31())).Logger()
 	if e := log.Trace(); e.Enabled() {
 		bodyBytes, err := ioutil.ReadAll(body)
 		if err != nil {
@@ -62,6 +63,10 @@ func (s *Service) post(ctx context.Context, endpoint string, body io.Reader) (io
 	}
 	req.Header.Set("Content-type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	if err := util.SetAuthHeaders(req, s.secrets, s.headers, s.bearerToken, s.jwtSecret); err != nil {
+		cancel()
+		return nil, err
+	}
 	resp, err := s.client.Do(req)
 	if err != nil {
 		cancel()
@@ -24,6 +24,17 @@ import (
 type metadata struct {
 	LatestBlock  uint64   `json:"latest_block"`
 	MissedBlocks []uint64 `json:"missed_blocks,omitempty"`
+	// ConfirmedBlock is the latest block up to which deposits have the required number of confirmations.
+	// It only trails LatestBlock when deposits are indexed provisionally.
+	ConfirmedBlock uint64 `json:"confirmed_block,omitempty"`
+	// RecentBlocks are the most recent blocks up to which deposits have been processed, oldest first.
+	RecentBlocks []*processedBlock `json:"recent_blocks,omitempty"`
+}
+
+// processedBlock is a block up to which deposits have been processed.
+type processedBlock struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
 }
 
 // metadataKey is the key for the metadata.
@@ -23,53 +23,86 @@ import (
 
 var metricsNamespace = "chaind_eth1deposits"
 
-var highestBlock uint64
-var latestBlock prometheus.Gauge
-var blocksProcessed prometheus.Gauge
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	highestBlock    uint64
+	latestBlock     prometheus.Gauge
+	blocksProcessed prometheus.Gauge
+	confirmedBlock  prometheus.Gauge
+	reorgs          prometheus.Counter
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if latestBlock != nil {
-		// Already registered.
-		return nil
-	}
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
-func registerPrometheusMetrics(ctx context.Context) error {
-	latestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_block",
 		Help:      "Latest Ethereum 1 block processed",
 	})
-	if err := prometheus.Register(latestBlock); err != nil {
-		return errors.Wrap(err, "failed to register latest_block")
+	if err := registerer.Register(m.latestBlock); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_block")
 	}
 
-	blocksProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.blocksProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "blocks_processed",
 		Help:      "Number of Ethereum 1 blocks processed",
 	})
-	if err := prometheus.Register(blocksProcessed); err != nil {
-		return errors.Wrap(err, "failed to register blocks_processed")
+	if err := registerer.Register(m.blocksProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register blocks_processed")
 	}
 
-	return nil
+	m.confirmedBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "confirmed_block",
+		Help:      "Latest Ethereum 1 block whose deposits have the required number of confirmations",
+	})
+	if err := registerer.Register(m.confirmedBlock); err != nil {
+		return nil, errors.Wrap(err, "failed to register confirmed_block")
+	}
+
+	m.reorgs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reorgs_total",
+		Help:      "Number of Ethereum 1 reorgs for which deposits have been refetched",
+	})
+	if err := registerer.Register(m.reorgs); err != nil {
+		return nil, errors.Wrap(err, "failed to register reorgs_total")
+	}
+
+	return m, nil
 }
 
-func monitorBlockProcessed(block uint64) {
-	if blocksProcessed != nil {
-		blocksProcessed.Inc()
-		if block > highestBlock {
-			latestBlock.Set(float64(block))
-			highestBlock = block
+func (s *Service) monitorBlockProcessed(block uint64) {
+	if s.metrics.blocksProcessed != nil {
+		s.metrics.blocksProcessed.Inc()
+		if block > s.metrics.highestBlock {
+			s.metrics.latestBlock.Set(float64(block))
+			s.metrics.highestBlock = block
 		}
 	}
 }
+
+func (s *Service) monitorConfirmedBlock(block uint64) {
+	if s.metrics.confirmedBlock != nil {
+		s.metrics.confirmedBlock.Set(float64(block))
+	}
+}
+
+func (s *Service) monitorReorg() {
+	if s.metrics.reorgs != nil {
+		s.metrics.reorgs.Inc()
+	}
+}
@@ -14,22 +14,34 @@
 package getlogs
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/secrets"
 )
 
 type parameters struct {
 	logLevel           zerolog.Level
+	network            string
 	monitor            metrics.Service
 	connectionURL      string
 	chainDB            chaindb.Service
 	eth1DepositsSetter chaindb.ETH1DepositsSetter
 	eth1Confirmations  uint64
+	provisional        bool
 	startBlock         string
+	proxyURL           string
+	clientCert         []byte
+	clientKey          []byte
+	caCert             []byte
+	headers            map[string]string
+	bearerToken        string
+	jwtSecret          []byte
+	secrets            secrets.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +62,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -78,6 +97,14 @@ func WithETH1Confirmations(confirmations uint64) Parameter {
 	})
 }
 
+// WithProvisional sets the service to index deposits as soon as they are seen, and to
+// reconcile them once they have the required number of confirmations.
+func WithProvisional(provisional bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.provisional = provisional
+	})
+}
+
 // WithConnectionURL sets the Ethereum 1 connection URL service for this module.
 func WithConnectionURL(url string) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -92,6 +119,65 @@ func WithStartBlock(block string) Parameter {
 	})
 }
 
+// WithProxyURL sets the URL of an HTTP or SOCKS proxy through which to connect to
+// the Ethereum 1 node.
+func WithProxyURL(url string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.proxyURL = url
+	})
+}
+
+// WithClientCert sets the bytes of the client TLS certificate.
+func WithClientCert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientCert = cert
+	})
+}
+
+// WithClientKey sets the bytes of the client TLS key.
+func WithClientKey(key []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientKey = key
+	})
+}
+
+// WithCACert sets the bytes of the certificate authority TLS certificate.
+func WithCACert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = cert
+	})
+}
+
+// WithHeaders sets additional headers sent with each request, for example an API key.
+func WithHeaders(headers map[string]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headers = headers
+	})
+}
+
+// WithBearerToken sets the token sent in the Authorization header of each request.
+func WithBearerToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bearerToken = token
+	})
+}
+
+// WithJWTSecret sets the secret used to sign a JSON web token sent in the
+// Authorization header of each request.
+func WithJWTSecret(secret []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.jwtSecret = secret
+	})
+}
+
+// WithSecrets sets the secrets service used to resolve header values and the
+// bearer token, if they refer to secrets.
+func WithSecrets(secrets secrets.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.secrets = secrets
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -110,9 +196,21 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.eth1DepositsSetter == nil {
 		return nil, errors.New("no Ethereum 1 deposits setter specified")
 	}
+	if _, isDeleter := parameters.eth1DepositsSetter.(chaindb.ETH1DepositsDeleter); !isDeleter {
+		return nil, errors.New("Ethereum 1 deposits setter does not support deletion")
+	}
+	if parameters.provisional && parameters.eth1Confirmations > recentBlocksDistance {
+		return nil, fmt.Errorf("provisional deposits cannot be reconciled over more than %d confirmations", recentBlocksDistance)
+	}
 	if parameters.connectionURL == "" {
 		return nil, errors.New("no connection URL specified")
 	}
+	if (parameters.clientCert == nil) != (parameters.clientKey == nil) {
+		return nil, errors.New("client certificate and key must be specified together")
+	}
+	if parameters.bearerToken != "" && parameters.jwtSecret != nil {
+		return nil, errors.New("only one of bearer token and JWT secret can be specified")
+	}
 	if parameters.startBlock != "" {
 		_, err := strconv.ParseInt(parameters.startBlock, 10, 64)
 		if err != nil {
@@ -0,0 +1,119 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// maxRecentBlocks is the number of recent blocks whose hashes are kept to detect reorgs.
+const maxRecentBlocks = 32
+
+// recentBlocksDistance is the distance from the head within which the hashes of blocks are kept.
+// Reorgs do not reach further back than this, so there is no need to fetch hashes when catching up.
+const recentBlocksDistance = 1024
+
+// recordBlock records the hash of a block up to which deposits have been processed.
+func (md *metadata) recordBlock(number uint64, hash []byte) {
+	md.RecentBlocks = append(md.RecentBlocks, &processedBlock{
+		Number: number,
+		Hash:   fmt.Sprintf("%#x", hash),
+	})
+	if len(md.RecentBlocks) > maxRecentBlocks {
+		md.RecentBlocks = md.RecentBlocks[len(md.RecentBlocks)-maxRecentBlocks:]
+	}
+}
+
+// handleReorg checks if the blocks up to which deposits have been processed are still canonical.  If
+// not, it removes the deposits after the latest block that is still canonical so that they are refetched.
+func (s *Service) handleReorg(ctx context.Context, md *metadata) error {
+	if len(md.RecentBlocks) == 0 {
+		return nil
+	}
+
+	// Work back through the recent blocks to find the latest that is still canonical.
+	canonical := -1
+	for i := len(md.RecentBlocks) - 1; i >= 0; i-- {
+		hash, err := s.blockHashByNumber(ctx, md.RecentBlocks[i].Number)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain block hash")
+		}
+		if hash == nil {
+			// The client does not have the block, for example because it is still syncing, so cannot tell.
+			s.log.Debug().Uint64("block", md.RecentBlocks[i].Number).Msg("Client does not have block; not checking for reorg")
+			return nil
+		}
+		if fmt.Sprintf("%#x", hash) == md.RecentBlocks[i].Hash {
+			canonical = i
+			break
+		}
+	}
+	if canonical == len(md.RecentBlocks)-1 {
+		// No reorg.
+		return nil
+	}
+
+	var rewindBlock uint64
+	if canonical >= 0 {
+		rewindBlock = md.RecentBlocks[canonical].Number
+	} else {
+		// None of the recent blocks are canonical, so the reorg is deeper than can be tracked.  Rewind to before
+		// the oldest of them, although deposits prior to it may also have been reorged.
+		s.log.Warn().Uint64("block", md.RecentBlocks[0].Number).Msg("Reorg deeper than recent blocks; deposits before the block are not checked")
+		if md.RecentBlocks[0].Number > 0 {
+			rewindBlock = md.RecentBlocks[0].Number - 1
+		}
+	}
+	s.log.Info().Uint64("latest_block", md.LatestBlock).Uint64("rewind_block", rewindBlock).Msg("Reorg detected; refetching deposits")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.eth1DepositsDeleter.DeleteETH1Deposits(ctx, rewindBlock+1, md.LatestBlock); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to delete reorged deposits")
+	}
+
+	md.LatestBlock = rewindBlock
+	if md.ConfirmedBlock > rewindBlock {
+		s.log.Warn().Uint64("confirmed_block", md.ConfirmedBlock).Uint64("rewind_block", rewindBlock).Msg("Reorg deeper than confirmations; confirmed deposits refetched")
+		md.ConfirmedBlock = rewindBlock
+	}
+	md.RecentBlocks = md.RecentBlocks[:canonical+1]
+	// Missed blocks after the rewind block will be fetched again.
+	missedBlocks := make([]uint64, 0, len(md.MissedBlocks))
+	for _, missedBlock := range md.MissedBlocks {
+		if missedBlock <= rewindBlock {
+			missedBlocks = append(missedBlocks, missedBlock)
+		}
+	}
+	md.MissedBlocks = missedBlocks
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	s.monitorReorg()
+
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+// newReorgTestService creates a service backed by an Ethereum 1 client that returns the given block hashes.
+func newReorgTestService(t *testing.T, hashes map[uint64]string) (*Service, *mockchaindb.Service) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		number, err := strconv.ParseUint(strings.TrimPrefix(req.Params[0].(string), "0x"), 16, 64)
+		require.NoError(t, err)
+		hash, exists := hashes[number]
+		if !exists {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1901,"result":null}`))
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1901,"result":{"hash":"%s"}}`, hash)))
+	}))
+	t.Cleanup(srv.Close)
+
+	base, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	chainDB := mockchaindb.New()
+
+	return &Service{
+		metrics:             &serviceMetrics{},
+		chainDB:             chainDB,
+		eth1DepositsDeleter: chainDB,
+		timeout:             5 * time.Second,
+		base:                base,
+		client:              srv.Client(),
+	}, chainDB
+}
+
+func TestHandleReorg(t *testing.T) {
+	recentBlocks := func() []*processedBlock {
+		return []*processedBlock{
+			{Number: 100, Hash: "0x0100"},
+			{Number: 110, Hash: "0x0110"},
+			{Number: 120, Hash: "0x0120"},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		hashes       map[uint64]string
+		missedBlocks []uint64
+		deleted      []interface{}
+		latestBlock  uint64
+		recentBlocks int
+		missed       []uint64
+		confirmed    uint64
+	}{
+		{
+			name:         "NoReorg",
+			hashes:       map[uint64]string{100: "0x0100", 110: "0x0110", 120: "0x0120"},
+			latestBlock:  120,
+			recentBlocks: 3,
+			confirmed:    108,
+		},
+		{
+			name:         "Reorg",
+			hashes:       map[uint64]string{100: "0x0100", 110: "0x0110", 120: "0x0121"},
+			missedBlocks: []uint64{105, 115},
+			deleted:      []interface{}{uint64(111), uint64(120)},
+			latestBlock:  110,
+			recentBlocks: 2,
+			missed:       []uint64{105},
+			confirmed:    108,
+		},
+		{
+			name:         "ReorgBlockMissing",
+			hashes:       map[uint64]string{100: "0x0100"},
+			latestBlock:  120,
+			recentBlocks: 3,
+			confirmed:    108,
+		},
+		{
+			name:         "ReorgDeep",
+			hashes:       map[uint64]string{100: "0x0101", 110: "0x0111", 120: "0x0121"},
+			deleted:      []interface{}{uint64(100), uint64(120)},
+			latestBlock:  99,
+			recentBlocks: 0,
+			confirmed:    99,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, chainDB := newReorgTestService(t, test.hashes)
+			md := &metadata{
+				LatestBlock:    120,
+				MissedBlocks:   test.missedBlocks,
+				RecentBlocks:   recentBlocks(),
+				ConfirmedBlock: 108,
+			}
+			require.NoError(t, s.handleReorg(context.Background(), md))
+			calls := chainDB.CallsTo("DeleteETH1Deposits")
+			if test.deleted == nil {
+				require.Len(t, calls, 0)
+			} else {
+				require.Len(t, calls, 1)
+				require.Equal(t, test.deleted, calls[0].Args)
+			}
+			require.Equal(t, test.latestBlock, md.LatestBlock)
+			require.Len(t, md.RecentBlocks, test.recentBlocks)
+			require.Equal(t, test.confirmed, md.ConfirmedBlock)
+			if test.missed == nil {
+				require.Empty(t, md.MissedBlocks)
+			} else {
+				require.Equal(t, test.missed, md.MissedBlocks)
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name           string
+		confirmedBlock uint64
+		confirmed      uint64
+		updated        bool
+	}{
+		{
+			name:           "Advance",
+			confirmedBlock: 110,
+			confirmed:      110,
+			updated:        true,
+		},
+		{
+			name:           "BeyondLatest",
+			confirmedBlock: 130,
+			confirmed:      120,
+			updated:        true,
+		},
+		{
+			name:           "Unchanged",
+			confirmedBlock: 100,
+			confirmed:      100,
+		},
+		{
+			name:           "Behind",
+			confirmedBlock: 90,
+			confirmed:      100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, chainDB := newReorgTestService(t, nil)
+			s.provisional = true
+			md := &metadata{
+				LatestBlock:    120,
+				ConfirmedBlock: 100,
+			}
+			require.NoError(t, s.confirm(context.Background(), md, test.confirmedBlock))
+			require.Equal(t, test.confirmed, md.ConfirmedBlock)
+			if test.updated {
+				require.Len(t, chainDB.CallsTo("SetMetadata"), 1)
+			} else {
+				require.Len(t, chainDB.CallsTo("SetMetadata"), 0)
+			}
+		})
+	}
+}
+
+func TestRecordBlock(t *testing.T) {
+	md := &metadata{}
+	for i := uint64(0); i < maxRecentBlocks+2; i++ {
+		md.recordBlock(i, []byte{byte(i)})
+	}
+	require.Len(t, md.RecentBlocks, maxRecentBlocks)
+	require.Equal(t, uint64(2), md.RecentBlocks[0].Number)
+	require.Equal(t, "0x21", md.RecentBlocks[maxRecentBlocks-1].Hash)
+}
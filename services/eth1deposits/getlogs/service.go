@@ -25,26 +25,34 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
-// module-wide log.
-var log zerolog.Logger
-
 // Service is an Ethereum 1 deposits service that fetches deposits through fetching logs.
 type Service struct {
+	log                    zerolog.Logger
+	metrics                *serviceMetrics
 	chainDB                chaindb.Service
 	timeout                time.Duration
 	base                   *url.URL
 	client                 *http.Client
 	eth1DepositsSetter     chaindb.ETH1DepositsSetter
+	eth1DepositsDeleter    chaindb.ETH1DepositsDeleter
 	eth1Confirmations      uint64
+	provisional            bool
 	blockTimestamps        map[[32]byte]time.Time
 	blocksPerRequest       uint64
 	depositContractAddress []byte
 	activitySem            *semaphore.Weighted
+	paused                 atomic.Bool
+	secrets                secrets.Service
+	headers                map[string]string
+	bearerToken            string
+	jwtSecret              []byte
 }
 
 // New creates a new Ethereum 1 deposit service.
@@ -55,18 +63,20 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "eth1deposits").Str("impl", "getlogs").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log := util.NetworkServiceLogger(parameters.network, "eth1deposits", "getlogs", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
 	// Connect to Ethereum 1.
 	connectionURL := parameters.connectionURL
-	if !strings.HasPrefix(connectionURL, "http") {
+	switch {
+	case util.UnixSocketPath(connectionURL) != "":
+		// Requests over a unix domain socket still require a host.
+		connectionURL = "http://localhost"
+	case !strings.HasPrefix(connectionURL, "http"):
 		connectionURL = fmt.Sprintf("http://%s", parameters.connectionURL)
 	}
 	base, err := url.Parse(connectionURL)
@@ -74,17 +84,27 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.Wrap(err, "invalid URL")
 	}
 
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:        64,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     384 * time.Second,
+	}
+	if err := util.ConfigureTransport(transport, parameters.connectionURL, parameters.proxyURL); err != nil {
+		return nil, err
+	}
+	if parameters.clientCert != nil || parameters.caCert != nil {
+		transport.TLSClientConfig, err = util.TLSConfig(base.Hostname(), parameters.clientCert, parameters.clientKey, parameters.caCert)
+		if err != nil {
+			return nil, err
+		}
+	}
 	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:        64,
-			MaxIdleConnsPerHost: 64,
-			IdleConnTimeout:     384 * time.Second,
-		},
+		Transport: transport,
 	}
 
 	spec, err := parameters.chainDB.(chaindb.ChainSpecProvider).ChainSpec(ctx)
@@ -98,16 +118,24 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:                    log,
+		metrics:                svcMetrics,
 		chainDB:                parameters.chainDB,
 		timeout:                30 * time.Second,
 		eth1DepositsSetter:     parameters.eth1DepositsSetter,
+		eth1DepositsDeleter:    parameters.eth1DepositsSetter.(chaindb.ETH1DepositsDeleter),
 		base:                   base,
 		client:                 client,
 		eth1Confirmations:      parameters.eth1Confirmations,
+		provisional:            parameters.provisional,
 		blockTimestamps:        make(map[[32]byte]time.Time),
 		blocksPerRequest:       64,
 		depositContractAddress: depositContractAddress,
 		activitySem:            semaphore.NewWeighted(1),
+		secrets:                parameters.secrets,
+		headers:                parameters.headers,
+		bearerToken:            parameters.bearerToken,
+		jwtSecret:              parameters.jwtSecret,
 	}
 
 	chainID, err := s.chainID(ctx)
@@ -140,7 +168,7 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 	// Work out the block from which to start.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
 	if startBlock >= 0 {
 		// Explicit requirement to start at a given block.
@@ -149,48 +177,60 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 		} else {
 			md.LatestBlock = 0
 		}
+		// Recent blocks may be after the start block, so are no longer of use.
+		md.RecentBlocks = nil
 	}
-	log.Info().Uint64("block", md.LatestBlock).Msg("Last processed block")
+	s.log.Info().Uint64("block", md.LatestBlock).Msg("Last processed block")
 
 	s.parseNewBlocks(ctx, md)
 	if len(md.MissedBlocks) > 0 {
-		log.Info().Uints64("missed_blocks", md.MissedBlocks).Msg("Re-fetching missed blocks")
+		s.log.Info().Uints64("missed_blocks", md.MissedBlocks).Msg("Re-fetching missed blocks")
 		s.handleMissed(ctx, md)
-		log.Info().Uint64("block", md.LatestBlock).Msg("Catching up from block")
+		s.log.Info().Uint64("block", md.LatestBlock).Msg("Catching up from block")
 		s.parseNewBlocks(ctx, md)
 	}
-	log.Info().Msg("Caught up")
+	s.log.Info().Msg("Caught up")
 
 	// Run periodically.
 	go func(ctx context.Context, s *Service) {
 		for {
 			select {
 			case <-time.After(2 * time.Minute):
+				if s.paused.Load() {
+					s.log.Trace().Msg("Paused; not checking for new blocks")
+					continue
+				}
 				s.checkLatestBlock(ctx)
 			case <-ctx.Done():
-				log.Debug().Msg("Context done")
+				s.log.Debug().Msg("Context done")
 				return
 			}
 		}
 	}(ctx, s)
 }
 
-func (s *Service) getLatestHeadBlock(ctx context.Context) (uint64, error) {
+// getLatestHeadBlock returns the latest block to which deposits should be fetched, and the latest
+// block that has the required number of confirmations.
+func (s *Service) getLatestHeadBlock(ctx context.Context) (uint64, uint64, error) {
 	head, err := s.blockNumber(ctx)
 	if err != nil {
-		return 0, errors.Wrap(err, "failed to obtain block number")
+		return 0, 0, errors.Wrap(err, "failed to obtain block number")
 	}
+	confirmedBlock := uint64(0)
 	if head > s.eth1Confirmations {
-		return head - s.eth1Confirmations, nil
+		confirmedBlock = head - s.eth1Confirmations
 	}
-	return 0, nil
+	if s.provisional {
+		return head, confirmedBlock, nil
+	}
+	return confirmedBlock, confirmedBlock, nil
 }
 
 func (s *Service) checkLatestBlock(ctx context.Context) {
 	// Work out the block from which to start.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
 	s.parseNewBlocks(ctx, md)
 }
@@ -199,23 +239,28 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 	defer s.activitySem.Release(1)
 
-	latestHeadBlock, err := s.getLatestHeadBlock(ctx)
+	latestHeadBlock, confirmedBlock, err := s.getLatestHeadBlock(ctx)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to obtain latest head block")
+		s.log.Error().Err(err).Msg("Failed to obtain latest head block")
 		return
 	}
 
 	if latestHeadBlock == 0 {
-		log.Debug().Msg("Ethereum 1 node is syncing; not fetching blocks")
+		s.log.Debug().Msg("Ethereum 1 node is syncing; not fetching blocks")
+		return
+	}
+
+	if err := s.handleReorg(ctx, md); err != nil {
+		s.log.Error().Err(err).Msg("Failed to handle reorg")
 		return
 	}
 
-	log.Trace().Uint64("start_block", md.LatestBlock+1).Uint64("end_block", latestHeadBlock).Msg("Fetching ETH1 logs in batches")
+	s.log.Trace().Uint64("start_block", md.LatestBlock+1).Uint64("end_block", latestHeadBlock).Msg("Fetching ETH1 logs in batches")
 	for block := md.LatestBlock + 1; block <= latestHeadBlock; block += s.blocksPerRequest {
 		startBlock := block
 		endBlock := block + s.blocksPerRequest - 1
@@ -223,7 +268,7 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 			endBlock = latestHeadBlock
 		}
 
-		log := log.With().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Logger()
+		log := s.log.With().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
@@ -231,6 +276,18 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 			return
 		}
 
+		// Obtain the hash of recent blocks before their deposits, so that a reorg in between is
+		// detected on the next update rather than missed.
+		var endBlockHash []byte
+		if latestHeadBlock-endBlock < recentBlocksDistance {
+			endBlockHash, err = s.blockHashByNumber(ctx, endBlock)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to obtain block hash")
+				cancel()
+				return
+			}
+		}
+
 		if err := s.handleBlocks(ctx, startBlock, endBlock); err != nil {
 			log.Warn().Err(err).Msg("Failed to update ETH1 deposits")
 			for missedBlock := block; missedBlock <= endBlock; missedBlock++ {
@@ -239,6 +296,9 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 		}
 
 		md.LatestBlock = endBlock
+		if endBlockHash != nil {
+			md.recordBlock(endBlock, endBlockHash)
+		}
 		if err := s.setMetadata(ctx, md); err != nil {
 			log.Error().Err(err).Msg("Failed to set metadata")
 			cancel()
@@ -251,4 +311,39 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 			return
 		}
 	}
+
+	if err := s.confirm(ctx, md, confirmedBlock); err != nil {
+		s.log.Error().Err(err).Msg("Failed to confirm deposits")
+	}
+}
+
+// confirm marks the deposits up to the given block as confirmed.  Any reorg of the
+// blocks up to it has already been handled, so deposits from them are canonical.
+func (s *Service) confirm(ctx context.Context, md *metadata, confirmedBlock uint64) error {
+	if confirmedBlock > md.LatestBlock {
+		confirmedBlock = md.LatestBlock
+	}
+	if confirmedBlock <= md.ConfirmedBlock {
+		return nil
+	}
+	if s.provisional {
+		s.log.Trace().Uint64("start_block", md.ConfirmedBlock+1).Uint64("end_block", confirmedBlock).Msg("Confirmed provisional deposits")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	md.ConfirmedBlock = confirmedBlock
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	s.monitorConfirmedBlock(confirmedBlock)
+
+	return nil
 }
@@ -56,6 +56,52 @@ func TestService(t *testing.T) {
 			},
 			err: "problem with parameters: no connection URL specified",
 		},
+		{
+			name: "ClientKeyMissing",
+			params: []getlogs.Parameter{
+				getlogs.WithLogLevel(zerolog.Disabled),
+				getlogs.WithChainDB(chainDB),
+				getlogs.WithETH1DepositsSetter(chainDB),
+				getlogs.WithConnectionURL(os.Getenv("EXECCLIENT_URL")),
+				getlogs.WithClientCert([]byte("cert")),
+			},
+			err: "problem with parameters: client certificate and key must be specified together",
+		},
+		{
+			name: "BearerTokenAndJWTSecret",
+			params: []getlogs.Parameter{
+				getlogs.WithLogLevel(zerolog.Disabled),
+				getlogs.WithChainDB(chainDB),
+				getlogs.WithETH1DepositsSetter(chainDB),
+				getlogs.WithConnectionURL(os.Getenv("EXECCLIENT_URL")),
+				getlogs.WithBearerToken("token"),
+				getlogs.WithJWTSecret([]byte("secretsecretsecretsecretsecretse")),
+			},
+			err: "problem with parameters: only one of bearer token and JWT secret can be specified",
+		},
+		{
+			name: "ProxySchemeUnsupported",
+			params: []getlogs.Parameter{
+				getlogs.WithLogLevel(zerolog.Disabled),
+				getlogs.WithChainDB(chainDB),
+				getlogs.WithETH1DepositsSetter(chainDB),
+				getlogs.WithConnectionURL(os.Getenv("EXECCLIENT_URL")),
+				getlogs.WithProxyURL("ftp://proxy.example.com"),
+			},
+			err: `unsupported proxy scheme "ftp"`,
+		},
+		{
+			name: "ProvisionalConfirmationsTooHigh",
+			params: []getlogs.Parameter{
+				getlogs.WithLogLevel(zerolog.Disabled),
+				getlogs.WithChainDB(chainDB),
+				getlogs.WithETH1DepositsSetter(chainDB),
+				getlogs.WithConnectionURL(os.Getenv("EXECCLIENT_URL")),
+				getlogs.WithETH1Confirmations(2048),
+				getlogs.WithProvisional(true),
+			},
+			err: "problem with parameters: provisional deposits cannot be reconciled over more than 1024 confirmations",
+		},
 		{
 			name: "Good",
 			params: []getlogs.Parameter{
@@ -0,0 +1,81 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesced
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_eth2client"
+
+// serviceMetrics holds the metrics of the service for a network.
+type serviceMetrics struct {
+	coalescedRequests *prometheus.CounterVec
+}
+
+// networkMetrics holds the metrics registered for each network, which are shared
+// by all clients for the network.
+var (
+	networkMetrics   = make(map[string]*serviceMetrics)
+	networkMetricsMu sync.Mutex
+)
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		networkMetricsMu.Lock()
+		defer networkMetricsMu.Unlock()
+		if m, exists := networkMetrics[network]; exists {
+			// Already registered.
+			return m, nil
+		}
+		m, err := registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+		if err != nil {
+			return nil, err
+		}
+		networkMetrics[network] = m
+		return m, nil
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.coalescedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "coalesced_requests_total",
+		Help:      "Number of requests answered by an identical request already in flight to the beacon node",
+	}, []string{"endpoint"})
+	if err := registerer.Register(m.coalescedRequests); err != nil {
+		return nil, errors.Wrap(err, "failed to register coalesced_requests_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorCoalesced(endpoint string) {
+	if s.metrics.coalescedRequests != nil {
+		s.metrics.coalescedRequests.WithLabelValues(endpoint).Inc()
+	}
+}
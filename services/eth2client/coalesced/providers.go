@@ -0,0 +1,275 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesced
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// BeaconCommittees fetches all beacon committees for the epoch at the given state.
+func (s *Service) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/committees", fmt.Sprintf("committees/%s", stateID), func(ctx context.Context) (interface{}, error) {
+		return provider.BeaconCommittees(ctx, stateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*apiv1.BeaconCommittee), nil
+}
+
+// BeaconCommitteesAtEpoch fetches all beacon committees for the given epoch at the given state.
+func (s *Service) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/committees", fmt.Sprintf("committees/%s/%d", stateID, epoch), func(ctx context.Context) (interface{}, error) {
+		return provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*apiv1.BeaconCommittee), nil
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconStateProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v2/debug/beacon/states/{state_id}", fmt.Sprintf("state/%s", stateID), func(ctx context.Context) (interface{}, error) {
+		return provider.BeaconState(ctx, stateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*spec.VersionedBeaconState), nil
+}
+
+// Events feeds requested events with the given topics to the supplied handler.
+// Subscriptions are long-lived, so are not coalesced.
+func (s *Service) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	provider, isProvider := s.eth2Client.(eth2client.EventsProvider)
+	if !isProvider {
+		return errNotProvided
+	}
+	return provider.Events(ctx, topics, handler)
+}
+
+// Finality provides the finality given a state ID.
+func (s *Service) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	provider, isProvider := s.eth2Client.(eth2client.FinalityProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/finality_checkpoints", fmt.Sprintf("finality/%s", stateID), func(ctx context.Context) (interface{}, error) {
+		return provider.Finality(ctx, stateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*apiv1.Finality), nil
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ForkScheduleProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/config/fork_schedule", "forkschedule", func(ctx context.Context) (interface{}, error) {
+		return provider.ForkSchedule(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*phase0.Fork), nil
+}
+
+// Genesis fetches genesis information for the chain.
+func (s *Service) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	provider, isProvider := s.eth2Client.(eth2client.GenesisProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/genesis", "genesis", func(ctx context.Context) (interface{}, error) {
+		return provider.Genesis(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*apiv1.Genesis), nil
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	provider, isProvider := s.eth2Client.(eth2client.GenesisTimeProvider)
+	if !isProvider {
+		return time.Time{}, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/genesis", "genesistime", func(ctx context.Context) (interface{}, error) {
+		return provider.GenesisTime(ctx)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return res.(time.Time), nil
+}
+
+// NodeSyncing provides the state of the node's synchronization with the chain.
+func (s *Service) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	provider, isProvider := s.eth2Client.(eth2client.NodeSyncingProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/node/syncing", "syncing", func(ctx context.Context) (interface{}, error) {
+		return provider.NodeSyncing(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*apiv1.SyncState), nil
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (s *Service) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/validator/duties/proposer/{epoch}", fmt.Sprintf("proposerduties/%d/%v", epoch, validatorIndices), func(ctx context.Context) (interface{}, error) {
+		return provider.ProposerDuties(ctx, epoch, validatorIndices)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.([]*apiv1.ProposerDuty), nil
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v2/beacon/blocks/{block_id}", fmt.Sprintf("block/%s", blockID), func(ctx context.Context) (interface{}, error) {
+		return provider.SignedBeaconBlock(ctx, blockID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*spec.VersionedSignedBeaconBlock), nil
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (s *Service) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SlotsPerEpochProvider)
+	if !isProvider {
+		return 0, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/config/spec", "slotsperepoch", func(ctx context.Context) (interface{}, error) {
+		return provider.SlotsPerEpoch(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.(uint64), nil
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/config/spec", "spec", func(ctx context.Context) (interface{}, error) {
+		return provider.Spec(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[string]interface{}), nil
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (s *Service) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/sync_committees", fmt.Sprintf("synccommittee/%s", stateID), func(ctx context.Context) (interface{}, error) {
+		return provider.SyncCommittee(ctx, stateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*apiv1.SyncCommittee), nil
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (s *Service) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/sync_committees", fmt.Sprintf("synccommittee/%s/%d", stateID, epoch), func(ctx context.Context) (interface{}, error) {
+		return provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*apiv1.SyncCommittee), nil
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+func (s *Service) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/validators", fmt.Sprintf("validators/%s/%v", stateID, validatorIndices), func(ctx context.Context) (interface{}, error) {
+		return provider.Validators(ctx, stateID, validatorIndices)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[phase0.ValidatorIndex]*apiv1.Validator), nil
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (s *Service) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	res, err := s.coalesce(ctx, "/eth/v1/beacon/states/{state_id}/validators", fmt.Sprintf("validatorsbypubkey/%s/%x", stateID, validatorPubKeys), func(ctx context.Context) (interface{}, error) {
+		return provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[phase0.ValidatorIndex]*apiv1.Validator), nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesced
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/singleflight"
+)
+
+// Service wraps an Ethereum 2 client, coalescing identical requests that are in
+// flight at the same time into a single request to the beacon node.  Clients are
+// shared between modules, so this avoids, for example, the same block being
+// fetched by multiple modules when it arrives.  Results are shared between callers,
+// so must not be altered.
+type Service struct {
+	log        zerolog.Logger
+	metrics    *serviceMetrics
+	eth2Client eth2client.Service
+	group      singleflight.Group
+}
+
+// New creates a new coalescing Ethereum 2 client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "eth2client", "coalesced", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	return &Service{
+		log:        log,
+		metrics:    svcMetrics,
+		eth2Client: parameters.eth2Client,
+	}, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.eth2Client.Name()
+}
+
+// Address returns the address of the client.
+func (s *Service) Address() string {
+	return s.eth2Client.Address()
+}
+
+// errNotProvided is returned when the underlying client does not provide the requested data.
+var errNotProvided = errors.New("underlying client does not provide this information")
+
+// coalesce carries out a request, unless an identical request is already in flight
+// in which case it waits for and returns the result of that request.  The request
+// is not tied to the lifetime of any single caller, so a caller giving up does not
+// cause the request to fail for others.
+func (s *Service) coalesce(ctx context.Context,
+	endpoint string,
+	key string,
+	request func(context.Context) (interface{}, error),
+) (
+	interface{},
+	error,
+) {
+	requested := false
+	resCh := s.group.DoChan(key, func() (interface{}, error) {
+		requested = true
+		return request(util.WithoutCancel(ctx))
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resCh:
+		if !requested {
+			s.log.Trace().Str("key", key).Msg("Coalesced request")
+			s.monitorCoalesced(endpoint)
+		}
+		return res.Val, res.Err
+	}
+}
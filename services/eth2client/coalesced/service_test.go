@@ -0,0 +1,152 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coalesced_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/coalesced"
+)
+
+// blockingClient is a client that returns blocks only once released.
+type blockingClient struct {
+	requests int32
+	release  chan struct{}
+}
+
+func (*blockingClient) Name() string    { return "blocking" }
+func (*blockingClient) Address() string { return "localhost" }
+
+func (c *blockingClient) SignedBeaconBlock(_ context.Context, _ string) (*spec.VersionedSignedBeaconBlock, error) {
+	atomic.AddInt32(&c.requests, 1)
+	<-c.release
+	return &spec.VersionedSignedBeaconBlock{Version: spec.DataVersionPhase0}, nil
+}
+
+// nameOnlyClient is a client that provides no information.
+type nameOnlyClient struct{}
+
+func (*nameOnlyClient) Name() string    { return "name only" }
+func (*nameOnlyClient) Address() string { return "localhost" }
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []coalesced.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []coalesced.Parameter{
+				coalesced.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "Good",
+			params: []coalesced.Parameter{
+				coalesced.WithLogLevel(zerolog.Disabled),
+				coalesced.WithETH2Client(&nameOnlyClient{}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := coalesced.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	ctx := context.Background()
+
+	client := &blockingClient{release: make(chan struct{})}
+	s, err := coalesced.New(ctx,
+		coalesced.WithLogLevel(zerolog.Disabled),
+		coalesced.WithETH2Client(client),
+	)
+	require.NoError(t, err)
+
+	// A caller that gives up does not affect the others.
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancelledErr := make(chan error)
+	go func() {
+		_, err := s.SignedBeaconBlock(cancelledCtx, "1")
+		cancelledErr <- err
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&client.requests) == 1 }, time.Second, time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-cancelledErr, context.Canceled)
+
+	var started sync.WaitGroup
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		started.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			block, err := s.SignedBeaconBlock(ctx, "1")
+			require.NoError(t, err)
+			require.Equal(t, spec.DataVersionPhase0, block.Version)
+		}()
+	}
+	// A different block is requested separately.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := s.SignedBeaconBlock(ctx, "2")
+		require.NoError(t, err)
+	}()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&client.requests) == 2 }, time.Second, time.Millisecond)
+	// Allow the callers to join the request in flight before it completes.
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(client.release)
+	wg.Wait()
+	require.Equal(t, int32(2), atomic.LoadInt32(&client.requests))
+
+	// Requests after completion are made afresh.
+	_, err = s.SignedBeaconBlock(ctx, "1")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&client.requests))
+}
+
+func TestNotProvided(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := coalesced.New(ctx,
+		coalesced.WithLogLevel(zerolog.Disabled),
+		coalesced.WithETH2Client(&nameOnlyClient{}),
+	)
+	require.NoError(t, err)
+
+	_, err = s.Genesis(ctx)
+	require.EqualError(t, err, "underlying client does not provide this information")
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genesisstate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Genesis provides genesis information for the chain.
+func (s *Service) Genesis(_ context.Context) (*apiv1.Genesis, error) {
+	return s.genesis, nil
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(_ context.Context) (time.Time, error) {
+	return s.genesis.GenesisTime, nil
+}
+
+// GenesisValidatorsRoot provides the genesis validators root of the chain.
+func (s *Service) GenesisValidatorsRoot(_ context.Context) ([]byte, error) {
+	return s.genesis.GenesisValidatorsRoot[:], nil
+}
+
+// Validators provides the validators at genesis, optionally filtered by index.
+// Only the genesis state is available, so the state ID must be "genesis" or "0".
+func (s *Service) Validators(_ context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	if stateID != "genesis" && stateID != "0" {
+		return nil, fmt.Errorf("state %s not available from genesis state", stateID)
+	}
+
+	if len(validatorIndices) == 0 {
+		return s.validators, nil
+	}
+	res := make(map[phase0.ValidatorIndex]*apiv1.Validator, len(validatorIndices))
+	for _, index := range validatorIndices {
+		if validator, exists := s.validators[index]; exists {
+			res[index] = validator
+		}
+	}
+
+	return res, nil
+}
+
+// ValidatorsByPubKey provides the validators at genesis, filtered by public key.
+// Only the genesis state is available, so the state ID must be "genesis" or "0".
+func (s *Service) ValidatorsByPubKey(_ context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	if stateID != "genesis" && stateID != "0" {
+		return nil, fmt.Errorf("state %s not available from genesis state", stateID)
+	}
+
+	pubKeys := make(map[phase0.BLSPubKey]bool, len(validatorPubKeys))
+	for _, pubKey := range validatorPubKeys {
+		pubKeys[pubKey] = true
+	}
+	res := make(map[phase0.ValidatorIndex]*apiv1.Validator)
+	for index, validator := range s.validators {
+		if pubKeys[validator.Validator.PublicKey] {
+			res[index] = validator
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,148 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genesisstate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service provides genesis information and the initial validator set from a
+// genesis state held in a local SSZ file, for chains whose beacon nodes do not
+// serve genesis information or cannot be reached.
+type Service struct {
+	log        zerolog.Logger
+	genesis    *apiv1.Genesis
+	validators map[phase0.ValidatorIndex]*apiv1.Validator
+}
+
+// New creates a new genesis state service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "eth2client", "genesisstate", parameters.logLevel)
+
+	data, err := os.ReadFile(parameters.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read genesis state")
+	}
+
+	state, err := decodeState(data)
+	if err != nil {
+		return nil, err
+	}
+	if state.slot != 0 {
+		return nil, fmt.Errorf("state is for slot %d, not genesis", state.slot)
+	}
+	if len(state.balances) != len(state.validators) {
+		return nil, fmt.Errorf("state has %d validators but %d balances", len(state.validators), len(state.balances))
+	}
+	log.Trace().Str("version", state.version).Int("validators", len(state.validators)).Msg("Decoded genesis state")
+
+	s := &Service{
+		log: log,
+		genesis: &apiv1.Genesis{
+			GenesisTime:        time.Unix(int64(state.genesisTime), 0),
+			GenesisForkVersion: state.fork.CurrentVersion,
+		},
+		validators: make(map[phase0.ValidatorIndex]*apiv1.Validator, len(state.validators)),
+	}
+	copy(s.genesis.GenesisValidatorsRoot[:], state.genesisValidatorsRoot)
+	for i, validator := range state.validators {
+		index := phase0.ValidatorIndex(i)
+		status := apiv1.ValidatorStatePendingInitialized
+		if validator.ActivationEpoch == 0 {
+			status = apiv1.ValidatorStateActiveOngoing
+		}
+		s.validators[index] = &apiv1.Validator{
+			Index:     index,
+			Balance:   phase0.Gwei(state.balances[i]),
+			Status:    status,
+			Validator: validator,
+		}
+	}
+
+	return s, nil
+}
+
+// genesisState contains the parts of a beacon state common to all forks that are
+// required to provide genesis information.
+type genesisState struct {
+	version               string
+	genesisTime           uint64
+	genesisValidatorsRoot []byte
+	slot                  uint64
+	fork                  *phase0.Fork
+	validators            []*phase0.Validator
+	balances              []uint64
+}
+
+// decodeState decodes an SSZ-encoded beacon state.  The file does not say to which
+// fork the state belongs, so each is tried in turn; the layouts of the forks differ,
+// so only the correct fork decodes.
+func decodeState(data []byte) (*genesisState, error) {
+	bellatrixState := &bellatrix.BeaconState{}
+	if err := bellatrixState.UnmarshalSSZ(data); err == nil {
+		return &genesisState{
+			version:               "bellatrix",
+			genesisTime:           bellatrixState.GenesisTime,
+			genesisValidatorsRoot: bellatrixState.GenesisValidatorsRoot,
+			slot:                  bellatrixState.Slot,
+			fork:                  bellatrixState.Fork,
+			validators:            bellatrixState.Validators,
+			balances:              bellatrixState.Balances,
+		}, nil
+	}
+
+	altairState := &altair.BeaconState{}
+	if err := altairState.UnmarshalSSZ(data); err == nil {
+		return &genesisState{
+			version:               "altair",
+			genesisTime:           altairState.GenesisTime,
+			genesisValidatorsRoot: altairState.GenesisValidatorsRoot,
+			slot:                  altairState.Slot,
+			fork:                  altairState.Fork,
+			validators:            altairState.Validators,
+			balances:              altairState.Balances,
+		}, nil
+	}
+
+	phase0State := &phase0.BeaconState{}
+	if err := phase0State.UnmarshalSSZ(data); err != nil {
+		return nil, errors.Wrap(err, "failed to decode genesis state")
+	}
+	return &genesisState{
+		version:               "phase0",
+		genesisTime:           phase0State.GenesisTime,
+		genesisValidatorsRoot: phase0State.GenesisValidatorsRoot,
+		slot:                  phase0State.Slot,
+		fork:                  phase0State.Fork,
+		validators:            phase0State.Validators,
+		balances:              phase0State.Balances,
+	}, nil
+}
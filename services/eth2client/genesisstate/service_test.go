@@ -0,0 +1,199 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genesisstate_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/genesisstate"
+)
+
+// roots returns the given number of zero roots.
+func roots(n int) [][]byte {
+	res := make([][]byte, n)
+	for i := range res {
+		res[i] = make([]byte, 32)
+	}
+	return res
+}
+
+// writeState writes an SSZ-encoded phase0 state at the given slot to a file, returning its path.
+func writeState(t *testing.T, slot uint64) string {
+	t.Helper()
+
+	validatorsRoot := make([]byte, 32)
+	validatorsRoot[0] = 0x01
+	state := &phase0.BeaconState{
+		GenesisTime:           1606824023,
+		GenesisValidatorsRoot: validatorsRoot,
+		Slot:                  slot,
+		Fork: &phase0.Fork{
+			CurrentVersion: phase0.Version{0x00, 0x00, 0x10, 0x20},
+		},
+		LatestBlockHeader: &phase0.BeaconBlockHeader{
+			ParentRoot: phase0.Root{},
+			StateRoot:  phase0.Root{},
+			BodyRoot:   phase0.Root{},
+		},
+		BlockRoots:  roots(8192),
+		StateRoots:  roots(8192),
+		ETH1Data:    &phase0.ETH1Data{DepositRoot: phase0.Root{}, BlockHash: make([]byte, 32)},
+		RANDAOMixes: roots(65536),
+		Slashings:   make([]uint64, 8192),
+		Validators: []*phase0.Validator{
+			{
+				PublicKey:                  phase0.BLSPubKey{0x01},
+				WithdrawalCredentials:      make([]byte, 32),
+				EffectiveBalance:           32000000000,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  0xffffffffffffffff,
+				WithdrawableEpoch:          0xffffffffffffffff,
+			},
+			{
+				PublicKey:                  phase0.BLSPubKey{0x02},
+				WithdrawalCredentials:      make([]byte, 32),
+				EffectiveBalance:           16000000000,
+				ActivationEligibilityEpoch: 0xffffffffffffffff,
+				ActivationEpoch:            0xffffffffffffffff,
+				ExitEpoch:                  0xffffffffffffffff,
+				WithdrawableEpoch:          0xffffffffffffffff,
+			},
+		},
+		Balances:                    []uint64{32000000000, 16000000000},
+		JustificationBits:           bitfield.NewBitvector4(),
+		PreviousJustifiedCheckpoint: &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:  &phase0.Checkpoint{},
+		FinalizedCheckpoint:         &phase0.Checkpoint{},
+	}
+	data, err := state.MarshalSSZ()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "genesis.ssz")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	badPath := filepath.Join(t.TempDir(), "bad.ssz")
+	require.NoError(t, os.WriteFile(badPath, []byte("not a state"), 0o600))
+
+	tests := []struct {
+		name   string
+		params []genesisstate.Parameter
+		err    string
+	}{
+		{
+			name: "PathMissing",
+			params: []genesisstate.Parameter{
+				genesisstate.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no path specified",
+		},
+		{
+			name: "FileMissing",
+			params: []genesisstate.Parameter{
+				genesisstate.WithLogLevel(zerolog.Disabled),
+				genesisstate.WithPath(filepath.Join(t.TempDir(), "missing.ssz")),
+			},
+			err: "failed to read genesis state",
+		},
+		{
+			name: "FileInvalid",
+			params: []genesisstate.Parameter{
+				genesisstate.WithLogLevel(zerolog.Disabled),
+				genesisstate.WithPath(badPath),
+			},
+			err: "failed to decode genesis state",
+		},
+		{
+			name: "NotGenesis",
+			params: []genesisstate.Parameter{
+				genesisstate.WithLogLevel(zerolog.Disabled),
+				genesisstate.WithPath(writeState(t, 32)),
+			},
+			err: "state is for slot 32, not genesis",
+		},
+		{
+			name: "Good",
+			params: []genesisstate.Parameter{
+				genesisstate.WithLogLevel(zerolog.Disabled),
+				genesisstate.WithPath(writeState(t, 0)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := genesisstate.New(ctx, test.params...)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := genesisstate.New(ctx,
+		genesisstate.WithLogLevel(zerolog.Disabled),
+		genesisstate.WithPath(writeState(t, 0)),
+	)
+	require.NoError(t, err)
+
+	genesis, err := s.Genesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1606824023, 0), genesis.GenesisTime)
+	require.Equal(t, phase0.Root{0x01}, genesis.GenesisValidatorsRoot)
+	require.Equal(t, phase0.Version{0x00, 0x00, 0x10, 0x20}, genesis.GenesisForkVersion)
+
+	genesisTime, err := s.GenesisTime(ctx)
+	require.NoError(t, err)
+	require.Equal(t, genesis.GenesisTime, genesisTime)
+
+	validators, err := s.Validators(ctx, "genesis", nil)
+	require.NoError(t, err)
+	require.Len(t, validators, 2)
+	require.Equal(t, apiv1.ValidatorStateActiveOngoing, validators[0].Status)
+	require.Equal(t, apiv1.ValidatorStatePendingInitialized, validators[1].Status)
+	require.Equal(t, phase0.Gwei(16000000000), validators[1].Balance)
+
+	validators, err = s.Validators(ctx, "0", []phase0.ValidatorIndex{1, 2})
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	require.Equal(t, phase0.BLSPubKey{0x02}, validators[1].Validator.PublicKey)
+
+	validators, err = s.ValidatorsByPubKey(ctx, "genesis", []phase0.BLSPubKey{{0x01}, {0x03}})
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	require.Equal(t, phase0.Gwei(32000000000), validators[0].Balance)
+
+	_, err = s.Validators(ctx, "head", nil)
+	require.EqualError(t, err, "state head not available from genesis state")
+}
@@ -0,0 +1,229 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitored
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// BeaconCommittees fetches all beacon committees for the epoch at the given state.
+func (s *Service) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.BeaconCommittees(ctx, stateID)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/committees", started, err)
+	return res, err
+}
+
+// BeaconCommitteesAtEpoch fetches all beacon committees for the given epoch at the given state.
+func (s *Service) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/committees", started, err)
+	return res, err
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	provider, isProvider := s.eth2Client.(eth2client.BeaconStateProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.BeaconState(ctx, stateID)
+	s.monitorRequest("/eth/v2/debug/beacon/states/{state_id}", started, err)
+	return res, err
+}
+
+// Events feeds requested events with the given topics to the supplied handler.
+// Only the initial subscription is counted.
+func (s *Service) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	provider, isProvider := s.eth2Client.(eth2client.EventsProvider)
+	if !isProvider {
+		return errNotProvided
+	}
+	started := time.Now()
+	err := provider.Events(ctx, topics, handler)
+	s.monitorRequest("/eth/v1/events", started, err)
+	return err
+}
+
+// Finality provides the finality given a state ID.
+func (s *Service) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	provider, isProvider := s.eth2Client.(eth2client.FinalityProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.Finality(ctx, stateID)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/finality_checkpoints", started, err)
+	return res, err
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ForkScheduleProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.ForkSchedule(ctx)
+	s.monitorRequest("/eth/v1/config/fork_schedule", started, err)
+	return res, err
+}
+
+// Genesis fetches genesis information for the chain.
+func (s *Service) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	provider, isProvider := s.eth2Client.(eth2client.GenesisProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.Genesis(ctx)
+	s.monitorRequest("/eth/v1/beacon/genesis", started, err)
+	return res, err
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	provider, isProvider := s.eth2Client.(eth2client.GenesisTimeProvider)
+	if !isProvider {
+		return time.Time{}, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.GenesisTime(ctx)
+	s.monitorRequest("/eth/v1/beacon/genesis", started, err)
+	return res, err
+}
+
+// NodeSyncing provides the state of the node's synchronization with the chain.
+func (s *Service) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	provider, isProvider := s.eth2Client.(eth2client.NodeSyncingProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.NodeSyncing(ctx)
+	s.monitorRequest("/eth/v1/node/syncing", started, err)
+	return res, err
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (s *Service) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.ProposerDuties(ctx, epoch, validatorIndices)
+	s.monitorRequest("/eth/v1/validator/duties/proposer/{epoch}", started, err)
+	return res, err
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.SignedBeaconBlock(ctx, blockID)
+	s.monitorRequest("/eth/v2/beacon/blocks/{block_id}", started, err)
+	return res, err
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (s *Service) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SlotsPerEpochProvider)
+	if !isProvider {
+		return 0, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.SlotsPerEpoch(ctx)
+	s.monitorRequest("/eth/v1/config/spec", started, err)
+	return res, err
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.Spec(ctx)
+	s.monitorRequest("/eth/v1/config/spec", started, err)
+	return res, err
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (s *Service) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.SyncCommittee(ctx, stateID)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/sync_committees", started, err)
+	return res, err
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (s *Service) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.eth2Client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/sync_committees", started, err)
+	return res, err
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+func (s *Service) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.Validators(ctx, stateID, validatorIndices)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/validators", started, err)
+	return res, err
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (s *Service) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errNotProvided
+	}
+	started := time.Now()
+	res, err := provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+	s.monitorRequest("/eth/v1/beacon/states/{state_id}/validators", started, err)
+	return res, err
+}
@@ -0,0 +1,65 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitored
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service wraps an Ethereum 2 client, counting and timing requests and their failures by endpoint.
+type Service struct {
+	log        zerolog.Logger
+	metrics    *serviceMetrics
+	eth2Client eth2client.Service
+}
+
+// New creates a new monitored Ethereum 2 client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "eth2client", "monitored", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	return &Service{
+		log:        log,
+		metrics:    svcMetrics,
+		eth2Client: parameters.eth2Client,
+	}, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.eth2Client.Name()
+}
+
+// Address returns the address of the client.
+func (s *Service) Address() string {
+	return s.eth2Client.Address()
+}
+
+// errNotProvided is returned when the underlying client does not provide the requested data.
+var errNotProvided = errors.New("underlying client does not provide this information")
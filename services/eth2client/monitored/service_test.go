@@ -0,0 +1,107 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitored_test
+
+import (
+	"context"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	mockclient "github.com/attestantio/go-eth2-client/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/monitored"
+)
+
+// nameOnlyClient is a client that provides no information.
+type nameOnlyClient struct{}
+
+func (*nameOnlyClient) Name() string    { return "name only" }
+func (*nameOnlyClient) Address() string { return "localhost" }
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	eth2Client, err := mockclient.New(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []monitored.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []monitored.Parameter{
+				monitored.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "Good",
+			params: []monitored.Parameter{
+				monitored.WithLogLevel(zerolog.Disabled),
+				monitored.WithETH2Client(eth2Client),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := monitored.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	ctx := context.Background()
+
+	eth2Client, err := mockclient.New(ctx)
+	require.NoError(t, err)
+
+	s, err := monitored.New(ctx,
+		monitored.WithLogLevel(zerolog.Disabled),
+		monitored.WithETH2Client(eth2Client),
+	)
+	require.NoError(t, err)
+	require.Equal(t, eth2Client.Name(), s.Name())
+	require.Equal(t, eth2Client.Address(), s.Address())
+
+	expected, err := eth2Client.Genesis(ctx)
+	require.NoError(t, err)
+	genesis, err := s.Genesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, expected, genesis)
+
+	var _ eth2client.SignedBeaconBlockProvider = s
+	var _ eth2client.ValidatorsProvider = s
+}
+
+func TestNotProvided(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := monitored.New(ctx,
+		monitored.WithLogLevel(zerolog.Disabled),
+		monitored.WithETH2Client(&nameOnlyClient{}),
+	)
+	require.NoError(t, err)
+
+	_, err = s.Genesis(ctx)
+	require.EqualError(t, err, "underlying client does not provide this information")
+}
@@ -0,0 +1,150 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/secrets"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	network     string
+	address     string
+	proxyURL    string
+	clientCert  []byte
+	clientKey   []byte
+	caCert      []byte
+	headers     map[string]string
+	bearerToken string
+	jwtSecret   []byte
+	secrets     secrets.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithAddress sets the address of the beacon node to which requests are forwarded.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithProxyURL sets the URL of an HTTP or SOCKS proxy through which to connect to
+// the beacon node.
+func WithProxyURL(url string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.proxyURL = url
+	})
+}
+
+// WithClientCert sets the bytes of the client TLS certificate.
+func WithClientCert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientCert = cert
+	})
+}
+
+// WithClientKey sets the bytes of the client TLS key.
+func WithClientKey(key []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientKey = key
+	})
+}
+
+// WithCACert sets the bytes of the certificate authority TLS certificate.
+func WithCACert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = cert
+	})
+}
+
+// WithHeaders sets additional headers sent with each request, for example an API key.
+func WithHeaders(headers map[string]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headers = headers
+	})
+}
+
+// WithBearerToken sets the token sent in the Authorization header of each request.
+func WithBearerToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bearerToken = token
+	})
+}
+
+// WithJWTSecret sets the secret used to sign a JSON web token sent in the
+// Authorization header of each request.
+func WithJWTSecret(secret []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.jwtSecret = secret
+	})
+}
+
+// WithSecrets sets the secrets service used to resolve header values and the
+// bearer token, if they refer to secrets.
+func WithSecrets(secrets secrets.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.secrets = secrets
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if (parameters.clientCert == nil) != (parameters.clientKey == nil) {
+		return nil, errors.New("client certificate and key must be specified together")
+	}
+	if parameters.bearerToken != "" && parameters.jwtSecret != nil {
+		return nil, errors.New("only one of bearer token and JWT secret can be specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a proxy that forwards requests received on a loopback address to a
+// beacon node, adding the TLS configuration and authentication headers that the
+// Ethereum 2 client cannot itself be configured with.
+type Service struct {
+	log     zerolog.Logger
+	address string
+	server  *http.Server
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "eth2client", "proxy", parameters.logLevel)
+
+	address := parameters.address
+	switch {
+	case util.UnixSocketPath(address) != "":
+		// Requests over a unix domain socket still require a host.
+		address = "http://localhost"
+	case !strings.HasPrefix(address, "http"):
+		// Connections with TLS configuration are assumed to be to HTTPS endpoints.
+		if parameters.clientCert != nil || parameters.caCert != nil {
+			address = fmt.Sprintf("https://%s", address)
+		} else {
+			address = fmt.Sprintf("http://%s", address)
+		}
+	}
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 64
+	// Proxies are only used if explicitly configured, rather than from the environment.
+	transport.Proxy = nil
+	if err := util.ConfigureTransport(transport, parameters.address, parameters.proxyURL); err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig, err = util.TLSConfig(target.Hostname(), parameters.clientCert, parameters.clientKey, parameters.caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = &authTransport{
+		transport:   transport,
+		secrets:     parameters.secrets,
+		headers:     parameters.headers,
+		bearerToken: parameters.bearerToken,
+		jwtSecret:   parameters.jwtSecret,
+	}
+	// Flush immediately, so that event streams are passed on as they arrive.
+	proxy.FlushInterval = -1
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		log.Debug().Str("path", req.URL.Path).Err(err).Msg("Failed to forward request")
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+
+	s := &Service{
+		log:     log,
+		address: fmt.Sprintf("http://%s", listener.Addr().String()),
+		server: &http.Server{
+			Handler:           proxy,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Proxy stopped")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; closing proxy")
+		if err := s.server.Close(); err != nil {
+			log.Warn().Err(err).Msg("Failed to close proxy")
+		}
+	}()
+	log.Trace().Str("address", s.address).Str("target", target.Host).Msg("Started proxy")
+
+	return s, nil
+}
+
+// Address returns the loopback address on which the proxy receives requests.
+func (s *Service) Address() string {
+	return s.address
+}
+
+// authTransport adds authentication headers to requests before sending them.
+type authTransport struct {
+	transport   http.RoundTripper
+	secrets     secrets.Service
+	headers     map[string]string
+	bearerToken string
+	jwtSecret   []byte
+}
+
+// RoundTrip sends a request with authentication headers.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := util.SetAuthHeaders(req, t.secrets, t.headers, t.bearerToken, t.jwtSecret); err != nil {
+		return nil, err
+	}
+
+	return t.transport.RoundTrip(req)
+}
@@ -0,0 +1,328 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/proxy"
+	"github.com/wealdtech/chaind/testing/certs"
+)
+
+// newServer creates a server that requires a client certificate signed by the CA.
+func newServer(t *testing.T, testCerts *certs.Certs) *httptest.Server {
+	t.Helper()
+
+	serverPair, err := tls.X509KeyPair(testCerts.ServerCert, testCerts.ServerKey)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(testCerts.CACert))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"path":%q,"client":%q}`, r.URL.RequestURI(), r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params []proxy.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "ClientKeyMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5052"),
+				proxy.WithClientCert([]byte("cert")),
+			},
+			err: "problem with parameters: client certificate and key must be specified together",
+		},
+		{
+			name: "CACertInvalid",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5052"),
+				proxy.WithCACert([]byte("invalid")),
+			},
+			err: "failed to append root CA certificates",
+		},
+		{
+			name: "BearerTokenAndJWTSecret",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5052"),
+				proxy.WithBearerToken("token"),
+				proxy.WithJWTSecret([]byte("secretsecretsecretsecretsecretse")),
+			},
+			err: "problem with parameters: only one of bearer token and JWT secret can be specified",
+		},
+		{
+			name: "Good",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5052"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := proxy.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.True(t, strings.HasPrefix(s.Address(), "http://127.0.0.1:"))
+			}
+		})
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testCerts := certs.Generate(t)
+	server := newServer(t, testCerts)
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name   string
+		params []proxy.Parameter
+		status int
+		body   string
+	}{
+		{
+			name: "ClientCertMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+				proxy.WithCACert(testCerts.CACert),
+			},
+			status: http.StatusBadGateway,
+		},
+		{
+			name: "CACertMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+				proxy.WithClientCert(testCerts.ClientCert),
+				proxy.WithClientKey(testCerts.ClientKey),
+			},
+			status: http.StatusBadGateway,
+		},
+		{
+			name: "Good",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+				proxy.WithClientCert(testCerts.ClientCert),
+				proxy.WithClientKey(testCerts.ClientKey),
+				proxy.WithCACert(testCerts.CACert),
+			},
+			status: http.StatusOK,
+			body:   `{"path":"/eth/v1/node/version?a=b","client":"chaind"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := proxy.New(ctx, test.params...)
+			require.NoError(t, err)
+
+			resp, err := http.Get(fmt.Sprintf("%s/eth/v1/node/version?a=b", s.Address()))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.body != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, test.body, string(body))
+			}
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name   string
+		params []proxy.Parameter
+		status int
+	}{
+		{
+			name: "Unauthenticated",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "BearerTokenOnly",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+				proxy.WithBearerToken("token"),
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "Good",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(address),
+				proxy.WithBearerToken("token"),
+				proxy.WithHeaders(map[string]string{"x-api-key": "key"}),
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := proxy.New(ctx, test.params...)
+			require.NoError(t, err)
+
+			resp, err := http.Get(fmt.Sprintf("%s/eth/v1/node/version", s.Address()))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestConnectivity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Unix domain socket paths are limited in length, so avoid the test's own temporary directory.
+	dir, err := os.MkdirTemp("", "chaind")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "beacon.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	socketServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"via":"socket","path":%q}`, r.URL.RequestURI())
+	}))
+	socketServer.Listener = listener
+	socketServer.Start()
+	defer socketServer.Close()
+
+	// The forward proxy answers requests itself rather than passing them on.
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"via":"proxy","url":%q}`, r.URL.String())
+	}))
+	defer proxyServer.Close()
+
+	tests := []struct {
+		name   string
+		params []proxy.Parameter
+		err    string
+		body   string
+	}{
+		{
+			name: "UnixSocket",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(fmt.Sprintf("unix://%s", socketPath)),
+			},
+			body: `{"via":"socket","path":"/eth/v1/node/version"}`,
+		},
+		{
+			name: "Proxy",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("beacon.example.com:5052"),
+				proxy.WithProxyURL(proxyServer.URL),
+			},
+			body: `{"via":"proxy","url":"http://beacon.example.com:5052/eth/v1/node/version"}`,
+		},
+		{
+			name: "UnixSocketAndProxy",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress(fmt.Sprintf("unix://%s", socketPath)),
+				proxy.WithProxyURL(proxyServer.URL),
+			},
+			err: "cannot connect to a unix domain socket through a proxy",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := proxy.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			resp, err := http.Get(fmt.Sprintf("%s/eth/v1/node/version", s.Address()))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, test.body, string(body))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssz
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	if atomic.LoadInt32(&s.jsonOnly) == 1 {
+		return s.Service.SignedBeaconBlock(ctx, blockID)
+	}
+
+	data, version, err := s.get(ctx, fmt.Sprintf("/eth/v2/beacon/blocks/%s", blockID))
+	if s.useJSON(err) {
+		return s.Service.SignedBeaconBlock(ctx, blockID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request signed beacon block")
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	res := &spec.VersionedSignedBeaconBlock{}
+	switch version {
+	case "phase0":
+		res.Version = spec.DataVersionPhase0
+		res.Phase0 = &phase0.SignedBeaconBlock{}
+		err = res.Phase0.UnmarshalSSZ(data)
+	case "altair":
+		res.Version = spec.DataVersionAltair
+		res.Altair = &altair.SignedBeaconBlock{}
+		err = res.Altair.UnmarshalSSZ(data)
+	case "bellatrix":
+		res.Version = spec.DataVersionBellatrix
+		res.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		err = res.Bellatrix.UnmarshalSSZ(data)
+	default:
+		return nil, fmt.Errorf("unhandled block version %s", version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode signed beacon block")
+	}
+
+	return res, nil
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	if atomic.LoadInt32(&s.jsonOnly) == 1 {
+		return s.Service.BeaconState(ctx, stateID)
+	}
+
+	data, version, err := s.get(ctx, fmt.Sprintf("/eth/v2/debug/beacon/states/%s", stateID))
+	if s.useJSON(err) {
+		return s.Service.BeaconState(ctx, stateID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to request beacon state")
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	res := &spec.VersionedBeaconState{}
+	switch version {
+	case "phase0":
+		res.Version = spec.DataVersionPhase0
+		res.Phase0 = &phase0.BeaconState{}
+		err = res.Phase0.UnmarshalSSZ(data)
+	case "altair":
+		res.Version = spec.DataVersionAltair
+		res.Altair = &altair.BeaconState{}
+		err = res.Altair.UnmarshalSSZ(data)
+	case "bellatrix":
+		res.Version = spec.DataVersionBellatrix
+		res.Bellatrix = &bellatrix.BeaconState{}
+		err = res.Bellatrix.UnmarshalSSZ(data)
+	default:
+		return nil, fmt.Errorf("unhandled state version %s", version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode beacon state")
+	}
+
+	return res, nil
+}
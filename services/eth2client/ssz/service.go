@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssz
+
+import (
+	"context"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an Ethereum 2 client that fetches blocks and states from the beacon
+// node in SSZ, which is considerably cheaper to transfer and decode than JSON.
+// If the beacon node does not serve SSZ then it falls back to JSON.  All other
+// requests are made by the underlying HTTP client.
+type Service struct {
+	log zerolog.Logger
+	*http.Service
+	base   *url.URL
+	client *nethttp.Client
+	// jsonOnly is set to 1 once the beacon node is found not to serve SSZ.
+	jsonOnly int32
+}
+
+// errSSZUnsupported is returned when the beacon node does not serve SSZ for a request.
+var errSSZUnsupported = errors.New("SSZ not supported")
+
+// New creates a new SSZ Ethereum 2 client.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "eth2client", "ssz", parameters.logLevel)
+
+	address := parameters.httpClient.Address()
+	if !strings.HasPrefix(address, "http") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+	base, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 64
+	// The beacon node is reached directly or through the local proxy, never through
+	// proxies from the environment.
+	transport.Proxy = nil
+
+	return &Service{
+		log:     log,
+		Service: parameters.httpClient,
+		base:    base,
+		client: &nethttp.Client{
+			Timeout:   parameters.timeout,
+			Transport: transport,
+		},
+	}, nil
+}
+
+// get fetches an endpoint in SSZ, returning the data and its consensus version.
+// It returns nil data if the item was not found, and errSSZUnsupported if the
+// beacon node responded with something other than SSZ.
+func (s *Service) get(ctx context.Context, endpoint string) ([]byte, string, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, fmt.Sprintf("%s%s", strings.TrimSuffix(s.base.String(), "/"), endpoint), nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to create GET request")
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to call GET endpoint")
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == nethttp.StatusNotFound:
+		// Nothing found.  This is not an error, so we return nil on both counts.
+		return nil, "", nil
+	case resp.StatusCode == nethttp.StatusNotAcceptable || resp.StatusCode == nethttp.StatusUnsupportedMediaType:
+		return nil, "", errSSZUnsupported
+	case resp.StatusCode/100 != 2:
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "failed to read GET response")
+		}
+		return nil, "", fmt.Errorf("GET failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	// Nodes that do not support SSZ may ignore the requested content type.
+	version := strings.ToLower(resp.Header.Get("Eth-Consensus-Version"))
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/octet-stream") || version == "" {
+		return nil, "", errSSZUnsupported
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to read GET response")
+	}
+
+	return data, version, nil
+}
+
+// useJSON returns true if the error from an SSZ request shows that the beacon node
+// does not serve SSZ, in which case all future requests are made in JSON.
+func (s *Service) useJSON(err error) bool {
+	if !errors.Is(err, errSSZUnsupported) {
+		return false
+	}
+	if atomic.CompareAndSwapInt32(&s.jsonOnly, 0, 1) {
+		s.log.Info().Msg("Beacon node does not serve SSZ; fetching blocks and states in JSON")
+	}
+
+	return true
+}
@@ -0,0 +1,155 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssz_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/ssz"
+	"github.com/wealdtech/chaind/testing/simulator"
+)
+
+// newHTTPClient creates an HTTP client for the simulator.  The client's context is
+// never cancelled, as go-eth2-client logs from a background goroutine on cancellation.
+func newHTTPClient(t *testing.T, sim *simulator.Service) *http.Service {
+	t.Helper()
+	client, err := http.New(context.Background(),
+		http.WithLogLevel(zerolog.Disabled),
+		http.WithAddress(sim.Address()),
+		http.WithTimeout(5*time.Second),
+	)
+	require.NoError(t, err)
+
+	return client.(*http.Service)
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx)
+	require.NoError(t, err)
+	httpClient := newHTTPClient(t, sim)
+
+	tests := []struct {
+		name   string
+		params []ssz.Parameter
+		err    string
+	}{
+		{
+			name: "HTTPClientMissing",
+			params: []ssz.Parameter{
+				ssz.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no HTTP client specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []ssz.Parameter{
+				ssz.WithLogLevel(zerolog.Disabled),
+				ssz.WithHTTPClient(httpClient),
+				ssz.WithTimeout(0),
+			},
+			err: "problem with parameters: no timeout specified",
+		},
+		{
+			name: "Good",
+			params: []ssz.Parameter{
+				ssz.WithLogLevel(zerolog.Disabled),
+				ssz.WithHTTPClient(httpClient),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ssz.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSignedBeaconBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name string
+		ssz  bool
+	}{
+		{
+			name: "SSZ",
+			ssz:  true,
+		},
+		{
+			name: "JSONFallback",
+			ssz:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sim, err := simulator.New(ctx,
+				simulator.WithSlotsPerEpoch(4),
+				simulator.WithValidators(16),
+				simulator.WithAltairForkEpoch(1),
+				simulator.WithBellatrixForkEpoch(2),
+				simulator.WithSSZ(test.ssz),
+			)
+			require.NoError(t, err)
+			roots := make(map[phase0.Slot]phase0.Root)
+			for slot := phase0.Slot(1); slot <= 10; slot++ {
+				roots[slot], err = sim.ProposeBlock(slot)
+				require.NoError(t, err)
+			}
+
+			s, err := ssz.New(ctx,
+				ssz.WithLogLevel(zerolog.Disabled),
+				ssz.WithHTTPClient(newHTTPClient(t, sim)),
+			)
+			require.NoError(t, err)
+
+			versions := map[phase0.Slot]spec.DataVersion{
+				3: spec.DataVersionPhase0,
+				4: spec.DataVersionAltair,
+				9: spec.DataVersionBellatrix,
+			}
+			for slot, version := range versions {
+				block, err := s.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+				require.NoError(t, err)
+				require.NotNil(t, block)
+				require.Equal(t, version, block.Version)
+				root, err := block.Root()
+				require.NoError(t, err)
+				require.Equal(t, roots[slot], root)
+			}
+
+			block, err := s.SignedBeaconBlock(ctx, "11")
+			require.NoError(t, err)
+			require.Nil(t, block)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cel
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// IncludeAttestation returns true if the attestation should be stored.
+func (s *Service) IncludeAttestation(_ context.Context, attestation *chaindb.Attestation) bool {
+	if s.attestations == nil {
+		return true
+	}
+
+	return s.include(s.attestations, "attestation", "attestation", map[string]interface{}{
+		"inclusion_slot":      int64(attestation.InclusionSlot),
+		"inclusion_index":     int64(attestation.InclusionIndex),
+		"slot":                int64(attestation.Slot),
+		"committee_index":     int64(attestation.CommitteeIndex),
+		"aggregation_indices": indices(attestation.AggregationIndices),
+		"source_epoch":        int64(attestation.SourceEpoch),
+		"target_epoch":        int64(attestation.TargetEpoch),
+	})
+}
+
+// IncludeValidatorBalance returns true if the validator balance should be stored.
+func (s *Service) IncludeValidatorBalance(_ context.Context, balance *chaindb.ValidatorBalance) bool {
+	if s.validatorBalances == nil {
+		return true
+	}
+
+	return s.include(s.validatorBalances, "validator_balance", "balance", map[string]interface{}{
+		"validator_index":   int64(balance.Index),
+		"epoch":             int64(balance.Epoch),
+		"balance":           int64(balance.Balance),
+		"effective_balance": int64(balance.EffectiveBalance),
+	})
+}
+
+// IncludeValidatorEpochSummary returns true if the validator epoch summary should be stored.
+func (s *Service) IncludeValidatorEpochSummary(_ context.Context, summary *chaindb.ValidatorEpochSummary) bool {
+	if s.validatorEpochSummaries == nil {
+		return true
+	}
+
+	return s.include(s.validatorEpochSummaries, "validator_epoch_summary", "summary", map[string]interface{}{
+		"validator_index":               int64(summary.Index),
+		"epoch":                         int64(summary.Epoch),
+		"proposer_duties":               int64(summary.ProposerDuties),
+		"proposals_included":            int64(summary.ProposalsIncluded),
+		"attestation_included":          summary.AttestationIncluded,
+		"sync_committee_participations": int64(summary.SyncCommitteeParticipations),
+		"sync_committee_misses":         int64(summary.SyncCommitteeMisses),
+	})
+}
+
+// indices converts validator indices to the integers used by expressions.
+func indices(input []phase0.ValidatorIndex) []int64 {
+	res := make([]int64, len(input))
+	for i := range input {
+		res[i] = int64(input[i])
+	}
+
+	return res
+}
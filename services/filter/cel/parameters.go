@@ -0,0 +1,112 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cel
+
+import (
+	"errors"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel                          zerolog.Level
+	network                           string
+	monitor                           metrics.Service
+	validators                        []phase0.ValidatorIndex
+	attestationsExpression            string
+	validatorBalancesExpression       string
+	validatorEpochSummariesExpression string
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithValidators sets the validators available to expressions as 'validators'.
+func WithValidators(validators []phase0.ValidatorIndex) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validators = validators
+	})
+}
+
+// WithAttestationsExpression sets the expression that decides if an attestation is stored.
+func WithAttestationsExpression(expression string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationsExpression = expression
+	})
+}
+
+// WithValidatorBalancesExpression sets the expression that decides if a validator balance is stored.
+func WithValidatorBalancesExpression(expression string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorBalancesExpression = expression
+	})
+}
+
+// WithValidatorEpochSummariesExpression sets the expression that decides if a validator epoch summary is stored.
+func WithValidatorEpochSummariesExpression(expression string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorEpochSummariesExpression = expression
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.attestationsExpression == "" &&
+		parameters.validatorBalancesExpression == "" &&
+		parameters.validatorEpochSummariesExpression == "" {
+		return nil, errors.New("no expressions specified")
+	}
+
+	return &parameters, nil
+}
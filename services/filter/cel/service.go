@@ -0,0 +1,131 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cel
+
+import (
+	"context"
+	"fmt"
+
+	celgo "github.com/google/cel-go/cel"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a filter service that decides which entities are stored by evaluating
+// Common Expression Language (CEL) expressions.  Entities of a type without an
+// expression are always stored.
+type Service struct {
+	log                     zerolog.Logger
+	metrics                 *serviceMetrics
+	validators              []int64
+	attestations            celgo.Program
+	validatorBalances       celgo.Program
+	validatorEpochSummaries celgo.Program
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "filter", "cel", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	env, err := celgo.NewEnv(
+		celgo.Variable("validators", celgo.ListType(celgo.IntType)),
+		celgo.Variable("attestation", celgo.MapType(celgo.StringType, celgo.DynType)),
+		celgo.Variable("balance", celgo.MapType(celgo.StringType, celgo.DynType)),
+		celgo.Variable("summary", celgo.MapType(celgo.StringType, celgo.DynType)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create expression environment")
+	}
+
+	s := &Service{
+		log:        log,
+		metrics:    svcMetrics,
+		validators: make([]int64, len(parameters.validators)),
+	}
+	for i, validator := range parameters.validators {
+		s.validators[i] = int64(validator)
+	}
+
+	if s.attestations, err = compile(env, parameters.attestationsExpression); err != nil {
+		return nil, errors.Wrap(err, "invalid attestations expression")
+	}
+	if s.validatorBalances, err = compile(env, parameters.validatorBalancesExpression); err != nil {
+		return nil, errors.Wrap(err, "invalid validator balances expression")
+	}
+	if s.validatorEpochSummaries, err = compile(env, parameters.validatorEpochSummariesExpression); err != nil {
+		return nil, errors.Wrap(err, "invalid validator epoch summaries expression")
+	}
+
+	return s, nil
+}
+
+// compile compiles an expression, returning nil if the expression is empty.
+func compile(env *celgo.Env, expression string) (celgo.Program, error) {
+	if expression == "" {
+		return nil, nil
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != celgo.BoolType && ast.OutputType() != celgo.DynType {
+		return nil, fmt.Errorf("expression returns %v rather than bool", ast.OutputType())
+	}
+
+	return env.Program(ast)
+}
+
+// include evaluates the program for an entity.  Entities for which evaluation fails are
+// included, so that data is not lost because of a faulty expression.
+func (s *Service) include(program celgo.Program, entity string, variable string, value map[string]interface{}) bool {
+	if program == nil {
+		return true
+	}
+
+	out, _, err := program.Eval(map[string]interface{}{
+		"validators": s.validators,
+		variable:     value,
+	})
+	if err != nil {
+		s.log.Warn().Str("entity", entity).Err(err).Msg("Failed to evaluate expression; including entity")
+		s.monitorEntity(entity, "error")
+		return true
+	}
+	included, isBool := out.Value().(bool)
+	if !isBool {
+		s.log.Warn().Str("entity", entity).Str("result", fmt.Sprintf("%v", out.Value())).Msg("Expression did not return a boolean; including entity")
+		s.monitorEntity(entity, "error")
+		return true
+	}
+
+	if included {
+		s.monitorEntity(entity, "included")
+	} else {
+		s.monitorEntity(entity, "excluded")
+	}
+	return included
+}
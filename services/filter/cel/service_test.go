@@ -0,0 +1,135 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/filter/cel"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []cel.Parameter
+		err    string
+	}{
+		{
+			name: "ExpressionsMissing",
+			err:  "problem with parameters: no expressions specified",
+		},
+		{
+			name: "ExpressionInvalid",
+			params: []cel.Parameter{
+				cel.WithAttestationsExpression("attestation.slot >"),
+			},
+			err: "invalid attestations expression: ERROR: <input>:1:19: Syntax error: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}\n | attestation.slot >\n | ..................^",
+		},
+		{
+			name: "ExpressionNotBool",
+			params: []cel.Parameter{
+				cel.WithValidatorBalancesExpression("1 + 2"),
+			},
+			err: "invalid validator balances expression: expression returns int rather than bool",
+		},
+		{
+			name: "ExpressionUnknownVariable",
+			params: []cel.Parameter{
+				cel.WithValidatorEpochSummariesExpression("unknown.epoch > 2"),
+			},
+			err: "invalid validator epoch summaries expression: ERROR: <input>:1:1: undeclared reference to 'unknown' (in container '')\n | unknown.epoch > 2\n | ^",
+		},
+		{
+			name: "Good",
+			params: []cel.Parameter{
+				cel.WithValidators([]phase0.ValidatorIndex{1, 2}),
+				cel.WithAttestationsExpression("attestation.aggregation_indices.exists(i, i in validators)"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := cel.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIncludeAttestation(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := cel.New(ctx,
+		cel.WithValidators([]phase0.ValidatorIndex{1, 2}),
+		cel.WithAttestationsExpression("attestation.aggregation_indices.exists(i, i in validators)"),
+	)
+	require.NoError(t, err)
+
+	require.True(t, s.IncludeAttestation(ctx, &chaindb.Attestation{AggregationIndices: []phase0.ValidatorIndex{5, 2}}))
+	require.False(t, s.IncludeAttestation(ctx, &chaindb.Attestation{AggregationIndices: []phase0.ValidatorIndex{5, 6}}))
+	require.False(t, s.IncludeAttestation(ctx, &chaindb.Attestation{}))
+
+	// Entities without an expression are always included.
+	require.True(t, s.IncludeValidatorBalance(ctx, &chaindb.ValidatorBalance{Index: 5}))
+	require.True(t, s.IncludeValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{Index: 5}))
+}
+
+func TestIncludeValidatorBalance(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := cel.New(ctx,
+		cel.WithValidators([]phase0.ValidatorIndex{1, 2}),
+		cel.WithValidatorBalancesExpression("balance.validator_index in validators || balance.balance > 32000000000"),
+	)
+	require.NoError(t, err)
+
+	require.True(t, s.IncludeValidatorBalance(ctx, &chaindb.ValidatorBalance{Index: 1, Balance: 1}))
+	require.True(t, s.IncludeValidatorBalance(ctx, &chaindb.ValidatorBalance{Index: 5, Balance: 33000000000}))
+	require.False(t, s.IncludeValidatorBalance(ctx, &chaindb.ValidatorBalance{Index: 5, Balance: 31000000000}))
+}
+
+func TestIncludeValidatorEpochSummary(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := cel.New(ctx,
+		cel.WithValidatorEpochSummariesExpression("!summary.attestation_included || summary.proposer_duties > 0"),
+	)
+	require.NoError(t, err)
+
+	require.True(t, s.IncludeValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{AttestationIncluded: false}))
+	require.True(t, s.IncludeValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{AttestationIncluded: true, ProposerDuties: 1}))
+	require.False(t, s.IncludeValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{AttestationIncluded: true}))
+}
+
+func TestEvaluationFailure(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := cel.New(ctx,
+		cel.WithValidatorBalancesExpression("balance.balance / (balance.epoch - balance.epoch) > 0"),
+	)
+	require.NoError(t, err)
+
+	// Entities for which the expression fails to evaluate are included.
+	require.True(t, s.IncludeValidatorBalance(ctx, &chaindb.ValidatorBalance{Epoch: 1, Balance: 1}))
+}
@@ -0,0 +1,33 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filter provides an interface to decide which entities are stored.
+package filter
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service decides which entities are stored in the database.
+type Service interface {
+	// IncludeAttestation returns true if the attestation should be stored.
+	IncludeAttestation(ctx context.Context, attestation *chaindb.Attestation) bool
+
+	// IncludeValidatorBalance returns true if the validator balance should be stored.
+	IncludeValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) bool
+
+	// IncludeValidatorEpochSummary returns true if the validator epoch summary should be stored.
+	IncludeValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) bool
+}
@@ -0,0 +1,60 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// Pause stops the finalizer from acting on finality checkpoints.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+	s.log.Info().Msg("Paused")
+}
+
+// Resume allows the finalizer to act on finality checkpoints again.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+	s.log.Info().Msg("Resumed")
+}
+
+// Paused returns true if the finalizer is paused.
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
+
+// Trigger runs the finalizer against the current finalized checkpoint,
+// regardless of whether the finalizer is paused.
+func (s *Service) Trigger(ctx context.Context) error {
+	provider, isProvider := s.eth2Client.(eth2client.FinalityProvider)
+	if !isProvider {
+		return errors.New("client does not provide finality")
+	}
+	finality, err := provider.Finality(ctx, "head")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain finality")
+	}
+	if finality.Finalized == nil {
+		return errors.New("no finalized checkpoint")
+	}
+
+	s.log.Info().Uint64("epoch", uint64(finality.Finalized.Epoch)).Msg("Triggered")
+	s.handleFinalityCheckpoint(ctx, finality.Finalized.Epoch, finality.Finalized.Root, phase0.Root{})
+
+	return nil
+}
@@ -17,13 +17,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
 )
 
 // OnFinalityCheckpointReceived receives finality checkpoint notifications.
@@ -33,7 +36,20 @@ func (s *Service) OnFinalityCheckpointReceived(
 	blockRoot phase0.Root,
 	stateRoot phase0.Root,
 ) {
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	if s.paused.Load() {
+		s.log.Trace().Uint64("epoch", uint64(epoch)).Msg("Paused; ignoring finality checkpoint")
+		return
+	}
+	s.handleFinalityCheckpoint(ctx, epoch, blockRoot, stateRoot)
+}
+
+func (s *Service) handleFinalityCheckpoint(ctx context.Context,
+	epoch phase0.Epoch,
+	blockRoot phase0.Root,
+	stateRoot phase0.Root,
+) {
+	started := time.Now()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 	log.Trace().
 		Str("block_root", fmt.Sprintf("%#x", blockRoot)).
 		Str("state_root", fmt.Sprintf("%#x", stateRoot)).
@@ -47,6 +63,16 @@ func (s *Service) OnFinalityCheckpointReceived(
 	}
 	defer s.activitySem.Release(1)
 
+	blockRoot, epoch, err := s.limitFinality(ctx, blockRoot, epoch)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to limit finality to written blocks")
+		return
+	}
+	if epoch == 0 {
+		log.Trace().Msg("No written blocks to finalize")
+		return
+	}
+
 	// Receiving epoch x means that slots up to (x*32) have been finalized
 	// one way or the other (canonical or non-canonical), so attempt to update
 	// all blocks from this slot backwards as either canonical or not.
@@ -79,7 +105,8 @@ func (s *Service) OnFinalityCheckpointReceived(
 
 	}
 
-	monitorEpochProcessed(epoch)
+	s.monitorEpochProcessed(epoch)
+	s.monitorProcessingDuration(time.Since(started))
 	log.Trace().Msg("Finished handling finality checkpoint")
 
 	// Notify that finality has been updated.
@@ -88,6 +115,40 @@ func (s *Service) OnFinalityCheckpointReceived(
 	}
 }
 
+// limitFinality returns the finalized checkpoint to act on, which is the supplied checkpoint unless
+// the blocks service has slots before it that remain to be written, as happens when syncing backward
+// from the head.  In that case the checkpoint finalized as of the lowest such slot is returned, so
+// that the finalizer, and the summarizer that follows it, stay behind the blocks that have been
+// written and pick up the remainder as they arrive.  An epoch of 0 means nothing can be finalized.
+func (s *Service) limitFinality(ctx context.Context,
+	blockRoot phase0.Root,
+	epoch phase0.Epoch,
+) (
+	phase0.Root,
+	phase0.Epoch,
+	error,
+) {
+	provider, isProvider := s.blocks.(blocks.UnwrittenSlotsProvider)
+	if !isProvider {
+		return blockRoot, epoch, nil
+	}
+	slot, exists, err := provider.LowestUnwrittenSlot(ctx)
+	if err != nil {
+		return phase0.Root{}, 0, errors.Wrap(err, "failed to obtain lowest unwritten slot")
+	}
+	if !exists || s.chainTime.FirstSlotOfEpoch(epoch) < slot {
+		return blockRoot, epoch, nil
+	}
+
+	finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return phase0.Root{}, 0, errors.Wrap(err, "failed to obtain finality for lowest unwritten slot")
+	}
+	s.log.Trace().Uint64("unwritten_slot", uint64(slot)).Uint64("finalized_epoch", uint64(finality.Finalized.Epoch)).Msg("Limited finality to written blocks")
+
+	return finality.Finalized.Root, finality.Finalized.Epoch, nil
+}
+
 func (s *Service) buildFinalityStack(ctx context.Context,
 	blockRoot phase0.Root,
 	epoch phase0.Epoch,
@@ -111,6 +172,7 @@ func (s *Service) buildFinalityStack(ctx context.Context,
 	epochStack := []phase0.Epoch{}
 	slot := s.chainTime.FirstSlotOfEpoch(epoch)
 	slotsPerChunk := phase0.Slot(256)
+	windowStartSlot := s.chainTime.FirstSlotOfEpoch(chaintime.WindowStart(s.chainTime, s.window))
 
 	rootStackItem := blockRoot
 	epochStackItem := epoch
@@ -128,17 +190,21 @@ func (s *Service) buildFinalityStack(ctx context.Context,
 			// Reached the location we have already finalized.
 			break
 		}
+		if slot < windowStartSlot {
+			// Reached the start of the rolling window.
+			break
+		}
 
 		finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, fmt.Sprintf("%d", slot))
 		if err != nil {
 			return []phase0.Root{}, []phase0.Epoch{}, errors.Wrap(err, "failed to obtain finality for state")
 		}
-		log.Trace().Uint64("slot", uint64(slot)).Uint64("finalized_epoch", uint64(finality.Finalized.Epoch)).Msg("Obtained finality")
+		s.log.Trace().Uint64("slot", uint64(slot)).Uint64("finalized_epoch", uint64(finality.Finalized.Epoch)).Msg("Obtained finality")
 		rootStackItem = finality.Finalized.Root
 		epochStackItem = finality.Finalized.Epoch
 	}
 
-	if e := log.Trace(); e.Enabled() {
+	if e := s.log.Trace(); e.Enabled() {
 		roots := make([]string, len(rootStack))
 		epochs := make([]uint64, len(epochStack))
 		for i := range rootStack {
@@ -163,7 +229,7 @@ func (s *Service) runFinalityTransaction(
 		return errors.Wrap(err, "Failed to start transaction on finality")
 	}
 
-	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Updating canonical blocks on finality")
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Msg("Updating canonical blocks on finality")
 	if err := s.updateCanonicalBlocks(ctx, root); err != nil {
 		return errors.Wrap(err, "Failed to update canonical blocks on finality")
 	}
@@ -173,7 +239,7 @@ func (s *Service) runFinalityTransaction(
 		// completed, in which case we will receive an error here (because the block is
 		// not marked as finalized).  As such we do not log this error as a problem; the
 		// block and related attestations will be finalized again next time around.
-		log.Debug().Err(err).Msg("Failed to update attestations on finality; will retry next finality update")
+		s.log.Debug().Err(err).Msg("Failed to update attestations on finality; will retry next finality update")
 	}
 
 	if err := s.chainDB.CommitTx(ctx); err != nil {
@@ -199,7 +265,7 @@ func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) e
 	if block == nil {
 		return errors.New("missing canonical block")
 	}
-	log.Trace().Uint64("slot", uint64(block.Slot)).Msg("Canonicalizing up to slot")
+	s.log.Trace().Uint64("slot", uint64(block.Slot)).Msg("Canonicalizing up to slot")
 
 	if err := s.canonicalizeBlocks(ctx, root, md.LatestCanonicalSlot); err != nil {
 		return errors.Wrap(err, "failed to update canonical blocks from canonical root")
@@ -219,7 +285,8 @@ func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) e
 
 // canonicalizeBlocks marks the given block and all its parents as canonical.
 func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limit phase0.Slot) error {
-	log.Trace().Str("root", fmt.Sprintf("%#x", root)).Uint64("limit", uint64(limit)).Msg("Canonicalizing blocks")
+	s.log.Trace().Str("root", fmt.Sprintf("%#x", root)).Uint64("limit", uint64(limit)).Msg("Canonicalizing blocks")
+	windowStartSlot := s.chainTime.FirstSlotOfEpoch(chaintime.WindowStart(s.chainTime, s.window))
 
 	for {
 		block, err := s.fetchBlock(ctx, root)
@@ -228,7 +295,7 @@ func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limi
 		}
 
 		if block == nil {
-			log.Error().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Block not found for root")
+			s.log.Error().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Block not found for root")
 			return errors.New("block not found for root")
 		}
 
@@ -238,18 +305,26 @@ func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limi
 
 		// Update if the current status is either indeterminate or non-canonical.
 		if block.Canonical == nil || !*block.Canonical {
+			if block.Canonical != nil {
+				// Block was previously marked as non-canonical, so this is a reorg repair.
+				s.monitorReorgRepair("canonicalized")
+			}
 			canonical := true
 			block.Canonical = &canonical
 			if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
 				return errors.Wrap(err, "failed to set block to canonical")
 			}
-			log.Trace().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block is canonical")
+			s.log.Trace().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block is canonical")
 		}
 
 		if block.Slot == 0 {
 			// Reached the genesis block; done.
 			break
 		}
+		if block.Slot <= windowStartSlot {
+			// Reached the start of the rolling window; earlier blocks are not kept.
+			break
+		}
 
 		// Loop for parent.
 		root = block.ParentRoot
@@ -267,12 +342,12 @@ func (s *Service) updateIndeterminateBlocks(ctx context.Context, slot phase0.Slo
 	}
 
 	for _, nonCanonicalRoot := range nonCanonicalRoots {
-		log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Msg("Fetching indeterminate block")
+		s.log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Msg("Fetching indeterminate block")
 		nonCanonicalBlock, err := s.blocksProvider.BlockByRoot(ctx, nonCanonicalRoot)
 		if err != nil {
 			return err
 		}
-		log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Uint64("slot", uint64(nonCanonicalBlock.Slot)).Msg("Fetched indeterminate block")
+		s.log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Uint64("slot", uint64(nonCanonicalBlock.Slot)).Msg("Fetched indeterminate block")
 		childrenBlocks, err := s.blocksProvider.BlocksByParentRoot(ctx, nonCanonicalRoot)
 		if err != nil {
 			return err
@@ -287,7 +362,10 @@ func (s *Service) updateIndeterminateBlocks(ctx context.Context, slot phase0.Slo
 		if err := s.blocksSetter.SetBlock(ctx, nonCanonicalBlock); err != nil {
 			return err
 		}
-		log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Uint64("slot", uint64(nonCanonicalBlock.Slot)).Bool("canonical", *nonCanonicalBlock.Canonical).Msg("Marking block")
+		if !canonical {
+			s.monitorReorgRepair("orphaned")
+		}
+		s.log.Trace().Str("root", fmt.Sprintf("%#x", nonCanonicalRoot)).Uint64("slot", uint64(nonCanonicalBlock.Slot)).Bool("canonical", *nonCanonicalBlock.Canonical).Msg("Marking block")
 	}
 
 	return nil
@@ -309,7 +387,7 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 	}
 	if len(latestBlocks) == 0 {
 		// No blocks yet; bow out but no error.
-		log.Trace().Msg("No blocks in database")
+		s.log.Trace().Msg("No blocks in database")
 		return nil
 	}
 	earliestAllowableSlot := s.chainTime.FirstSlotOfEpoch(epoch)
@@ -320,7 +398,7 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 	}
 	if latestBlocks[0].Slot < earliestAllowableSlot {
 		// Bow out, but no error.
-		log.Trace().Msg("Not enough blocks in the database; not updating attestations")
+		s.log.Trace().Msg("Not enough blocks in the database; not updating attestations")
 		return nil
 	}
 
@@ -330,8 +408,12 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 	if firstEpoch != 0 {
 		firstEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); firstEpoch < windowStart {
+		// Attestations before the rolling window are not kept.
+		firstEpoch = windowStart
+	}
 
-	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("latest_epoch", uint64(epoch)).Msg("Epochs over which to update attestations")
+	s.log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("latest_epoch", uint64(epoch)).Msg("Epochs over which to update attestations")
 	for curEpoch := firstEpoch; curEpoch <= epoch; curEpoch++ {
 		if err := s.updateAttestationsForEpoch(ctx, curEpoch); err != nil {
 			return errors.Wrap(err, "failed to update attestations in epoch")
@@ -348,7 +430,7 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 
 // updateAttestationsForEpoch updates the attestations for the given epoch.
 func (s *Service) updateAttestationsForEpoch(ctx context.Context, epoch phase0.Epoch) error {
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 	log.Trace().Msg("Updating attestation finality for epoch")
 
 	// Because the epoch is canonical, all of the slots in the epoch are canonical.
@@ -428,14 +510,14 @@ func (s *Service) updateAttestationTargetCorrect(ctx context.Context, attestatio
 		// Work backwards until we find a canonical block.
 		canonicalBlockFound := false
 		for slot := startSlot; !canonicalBlockFound; slot-- {
-			log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
+			s.log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
 			blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
 			if err != nil {
 				return errors.Wrap(err, "failed to obtain block")
 			}
 			for _, block := range blocks {
 				if block.Canonical != nil && *block.Canonical {
-					log.Trace().Uint64("target_epoch", uint64(attestation.TargetEpoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
+					s.log.Trace().Uint64("target_epoch", uint64(attestation.TargetEpoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
 					canonicalBlockFound = true
 					epochRoots[attestation.TargetEpoch] = block.Root
 					targetCorrect = bytes.Equal(attestation.TargetRoot[:], block.Root[:])
@@ -467,22 +549,22 @@ func (s *Service) updateAttestationHeadCorrect(ctx context.Context,
 	if headRoot, exists := headRoots[attestation.Slot]; exists {
 		headCorrect = bytes.Equal(attestation.BeaconBlockRoot[:], headRoot[:])
 	} else {
-		log.Trace().Uint64("slot", uint64(attestation.Slot)).Msg("Checking attestation head vote")
+		s.log.Trace().Uint64("slot", uint64(attestation.Slot)).Msg("Checking attestation head vote")
 		// Start with slot of the attestation.
 		canonicalBlockFound := false
 		for slot := attestation.Slot; !canonicalBlockFound; slot-- {
-			log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
+			s.log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
 			blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
 			if err != nil {
 				return errors.Wrap(err, "failed to obtain block")
 			}
 			for _, block := range blocks {
 				if block.Canonical != nil && *block.Canonical {
-					log.Trace().Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
+					s.log.Trace().Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
 					canonicalBlockFound = true
 					headRoots[attestation.Slot] = block.Root
 					headCorrect = bytes.Equal(attestation.BeaconBlockRoot[:], block.Root[:])
-					log.Trace().Str("attestation_root", fmt.Sprintf("%#x", attestation.BeaconBlockRoot)).Str("block_root", fmt.Sprintf("%#x", block.Root)).Msg("Found canonical block")
+					s.log.Trace().Str("attestation_root", fmt.Sprintf("%#x", attestation.BeaconBlockRoot)).Str("block_root", fmt.Sprintf("%#x", block.Root)).Msg("Found canonical block")
 					break
 				}
 			}
@@ -510,7 +592,7 @@ func (s *Service) fetchBlock(ctx context.Context, root phase0.Root) (*chaindb.Bl
 			return nil, errors.Wrap(err, "failed to obtain block from provider")
 		}
 		// Not found in the database, try fetching it from the chain.
-		log.Debug().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Failed to obtain block from provider; fetching from chain")
+		s.log.Debug().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Failed to obtain block from provider; fetching from chain")
 		signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%#x", root))
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to obtain block from chain")
@@ -548,13 +630,14 @@ func (s *Service) fetchBlock(ctx context.Context, root phase0.Root) (*chaindb.Bl
 		}
 		if latestBlocks[0].Slot < earliestAllowableSlot {
 			// Bow out, but no error.
-			log.Trace().Msg("Not enough blocks in the database; not finalizing")
+			s.log.Trace().Msg("Not enough blocks in the database; not finalizing")
 			return nil, nil
 		}
 
 		if err := s.blocks.OnBlock(ctx, signedBlock); err != nil {
 			return nil, errors.Wrap(err, "failed to store block")
 		}
+		s.monitorReorgRepair("missing_block")
 
 		// Re-fetch from the database.
 		block, err = s.blocksProvider.BlockByRoot(ctx, root)
@@ -28,6 +28,7 @@ import (
 
 type parameters struct {
 	logLevel         zerolog.Level
+	network          string
 	monitor          metrics.Service
 	eth2Client       eth2client.Service
 	chainDB          chaindb.Service
@@ -35,6 +36,7 @@ type parameters struct {
 	blocks           blocks.Service
 	finalityHandlers []handlers.FinalityHandler
 	activitySem      *semaphore.Weighted
+	window           uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -55,6 +57,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -104,6 +113,13 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithWindow sets the number of recent epochs for which finality updates are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -0,0 +1,158 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ReindexEpoch refetches the blocks for a finalized epoch and replaces the data held for
+// the epoch's slots, along with attestation finality information, in a single transaction.
+// It can be run against a live database, as readers see either the old or new data.
+func (s *Service) ReindexEpoch(ctx context.Context, epoch phase0.Epoch) error {
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	signedBeaconBlockProvider, isProvider := s.eth2Client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return errors.New("client does not provide signed beacon blocks")
+	}
+	slotDataDeleter, isDeleter := s.chainDB.(chaindb.SlotDataDeleter)
+	if !isDeleter {
+		return errors.New("chain DB does not support slot data deletion")
+	}
+	missedSlotsSetter, isSetter := s.chainDB.(chaindb.MissedSlotsSetter)
+	if !isSetter {
+		return errors.New("chain DB does not support missed slot setting")
+	}
+
+	// Avoid the blocks and finalizer modules writing the same data concurrently.
+	if !s.activitySem.TryAcquire(1) {
+		return errors.New("blocks or finalizer activity in progress; try again later")
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	// Attestations included in the epoch are updated as part of the following epoch, so both
+	// must have been finalized.
+	if epoch+1 > md.LastFinalizedEpoch {
+		return fmt.Errorf("epoch %d has not been finalized", epoch)
+	}
+
+	log.Info().Msg("Reindexing epoch")
+
+	// Fetch the blocks prior to starting the transaction, to keep it short.
+	startSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	endSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
+	signedBlocks := make(map[phase0.Slot]*spec.VersionedSignedBeaconBlock)
+	for slot := startSlot; slot < endSlot; slot++ {
+		signedBlock, err := signedBeaconBlockProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to obtain block for slot %d", slot))
+		}
+		signedBlocks[slot] = signedBlock
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := slotDataDeleter.DeleteSlotData(ctx, startSlot, endSlot); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to delete existing data")
+	}
+
+	for slot := startSlot; slot < endSlot; slot++ {
+		signedBlock := signedBlocks[slot]
+		if signedBlock == nil {
+			if err := missedSlotsSetter.SetMissedSlot(ctx, slot); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set missed slot")
+			}
+			continue
+		}
+		if err := s.reindexBlock(ctx, signedBlock); err != nil {
+			cancel()
+			return errors.Wrap(err, fmt.Sprintf("failed to reindex block at slot %d", slot))
+		}
+	}
+
+	if epoch > 0 {
+		if err := s.updateAttestationsForEpoch(ctx, epoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to update attestations for epoch")
+		}
+	}
+	if err := s.updateAttestationsForEpoch(ctx, epoch+1); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to update attestations for following epoch")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	log.Info().Int("blocks", countBlocks(signedBlocks)).Msg("Reindexed epoch")
+
+	return nil
+}
+
+// reindexBlock stores a block from a finalized epoch, marking it as canonical.
+func (s *Service) reindexBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) error {
+	if err := s.blocks.OnBlock(ctx, signedBlock); err != nil {
+		return errors.Wrap(err, "failed to store block")
+	}
+
+	root, err := signedBlock.Root()
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate block root")
+	}
+	block, err := s.blocksProvider.BlockByRoot(ctx, root)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain stored block")
+	}
+	if block == nil {
+		return errors.New("stored block not found")
+	}
+	// Blocks fetched by slot for a finalized epoch are canonical.
+	canonical := true
+	block.Canonical = &canonical
+	if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+		return errors.Wrap(err, "failed to set canonical state of block")
+	}
+
+	return nil
+}
+
+// countBlocks returns the number of slots that have blocks.
+func countBlocks(signedBlocks map[phase0.Slot]*spec.VersionedSignedBeaconBlock) int {
+	count := 0
+	for _, signedBlock := range signedBlocks {
+		if signedBlock != nil {
+			count++
+		}
+	}
+	return count
+}
@@ -20,16 +20,19 @@ import (
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a finalizer service.
 type Service struct {
+	log              zerolog.Logger
+	metrics          *serviceMetrics
 	eth2Client       eth2client.Service
 	chainDB          chaindb.Service
 	blocksProvider   chaindb.BlocksProvider
@@ -38,11 +41,10 @@ type Service struct {
 	blocks           blocks.Service
 	finalityHandlers []handlers.FinalityHandler
 	activitySem      *semaphore.Weighted
+	paused           atomic.Bool
+	window           uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -51,9 +53,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "finalizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "finalizer", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -68,8 +71,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:              log,
+		metrics:          svcMetrics,
 		eth2Client:       parameters.eth2Client,
 		chainDB:          parameters.chainDB,
+		window:           parameters.window,
 		blocksProvider:   blocksProvider,
 		blocksSetter:     blocksSetter,
 		chainTime:        parameters.chainTime,
@@ -96,7 +102,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain metadata")
 	}
-	monitorLatestEpoch(md.LastFinalizedEpoch)
+	s.monitorLatestEpoch(md.LastFinalizedEpoch)
 
 	return s, nil
 }
@@ -0,0 +1,251 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Signals from which clients are inferred, strongest first.
+const (
+	// signalGraffiti is a client named in the block's graffiti.
+	signalGraffiti = "graffiti"
+	// signalProposer is the client last named in the graffiti of the proposer's blocks.
+	signalProposer = "proposer"
+	// signalStructure is the client that most often produces blocks with the same structure.
+	signalStructure = "structure"
+	// signalNone is no signal, in which case the client is unknown.
+	signalNone = "none"
+)
+
+// unknown is the client of blocks that cannot be classified.
+const unknown = "unknown"
+
+// maxAttestations is the maximum number of attestations in a block.
+const maxAttestations = 128
+
+// Confidences for each signal.  Clients inferred from the proposer or from block structure are
+// scaled down from these, as they are only ever as good as the graffiti from which they were learned.
+const (
+	versionConfidence    = 0.95
+	rocketPoolConfidence = 0.9
+	nameConfidence       = 0.8
+	proposerConfidence   = 0.9
+	structureConfidence  = 0.5
+)
+
+// minStructureSamples is the number of blocks with a given structure that must have been classified
+// from their graffiti before the structure is used to classify other blocks.
+const minStructureSamples = 32
+
+// clientNames are the names by which each client appears in graffiti.
+var clientNames = map[string][]string{
+	"lighthouse": {"lighthouse"},
+	"lodestar":   {"lodestar"},
+	"nimbus":     {"nimbus"},
+	"prysm":      {"prysm", "prysmatic"},
+	"teku":       {"teku"},
+}
+
+// versionRegex matches graffiti that starts with a client and its version, as set by default by some clients.
+var versionRegex = regexp.MustCompile(`^(lighthouse|lodestar|nimbus|prysm|teku)/v[0-9]`)
+
+// rocketPoolRegex matches the graffiti set by Rocket Pool, which includes a letter for the client.
+var rocketPoolRegex = regexp.MustCompile(`^rp-([lnpt]) `)
+
+// rocketPoolClients are the clients for each letter in Rocket Pool graffiti.
+var rocketPoolClients = map[string]string{
+	"l": "lighthouse",
+	"n": "nimbus",
+	"p": "prysm",
+	"t": "teku",
+}
+
+// nameRegexes match client names as whole words in graffiti.
+var nameRegexes = func() map[string]*regexp.Regexp {
+	res := make(map[string]*regexp.Regexp, len(clientNames))
+	for client, names := range clientNames {
+		res[client] = regexp.MustCompile(fmt.Sprintf(`(^|[^a-z])(%s)([^a-z]|$)`, strings.Join(names, "|")))
+	}
+	return res
+}()
+
+// classification is the likely client of a block's proposer.
+type classification struct {
+	client     string
+	confidence float64
+	signal     string
+}
+
+// classifier infers the clients of block proposers.  It learns the clients of proposers and
+// the structures of their blocks from blocks whose graffiti names their client, and uses these
+// to classify blocks whose graffiti does not.
+type classifier struct {
+	// proposers are the clients last named in the graffiti of each proposer's blocks.
+	proposers map[phase0.ValidatorIndex]*classification
+	// structures are the number of blocks classified from their graffiti for each structure and client.
+	structures map[string]map[string]uint64
+}
+
+// newClassifier creates a new classifier.
+func newClassifier() *classifier {
+	return &classifier{
+		proposers:  make(map[phase0.ValidatorIndex]*classification),
+		structures: make(map[string]map[string]uint64),
+	}
+}
+
+// classify infers the client of the proposer of a block from the block and the attestations that it includes,
+// learning from the block if its graffiti names its client.  Blocks should be supplied in slot order.
+func (c *classifier) classify(block *chaindb.Block, attestations []*chaindb.Attestation) *classification {
+	blockStructure := structure(attestations)
+
+	if client, confidence := graffitiClient(block.Graffiti); client != "" {
+		res := &classification{
+			client:     client,
+			confidence: confidence,
+			signal:     signalGraffiti,
+		}
+		c.proposers[block.ProposerIndex] = res
+		if _, exists := c.structures[blockStructure]; !exists {
+			c.structures[blockStructure] = make(map[string]uint64)
+		}
+		c.structures[blockStructure][client]++
+		return res
+	}
+
+	if learned, exists := c.proposers[block.ProposerIndex]; exists {
+		return &classification{
+			client:     learned.client,
+			confidence: proposerConfidence * learned.confidence,
+			signal:     signalProposer,
+		}
+	}
+
+	if client, share := c.structureClient(blockStructure); client != "" {
+		return &classification{
+			client:     client,
+			confidence: structureConfidence * share,
+			signal:     signalStructure,
+		}
+	}
+
+	return &classification{
+		client: unknown,
+		signal: signalNone,
+	}
+}
+
+// structureClient returns the client that most often produces blocks with the given structure, along
+// with the share of such blocks that it produces.  It returns no client if the structure has been seen
+// too few times, or if no client produces the majority of blocks with the structure.
+func (c *classifier) structureClient(blockStructure string) (string, float64) {
+	counts, exists := c.structures[blockStructure]
+	if !exists {
+		return "", 0
+	}
+	total := uint64(0)
+	bestClient := ""
+	bestCount := uint64(0)
+	for client, count := range counts {
+		total += count
+		if count > bestCount || (count == bestCount && client < bestClient) {
+			bestClient = client
+			bestCount = count
+		}
+	}
+	if total < minStructureSamples || bestCount*2 <= total {
+		return "", 0
+	}
+
+	return bestClient, float64(bestCount) / float64(total)
+}
+
+// graffitiClient returns the client named in graffiti, along with the confidence in it.
+// It returns no client if the graffiti names no client, or more than one.
+func graffitiClient(graffiti []byte) (string, float64) {
+	text := strings.ToLower(strings.TrimSpace(string(bytes.TrimRight(graffiti, "\x00"))))
+	if text == "" {
+		return "", 0
+	}
+
+	if match := versionRegex.FindStringSubmatch(text); match != nil {
+		return match[1], versionConfidence
+	}
+	if match := rocketPoolRegex.FindStringSubmatch(text); match != nil {
+		return rocketPoolClients[match[1]], rocketPoolConfidence
+	}
+
+	named := ""
+	for client, nameRegex := range nameRegexes {
+		if nameRegex.MatchString(text) {
+			if named != "" {
+				// More than one client named, for example by a multi-client setup.
+				return "", 0
+			}
+			named = client
+		}
+	}
+	if named == "" {
+		return "", 0
+	}
+
+	return named, nameConfidence
+}
+
+// structure describes the structure of a block: the order of its attestations and whether it is
+// full of attestations.  Clients pack blocks in their own ways, so blocks with the same structure
+// are more likely to come from the same client.
+func structure(attestations []*chaindb.Attestation) string {
+	fill := "partial"
+	if len(attestations) >= maxAttestations {
+		fill = "full"
+	}
+
+	return fmt.Sprintf("%s/%s", attestationOrder(attestations), fill)
+}
+
+// attestationOrder returns the order of the slots of attestations in a block: ascending,
+// descending, unordered, or none if there are too few attestations to tell.
+func attestationOrder(attestations []*chaindb.Attestation) string {
+	ascending := true
+	descending := true
+	changes := 0
+	for i := 1; i < len(attestations); i++ {
+		switch {
+		case attestations[i].Slot > attestations[i-1].Slot:
+			descending = false
+			changes++
+		case attestations[i].Slot < attestations[i-1].Slot:
+			ascending = false
+			changes++
+		}
+	}
+	switch {
+	case changes == 0:
+		return "none"
+	case ascending:
+		return "ascending"
+	case descending:
+		return "descending"
+	default:
+		return "unordered"
+	}
+}
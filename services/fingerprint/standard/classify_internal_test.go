@@ -0,0 +1,179 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestGraffitiClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		graffiti   []byte
+		client     string
+		confidence float64
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:     "Padded",
+			graffiti: make([]byte, 32),
+		},
+		{
+			name:       "Version",
+			graffiti:   []byte("Lighthouse/v3.1.0-aa022f4"),
+			client:     "lighthouse",
+			confidence: versionConfidence,
+		},
+		{
+			name:       "RocketPool",
+			graffiti:   []byte("RP-N v1.5.3 (my node)"),
+			client:     "nimbus",
+			confidence: rocketPoolConfidence,
+		},
+		{
+			name:       "Name",
+			graffiti:   []byte("Staked with teku"),
+			client:     "teku",
+			confidence: nameConfidence,
+		},
+		{
+			name:       "NamePadded",
+			graffiti:   append([]byte("prysm"), make([]byte, 27)...),
+			client:     "prysm",
+			confidence: nameConfidence,
+		},
+		{
+			name:     "NamePartOfWord",
+			graffiti: []byte("lighthousekeeper"),
+		},
+		{
+			name:     "Ambiguous",
+			graffiti: []byte("lighthouse+teku"),
+		},
+		{
+			name:     "NoClient",
+			graffiti: []byte("hello world"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, confidence := graffitiClient(test.graffiti)
+			require.Equal(t, test.client, client)
+			require.Equal(t, test.confidence, confidence)
+		})
+	}
+}
+
+func TestStructure(t *testing.T) {
+	slots := func(slots ...phase0.Slot) []*chaindb.Attestation {
+		res := make([]*chaindb.Attestation, len(slots))
+		for i := range slots {
+			res[i] = &chaindb.Attestation{Slot: slots[i]}
+		}
+		return res
+	}
+	full := make([]phase0.Slot, maxAttestations)
+	for i := range full {
+		full[i] = phase0.Slot(i / 4)
+	}
+
+	tests := []struct {
+		name         string
+		attestations []*chaindb.Attestation
+		expected     string
+	}{
+		{
+			name:     "Empty",
+			expected: "none/partial",
+		},
+		{
+			name:         "Ascending",
+			attestations: slots(1, 1, 2, 3),
+			expected:     "ascending/partial",
+		},
+		{
+			name:         "Descending",
+			attestations: slots(3, 2, 2, 1),
+			expected:     "descending/partial",
+		},
+		{
+			name:         "Unordered",
+			attestations: slots(2, 3, 1),
+			expected:     "unordered/partial",
+		},
+		{
+			name:         "Full",
+			attestations: slots(full...),
+			expected:     "ascending/full",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, structure(test.attestations))
+		})
+	}
+}
+
+func TestClassifyProposer(t *testing.T) {
+	c := newClassifier()
+
+	res := c.classify(&chaindb.Block{Slot: 1, ProposerIndex: 5}, nil)
+	require.Equal(t, &classification{client: unknown, signal: signalNone}, res)
+
+	res = c.classify(&chaindb.Block{Slot: 2, ProposerIndex: 5, Graffiti: []byte("teku/v22.10.1")}, nil)
+	require.Equal(t, &classification{client: "teku", confidence: versionConfidence, signal: signalGraffiti}, res)
+
+	res = c.classify(&chaindb.Block{Slot: 3, ProposerIndex: 5}, nil)
+	require.Equal(t, "teku", res.client)
+	require.Equal(t, signalProposer, res.signal)
+	require.InDelta(t, proposerConfidence*versionConfidence, res.confidence, 1e-9)
+
+	// A different proposer is not classified.
+	res = c.classify(&chaindb.Block{Slot: 4, ProposerIndex: 6}, nil)
+	require.Equal(t, signalNone, res.signal)
+}
+
+func TestClassifyStructure(t *testing.T) {
+	c := newClassifier()
+	descending := []*chaindb.Attestation{{Slot: 3}, {Slot: 2}}
+
+	// Learn from blocks with graffiti until just short of the threshold.
+	for i := 0; i < minStructureSamples-1; i++ {
+		c.classify(&chaindb.Block{
+			Slot:          phase0.Slot(i),
+			ProposerIndex: phase0.ValidatorIndex(i),
+			Graffiti:      []byte("nimbus"),
+		}, descending)
+	}
+	res := c.classify(&chaindb.Block{Slot: 100, ProposerIndex: 1000}, descending)
+	require.Equal(t, signalNone, res.signal)
+
+	c.classify(&chaindb.Block{Slot: 101, ProposerIndex: 101, Graffiti: []byte("nimbus")}, descending)
+	res = c.classify(&chaindb.Block{Slot: 102, ProposerIndex: 1000}, descending)
+	require.Equal(t, "nimbus", res.client)
+	require.Equal(t, signalStructure, res.signal)
+	require.InDelta(t, structureConfidence, res.confidence, 1e-9)
+
+	// A different structure is not classified.
+	res = c.classify(&chaindb.Block{Slot: 103, ProposerIndex: 1000}, []*chaindb.Attestation{{Slot: 2}, {Slot: 3}})
+	require.Equal(t, signalNone, res.signal)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_fingerprint"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	latestSlot       prometheus.Gauge
+	blocksClassified *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_slot",
+		Help:      "Latest slot for which blocks have been classified",
+	})
+	if err := registerer.Register(m.latestSlot); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_slot")
+	}
+
+	m.blocksClassified = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "blocks_total",
+		Help:      "Number of blocks classified",
+	}, []string{"signal"})
+	if err := registerer.Register(m.blocksClassified); err != nil {
+		return nil, errors.Wrap(err, "failed to register blocks_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorSlotProcessed(slot phase0.Slot) {
+	if s.metrics.latestSlot != nil {
+		s.metrics.latestSlot.Set(float64(slot))
+	}
+}
+
+func (s *Service) monitorBlockClassified(signal string) {
+	if s.metrics.blocksClassified != nil {
+		s.metrics.blocksClassified.WithLabelValues(signal).Inc()
+	}
+}
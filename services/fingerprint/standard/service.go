@@ -0,0 +1,244 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// chunkSlots is the number of slots processed in each transaction.
+const chunkSlots = phase0.Slot(1024)
+
+// primeSlots is the number of slots prior to the next slot to be processed that are replayed
+// on startup, to rebuild what the classifier has learned about proposers and block structures.
+const primeSlots = phase0.Slot(7200)
+
+// Service is a proposer client fingerprinting service.
+type Service struct {
+	log                  zerolog.Logger
+	metrics              *serviceMetrics
+	chainDB              chaindb.Service
+	blocksProvider       chaindb.BlocksProvider
+	attestationsProvider chaindb.AttestationsProvider
+	blockClientsSetter   chaindb.BlockClientsSetter
+	chainTime            chaintime.Service
+	interval             time.Duration
+	classifier           *classifier
+	primed               bool
+	updateMu             sync.Mutex
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "fingerprint", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:                  log,
+		metrics:              svcMetrics,
+		chainDB:              parameters.chainDB,
+		blocksProvider:       parameters.chainDB.(chaindb.BlocksProvider),
+		attestationsProvider: parameters.chainDB.(chaindb.AttestationsProvider),
+		blockClientsSetter:   parameters.chainDB.(chaindb.BlockClientsSetter),
+		chainTime:            parameters.chainTime,
+		interval:             parameters.interval,
+		classifier:           newClassifier(),
+	}
+
+	// Update immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "fingerprint", "fingerprint block proposers",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic fingerprinting")
+	}
+	go s.update(ctx)
+
+	return s, nil
+}
+
+// update classifies all blocks that have not yet been processed.
+func (s *Service) update(ctx context.Context) {
+	if !s.updateMu.TryLock() {
+		s.log.Debug().Msg("Update already in progress")
+		return
+	}
+	defer s.updateMu.Unlock()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	latestBlocks, err := s.blocksProvider.LatestBlocks(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain latest blocks")
+		return
+	}
+	if len(latestBlocks) == 0 {
+		s.log.Trace().Msg("No blocks; nothing to do")
+		return
+	}
+	latestSlot := latestBlocks[0].Slot
+
+	if !s.primed {
+		if err := s.prime(ctx, md.NextSlot); err != nil {
+			s.log.Error().Err(err).Msg("Failed to prime classifier")
+			return
+		}
+		s.primed = true
+	}
+
+	for startSlot := md.NextSlot; startSlot <= latestSlot; startSlot += chunkSlots {
+		if ctx.Err() != nil {
+			return
+		}
+		endSlot := startSlot + chunkSlots
+		if endSlot > latestSlot+1 {
+			endSlot = latestSlot + 1
+		}
+		blockClients, err := s.classifySlots(ctx, startSlot, endSlot)
+		if err != nil {
+			s.log.Error().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Err(err).Msg("Failed to classify blocks")
+			return
+		}
+		if err := s.store(ctx, md, endSlot, blockClients); err != nil {
+			s.log.Error().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Err(err).Msg("Failed to store block clients")
+			return
+		}
+		for _, blockClient := range blockClients {
+			s.monitorBlockClassified(blockClient.Signal)
+		}
+		s.monitorSlotProcessed(endSlot - 1)
+		s.log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Int("blocks", len(blockClients)).Msg("Classified blocks")
+	}
+}
+
+// prime replays the blocks prior to the given slot through the classifier without storing the results.
+func (s *Service) prime(ctx context.Context, nextSlot phase0.Slot) error {
+	startSlot := phase0.Slot(0)
+	if nextSlot > primeSlots {
+		startSlot = nextSlot - primeSlots
+	}
+	for ; startSlot < nextSlot; startSlot += chunkSlots {
+		endSlot := startSlot + chunkSlots
+		if endSlot > nextSlot {
+			endSlot = nextSlot
+		}
+		if _, err := s.classifySlots(ctx, startSlot, endSlot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// classifySlots classifies the blocks in the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) classifySlots(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.BlockClient, error) {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	if len(blocks) == 0 {
+		return []*chaindb.BlockClient{}, nil
+	}
+
+	attestations, err := s.attestationsProvider.AttestationsInSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	blockAttestations := make(map[phase0.Root][]*chaindb.Attestation, len(blocks))
+	for _, attestation := range attestations {
+		blockAttestations[attestation.InclusionBlockRoot] = append(blockAttestations[attestation.InclusionBlockRoot], attestation)
+	}
+
+	blockClients := make([]*chaindb.BlockClient, 0, len(blocks))
+	for _, block := range blocks {
+		res := s.classifier.classify(block, blockAttestations[block.Root])
+		blockClients = append(blockClients, &chaindb.BlockClient{
+			Slot:          block.Slot,
+			Epoch:         s.chainTime.SlotToEpoch(block.Slot),
+			BlockRoot:     block.Root,
+			ProposerIndex: block.ProposerIndex,
+			Client:        res.client,
+			Confidence:    res.confidence,
+			Signal:        res.signal,
+		})
+	}
+
+	return blockClients, nil
+}
+
+// store stores the block clients, along with the metadata, in a single transaction.
+func (s *Service) store(ctx context.Context,
+	md *metadata,
+	nextSlot phase0.Slot,
+	blockClients []*chaindb.BlockClient,
+) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if len(blockClients) > 0 {
+		if err := s.blockClientsSetter.SetBlockClients(ctx, blockClients); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set block clients")
+		}
+	}
+
+	md.NextSlot = nextSlot
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/fingerprint/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "IntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Second),
+			},
+			err: "problem with parameters: interval must be at least 1 minute",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks := []*chaindb.Block{
+		{
+			Slot:          1,
+			ProposerIndex: 10,
+			Root:          phase0.Root{0x01},
+			Graffiti:      []byte("Lighthouse/v3.1.0"),
+		},
+		{
+			Slot:          2,
+			ProposerIndex: 10,
+			Root:          phase0.Root{0x02},
+		},
+		{
+			Slot:          3,
+			ProposerIndex: 11,
+			Root:          phase0.Root{0x03},
+		},
+	}
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("LatestBlocks", blocks[2:])
+	chainDB.SetResponse("BlocksForSlotRange", blocks)
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithScheduler(scheduler),
+	)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(chainDB.CallsTo("SetMetadata")) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	calls := chainDB.CallsTo("SetBlockClients")
+	require.Len(t, calls, 1)
+	blockClients := calls[0].Args[0].([]*chaindb.BlockClient)
+	require.Len(t, blockClients, 3)
+	require.Equal(t, "lighthouse", blockClients[0].Client)
+	require.Equal(t, "graffiti", blockClients[0].Signal)
+	require.Equal(t, "lighthouse", blockClients[1].Client)
+	require.Equal(t, "proposer", blockClients[1].Signal)
+	require.Equal(t, "unknown", blockClients[2].Client)
+	require.Equal(t, "none", blockClients[2].Signal)
+
+	calls = chainDB.CallsTo("BlocksForSlotRange")
+	require.Equal(t, []interface{}{phase0.Slot(0), phase0.Slot(4)}, calls[0].Args)
+}
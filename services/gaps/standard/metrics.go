@@ -0,0 +1,98 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_gaps"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	unaccountedSlots prometheus.Gauge
+	scans            *prometheus.CounterVec
+	refetches        *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.unaccountedSlots = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "unaccounted_slots",
+		Help:      "Number of slots with neither a block nor a missed slot marker",
+	})
+	if err := registerer.Register(m.unaccountedSlots); err != nil {
+		return nil, errors.Wrap(err, "failed to register unaccounted_slots")
+	}
+
+	m.scans = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "scans_total",
+		Help:      "Number of scans for gaps",
+	}, []string{"result"})
+	if err := registerer.Register(m.scans); err != nil {
+		return nil, errors.Wrap(err, "failed to register scans_total")
+	}
+
+	m.refetches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "refetches_total",
+		Help:      "Number of slots refetched to fill gaps",
+	}, []string{"result"})
+	if err := registerer.Register(m.refetches); err != nil {
+		return nil, errors.Wrap(err, "failed to register refetches_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorUnaccountedSlots(slots int) {
+	if s.metrics.unaccountedSlots != nil {
+		s.metrics.unaccountedSlots.Set(float64(slots))
+	}
+}
+
+func (s *Service) monitorScan(succeeded bool) {
+	if s.metrics.scans != nil {
+		if succeeded {
+			s.metrics.scans.WithLabelValues("succeeded").Inc()
+		} else {
+			s.metrics.scans.WithLabelValues("failed").Inc()
+		}
+	}
+}
+
+func (s *Service) monitorRefetch(result string) {
+	if s.metrics.refetches != nil {
+		s.metrics.refetches.WithLabelValues(result).Inc()
+	}
+}
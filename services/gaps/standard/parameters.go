@@ -0,0 +1,198 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel     zerolog.Level
+	network      string
+	monitor      metrics.Service
+	eth2Client   eth2client.Service
+	chainDB      chaindb.Service
+	blocks       blocks.Service
+	chainTime    chaintime.Service
+	scheduler    scheduler.Service
+	interval     time.Duration
+	startSlot    phase0.Slot
+	refetch      bool
+	refetchLimit int
+	window       uint64
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithBlocks sets the blocks service for this module, used to refetch blocks.
+func WithBlocks(blocks blocks.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blocks = blocks
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithInterval sets the interval between scans for gaps.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithStartSlot sets the slot from which to scan for gaps.
+func WithStartSlot(slot phase0.Slot) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startSlot = slot
+	})
+}
+
+// WithRefetch sets whether slots in gaps are refetched.
+func WithRefetch(refetch bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refetch = refetch
+	})
+}
+
+// WithRefetchLimit sets the maximum number of slots refetched in each scan.
+func WithRefetchLimit(limit int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refetchLimit = limit
+	})
+}
+
+// WithChainTime sets the chain time service for this module, used to find the start of the rolling window.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithWindow sets the number of recent epochs for which gaps are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:     zerolog.GlobalLevel(),
+		interval:     time.Hour,
+		refetchLimit: 256,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.MissedSlotsProvider); !isProvider {
+		return nil, errors.New("chain database does not provide missed slots")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.BlocksProvider); !isProvider {
+		return nil, errors.New("chain database does not provide blocks")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.interval < time.Minute {
+		return nil, errors.New("interval must be at least 1 minute")
+	}
+	if parameters.window > 0 && parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.refetch {
+		if parameters.eth2Client == nil {
+			return nil, errors.New("no Ethereum 2 client specified")
+		}
+		if _, isProvider := parameters.eth2Client.(eth2client.SignedBeaconBlockProvider); !isProvider {
+			return nil, errors.New("Ethereum 2 client does not provide signed beacon blocks")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.MissedSlotsSetter); !isSetter {
+			return nil, errors.New("chain database does not support missed slot setting")
+		}
+		if parameters.blocks == nil {
+			return nil, errors.New("no blocks specified")
+		}
+		if parameters.refetchLimit < 1 {
+			return nil, errors.New("refetch limit must be at least 1")
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,214 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a gap detection service.
+type Service struct {
+	log          zerolog.Logger
+	metrics      *serviceMetrics
+	eth2Client   eth2client.Service
+	chainDB      chaindb.Service
+	blocks       blocks.Service
+	chainTime    chaintime.Service
+	interval     time.Duration
+	startSlot    phase0.Slot
+	refetch      bool
+	refetchLimit int
+	scanMu       sync.Mutex
+	window       uint64
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "gaps", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:          log,
+		metrics:      svcMetrics,
+		eth2Client:   parameters.eth2Client,
+		chainDB:      parameters.chainDB,
+		window:       parameters.window,
+		blocks:       parameters.blocks,
+		chainTime:    parameters.chainTime,
+		interval:     parameters.interval,
+		startSlot:    parameters.startSlot,
+		refetch:      parameters.refetch,
+		refetchLimit: parameters.refetchLimit,
+	}
+
+	// Scan immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.scan(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "gaps", "scan for gaps",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic scan for gaps")
+	}
+	go s.scan(ctx)
+
+	return s, nil
+}
+
+// scan looks for slots between the start slot and the latest block that have neither a
+// block nor a missed slot marker, refetching them if configured.
+func (s *Service) scan(ctx context.Context) {
+	if !s.scanMu.TryLock() {
+		s.log.Debug().Msg("Scan already in progress")
+		return
+	}
+	defer s.scanMu.Unlock()
+
+	started := time.Now()
+	gaps, err := s.findGaps(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to scan for gaps")
+		s.monitorScan(false)
+		return
+	}
+	s.monitorScan(true)
+	s.log.Trace().Int("gaps", len(gaps)).Dur("elapsed", time.Since(started)).Msg("Scanned for gaps")
+
+	if len(gaps) == 0 {
+		s.monitorUnaccountedSlots(0)
+		return
+	}
+	s.log.Warn().
+		Int("slots", len(gaps)).
+		Uint64("first_slot", uint64(gaps[0])).
+		Uint64("last_slot", uint64(gaps[len(gaps)-1])).
+		Msg("Found slots with neither a block nor a missed slot marker")
+
+	if !s.refetch {
+		s.monitorUnaccountedSlots(len(gaps))
+		return
+	}
+
+	remaining := len(gaps)
+	if len(gaps) > s.refetchLimit {
+		gaps = gaps[:s.refetchLimit]
+	}
+	for _, slot := range gaps {
+		if ctx.Err() != nil {
+			break
+		}
+		result, err := s.refetchSlot(ctx, slot)
+		if err != nil {
+			s.log.Warn().Uint64("slot", uint64(slot)).Err(err).Msg("Failed to refetch slot")
+			s.monitorRefetch("failed")
+			continue
+		}
+		s.log.Debug().Uint64("slot", uint64(slot)).Str("result", result).Msg("Refetched slot")
+		s.monitorRefetch(result)
+		remaining--
+	}
+	s.monitorUnaccountedSlots(remaining)
+}
+
+// findGaps returns the slots between the start slot and the latest block that have neither
+// a block nor a missed slot marker.
+func (s *Service) findGaps(ctx context.Context) ([]phase0.Slot, error) {
+	latestBlocks, err := s.chainDB.(chaindb.BlocksProvider).LatestBlocks(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain latest blocks")
+	}
+	startSlot := s.startSlot
+	if s.window > 0 {
+		// Slots before the rolling window are pruned, so are not gaps.
+		if windowStart := s.chainTime.FirstSlotOfEpoch(chaintime.WindowStart(s.chainTime, s.window)); startSlot < windowStart {
+			startSlot = windowStart
+		}
+	}
+	if len(latestBlocks) == 0 || latestBlocks[0].Slot < startSlot {
+		// Nothing to scan.
+		return nil, nil
+	}
+
+	gaps, err := s.chainDB.(chaindb.MissedSlotsProvider).UnaccountedSlots(ctx, startSlot, latestBlocks[0].Slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain unaccounted slots")
+	}
+
+	return gaps, nil
+}
+
+// refetchSlot fetches the block for the slot from the beacon node and stores it, or marks
+// the slot as missed if there is no block.  It returns "block" or "missed" accordingly.
+func (s *Service) refetchSlot(ctx context.Context, slot phase0.Slot) (string, error) {
+	signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain beacon block")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to begin transaction")
+	}
+
+	result := "block"
+	if signedBlock == nil {
+		result = "missed"
+		if err := s.chainDB.(chaindb.MissedSlotsSetter).SetMissedSlot(ctx, slot); err != nil {
+			cancel()
+			return "", errors.Wrap(err, "failed to set missed slot")
+		}
+	} else {
+		if err := s.blocks.OnBlock(ctx, signedBlock); err != nil {
+			cancel()
+			return "", errors.Wrap(err, "failed to store block")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return "", errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,146 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockblocks "github.com/wealdtech/chaind/services/blocks/mock"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/gaps/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eth2Client, err := mock.New(ctx)
+	require.NoError(t, err)
+	chainDB := mockchaindb.New()
+	blocks := mockblocks.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+	// Each good service schedules a job with the same name, so needs its own scheduler.
+	refetchScheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "IntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Second),
+			},
+			err: "problem with parameters: interval must be at least 1 minute",
+		},
+		{
+			name: "RefetchETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithBlocks(blocks),
+				standard.WithRefetch(true),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "RefetchBlocksMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithRefetch(true),
+			},
+			err: "problem with parameters: no blocks specified",
+		},
+		{
+			name: "RefetchLimitZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithBlocks(blocks),
+				standard.WithRefetch(true),
+				standard.WithRefetchLimit(0),
+			},
+			err: "problem with parameters: refetch limit must be at least 1",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Hour),
+			},
+		},
+		{
+			name: "GoodRefetch",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(refetchScheduler),
+				standard.WithBlocks(blocks),
+				standard.WithRefetch(true),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
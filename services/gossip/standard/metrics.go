@@ -0,0 +1,80 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_gossip"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	attestations *prometheus.CounterVec
+	pending      prometheus.Gauge
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.attestations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "attestations_total",
+		Help:      "Number of attestations received from the network",
+	}, []string{"result"})
+	if err := registerer.Register(m.attestations); err != nil {
+		return nil, errors.Wrap(err, "failed to register attestations_total")
+	}
+
+	m.pending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "pending_attestations",
+		Help:      "Number of attestations waiting to be written",
+	})
+	if err := registerer.Register(m.pending); err != nil {
+		return nil, errors.Wrap(err, "failed to register pending_attestations")
+	}
+
+	return m, nil
+}
+
+// monitorAttestations notes attestations received from the network, with a result of
+// "written", "failed", "dropped" or "aggregate".
+func (s *Service) monitorAttestations(result string, count int) {
+	if s.metrics.attestations != nil {
+		s.metrics.attestations.WithLabelValues(result).Add(float64(count))
+	}
+}
+
+func (s *Service) monitorPending(count int) {
+	if s.metrics.pending != nil {
+		s.metrics.pending.Set(float64(count))
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	network       string
+	monitor       metrics.Service
+	eth2Client    eth2client.Service
+	chainDB       chaindb.Service
+	batchSize     int
+	flushInterval time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithBatchSize sets the number of attestations written in each transaction.
+func WithBatchSize(batchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchSize = batchSize
+	})
+}
+
+// WithFlushInterval sets the longest that attestations wait before being written.
+func WithFlushInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.flushInterval = interval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		batchSize:     4096,
+		flushInterval: 2 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.EventsProvider); !isProvider {
+		return nil, errors.New("client does not provide events")
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if _, isSetter := parameters.chainDB.(chaindb.GossipAttestationsSetter); !isSetter {
+		return nil, errors.New("chain database does not support gossip attestation setting")
+	}
+	if parameters.batchSize < 1 {
+		return nil, errors.New("batch size must be at least 1")
+	}
+	if parameters.flushInterval <= 0 {
+		return nil, errors.New("flush interval must be positive")
+	}
+
+	return &parameters, nil
+}
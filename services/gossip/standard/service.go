@@ -0,0 +1,198 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// maxPendingBatches is the number of batches that can be held in memory
+// before further attestations are dropped.
+const maxPendingBatches = 16
+
+// Service captures attestations as they are seen on the network.
+type Service struct {
+	log                      zerolog.Logger
+	metrics                  *serviceMetrics
+	chainDB                  chaindb.Service
+	gossipAttestationsSetter chaindb.GossipAttestationsSetter
+	batchSize                int
+	flushInterval            time.Duration
+	pendingMu                sync.Mutex
+	pending                  []*chaindb.GossipAttestation
+	flushCh                  chan struct{}
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "gossip", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:                      log,
+		metrics:                  svcMetrics,
+		chainDB:                  parameters.chainDB,
+		gossipAttestationsSetter: parameters.chainDB.(chaindb.GossipAttestationsSetter),
+		batchSize:                parameters.batchSize,
+		flushInterval:            parameters.flushInterval,
+		pending:                  make([]*chaindb.GossipAttestation, 0, parameters.batchSize),
+		flushCh:                  make(chan struct{}, 1),
+	}
+
+	if err := parameters.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"attestation"}, func(event *api.Event) {
+		// Note the time before anything else, as it is the time of arrival.
+		arrivalTime := time.Now()
+		if event.Data == nil {
+			// Happens when the channel shuts down, nothing to worry about.
+			return
+		}
+		s.OnAttestation(ctx, event.Data.(*phase0.Attestation), arrivalTime)
+	}); err != nil {
+		return nil, errors.Wrap(err, "failed to add attestation handler")
+	}
+
+	go s.flusher(ctx)
+
+	return s, nil
+}
+
+// OnAttestation handles an attestation seen on the network.
+// Only unaggregated attestations are kept, as aggregates are built from them
+// and would otherwise duplicate the data.
+func (s *Service) OnAttestation(_ context.Context, attestation *phase0.Attestation, arrivalTime time.Time) {
+	if attestation == nil || attestation.Data == nil {
+		return
+	}
+	if attestation.AggregationBits.Count() != 1 {
+		s.monitorAttestations("aggregate", 1)
+		return
+	}
+
+	gossipAttestation := &chaindb.GossipAttestation{
+		Slot:            attestation.Data.Slot,
+		CommitteeIndex:  attestation.Data.Index,
+		AggregationBits: []byte(attestation.AggregationBits),
+		BeaconBlockRoot: attestation.Data.BeaconBlockRoot,
+		ArrivalTime:     arrivalTime,
+	}
+	if attestation.Data.Source != nil {
+		gossipAttestation.SourceEpoch = attestation.Data.Source.Epoch
+		gossipAttestation.SourceRoot = attestation.Data.Source.Root
+	}
+	if attestation.Data.Target != nil {
+		gossipAttestation.TargetEpoch = attestation.Data.Target.Epoch
+		gossipAttestation.TargetRoot = attestation.Data.Target.Root
+	}
+
+	s.pendingMu.Lock()
+	if len(s.pending) >= s.batchSize*maxPendingBatches {
+		s.pendingMu.Unlock()
+		s.log.Trace().Uint64("slot", uint64(gossipAttestation.Slot)).Msg("Too many pending attestations; dropping")
+		s.monitorAttestations("dropped", 1)
+		return
+	}
+	s.pending = append(s.pending, gossipAttestation)
+	pendingCount := len(s.pending)
+	s.pendingMu.Unlock()
+	s.monitorPending(pendingCount)
+
+	if pendingCount >= s.batchSize {
+		// Wake the flusher; if it is already due to run there is nothing more to do.
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flusher writes pending attestations to the database until the context is done.
+func (s *Service) flusher(ctx context.Context) {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Write out whatever remains, ignoring the cancelled context.
+			s.flush(util.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			s.flush(ctx)
+		case <-s.flushCh:
+			s.flush(ctx)
+		}
+	}
+}
+
+// flush writes all pending attestations to the database, a batch at a time.
+func (s *Service) flush(ctx context.Context) {
+	s.pendingMu.Lock()
+	attestations := s.pending
+	s.pending = make([]*chaindb.GossipAttestation, 0, s.batchSize)
+	s.pendingMu.Unlock()
+	s.monitorPending(0)
+
+	for start := 0; start < len(attestations); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(attestations) {
+			end = len(attestations)
+		}
+		batch := attestations[start:end]
+		if err := s.write(ctx, batch); err != nil {
+			s.log.Error().Err(err).Int("attestations", len(batch)).Msg("Failed to write attestations")
+			s.monitorAttestations("failed", len(batch))
+			continue
+		}
+		s.monitorAttestations("written", len(batch))
+	}
+}
+
+// write writes a batch of attestations in a single transaction.
+func (s *Service) write(ctx context.Context, attestations []*chaindb.GossipAttestation) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.gossipAttestationsSetter.SetGossipAttestations(ctx, attestations); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set attestations")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
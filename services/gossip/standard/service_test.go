@@ -0,0 +1,195 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/gossip/standard"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+// eventsClient is a client that only provides events.
+type eventsClient struct {
+	*mock.EventsProvider
+}
+
+func (c *eventsClient) Name() string    { return "mock" }
+func (c *eventsClient) Address() string { return "mock" }
+
+// noEventsClient is a client that does not provide events.
+type noEventsClient struct{}
+
+func (c *noEventsClient) Name() string    { return "mock" }
+func (c *noEventsClient) Address() string { return "mock" }
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	eth2Client := &eventsClient{EventsProvider: mock.NewEventsProvider()}
+	chainDB := mockchaindb.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ETH2ClientNoEvents",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(&noEventsClient{}),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: client does not provide events",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "BatchSizeZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithBatchSize(0),
+			},
+			err: "problem with parameters: batch size must be at least 1",
+		},
+		{
+			name: "FlushIntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithFlushInterval(0),
+			},
+			err: "problem with parameters: flush interval must be positive",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func attestation(slot phase0.Slot, aggregationBits []byte) *phase0.Attestation {
+	return &phase0.Attestation{
+		AggregationBits: aggregationBits,
+		Data: &phase0.AttestationData{
+			Slot:   slot,
+			Source: &phase0.Checkpoint{},
+			Target: &phase0.Checkpoint{Epoch: 1},
+		},
+	}
+}
+
+func TestCapture(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eth2Client := &eventsClient{EventsProvider: mock.NewEventsProvider()}
+	chainDB := mockchaindb.New()
+
+	_, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithETH2Client(eth2Client),
+		standard.WithChainDB(chainDB),
+		standard.WithBatchSize(2),
+		standard.WithFlushInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, eth2Client.Subscriptions("attestation"))
+
+	// Aggregate, ignored.
+	eth2Client.SendEvent(&api.Event{Topic: "attestation", Data: attestation(1, []byte{0x07})})
+	// Unaggregated.
+	eth2Client.SendEvent(&api.Event{Topic: "attestation", Data: attestation(1, []byte{0x05})})
+	// Channel shutdown, ignored.
+	eth2Client.SendEvent(&api.Event{Topic: "attestation"})
+	// Unaggregated; fills the batch.
+	eth2Client.SendEvent(&api.Event{Topic: "attestation", Data: attestation(2, []byte{0x06})})
+
+	require.Eventually(t, func() bool {
+		return len(chainDB.CallsTo("SetGossipAttestations")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	attestations := chainDB.CallsTo("SetGossipAttestations")[0].Args[0].([]*chaindb.GossipAttestation)
+	require.Len(t, attestations, 2)
+	require.Equal(t, phase0.Slot(1), attestations[0].Slot)
+	require.Equal(t, phase0.Epoch(1), attestations[0].TargetEpoch)
+	require.Equal(t, phase0.Slot(2), attestations[1].Slot)
+	require.False(t, attestations[0].ArrivalTime.IsZero())
+	require.Len(t, chainDB.CallsTo("CommitTx"), 1)
+}
+
+func TestFlushOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	eth2Client := &eventsClient{EventsProvider: mock.NewEventsProvider()}
+	chainDB := mockchaindb.New()
+
+	_, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithETH2Client(eth2Client),
+		standard.WithChainDB(chainDB),
+		standard.WithFlushInterval(time.Hour),
+	)
+	require.NoError(t, err)
+
+	eth2Client.SendEvent(&api.Event{Topic: "attestation", Data: attestation(1, []byte{0x05})})
+	require.Empty(t, chainDB.CallsTo("SetGossipAttestations"))
+
+	cancel()
+	require.Eventually(t, func() bool {
+		return len(chainDB.CallsTo("SetGossipAttestations")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
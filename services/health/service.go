@@ -0,0 +1,51 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+)
+
+// Report is a report of the health of chaind.
+type Report struct {
+	// Healthy is true if all components are healthy.
+	Healthy bool `json:"healthy"`
+	// Components contains the health of the individual components.
+	Components []*ComponentReport `json:"components"`
+}
+
+// ComponentReport is a report of the health of a single component.
+type ComponentReport struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Lag is the number of epochs by which the component is behind the chain, if applicable.
+	Lag *uint64 `json:"lag,omitempty"`
+	// Error is the reason for the component being unhealthy, if applicable.
+	Error string `json:"error,omitempty"`
+}
+
+// Service is the interface for a health service.
+type Service interface {
+	// Report reports the current health of chaind.
+	Report(ctx context.Context) *Report
+
+	// Readiness reports if chaind is ready to serve queries.
+	Readiness(ctx context.Context) *Report
+
+	// SetUpgraded notes that the database schema upgrade has completed.
+	SetUpgraded()
+
+	// SetModules sets the modules on which to report.
+	SetModules(modules []string) error
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// moduleProgress describes how to obtain the progress of a module from its metadata.
+type moduleProgress struct {
+	// metadataKey is the key of the module's metadata.
+	metadataKey string
+	// field is the field in the metadata holding the latest slot or epoch processed.
+	field string
+	// slot is true if the field holds a slot rather than an epoch.
+	slot bool
+	// expectedLag is the number of epochs the module is expected to be behind when in sync,
+	// for example because it only processes finalized data.
+	expectedLag phase0.Epoch
+}
+
+// moduleProgresses are the modules for which progress can be obtained, keyed by module name.
+var moduleProgresses = map[string]*moduleProgress{
+	"blocks": {
+		metadataKey: "blocks.standard",
+		field:       "latest_slot",
+		slot:        true,
+	},
+	"beacon-committees": {
+		metadataKey: "beaconcommittees.standard",
+		field:       "latest_epoch",
+	},
+	"proposer-duties": {
+		metadataKey: "proposerduties.standard",
+		field:       "latest_epoch",
+	},
+	"validators": {
+		metadataKey: "validators.standard",
+		field:       "latest_epoch",
+	},
+	"finalizer": {
+		metadataKey: "finalizer.standard",
+		field:       "latest_epoch",
+		expectedLag: 2,
+	},
+	"summarizer": {
+		metadataKey: "summarizer.standard",
+		field:       "latest_epoch",
+		expectedLag: 3,
+	},
+}
+
+// moduleLag returns the number of epochs by which the given module is behind its expected position.
+func (s *Service) moduleLag(ctx context.Context, module string) (phase0.Epoch, error) {
+	progress, exists := moduleProgresses[module]
+	if !exists {
+		return 0, errors.New("unknown module")
+	}
+
+	mdJSON, err := s.chainDB.Metadata(ctx, progress.metadataKey)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fetch metadata")
+	}
+	var latest uint64
+	if mdJSON != nil {
+		md := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(mdJSON, &md); err != nil {
+			return 0, errors.Wrap(err, "failed to unmarshal metadata")
+		}
+		if val, exists := md[progress.field]; exists {
+			if err := json.Unmarshal(val, &latest); err != nil {
+				return 0, errors.Wrap(err, "failed to unmarshal progress")
+			}
+		}
+	}
+
+	latestEpoch := phase0.Epoch(latest)
+	if progress.slot {
+		latestEpoch = s.chainTime.SlotToEpoch(phase0.Slot(latest))
+	}
+	latestEpoch += progress.expectedLag
+
+	currentEpoch := s.chainTime.CurrentEpoch()
+	if latestEpoch >= currentEpoch {
+		return 0, nil
+	}
+	return currentEpoch - latestEpoch, nil
+}
@@ -0,0 +1,159 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	network       string
+	listenAddress string
+	eth2Client    eth2client.Service
+	chainDB       chaindb.Service
+	chainTime     chaintime.Service
+	modules       []string
+	maxLag        phase0.Epoch
+	readyMaxLag   phase0.Epoch
+	timeout       time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithListenAddress sets the listen address for the health endpoints.
+func WithListenAddress(listenAddress string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listenAddress = listenAddress
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithModules sets the modules for which sync lag is reported.
+func WithModules(modules []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.modules = modules
+	})
+}
+
+// WithMaxLag sets the maximum number of epochs a module can be behind before it is considered unhealthy.
+func WithMaxLag(maxLag phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxLag = maxLag
+	})
+}
+
+// WithReadyMaxLag sets the maximum number of epochs a module can be behind before chaind is considered not ready.
+func WithReadyMaxLag(readyMaxLag phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readyMaxLag = readyMaxLag
+	})
+}
+
+// WithTimeout sets the timeout for health checks.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:    zerolog.GlobalLevel(),
+		maxLag:      2,
+		readyMaxLag: 2,
+		timeout:     5 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.NodeSyncingProvider); !isProvider {
+		//nolint:stylecheck
+		return nil, errors.New("Ethereum 2 client does not provide sync state") // skipcq: SCC-ST1005
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	for _, module := range parameters.modules {
+		if _, exists := moduleProgresses[module]; !exists {
+			return nil, fmt.Errorf("unknown module %q", module)
+		}
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("no timeout specified")
+	}
+
+	return &parameters, nil
+}
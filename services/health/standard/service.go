@@ -0,0 +1,283 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/health"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
+)
+
+// Service is a health service.
+type Service struct {
+	log         zerolog.Logger
+	eth2Client  eth2client.Service
+	chainDB     chaindb.Service
+	chainTime   chaintime.Service
+	modulesMu   sync.RWMutex
+	modules     []string
+	maxLag      phase0.Epoch
+	readyMaxLag phase0.Epoch
+	timeout     time.Duration
+	upgraded    atomic.Bool
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "health", "standard", parameters.logLevel)
+
+	s := &Service{
+		log:         log,
+		eth2Client:  parameters.eth2Client,
+		chainDB:     parameters.chainDB,
+		chainTime:   parameters.chainTime,
+		modules:     parameters.modules,
+		maxLag:      parameters.maxLag,
+		readyMaxLag: parameters.readyMaxLag,
+		timeout:     parameters.timeout,
+	}
+
+	if parameters.listenAddress != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", s.handleHealthz)
+		mux.HandleFunc("/live", s.handleLive)
+		mux.HandleFunc("/ready", s.handleReady)
+		server := &http.Server{
+			Addr:              parameters.listenAddress,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Warn().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run health server")
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			if err := server.Close(); err != nil {
+				log.Debug().Err(err).Msg("Failed to close health server")
+			}
+		}()
+	}
+
+	return s, nil
+}
+
+// Report reports the current health of chaind.
+func (s *Service) Report(ctx context.Context) *health.Report {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	modules := s.currentModules()
+	report := &health.Report{
+		Healthy:    true,
+		Components: make([]*health.ComponentReport, 0, 2+len(modules)),
+	}
+	report.Components = append(report.Components, s.beaconNodeReport(ctx))
+	report.Components = append(report.Components, s.databaseReport(ctx))
+	for _, module := range modules {
+		report.Components = append(report.Components, s.moduleReport(ctx, module, s.maxLag))
+	}
+	report.Healthy = componentsHealthy(report.Components)
+
+	return report
+}
+
+// Readiness reports if chaind is ready to serve queries.
+// This requires the database schema upgrade to have completed, and all modules to be within
+// the ready lag of the chain.  The beacon node is not considered, as queries are served from
+// the database.
+func (s *Service) Readiness(ctx context.Context) *health.Report {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	modules := s.currentModules()
+	report := &health.Report{
+		Components: make([]*health.ComponentReport, 0, 2+len(modules)),
+	}
+	report.Components = append(report.Components, s.schemaReport())
+	report.Components = append(report.Components, s.databaseReport(ctx))
+	for _, module := range modules {
+		report.Components = append(report.Components, s.moduleReport(ctx, module, s.readyMaxLag))
+	}
+	report.Healthy = componentsHealthy(report.Components)
+
+	return report
+}
+
+// SetUpgraded notes that the database schema upgrade has completed.
+func (s *Service) SetUpgraded() {
+	s.upgraded.Store(true)
+}
+
+// SetModules sets the modules on which to report.
+func (s *Service) SetModules(modules []string) error {
+	for _, module := range modules {
+		if _, exists := moduleProgresses[module]; !exists {
+			return fmt.Errorf("unknown module %q", module)
+		}
+	}
+
+	s.modulesMu.Lock()
+	s.modules = modules
+	s.modulesMu.Unlock()
+
+	return nil
+}
+
+// currentModules returns the modules on which to report.
+func (s *Service) currentModules() []string {
+	s.modulesMu.RLock()
+	defer s.modulesMu.RUnlock()
+
+	return s.modules
+}
+
+// componentsHealthy returns true if all of the components are healthy.
+func componentsHealthy(components []*health.ComponentReport) bool {
+	for _, component := range components {
+		if !component.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaReport reports on the state of the database schema.
+func (s *Service) schemaReport() *health.ComponentReport {
+	report := &health.ComponentReport{
+		Name: "schema",
+	}
+
+	if !s.upgraded.Load() {
+		report.Error = "database schema upgrade has not completed"
+		return report
+	}
+	report.Healthy = true
+
+	return report
+}
+
+// beaconNodeReport reports on the health of the beacon node.
+func (s *Service) beaconNodeReport(ctx context.Context) *health.ComponentReport {
+	report := &health.ComponentReport{
+		Name: "beacon node",
+	}
+
+	syncState, err := s.eth2Client.(eth2client.NodeSyncingProvider).NodeSyncing(ctx)
+	switch {
+	case err != nil:
+		report.Error = err.Error()
+	case syncState == nil:
+		report.Error = "no sync state returned"
+	case syncState.IsSyncing:
+		report.Error = "beacon node is syncing"
+	default:
+		report.Healthy = true
+	}
+
+	return report
+}
+
+// databaseReport reports on the health of the database.
+func (s *Service) databaseReport(ctx context.Context) *health.ComponentReport {
+	report := &health.ComponentReport{
+		Name: "database",
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	// Nothing has been written, so roll back.
+	cancel()
+	report.Healthy = true
+
+	return report
+}
+
+// moduleReport reports on the health of a module.
+func (s *Service) moduleReport(ctx context.Context, module string, maxLag phase0.Epoch) *health.ComponentReport {
+	report := &health.ComponentReport{
+		Name: module,
+	}
+
+	lag, err := s.moduleLag(ctx, module)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	reportedLag := uint64(lag)
+	report.Lag = &reportedLag
+	if lag > maxLag {
+		report.Error = "module is behind the chain"
+		return report
+	}
+	report.Healthy = true
+
+	return report
+}
+
+// handleHealthz handles requests for the health report.
+func (s *Service) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.writeReport(w, s.Report(r.Context()))
+}
+
+// handleLive handles liveness requests.
+// This only requires the process to be able to respond, so that it is not
+// restarted during long-running operations such as schema upgrades.
+func (s *Service) handleLive(w http.ResponseWriter, _ *http.Request) {
+	s.writeReport(w, &health.Report{
+		Healthy:    true,
+		Components: []*health.ComponentReport{},
+	})
+}
+
+// handleReady handles readiness requests.
+func (s *Service) handleReady(w http.ResponseWriter, r *http.Request) {
+	s.writeReport(w, s.Readiness(r.Context()))
+}
+
+// writeReport writes a report, with a status reflecting its health.
+func (s *Service) writeReport(w http.ResponseWriter, report *health.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		s.log.Debug().Err(err).Msg("Failed to write health report")
+	}
+}
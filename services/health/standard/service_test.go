@@ -0,0 +1,189 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/health/standard"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	eth2Client := mock.NewNodeSyncingProvider(false)
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "ModuleUnknown",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithModules([]string{"unknown"}),
+			},
+			err: `problem with parameters: unknown module "unknown"`,
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithModules([]string{"blocks", "finalizer"}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		syncing bool
+		healthy bool
+	}{
+		{
+			name:    "Healthy",
+			healthy: true,
+		},
+		{
+			name:    "BeaconNodeSyncing",
+			syncing: true,
+			healthy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := standard.New(ctx,
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(mock.NewNodeSyncingProvider(test.syncing)),
+				standard.WithChainDB(mockchaindb.New()),
+				standard.WithChainTime(mockchaintime.New()),
+				standard.WithModules([]string{"blocks"}),
+			)
+			require.NoError(t, err)
+
+			report := s.Report(ctx)
+			require.Equal(t, test.healthy, report.Healthy)
+			require.Len(t, report.Components, 3)
+			require.Equal(t, "blocks", report.Components[2].Name)
+			require.NotNil(t, report.Components[2].Lag)
+			require.Equal(t, uint64(0), *report.Components[2].Lag)
+		})
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithETH2Client(mock.NewNodeSyncingProvider(true)),
+		standard.WithChainDB(mockchaindb.New()),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithModules([]string{"blocks"}),
+	)
+	require.NoError(t, err)
+
+	// Not ready until the upgrade has completed.
+	report := s.Readiness(ctx)
+	require.False(t, report.Healthy)
+	require.Equal(t, "schema", report.Components[0].Name)
+	require.False(t, report.Components[0].Healthy)
+
+	// Ready once upgraded, regardless of the beacon node syncing.
+	s.SetUpgraded()
+	report = s.Readiness(ctx)
+	require.True(t, report.Healthy)
+}
+
+func TestSetModules(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithETH2Client(mock.NewNodeSyncingProvider(false)),
+		standard.WithChainDB(mockchaindb.New()),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithModules([]string{"blocks"}),
+	)
+	require.NoError(t, err)
+	require.Len(t, s.Report(ctx).Components, 3)
+
+	require.EqualError(t, s.SetModules([]string{"unknown"}), `unknown module "unknown"`)
+	require.Len(t, s.Report(ctx).Components, 3)
+
+	require.NoError(t, s.SetModules([]string{"blocks", "validators"}))
+	report := s.Report(ctx)
+	require.Len(t, report.Components, 4)
+	require.Equal(t, "validators", report.Components[3].Name)
+
+	require.NoError(t, s.SetModules(nil))
+	require.Len(t, s.Report(ctx).Components, 2)
+}
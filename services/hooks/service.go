@@ -0,0 +1,44 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"github.com/wealdtech/chaind/handlers"
+)
+
+// Event is a point at which hooks are invoked.
+type Event string
+
+const (
+	// EventPostBlockIndex is invoked when a block has been indexed.
+	EventPostBlockIndex Event = "post-block-index"
+	// EventPostFinalization is invoked when finality has been updated.
+	EventPostFinalization Event = "post-finalization"
+	// EventPostUpgrade is invoked when the database schema has been upgraded.
+	EventPostUpgrade Event = "post-upgrade"
+)
+
+// Events are all of the events at which hooks can be invoked.
+var Events = []Event{
+	EventPostBlockIndex,
+	EventPostFinalization,
+	EventPostUpgrade,
+}
+
+// Service is a hooks service.
+type Service interface {
+	handlers.BlockHandler
+	handlers.FinalityHandler
+	handlers.UpgradeHandler
+}
@@ -0,0 +1,175 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/hooks"
+)
+
+// blockPayload is the payload for the post-block-index event.
+type blockPayload struct {
+	Event         hooks.Event           `json:"event"`
+	Slot          phase0.Slot           `json:"slot"`
+	Root          string                `json:"root"`
+	ParentRoot    string                `json:"parent_root"`
+	ProposerIndex phase0.ValidatorIndex `json:"proposer_index"`
+}
+
+// finalityPayload is the payload for the post-finalization event.
+type finalityPayload struct {
+	Event hooks.Event  `json:"event"`
+	Epoch phase0.Epoch `json:"epoch"`
+}
+
+// upgradePayload is the payload for the post-upgrade event.
+type upgradePayload struct {
+	Event           hooks.Event `json:"event"`
+	FromVersion     uint64      `json:"from_version"`
+	ToVersion       uint64      `json:"to_version"`
+	RequiresRefetch bool        `json:"requires_refetch"`
+}
+
+// OnBlockIndexed is called when a block has been committed to the database.
+func (s *Service) OnBlockIndexed(_ context.Context, block *chaindb.Block) {
+	s.enqueue(hooks.EventPostBlockIndex, &blockPayload{
+		Event:         hooks.EventPostBlockIndex,
+		Slot:          block.Slot,
+		Root:          fmt.Sprintf("%#x", block.Root),
+		ParentRoot:    fmt.Sprintf("%#x", block.ParentRoot),
+		ProposerIndex: block.ProposerIndex,
+	})
+}
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+func (s *Service) OnFinalityUpdated(_ context.Context, epoch phase0.Epoch) {
+	s.enqueue(hooks.EventPostFinalization, &finalityPayload{
+		Event: hooks.EventPostFinalization,
+		Epoch: epoch,
+	})
+}
+
+// OnSchemaUpgraded is called when the database schema has been upgraded.
+func (s *Service) OnSchemaUpgraded(_ context.Context, fromVersion uint64, toVersion uint64, requiresRefetch bool) {
+	s.enqueue(hooks.EventPostUpgrade, &upgradePayload{
+		Event:           hooks.EventPostUpgrade,
+		FromVersion:     fromVersion,
+		ToVersion:       toVersion,
+		RequiresRefetch: requiresRefetch,
+	})
+}
+
+// enqueue queues an event for its hooks to be invoked.
+func (s *Service) enqueue(event hooks.Event, payload interface{}) {
+	if len(s.commands[event]) == 0 && len(s.webhooks[event]) == 0 {
+		// No hooks for this event.
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		s.log.Error().Str("event", string(event)).Err(err).Msg("Failed to marshal payload")
+		return
+	}
+
+	select {
+	case s.queue <- &invocation{event: event, payload: data}:
+	default:
+		s.log.Warn().Str("event", string(event)).Msg("Hook queue full; dropping event")
+		s.monitorEventDropped(event)
+	}
+}
+
+// invoke invokes the hooks for an event.
+func (s *Service) invoke(ctx context.Context, invocation *invocation) {
+	log := s.log.With().Str("event", string(invocation.event)).Logger()
+
+	for _, command := range s.commands[invocation.event] {
+		err := s.runCommand(ctx, invocation.event, command, invocation.payload)
+		s.monitorHookInvoked(invocation.event, "command", err == nil)
+		if err != nil {
+			log.Warn().Str("command", command[0]).Err(err).Msg("Hook command failed")
+		}
+	}
+
+	for _, webhook := range s.webhooks[invocation.event] {
+		err := s.post(ctx, webhook, invocation.payload)
+		s.monitorHookInvoked(invocation.event, "webhook", err == nil)
+		if err != nil {
+			log.Warn().Str("webhook", webhook).Err(err).Msg("Hook webhook failed")
+		}
+	}
+
+	log.Trace().Msg("Invoked hooks")
+}
+
+// runCommand runs a command, supplying the payload on its standard input.
+// The command is run directly rather than through a shell.
+func (s *Service) runCommand(ctx context.Context, event hooks.Event, command []string, payload []byte) error {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// #nosec G204
+	cmd := exec.CommandContext(opCtx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("CHAIND_EVENT=%s", event))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// post posts the payload to a webhook.
+func (s *Service) post(ctx context.Context, url string, payload []byte) error {
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create POST request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to call POST endpoint")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("POST failed with status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("POST failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	return nil
+}
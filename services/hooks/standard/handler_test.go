@@ -0,0 +1,112 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/hooks"
+	"github.com/wealdtech/chaind/services/hooks/standard"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	received := make([]map[string]interface{}, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		payload := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(data, &payload))
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithWebhooks(hooks.EventPostBlockIndex, []string{server.URL}),
+		standard.WithWebhooks(hooks.EventPostUpgrade, []string{server.URL}),
+	)
+	require.NoError(t, err)
+
+	s.OnBlockIndexed(ctx, &chaindb.Block{
+		Slot:          10,
+		ProposerIndex: 5,
+		Root:          phase0.Root{0x01},
+	})
+	// No hooks are configured for finality, so this is ignored.
+	s.OnFinalityUpdated(ctx, 2)
+	s.OnSchemaUpgraded(ctx, 26, 27, false)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// Events are invoked in order.
+	require.Equal(t, "post-block-index", received[0]["event"])
+	require.Equal(t, float64(10), received[0]["slot"])
+	require.Equal(t, float64(5), received[0]["proposer_index"])
+	require.Equal(t, "0x0100000000000000000000000000000000000000000000000000000000000000", received[0]["root"])
+	require.Equal(t, "post-upgrade", received[1]["event"])
+	require.Equal(t, float64(26), received[1]["from_version"])
+	require.Equal(t, float64(27), received[1]["to_version"])
+}
+
+func TestCommands(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := os.Stat("/usr/bin/tee"); err != nil {
+		t.Skip("tee not available")
+	}
+	output := filepath.Join(t.TempDir(), "payload.json")
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithCommands(hooks.EventPostFinalization, []string{"/usr/bin/tee " + output}),
+	)
+	require.NoError(t, err)
+
+	s.OnFinalityUpdated(ctx, 12)
+
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(output)
+		return err == nil && len(data) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"event":"post-finalization","epoch":12}`, string(data))
+}
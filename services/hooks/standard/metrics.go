@@ -0,0 +1,83 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/hooks"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_hooks"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	hooksInvoked  *prometheus.CounterVec
+	eventsDropped *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.hooksInvoked = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "invocations_total",
+		Help:      "Number of hooks invoked",
+	}, []string{"event", "type", "result"})
+	if err := registerer.Register(m.hooksInvoked); err != nil {
+		return nil, errors.Wrap(err, "failed to register invocations_total")
+	}
+
+	m.eventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_dropped_total",
+		Help:      "Number of events dropped because the queue was full",
+	}, []string{"event"})
+	if err := registerer.Register(m.eventsDropped); err != nil {
+		return nil, errors.Wrap(err, "failed to register events_dropped_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorHookInvoked(event hooks.Event, hookType string, succeeded bool) {
+	if s.metrics.hooksInvoked != nil {
+		if succeeded {
+			s.metrics.hooksInvoked.WithLabelValues(string(event), hookType, "succeeded").Inc()
+		} else {
+			s.metrics.hooksInvoked.WithLabelValues(string(event), hookType, "failed").Inc()
+		}
+	}
+}
+
+func (s *Service) monitorEventDropped(event hooks.Event) {
+	if s.metrics.eventsDropped != nil {
+		s.metrics.eventsDropped.WithLabelValues(string(event)).Inc()
+	}
+}
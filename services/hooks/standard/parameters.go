@@ -0,0 +1,154 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/hooks"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel  zerolog.Level
+	network   string
+	monitor   metrics.Service
+	commands  map[hooks.Event][]string
+	webhooks  map[hooks.Event][]string
+	timeout   time.Duration
+	queueSize int
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithCommands sets the commands run for an event.
+func WithCommands(event hooks.Event, commands []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.commands[event] = commands
+	})
+}
+
+// WithWebhooks sets the URLs to which an event is posted.
+func WithWebhooks(event hooks.Event, webhooks []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.webhooks[event] = webhooks
+	})
+}
+
+// WithTimeout sets the timeout for each invocation of a hook.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithQueueSize sets the number of events that can wait for their hooks to be invoked.
+func WithQueueSize(queueSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.queueSize = queueSize
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:  zerolog.GlobalLevel(),
+		commands:  make(map[hooks.Event][]string),
+		webhooks:  make(map[hooks.Event][]string),
+		timeout:   10 * time.Second,
+		queueSize: 1024,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	hooksPresent := false
+	for _, event := range hooks.Events {
+		for _, command := range parameters.commands[event] {
+			if len(strings.Fields(command)) == 0 {
+				return nil, fmt.Errorf("empty command for %s", event)
+			}
+			hooksPresent = true
+		}
+		for _, webhook := range parameters.webhooks[event] {
+			if err := checkURL(webhook); err != nil {
+				return nil, fmt.Errorf("invalid webhook %q for %s: %v", webhook, event, err)
+			}
+			hooksPresent = true
+		}
+	}
+	if !hooksPresent {
+		return nil, errors.New("no hooks specified")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.queueSize <= 0 {
+		return nil, errors.New("queue size must be greater than 0")
+	}
+
+	return &parameters, nil
+}
+
+// checkURL checks that the supplied string is an absolute HTTP or HTTPS URL.
+func checkURL(input string) error {
+	u, err := url.Parse(input)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return errors.New("no host")
+	}
+	return nil
+}
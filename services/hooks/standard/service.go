@@ -0,0 +1,94 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/hooks"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a hooks service.
+// Events are queued, and their hooks invoked in turn in the order that the events
+// were received.  If the queue is full then events are dropped.
+type Service struct {
+	log      zerolog.Logger
+	metrics  *serviceMetrics
+	commands map[hooks.Event][][]string
+	webhooks map[hooks.Event][]string
+	timeout  time.Duration
+	queue    chan *invocation
+	client   *http.Client
+}
+
+// invocation is an event awaiting its hooks.
+type invocation struct {
+	event   hooks.Event
+	payload []byte
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "hooks", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	commands := make(map[hooks.Event][][]string)
+	for event, eventCommands := range parameters.commands {
+		for _, command := range eventCommands {
+			commands[event] = append(commands[event], strings.Fields(command))
+		}
+	}
+
+	s := &Service{
+		log:      log,
+		metrics:  svcMetrics,
+		commands: commands,
+		webhooks: parameters.webhooks,
+		timeout:  parameters.timeout,
+		queue:    make(chan *invocation, parameters.queueSize),
+		client:   &http.Client{},
+	}
+
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// run invokes the hooks for queued events until the context is done.
+func (s *Service) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case invocation := <-s.queue:
+			s.invoke(ctx, invocation)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/hooks"
+	"github.com/wealdtech/chaind/services/hooks/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "HooksMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no hooks specified",
+		},
+		{
+			name: "CommandEmpty",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCommands(hooks.EventPostBlockIndex, []string{" "}),
+			},
+			err: "problem with parameters: empty command for post-block-index",
+		},
+		{
+			name: "WebhookInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithWebhooks(hooks.EventPostFinalization, []string{"localhost:8080/hooks"}),
+			},
+			err: `problem with parameters: invalid webhook "localhost:8080/hooks" for post-finalization: scheme must be http or https`,
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCommands(hooks.EventPostUpgrade, []string{"/bin/true"}),
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "QueueSizeZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCommands(hooks.EventPostUpgrade, []string{"/bin/true"}),
+				standard.WithQueueSize(0),
+			},
+			err: "problem with parameters: queue size must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCommands(hooks.EventPostUpgrade, []string{"/bin/true"}),
+				standard.WithWebhooks(hooks.EventPostBlockIndex, []string{"http://localhost:8080/hooks"}),
+				standard.WithTimeout(time.Second),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package income
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ValidatorIncome holds the income of a validator over a period.
+type ValidatorIncome struct {
+	Index phase0.ValidatorIndex
+	// Income is the income of the validator over the period, in Gwei.
+	Income int64
+	// Epochs is the number of epochs in the period for which income is known.
+	Epochs uint64
+	// APR is the annualized return of the validator over the period, relative to its
+	// effective balance.
+	APR float64
+}
+
+// LabelIncome holds the income of the validators with a label over a period.
+type LabelIncome struct {
+	Label string
+	// Income is the income of the validators over the period, in Gwei.
+	Income int64
+	// ValidatorEpochs is the number of validator epochs in the period for which income is known.
+	ValidatorEpochs uint64
+	// APR is the annualized return of the validators over the period, relative to their
+	// effective balances.
+	APR float64
+}
+
+// AnnualizedReturn holds the annualized return of a set of validators over a window of epochs.
+type AnnualizedReturn struct {
+	// Label is the label of the validators, or empty for all validators.
+	Label string
+	// StartEpoch and EndEpoch are the first and last epochs of the window.
+	StartEpoch phase0.Epoch
+	EndEpoch   phase0.Epoch
+	// Income is the income of the validators over the window, in Gwei.
+	Income int64
+	// ValidatorEpochs is the number of validator epochs in the window for which income is known.
+	ValidatorEpochs uint64
+	// APR is the annualized return of the validators over the window, relative to their
+	// effective balances.
+	APR float64
+}
+
+// Service is an income service.
+type Service interface {
+	// ValidatorIncome provides the income of validators over a range of epochs, keyed by
+	// validator index.  If validators is nil then all validators are returned.
+	// Ranges are inclusive of start and end.
+	ValidatorIncome(ctx context.Context,
+		validators []phase0.ValidatorIndex,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[phase0.ValidatorIndex]*ValidatorIncome,
+		error,
+	)
+
+	// DailyValidatorIncome provides the income of validators over the UTC day containing
+	// the given time, keyed by validator index.  If validators is nil then all validators
+	// are returned.
+	DailyValidatorIncome(ctx context.Context,
+		validators []phase0.ValidatorIndex,
+		day time.Time,
+	) (
+		map[phase0.ValidatorIndex]*ValidatorIncome,
+		error,
+	)
+
+	// LabelIncome provides the income of the validators with each label over a range of
+	// epochs, keyed by label.  If labels is nil then all labels are returned.
+	// Ranges are inclusive of start and end.
+	LabelIncome(ctx context.Context,
+		labels []string,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		map[string]*LabelIncome,
+		error,
+	)
+
+	// DailyLabelIncome provides the income of the validators with each label over the UTC
+	// day containing the given time, keyed by label.  If labels is nil then all labels are
+	// returned.
+	DailyLabelIncome(ctx context.Context,
+		labels []string,
+		day time.Time,
+	) (
+		map[string]*LabelIncome,
+		error,
+	)
+
+	// AnnualizedReturns provides the annualized returns of validators over consecutive windows of
+	// the given number of epochs within a range of epochs, for all validators and for the validators
+	// with each of the given labels.  If labels is nil then only the returns for all validators are provided.
+	// Ranges are inclusive of start and end; the final window is shorter if the range is not a multiple
+	// of the window.  A window of 0 epochs covers the entire range.
+	AnnualizedReturns(ctx context.Context,
+		labels []string,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+		windowEpochs uint64,
+	) (
+		[]*AnnualizedReturn,
+		error,
+	)
+}
@@ -0,0 +1,199 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/income"
+)
+
+// year is the duration of a year for the purposes of annualizing returns.
+const year = 36525 * 24 * time.Hour / 100
+
+// ValidatorIncome provides the income of validators over a range of epochs, keyed by
+// validator index.  If validators is nil then all validators are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) ValidatorIncome(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]*income.ValidatorIncome,
+	error,
+) {
+	if endEpoch < startEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+
+	aggregates, err := s.validatorIncomeProvider.ValidatorIncome(ctx, validators, startEpoch, endEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator income")
+	}
+
+	epochDuration := s.chainTime.StartOfEpoch(1).Sub(s.chainTime.StartOfEpoch(0))
+	res := make(map[phase0.ValidatorIndex]*income.ValidatorIncome, len(aggregates))
+	for index, aggregate := range aggregates {
+		res[index] = &income.ValidatorIncome{
+			Index:  index,
+			Income: aggregate.Income,
+			Epochs: aggregate.Epochs,
+			APR:    apr(aggregate.Income, aggregate.EffectiveBalance, aggregate.Epochs, epochDuration),
+		}
+	}
+
+	return res, nil
+}
+
+// DailyValidatorIncome provides the income of validators over the UTC day containing
+// the given time, keyed by validator index.  If validators is nil then all validators
+// are returned.
+func (s *Service) DailyValidatorIncome(ctx context.Context,
+	validators []phase0.ValidatorIndex,
+	day time.Time,
+) (
+	map[phase0.ValidatorIndex]*income.ValidatorIncome,
+	error,
+) {
+	startEpoch, endEpoch, err := s.dayEpochs(day)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ValidatorIncome(ctx, validators, startEpoch, endEpoch)
+}
+
+// LabelIncome provides the income of the validators with each label over a range of
+// epochs, keyed by label.  If labels is nil then all labels are returned.
+// Ranges are inclusive of start and end.
+func (s *Service) LabelIncome(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	map[string]*income.LabelIncome,
+	error,
+) {
+	if endEpoch < startEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+
+	aggregates, err := s.validatorIncomeProvider.ValidatorIncomeByLabel(ctx, labels, startEpoch, endEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator income by label")
+	}
+
+	epochDuration := s.chainTime.StartOfEpoch(1).Sub(s.chainTime.StartOfEpoch(0))
+	res := make(map[string]*income.LabelIncome, len(aggregates))
+	for label, aggregate := range aggregates {
+		res[label] = &income.LabelIncome{
+			Label:           label,
+			Income:          aggregate.Income,
+			ValidatorEpochs: aggregate.ValidatorEpochs,
+			APR:             apr(aggregate.Income, aggregate.EffectiveBalance, aggregate.ValidatorEpochs, epochDuration),
+		}
+	}
+
+	return res, nil
+}
+
+// DailyLabelIncome provides the income of the validators with each label over the UTC
+// day containing the given time, keyed by label.  If labels is nil then all labels are
+// returned.
+func (s *Service) DailyLabelIncome(ctx context.Context,
+	labels []string,
+	day time.Time,
+) (
+	map[string]*income.LabelIncome,
+	error,
+) {
+	startEpoch, endEpoch, err := s.dayEpochs(day)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.LabelIncome(ctx, labels, startEpoch, endEpoch)
+}
+
+// AnnualizedReturns provides the annualized returns of validators over consecutive windows of
+// the given number of epochs within a range of epochs, for all validators and for the validators
+// with each of the given labels.  If labels is nil then only the returns for all validators are provided.
+// Ranges are inclusive of start and end; the final window is shorter if the range is not a multiple
+// of the window.  A window of 0 epochs covers the entire range.
+func (s *Service) AnnualizedReturns(ctx context.Context,
+	labels []string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	windowEpochs uint64,
+) (
+	[]*income.AnnualizedReturn,
+	error,
+) {
+	if endEpoch < startEpoch {
+		return nil, errors.New("end epoch before start epoch")
+	}
+
+	aggregates, err := s.validatorIncomeProvider.WindowedValidatorIncome(ctx, labels, startEpoch, endEpoch, windowEpochs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain windowed validator income")
+	}
+
+	epochDuration := s.chainTime.StartOfEpoch(1).Sub(s.chainTime.StartOfEpoch(0))
+	res := make([]*income.AnnualizedReturn, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		res = append(res, &income.AnnualizedReturn{
+			Label:           aggregate.Label,
+			StartEpoch:      aggregate.StartEpoch,
+			EndEpoch:        aggregate.EndEpoch,
+			Income:          aggregate.Income,
+			ValidatorEpochs: aggregate.ValidatorEpochs,
+			APR:             apr(aggregate.Income, aggregate.EffectiveBalance, aggregate.ValidatorEpochs, epochDuration),
+		})
+	}
+
+	return res, nil
+}
+
+// dayEpochs provides the first and last epochs of the UTC day containing the given time.
+// Epochs are attributed to the day in which they start.
+func (s *Service) dayEpochs(day time.Time) (phase0.Epoch, phase0.Epoch, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+	if !end.After(s.chainTime.GenesisTime()) {
+		return 0, 0, errors.New("day is before genesis")
+	}
+
+	startEpoch := s.chainTime.TimestampToEpoch(start)
+	if s.chainTime.StartOfEpoch(startEpoch).Before(start) {
+		startEpoch++
+	}
+	endEpoch := s.chainTime.TimestampToEpoch(end.Add(-time.Nanosecond))
+
+	return startEpoch, endEpoch, nil
+}
+
+// apr calculates the annualized return of income over a number of validator epochs,
+// relative to the mean effective balance.
+func apr(income int64, effectiveBalance phase0.Gwei, validatorEpochs uint64, epochDuration time.Duration) float64 {
+	if effectiveBalance == 0 || validatorEpochs == 0 || epochDuration == 0 {
+		return 0
+	}
+
+	periods := float64(year) / (float64(epochDuration) * float64(validatorEpochs))
+
+	return float64(income) / float64(effectiveBalance) * periods
+}
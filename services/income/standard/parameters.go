@@ -0,0 +1,147 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	network       string
+	monitor       metrics.Service
+	chainDB       chaindb.Service
+	chainTime     chaintime.Service
+	scheduler     scheduler.Service
+	validatorKeys validatorkeys.Service
+	interval      time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithValidatorKeys sets the validator keys service for this module.
+func WithValidatorKeys(validatorKeys validatorkeys.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorKeys = validatorKeys
+	})
+}
+
+// WithInterval sets the interval between checks for new validator balances.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		interval: 5 * time.Minute,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider); !isProvider {
+		return nil, errors.New("chain database does not provide validator balances")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.DepositsProvider); !isProvider {
+		return nil, errors.New("chain database does not provide deposits")
+	}
+	if _, isSetter := parameters.chainDB.(chaindb.ValidatorIncomeSetter); !isSetter {
+		return nil, errors.New("chain database does not support validator income setting")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.ValidatorIncomeProvider); !isProvider {
+		return nil, errors.New("chain database does not provide validator income")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.validatorKeys == nil {
+		return nil, errors.New("no validator keys specified")
+	}
+	if parameters.interval < time.Minute {
+		return nil, errors.New("interval must be at least 1 minute")
+	}
+
+	return &parameters, nil
+}
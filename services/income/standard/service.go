@@ -0,0 +1,255 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a validator income service.
+type Service struct {
+	log                     zerolog.Logger
+	metrics                 *serviceMetrics
+	chainDB                 chaindb.Service
+	chainTime               chaintime.Service
+	validatorsProvider      chaindb.ValidatorsProvider
+	depositsProvider        chaindb.DepositsProvider
+	validatorIncomeSetter   chaindb.ValidatorIncomeSetter
+	validatorIncomeProvider chaindb.ValidatorIncomeProvider
+	validatorKeys           validatorkeys.Service
+	interval                time.Duration
+	updateMu                sync.Mutex
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "income", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		log:                     log,
+		metrics:                 svcMetrics,
+		chainDB:                 parameters.chainDB,
+		chainTime:               parameters.chainTime,
+		validatorsProvider:      parameters.chainDB.(chaindb.ValidatorsProvider),
+		depositsProvider:        parameters.chainDB.(chaindb.DepositsProvider),
+		validatorIncomeSetter:   parameters.chainDB.(chaindb.ValidatorIncomeSetter),
+		validatorIncomeProvider: parameters.chainDB.(chaindb.ValidatorIncomeProvider),
+		validatorKeys:           parameters.validatorKeys,
+		interval:                parameters.interval,
+	}
+
+	// Update immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "income", "calculate validator income",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic income calculation")
+	}
+	go s.update(ctx)
+
+	return s, nil
+}
+
+// update calculates income for all epochs with balances that have not yet been processed.
+func (s *Service) update(ctx context.Context) {
+	if !s.updateMu.TryLock() {
+		s.log.Debug().Msg("Update already in progress")
+		return
+	}
+	defer s.updateMu.Unlock()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	// Income for an epoch requires the balances at the start of both it and the following epoch.
+	balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, md.NextEpoch)
+	if err != nil {
+		s.log.Error().Uint64("epoch", uint64(md.NextEpoch)).Err(err).Msg("Failed to obtain validator balances")
+		return
+	}
+	for epoch := md.NextEpoch; len(balances) > 0; epoch++ {
+		if ctx.Err() != nil {
+			return
+		}
+		nextBalances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch+1)
+		if err != nil {
+			s.log.Error().Uint64("epoch", uint64(epoch+1)).Err(err).Msg("Failed to obtain validator balances")
+			return
+		}
+		if len(nextBalances) == 0 {
+			s.log.Trace().Uint64("epoch", uint64(epoch)).Msg("No validator balances for following epoch; nothing more to do")
+			return
+		}
+
+		if err := s.updateEpoch(ctx, md, epoch, balances, nextBalances); err != nil {
+			s.log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to update income")
+			return
+		}
+		s.monitorEpochProcessed(epoch)
+		s.log.Trace().Uint64("epoch", uint64(epoch)).Int("validators", len(balances)).Msg("Updated income")
+
+		balances = nextBalances
+	}
+}
+
+// updateEpoch calculates and stores the income of validators for the given epoch.
+func (s *Service) updateEpoch(ctx context.Context,
+	md *metadata,
+	epoch phase0.Epoch,
+	balances []*chaindb.ValidatorBalance,
+	nextBalances []*chaindb.ValidatorBalance,
+) error {
+	deposits, err := s.depositsForEpoch(ctx, epoch)
+	if err != nil {
+		return err
+	}
+
+	income := calculateIncome(balances, nextBalances, deposits)
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.validatorIncomeSetter.SetValidatorIncome(ctx, income); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator income")
+	}
+
+	md.NextEpoch = epoch + 1
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// depositsForEpoch provides the total amount deposited to each validator between the
+// balances at the start of the given epoch and the balances at the start of the next.
+func (s *Service) depositsForEpoch(ctx context.Context, epoch phase0.Epoch) (map[phase0.ValidatorIndex]phase0.Gwei, error) {
+	// Balances are obtained from the state at the first slot of each epoch, which includes the
+	// block at that slot, so the deposits that affect the change in balance are those in the
+	// blocks after the first slot of this epoch up to and including the first slot of the next.
+	deposits, err := s.depositsProvider.DepositsForSlotRange(ctx,
+		s.chainTime.FirstSlotOfEpoch(epoch)+1,
+		s.chainTime.FirstSlotOfEpoch(epoch+1)+1,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposits")
+	}
+	if len(deposits) == 0 {
+		return map[phase0.ValidatorIndex]phase0.Gwei{}, nil
+	}
+
+	pubKeys := make([]phase0.BLSPubKey, 0, len(deposits))
+	for _, deposit := range deposits {
+		pubKeys = append(pubKeys, deposit.ValidatorPubKey)
+	}
+	indices, err := s.validatorKeys.IndicesByPubKey(ctx, pubKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators for deposits")
+	}
+
+	amounts := make(map[phase0.ValidatorIndex]phase0.Gwei)
+	for _, deposit := range deposits {
+		index, exists := indices[deposit.ValidatorPubKey]
+		if !exists {
+			// Deposit for a validator that is not yet known, so it has no income to adjust.
+			continue
+		}
+		amounts[index] += deposit.Amount
+	}
+
+	return amounts, nil
+}
+
+// calculateIncome calculates the income of each validator from its balances at the start
+// of an epoch and the start of the following epoch, less any deposits made in between.
+func calculateIncome(balances []*chaindb.ValidatorBalance,
+	nextBalances []*chaindb.ValidatorBalance,
+	deposits map[phase0.ValidatorIndex]phase0.Gwei,
+) []*chaindb.ValidatorIncome {
+	nextBalancesByIndex := make(map[phase0.ValidatorIndex]phase0.Gwei, len(nextBalances))
+	for _, balance := range nextBalances {
+		nextBalancesByIndex[balance.Index] = balance.Balance
+	}
+
+	income := make([]*chaindb.ValidatorIncome, 0, len(balances))
+	for _, balance := range balances {
+		nextBalance, exists := nextBalancesByIndex[balance.Index]
+		if !exists {
+			continue
+		}
+		income = append(income, &chaindb.ValidatorIncome{
+			Index:            balance.Index,
+			Epoch:            balance.Epoch,
+			Income:           int64(nextBalance) - int64(balance.Balance) - int64(deposits[balance.Index]),
+			EffectiveBalance: balance.EffectiveBalance,
+		})
+	}
+
+	// Rank by income relative to effective balance, so that validators with
+	// lower effective balances are not penalised.
+	rates := make([]float64, len(income))
+	for i := range income {
+		if income[i].EffectiveBalance > 0 {
+			rates[i] = float64(income[i].Income) / float64(income[i].EffectiveBalance)
+		}
+	}
+	for i, percentile := range util.PercentRanks(rates) {
+		income[i].Percentile = percentile
+	}
+
+	return income
+}
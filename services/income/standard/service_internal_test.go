@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestCalculateIncome(t *testing.T) {
+	balances := []*chaindb.ValidatorBalance{
+		{Index: 1, Epoch: 10, Balance: 32000000000, EffectiveBalance: 32000000000},
+		{Index: 2, Epoch: 10, Balance: 32000000000, EffectiveBalance: 32000000000},
+		{Index: 3, Epoch: 10, Balance: 31000000000, EffectiveBalance: 31000000000},
+		{Index: 4, Epoch: 10, Balance: 32000000000, EffectiveBalance: 32000000000},
+	}
+	nextBalances := []*chaindb.ValidatorBalance{
+		{Index: 1, Epoch: 11, Balance: 32000010000, EffectiveBalance: 32000000000},
+		{Index: 2, Epoch: 11, Balance: 31999990000, EffectiveBalance: 32000000000},
+		{Index: 3, Epoch: 11, Balance: 32000005000, EffectiveBalance: 31000000000},
+		{Index: 5, Epoch: 11, Balance: 32000000000, EffectiveBalance: 32000000000},
+	}
+	deposits := map[phase0.ValidatorIndex]phase0.Gwei{
+		3: 1000000000,
+		5: 32000000000,
+	}
+
+	require.Equal(t, []*chaindb.ValidatorIncome{
+		{Index: 1, Epoch: 10, Income: 10000, EffectiveBalance: 32000000000, Percentile: 1},
+		{Index: 2, Epoch: 10, Income: -10000, EffectiveBalance: 32000000000, Percentile: 0},
+		{Index: 3, Epoch: 10, Income: 5000, EffectiveBalance: 31000000000, Percentile: 0.5},
+	}, calculateIncome(balances, nextBalances, deposits))
+}
+
+func TestAPR(t *testing.T) {
+	epochDuration := 384 * time.Second
+	epochsPerYear := uint64(year / epochDuration)
+
+	tests := []struct {
+		name             string
+		income           int64
+		effectiveBalance phase0.Gwei
+		validatorEpochs  uint64
+		expected         float64
+	}{
+		{
+			name:             "NoEpochs",
+			income:           10000,
+			effectiveBalance: 32000000000,
+			expected:         0,
+		},
+		{
+			name:            "NoEffectiveBalance",
+			income:          10000,
+			validatorEpochs: 1,
+			expected:        0,
+		},
+		{
+			name:             "Year",
+			income:           1600000000,
+			effectiveBalance: 32000000000,
+			validatorEpochs:  epochsPerYear,
+			expected:         0.05,
+		},
+		{
+			name:             "Day",
+			income:           1600000000,
+			effectiveBalance: 32000000000,
+			validatorEpochs:  225,
+			expected:         0.05 * float64(year) / float64(225*epochDuration),
+		},
+		{
+			name:             "MultipleValidators",
+			income:           3200000000,
+			effectiveBalance: 32000000000,
+			validatorEpochs:  2 * epochsPerYear,
+			expected:         0.05,
+		},
+		{
+			name:             "Negative",
+			income:           -1600000000,
+			effectiveBalance: 32000000000,
+			validatorEpochs:  epochsPerYear,
+			expected:         -0.05,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.InDelta(t, test.expected, apr(test.income, test.effectiveBalance, test.validatorEpochs, epochDuration), 1e-4)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_leader"
+
+var isLeader prometheus.Gauge
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if isLeader != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context) error {
+	isLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "is_leader",
+		Help:      "1 if this instance is the leader, otherwise 0",
+	})
+	if err := prometheus.Register(isLeader); err != nil {
+		return errors.Wrap(err, "failed to register is_leader")
+	}
+
+	return nil
+}
+
+func monitorLeader(leader bool) {
+	if isLeader != nil {
+		if leader {
+			isLeader.Set(1)
+		} else {
+			isLeader.Set(0)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	monitor       metrics.Service
+	connectionURL string
+	lockID        int64
+	retryInterval time.Duration
+	checkInterval time.Duration
+	clientCert    []byte
+	clientKey     []byte
+	caCert        []byte
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithConnectionURL sets the connection URL of the database on which the lock is held.
+func WithConnectionURL(connectionURL string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.connectionURL = connectionURL
+	})
+}
+
+// WithLockID sets the ID of the advisory lock held by the leader.
+func WithLockID(lockID int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lockID = lockID
+	})
+}
+
+// WithRetryInterval sets the interval between attempts to become the leader.
+func WithRetryInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retryInterval = interval
+	})
+}
+
+// WithCheckInterval sets the interval between checks that leadership is still held.
+func WithCheckInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkInterval = interval
+	})
+}
+
+// WithClientCert sets the bytes of the client TLS certificate.
+func WithClientCert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientCert = cert
+	})
+}
+
+// WithClientKey sets the bytes of the client TLS key.
+func WithClientKey(key []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientKey = key
+	})
+}
+
+// WithCACert sets the bytes of the certificate authority TLS certificate.
+func WithCACert(cert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = cert
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		retryInterval: 5 * time.Second,
+		checkInterval: 5 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.connectionURL == "" {
+		return nil, errors.New("no connection URL specified")
+	}
+	if (parameters.clientCert == nil) != (parameters.clientKey == nil) {
+		return nil, errors.New("client certificate and key must be specified together")
+	}
+	if parameters.retryInterval <= 0 {
+		return nil, errors.New("retry interval must be positive")
+	}
+	if parameters.checkInterval <= 0 {
+		return nil, errors.New("check interval must be positive")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,207 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service elects a leader by holding a PostgreSQL session-level advisory lock.  The lock is
+// held on a dedicated connection, so is released by the database if the leader fails.
+type Service struct {
+	connConfig    *pgx.ConnConfig
+	lockID        int64
+	retryInterval time.Duration
+	checkInterval time.Duration
+
+	// mu protects conn, which is not safe for concurrent use.
+	mu   sync.Mutex
+	conn *pgx.Conn
+	// stopCheck stops checking that leadership is held; nil if leadership has not been acquired.
+	stopCheck context.CancelFunc
+	lost      chan struct{}
+	lostOnce  sync.Once
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("leader", "postgresql", parameters.logLevel)
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+	monitorLeader(false)
+
+	connConfig, err := pgx.ParseConfig(parameters.connectionURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid connection URL")
+	}
+	if parameters.caCert != nil || parameters.clientCert != nil {
+		tlsConfig, err := util.TLSConfig(connConfig.Host, parameters.clientCert, parameters.clientKey, parameters.caCert)
+		if err != nil {
+			return nil, err
+		}
+		connConfig.TLSConfig = tlsConfig
+		connConfig.Fallbacks = nil
+	}
+
+	return &Service{
+		connConfig:    connConfig,
+		lockID:        parameters.lockID,
+		retryInterval: parameters.retryInterval,
+		checkInterval: parameters.checkInterval,
+		lost:          make(chan struct{}),
+	}, nil
+}
+
+// Acquire blocks until this instance is the leader, or the context is cancelled.
+func (s *Service) Acquire(ctx context.Context) error {
+	log := log.With().Int64("lock_id", s.lockID).Logger()
+	for {
+		acquired, err := s.tryAcquire(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to attempt to acquire leadership; will retry")
+		}
+		if acquired {
+			log.Info().Msg("Acquired leadership")
+			monitorLeader(true)
+			// Leadership is checked until released, regardless of the supplied context.
+			checkCtx, stopCheck := context.WithCancel(util.WithoutCancel(ctx))
+			s.mu.Lock()
+			s.stopCheck = stopCheck
+			s.mu.Unlock()
+			go s.check(checkCtx)
+			return nil
+		}
+		log.Debug().Msg("Another instance is the leader; will retry")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retryInterval):
+		}
+	}
+}
+
+// tryAcquire attempts to acquire the advisory lock, returning true if it was acquired.
+func (s *Service) tryAcquire(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := pgx.ConnectConfig(ctx, s.connConfig)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to connect to database")
+		}
+		s.conn = conn
+	}
+
+	var acquired bool
+	if err := s.conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", s.lockID).Scan(&acquired); err != nil {
+		s.closeConn()
+		return false, errors.Wrap(err, "failed to attempt to obtain advisory lock")
+	}
+
+	return acquired, nil
+}
+
+// check periodically confirms that the connection holding the lock is still alive.
+// If it is not then the database will have released the lock, and leadership is lost.
+func (s *Service) check(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		if s.conn == nil {
+			s.mu.Unlock()
+			return
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, s.checkInterval)
+		err := s.conn.Ping(pingCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Error().Err(err).Msg("Lost connection holding leadership lock; leadership lost")
+			s.closeConn()
+			s.mu.Unlock()
+			monitorLeader(false)
+			s.lostOnce.Do(func() { close(s.lost) })
+			return
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Lost returns a channel that is closed if leadership is lost after it has been acquired.
+func (s *Service) Lost() <-chan struct{} {
+	return s.lost
+}
+
+// Release releases leadership, if held, allowing another instance to take over.
+func (s *Service) Release(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopCheck != nil {
+		s.stopCheck()
+		s.stopCheck = nil
+	}
+	if s.conn == nil {
+		return nil
+	}
+
+	_, err := s.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", s.lockID)
+	// Closing the connection releases the lock regardless.
+	s.closeConn()
+	monitorLeader(false)
+	if err != nil {
+		return errors.Wrap(err, "failed to release advisory lock")
+	}
+	log.Info().Msg("Released leadership")
+
+	return nil
+}
+
+// closeConn closes the connection holding the lock.
+// This assumes that the service's lock is held.
+func (s *Service) closeConn() {
+	// Use a fresh context, as the connection should be closed even if the caller's context has been cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.conn.Close(ctx); err != nil {
+		log.Debug().Err(err).Msg("Failed to close connection")
+	}
+	s.conn = nil
+}
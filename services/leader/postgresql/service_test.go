@@ -0,0 +1,140 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/leader/postgresql"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []postgresql.Parameter
+		err    string
+	}{
+		{
+			name: "ConnectionURLMissing",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no connection URL specified",
+		},
+		{
+			name: "RetryIntervalZero",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost/chain"),
+				postgresql.WithRetryInterval(0),
+			},
+			err: "problem with parameters: retry interval must be positive",
+		},
+		{
+			name: "CheckIntervalZero",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost/chain"),
+				postgresql.WithCheckInterval(0),
+			},
+			err: "problem with parameters: check interval must be positive",
+		},
+		{
+			name: "ConnectionURLInvalid",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost:port/chain"),
+			},
+			err: "invalid connection URL: cannot parse `postgres://localhost:port/chain`: failed to parse as URL (parse \"postgres://localhost:port/chain\": invalid port \":port\" after host)",
+		},
+		{
+			name: "ClientKeyMissing",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost/chain"),
+				postgresql.WithClientCert([]byte("cert")),
+			},
+			err: "problem with parameters: client certificate and key must be specified together",
+		},
+		{
+			name: "ClientCertInvalid",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost/chain"),
+				postgresql.WithClientCert([]byte("cert")),
+				postgresql.WithClientKey([]byte("key")),
+			},
+			err: "failed to create client certificate: tls: failed to find any PEM data in certificate input",
+		},
+		{
+			name: "Good",
+			params: []postgresql.Parameter{
+				postgresql.WithLogLevel(zerolog.Disabled),
+				postgresql.WithConnectionURL("postgres://localhost/chain"),
+				postgresql.WithLockID(1),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := postgresql.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestElection(t *testing.T) {
+	if os.Getenv("CHAINDB_URL") == "" {
+		t.Skip("CHAINDB_URL not set")
+	}
+	ctx := context.Background()
+
+	params := []postgresql.Parameter{
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+		postgresql.WithLockID(0x636861696e64),
+		postgresql.WithRetryInterval(100 * time.Millisecond),
+	}
+	first, err := postgresql.New(ctx, params...)
+	require.NoError(t, err)
+	second, err := postgresql.New(ctx, params...)
+	require.NoError(t, err)
+
+	require.NoError(t, first.Acquire(ctx))
+	defer func() {
+		require.NoError(t, first.Release(ctx))
+	}()
+
+	// The second instance cannot become the leader whilst the first holds the lock.
+	waitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, second.Acquire(waitCtx), context.DeadlineExceeded)
+
+	// Once the first instance releases the lock the second can take over.
+	require.NoError(t, first.Release(ctx))
+	require.NoError(t, second.Acquire(ctx))
+	require.NoError(t, second.Release(ctx))
+}
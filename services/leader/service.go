@@ -0,0 +1,31 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+)
+
+// Service is the interface for leader election, which ensures that only one of a number of
+// instances sharing a database writes to it at a time.
+type Service interface {
+	// Acquire blocks until this instance is the leader, or the context is cancelled.
+	Acquire(ctx context.Context) error
+
+	// Lost returns a channel that is closed if leadership is lost after it has been acquired.
+	Lost() <-chan struct{}
+
+	// Release releases leadership, if held, allowing another instance to take over.
+	Release(ctx context.Context) error
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a metrics service exposing metrics via prometheus.
@@ -37,10 +37,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "metrics").Str("impl", "prometheus").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log = util.ServiceLogger("metrics", "prometheus", parameters.logLevel)
 
 	s := &Service{}
 
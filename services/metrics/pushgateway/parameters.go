@@ -0,0 +1,102 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushgateway
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel zerolog.Level
+	address  string
+	job      string
+	grouping map[string]string
+	gatherer prometheus.Gatherer
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithAddress sets the URL of the pushgateway.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithJob sets the job under which metrics are pushed.
+func WithJob(job string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.job = job
+	})
+}
+
+// WithGrouping adds a label to the grouping key under which metrics are pushed.
+func WithGrouping(name string, value string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.grouping[name] = value
+	})
+}
+
+// WithGatherer sets the gatherer from which metrics are obtained.
+func WithGatherer(gatherer prometheus.Gatherer) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.gatherer = gatherer
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		job:      "chaind",
+		grouping: make(map[string]string),
+		gatherer: prometheus.DefaultGatherer,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.job == "" {
+		return nil, errors.New("no job specified")
+	}
+	if parameters.gatherer == nil {
+		return nil, errors.New("no gatherer specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushgateway
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a metrics service pushing metrics to a prometheus pushgateway.
+// It is intended for short-lived commands, which push their metrics once on
+// completion rather than being scraped.
+type Service struct {
+	pusher *push.Pusher
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new pushgateway metrics service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("metrics", "pushgateway", parameters.logLevel)
+
+	pusher := push.New(parameters.address, parameters.job).Gatherer(parameters.gatherer)
+	// Sort the grouping labels so that the URL is stable.
+	names := make([]string, 0, len(parameters.grouping))
+	for name := range parameters.grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pusher = pusher.Grouping(name, parameters.grouping[name])
+	}
+
+	return &Service{
+		pusher: pusher,
+	}, nil
+}
+
+// Presenter returns the presenter for the events.
+func (s *Service) Presenter() string {
+	return "pushgateway"
+}
+
+// Push pushes the current metrics to the pushgateway, replacing any previously
+// pushed with the same job and grouping.
+func (s *Service) Push(ctx context.Context) error {
+	if err := s.pusher.PushContext(ctx); err != nil {
+		return errors.Wrap(err, "failed to push metrics")
+	}
+	log.Trace().Msg("Pushed metrics")
+
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushgateway_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/metrics/pushgateway"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []pushgateway.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			err:  "problem with parameters: no address specified",
+		},
+		{
+			name: "JobMissing",
+			params: []pushgateway.Parameter{
+				pushgateway.WithAddress("http://localhost:9091"),
+				pushgateway.WithJob(""),
+			},
+			err: "problem with parameters: no job specified",
+		},
+		{
+			name: "Good",
+			params: []pushgateway.Parameter{
+				pushgateway.WithAddress("http://localhost:9091"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := pushgateway.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "pushgateway", s.Presenter())
+			}
+		})
+	}
+}
+
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+
+	var method, path string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		var err error
+		body, err = io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+	require.NoError(t, registry.Register(gauge))
+	gauge.Set(5)
+
+	s, err := pushgateway.New(ctx,
+		pushgateway.WithAddress(server.URL),
+		pushgateway.WithGrouping("command", "verify"),
+		pushgateway.WithGatherer(registry),
+	)
+	require.NoError(t, err)
+
+	var pusher metrics.Pusher = s
+	require.NoError(t, pusher.Push(ctx))
+	require.Equal(t, http.MethodPut, method)
+	require.Equal(t, "/metrics/job/chaind/command/verify", path)
+	require.NotEmpty(t, body)
+}
+
+func TestPushFailure(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	s, err := pushgateway.New(ctx,
+		pushgateway.WithAddress(server.URL),
+		pushgateway.WithGatherer(prometheus.NewRegistry()),
+	)
+	require.NoError(t, err)
+
+	require.ErrorContains(t, s.Push(ctx), "failed to push metrics")
+}
@@ -0,0 +1,62 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// registerer registers metrics with the default registry, replacing any that are already
+// registered.
+type registerer struct {
+	prometheus.Registerer
+}
+
+// NewRegisterer returns the registerer for the metrics of a module running for the given
+// network.  If the network has a name then the module's metrics are labelled with it.
+// Registering a metric that is already registered replaces the existing metric, so that a
+// module that is restarted presents the metrics of its new instance.
+func NewRegisterer(network string) prometheus.Registerer {
+	if network == "" {
+		return &registerer{Registerer: prometheus.DefaultRegisterer}
+	}
+
+	return &registerer{
+		Registerer: prometheus.WrapRegistererWith(prometheus.Labels{"network": network}, prometheus.DefaultRegisterer),
+	}
+}
+
+// Register registers a collector, replacing any existing collector with the same metrics.
+func (r *registerer) Register(collector prometheus.Collector) error {
+	err := r.Registerer.Register(collector)
+	var alreadyRegisteredErr prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegisteredErr) {
+		r.Registerer.Unregister(collector)
+		err = r.Registerer.Register(collector)
+	}
+
+	return err
+}
+
+// MustRegister registers collectors, replacing any existing collectors with the same
+// metrics, and panics on failure.
+func (r *registerer) MustRegister(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if err := r.Register(collector); err != nil {
+			panic(err)
+		}
+	}
+}
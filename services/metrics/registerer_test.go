@@ -0,0 +1,67 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+// gaugeValues returns the values of the test gauge, keyed by network.
+func gaugeValues(t *testing.T) map[string]float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	res := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "chaind_test_registerer" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			network := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "network" {
+					network = label.GetValue()
+				}
+			}
+			res[network] = metric.GetGauge().GetValue()
+		}
+	}
+
+	return res
+}
+
+func TestRegisterer(t *testing.T) {
+	newGauge := func(value float64) prometheus.Gauge {
+		gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "chaind_test_registerer",
+			Help: "Test gauge",
+		})
+		gauge.Set(value)
+
+		return gauge
+	}
+
+	require.NoError(t, metrics.NewRegisterer("mainnet").Register(newGauge(1)))
+	require.NoError(t, metrics.NewRegisterer("goerli").Register(newGauge(2)))
+	require.Equal(t, map[string]float64{"mainnet": 1, "goerli": 2}, gaugeValues(t))
+
+	// Registering again for a network replaces its metric.
+	require.NoError(t, metrics.NewRegisterer("mainnet").Register(newGauge(3)))
+	require.Equal(t, map[string]float64{"mainnet": 3, "goerli": 2}, gaugeValues(t))
+}
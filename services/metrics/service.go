@@ -14,8 +14,20 @@
 // Package metrics provides an interface to present metrics.
 package metrics
 
+import "context"
+
 // Service is the generic metrics service.
+// Modules collect metrics with the prometheus client library, registering them
+// with the default registry through NewRegisterer(), unless the presenter is "null";
+// the service is responsible for presenting them.
 type Service interface {
 	// Presenter provides the presenter for this service.
 	Presenter() string
 }
+
+// Pusher is the interface for metrics services that push metrics on request,
+// rather than presenting them continuously.
+type Pusher interface {
+	// Push pushes the current metrics.
+	Push(ctx context.Context) error
+}
@@ -0,0 +1,122 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// Formats for metrics.
+const (
+	// FormatStatsD folds labels in to metric names.
+	FormatStatsD = "statsd"
+	// FormatDatadog sends labels as DogStatsD tags.
+	FormatDatadog = "datadog"
+)
+
+type parameters struct {
+	logLevel zerolog.Level
+	address  string
+	interval time.Duration
+	prefix   string
+	format   string
+	gatherer prometheus.Gatherer
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithAddress sets the UDP address of the StatsD server.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithInterval sets the interval between sending metrics.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithPrefix sets a prefix for the names of metrics.
+func WithPrefix(prefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.prefix = prefix
+	})
+}
+
+// WithFormat sets the format of metrics.
+func WithFormat(format string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.format = format
+	})
+}
+
+// WithGatherer sets the gatherer from which metrics are obtained.
+func WithGatherer(gatherer prometheus.Gatherer) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.gatherer = gatherer
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		interval: 10 * time.Second,
+		format:   FormatStatsD,
+		gatherer: prometheus.DefaultGatherer,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+	if parameters.format != FormatStatsD && parameters.format != FormatDatadog {
+		return nil, errors.New("format must be statsd or datadog")
+	}
+	if parameters.gatherer == nil {
+		return nil, errors.New("no gatherer specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,276 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// maxPacketSize is the largest UDP payload sent, chosen to avoid fragmentation on common networks.
+const maxPacketSize = 1432
+
+// Service is a metrics service sending metrics to a StatsD server.
+// Metrics are gathered from the prometheus registry at each interval; counters
+// and histograms are sent as the change since the previous interval, and gauges
+// as their current value.
+type Service struct {
+	conn     net.Conn
+	prefix   string
+	format   string
+	gatherer prometheus.Gatherer
+	previous map[string]float64
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new StatsD metrics service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("metrics", "statsd", parameters.logLevel)
+
+	conn, err := net.Dial("udp", parameters.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to StatsD server")
+	}
+
+	s := &Service{
+		conn:     conn,
+		prefix:   parameters.prefix,
+		format:   parameters.format,
+		gatherer: parameters.gatherer,
+		previous: make(map[string]float64),
+	}
+
+	go s.run(ctx, parameters.interval)
+
+	return s, nil
+}
+
+// Presenter returns the presenter for the events.
+func (s *Service) Presenter() string {
+	return "statsd"
+}
+
+// run sends metrics at each interval until the context is done, sending a final
+// set of metrics before returning.
+func (s *Service) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.send()
+			if err := s.conn.Close(); err != nil {
+				log.Debug().Err(err).Msg("Failed to close connection")
+			}
+			return
+		case <-ticker.C:
+			s.send()
+		}
+	}
+}
+
+// send gathers and sends the current metrics.
+func (s *Service) send() {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		// Gather can return partial results alongside an error, so send what we have.
+		log.Warn().Err(err).Msg("Failed to gather some metrics")
+	}
+
+	lines := make([]string, 0)
+	for _, family := range families {
+		lines = append(lines, s.familyLines(family)...)
+	}
+
+	packet := make([]byte, 0, maxPacketSize)
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > maxPacketSize {
+			s.write(packet)
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+	if len(packet) > 0 {
+		s.write(packet)
+	}
+}
+
+func (s *Service) write(packet []byte) {
+	if _, err := s.conn.Write(packet); err != nil {
+		log.Debug().Err(err).Msg("Failed to send metrics")
+	}
+}
+
+// familyLines provides the StatsD lines for a metric family.
+func (s *Service) familyLines(family *dto.MetricFamily) []string {
+	name := family.GetName()
+	lines := make([]string, 0)
+	for _, metric := range family.GetMetric() {
+		labels := metric.GetLabel()
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			lines = append(lines, s.counter(name, labels, metric.GetCounter().GetValue())...)
+		case dto.MetricType_GAUGE:
+			lines = append(lines, s.gauge(name, labels, metric.GetGauge().GetValue())...)
+		case dto.MetricType_UNTYPED:
+			lines = append(lines, s.gauge(name, labels, metric.GetUntyped().GetValue())...)
+		case dto.MetricType_HISTOGRAM:
+			histogram := metric.GetHistogram()
+			lines = append(lines, s.counter(name+"_count", labels, float64(histogram.GetSampleCount()))...)
+			lines = append(lines, s.counter(name+"_sum", labels, histogram.GetSampleSum())...)
+			for _, bucket := range histogram.GetBucket() {
+				lines = append(lines, s.counter(name+"_bucket", withLabel(labels, "le", formatValue(bucket.GetUpperBound())), float64(bucket.GetCumulativeCount()))...)
+			}
+			lines = append(lines, s.counter(name+"_bucket", withLabel(labels, "le", "+Inf"), float64(histogram.GetSampleCount()))...)
+		case dto.MetricType_SUMMARY:
+			summary := metric.GetSummary()
+			lines = append(lines, s.counter(name+"_count", labels, float64(summary.GetSampleCount()))...)
+			lines = append(lines, s.counter(name+"_sum", labels, summary.GetSampleSum())...)
+			for _, quantile := range summary.GetQuantile() {
+				lines = append(lines, s.gauge(name, withLabel(labels, "quantile", formatValue(quantile.GetQuantile())), quantile.GetValue())...)
+			}
+		}
+	}
+
+	return lines
+}
+
+// counter provides the lines for the change in a counter since it was last sent.
+func (s *Service) counter(name string, labels []*dto.LabelPair, value float64) []string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	name = s.name(name, labels)
+	key := name
+	for _, label := range labels {
+		key += fmt.Sprintf("|%s=%s", label.GetName(), label.GetValue())
+	}
+	delta := value - s.previous[key]
+	if delta < 0 {
+		// Counter has been reset.
+		delta = value
+	}
+	s.previous[key] = value
+	if delta == 0 {
+		return nil
+	}
+
+	return []string{s.line(name, delta, "c", labels)}
+}
+
+// gauge provides the lines for the value of a gauge.
+func (s *Service) gauge(name string, labels []*dto.LabelPair, value float64) []string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	name = s.name(name, labels)
+	if value < 0 && s.format == FormatStatsD {
+		// StatsD treats signed gauge values as changes, so reset the gauge first.
+		return []string{
+			s.line(name, 0, "g", labels),
+			s.line(name, value, "g", labels),
+		}
+	}
+
+	return []string{s.line(name, value, "g", labels)}
+}
+
+// name provides the name of the metric as sent.
+func (s *Service) name(name string, labels []*dto.LabelPair) string {
+	if s.format == FormatDatadog {
+		return s.prefix + name
+	}
+
+	var builder strings.Builder
+	builder.WriteString(s.prefix)
+	builder.WriteString(name)
+	for _, label := range labels {
+		builder.WriteByte('.')
+		builder.WriteString(sanitizeName(label.GetName()))
+		builder.WriteByte('.')
+		builder.WriteString(sanitizeName(label.GetValue()))
+	}
+
+	return builder.String()
+}
+
+// line provides a line in StatsD format.
+func (s *Service) line(name string, value float64, metricType string, labels []*dto.LabelPair) string {
+	if s.format != FormatDatadog || len(labels) == 0 {
+		return fmt.Sprintf("%s:%s|%s", name, formatValue(value), metricType)
+	}
+
+	tags := make([]string, len(labels))
+	for i, label := range labels {
+		tags[i] = fmt.Sprintf("%s:%s", label.GetName(), sanitizeTag(label.GetValue()))
+	}
+
+	return fmt.Sprintf("%s:%s|%s|#%s", name, formatValue(value), metricType, strings.Join(tags, ","))
+}
+
+// withLabel provides the labels with an additional label.
+func withLabel(labels []*dto.LabelPair, name string, value string) []*dto.LabelPair {
+	res := make([]*dto.LabelPair, len(labels), len(labels)+1)
+	copy(res, labels)
+
+	return append(res, &dto.LabelPair{Name: &name, Value: &value})
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// sanitizeName replaces characters that cannot appear in a StatsD metric name.
+func sanitizeName(input string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, input)
+}
+
+// sanitizeTag replaces characters that cannot appear in a DogStatsD tag value.
+func sanitizeTag(input string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '|', '#', '\n':
+			return '_'
+		default:
+			return r
+		}
+	}, input)
+}
@@ -0,0 +1,177 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsd_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/metrics/statsd"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []statsd.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			err:  "problem with parameters: no address specified",
+		},
+		{
+			name: "IntervalZero",
+			params: []statsd.Parameter{
+				statsd.WithAddress("127.0.0.1:8125"),
+				statsd.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be positive",
+		},
+		{
+			name: "FormatInvalid",
+			params: []statsd.Parameter{
+				statsd.WithAddress("127.0.0.1:8125"),
+				statsd.WithFormat("graphite"),
+			},
+			err: "problem with parameters: format must be statsd or datadog",
+		},
+		{
+			name: "Good",
+			params: []statsd.Parameter{
+				statsd.WithAddress("127.0.0.1:8125"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			s, err := statsd.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "statsd", s.Presenter())
+			}
+		})
+	}
+}
+
+// receive returns the lines received by the listener in the next packets.
+func receive(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	lines := make([]string, 0)
+	buf := make([]byte, 65536)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+		// Subsequent packets in the same send arrive immediately.
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+	}
+
+	return lines
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+		next     []string
+	}{
+		{
+			name:   "StatsD",
+			format: statsd.FormatStatsD,
+			expected: []string{
+				"test_gauge:0|g",
+				"test_gauge:-2|g",
+				"test_writes_total.table.t_blocks:3|c",
+				"test_size_count.table.t_blocks:1|c",
+				"test_size_sum.table.t_blocks:5|c",
+				"test_size_bucket.table.t_blocks.le.10:1|c",
+				"test_size_bucket.table.t_blocks.le._Inf:1|c",
+			},
+			next: []string{
+				"test_gauge:0|g",
+				"test_gauge:-2|g",
+				"test_writes_total.table.t_blocks:2|c",
+			},
+		},
+		{
+			name:   "Datadog",
+			format: statsd.FormatDatadog,
+			expected: []string{
+				"test_gauge:-2|g",
+				"test_writes_total:3|c|#table:t_blocks",
+				"test_size_count:1|c|#table:t_blocks",
+				"test_size_sum:5|c|#table:t_blocks",
+				"test_size_bucket:1|c|#table:t_blocks,le:10",
+				"test_size_bucket:1|c|#table:t_blocks,le:+Inf",
+			},
+			next: []string{
+				"test_gauge:-2|g",
+				"test_writes_total:2|c|#table:t_blocks",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer listener.Close()
+
+			registry := prometheus.NewRegistry()
+			gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+			require.NoError(t, registry.Register(gauge))
+			counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_writes_total"}, []string{"table"})
+			require.NoError(t, registry.Register(counter))
+			histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_size", Buckets: []float64{10}}, []string{"table"})
+			require.NoError(t, registry.Register(histogram))
+
+			gauge.Set(-2)
+			counter.WithLabelValues("t_blocks").Add(3)
+			histogram.WithLabelValues("t_blocks").Observe(5)
+
+			_, err = statsd.New(ctx,
+				statsd.WithAddress(listener.LocalAddr().String()),
+				statsd.WithInterval(time.Second),
+				statsd.WithFormat(test.format),
+				statsd.WithGatherer(registry),
+			)
+			require.NoError(t, err)
+
+			require.ElementsMatch(t, test.expected, receive(t, listener))
+
+			// Only changes to counters and histograms are sent.
+			counter.WithLabelValues("t_blocks").Add(2)
+			require.ElementsMatch(t, test.next, receive(t, listener))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_peers"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	peers   *prometheus.GaugeVec
+	samples *prometheus.CounterVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.peers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "connected",
+		Help:      "Number of peers of the beacon node at the latest sample",
+	}, []string{"client"})
+	if err := registerer.Register(m.peers); err != nil {
+		return nil, errors.Wrap(err, "failed to register connected")
+	}
+
+	m.samples = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "samples_total",
+		Help:      "Number of samples of the beacon node's peers",
+	}, []string{"result"})
+	if err := registerer.Register(m.samples); err != nil {
+		return nil, errors.Wrap(err, "failed to register samples_total")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorPeers(clients map[string]uint32) {
+	if s.metrics.peers != nil {
+		// Clients that are no longer seen should not keep their last count.
+		s.metrics.peers.Reset()
+		for client, count := range clients {
+			s.metrics.peers.WithLabelValues(client).Set(float64(count))
+		}
+	}
+}
+
+func (s *Service) monitorSample(succeeded bool) {
+	if s.metrics.samples != nil {
+		if succeeded {
+			s.metrics.samples.WithLabelValues("succeeded").Inc()
+		} else {
+			s.metrics.samples.WithLabelValues("failed").Inc()
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// unknown is the client and version of peers that cannot be identified.
+const unknown = "unknown"
+
+// errNotFound is returned when the beacon node does not serve an endpoint.
+var errNotFound = errors.New("endpoint not found")
+
+// versionRegex matches the release part of a version, dropping any commit or build suffix.
+var versionRegex = regexp.MustCompile(`^v?([0-9]+\.[0-9]+\.[0-9]+)`)
+
+// peer is a connected peer of the beacon node.
+type peer struct {
+	client  string
+	version string
+}
+
+// lighthousePeer is a peer as returned by Lighthouse's peers endpoint.
+type lighthousePeer struct {
+	PeerInfo struct {
+		Client struct {
+			Kind    string `json:"kind"`
+			Version string `json:"version"`
+		} `json:"client"`
+	} `json:"peer_info"`
+}
+
+// standardPeers is the response of the standard peers endpoint.
+type standardPeers struct {
+	Data []struct {
+		State string `json:"state"`
+	} `json:"data"`
+}
+
+// fetchPeers fetches the connected peers of the beacon node.  The standard API does not
+// provide the client of each peer, so it is obtained from Lighthouse's own endpoint if
+// the beacon node serves it; otherwise all peers are of an unknown client.
+func (s *Service) fetchPeers(ctx context.Context) ([]*peer, error) {
+	if !s.standardOnly {
+		peers, err := s.fetchLighthousePeers(ctx)
+		if err == nil {
+			return peers, nil
+		}
+		if !errors.Is(err, errNotFound) {
+			return nil, err
+		}
+		s.log.Info().Msg("Beacon node does not provide the clients of its peers; recording peers of unknown client")
+		s.standardOnly = true
+	}
+
+	return s.fetchStandardPeers(ctx)
+}
+
+// fetchLighthousePeers fetches connected peers from Lighthouse's peers endpoint.
+func (s *Service) fetchLighthousePeers(ctx context.Context) ([]*peer, error) {
+	data, err := s.get(ctx, "/lighthouse/peers/connected")
+	if err != nil {
+		return nil, err
+	}
+
+	lighthousePeers := make([]*lighthousePeer, 0)
+	if err := json.Unmarshal(data, &lighthousePeers); err != nil {
+		return nil, errors.Wrap(err, "failed to parse lighthouse peers")
+	}
+
+	peers := make([]*peer, len(lighthousePeers))
+	for i := range lighthousePeers {
+		peers[i] = &peer{
+			client:  normalizeClient(lighthousePeers[i].PeerInfo.Client.Kind),
+			version: normalizeVersion(lighthousePeers[i].PeerInfo.Client.Version),
+		}
+	}
+
+	return peers, nil
+}
+
+// fetchStandardPeers fetches connected peers from the standard peers endpoint.
+func (s *Service) fetchStandardPeers(ctx context.Context) ([]*peer, error) {
+	data, err := s.get(ctx, "/eth/v1/node/peers?state=connected")
+	if err != nil {
+		return nil, err
+	}
+
+	response := &standardPeers{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, errors.Wrap(err, "failed to parse peers")
+	}
+
+	peers := make([]*peer, 0, len(response.Data))
+	for i := range response.Data {
+		// Not all beacon nodes filter by state.
+		if response.Data[i].State != "connected" {
+			continue
+		}
+		peers = append(peers, &peer{
+			client:  unknown,
+			version: unknown,
+		})
+	}
+
+	return peers, nil
+}
+
+// get fetches an endpoint from the beacon node.
+func (s *Service) get(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, fmt.Sprintf("%s%s", s.base, endpoint), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GET request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call GET endpoint")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read GET response")
+	}
+
+	switch {
+	case resp.StatusCode == nethttp.StatusNotFound:
+		return nil, errNotFound
+	case resp.StatusCode/100 != 2:
+		return nil, fmt.Errorf("GET failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	return data, nil
+}
+
+// normalizeClient returns the client in lower case, or unknown if it is not known.
+func normalizeClient(client string) string {
+	client = strings.ToLower(strings.TrimSpace(client))
+	if client == "" {
+		return unknown
+	}
+
+	return client
+}
+
+// normalizeVersion returns the release of a version, for example v3.1.0 for v3.1.0-aa022f4,
+// so that peers on the same release are counted together.
+func normalizeVersion(version string) string {
+	match := versionRegex.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return unknown
+	}
+
+	return fmt.Sprintf("v%s", match[1])
+}
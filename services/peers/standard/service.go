@@ -0,0 +1,196 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a service that samples the peers of the beacon node.
+type Service struct {
+	log              zerolog.Logger
+	metrics          *serviceMetrics
+	chainDB          chaindb.Service
+	peerCountsSetter chaindb.PeerCountsSetter
+	chainTime        chaintime.Service
+	interval         time.Duration
+	base             string
+	client           *nethttp.Client
+	sampleMu         sync.Mutex
+	// standardOnly is set once the beacon node is found not to provide the clients of its peers.
+	standardOnly bool
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "peers", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	base := parameters.eth2Client.Address()
+	if !strings.HasPrefix(base, "http") {
+		base = fmt.Sprintf("http://%s", base)
+	}
+
+	transport := nethttp.DefaultTransport.(*nethttp.Transport).Clone()
+	// The beacon node is reached directly or through the local proxy, never through
+	// proxies from the environment.
+	transport.Proxy = nil
+
+	s := &Service{
+		log:              log,
+		metrics:          svcMetrics,
+		chainDB:          parameters.chainDB,
+		peerCountsSetter: parameters.chainDB.(chaindb.PeerCountsSetter),
+		chainTime:        parameters.chainTime,
+		interval:         parameters.interval,
+		base:             strings.TrimSuffix(base, "/"),
+		client: &nethttp.Client{
+			Timeout:   parameters.timeout,
+			Transport: transport,
+		},
+	}
+
+	// Sample immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.sample(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "peers", "sample peers",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic sample of peers")
+	}
+	go s.sample(ctx)
+
+	return s, nil
+}
+
+// sample fetches the peers of the beacon node and stores their counts by client and version.
+func (s *Service) sample(ctx context.Context) {
+	if !s.sampleMu.TryLock() {
+		s.log.Debug().Msg("Sample already in progress")
+		return
+	}
+	defer s.sampleMu.Unlock()
+
+	timestamp := time.Now().UTC().Truncate(time.Second)
+	peers, err := s.fetchPeers(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to fetch peers")
+		s.monitorSample(false)
+		return
+	}
+
+	counts := s.peerCounts(timestamp, peers)
+	if err := s.store(ctx, counts); err != nil {
+		s.log.Error().Err(err).Msg("Failed to store peer counts")
+		s.monitorSample(false)
+		return
+	}
+	s.monitorSample(true)
+
+	clients := make(map[string]uint32)
+	for _, count := range counts {
+		clients[count.Client] += count.Count
+	}
+	s.monitorPeers(clients)
+	s.log.Trace().Int("peers", len(peers)).Int("versions", len(counts)).Msg("Sampled peers")
+}
+
+// peerCounts counts peers by client and version.
+func (s *Service) peerCounts(timestamp time.Time, peers []*peer) []*chaindb.PeerCount {
+	epoch := s.chainTime.CurrentEpoch()
+	countsByVersion := make(map[string]*chaindb.PeerCount)
+	for _, peer := range peers {
+		key := fmt.Sprintf("%s/%s", peer.client, peer.version)
+		count, exists := countsByVersion[key]
+		if !exists {
+			count = &chaindb.PeerCount{
+				Timestamp: timestamp,
+				Epoch:     epoch,
+				Client:    peer.client,
+				Version:   peer.version,
+			}
+			countsByVersion[key] = count
+		}
+		count.Count++
+	}
+
+	counts := make([]*chaindb.PeerCount, 0, len(countsByVersion))
+	for _, count := range countsByVersion {
+		counts = append(counts, count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Client != counts[j].Client {
+			return counts[i].Client < counts[j].Client
+		}
+		return counts[i].Version < counts[j].Version
+	})
+
+	return counts
+}
+
+// store stores peer counts.
+func (s *Service) store(ctx context.Context, counts []*chaindb.PeerCount) error {
+	if len(counts) == 0 {
+		// Nothing to store.
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.peerCountsSetter.SetPeerCounts(ctx, counts); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set peer counts")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/peers/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+// addressClient is a client that provides only its address.
+type addressClient struct {
+	address string
+}
+
+func (c *addressClient) Name() string    { return "mock" }
+func (c *addressClient) Address() string { return c.address }
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eth2Client := &addressClient{address: "localhost:1"}
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "IntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Second),
+			},
+			err: "problem with parameters: interval must be at least 1 minute",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be positive",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// sampledCounts starts a service against the given beacon node and returns the first peer counts that it stores.
+func sampledCounts(t *testing.T, handler nethttp.Handler) []*chaindb.PeerCount {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	chainDB := mockchaindb.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithETH2Client(&addressClient{address: server.URL}),
+		standard.WithChainDB(chainDB),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithScheduler(scheduler),
+	)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(chainDB.CallsTo("SetPeerCounts")) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return chainDB.CallsTo("SetPeerCounts")[0].Args[0].([]*chaindb.PeerCount)
+}
+
+func TestLighthousePeers(t *testing.T) {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("/lighthouse/peers/connected", func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		peer := func(kind string, version string) string {
+			return fmt.Sprintf(`{"peer_id":"id","peer_info":{"client":{"kind":%q,"version":%q,"os_version":"linux-x86_64"}}}`, kind, version)
+		}
+		fmt.Fprintf(w, "[%s,%s,%s,%s]",
+			peer("Prysm", "v3.1.1"),
+			peer("Lighthouse", "v3.1.0-aa022f4"),
+			peer("Lighthouse", "v3.1.0-bb022f4"),
+			peer("Unknown", ""),
+		)
+	})
+
+	counts := sampledCounts(t, mux)
+	require.Len(t, counts, 3)
+	require.Equal(t, "lighthouse", counts[0].Client)
+	require.Equal(t, "v3.1.0", counts[0].Version)
+	require.Equal(t, uint32(2), counts[0].Count)
+	require.Equal(t, "prysm", counts[1].Client)
+	require.Equal(t, "v3.1.1", counts[1].Version)
+	require.Equal(t, uint32(1), counts[1].Count)
+	require.Equal(t, "unknown", counts[2].Client)
+	require.Equal(t, "unknown", counts[2].Version)
+}
+
+func TestStandardPeers(t *testing.T) {
+	mux := nethttp.NewServeMux()
+	mux.HandleFunc("/eth/v1/node/peers", func(w nethttp.ResponseWriter, _ *nethttp.Request) {
+		fmt.Fprint(w, `{"data":[{"peer_id":"a","state":"connected"},{"peer_id":"b","state":"connected"},{"peer_id":"c","state":"disconnected"}],"meta":{"count":3}}`)
+	})
+
+	counts := sampledCounts(t, mux)
+	require.Len(t, counts, 1)
+	require.Equal(t, "unknown", counts[0].Client)
+	require.Equal(t, uint32(2), counts[0].Count)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priority
+
+import (
+	"context"
+)
+
+// Priority is the priority of a piece of work.
+type Priority int
+
+const (
+	// Backfill is work to catch up with historical data, which can wait.
+	Backfill Priority = iota
+	// Live is work to keep up with the head of the chain.
+	Live
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case Backfill:
+		return "backfill"
+	case Live:
+		return "live"
+	default:
+		return "unknown"
+	}
+}
+
+// Service is the interface for a service that shares beacon node and database
+// capacity between work of different priorities.
+type Service interface {
+	// Acquire waits until work of the given priority can proceed, returning a function
+	// that must be called when the work is complete.
+	// An error is returned if the context is done before the work can proceed.
+	Acquire(ctx context.Context, priority Priority) (func(), error)
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/priority"
+)
+
+var metricsNamespace = "chaind_priority"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	active       *prometheus.GaugeVec
+	waiting      *prometheus.GaugeVec
+	waitDuration *prometheus.HistogramVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.active = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active",
+		Help:      "Number of pieces of work in progress, by priority",
+	}, []string{"priority"})
+	if err := registerer.Register(m.active); err != nil {
+		return nil, errors.Wrap(err, "failed to register active")
+	}
+
+	m.waiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "waiting",
+		Help:      "Number of pieces of work waiting to proceed, by priority",
+	}, []string{"priority"})
+	if err := registerer.Register(m.waiting); err != nil {
+		return nil, errors.Wrap(err, "failed to register waiting")
+	}
+
+	m.waitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "wait_duration_seconds",
+		Help:      "Time spent waiting to proceed, by priority",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"priority"})
+	if err := registerer.Register(m.waitDuration); err != nil {
+		return nil, errors.Wrap(err, "failed to register wait_duration_seconds")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorState(activeWork map[priority.Priority]int, waitingWork map[priority.Priority]int) {
+	if s.metrics.active != nil {
+		for _, p := range []priority.Priority{priority.Backfill, priority.Live} {
+			s.metrics.active.WithLabelValues(p.String()).Set(float64(activeWork[p]))
+			s.metrics.waiting.WithLabelValues(p.String()).Set(float64(waitingWork[p]))
+		}
+	}
+}
+
+func (s *Service) monitorWait(p priority.Priority, duration time.Duration) {
+	if s.metrics.waitDuration != nil {
+		s.metrics.waitDuration.WithLabelValues(p.String()).Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/util"
+)
+
+// waiter is a piece of work waiting to proceed.
+type waiter struct {
+	priority priority.Priority
+	ch       chan struct{}
+	granted  bool
+}
+
+// Service shares a fixed capacity between live and backfill work.  Live work
+// always proceeds ahead of waiting backfill work, and backfill work cannot use
+// the places reserved for live work, so live work does not wait for backfill work
+// already in progress to complete.  Work of the same priority proceeds in the order in
+// which it asked.
+type Service struct {
+	log      zerolog.Logger
+	metrics  *serviceMetrics
+	mu       sync.Mutex
+	capacity int
+	reserved int
+	active   map[priority.Priority]int
+	waiters  map[priority.Priority][]*waiter
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "priority", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	return &Service{
+		log:      log,
+		metrics:  svcMetrics,
+		capacity: parameters.capacity,
+		reserved: parameters.reserved,
+		active:   make(map[priority.Priority]int),
+		waiters:  make(map[priority.Priority][]*waiter),
+	}, nil
+}
+
+// Acquire waits until work of the given priority can proceed, returning a function
+// that must be called when the work is complete.
+// An error is returned if the context is done before the work can proceed.
+func (s *Service) Acquire(ctx context.Context, p priority.Priority) (func(), error) {
+	started := time.Now()
+
+	s.mu.Lock()
+	if s.canProceed(p) {
+		s.active[p]++
+		s.monitorState(s.active, s.waiting())
+		s.mu.Unlock()
+		s.monitorWait(p, time.Since(started))
+		return s.releaser(p), nil
+	}
+	w := &waiter{
+		priority: p,
+		ch:       make(chan struct{}),
+	}
+	s.waiters[p] = append(s.waiters[p], w)
+	s.monitorState(s.active, s.waiting())
+	s.mu.Unlock()
+	s.log.Trace().Stringer("priority", p).Msg("Waiting to proceed")
+
+	select {
+	case <-w.ch:
+		s.monitorWait(p, time.Since(started))
+		return s.releaser(p), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// Granted at the same time as the context finished; hand the place back.
+			s.active[p]--
+			s.dispatch()
+		} else {
+			s.remove(w)
+		}
+		s.monitorState(s.active, s.waiting())
+		s.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// canProceed returns true if work of the given priority can proceed immediately.
+// This assumes that the caller holds the lock.
+func (s *Service) canProceed(p priority.Priority) bool {
+	total := s.active[priority.Live] + s.active[priority.Backfill]
+	if total >= s.capacity {
+		return false
+	}
+	if p == priority.Live {
+		return len(s.waiters[priority.Live]) == 0
+	}
+
+	return len(s.waiters[priority.Live]) == 0 &&
+		len(s.waiters[priority.Backfill]) == 0 &&
+		s.active[priority.Backfill] < s.capacity-s.reserved
+}
+
+// dispatch allows waiting work to proceed whilst there is capacity, live work first.
+// This assumes that the caller holds the lock.
+func (s *Service) dispatch() {
+	for {
+		total := s.active[priority.Live] + s.active[priority.Backfill]
+		var p priority.Priority
+		switch {
+		case total >= s.capacity:
+			return
+		case len(s.waiters[priority.Live]) > 0:
+			p = priority.Live
+		case len(s.waiters[priority.Backfill]) > 0 && s.active[priority.Backfill] < s.capacity-s.reserved:
+			p = priority.Backfill
+		default:
+			return
+		}
+		w := s.waiters[p][0]
+		s.waiters[p] = s.waiters[p][1:]
+		w.granted = true
+		s.active[p]++
+		close(w.ch)
+	}
+}
+
+// remove removes a waiter that is no longer waiting.
+// This assumes that the caller holds the lock.
+func (s *Service) remove(w *waiter) {
+	waiters := s.waiters[w.priority]
+	for i := range waiters {
+		if waiters[i] == w {
+			s.waiters[w.priority] = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	// Backfill work may have been held behind this waiter.
+	s.dispatch()
+}
+
+// waiting returns the number of waiters for each priority.
+// This assumes that the caller holds the lock.
+func (s *Service) waiting() map[priority.Priority]int {
+	res := make(map[priority.Priority]int, len(s.waiters))
+	for p, waiters := range s.waiters {
+		res[p] = len(waiters)
+	}
+
+	return res
+}
+
+// releaser returns a function that releases a place held by work of the given priority.
+func (s *Service) releaser(p priority.Priority) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.active[p]--
+			s.dispatch()
+			s.monitorState(s.active, s.waiting())
+			s.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/priority"
+	"github.com/wealdtech/chaind/services/priority/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "Default",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+		},
+		{
+			name: "CapacityZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCapacity(0),
+			},
+			err: "problem with parameters: capacity must be at least 1",
+		},
+		{
+			name: "ReservedNegative",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithReserved(-1),
+			},
+			err: "problem with parameters: reserved cannot be negative",
+		},
+		{
+			name: "ReservedAll",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCapacity(2),
+				standard.WithReserved(2),
+			},
+			err: "problem with parameters: reserved must be less than capacity",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// acquireAsync acquires a place in the background, sending the release function once acquired.
+func acquireAsync(ctx context.Context, s *standard.Service, p priority.Priority) <-chan func() {
+	ch := make(chan func(), 1)
+	go func() {
+		release, err := s.Acquire(ctx, p)
+		if err == nil {
+			ch <- release
+		}
+	}()
+
+	return ch
+}
+
+func TestReserved(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithCapacity(3),
+		standard.WithReserved(1),
+	)
+	require.NoError(t, err)
+
+	// Backfill can take all but the reserved place.
+	release1, err := s.Acquire(ctx, priority.Backfill)
+	require.NoError(t, err)
+	release2, err := s.Acquire(ctx, priority.Backfill)
+	require.NoError(t, err)
+	backfill := acquireAsync(ctx, s, priority.Backfill)
+
+	// Live work proceeds immediately.
+	releaseLive, err := s.Acquire(ctx, priority.Live)
+	require.NoError(t, err)
+
+	select {
+	case <-backfill:
+		require.Fail(t, "backfill proceeded beyond its capacity")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Releasing backfill work lets the waiting backfill work proceed.
+	release1()
+	// Releasing more than once has no effect.
+	release1()
+	select {
+	case release := <-backfill:
+		release()
+	case <-time.After(time.Second):
+		require.Fail(t, "backfill did not proceed")
+	}
+
+	release2()
+	releaseLive()
+}
+
+func TestLiveFirst(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithCapacity(1),
+		standard.WithReserved(0),
+	)
+	require.NoError(t, err)
+
+	release, err := s.Acquire(ctx, priority.Backfill)
+	require.NoError(t, err)
+
+	backfill := acquireAsync(ctx, s, priority.Backfill)
+	time.Sleep(50 * time.Millisecond)
+	live := acquireAsync(ctx, s, priority.Live)
+	time.Sleep(50 * time.Millisecond)
+
+	// Live work proceeds ahead of backfill work that was waiting before it.
+	release()
+	var releaseLive func()
+	select {
+	case releaseLive = <-live:
+	case <-backfill:
+		require.Fail(t, "backfill proceeded ahead of live")
+	case <-time.After(time.Second):
+		require.Fail(t, "live did not proceed")
+	}
+
+	releaseLive()
+	select {
+	case release := <-backfill:
+		release()
+	case <-time.After(time.Second):
+		require.Fail(t, "backfill did not proceed")
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithCapacity(1),
+		standard.WithReserved(0),
+	)
+	require.NoError(t, err)
+
+	release, err := s.Acquire(ctx, priority.Live)
+	require.NoError(t, err)
+
+	cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = s.Acquire(cancelCtx, priority.Backfill)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The cancelled waiter does not hold a place.
+	release()
+	release, err = s.Acquire(ctx, priority.Backfill)
+	require.NoError(t, err)
+	release()
+}
@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -39,12 +40,12 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 
 	epoch := s.chainTime.SlotToEpoch(slot)
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
@@ -58,6 +59,7 @@ func (s *Service) OnBeaconChainHeadUpdated(
 }
 
 func (s *Service) updateProposerDutiesForEpoch(ctx context.Context, epoch phase0.Epoch) error {
+	started := time.Now()
 	duties, err := s.eth2Client.(eth2client.ProposerDutiesProvider).ProposerDuties(ctx, epoch, nil)
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch proposer duties")
@@ -73,6 +75,7 @@ func (s *Service) updateProposerDutiesForEpoch(ctx context.Context, epoch phase0
 		}
 	}
 
-	monitorEpochProcessed(epoch)
+	s.monitorEpochProcessed(epoch)
+	s.monitorProcessingDuration(time.Since(started))
 	return nil
 }
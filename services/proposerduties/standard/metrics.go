@@ -15,63 +15,110 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"go.uber.org/atomic"
 )
 
 var metricsNamespace = "chaind_proposerduties"
 
-var highestEpoch phase0.Epoch
-var latestEpoch prometheus.Gauge
-var epochsProcessed prometheus.Gauge
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	highestEpoch       atomic.Uint64
+	latestEpoch        prometheus.Gauge
+	epochsProcessed    prometheus.Gauge
+	lagEpochs          prometheus.GaugeFunc
+	processingDuration prometheus.Histogram
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if latestEpoch != nil {
-		// Already registered.
-		return nil
-	}
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service, chainTime chaintime.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network), chainTime)
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
 // skipcq: RVV-B0012
-func registerPrometheusMetrics(ctx context.Context) error {
-	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer, chainTime chaintime.Service) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_epoch",
 		Help:      "Latest epoch processed for proposer duties",
 	})
-	if err := prometheus.Register(latestEpoch); err != nil {
-		return errors.Wrap(err, "failed to register latest_epoch")
+	if err := registerer.Register(m.latestEpoch); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_epoch")
 	}
 
-	epochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.epochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "epochs_processed",
 		Help:      "Number of epochs processed",
 	})
-	if err := prometheus.Register(epochsProcessed); err != nil {
-		return errors.Wrap(err, "failed to register epochs_processed")
+	if err := registerer.Register(m.epochsProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register epochs_processed")
+	}
+
+	m.lagEpochs = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_epochs",
+		Help:      "Number of epochs between the current epoch and the latest epoch processed",
+	}, func() float64 {
+		current := chainTime.CurrentEpoch()
+		latest := phase0.Epoch(m.highestEpoch.Load())
+		if latest >= current {
+			return 0
+		}
+		return float64(current - latest)
+	})
+	if err := registerer.Register(m.lagEpochs); err != nil {
+		return nil, errors.Wrap(err, "failed to register lag_epochs")
+	}
+
+	m.processingDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Time taken to process an epoch",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	})
+	if err := registerer.Register(m.processingDuration); err != nil {
+		return nil, errors.Wrap(err, "failed to register processing_duration_seconds")
 	}
 
-	return nil
+	return m, nil
 }
 
-func monitorEpochProcessed(epoch phase0.Epoch) {
-	if epochsProcessed != nil {
-		epochsProcessed.Inc()
-		if epoch > highestEpoch {
-			latestEpoch.Set(float64(epoch))
-			highestEpoch = epoch
+// monitorLatestEpoch sets the latest epoch without registering an
+// increase in epochs processed.  This does not usually need to be
+// called directly, as it is called as part of monitorEpochProcessed.
+func (s *Service) monitorLatestEpoch(epoch phase0.Epoch) {
+	s.metrics.highestEpoch.Store(uint64(epoch))
+	if s.metrics.latestEpoch != nil {
+		s.metrics.latestEpoch.Set(float64(epoch))
+	}
+}
+
+func (s *Service) monitorEpochProcessed(epoch phase0.Epoch) {
+	if s.metrics.epochsProcessed != nil {
+		s.metrics.epochsProcessed.Inc()
+		if epoch > phase0.Epoch(s.metrics.highestEpoch.Load()) {
+			s.monitorLatestEpoch(epoch)
 		}
 	}
 }
+
+func (s *Service) monitorProcessingDuration(duration time.Duration) {
+	if s.metrics.processingDuration != nil {
+		s.metrics.processingDuration.Observe(duration.Seconds())
+	}
+}
@@ -25,11 +25,13 @@ import (
 
 type parameters struct {
 	logLevel   zerolog.Level
+	network    string
 	monitor    metrics.Service
 	eth2Client eth2client.Service
 	chainDB    chaindb.Service
 	chainTime  chaintime.Service
 	startEpoch int64
+	window     uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +52,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -85,6 +94,13 @@ func WithStartEpoch(startEpoch int64) Parameter {
 	})
 }
 
+// WithWindow sets the number of recent epochs for which proposer duties are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -21,24 +21,24 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
+	log                  zerolog.Logger
+	metrics              *serviceMetrics
 	eth2Client           eth2client.Service
 	chainDB              chaindb.Service
 	proposerDutiesSetter chaindb.ProposerDutiesSetter
 	chainTime            chaintime.Service
 	activitySem          *semaphore.Weighted
+	window               uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -47,9 +47,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "proposerduties").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "proposerduties", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -59,8 +60,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:                  log,
+		metrics:              svcMetrics,
 		eth2Client:           parameters.eth2Client,
 		chainDB:              parameters.chainDB,
+		window:               parameters.window,
 		proposerDutiesSetter: proposerDutiesSetter,
 		chainTime:            parameters.chainTime,
 		activitySem:          semaphore.NewWeighted(1),
@@ -76,8 +80,9 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 	// Work out the epoch from which to start.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
+	s.monitorLatestEpoch(md.LatestEpoch)
 	if startEpoch >= 0 {
 		// Explicit requirement to start at a given epoch.
 		md.LatestEpoch = phase0.Epoch(startEpoch)
@@ -85,8 +90,12 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		// We have a definite hit on this being the last processed epoch; increment it to avoid duplication of work.
 		md.LatestEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); md.LatestEpoch < windowStart {
+		// Duties before the rolling window would be pruned, so do not fetch them.
+		md.LatestEpoch = windowStart
+	}
 
-	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
+	s.log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 	s.catchup(ctx, md)
 	if len(md.MissedEpochs) > 0 {
 		// Need this as a []uint64 for logging only.
@@ -94,26 +103,26 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		for i := range md.MissedEpochs {
 			missedEpochs[i] = uint64(md.MissedEpochs[i])
 		}
-		log.Info().Uints64("missed_epochs", missedEpochs).Msg("Re-fetching missed epochs")
+		s.log.Info().Uints64("missed_epochs", missedEpochs).Msg("Re-fetching missed epochs")
 		s.handleMissed(ctx, md)
 		// Catchup again, in case handling the missed epochs took a while.
-		log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
+		s.log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 		s.catchup(ctx, md)
 	}
-	log.Info().Msg("Caught up")
+	s.log.Info().Msg("Caught up")
 
 	// Set up the handler for new chain head updates.
 	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
+		s.log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
 
 func (s *Service) catchup(ctx context.Context, md *metadata) {
 	for epoch := md.LatestEpoch; epoch <= s.chainTime.CurrentEpoch(); epoch++ {
-		log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+		log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
@@ -145,7 +154,7 @@ func (s *Service) catchup(ctx context.Context, md *metadata) {
 func (s *Service) handleMissed(ctx context.Context, md *metadata) {
 	failed := 0
 	for i := 0; i < len(md.MissedEpochs); i++ {
-		log := log.With().Uint64("epoch", uint64(md.MissedEpochs[i])).Logger()
+		log := s.log.With().Uint64("epoch", uint64(md.MissedEpochs[i])).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
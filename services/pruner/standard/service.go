@@ -0,0 +1,226 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a pruning service, that removes rows from tables once they are older
+// than the table's retention period.
+type Service struct {
+	log        zerolog.Logger
+	metrics    *serviceMetrics
+	chainDB    chaindb.Service
+	pruner     chaindb.TablePruner
+	chainTime  chaintime.Service
+	interval   time.Duration
+	tables     []string
+	retentions map[string]time.Duration
+	window     uint64
+}
+
+// windowTables are the tables of raw chain data that are pruned in rolling window mode.
+var windowTables = map[string]bool{
+	"t_attestations":        true,
+	"t_beacon_committees":   true,
+	"t_block_arrivals":      true,
+	"t_block_clients":       true,
+	"t_blocks":              true,
+	"t_gossip_attestations": true,
+	"t_missed_slots":        true,
+	"t_proposer_duties":     true,
+	"t_sync_aggregates":     true,
+	"t_validator_balances":  true,
+}
+
+// windowOnlyTables are the tables that can only be pruned in rolling window mode, as
+// other modules expect them to be complete otherwise.
+var windowOnlyTables = map[string]bool{
+	"t_blocks":       true,
+	"t_missed_slots": true,
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "pruner", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	pruner := parameters.chainDB.(chaindb.TablePruner)
+	prunableTables, err := pruner.PrunableTables(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain prunable tables")
+	}
+	prunable := make(map[string]bool, len(prunableTables))
+	for _, table := range prunableTables {
+		prunable[table] = true
+	}
+	tables := make([]string, 0, len(parameters.retentions)+len(windowTables))
+	for table := range parameters.retentions {
+		if !prunable[table] {
+			return nil, fmt.Errorf("table %s cannot be pruned", table)
+		}
+		if windowOnlyTables[table] && parameters.window == 0 {
+			return nil, fmt.Errorf("table %s can only be pruned with a rolling window", table)
+		}
+		tables = append(tables, table)
+	}
+	if parameters.window > 0 {
+		for table := range windowTables {
+			if !prunable[table] {
+				return nil, fmt.Errorf("table %s cannot be pruned", table)
+			}
+			if _, exists := parameters.retentions[table]; !exists {
+				tables = append(tables, table)
+			}
+		}
+	}
+	sort.Strings(tables)
+
+	s := &Service{
+		log:        log,
+		metrics:    svcMetrics,
+		chainDB:    parameters.chainDB,
+		pruner:     pruner,
+		chainTime:  parameters.chainTime,
+		interval:   parameters.interval,
+		tables:     tables,
+		retentions: parameters.retentions,
+		window:     parameters.window,
+	}
+
+	// Prune immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.prune(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "pruner", "prune tables",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic pruning of tables")
+	}
+	go s.prune(ctx)
+
+	return s, nil
+}
+
+// prune prunes each table with a retention period.
+func (s *Service) prune(ctx context.Context) {
+	// Table sizes are used to estimate the space reclaimed by pruning, so are
+	// optional.
+	sizes := make(map[string]*chaindb.TableStats)
+	if provider, isProvider := s.chainDB.(chaindb.TableStatsProvider); isProvider {
+		stats, err := provider.TableStats(ctx)
+		if err != nil {
+			s.log.Debug().Err(err).Msg("Failed to obtain table statistics; reclaimed space will not be estimated")
+		}
+		for _, tableStats := range stats {
+			sizes[tableStats.Name] = tableStats
+		}
+	}
+
+	now := time.Now()
+	for _, table := range s.tables {
+		epoch := s.pruneEpoch(table, now)
+		if epoch == 0 {
+			s.log.Trace().Str("table", table).Msg("Retention period extends to genesis; nothing to prune")
+			continue
+		}
+
+		started := time.Now()
+		rows, err := s.pruneTable(ctx, table, epoch)
+		if err != nil {
+			s.log.Error().Str("table", table).Err(err).Msg("Failed to prune table")
+			s.monitorPrune(table, false, 0, 0)
+			continue
+		}
+
+		reclaimed := int64(0)
+		if stats, exists := sizes[table]; exists && stats.Rows > 0 {
+			reclaimed = (stats.TableSize + stats.IndexesSize) * rows / stats.Rows
+		}
+		s.log.Trace().
+			Str("table", table).
+			Uint64("to_epoch", uint64(epoch)).
+			Int64("rows", rows).
+			Int64("reclaimed_bytes", reclaimed).
+			Dur("elapsed", time.Since(started)).
+			Msg("Pruned table")
+		s.monitorPrune(table, true, rows, reclaimed)
+	}
+}
+
+// pruneEpoch provides the epoch before which rows of the table are pruned.  If the table has
+// both a retention period and is in the rolling window, the shorter of the two applies.
+func (s *Service) pruneEpoch(table string, now time.Time) phase0.Epoch {
+	epoch := phase0.Epoch(0)
+	if retention, exists := s.retentions[table]; exists {
+		epoch = s.chainTime.TimestampToEpoch(now.Add(-retention))
+	}
+	if s.window > 0 && windowTables[table] {
+		if windowStart := chaintime.WindowStart(s.chainTime, s.window); windowStart > epoch {
+			epoch = windowStart
+		}
+	}
+
+	return epoch
+}
+
+// pruneTable prunes a single table in its own transaction.
+func (s *Service) pruneTable(ctx context.Context, table string, epoch phase0.Epoch) (int64, error) {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to begin transaction")
+	}
+
+	rows, err := s.pruner.PruneTable(ctx, table, epoch, s.chainTime.FirstSlotOfEpoch(epoch))
+	if err != nil {
+		cancel()
+		return 0, err
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return 0, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return rows, nil
+}
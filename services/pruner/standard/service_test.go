@@ -0,0 +1,202 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/pruner/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("PrunableTables", []string{
+		"t_attestations",
+		"t_beacon_committees",
+		"t_block_arrivals",
+		"t_block_clients",
+		"t_blocks",
+		"t_gossip_attestations",
+		"t_missed_slots",
+		"t_proposer_duties",
+		"t_sync_aggregates",
+		"t_validator_balances",
+	})
+	chainTime := mockchaintime.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithRetentions(map[string]time.Duration{"t_attestations": time.Hour}),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithRetentions(map[string]time.Duration{"t_attestations": time.Hour}),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithRetentions(map[string]time.Duration{"t_attestations": time.Hour}),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "IntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Second),
+				standard.WithRetentions(map[string]time.Duration{"t_attestations": time.Hour}),
+			},
+			err: "problem with parameters: interval must be at least 1 minute",
+		},
+		{
+			name: "RetentionsMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no retentions or window specified",
+		},
+		{
+			name: "RetentionZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithRetentions(map[string]time.Duration{"t_attestations": 0}),
+			},
+			err: "problem with parameters: retention for t_attestations must be positive",
+		},
+		{
+			name: "TableNotPrunable",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithRetentions(map[string]time.Duration{"t_validators": time.Hour}),
+			},
+			err: "table t_validators cannot be pruned",
+		},
+		{
+			name: "BlocksWithoutWindow",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithRetentions(map[string]time.Duration{"t_blocks": time.Hour}),
+			},
+			err: "table t_blocks can only be pruned with a rolling window",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithScheduler(scheduler),
+				standard.WithInterval(time.Hour),
+				standard.WithRetentions(map[string]time.Duration{
+					"t_attestations":       90 * 24 * time.Hour,
+					"t_validator_balances": 30 * 24 * time.Hour,
+				}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("PrunableTables", []string{
+		"t_attestations",
+		"t_beacon_committees",
+		"t_block_arrivals",
+		"t_block_clients",
+		"t_blocks",
+		"t_gossip_attestations",
+		"t_missed_slots",
+		"t_proposer_duties",
+		"t_sync_aggregates",
+		"t_validator_balances",
+	})
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	// A window requires no retentions, and allows blocks to be pruned.
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithChainTime(mockchaintime.New()),
+		standard.WithScheduler(scheduler),
+		standard.WithWindow(64),
+		standard.WithRetentions(map[string]time.Duration{"t_blocks": time.Hour}),
+	)
+	require.NoError(t, err)
+}
@@ -0,0 +1,119 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_relays"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	latestSlot           prometheus.Gauge
+	blocksAttributed     *prometheus.CounterVec
+	relayRequests        *prometheus.CounterVec
+	registeredValidators *prometheus.GaugeVec
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_slot",
+		Help:      "Latest slot for which blocks have been attributed",
+	})
+	if err := registerer.Register(m.latestSlot); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_slot")
+	}
+
+	m.blocksAttributed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "blocks_total",
+		Help:      "Number of blocks attributed",
+	}, []string{"builder"})
+	if err := registerer.Register(m.blocksAttributed); err != nil {
+		return nil, errors.Wrap(err, "failed to register blocks_total")
+	}
+
+	m.relayRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of requests for data made to relays",
+	}, []string{"relay", "result"})
+	if err := registerer.Register(m.relayRequests); err != nil {
+		return nil, errors.Wrap(err, "failed to register requests_total")
+	}
+
+	m.registeredValidators = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "registered_validators",
+		Help:      "Number of validators registered with relays",
+	}, []string{"relay"})
+	if err := registerer.Register(m.registeredValidators); err != nil {
+		return nil, errors.Wrap(err, "failed to register registered_validators")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorSlotProcessed(slot phase0.Slot) {
+	if s.metrics.latestSlot != nil {
+		s.metrics.latestSlot.Set(float64(slot))
+	}
+}
+
+func (s *Service) monitorBlockAttributed(relayBuilt bool) {
+	if s.metrics.blocksAttributed != nil {
+		if relayBuilt {
+			s.metrics.blocksAttributed.WithLabelValues("relay").Inc()
+		} else {
+			s.metrics.blocksAttributed.WithLabelValues("local").Inc()
+		}
+	}
+}
+
+func (s *Service) monitorRelayRequest(relay string, succeeded bool) {
+	if s.metrics.relayRequests != nil {
+		if succeeded {
+			s.metrics.relayRequests.WithLabelValues(relay, "succeeded").Inc()
+		} else {
+			s.metrics.relayRequests.WithLabelValues(relay, "failed").Inc()
+		}
+	}
+}
+
+func (s *Service) monitorRegisteredValidators(relay string, validators int) {
+	if s.metrics.registeredValidators != nil {
+		s.metrics.registeredValidators.WithLabelValues(relay).Set(float64(validators))
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type parameters struct {
+	logLevel  zerolog.Level
+	network   string
+	monitor   metrics.Service
+	chainDB   chaindb.Service
+	scheduler scheduler.Service
+	addresses []string
+	interval  time.Duration
+	timeout   time.Duration
+	lag       phase0.Slot
+
+	registrations         bool
+	registrationsLabels   []string
+	registrationsInterval time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithAddresses sets the addresses of the relays from which to obtain data.
+func WithAddresses(addresses []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.addresses = addresses
+	})
+}
+
+// WithInterval sets the interval between checks for new blocks.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithTimeout sets the timeout for requests to relays.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithLag sets the number of slots behind the latest block at which blocks are attributed,
+// giving relays time to report the payloads that they delivered.
+func WithLag(lag phase0.Slot) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lag = lag
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+// WithRegistrations sets whether to obtain the registrations of validators with the relays.
+func WithRegistrations(registrations bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.registrations = registrations
+	})
+}
+
+// WithRegistrationsLabels sets the labels of the validators whose registrations are obtained.
+func WithRegistrationsLabels(labels []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.registrationsLabels = labels
+	})
+}
+
+// WithRegistrationsInterval sets the interval between obtaining the registrations of validators.
+func WithRegistrationsInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.registrationsInterval = interval
+	})
+}
+
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		interval: 5 * time.Minute,
+		timeout:  30 * time.Second,
+		lag:      64,
+
+		registrationsInterval: time.Hour,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if _, isProvider := parameters.chainDB.(chaindb.BlocksProvider); !isProvider {
+		return nil, errors.New("chain database does not provide blocks")
+	}
+	if _, isSetter := parameters.chainDB.(chaindb.BlockRelaysSetter); !isSetter {
+		return nil, errors.New("chain database does not support block relay setting")
+	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if len(parameters.addresses) == 0 {
+		return nil, errors.New("no relay addresses specified")
+	}
+	names := make(map[string]bool, len(parameters.addresses))
+	for _, address := range parameters.addresses {
+		relayURL, err := url.Parse(address)
+		if err != nil || relayURL.Host == "" || (relayURL.Scheme != "http" && relayURL.Scheme != "https") {
+			return nil, fmt.Errorf("invalid relay address %s", address)
+		}
+		if names[relayURL.Host] {
+			return nil, fmt.Errorf("duplicate relay %s", relayURL.Host)
+		}
+		names[relayURL.Host] = true
+	}
+	if parameters.interval < time.Minute {
+		return nil, errors.New("interval must be at least 1 minute")
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("no timeout specified")
+	}
+	if parameters.registrations {
+		if _, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider); !isProvider {
+			return nil, errors.New("chain database does not provide validators")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.ValidatorRegistrationsSetter); !isSetter {
+			return nil, errors.New("chain database does not support validator registration setting")
+		}
+		if len(parameters.registrationsLabels) > 0 {
+			if _, isProvider := parameters.chainDB.(chaindb.ValidatorLabelsProvider); !isProvider {
+				return nil, errors.New("chain database does not provide validator labels")
+			}
+		}
+		if parameters.registrationsInterval < time.Minute {
+			return nil, errors.New("registrations interval must be at least 1 minute")
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,180 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// registrationsConcurrency is the number of validator registrations requested from a relay at a time.
+const registrationsConcurrency = 16
+
+// farFutureEpoch is the exit epoch of validators that have not exited.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// updateRegistrations obtains the current registrations of validators with each relay.
+func (s *Service) updateRegistrations(ctx context.Context) {
+	if !s.registrationsMu.TryLock() {
+		s.log.Debug().Msg("Registrations update already in progress")
+		return
+	}
+	defer s.registrationsMu.Unlock()
+
+	validators, err := s.registrationValidators(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain validators")
+		return
+	}
+	if len(validators) == 0 {
+		s.log.Trace().Msg("No validators; nothing to do")
+		return
+	}
+
+	for _, relay := range s.relays {
+		if ctx.Err() != nil {
+			return
+		}
+		registrations, err := s.relayRegistrations(ctx, relay, validators)
+		if err != nil {
+			s.log.Error().Str("relay", relay.name).Err(err).Msg("Failed to obtain validator registrations")
+			continue
+		}
+		if err := s.storeRegistrations(ctx, registrations); err != nil {
+			s.log.Error().Str("relay", relay.name).Err(err).Msg("Failed to store validator registrations")
+			continue
+		}
+		s.monitorRegisteredValidators(relay.name, len(registrations))
+		s.log.Trace().Str("relay", relay.name).Int("registrations", len(registrations)).Msg("Obtained validator registrations")
+	}
+}
+
+// registrationValidators provides the validators whose registrations are obtained: those with
+// the configured labels if any, otherwise all, excluding validators that have exited.
+func (s *Service) registrationValidators(ctx context.Context) ([]*chaindb.Validator, error) {
+	var validators []*chaindb.Validator
+	if len(s.registrationsLabels) > 0 {
+		indices, err := s.validatorLabelsProvider.ValidatorsByLabel(ctx, s.registrationsLabels)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain labelled validators")
+		}
+		if len(indices) == 0 {
+			return []*chaindb.Validator{}, nil
+		}
+		labelledValidators, err := s.validatorsProvider.ValidatorsByIndex(ctx, indices)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		validators = make([]*chaindb.Validator, 0, len(labelledValidators))
+		for _, index := range indices {
+			if validator, exists := labelledValidators[index]; exists {
+				validators = append(validators, validator)
+			}
+		}
+	} else {
+		var err error
+		validators, err = s.validatorsProvider.Validators(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+	}
+
+	res := make([]*chaindb.Validator, 0, len(validators))
+	for _, validator := range validators {
+		if validator.ExitEpoch == farFutureEpoch {
+			res = append(res, validator)
+		}
+	}
+
+	return res, nil
+}
+
+// relayRegistrations obtains the registrations of the given validators with a relay.
+func (s *Service) relayRegistrations(ctx context.Context,
+	relay *relay,
+	validators []*chaindb.Validator,
+) (
+	[]*chaindb.ValidatorRegistration,
+	error,
+) {
+	registrations := make([]*chaindb.ValidatorRegistration, 0)
+	var registrationsMu sync.Mutex
+	var firstErr error
+
+	sem := make(chan struct{}, registrationsConcurrency)
+	var wg sync.WaitGroup
+	for _, validator := range validators {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(validator *chaindb.Validator) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			registration, err := relay.validatorRegistration(ctx, validator.PublicKey)
+			s.monitorRelayRequest(relay.name, err == nil)
+			registrationsMu.Lock()
+			defer registrationsMu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "failed to obtain registration of validator %d", validator.Index)
+				}
+				return
+			}
+			if registration == nil {
+				return
+			}
+			registrations = append(registrations, &chaindb.ValidatorRegistration{
+				ValidatorIndex: validator.Index,
+				Relay:          relay.name,
+				Timestamp:      registration.timestamp,
+				FeeRecipient:   registration.feeRecipient,
+				GasLimit:       registration.gasLimit,
+			})
+		}(validator)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return registrations, nil
+}
+
+// storeRegistrations stores validator registrations in a single transaction.
+func (s *Service) storeRegistrations(ctx context.Context, registrations []*chaindb.ValidatorRegistration) error {
+	if len(registrations) == 0 {
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.validatorRegistrationsSetter.SetValidatorRegistrations(ctx, registrations); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator registrations")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
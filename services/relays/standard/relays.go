@@ -0,0 +1,295 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// deliveredPayloadsLimit is the maximum number of delivered payloads requested from a relay at a time.
+const deliveredPayloadsLimit = 200
+
+// relay is a relay from which data is obtained.
+type relay struct {
+	// name is the name of the relay, being the host of its address.
+	name    string
+	address string
+	client  *http.Client
+	timeout time.Duration
+}
+
+// deliveredPayload is a payload delivered by a relay to a proposer.
+type deliveredPayload struct {
+	slot          phase0.Slot
+	blockHash     [32]byte
+	builderPubKey phase0.BLSPubKey
+	value         *big.Int
+}
+
+// bidTraceJSON is the JSON representation of a delivered payload in the relay data API.
+type bidTraceJSON struct {
+	Slot          string `json:"slot"`
+	BlockHash     string `json:"block_hash"`
+	BuilderPubKey string `json:"builder_pubkey"`
+	Value         string `json:"value"`
+}
+
+// newRelay creates a relay with the given address.
+func newRelay(address string, timeout time.Duration) (*relay, error) {
+	relayURL, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid relay address")
+	}
+
+	return &relay{
+		name:    relayURL.Host,
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{},
+		timeout: timeout,
+	}, nil
+}
+
+// deliveredPayloads fetches the payloads delivered by the relay in the given slot range.
+// Ranges are inclusive of start and exclusive of end.
+func (r *relay) deliveredPayloads(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*deliveredPayload, error) {
+	payloads := make([]*deliveredPayload, 0)
+	if endSlot <= startSlot {
+		return payloads, nil
+	}
+
+	// Payloads are returned latest first, at or before the cursor.
+	seen := make(map[[32]byte]bool)
+	cursor := endSlot - 1
+	for {
+		page, err := r.deliveredPayloadsPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		earliestSlot := cursor
+		for _, payload := range page {
+			if payload.slot < earliestSlot {
+				earliestSlot = payload.slot
+			}
+			if payload.slot < startSlot || payload.slot > cursor || seen[payload.blockHash] {
+				continue
+			}
+			seen[payload.blockHash] = true
+			payloads = append(payloads, payload)
+		}
+		if len(page) < deliveredPayloadsLimit || earliestSlot < startSlot {
+			break
+		}
+		// The page may have ended part way through the payloads of its earliest slot, so
+		// start the next page at that slot unless the entire page was at the cursor.
+		if earliestSlot == cursor {
+			if cursor == startSlot {
+				break
+			}
+			earliestSlot--
+		}
+		cursor = earliestSlot
+	}
+
+	return payloads, nil
+}
+
+// deliveredPayloadsPage fetches a single page of payloads delivered by the relay at or before the cursor.
+func (r *relay) deliveredPayloadsPage(ctx context.Context, cursor phase0.Slot) ([]*deliveredPayload, error) {
+	opCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(opCtx,
+		http.MethodGet,
+		fmt.Sprintf("%s/relay/v1/data/bidtraces/proposer_payload_delivered?cursor=%d&limit=%d", r.address, cursor, deliveredPayloadsLimit),
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GET request")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call GET endpoint")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("GET failed with status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("GET failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	traces := make([]*bidTraceJSON, 0)
+	if err := json.NewDecoder(resp.Body).Decode(&traces); err != nil {
+		return nil, errors.Wrap(err, "failed to parse delivered payloads")
+	}
+
+	payloads := make([]*deliveredPayload, 0, len(traces))
+	for _, trace := range traces {
+		payload, err := trace.unpack()
+		if err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+// unpack converts the JSON representation of a delivered payload.
+func (b *bidTraceJSON) unpack() (*deliveredPayload, error) {
+	slot, err := strconv.ParseUint(b.Slot, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid slot")
+	}
+	payload := &deliveredPayload{
+		slot: phase0.Slot(slot),
+	}
+
+	blockHash, err := hex.DecodeString(strings.TrimPrefix(b.BlockHash, "0x"))
+	if err != nil || len(blockHash) != len(payload.blockHash) {
+		return nil, fmt.Errorf("invalid block hash %s", b.BlockHash)
+	}
+	copy(payload.blockHash[:], blockHash)
+
+	builderPubKey, err := hex.DecodeString(strings.TrimPrefix(b.BuilderPubKey, "0x"))
+	if err != nil || len(builderPubKey) != len(payload.builderPubKey) {
+		return nil, fmt.Errorf("invalid builder public key %s", b.BuilderPubKey)
+	}
+	copy(payload.builderPubKey[:], builderPubKey)
+
+	value, success := new(big.Int).SetString(b.Value, 10)
+	if !success {
+		return nil, fmt.Errorf("invalid value %s", b.Value)
+	}
+	payload.value = value
+
+	return payload, nil
+}
+
+// validatorRegistration is a registration of a validator with a relay.
+type validatorRegistration struct {
+	pubKey       phase0.BLSPubKey
+	timestamp    time.Time
+	feeRecipient [20]byte
+	gasLimit     uint64
+}
+
+// signedValidatorRegistrationJSON is the JSON representation of a validator registration in the relay data API.
+type signedValidatorRegistrationJSON struct {
+	Message *validatorRegistrationJSON `json:"message"`
+}
+
+// validatorRegistrationJSON is the JSON representation of the message of a validator registration.
+type validatorRegistrationJSON struct {
+	FeeRecipient string `json:"fee_recipient"`
+	GasLimit     string `json:"gas_limit"`
+	Timestamp    string `json:"timestamp"`
+	PubKey       string `json:"pubkey"`
+}
+
+// validatorRegistration fetches the latest registration of the validator with the relay,
+// or nil if the validator has not registered with the relay.
+func (r *relay) validatorRegistration(ctx context.Context, pubKey phase0.BLSPubKey) (*validatorRegistration, error) {
+	opCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(opCtx,
+		http.MethodGet,
+		fmt.Sprintf("%s/relay/v1/data/validator_registration?pubkey=%#x", r.address, pubKey),
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create GET request")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call GET endpoint")
+	}
+	defer resp.Body.Close()
+
+	// Relays return not found or bad request for validators that have not registered.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("GET failed with status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("GET failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	signedRegistration := &signedValidatorRegistrationJSON{}
+	if err := json.NewDecoder(resp.Body).Decode(signedRegistration); err != nil {
+		return nil, errors.Wrap(err, "failed to parse validator registration")
+	}
+	if signedRegistration.Message == nil {
+		return nil, errors.New("validator registration missing message")
+	}
+	registration, err := signedRegistration.Message.unpack()
+	if err != nil {
+		return nil, err
+	}
+	if registration.pubKey != pubKey {
+		return nil, fmt.Errorf("validator registration for incorrect public key %#x", registration.pubKey)
+	}
+
+	return registration, nil
+}
+
+// unpack converts the JSON representation of a validator registration.
+func (v *validatorRegistrationJSON) unpack() (*validatorRegistration, error) {
+	registration := &validatorRegistration{}
+
+	feeRecipient, err := hex.DecodeString(strings.TrimPrefix(v.FeeRecipient, "0x"))
+	if err != nil || len(feeRecipient) != len(registration.feeRecipient) {
+		return nil, fmt.Errorf("invalid fee recipient %s", v.FeeRecipient)
+	}
+	copy(registration.feeRecipient[:], feeRecipient)
+
+	gasLimit, err := strconv.ParseUint(v.GasLimit, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid gas limit")
+	}
+	registration.gasLimit = gasLimit
+
+	timestamp, err := strconv.ParseInt(v.Timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid timestamp")
+	}
+	registration.timestamp = time.Unix(timestamp, 0)
+
+	pubKey, err := hex.DecodeString(strings.TrimPrefix(v.PubKey, "0x"))
+	if err != nil || len(pubKey) != len(registration.pubKey) {
+		return nil, fmt.Errorf("invalid public key %s", v.PubKey)
+	}
+	copy(registration.pubKey[:], pubKey)
+
+	return registration, nil
+}
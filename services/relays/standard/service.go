@@ -0,0 +1,282 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// chunkSlots is the number of slots processed in each transaction.
+const chunkSlots = phase0.Slot(256)
+
+// Service is a relay data service.
+type Service struct {
+	log               zerolog.Logger
+	metrics           *serviceMetrics
+	chainDB           chaindb.Service
+	blocksProvider    chaindb.BlocksProvider
+	blockRelaysSetter chaindb.BlockRelaysSetter
+	relays            []*relay
+	interval          time.Duration
+	lag               phase0.Slot
+	updateMu          sync.Mutex
+
+	validatorsProvider           chaindb.ValidatorsProvider
+	validatorLabelsProvider      chaindb.ValidatorLabelsProvider
+	validatorRegistrationsSetter chaindb.ValidatorRegistrationsSetter
+	registrationsLabels          []string
+	registrationsInterval        time.Duration
+	registrationsMu              sync.Mutex
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "relays", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	relays := make([]*relay, 0, len(parameters.addresses))
+	for _, address := range parameters.addresses {
+		relay, err := newRelay(address, parameters.timeout)
+		if err != nil {
+			return nil, err
+		}
+		relays = append(relays, relay)
+	}
+
+	s := &Service{
+		log:               log,
+		metrics:           svcMetrics,
+		chainDB:           parameters.chainDB,
+		blocksProvider:    parameters.chainDB.(chaindb.BlocksProvider),
+		blockRelaysSetter: parameters.chainDB.(chaindb.BlockRelaysSetter),
+		relays:            relays,
+		interval:          parameters.interval,
+		lag:               parameters.lag,
+	}
+	if parameters.registrations {
+		s.validatorsProvider = parameters.chainDB.(chaindb.ValidatorsProvider)
+		s.validatorRegistrationsSetter = parameters.chainDB.(chaindb.ValidatorRegistrationsSetter)
+		if len(parameters.registrationsLabels) > 0 {
+			s.validatorLabelsProvider = parameters.chainDB.(chaindb.ValidatorLabelsProvider)
+			s.registrationsLabels = parameters.registrationsLabels
+		}
+		s.registrationsInterval = parameters.registrationsInterval
+	}
+
+	// Update immediately, and then at the configured interval.
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.interval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.update(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "relays", "obtain relay data",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic relay data")
+	}
+	go s.update(ctx)
+
+	if parameters.registrations {
+		registrationsRuntimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+			s := data.(*Service)
+			return time.Now().Add(s.registrationsInterval), nil
+		}
+		registrationsJobFunc := func(ctx context.Context, data interface{}) {
+			s := data.(*Service)
+			s.updateRegistrations(ctx)
+		}
+		if err := parameters.scheduler.SchedulePeriodicJob(ctx, "relays", "obtain validator registrations",
+			registrationsRuntimeFunc,
+			s,
+			registrationsJobFunc,
+			s,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to set up periodic validator registrations")
+		}
+		go s.updateRegistrations(ctx)
+	}
+
+	return s, nil
+}
+
+// update attributes all blocks that have not yet been processed.
+func (s *Service) update(ctx context.Context) {
+	if !s.updateMu.TryLock() {
+		s.log.Debug().Msg("Update already in progress")
+		return
+	}
+	defer s.updateMu.Unlock()
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	latestBlocks, err := s.blocksProvider.LatestBlocks(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to obtain latest blocks")
+		return
+	}
+	if len(latestBlocks) == 0 {
+		s.log.Trace().Msg("No blocks; nothing to do")
+		return
+	}
+	// Blocks close to the head may not yet have been reported by the relays that delivered
+	// them, and would be marked as built locally without being checked again.
+	if latestBlocks[0].Slot < s.lag {
+		s.log.Trace().Msg("No blocks beyond lag; nothing to do")
+		return
+	}
+	latestSlot := latestBlocks[0].Slot - s.lag
+
+	for startSlot := md.NextSlot; startSlot <= latestSlot; startSlot += chunkSlots {
+		if ctx.Err() != nil {
+			return
+		}
+		endSlot := startSlot + chunkSlots
+		if endSlot > latestSlot+1 {
+			endSlot = latestSlot + 1
+		}
+		blockRelays, err := s.attributeSlots(ctx, startSlot, endSlot)
+		if err != nil {
+			s.log.Error().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Err(err).Msg("Failed to attribute blocks")
+			return
+		}
+		if err := s.store(ctx, md, endSlot, blockRelays); err != nil {
+			s.log.Error().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Err(err).Msg("Failed to store block relays")
+			return
+		}
+		for _, blockRelay := range blockRelays {
+			s.monitorBlockAttributed(len(blockRelay.Relays) > 0)
+		}
+		s.monitorSlotProcessed(endSlot - 1)
+		s.log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Int("blocks", len(blockRelays)).Msg("Attributed blocks")
+	}
+}
+
+// attributeSlots attributes the execution payloads of the blocks in the given slot range to
+// the relays that delivered them.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) attributeSlots(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.BlockRelays, error) {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	// Blocks prior to the merge have no payloads to attribute, in which case the relays are not queried.
+	payloadBlocks := make([]*chaindb.Block, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ExecutionPayload != nil && block.ExecutionPayload.BlockHash != [32]byte{} {
+			payloadBlocks = append(payloadBlocks, block)
+		}
+	}
+	if len(payloadBlocks) == 0 {
+		return []*chaindb.BlockRelays{}, nil
+	}
+
+	delivered := make(map[[32]byte]*chaindb.BlockRelays)
+	for _, relay := range s.relays {
+		payloads, err := relay.deliveredPayloads(ctx, startSlot, endSlot)
+		s.monitorRelayRequest(relay.name, err == nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain delivered payloads from %s", relay.name)
+		}
+		for _, payload := range payloads {
+			blockRelays, exists := delivered[payload.blockHash]
+			if !exists {
+				builderPubKey := payload.builderPubKey
+				blockRelays = &chaindb.BlockRelays{
+					Relays:        make([]string, 0, 1),
+					BuilderPubKey: &builderPubKey,
+					Value:         payload.value,
+				}
+				delivered[payload.blockHash] = blockRelays
+			}
+			blockRelays.Relays = append(blockRelays.Relays, relay.name)
+		}
+	}
+
+	res := make([]*chaindb.BlockRelays, 0, len(payloadBlocks))
+	for _, block := range payloadBlocks {
+		blockRelays, exists := delivered[block.ExecutionPayload.BlockHash]
+		if !exists {
+			// Not delivered by any relay, so built locally.
+			blockRelays = &chaindb.BlockRelays{
+				Relays: []string{},
+			}
+		}
+		sort.Strings(blockRelays.Relays)
+		blockRelays.BlockRoot = block.Root
+		res = append(res, blockRelays)
+	}
+
+	return res, nil
+}
+
+// store stores the block relays, along with the metadata, in a single transaction.
+func (s *Service) store(ctx context.Context,
+	md *metadata,
+	nextSlot phase0.Slot,
+	blockRelays []*chaindb.BlockRelays,
+) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if len(blockRelays) > 0 {
+		if err := s.blockRelaysSetter.SetBlockRelays(ctx, blockRelays); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set block relays")
+		}
+	}
+
+	md.NextSlot = nextSlot
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
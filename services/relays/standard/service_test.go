@@ -0,0 +1,299 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/relays/standard"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"https://relay.example.com"}),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithAddresses([]string{"https://relay.example.com"}),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "AddressesMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+			},
+			err: "problem with parameters: no relay addresses specified",
+		},
+		{
+			name: "AddressInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"relay.example.com"}),
+			},
+			err: "problem with parameters: invalid relay address relay.example.com",
+		},
+		{
+			name: "AddressDuplicate",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"https://relay.example.com", "https://relay.example.com/"}),
+			},
+			err: "problem with parameters: duplicate relay relay.example.com",
+		},
+		{
+			name: "IntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"https://relay.example.com"}),
+				standard.WithInterval(time.Second),
+			},
+			err: "problem with parameters: interval must be at least 1 minute",
+		},
+		{
+			name: "RegistrationsIntervalTooShort",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"https://relay.example.com"}),
+				standard.WithRegistrations(true),
+				standard.WithRegistrationsInterval(time.Second),
+			},
+			err: "problem with parameters: registrations interval must be at least 1 minute",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithScheduler(scheduler),
+				standard.WithAddresses([]string{"https://relay.example.com"}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// relayHandler serves delivered payloads for blocks with the given hashes at slot 2.
+func relayHandler(blockHashes ...byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/relay/v1/data/bidtraces/proposer_payload_delivered" {
+			http.NotFound(w, r)
+			return
+		}
+		body := "["
+		for i, blockHash := range blockHashes {
+			if i > 0 {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"slot":"2","block_hash":"%#064x","builder_pubkey":"%#096x","value":"1000"}`, blockHash, 0xb1)
+		}
+		body += "]"
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	relay1 := httptest.NewServer(relayHandler(0x01))
+	defer relay1.Close()
+	relay2 := httptest.NewServer(relayHandler(0x01, 0x03))
+	defer relay2.Close()
+
+	blocks := []*chaindb.Block{
+		{
+			// Prior to the merge.
+			Slot: 1,
+			Root: phase0.Root{0x01},
+		},
+		{
+			// Delivered by both relays.
+			Slot: 2,
+			Root: phase0.Root{0x02},
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				BlockHash: [32]byte{31: 0x01},
+			},
+		},
+		{
+			// Built locally.
+			Slot: 3,
+			Root: phase0.Root{0x03},
+			ExecutionPayload: &chaindb.ExecutionPayload{
+				BlockHash: [32]byte{31: 0x02},
+			},
+		},
+	}
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("LatestBlocks", blocks[2:])
+	chainDB.SetResponse("BlocksForSlotRange", blocks)
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithScheduler(scheduler),
+		standard.WithAddresses([]string{relay1.URL, relay2.URL}),
+		standard.WithLag(0),
+	)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(chainDB.CallsTo("SetMetadata")) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	calls := chainDB.CallsTo("SetBlockRelays")
+	require.Len(t, calls, 1)
+	blockRelays := calls[0].Args[0].([]*chaindb.BlockRelays)
+	require.Len(t, blockRelays, 2)
+	require.Equal(t, phase0.Root{0x02}, blockRelays[0].BlockRoot)
+	require.Len(t, blockRelays[0].Relays, 2)
+	require.Equal(t, phase0.BLSPubKey{47: 0xb1}, *blockRelays[0].BuilderPubKey)
+	require.Equal(t, big.NewInt(1000), blockRelays[0].Value)
+	require.Equal(t, phase0.Root{0x03}, blockRelays[1].BlockRoot)
+	require.Empty(t, blockRelays[1].Relays)
+	require.Nil(t, blockRelays[1].BuilderPubKey)
+}
+
+// registrationHandler serves registrations for validators whose public keys start with 0x01.
+func registrationHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/relay/v1/data/bidtraces/proposer_payload_delivered":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	case "/relay/v1/data/validator_registration":
+		pubKey := r.URL.Query().Get("pubkey")
+		if !strings.HasPrefix(pubKey, "0x01") {
+			http.Error(w, `{"code":400,"message":"no registration found for validator"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"message":{"fee_recipient":"%#040x","gas_limit":"30000000","timestamp":"1663000000","pubkey":"%s"},"signature":"0x00"}`, 0xfe, pubKey)))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRegistrations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	relay := httptest.NewServer(http.HandlerFunc(registrationHandler))
+	defer relay.Close()
+
+	chainDB := mockchaindb.New()
+	chainDB.SetResponse("Validators", []*chaindb.Validator{
+		{
+			// Registered.
+			Index:     1,
+			PublicKey: phase0.BLSPubKey{0x01},
+			ExitEpoch: 0xffffffffffffffff,
+		},
+		{
+			// Not registered.
+			Index:     2,
+			PublicKey: phase0.BLSPubKey{0x02},
+			ExitEpoch: 0xffffffffffffffff,
+		},
+		{
+			// Exited.
+			Index:     3,
+			PublicKey: phase0.BLSPubKey{0x01, 0x03},
+			ExitEpoch: 100,
+		},
+	})
+	scheduler, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(zerolog.Disabled),
+	)
+	require.NoError(t, err)
+
+	_, err = standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithScheduler(scheduler),
+		standard.WithAddresses([]string{relay.URL}),
+		standard.WithRegistrations(true),
+	)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(chainDB.CallsTo("SetValidatorRegistrations")) > 0 }, 5*time.Second, 10*time.Millisecond)
+
+	calls := chainDB.CallsTo("SetValidatorRegistrations")
+	require.Len(t, calls, 1)
+	registrations := calls[0].Args[0].([]*chaindb.ValidatorRegistration)
+	require.Len(t, registrations, 1)
+	require.Equal(t, phase0.ValidatorIndex(1), registrations[0].ValidatorIndex)
+	require.Equal(t, strings.TrimPrefix(relay.URL, "http://"), registrations[0].Relay)
+	require.Equal(t, [20]byte{19: 0xfe}, registrations[0].FeeRecipient)
+	require.Equal(t, uint64(30000000), registrations[0].GasLimit)
+	require.Equal(t, time.Unix(1663000000, 0), registrations[0].Timestamp)
+}
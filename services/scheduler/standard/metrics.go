@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
@@ -22,79 +23,102 @@ import (
 
 var metricsNamespace = "scheduler"
 
-var schedulerJobsScheduled *prometheus.CounterVec
-var schedulerJobsCancelled *prometheus.CounterVec
-var schedulerJobsStarted *prometheus.CounterVec
+// serviceMetrics holds the metrics of the service for a network.
+type serviceMetrics struct {
+	schedulerJobsScheduled *prometheus.CounterVec
+	schedulerJobsCancelled *prometheus.CounterVec
+	schedulerJobsStarted   *prometheus.CounterVec
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if schedulerJobsScheduled != nil {
-		// Already registered.
-		return nil
-	}
+// networkMetrics holds the metrics registered for each network, which are shared
+// by all schedulers for the network.
+var (
+	networkMetrics   = make(map[string]*serviceMetrics)
+	networkMetricsMu sync.Mutex
+)
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		networkMetricsMu.Lock()
+		defer networkMetricsMu.Unlock()
+		if m, exists := networkMetrics[network]; exists {
+			// Already registered.
+			return m, nil
+		}
+		m, err := registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+		if err != nil {
+			return nil, err
+		}
+		networkMetrics[network] = m
+		return m, nil
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
-func registerPrometheusMetrics(_ context.Context) error {
-	schedulerJobsScheduled = prometheus.NewCounterVec(prometheus.CounterOpts{
+func registerPrometheusMetrics(_ context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.schedulerJobsScheduled = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "jobs",
 		Name:      "scheduled_total",
 		Help:      "The number of jobs scheduled.",
 	}, []string{"class"})
-	if err := prometheus.Register(schedulerJobsScheduled); err != nil {
-		return err
+	if err := registerer.Register(m.schedulerJobsScheduled); err != nil {
+		return nil, err
 	}
 
-	schedulerJobsCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	m.schedulerJobsCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "jobs",
 		Name:      "cancelled_total",
 		Help:      "The number of scheduled jobs cancelled.",
 	}, []string{"class"})
-	if err := prometheus.Register(schedulerJobsCancelled); err != nil {
-		return err
+	if err := registerer.Register(m.schedulerJobsCancelled); err != nil {
+		return nil, err
 	}
 
-	schedulerJobsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
+	m.schedulerJobsStarted = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: "jobs",
 		Name:      "started_total",
 		Help:      "The number of scheduled jobs started.",
 	}, []string{"class", "trigger"})
-	return prometheus.Register(schedulerJobsStarted)
+	if err := registerer.Register(m.schedulerJobsStarted); err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
 
 // jobScheduled is called when a job is scheduled.
-func jobScheduled(class string) {
-	if schedulerJobsScheduled != nil {
-		schedulerJobsScheduled.WithLabelValues(class).Inc()
+func (s *Service) jobScheduled(class string) {
+	if s.metrics.schedulerJobsScheduled != nil {
+		s.metrics.schedulerJobsScheduled.WithLabelValues(class).Inc()
 	}
 }
 
 // jobCancelled is called when a scheduled job is cancelled.
-func jobCancelled(class string) {
-	if schedulerJobsCancelled != nil {
-		schedulerJobsCancelled.WithLabelValues(class).Inc()
+func (s *Service) jobCancelled(class string) {
+	if s.metrics.schedulerJobsCancelled != nil {
+		s.metrics.schedulerJobsCancelled.WithLabelValues(class).Inc()
 	}
 }
 
 // jobStartedOnTimer is called when a scheduled job is started due to meeting its time.
-func jobStartedOnTimer(class string) {
-	if schedulerJobsScheduled != nil {
-		schedulerJobsStarted.WithLabelValues(class, "timer").Inc()
+func (s *Service) jobStartedOnTimer(class string) {
+	if s.metrics.schedulerJobsScheduled != nil {
+		s.metrics.schedulerJobsStarted.WithLabelValues(class, "timer").Inc()
 	}
 }
 
 // jobStartedOnSignal is called when a scheduled job is started due to being manually signalled.
-func jobStartedOnSignal(class string) {
-	if schedulerJobsStarted != nil {
-		schedulerJobsStarted.WithLabelValues(class, "signal").Inc()
+func (s *Service) jobStartedOnSignal(class string) {
+	if s.metrics.schedulerJobsStarted != nil {
+		s.metrics.schedulerJobsStarted.WithLabelValues(class, "signal").Inc()
 	}
 }
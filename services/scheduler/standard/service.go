@@ -20,15 +20,12 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/sasha-s/go-deadlock"
 	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 	"go.uber.org/atomic"
 )
 
-// module-wide log.
-var log zerolog.Logger
-
 // job contains control points for a job.
 type job struct {
 	// stateLock is required for active or finalised.
@@ -44,6 +41,8 @@ type job struct {
 // the state of each job, in an attempt to ensure additional robustness in the face
 // of high concurrent load.
 type Service struct {
+	log       zerolog.Logger
+	metrics   *serviceMetrics
 	jobs      map[string]*job
 	jobsMutex deadlock.RWMutex
 }
@@ -56,17 +55,17 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "scheduler").Str("impl", "advanced").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
-	}
+	log := util.NetworkServiceLogger(parameters.network, "scheduler", "advanced", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
 	return &Service{
-		jobs: make(map[string]*job),
+		log:     log,
+		metrics: svcMetrics,
+		jobs:    make(map[string]*job),
 	}, nil
 }
 
@@ -99,47 +98,47 @@ func (s *Service) ScheduleJob(ctx context.Context,
 	}
 	s.jobs[name] = job
 	s.jobsMutex.Unlock()
-	jobScheduled(class)
+	s.jobScheduled(class)
 
-	log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
+	s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
 	go func() {
 		select {
 		case <-ctx.Done():
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
 			s.jobsMutex.Lock()
 			delete(s.jobs, name)
 			s.jobsMutex.Unlock()
 			finaliseJob(job)
-			jobCancelled(class)
+			s.jobCancelled(class)
 		case <-job.cancelCh:
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
 			// If we receive this signal the job has already been deleted from the jobs list so no need to
 			// do so again here.
 			finaliseJob(job)
-			jobCancelled(class)
+			s.jobCancelled(class)
 		case <-job.runCh:
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
 			// If we receive this signal the job has already been deleted from the jobs list so no need to
 			// do so again here.
-			jobStartedOnSignal(class)
+			s.jobStartedOnSignal(class)
 			jobFunc(ctx, data)
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 			finaliseJob(job)
 			job.active.Store(false)
 		case <-time.After(time.Until(runtime)):
 			// It is possible that the job is already active, so check that first before proceeding.
 			if job.active.Load() {
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
 				break
 			}
 			s.jobsMutex.Lock()
 			delete(s.jobs, name)
 			s.jobsMutex.Unlock()
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
 			job.active.Store(true)
-			jobStartedOnTimer(class)
+			s.jobStartedOnTimer(class)
 			jobFunc(ctx, data)
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 			job.active.Store(false)
 			finaliseJob(job)
 		}
@@ -184,60 +183,60 @@ func (s *Service) SchedulePeriodicJob(ctx context.Context,
 	}
 	s.jobs[name] = job
 	s.jobsMutex.Unlock()
-	jobScheduled(class)
+	s.jobScheduled(class)
 
 	go func() {
 		for {
 			runtime, err := runtimeFunc(ctx, runtimeData)
 			if err == scheduler.ErrNoMoreInstances {
-				log.Trace().Str("job", name).Msg("No more instances; period job stopping")
+				s.log.Trace().Str("job", name).Msg("No more instances; period job stopping")
 				s.jobsMutex.Lock()
 				delete(s.jobs, name)
 				s.jobsMutex.Unlock()
 				finaliseJob(job)
-				jobCancelled(class)
+				s.jobCancelled(class)
 				return
 			}
 			if err != nil {
-				log.Error().Str("job", name).Err(err).Msg("Failed to obtain runtime; periodic job stopping")
+				s.log.Error().Str("job", name).Err(err).Msg("Failed to obtain runtime; periodic job stopping")
 				s.jobsMutex.Lock()
 				delete(s.jobs, name)
 				s.jobsMutex.Unlock()
 				finaliseJob(job)
-				jobCancelled(class)
+				s.jobCancelled(class)
 				return
 			}
-			log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
+			s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Scheduled job")
 			select {
 			case <-ctx.Done():
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Parent context done; job not running")
 				s.jobsMutex.Lock()
 				delete(s.jobs, name)
 				s.jobsMutex.Unlock()
 				finaliseJob(job)
-				jobCancelled(class)
+				s.jobCancelled(class)
 				return
 			case <-job.cancelCh:
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Cancel triggered; job not running")
 				finaliseJob(job)
-				jobCancelled(class)
+				s.jobCancelled(class)
 				return
 			case <-job.runCh:
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
-				jobStartedOnSignal(class)
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Run triggered; job running")
+				s.jobStartedOnSignal(class)
 				jobFunc(ctx, jobData)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 				job.active.Store(false)
 			case <-time.After(time.Until(runtime)):
 				if job.active.Load() {
-					log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
+					s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Already running; job not running")
 					continue
 				}
 				job.active.Store(true)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
-				jobStartedOnTimer(class)
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Timer triggered; job running")
+				s.jobStartedOnTimer(class)
 				jobFunc(ctx, jobData)
-				log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
+				s.log.Trace().Str("job", name).Time("scheduled", runtime).Msg("Job complete")
 				job.active.Store(false)
 			}
 		}
@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awssecretsmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a secrets provider that reads secrets from AWS Secrets Manager.
+type Service struct {
+	client *secretsmanager.Client
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("secrets", "awssecretsmanager", parameters.logLevel)
+
+	options := make([]func(*config.LoadOptions) error, 0)
+	if parameters.region != "" {
+		options = append(options, config.WithRegion(parameters.region))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load AWS configuration")
+	}
+
+	client := secretsmanager.NewFromConfig(awsConfig, func(o *secretsmanager.Options) {
+		if parameters.endpoint != "" {
+			o.BaseEndpoint = &parameters.endpoint
+		}
+	})
+
+	return &Service{
+		client: client,
+	}, nil
+}
+
+// Name is the name of the provider, as used in references.
+func (*Service) Name() string {
+	return "aws"
+}
+
+// Secret fetches the current value of the secret with the given ID.  The ID is
+// the name or ARN of the secret, optionally followed by #field to select a field
+// of a secret that holds a JSON object.
+func (s *Service) Secret(ctx context.Context, path string) (string, error) {
+	id, field := secrets.SplitField(path)
+	output, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: &id,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain secret from AWS Secrets Manager")
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s does not have a string value", id)
+	}
+	log.Trace().Str("id", id).Str("field", field).Msg("Read secret")
+
+	if field == "" {
+		return *output.SecretString, nil
+	}
+
+	fields := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(*output.SecretString))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("secret %s is not a JSON object", id))
+	}
+	value, exists := fields[field]
+	if !exists {
+		return "", fmt.Errorf("field %s not found in secret %s", field, id)
+	}
+	if str, isString := value.(string); isString {
+		return str, nil
+	}
+
+	return fmt.Sprintf("%v", value), nil
+}
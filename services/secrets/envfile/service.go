@@ -0,0 +1,121 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envfile
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a secrets provider that reads secrets from a file of KEY=VALUE lines.
+// The file is read afresh for each secret, so that secrets rotated by rewriting
+// the file are picked up.
+type Service struct {
+	path string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("secrets", "envfile", parameters.logLevel)
+
+	if _, err := os.Stat(parameters.path); err != nil {
+		return nil, errors.Wrap(err, "failed to access environment file")
+	}
+
+	return &Service{
+		path: parameters.path,
+	}, nil
+}
+
+// Name is the name of the provider, as used in references.
+func (*Service) Name() string {
+	return "envfile"
+}
+
+// Secret fetches the current value of the secret with the given key.
+func (s *Service) Secret(_ context.Context, key string) (string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read environment file")
+	}
+
+	values, err := parse(data)
+	if err != nil {
+		return "", err
+	}
+	value, exists := values[key]
+	if !exists {
+		return "", fmt.Errorf("key %s not found in environment file", key)
+	}
+	log.Trace().Str("key", key).Msg("Read secret")
+
+	return value, nil
+}
+
+// parse parses the contents of an environment file.  Blank lines and lines
+// starting with # are ignored, and lines may be prefixed with "export".
+func parse(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		key, value, found := strings.Cut(text, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid entry at line %d of environment file", line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 {
+			switch value[0] {
+			case '"':
+				unquoted, err := strconv.Unquote(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid quoted value at line %d of environment file", line)
+				}
+				value = unquoted
+			case '\'':
+				if value[len(value)-1] == '\'' {
+					value = value[1 : len(value)-1]
+				}
+			}
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read environment file")
+	}
+
+	return values, nil
+}
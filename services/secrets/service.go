@@ -0,0 +1,56 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"strings"
+)
+
+// ReferencePrefix is the prefix of a configuration value that refers to a secret.
+// A reference has the form secret:<provider>:<path>, for example
+// secret:vault:secret/data/chaind#dsn.
+const ReferencePrefix = "secret:"
+
+// Service is the interface for a secrets service.
+type Service interface {
+	// Resolve resolves a value that may be a reference to a secret, returning the
+	// current value of the secret.  Values that are not references are returned
+	// unchanged.
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// Provider is the interface for a store of secrets.
+type Provider interface {
+	// Name is the name of the provider, as used in references.
+	Name() string
+
+	// Secret fetches the current value of the secret at the given path.
+	Secret(ctx context.Context, path string) (string, error)
+}
+
+// IsReference returns true if the value is a reference to a secret.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefix)
+}
+
+// SplitField splits a path of the form <name>#<field> in to its name and field.
+// The field is empty if the path does not contain one.
+func SplitField(path string) (string, string) {
+	if idx := strings.LastIndex(path, "#"); idx != -1 {
+		return path[:idx], path[idx+1:]
+	}
+
+	return path, ""
+}
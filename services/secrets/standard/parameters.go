@@ -0,0 +1,102 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/secrets"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	scheduler       scheduler.Service
+	providers       []secrets.Provider
+	refreshInterval time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithScheduler sets the scheduler for this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithProviders sets the providers of secrets.
+func WithProviders(providers []secrets.Provider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.providers = providers
+	})
+}
+
+// WithRefreshInterval sets the interval between refreshes of resolved secrets.
+func WithRefreshInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refreshInterval = interval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		refreshInterval: 5 * time.Minute,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.refreshInterval < time.Second {
+		return nil, errors.New("refresh interval must be at least 1 second")
+	}
+	names := make(map[string]bool)
+	for _, provider := range parameters.providers {
+		if provider == nil {
+			return nil, errors.New("nil provider specified")
+		}
+		if names[provider.Name()] {
+			return nil, fmt.Errorf("duplicate provider %s", provider.Name())
+		}
+		names[provider.Name()] = true
+	}
+
+	return &parameters, nil
+}
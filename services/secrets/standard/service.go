@@ -0,0 +1,151 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a secrets service that resolves references to secrets held by its
+// providers.  Resolved secrets are cached, and refreshed periodically so that
+// rotated secrets are picked up without a restart.
+type Service struct {
+	providers       map[string]secrets.Provider
+	refreshInterval time.Duration
+	valuesMu        sync.RWMutex
+	values          map[string]string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("secrets", "standard", parameters.logLevel)
+
+	providers := make(map[string]secrets.Provider, len(parameters.providers))
+	for _, provider := range parameters.providers {
+		providers[provider.Name()] = provider
+	}
+
+	s := &Service{
+		providers:       providers,
+		refreshInterval: parameters.refreshInterval,
+		values:          make(map[string]string),
+	}
+
+	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
+		s := data.(*Service)
+		return time.Now().Add(s.refreshInterval), nil
+	}
+	jobFunc := func(ctx context.Context, data interface{}) {
+		s := data.(*Service)
+		s.refresh(ctx)
+	}
+	if err := parameters.scheduler.SchedulePeriodicJob(ctx, "secrets", "refresh secrets",
+		runtimeFunc,
+		s,
+		jobFunc,
+		s,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to set up periodic refresh of secrets")
+	}
+
+	return s, nil
+}
+
+// Resolve resolves a value that may be a reference to a secret, returning the
+// current value of the secret.  Values that are not references are returned
+// unchanged.
+func (s *Service) Resolve(ctx context.Context, value string) (string, error) {
+	if !secrets.IsReference(value) {
+		return value, nil
+	}
+
+	s.valuesMu.RLock()
+	secret, exists := s.values[value]
+	s.valuesMu.RUnlock()
+	if exists {
+		return secret, nil
+	}
+
+	secret, err := s.fetch(ctx, value)
+	if err != nil {
+		return "", err
+	}
+	s.valuesMu.Lock()
+	s.values[value] = secret
+	s.valuesMu.Unlock()
+
+	return secret, nil
+}
+
+// fetch fetches the current value of a referenced secret from its provider.
+func (s *Service) fetch(ctx context.Context, reference string) (string, error) {
+	name, path, found := strings.Cut(strings.TrimPrefix(reference, secrets.ReferencePrefix), ":")
+	if !found || path == "" {
+		return "", fmt.Errorf("invalid secret reference %q; must be of the form %s<provider>:<path>", reference, secrets.ReferencePrefix)
+	}
+	provider, exists := s.providers[name]
+	if !exists {
+		return "", fmt.Errorf("secrets provider %s is not configured", name)
+	}
+
+	secret, err := provider.Secret(ctx, path)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("failed to obtain secret from %s", name))
+	}
+
+	return secret, nil
+}
+
+// refresh refreshes the values of resolved secrets.  If a secret cannot be
+// refreshed its previous value is retained.
+func (s *Service) refresh(ctx context.Context) {
+	s.valuesMu.RLock()
+	references := make([]string, 0, len(s.values))
+	for reference := range s.values {
+		references = append(references, reference)
+	}
+	s.valuesMu.RUnlock()
+
+	for _, reference := range references {
+		secret, err := s.fetch(ctx, reference)
+		if err != nil {
+			log.Warn().Str("reference", reference).Err(err).Msg("Failed to refresh secret; retaining previous value")
+			continue
+		}
+		s.valuesMu.Lock()
+		if s.values[reference] != secret {
+			log.Info().Str("reference", reference).Msg("Secret rotated")
+			s.values[reference] = secret
+		}
+		s.valuesMu.Unlock()
+	}
+}
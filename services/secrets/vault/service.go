@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/secrets"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a secrets provider that reads secrets from HashiCorp Vault.
+type Service struct {
+	client    *api.Client
+	tokenFile string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = util.ServiceLogger("secrets", "vault", parameters.logLevel)
+
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return nil, errors.Wrap(config.Error, "failed to create Vault configuration")
+	}
+	config.Address = parameters.address
+	config.Timeout = parameters.timeout
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Vault client")
+	}
+	if parameters.token != "" {
+		client.SetToken(parameters.token)
+	}
+	if parameters.namespace != "" {
+		client.SetNamespace(parameters.namespace)
+	}
+
+	s := &Service{
+		client:    client,
+		tokenFile: parameters.tokenFile,
+	}
+	if err := s.refreshToken(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Name is the name of the provider, as used in references.
+func (*Service) Name() string {
+	return "vault"
+}
+
+// Secret fetches the current value of the secret at the given path.  The path is
+// the API path of the secret, for example secret/data/chaind for version 2 of the
+// key/value engine, optionally followed by #field to select a field of the secret.
+func (s *Service) Secret(ctx context.Context, path string) (string, error) {
+	if err := s.refreshToken(); err != nil {
+		return "", err
+	}
+
+	path, field := secrets.SplitField(path)
+	secret, err := s.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read secret from Vault")
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf("secret %s not found in Vault", path)
+	}
+
+	data := secret.Data
+	// Version 2 of the key/value engine nests the fields of the secret.
+	if nested, isNested := data["data"].(map[string]interface{}); isNested {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("secret %s has multiple fields; one must be selected", path)
+		}
+		for k := range data {
+			field = k
+		}
+	}
+	value, exists := data[field]
+	if !exists {
+		return "", fmt.Errorf("field %s not found in secret %s", field, path)
+	}
+	log.Trace().Str("path", path).Str("field", field).Msg("Read secret")
+
+	return fmt.Sprintf("%v", value), nil
+}
+
+// refreshToken reads the token from the token file, if configured, so that tokens
+// renewed by an external agent are picked up.
+func (s *Service) refreshToken() error {
+	if s.tokenFile == "" {
+		return nil
+	}
+
+	token, err := os.ReadFile(s.tokenFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read Vault token file")
+	}
+	s.client.SetToken(strings.TrimSpace(string(token)))
+
+	return nil
+}
@@ -19,14 +19,19 @@ import (
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/scheduler"
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	scheduler  scheduler.Service
+	logLevel                  zerolog.Level
+	network                   string
+	eth2Client                eth2client.Service
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	scheduler                 scheduler.Service
+	genesisProvider           eth2client.GenesisProvider
+	genesisValidatorsProvider eth2client.ValidatorsProvider
 }
 
 // Parameter is the interface for service parameters.
@@ -47,6 +52,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithETH2Client sets the Ethereum 2 client for this module.
 func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -61,6 +73,13 @@ func WithChainDB(chainDB chaindb.Service) Parameter {
 	})
 }
 
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
 // WithScheduler sets the scheduler for this module.
 func WithScheduler(scheduler scheduler.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -68,6 +87,21 @@ func WithScheduler(scheduler scheduler.Service) Parameter {
 	})
 }
 
+// WithGenesisProvider sets the provider of genesis information, if not the Ethereum 2 client.
+func WithGenesisProvider(provider eth2client.GenesisProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisProvider = provider
+	})
+}
+
+// WithGenesisValidatorsProvider sets the provider of the validators at genesis.  If set,
+// the validators are stored when genesis information is first stored.
+func WithGenesisValidatorsProvider(provider eth2client.ValidatorsProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisValidatorsProvider = provider
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -82,9 +116,19 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.eth2Client == nil {
 		return nil, errors.New("no Ethereum 2 client specified")
 	}
+	if parameters.genesisProvider == nil {
+		genesisProvider, isProvider := parameters.eth2Client.(eth2client.GenesisProvider)
+		if !isProvider {
+			return nil, errors.New("Ethereum 2 client does not provide genesis information")
+		}
+		parameters.genesisProvider = genesisProvider
+	}
 	if parameters.chainDB == nil {
 		return nil, errors.New("no chain database specified")
 	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
 	if parameters.scheduler == nil {
 		return nil, errors.New("no scheduler specified")
 	}
@@ -15,26 +15,39 @@ package standard
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a spec service.
 type Service struct {
-	eth2Client         eth2client.Service
-	chainDB            chaindb.Service
-	chainSpecSetter    chaindb.ChainSpecSetter
-	genesisSetter      chaindb.GenesisSetter
-	forkScheduleSetter chaindb.ForkScheduleSetter
+	log                       zerolog.Logger
+	eth2Client                eth2client.Service
+	chainDB                   chaindb.Service
+	chainTime                 chaintime.Service
+	chainSpecProvider         chaindb.ChainSpecProvider
+	chainSpecSetter           chaindb.ChainSpecSetter
+	genesisSetter             chaindb.GenesisSetter
+	genesisProvider           eth2client.GenesisProvider
+	genesisValidatorsProvider eth2client.ValidatorsProvider
+	storedGenesisProvider     chaindb.GenesisProvider
+	validatorsSetter          chaindb.ValidatorsSetter
+	forkScheduleSetter        chaindb.ForkScheduleSetter
+	nextForkEpochMu           sync.Mutex
+	nextForkEpoch             phase0.Epoch
 }
 
-// module-wide log.
-var log zerolog.Logger
+// farFutureEpoch is the epoch of forks that are not scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
 
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
@@ -44,9 +57,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "spec").Str("impl", "standard").Logger()
-	if parameters.logLevel != log.GetLevel() {
-		log = log.Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "spec", "standard", parameters.logLevel)
+
+	chainSpecProvider, isChainSpecProvider := parameters.chainDB.(chaindb.ChainSpecProvider)
+	if !isChainSpecProvider {
+		return nil, errors.New("chain DB does not support chain spec providing")
 	}
 
 	chainSpecSetter, isChainSpecSetter := parameters.chainDB.(chaindb.ChainSpecSetter)
@@ -59,27 +74,62 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support genesis setting")
 	}
 
+	var storedGenesisProvider chaindb.GenesisProvider
+	var validatorsSetter chaindb.ValidatorsSetter
+	if parameters.genesisValidatorsProvider != nil {
+		var isProvider bool
+		storedGenesisProvider, isProvider = parameters.chainDB.(chaindb.GenesisProvider)
+		if !isProvider {
+			return nil, errors.New("chain DB does not support genesis providing")
+		}
+		var isSetter bool
+		validatorsSetter, isSetter = parameters.chainDB.(chaindb.ValidatorsSetter)
+		if !isSetter {
+			return nil, errors.New("chain DB does not support validator setting")
+		}
+	}
+
 	forkScheduleSetter, isForkScheduleSetter := parameters.chainDB.(chaindb.ForkScheduleSetter)
 	if !isForkScheduleSetter {
 		return nil, errors.New("chain DB does not support fork schedule setting")
 	}
 
 	s := &Service{
-		eth2Client:         parameters.eth2Client,
-		chainDB:            parameters.chainDB,
-		chainSpecSetter:    chainSpecSetter,
-		genesisSetter:      genesisSetter,
-		forkScheduleSetter: forkScheduleSetter,
+		log:                       log,
+		eth2Client:                parameters.eth2Client,
+		chainDB:                   parameters.chainDB,
+		chainTime:                 parameters.chainTime,
+		chainSpecProvider:         chainSpecProvider,
+		chainSpecSetter:           chainSpecSetter,
+		genesisSetter:             genesisSetter,
+		genesisProvider:           parameters.genesisProvider,
+		genesisValidatorsProvider: parameters.genesisValidatorsProvider,
+		storedGenesisProvider:     storedGenesisProvider,
+		validatorsSetter:          validatorsSetter,
+		forkScheduleSetter:        forkScheduleSetter,
+		nextForkEpoch:             farFutureEpoch,
 	}
 
 	// Update spec in the _foreground_.  This ensures that spec information
 	// is available to other modules when they start.
 	s.updateSpec(ctx)
 
-	// Set up a periodic refresh of the spec information.
+	// Set up a periodic refresh of the spec information.  Values can change
+	// at forks, so refresh shortly after the next fork as well as daily.
 	runtimeFunc := func(ctx context.Context, data interface{}) (time.Time, error) {
-		// Run daily.
-		return time.Now().AddDate(0, 0, 1), nil
+		s := data.(*Service)
+		next := time.Now().AddDate(0, 0, 1)
+		s.nextForkEpochMu.Lock()
+		nextForkEpoch := s.nextForkEpoch
+		s.nextForkEpochMu.Unlock()
+		if nextForkEpoch != farFutureEpoch {
+			// Wait for a slot, to ensure that the fork epoch has started.
+			forkTime := s.chainTime.StartOfSlot(s.chainTime.FirstSlotOfEpoch(nextForkEpoch) + 1)
+			if forkTime.Before(next) {
+				next = forkTime
+			}
+		}
+		return next, nil
 	}
 	jobFunc := func(ctx context.Context, data interface{}) {
 		log.Trace().Msg("Updating spec")
@@ -101,25 +151,25 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 func (s *Service) updateSpec(ctx context.Context) {
 	ctx, cancel, err := s.chainDB.BeginTx(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to begin transaction")
+		s.log.Fatal().Err(err).Msg("Failed to begin transaction")
 		return
 	}
 
 	if err := s.updateChainSpec(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update spec")
+		s.log.Fatal().Err(err).Msg("Failed to update spec")
 	}
 
 	if err := s.updateGenesis(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update genesis")
+		s.log.Fatal().Err(err).Msg("Failed to update genesis")
 	}
 
 	if err := s.updateForkSchedule(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update fork schedule")
+		s.log.Fatal().Err(err).Msg("Failed to update fork schedule")
 	}
 
 	if err := s.chainDB.CommitTx(ctx); err != nil {
 		cancel()
-		log.Fatal().Err(err).Msg("Failed to commit transaction")
+		s.log.Fatal().Err(err).Msg("Failed to commit transaction")
 	}
 
 }
@@ -131,9 +181,29 @@ func (s *Service) updateChainSpec(ctx context.Context) error {
 		return errors.Wrap(err, "failed to obtain chain spec")
 	}
 
+	storedSpec, err := s.chainSpecProvider.ChainSpec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain stored chain spec")
+	}
+
 	// Update the database.
+	if len(storedSpec) == 0 {
+		// First time; values are effective from genesis.
+		for k, v := range spec {
+			if err := s.chainSpecSetter.SetChainSpecValue(ctx, k, v); err != nil {
+				return errors.Wrap(err, "failed to set chain spec value")
+			}
+		}
+		return nil
+	}
+
+	// Subsequent times; only values that have changed are written, effective from the current epoch.
+	epoch := s.chainTime.CurrentEpoch()
 	for k, v := range spec {
-		if err := s.chainSpecSetter.SetChainSpecValue(ctx, k, v); err != nil {
+		if _, exists := storedSpec[k]; !exists {
+			s.log.Info().Str("key", k).Uint64("epoch", uint64(epoch)).Msg("New chain spec value")
+		}
+		if err := s.chainSpecSetter.SetChainSpecValueFromEpoch(ctx, k, v, epoch); err != nil {
 			return errors.Wrap(err, "failed to set chain spec value")
 		}
 	}
@@ -142,11 +212,25 @@ func (s *Service) updateChainSpec(ctx context.Context) error {
 
 func (s *Service) updateGenesis(ctx context.Context) error {
 	// Fetch genesis parameters.
-	genesis, err := s.eth2Client.(eth2client.GenesisProvider).Genesis(ctx)
+	genesis, err := s.genesisProvider.Genesis(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain genesis")
 	}
 
+	if s.genesisValidatorsProvider != nil {
+		// The validators at genesis are stored only the first time that genesis is
+		// stored, as after that they will have been updated by the validators module.
+		_, err := s.storedGenesisProvider.Genesis(ctx)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := s.storeGenesisValidators(ctx); err != nil {
+				return err
+			}
+		case err != nil:
+			return errors.Wrap(err, "failed to obtain stored genesis")
+		}
+	}
+
 	// Update the database.
 	if err := s.genesisSetter.SetGenesis(ctx, genesis); err != nil {
 		return errors.Wrap(err, "failed to set genesis")
@@ -155,6 +239,32 @@ func (s *Service) updateGenesis(ctx context.Context) error {
 	return nil
 }
 
+// storeGenesisValidators stores the validators at genesis.
+func (s *Service) storeGenesisValidators(ctx context.Context) error {
+	validators, err := s.genesisValidatorsProvider.Validators(ctx, "genesis", nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain genesis validators")
+	}
+
+	for index, validator := range validators {
+		if err := s.validatorsSetter.SetValidator(ctx, &chaindb.Validator{
+			PublicKey:                  validator.Validator.PublicKey,
+			Index:                      index,
+			EffectiveBalance:           validator.Validator.EffectiveBalance,
+			Slashed:                    validator.Validator.Slashed,
+			ActivationEligibilityEpoch: validator.Validator.ActivationEligibilityEpoch,
+			ActivationEpoch:            validator.Validator.ActivationEpoch,
+			ExitEpoch:                  validator.Validator.ExitEpoch,
+			WithdrawableEpoch:          validator.Validator.WithdrawableEpoch,
+		}); err != nil {
+			return errors.Wrap(err, "failed to set genesis validator")
+		}
+	}
+	s.log.Info().Int("validators", len(validators)).Msg("Stored genesis validators")
+
+	return nil
+}
+
 func (s *Service) updateForkSchedule(ctx context.Context) error {
 	// Fetch fork schedule.
 	schedule, err := s.eth2Client.(eth2client.ForkScheduleProvider).ForkSchedule(ctx)
@@ -167,5 +277,17 @@ func (s *Service) updateForkSchedule(ctx context.Context) error {
 		return errors.Wrap(err, "failed to set fork schedule")
 	}
 
+	// Note the next fork, to refresh the spec when it takes place.
+	currentEpoch := s.chainTime.CurrentEpoch()
+	nextForkEpoch := farFutureEpoch
+	for _, fork := range schedule {
+		if fork.Epoch > currentEpoch && fork.Epoch < nextForkEpoch {
+			nextForkEpoch = fork.Epoch
+		}
+	}
+	s.nextForkEpochMu.Lock()
+	s.nextForkEpoch = nextForkEpoch
+	s.nextForkEpochMu.Unlock()
+
 	return nil
 }
@@ -13,5 +13,18 @@
 
 package summarizer
 
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
 // Service is a summarizer service.
 type Service interface{}
+
+// Resummarizer is the interface for a summarizer that can regenerate existing summaries.
+type Resummarizer interface {
+	// Resummarize recalculates the summaries for the given range of epochs, inclusive,
+	// replacing the existing summaries.
+	Resummarize(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error
+}
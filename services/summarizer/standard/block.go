@@ -27,7 +27,7 @@ func (s *Service) summarizeBlocksInEpoch(ctx context.Context,
 	md *metadata,
 	epoch phase0.Epoch,
 ) error {
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 	if !s.blockSummaries {
 		log.Trace().Msg("Block epoch summaries not enabled")
 		return nil
@@ -62,17 +62,45 @@ func (s *Service) summarizeBlocksInEpoch(ctx context.Context,
 
 // summarizeBlock summarizes the block at the given slot.
 func (s *Service) summarizeBlock(ctx context.Context, slot phase0.Slot) error {
+	summary, err := s.blockSummary(ctx, slot)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		// No canonical block for this slot.
+		return nil
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set block summary")
+	}
+	if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set block summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set block summary")
+	}
+
+	return nil
+}
+
+// blockSummary calculates the summary for the canonical block at the given slot.
+// It returns nil if there is no canonical block at the slot.
+func (s *Service) blockSummary(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
 	summary := &chaindb.BlockSummary{
 		Slot: slot,
 	}
 
 	blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain blocks for slot")
+		return nil, errors.Wrap(err, "failed to obtain blocks for slot")
 	}
 	if len(blocks) == 0 {
 		// No block for this slot.
-		return nil
+		return nil, nil
 	}
 
 	var block *chaindb.Block
@@ -84,31 +112,18 @@ func (s *Service) summarizeBlock(ctx context.Context, slot phase0.Slot) error {
 	}
 	if block == nil {
 		// No canonical block for this slot.
-		return nil
+		return nil, nil
 	}
 
 	if err := s.attestationStatsForBlock(ctx, slot, summary, block); err != nil {
-		return errors.Wrap(err, "failed to calculate block attestation summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate block attestation summary statistics for epoch")
 	}
 
 	if err := s.parentDistanceForBlock(ctx, slot, summary, block); err != nil {
-		return errors.Wrap(err, "failed to calculate parent distance summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate parent distance summary statistics for epoch")
 	}
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set block summary")
-	}
-	if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set block summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set block summary")
-	}
-
-	return nil
+	return summary, nil
 }
 
 func (s *Service) attestationStatsForBlock(ctx context.Context,
@@ -116,7 +131,7 @@ func (s *Service) attestationStatsForBlock(ctx context.Context,
 	summary *chaindb.BlockSummary,
 	block *chaindb.Block,
 ) error {
-	log.Trace().Uint64("slot", uint64(slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Fetching attestations for canonical block")
+	s.log.Trace().Uint64("slot", uint64(slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Fetching attestations for canonical block")
 
 	attestations, err := s.attestationsProvider.AttestationsForBlock(ctx, block.Root)
 	if err != nil {
@@ -153,7 +168,7 @@ func (s *Service) attestationStatsForBlock(ctx context.Context,
 		}
 		seenAttestations[specAttestationRoot] = true
 		if attestation.Canonical == nil || !*attestation.Canonical {
-			log.Debug().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Uint64("inclusion_index", attestation.InclusionIndex).Msg("Attestation is not canonical")
+			s.log.Debug().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Uint64("inclusion_index", attestation.InclusionIndex).Msg("Attestation is not canonical")
 			continue
 		}
 		summary.AttestationsForBlock++
@@ -175,7 +190,7 @@ func (s *Service) parentDistanceForBlock(ctx context.Context,
 		return nil
 	}
 
-	log.Trace().Uint64("slot", uint64(slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Fetching parent for canonical block")
+	s.log.Trace().Uint64("slot", uint64(slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Fetching parent for canonical block")
 
 	parentBlock, err := s.blocksProvider.BlockByRoot(ctx, block.ParentRoot)
 	if err != nil {
@@ -0,0 +1,52 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// Pause stops the summarizer from acting on finality updates.
+func (s *Service) Pause() {
+	s.paused.Store(true)
+	s.log.Info().Msg("Paused")
+}
+
+// Resume allows the summarizer to act on finality updates again.
+func (s *Service) Resume() {
+	s.paused.Store(false)
+	s.log.Info().Msg("Resumed")
+}
+
+// Paused returns true if the summarizer is paused.
+func (s *Service) Paused() bool {
+	return s.paused.Load()
+}
+
+// Trigger runs the summarizer up to the most recent finality update,
+// regardless of whether the summarizer is paused.
+func (s *Service) Trigger(ctx context.Context) error {
+	finalizedEpoch := s.finalizedEpoch.Load()
+	if finalizedEpoch == 0 {
+		return errors.New("no finality update received")
+	}
+
+	s.log.Info().Uint64("finalized_epoch", finalizedEpoch).Msg("Triggered")
+	s.summarize(ctx, phase0.Epoch(finalizedEpoch))
+
+	return nil
+}
@@ -33,35 +33,72 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 	error,
 ) {
 	started := time.Now()
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 	if !s.epochSummaries {
 		log.Trace().Msg("Epoch summaries not enabled")
 		return false, nil
 	}
 	log.Trace().Msg("Summarizing epoch")
 
+	summary, err := s.epochSummary(ctx, epoch)
+	if err != nil {
+		return false, err
+	}
+	if summary == nil {
+		return false, nil
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Calculated summary")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
+	}
+	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set epoch summary")
+	}
+	md.LastEpoch = epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+
+	return true, nil
+}
+
+// epochSummary calculates the summary for the given epoch.
+// It returns nil if the data required for the summary is not yet present.
+func (s *Service) epochSummary(ctx context.Context, epoch phase0.Epoch) (*chaindb.EpochSummary, error) {
+	started := time.Now()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
+
 	summary := &chaindb.EpochSummary{
 		Epoch: epoch,
 	}
 
 	activeValidators, err := s.validatorSummaryStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate validator summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate validator summary statistics for epoch")
 	}
 	if summary.ActiveValidators == 0 {
-		return false, errors.New("no active validators to summarize for epoch")
+		return nil, errors.New("no active validators to summarize for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set validator summary stats")
 
 	// Active balance and active effective balance.
 	balances, err := s.validatorsProvider.ValidatorBalancesByEpoch(ctx, epoch)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to obtain validator balances")
+		return nil, errors.Wrap(err, "failed to obtain validator balances")
 	}
 	if len(balances) == 0 {
 		// This can happen if chaind does not have validator balances enabled, or has not yet obtained
-		// the balances.  We return false but no error.
-		return false, nil
+		// the balances.  We return no summary but no error.
+		return nil, nil
 	}
 	for i, balance := range balances {
 		if activeValidators[i] {
@@ -73,48 +110,35 @@ func (s *Service) summarizeEpoch(ctx context.Context,
 
 	err = s.blockStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate block summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set block summary stats")
 
 	err = s.slashingsStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate slashings summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set slashing stats")
 
 	err = s.attestationStatsForEpoch(ctx, epoch, balances, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate attestation summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set attestation stats")
 
 	err = s.depositStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
+		return nil, errors.Wrap(err, "failed to calculate deposit summary statistics for epoch")
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set deposit stats")
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	err = s.syncCommitteeStatsForEpoch(ctx, epoch, summary)
 	if err != nil {
-		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
-	}
-	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set epoch summary")
-	}
-	md.LastEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
+		return nil, errors.Wrap(err, "failed to calculate sync committee summary statistics for epoch")
 	}
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set sync committee stats")
 
-	return true, nil
+	return summary, nil
 }
 
 func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
@@ -158,7 +182,7 @@ func (s *Service) blockStatsForEpoch(ctx context.Context,
 ) error {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
-	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating block statistics")
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating block statistics")
 
 	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
@@ -180,7 +204,7 @@ func (s *Service) depositStatsForEpoch(ctx context.Context,
 ) error {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
-	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating deposit statistics")
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating deposit statistics")
 
 	deposits, err := s.depositsProvider.DepositsForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
@@ -199,7 +223,7 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 ) error {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
-	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating attestation statistics")
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating attestation statistics")
 
 	attestationsForEpoch, err := s.attestationsProvider.AttestationsForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
@@ -239,7 +263,7 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 		}
 		seenAttestations[specAttestationRoot] = true
 		if attestation.Canonical == nil || !*attestation.Canonical {
-			log.Warn().Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Uint64("inclusion_index", attestation.InclusionIndex).Msg("Attestation is not canonical; ignoring")
+			s.log.Warn().Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Uint64("inclusion_index", attestation.InclusionIndex).Msg("Attestation is not canonical; ignoring")
 			continue
 		}
 		if attestation.Slot >= minSlot && attestation.Slot <= maxSlot {
@@ -286,13 +310,30 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	return nil
 }
 
+func (s *Service) syncCommitteeStatsForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+	summary *chaindb.EpochSummary,
+) error {
+	participations, misses, err := s.syncCommitteeParticipationForEpoch(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	for _, participation := range participations {
+		summary.SyncCommitteeParticipations += participation
+	}
+	for _, miss := range misses {
+		summary.SyncCommitteeMisses += miss
+	}
+	return nil
+}
+
 func (s *Service) slashingsStatsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 	summary *chaindb.EpochSummary,
 ) error {
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
-	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating slashing statistics")
+	s.log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Msg("Updating slashing statistics")
 
 	proposerSlashings, err := s.proposerSlashingsProvider.ProposerSlashingsForSlotRange(ctx, minSlot, maxSlot+1)
 	if err != nil {
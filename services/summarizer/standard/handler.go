@@ -16,9 +16,11 @@ package standard
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaintime"
 )
 
 // OnFinalityUpdated is called when finality has been updated in the database.
@@ -26,13 +28,23 @@ func (s *Service) OnFinalityUpdated(
 	ctx context.Context,
 	finalizedEpoch phase0.Epoch,
 ) {
+	s.finalizedEpoch.Store(uint64(finalizedEpoch))
+	if s.paused.Load() {
+		s.log.Trace().Uint64("finalized_epoch", uint64(finalizedEpoch)).Msg("Paused; ignoring finality update")
+		return
+	}
+	s.summarize(ctx, finalizedEpoch)
+}
+
+func (s *Service) summarize(ctx context.Context, finalizedEpoch phase0.Epoch) {
+	started := time.Now()
 	// Once an epoch has been finalized we can summarize the epoch that comes two before it.
 	if finalizedEpoch < 2 {
 		return
 	}
 	summaryEpoch := finalizedEpoch - 2
 
-	log := log.With().Uint64("summary_epoch", uint64(summaryEpoch)).Logger()
+	log := s.log.With().Uint64("summary_epoch", uint64(summaryEpoch)).Logger()
 	log.Trace().Msg("Handler called")
 
 	// Only allow 1 handler to be active.
@@ -55,8 +67,17 @@ func (s *Service) OnFinalityUpdated(
 		log.Warn().Err(err).Msg("Failed to update validators")
 		return
 	}
+	if err := s.summarizeValidatorDays(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to update validator days")
+		return
+	}
+	if err := s.pruneValidatorSummaries(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to prune validator summaries")
+		return
+	}
 
-	monitorEpochProcessed(summaryEpoch)
+	s.monitorEpochProcessed(summaryEpoch)
+	s.monitorProcessingDuration(time.Since(started))
 	log.Trace().Msg("Finished handling finality checkpoint")
 }
 
@@ -74,7 +95,11 @@ func (s *Service) summarizeEpochs(ctx context.Context, summaryEpoch phase0.Epoch
 	if lastEpoch != 0 {
 		lastEpoch++
 	}
-	log.Trace().Uint64("last_epoch", uint64(lastEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Catchup bounds")
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); lastEpoch < windowStart {
+		// Data before the rolling window is not kept, so cannot be summarized.
+		lastEpoch = windowStart
+	}
+	s.log.Trace().Uint64("last_epoch", uint64(lastEpoch)).Uint64("summary_epoch", uint64(summaryEpoch)).Msg("Catchup bounds")
 
 	for epoch := lastEpoch; epoch <= summaryEpoch; epoch++ {
 		updated, err := s.summarizeEpoch(ctx, md, epoch)
@@ -82,7 +107,7 @@ func (s *Service) summarizeEpochs(ctx context.Context, summaryEpoch phase0.Epoch
 			return errors.Wrapf(err, "failed to update summary for epoch %d", epoch)
 		}
 		if !updated {
-			log.Debug().Uint64("epoch", uint64(epoch)).Msg("not enough data to update summary")
+			s.log.Debug().Uint64("epoch", uint64(epoch)).Msg("not enough data to update summary")
 			return nil
 		}
 	}
@@ -106,6 +131,10 @@ func (s *Service) summarizeBlocks(ctx context.Context,
 	if lastBlockEpoch != 0 {
 		lastBlockEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); lastBlockEpoch < windowStart {
+		// Data before the rolling window is not kept, so cannot be summarized.
+		lastBlockEpoch = windowStart
+	}
 
 	// The last epoch updated in the metadata tells us how far we can summarize,
 	// as it checks for the component data.  As such, if the finalized epoch
@@ -148,6 +177,10 @@ func (s *Service) summarizeValidators(ctx context.Context, summaryEpoch phase0.E
 	if lastValidatorEpoch != 0 {
 		lastValidatorEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); lastValidatorEpoch < windowStart {
+		// Data before the rolling window is not kept, so cannot be summarized.
+		lastValidatorEpoch = windowStart
+	}
 	for epoch := lastValidatorEpoch; epoch <= summaryEpoch; epoch++ {
 		if err := s.summarizeValidatorsInEpoch(ctx, md, epoch); err != nil {
 			return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries for epoch %d", epoch))
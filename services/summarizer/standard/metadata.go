@@ -26,6 +26,8 @@ type metadata struct {
 	LastValidatorEpoch phase0.Epoch `json:"latest_validator_epoch"`
 	LastBlockEpoch     phase0.Epoch `json:"latest_block_epoch"`
 	LastEpoch          phase0.Epoch `json:"latest_epoch"`
+	// LastValidatorDay is the start of the latest day of validator summaries, as a Unix timestamp.
+	LastValidatorDay int64 `json:"latest_validator_day,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
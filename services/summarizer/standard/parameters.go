@@ -15,6 +15,7 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
@@ -24,14 +25,21 @@ import (
 )
 
 type parameters struct {
-	logLevel           zerolog.Level
-	monitor            metrics.Service
-	eth2Client         eth2client.Service
-	chainDB            chaindb.Service
-	chainTime          chaintime.Service
-	epochSummaries     bool
-	blockSummaries     bool
-	validatorSummaries bool
+	logLevel                zerolog.Level
+	network                 string
+	monitor                 metrics.Service
+	eth2Client              eth2client.Service
+	chainDB                 chaindb.Service
+	chainTime               chaintime.Service
+	epochSummaries          bool
+	blockSummaries          bool
+	validatorSummaries      bool
+	validatorEpochSummaries bool
+	validatorDaySummaries   bool
+	validatorEpochRetention time.Duration
+	validatorDayRetention   time.Duration
+	maxValidatorDaysPerRun  uint64
+	window                  uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -52,6 +60,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -101,10 +116,59 @@ func WithValidatorSummaries(enabled bool) Parameter {
 	})
 }
 
+// WithValidatorEpochSummaries states if the module should retain validator epoch summaries.
+// If not, validator epoch summaries are removed once they have been included in validator
+// day summaries.
+func WithValidatorEpochSummaries(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorEpochSummaries = enabled
+	})
+}
+
+// WithValidatorDaySummaries states if the module should generate validator day summaries.
+func WithValidatorDaySummaries(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorDaySummaries = enabled
+	})
+}
+
+// WithValidatorEpochRetention sets the period for which validator epoch summaries are retained.
+// 0 retains them indefinitely.
+func WithValidatorEpochRetention(retention time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorEpochRetention = retention
+	})
+}
+
+// WithValidatorDayRetention sets the period for which validator day summaries are retained.
+// 0 retains them indefinitely.
+func WithValidatorDayRetention(retention time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorDayRetention = retention
+	})
+}
+
+// WithMaxValidatorDaysPerRun sets the maximum number of days for which validator day summaries
+// are generated each time the summarizer runs, so that historical days are caught up in batches.
+func WithMaxValidatorDaysPerRun(days uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxValidatorDaysPerRun = days
+	})
+}
+
+// WithWindow sets the number of recent epochs for which summaries are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:                zerolog.GlobalLevel(),
+		validatorEpochSummaries: true,
+		maxValidatorDaysPerRun:  5,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -121,6 +185,18 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.validatorSummaries && !parameters.validatorEpochSummaries && !parameters.validatorDaySummaries {
+		return nil, errors.New("validator summaries require epoch or day summaries")
+	}
+	if parameters.validatorEpochRetention < 0 {
+		return nil, errors.New("validator epoch retention cannot be negative")
+	}
+	if parameters.validatorDayRetention < 0 {
+		return nil, errors.New("validator day retention cannot be negative")
+	}
+	if parameters.maxValidatorDaysPerRun == 0 {
+		return nil, errors.New("max validator days per run must be greater than 0")
+	}
 
 	return &parameters, nil
 }
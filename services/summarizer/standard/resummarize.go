@@ -0,0 +1,213 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Resummarize recalculates the summaries for the given range of epochs, inclusive, replacing
+// the existing summaries.  Summaries for each epoch and day are replaced in a single transaction.
+// Only epochs that have already been summarized can be resummarized.
+func (s *Service) Resummarize(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) error {
+	if endEpoch < startEpoch {
+		return errors.New("end epoch before start epoch")
+	}
+	deleter, isDeleter := s.chainDB.(chaindb.SummariesDeleter)
+	if !isDeleter {
+		return errors.New("chain DB does not support deletion of summaries")
+	}
+
+	// Wait for any summarizing in progress to finish, and hold off any more until we are done.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire activity semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	if s.epochSummaries && endEpoch > md.LastEpoch {
+		return fmt.Errorf("epoch summaries have only been generated up to epoch %d", md.LastEpoch)
+	}
+	if s.blockSummaries && endEpoch > md.LastBlockEpoch {
+		return fmt.Errorf("block summaries have only been generated up to epoch %d", md.LastBlockEpoch)
+	}
+	if s.validatorSummaries && endEpoch > md.LastValidatorEpoch {
+		return fmt.Errorf("validator summaries have only been generated up to epoch %d", md.LastValidatorEpoch)
+	}
+
+	validatorDays := s.validatorSummaries && s.validatorDaySummaries
+	startDay := s.chainTime.StartOfEpoch(startEpoch).UTC().Truncate(24 * time.Hour)
+	endDay := s.chainTime.StartOfEpoch(endEpoch).UTC().Truncate(24 * time.Hour)
+	if validatorDays && !s.validatorEpochSummaries {
+		// Validator epoch summaries are not retained, so they must be regenerated for
+		// the whole of each day.
+		startEpoch, _ = s.dayEpochs(startDay)
+		_, endEpoch = s.dayEpochs(endDay)
+		if endEpoch > md.LastValidatorEpoch {
+			endEpoch = md.LastValidatorEpoch
+		}
+	}
+
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		if err := s.resummarizeEpoch(ctx, deleter, epoch); err != nil {
+			return errors.Wrapf(err, "failed to resummarize epoch %d", epoch)
+		}
+		s.log.Trace().Uint64("epoch", uint64(epoch)).Msg("Resummarized epoch")
+	}
+
+	if validatorDays {
+		for day := startDay; !day.After(endDay); day = day.Add(24 * time.Hour) {
+			if md.LastValidatorDay == 0 || day.Unix() > md.LastValidatorDay {
+				// Day has yet to be summarized; it will be summarized as usual when complete.
+				break
+			}
+			if err := s.resummarizeValidatorDay(ctx, deleter, day); err != nil {
+				return errors.Wrapf(err, "failed to resummarize day %s", day.Format("2006-01-02"))
+			}
+			s.log.Trace().Time("day", day).Msg("Resummarized day")
+		}
+	}
+
+	return nil
+}
+
+// resummarizeEpoch replaces the summaries for the given epoch.
+func (s *Service) resummarizeEpoch(ctx context.Context, deleter chaindb.SummariesDeleter, epoch phase0.Epoch) error {
+	var epochSummary *chaindb.EpochSummary
+	if s.epochSummaries {
+		var err error
+		epochSummary, err = s.epochSummary(ctx, epoch)
+		if err != nil {
+			return err
+		}
+		if epochSummary == nil {
+			return errors.New("insufficient data to summarize epoch")
+		}
+	}
+
+	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch+1) - 1
+	blockSummaries := make([]*chaindb.BlockSummary, 0)
+	if s.blockSummaries {
+		for slot := minSlot; slot <= maxSlot; slot++ {
+			blockSummary, err := s.blockSummary(ctx, slot)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create summary for block %d", slot)
+			}
+			if blockSummary != nil {
+				blockSummaries = append(blockSummaries, blockSummary)
+			}
+		}
+	}
+
+	var validatorSummaries []*chaindb.ValidatorEpochSummary
+	if s.validatorSummaries {
+		var err error
+		validatorSummaries, err = s.validatorSummariesForEpoch(ctx, epoch)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to resummarize epoch")
+	}
+
+	if s.epochSummaries {
+		if err := deleter.DeleteEpochSummaries(ctx, epoch, epoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete epoch summary")
+		}
+		if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, epochSummary); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set epoch summary")
+		}
+	}
+	if s.blockSummaries {
+		if err := deleter.DeleteBlockSummaries(ctx, minSlot, maxSlot); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete block summaries")
+		}
+		for _, blockSummary := range blockSummaries {
+			if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, blockSummary); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to set block summary")
+			}
+		}
+	}
+	if s.validatorSummaries {
+		if err := deleter.DeleteValidatorEpochSummaries(ctx, epoch, epoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete validator epoch summaries")
+		}
+		if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, validatorSummaries); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator epoch summaries")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to resummarize epoch")
+	}
+
+	return nil
+}
+
+// resummarizeValidatorDay replaces the validator summaries for the given day.
+func (s *Service) resummarizeValidatorDay(ctx context.Context, deleter chaindb.SummariesDeleter, day time.Time) error {
+	startEpoch, endEpoch := s.dayEpochs(day)
+	summaries, err := s.validatorSummariesForDay(ctx, day, startEpoch, endEpoch)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to resummarize day")
+	}
+
+	if err := deleter.DeleteValidatorDaySummaries(ctx, day, day); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to delete validator day summaries")
+	}
+	if err := s.chainDB.(chaindb.ValidatorDaySummariesSetter).SetValidatorDaySummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator day summaries")
+	}
+	if !s.validatorEpochSummaries {
+		// Epoch summaries are not retained once they are part of a day summary.
+		if err := deleter.DeleteValidatorEpochSummaries(ctx, startEpoch, endEpoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to delete validator epoch summaries")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to resummarize day")
+	}
+
+	return nil
+}
@@ -16,19 +16,23 @@ package standard
 import (
 	"context"
 	"math"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"go.uber.org/atomic"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a summarizer service.
 type Service struct {
+	log                             zerolog.Logger
+	metrics                         *serviceMetrics
 	eth2Client                      eth2client.Service
 	chainDB                         chaindb.Service
 	farFutureEpoch                  phase0.Epoch
@@ -39,6 +43,8 @@ type Service struct {
 	validatorsProvider              chaindb.ValidatorsProvider
 	attesterSlashingsProvider       chaindb.AttesterSlashingsProvider
 	proposerSlashingsProvider       chaindb.ProposerSlashingsProvider
+	syncCommitteesProvider          chaindb.SyncCommitteesProvider
+	syncAggregateProvider           chaindb.SyncAggregateProvider
 	chainTime                       chaintime.Service
 	maxTimelyAttestationSourceDelay uint64
 	maxTimelyAttestationTargetDelay uint64
@@ -46,12 +52,17 @@ type Service struct {
 	epochSummaries                  bool
 	blockSummaries                  bool
 	validatorSummaries              bool
+	validatorEpochSummaries         bool
+	validatorDaySummaries           bool
+	validatorEpochRetention         time.Duration
+	validatorDayRetention           time.Duration
+	maxValidatorDaysPerRun          uint64
 	activitySem                     *semaphore.Weighted
+	paused                          atomic.Bool
+	finalizedEpoch                  atomic.Uint64
+	window                          uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -60,9 +71,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "summarizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "summarizer", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -101,6 +113,31 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not provide proposer slashings")
 	}
 
+	syncCommitteesProvider, isProvider := parameters.chainDB.(chaindb.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide sync committees")
+	}
+
+	syncAggregateProvider, isProvider := parameters.chainDB.(chaindb.SyncAggregateProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide sync aggregates")
+	}
+
+	if parameters.validatorSummaries && parameters.validatorDaySummaries {
+		if _, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider); !isProvider {
+			return nil, errors.New("chain DB does not provide validator epoch summaries")
+		}
+		if _, isSetter := parameters.chainDB.(chaindb.ValidatorDaySummariesSetter); !isSetter {
+			return nil, errors.New("chain DB does not support validator day summaries")
+		}
+	}
+	if parameters.validatorSummaries &&
+		(!parameters.validatorEpochSummaries || parameters.validatorEpochRetention > 0 || parameters.validatorDayRetention > 0) {
+		if _, isPruner := parameters.chainDB.(chaindb.ValidatorSummariesPruner); !isPruner {
+			return nil, errors.New("chain DB does not support pruning of validator summaries")
+		}
+	}
+
 	spec, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain spec")
@@ -125,8 +162,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:                             log,
+		metrics:                         svcMetrics,
 		eth2Client:                      parameters.eth2Client,
 		chainDB:                         parameters.chainDB,
+		window:                          parameters.window,
 		farFutureEpoch:                  phase0.Epoch(0xffffffffffffffff),
 		proposerDutiesProvider:          proposerDutiesProvider,
 		attestationsProvider:            attestationsProvider,
@@ -135,6 +175,8 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		validatorsProvider:              validatorsProvider,
 		attesterSlashingsProvider:       attesterSlashingsProvider,
 		proposerSlashingsProvider:       proposerSlashingsProvider,
+		syncCommitteesProvider:          syncCommitteesProvider,
+		syncAggregateProvider:           syncAggregateProvider,
 		chainTime:                       parameters.chainTime,
 		maxTimelyAttestationSourceDelay: uint64(math.Sqrt(float64(slotsPerEpoch))),
 		maxTimelyAttestationTargetDelay: slotsPerEpoch,
@@ -142,6 +184,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		epochSummaries:                  parameters.epochSummaries,
 		blockSummaries:                  parameters.blockSummaries,
 		validatorSummaries:              parameters.validatorSummaries,
+		validatorEpochSummaries:         parameters.validatorEpochSummaries,
+		validatorDaySummaries:           parameters.validatorDaySummaries,
+		validatorEpochRetention:         parameters.validatorEpochRetention,
+		validatorDayRetention:           parameters.validatorDayRetention,
+		maxValidatorDaysPerRun:          parameters.maxValidatorDaysPerRun,
 		activitySem:                     semaphore.NewWeighted(1),
 	}
 
@@ -150,7 +197,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain metadata")
 	}
-	monitorLatestEpoch(md.LastEpoch)
+	s.monitorLatestEpoch(md.LastEpoch)
 
 	return s, nil
 }
@@ -0,0 +1,88 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// syncCommitteeParticipationForEpoch calculates the number of sync committee participations
+// and misses for each validator in the given epoch.
+// Counts are per slot with an included sync aggregate; slots without canonical blocks are not counted.
+func (s *Service) syncCommitteeParticipationForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	map[phase0.ValidatorIndex]int,
+	map[phase0.ValidatorIndex]int,
+	error,
+) {
+	participations := make(map[phase0.ValidatorIndex]int)
+	misses := make(map[phase0.ValidatorIndex]int)
+	if epoch < s.chainTime.AltairInitialEpoch() {
+		// No sync committees prior to Altair.
+		return participations, misses, nil
+	}
+
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx,
+		s.chainTime.FirstSlotOfEpoch(epoch),
+		s.chainTime.FirstSlotOfEpoch(epoch+1),
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	var committee []phase0.ValidatorIndex
+	for _, block := range blocks {
+		if block.Canonical == nil || !*block.Canonical {
+			continue
+		}
+		syncAggregate, err := s.syncAggregateProvider.SyncAggregateForBlock(ctx, block.Root)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to obtain sync aggregate")
+		}
+		if syncAggregate == nil {
+			continue
+		}
+		if committee == nil {
+			// Only fetch the committee once we know that there is an aggregate to which it applies.
+			syncCommittee, err := s.syncCommitteesProvider.SyncCommittee(ctx, s.chainTime.EpochToSyncCommitteePeriod(epoch))
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to obtain sync committee")
+			}
+			committee = syncCommittee.Committee
+		}
+		tallySyncAggregate(committee, syncAggregate.Bits, participations, misses)
+	}
+
+	return participations, misses, nil
+}
+
+// tallySyncAggregate adds the participations and misses of the sync committee members for a single aggregate.
+// A validator that appears in the committee more than once is counted for each of its positions.
+func tallySyncAggregate(committee []phase0.ValidatorIndex,
+	bits []byte,
+	participations map[phase0.ValidatorIndex]int,
+	misses map[phase0.ValidatorIndex]int,
+) {
+	for i, index := range committee {
+		if i/8 < len(bits) && bits[i/8]&(1<<(i%8)) != 0 {
+			participations[index]++
+		} else {
+			misses[index]++
+		}
+	}
+}
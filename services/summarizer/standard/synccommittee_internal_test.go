@@ -0,0 +1,77 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTallySyncAggregate(t *testing.T) {
+	tests := []struct {
+		name                   string
+		committee              []phase0.ValidatorIndex
+		bits                   []byte
+		expectedParticipations map[phase0.ValidatorIndex]int
+		expectedMisses         map[phase0.ValidatorIndex]int
+	}{
+		{
+			name:                   "Empty",
+			committee:              []phase0.ValidatorIndex{},
+			bits:                   []byte{},
+			expectedParticipations: map[phase0.ValidatorIndex]int{},
+			expectedMisses:         map[phase0.ValidatorIndex]int{},
+		},
+		{
+			name:                   "AllParticipated",
+			committee:              []phase0.ValidatorIndex{1, 2, 3},
+			bits:                   []byte{0x07},
+			expectedParticipations: map[phase0.ValidatorIndex]int{1: 1, 2: 1, 3: 1},
+			expectedMisses:         map[phase0.ValidatorIndex]int{},
+		},
+		{
+			name:                   "SomeMissed",
+			committee:              []phase0.ValidatorIndex{1, 2, 3},
+			bits:                   []byte{0x05},
+			expectedParticipations: map[phase0.ValidatorIndex]int{1: 1, 3: 1},
+			expectedMisses:         map[phase0.ValidatorIndex]int{2: 1},
+		},
+		{
+			name:                   "RepeatedMember",
+			committee:              []phase0.ValidatorIndex{1, 2, 1, 3, 4, 5, 6, 7, 1},
+			bits:                   []byte{0xfd, 0x00},
+			expectedParticipations: map[phase0.ValidatorIndex]int{1: 2, 3: 1, 4: 1, 5: 1, 6: 1, 7: 1},
+			expectedMisses:         map[phase0.ValidatorIndex]int{1: 1, 2: 1},
+		},
+		{
+			name:                   "ShortBits",
+			committee:              []phase0.ValidatorIndex{1, 2},
+			bits:                   []byte{},
+			expectedParticipations: map[phase0.ValidatorIndex]int{},
+			expectedMisses:         map[phase0.ValidatorIndex]int{1: 1, 2: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			participations := make(map[phase0.ValidatorIndex]int)
+			misses := make(map[phase0.ValidatorIndex]int)
+			tallySyncAggregate(test.committee, test.bits, participations, misses)
+			require.Equal(t, test.expectedParticipations, participations)
+			require.Equal(t, test.expectedMisses, misses)
+		})
+	}
+}
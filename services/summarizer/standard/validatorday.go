@@ -0,0 +1,270 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// summarizeValidatorDays updates the validator summaries for days whose epochs have all been summarized.
+// At most maxValidatorDaysPerRun days are summarized, so catching up on historical days takes
+// place in batches over multiple runs.
+func (s *Service) summarizeValidatorDays(ctx context.Context) error {
+	if !s.validatorSummaries || !s.validatorDaySummaries {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for validator day summarizer")
+	}
+
+	day := s.nextValidatorDay(md)
+	for i := uint64(0); i < s.maxValidatorDaysPerRun; i++ {
+		startEpoch, endEpoch := s.dayEpochs(day)
+		if endEpoch > md.LastValidatorEpoch {
+			// Not all epochs in the day have been summarized.
+			s.log.Trace().Time("day", day).Msg("Day not yet complete")
+			return nil
+		}
+		if err := s.summarizeValidatorDay(ctx, md, day, startEpoch, endEpoch); err != nil {
+			return errors.Wrapf(err, "failed to update validator summaries for day %s", day.Format("2006-01-02"))
+		}
+		day = day.Add(24 * time.Hour)
+	}
+
+	return nil
+}
+
+// summarizeValidatorDay updates the validator summaries for a given day.
+func (s *Service) summarizeValidatorDay(ctx context.Context,
+	md *metadata,
+	day time.Time,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) error {
+	started := time.Now()
+	log := s.log.With().Time("day", day).Logger()
+	log.Trace().Msg("Summarizing validator day")
+
+	summaries, err := s.validatorSummariesForDay(ctx, day, startEpoch, endEpoch)
+	if err != nil {
+		return err
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Accumulated epoch summaries")
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set validator day summaries")
+	}
+
+	if err := s.chainDB.(chaindb.ValidatorDaySummariesSetter).SetValidatorDaySummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator day summaries")
+	}
+
+	if !s.validatorEpochSummaries {
+		// Epoch summaries are not retained once they are part of a day summary.
+		if err := s.chainDB.(chaindb.ValidatorSummariesPruner).PruneValidatorEpochSummaries(ctx, endEpoch+1); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune validator epoch summaries")
+		}
+	}
+
+	md.LastValidatorDay = day.Unix()
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for validator day summaries")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to set validator day summaries")
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set validator day summaries")
+
+	return nil
+}
+
+// validatorSummariesForDay calculates the validator summaries for the given day from the
+// validator epoch summaries of its epochs.
+func (s *Service) validatorSummariesForDay(ctx context.Context,
+	day time.Time,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorDaySummary,
+	error,
+) {
+	accumulator := newDayAccumulator(day)
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		summaries, err := s.chainDB.(chaindb.ValidatorEpochSummariesProvider).ValidatorSummariesForEpoch(ctx, epoch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain validator summaries for epoch %d", epoch)
+		}
+		accumulator.add(summaries)
+	}
+
+	return accumulator.summaries(), nil
+}
+
+// pruneValidatorSummaries removes validator summaries that are older than their retention period.
+func (s *Service) pruneValidatorSummaries(ctx context.Context) error {
+	if !s.validatorSummaries {
+		return nil
+	}
+	if s.validatorEpochRetention == 0 && s.validatorDayRetention == 0 {
+		return nil
+	}
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata for validator summary pruning")
+	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to prune validator summaries")
+	}
+
+	now := time.Now()
+	if s.validatorEpochRetention > 0 {
+		pruneEpoch := s.chainTime.TimestampToEpoch(now.Add(-s.validatorEpochRetention))
+		if s.validatorDaySummaries {
+			// Retain epoch summaries that have yet to be included in a day summary.
+			firstEpoch, _ := s.dayEpochs(s.nextValidatorDay(md))
+			if pruneEpoch > firstEpoch {
+				pruneEpoch = firstEpoch
+			}
+		}
+		if err := s.chainDB.(chaindb.ValidatorSummariesPruner).PruneValidatorEpochSummaries(ctx, pruneEpoch); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune validator epoch summaries")
+		}
+	}
+	if s.validatorDayRetention > 0 {
+		pruneDay := now.Add(-s.validatorDayRetention).UTC().Truncate(24 * time.Hour)
+		if err := s.chainDB.(chaindb.ValidatorSummariesPruner).PruneValidatorDaySummaries(ctx, pruneDay); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to prune validator day summaries")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction to prune validator summaries")
+	}
+
+	return nil
+}
+
+// nextValidatorDay provides the start of the next day for which validator day summaries are required.
+func (s *Service) nextValidatorDay(md *metadata) time.Time {
+	if md.LastValidatorDay == 0 {
+		return s.chainTime.GenesisTime().UTC().Truncate(24 * time.Hour)
+	}
+	return time.Unix(md.LastValidatorDay, 0).UTC().Add(24 * time.Hour)
+}
+
+// dayEpochs provides the first and last epochs that start in the UTC day starting at the given time.
+func (s *Service) dayEpochs(day time.Time) (phase0.Epoch, phase0.Epoch) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	startEpoch := s.chainTime.TimestampToEpoch(start)
+	if s.chainTime.StartOfEpoch(startEpoch).Before(start) {
+		startEpoch++
+	}
+	endEpoch := s.chainTime.TimestampToEpoch(end.Add(-time.Nanosecond))
+
+	return startEpoch, endEpoch
+}
+
+// dayAccumulator accumulates validator epoch summaries in to validator day summaries.
+type dayAccumulator struct {
+	start           time.Time
+	daySummaries    map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary
+	inclusionDelays map[phase0.ValidatorIndex]int
+}
+
+// newDayAccumulator creates a new accumulator for the day starting at the given time.
+func newDayAccumulator(start time.Time) *dayAccumulator {
+	return &dayAccumulator{
+		start:           start,
+		daySummaries:    make(map[phase0.ValidatorIndex]*chaindb.ValidatorDaySummary),
+		inclusionDelays: make(map[phase0.ValidatorIndex]int),
+	}
+}
+
+// add adds validator epoch summaries to the day summaries.
+func (a *dayAccumulator) add(summaries []*chaindb.ValidatorEpochSummary) {
+	for _, summary := range summaries {
+		daySummary, exists := a.daySummaries[summary.Index]
+		if !exists {
+			daySummary = &chaindb.ValidatorDaySummary{
+				Index:          summary.Index,
+				StartTimestamp: a.start,
+			}
+			a.daySummaries[summary.Index] = daySummary
+		}
+		daySummary.ProposerDuties += summary.ProposerDuties
+		daySummary.ProposalsIncluded += summary.ProposalsIncluded
+		daySummary.SyncCommitteeParticipations += summary.SyncCommitteeParticipations
+		daySummary.SyncCommitteeMisses += summary.SyncCommitteeMisses
+		daySummary.AttestationDuties++
+		if !summary.AttestationIncluded {
+			continue
+		}
+		daySummary.AttestationsIncluded++
+		if summary.AttestationTargetCorrect != nil && *summary.AttestationTargetCorrect {
+			daySummary.AttestationsTargetCorrect++
+		}
+		if summary.AttestationHeadCorrect != nil && *summary.AttestationHeadCorrect {
+			daySummary.AttestationsHeadCorrect++
+		}
+		if summary.AttestationInclusionDelay != nil {
+			a.inclusionDelays[summary.Index] += *summary.AttestationInclusionDelay
+		}
+		if summary.AttestationSourceTimely != nil && *summary.AttestationSourceTimely {
+			daySummary.AttestationsSourceTimely++
+		}
+		if summary.AttestationTargetTimely != nil && *summary.AttestationTargetTimely {
+			daySummary.AttestationsTargetTimely++
+		}
+		if summary.AttestationHeadTimely != nil && *summary.AttestationHeadTimely {
+			daySummary.AttestationsHeadTimely++
+		}
+	}
+}
+
+// summaries provides the day summaries, ordered by validator index.
+func (a *dayAccumulator) summaries() []*chaindb.ValidatorDaySummary {
+	summaries := make([]*chaindb.ValidatorDaySummary, 0, len(a.daySummaries))
+	for index, summary := range a.daySummaries {
+		if summary.AttestationsIncluded > 0 {
+			summary.AttestationsInclusionDelay = float64(a.inclusionDelays[index]) / float64(summary.AttestationsIncluded)
+		}
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Index < summaries[j].Index
+	})
+
+	return summaries
+}
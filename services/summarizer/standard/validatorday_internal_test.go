@@ -0,0 +1,99 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestDayAccumulator(t *testing.T) {
+	yes := true
+	no := false
+	one := 1
+	three := 3
+	start := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	accumulator := newDayAccumulator(start)
+	accumulator.add([]*chaindb.ValidatorEpochSummary{
+		{
+			Index:                     2,
+			Epoch:                     10,
+			ProposerDuties:            1,
+			ProposalsIncluded:         1,
+			AttestationIncluded:       true,
+			AttestationTargetCorrect:  &yes,
+			AttestationHeadCorrect:    &yes,
+			AttestationInclusionDelay: &one,
+			AttestationSourceTimely:   &yes,
+			AttestationTargetTimely:   &yes,
+			AttestationHeadTimely:     &yes,
+		},
+		{
+			Index:                       1,
+			Epoch:                       10,
+			AttestationIncluded:         false,
+			SyncCommitteeParticipations: 30,
+			SyncCommitteeMisses:         2,
+		},
+	})
+	accumulator.add([]*chaindb.ValidatorEpochSummary{
+		{
+			Index:                     2,
+			Epoch:                     11,
+			ProposerDuties:            1,
+			AttestationIncluded:       true,
+			AttestationTargetCorrect:  &yes,
+			AttestationHeadCorrect:    &no,
+			AttestationInclusionDelay: &three,
+			AttestationSourceTimely:   &yes,
+			AttestationTargetTimely:   &no,
+			AttestationHeadTimely:     &no,
+		},
+		{
+			Index:                       1,
+			Epoch:                       11,
+			AttestationIncluded:         false,
+			SyncCommitteeParticipations: 31,
+			SyncCommitteeMisses:         1,
+		},
+	})
+
+	require.Equal(t, []*chaindb.ValidatorDaySummary{
+		{
+			Index:                       1,
+			StartTimestamp:              start,
+			AttestationDuties:           2,
+			SyncCommitteeParticipations: 61,
+			SyncCommitteeMisses:         3,
+		},
+		{
+			Index:                      2,
+			StartTimestamp:             start,
+			ProposerDuties:             2,
+			ProposalsIncluded:          1,
+			AttestationDuties:          2,
+			AttestationsIncluded:       2,
+			AttestationsTargetCorrect:  2,
+			AttestationsHeadCorrect:    1,
+			AttestationsInclusionDelay: 2,
+			AttestationsSourceTimely:   2,
+			AttestationsTargetTimely:   1,
+			AttestationsHeadTimely:     1,
+		},
+	}, accumulator.summaries())
+}
@@ -29,40 +29,81 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 ) error {
 	started := time.Now()
 
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 	if !s.validatorSummaries {
 		log.Trace().Msg("Validator epoch summaries not enabled")
 		return nil
 	}
 	log.Trace().Msg("Summarizing validator epoch")
 
-	proposerDuties, validatorProposerDuties, err := s.validatorProposerDutiesForEpoch(ctx, epoch)
+	summaries, err := s.validatorSummariesForEpoch(ctx, epoch)
 	if err != nil {
 		return err
 	}
+
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
+	}
+
+	if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator epoch summary")
+	}
+
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+	md.LastValidatorEpoch = epoch
+	if err := s.setMetadata(ctx, md); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
+	}
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set commit transaction to set validator epoch summary")
+	}
+
+	return nil
+}
+
+// validatorSummariesForEpoch calculates the validator summaries for the given epoch.
+func (s *Service) validatorSummariesForEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
+	started := time.Now()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
+
+	proposerDuties, validatorProposerDuties, err := s.validatorProposerDutiesForEpoch(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposer duties")
 
 	validatorProposals, err := s.validatorProposalsForEpoch(ctx, epoch, proposerDuties, validatorProposerDuties)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposals")
 
 	attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, err := s.attestationsForEpoch(ctx, epoch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched attestations")
 
-	// Store the data.
+	syncCommitteeParticipations, syncCommitteeMisses, err := s.syncCommitteeParticipationForEpoch(ctx, epoch)
+	if err != nil {
+		return nil, err
+	}
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched sync committee participation")
+
 	summaries := make([]*chaindb.ValidatorEpochSummary, 0, len(attestationsIncluded))
 	for index := range attestationsIncluded {
 		summary := &chaindb.ValidatorEpochSummary{
-			Index:               index,
-			Epoch:               epoch,
-			ProposerDuties:      validatorProposerDuties[index],
-			ProposalsIncluded:   validatorProposals[index],
-			AttestationIncluded: attestationsIncluded[index],
+			Index:                       index,
+			Epoch:                       epoch,
+			ProposerDuties:              validatorProposerDuties[index],
+			ProposalsIncluded:           validatorProposals[index],
+			AttestationIncluded:         attestationsIncluded[index],
+			SyncCommitteeParticipations: syncCommitteeParticipations[index],
+			SyncCommitteeMisses:         syncCommitteeMisses[index],
 		}
 		if summary.AttestationIncluded {
 			attestationTargetCorrect := attestationsTargetCorrect[index]
@@ -86,28 +127,7 @@ func (s *Service) summarizeValidatorsInEpoch(ctx context.Context,
 		summaries = append(summaries, summary)
 	}
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
-	}
-
-	if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set validator epoch summary")
-	}
-
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
-	md.LastValidatorEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set validator epoch summary")
-	}
-
-	return nil
+	return summaries, nil
 }
 
 func (s *Service) validatorProposerDutiesForEpoch(ctx context.Context,
@@ -203,7 +223,7 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 	if err != nil {
 		return nil, nil, nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain attestations for slot range")
 	}
-	log.Trace().Int("attestations", len(attestations)).Uint64("epoch", uint64(epoch)).Msg("Fetched attestations")
+	s.log.Trace().Int("attestations", len(attestations)).Uint64("epoch", uint64(epoch)).Msg("Fetched attestations")
 
 	// Mark up attestations for each validator.
 	attestationsIncluded := make(map[phase0.ValidatorIndex]bool)
@@ -215,7 +235,7 @@ func (s *Service) attestationsForEpoch(ctx context.Context,
 	attestationsHeadTimely := make(map[phase0.ValidatorIndex]bool)
 	for _, attestation := range attestations {
 		if attestation.Canonical == nil || !*attestation.Canonical {
-			log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation; ignoring")
+			s.log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation; ignoring")
 			continue
 		}
 		inclusionDelay := attestation.InclusionSlot - attestation.Slot
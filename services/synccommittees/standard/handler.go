@@ -16,6 +16,7 @@ package standard
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -30,14 +31,14 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 	defer s.activitySem.Release(1)
 
 	epoch := s.chainTime.SlotToEpoch(slot)
 	period := s.chainTime.EpochToSyncCommitteePeriod(epoch)
-	log := log.With().Uint64("slot", uint64(slot)).Uint64("epoch", uint64(epoch)).Uint64("period", period).Logger()
+	log := s.log.With().Uint64("slot", uint64(slot)).Uint64("epoch", uint64(epoch)).Uint64("period", period).Logger()
 
 	if period < s.chainTime.AltairInitialSyncCommitteePeriod() {
 		log.Trace().Msg("Chain is not yet generating sync committees")
@@ -63,13 +64,14 @@ func (s *Service) OnBeaconChainHeadUpdated(
 }
 
 func (s *Service) updateSyncCommitteeForPeriod(ctx context.Context, period uint64) error {
-	log.Trace().Uint64("period", period).Msg("Updating sync committee")
+	started := time.Now()
+	s.log.Trace().Uint64("period", period).Msg("Updating sync committee")
 
 	if period < s.chainTime.AltairInitialSyncCommitteePeriod() {
-		log.Trace().Uint64("period", period).Msg("period before Altair; nothing to do")
+		s.log.Trace().Uint64("period", period).Msg("period before Altair; nothing to do")
 	}
 
-	syncCommittee, err := s.syncCommitteesProvider.SyncCommittee(ctx, fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(s.chainTime.FirstEpochOfSyncPeriod(period))))
+	syncCommittee, err := s.syncCommitteesProvider.SyncCommittee(ctx, fmt.Sprintf("%d", s.chainTime.FirstSlotOfSyncPeriod(period)))
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch sync committee")
 	}
@@ -82,7 +84,8 @@ func (s *Service) updateSyncCommitteeForPeriod(ctx context.Context, period uint6
 		return errors.Wrap(err, "failed to set sync committee")
 	}
 
-	monitorPeriodProcessed(period)
+	s.monitorPeriodProcessed(period)
+	s.monitorProcessingDuration(time.Since(started))
 
 	return nil
 }
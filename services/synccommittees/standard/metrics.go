@@ -15,62 +15,109 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"go.uber.org/atomic"
 )
 
 var metricsNamespace = "chaind_synccommittees"
 
-var highestPeriod uint64
-var latestPeriod prometheus.Gauge
-var periodsProcessed prometheus.Gauge
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	highestPeriod      atomic.Uint64
+	latestPeriod       prometheus.Gauge
+	periodsProcessed   prometheus.Gauge
+	lagPeriods         prometheus.GaugeFunc
+	processingDuration prometheus.Histogram
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if latestPeriod != nil {
-		// Already registered.
-		return nil
-	}
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service, chainTime chaintime.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network), chainTime)
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
 // skipcq: RVV-B0012
-func registerPrometheusMetrics(ctx context.Context) error {
-	latestPeriod = prometheus.NewGauge(prometheus.GaugeOpts{
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer, chainTime chaintime.Service) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestPeriod = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_period",
 		Help:      "Latest sync committee period processed",
 	})
-	if err := prometheus.Register(latestPeriod); err != nil {
-		return errors.Wrap(err, "failed to register latest_period")
+	if err := registerer.Register(m.latestPeriod); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_period")
 	}
 
-	periodsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.periodsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "periods_processed",
 		Help:      "Number of periods processed",
 	})
-	if err := prometheus.Register(periodsProcessed); err != nil {
-		return errors.Wrap(err, "failed to register periods_processed")
+	if err := registerer.Register(m.periodsProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register periods_processed")
+	}
+
+	m.lagPeriods = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_periods",
+		Help:      "Number of periods between the current period and the latest period processed",
+	}, func() float64 {
+		current := chainTime.CurrentSyncCommitteePeriod()
+		latest := m.highestPeriod.Load()
+		if latest >= current {
+			return 0
+		}
+		return float64(current - latest)
+	})
+	if err := registerer.Register(m.lagPeriods); err != nil {
+		return nil, errors.Wrap(err, "failed to register lag_periods")
+	}
+
+	m.processingDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Time taken to process a sync committee period",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	})
+	if err := registerer.Register(m.processingDuration); err != nil {
+		return nil, errors.Wrap(err, "failed to register processing_duration_seconds")
 	}
 
-	return nil
+	return m, nil
 }
 
-func monitorPeriodProcessed(period uint64) {
-	if periodsProcessed != nil {
-		periodsProcessed.Inc()
-		if period > highestPeriod {
-			latestPeriod.Set(float64(period))
-			highestPeriod = period
+// monitorLatestPeriod sets the latest period without registering an
+// increase in periods processed.  This does not usually need to be
+// called directly, as it is called as part of monitorPeriodProcessed.
+func (s *Service) monitorLatestPeriod(period uint64) {
+	s.metrics.highestPeriod.Store(period)
+	if s.metrics.latestPeriod != nil {
+		s.metrics.latestPeriod.Set(float64(period))
+	}
+}
+
+func (s *Service) monitorPeriodProcessed(period uint64) {
+	if s.metrics.periodsProcessed != nil {
+		s.metrics.periodsProcessed.Inc()
+		if period > s.metrics.highestPeriod.Load() {
+			s.monitorLatestPeriod(period)
 		}
 	}
 }
+
+func (s *Service) monitorProcessingDuration(duration time.Duration) {
+	if s.metrics.processingDuration != nil {
+		s.metrics.processingDuration.Observe(duration.Seconds())
+	}
+}
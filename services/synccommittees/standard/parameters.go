@@ -25,6 +25,7 @@ import (
 
 type parameters struct {
 	logLevel     zerolog.Level
+	network      string
 	monitor      metrics.Service
 	eth2Client   eth2client.Service
 	chainDB      chaindb.Service
@@ -51,6 +52,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -20,14 +20,16 @@ import (
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a sync committee service.
 type Service struct {
+	log                          zerolog.Logger
+	metrics                      *serviceMetrics
 	eventsProvider               eth2client.EventsProvider
 	syncCommitteesProvider       eth2client.SyncCommitteesProvider
 	chainDB                      chaindb.Service
@@ -37,9 +39,6 @@ type Service struct {
 	epochsPerSyncCommitteePeriod uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -48,9 +47,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "synccommittees").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "synccommittees", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -78,6 +78,8 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:                          log,
+		metrics:                      svcMetrics,
 		eventsProvider:               parameters.eth2Client.(eth2client.EventsProvider),
 		syncCommitteesProvider:       parameters.eth2Client.(eth2client.SyncCommitteesProvider),
 		chainDB:                      parameters.chainDB,
@@ -97,8 +99,9 @@ func (s *Service) updateAfterRestart(ctx context.Context, startPeriod int64) {
 	// Work out the period from which to start.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
+	s.monitorLatestPeriod(md.LatestPeriod)
 	if startPeriod >= 0 {
 		// Explicit requirement to start at a given epoch.
 		// N.B. start period is latest period + 1.
@@ -112,16 +115,16 @@ func (s *Service) updateAfterRestart(ctx context.Context, startPeriod int64) {
 		}
 	}
 
-	log.Info().Uint64("period", md.LatestPeriod).Msg("Catching up from period")
+	s.log.Info().Uint64("period", md.LatestPeriod).Msg("Catching up from period")
 	s.catchup(ctx, md)
-	log.Info().Msg("Caught up")
+	s.log.Info().Msg("Caught up")
 
 	// Set up the handler for new chain head updates.
 	if err := s.eventsProvider.Events(ctx, []string{"head"}, func(event *api.Event) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot)
 	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add sync chain head updated handler")
+		s.log.Fatal().Err(err).Msg("Failed to add sync chain head updated handler")
 	}
 }
 
@@ -134,7 +137,7 @@ func (s *Service) catchup(ctx context.Context, md *metadata) {
 		period++
 	}
 	for ; period <= s.chainTime.CurrentSyncCommitteePeriod(); period++ {
-		log := log.With().Uint64("period", period).Logger()
+		log := s.log.With().Uint64("period", period).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
@@ -0,0 +1,32 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validatorkeys
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is the interface for a service that maps validator public keys to
+// indices, and indices to public keys.
+type Service interface {
+	// IndicesByPubKey provides the indices of the validators with the given public keys.
+	// Validators that are not known are not present in the result.
+	IndicesByPubKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]phase0.ValidatorIndex, error)
+
+	// PubKeysByIndex provides the public keys of the validators with the given indices.
+	// Validators that are not known are not present in the result.
+	PubKeysByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]phase0.BLSPubKey, error)
+}
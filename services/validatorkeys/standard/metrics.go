@@ -0,0 +1,74 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_validatorkeys"
+
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	lookups *prometheus.CounterVec
+	entries prometheus.Gauge
+}
+
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service) (*serviceMetrics, error) {
+	if monitor == nil {
+		// No monitor.
+		return &serviceMetrics{}, nil
+	}
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network))
+	}
+	return &serviceMetrics{}, nil
+}
+
+// skipcq: RVV-B0012
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.lookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "lookups_total",
+		Help:      "Number of validators looked up, by whether they were found in the cache",
+	}, []string{"result"})
+	if err := registerer.Register(m.lookups); err != nil {
+		return nil, errors.Wrap(err, "failed to register lookups_total")
+	}
+
+	m.entries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "entries",
+		Help:      "Number of validators in the cache",
+	})
+	if err := registerer.Register(m.entries); err != nil {
+		return nil, errors.Wrap(err, "failed to register entries")
+	}
+
+	return m, nil
+}
+
+func (s *Service) monitorLookups(hits int, misses int, size int) {
+	if s.metrics.lookups != nil {
+		s.metrics.lookups.WithLabelValues("hit").Add(float64(hits))
+		s.metrics.lookups.WithLabelValues("miss").Add(float64(misses))
+		s.metrics.entries.Set(float64(size))
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a cache mapping validator public keys to indices and back.  It is
+// populated lazily from the database as validators are looked up; a validator's
+// index never changes once assigned, so entries are never evicted.  Validators
+// not found in the database are not cached, so are picked up once indexed.
+type Service struct {
+	log                zerolog.Logger
+	metrics            *serviceMetrics
+	validatorsProvider chaindb.ValidatorsProvider
+	mu                 sync.RWMutex
+	indices            map[phase0.BLSPubKey]phase0.ValidatorIndex
+	pubKeys            map[phase0.ValidatorIndex]phase0.BLSPubKey
+}
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "validatorkeys", "standard", parameters.logLevel)
+
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor)
+	if err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	return &Service{
+		log:                log,
+		metrics:            svcMetrics,
+		validatorsProvider: parameters.chainDB.(chaindb.ValidatorsProvider),
+		indices:            make(map[phase0.BLSPubKey]phase0.ValidatorIndex),
+		pubKeys:            make(map[phase0.ValidatorIndex]phase0.BLSPubKey),
+	}, nil
+}
+
+// IndicesByPubKey provides the indices of the validators with the given public keys.
+// Validators that are not known are not present in the result.
+func (s *Service) IndicesByPubKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]phase0.ValidatorIndex, error) {
+	res := make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(pubKeys))
+	missing := make([]phase0.BLSPubKey, 0)
+	s.mu.RLock()
+	for _, pubKey := range pubKeys {
+		if index, exists := s.indices[pubKey]; exists {
+			res[pubKey] = index
+		} else {
+			missing = append(missing, pubKey)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(missing) > 0 {
+		validators, err := s.validatorsProvider.ValidatorsByPublicKey(ctx, missing)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators by public key")
+		}
+		for pubKey, validator := range validators {
+			res[pubKey] = validator.Index
+		}
+		s.add(validators)
+	}
+	s.monitor(len(pubKeys)-len(missing), len(missing))
+
+	return res, nil
+}
+
+// PubKeysByIndex provides the public keys of the validators with the given indices.
+// Validators that are not known are not present in the result.
+func (s *Service) PubKeysByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]phase0.BLSPubKey, error) {
+	res := make(map[phase0.ValidatorIndex]phase0.BLSPubKey, len(indices))
+	missing := make([]phase0.ValidatorIndex, 0)
+	s.mu.RLock()
+	for _, index := range indices {
+		if pubKey, exists := s.pubKeys[index]; exists {
+			res[index] = pubKey
+		} else {
+			missing = append(missing, index)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(missing) > 0 {
+		validators, err := s.validatorsProvider.ValidatorsByIndex(ctx, missing)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators by index")
+		}
+		byPubKey := make(map[phase0.BLSPubKey]*chaindb.Validator, len(validators))
+		for index, validator := range validators {
+			res[index] = validator.PublicKey
+			byPubKey[validator.PublicKey] = validator
+		}
+		s.add(byPubKey)
+	}
+	s.monitor(len(indices)-len(missing), len(missing))
+
+	return res, nil
+}
+
+// add adds validators to the cache.
+func (s *Service) add(validators map[phase0.BLSPubKey]*chaindb.Validator) {
+	if len(validators) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	for pubKey, validator := range validators {
+		s.indices[pubKey] = validator.Index
+		s.pubKeys[validator.Index] = pubKey
+	}
+	s.mu.Unlock()
+	s.log.Trace().Int("validators", len(validators)).Msg("Added validators to cache")
+}
+
+// monitor records the result of a lookup.
+func (s *Service) monitor(hits int, misses int) {
+	s.mu.RLock()
+	size := len(s.indices)
+	s.mu.RUnlock()
+	s.monitorLookups(hits, misses, size)
+}
@@ -0,0 +1,146 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/validatorkeys"
+	"github.com/wealdtech/chaind/services/validatorkeys/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(mockchaindb.New()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	s, err := standard.New(context.Background(),
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(mockchaindb.New()),
+	)
+	require.NoError(t, err)
+	require.Implements(t, (*validatorkeys.Service)(nil), s)
+}
+
+func TestIndicesByPubKey(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+	)
+	require.NoError(t, err)
+
+	validator1 := &chaindb.Validator{PublicKey: phase0.BLSPubKey{0x01}, Index: 1}
+	chainDB.SetResponse("ValidatorsByPublicKey", map[phase0.BLSPubKey]*chaindb.Validator{
+		validator1.PublicKey: validator1,
+	})
+
+	// The first lookup is from the database; an unknown validator is not present.
+	indices, err := s.IndicesByPubKey(ctx, []phase0.BLSPubKey{{0x01}, {0x02}})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.BLSPubKey]phase0.ValidatorIndex{{0x01}: 1}, indices)
+	calls := chainDB.CallsTo("ValidatorsByPublicKey")
+	require.Len(t, calls, 1)
+	require.Equal(t, []phase0.BLSPubKey{{0x01}, {0x02}}, calls[0].Args[0])
+
+	// Later lookups only go to the database for validators not in the cache.
+	chainDB.ResetCalls()
+	indices, err = s.IndicesByPubKey(ctx, []phase0.BLSPubKey{{0x01}})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.BLSPubKey]phase0.ValidatorIndex{{0x01}: 1}, indices)
+	require.Empty(t, chainDB.Calls())
+	_, err = s.IndicesByPubKey(ctx, []phase0.BLSPubKey{{0x01}, {0x02}})
+	require.NoError(t, err)
+	calls = chainDB.CallsTo("ValidatorsByPublicKey")
+	require.Len(t, calls, 1)
+	require.Equal(t, []phase0.BLSPubKey{{0x02}}, calls[0].Args[0])
+
+	// The reverse mapping is populated at the same time.
+	chainDB.ResetCalls()
+	pubKeys, err := s.PubKeysByIndex(ctx, []phase0.ValidatorIndex{1})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex]phase0.BLSPubKey{1: {0x01}}, pubKeys)
+	require.Empty(t, chainDB.Calls())
+}
+
+func TestPubKeysByIndex(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+	)
+	require.NoError(t, err)
+
+	chainDB.SetError("ValidatorsByIndex", errors.New("mock error"))
+	_, err = s.PubKeysByIndex(ctx, []phase0.ValidatorIndex{2})
+	require.EqualError(t, err, "failed to obtain validators by index: mock error")
+
+	validator2 := &chaindb.Validator{PublicKey: phase0.BLSPubKey{0x02}, Index: 2}
+	chainDB.SetError("ValidatorsByIndex", nil)
+	chainDB.SetResponse("ValidatorsByIndex", map[phase0.ValidatorIndex]*chaindb.Validator{
+		validator2.Index: validator2,
+	})
+	pubKeys, err := s.PubKeysByIndex(ctx, []phase0.ValidatorIndex{2})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.ValidatorIndex]phase0.BLSPubKey{2: {0x02}}, pubKeys)
+
+	chainDB.ResetCalls()
+	indices, err := s.IndicesByPubKey(ctx, []phase0.BLSPubKey{{0x02}})
+	require.NoError(t, err)
+	require.Equal(t, map[phase0.BLSPubKey]phase0.ValidatorIndex{{0x02}: 2}, indices)
+	require.Empty(t, chainDB.Calls())
+}
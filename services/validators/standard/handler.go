@@ -16,11 +16,13 @@ package standard
 import (
 	"context"
 	"fmt"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -34,7 +36,7 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	epochTransition bool,
 ) {
 	epoch := s.chainTime.SlotToEpoch(slot)
-	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 
 	if !epochTransition {
 		// Only interested in epoch transitions.
@@ -64,7 +66,7 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	}
 	s.activitySem.Release(1)
 
-	monitorEpochProcessed(epoch)
+	s.monitorEpochProcessed(epoch)
 	log.Trace().Msg("Finished handling epoch transition")
 }
 
@@ -72,6 +74,7 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 	md *metadata,
 	transitionedEpoch phase0.Epoch,
 ) error {
+	started := time.Now()
 	// We always fetch the latest validator information regardless of epoch.
 	validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, "head", nil)
 	if err != nil {
@@ -107,7 +110,8 @@ func (s *Service) onEpochTransitionValidators(ctx context.Context,
 		cancel()
 		return errors.Wrap(err, "failed to set commit transaction for validators")
 	}
-	monitorEpochProcessed(transitionedEpoch)
+	s.monitorEpochProcessed(transitionedEpoch)
+	s.monitorProcessingDuration(time.Since(started))
 
 	return nil
 }
@@ -126,8 +130,12 @@ func (s *Service) onEpochTransitionValidatorBalances(ctx context.Context,
 	if firstEpoch > 0 {
 		firstEpoch++
 	}
+	if windowStart := chaintime.WindowStart(s.chainTime, s.window); firstEpoch < windowStart {
+		// Balances before the rolling window would be pruned, so do not fetch them.
+		firstEpoch = windowStart
+	}
 	for epoch := firstEpoch; epoch <= transitionedEpoch; epoch++ {
-		log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+		log := s.log.With().Uint64("epoch", uint64(epoch)).Logger()
 		stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
 		log.Trace().Uint64("slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch))).Msg("Fetching validators")
 		validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, nil)
@@ -176,7 +184,7 @@ func (s *Service) onEpochTransitionValidatorBalances(ctx context.Context,
 			cancel()
 			return errors.Wrap(err, "failed to set commit transaction for validator balances")
 		}
-		monitorBalancesEpochProcessed(epoch)
+		s.monitorBalancesEpochProcessed(epoch)
 	}
 
 	return nil
@@ -15,94 +15,140 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"go.uber.org/atomic"
 )
 
 var metricsNamespace = "chaind_validators"
 
-var highestEpoch phase0.Epoch
-var latestEpoch prometheus.Gauge
-var epochsProcessed prometheus.Gauge
-
-var balancesHighestEpoch phase0.Epoch
-var balancesLatestEpoch prometheus.Gauge
-var balancesEpochsProcessed prometheus.Gauge
+// serviceMetrics holds the metrics of an instance of the service.
+type serviceMetrics struct {
+	highestEpoch            atomic.Uint64
+	latestEpoch             prometheus.Gauge
+	epochsProcessed         prometheus.Gauge
+	lagEpochs               prometheus.GaugeFunc
+	processingDuration      prometheus.Histogram
+	balancesHighestEpoch    phase0.Epoch
+	balancesLatestEpoch     prometheus.Gauge
+	balancesEpochsProcessed prometheus.Gauge
+}
 
-func registerMetrics(ctx context.Context, monitor metrics.Service) error {
-	if latestEpoch != nil {
-		// Already registered.
-		return nil
-	}
+func registerMetrics(ctx context.Context, network string, monitor metrics.Service, chainTime chaintime.Service) (*serviceMetrics, error) {
 	if monitor == nil {
 		// No monitor.
-		return nil
+		return &serviceMetrics{}, nil
 	}
-	if monitor.Presenter() == "prometheus" {
-		return registerPrometheusMetrics(ctx)
+	if monitor.Presenter() != "null" {
+		return registerPrometheusMetrics(ctx, metrics.NewRegisterer(network), chainTime)
 	}
-	return nil
+	return &serviceMetrics{}, nil
 }
 
-func registerPrometheusMetrics(ctx context.Context) error {
-	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+func registerPrometheusMetrics(ctx context.Context, registerer prometheus.Registerer, chainTime chaintime.Service) (*serviceMetrics, error) {
+	m := &serviceMetrics{}
+
+	m.latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "latest_epoch",
 		Help:      "Latest epoch processed for validators",
 	})
-	if err := prometheus.Register(latestEpoch); err != nil {
-		return errors.Wrap(err, "failed to register latest_epoch")
+	if err := registerer.Register(m.latestEpoch); err != nil {
+		return nil, errors.Wrap(err, "failed to register latest_epoch")
 	}
 
-	epochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.epochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "epochs_processed",
 		Help:      "Number of epochs processed",
 	})
-	if err := prometheus.Register(epochsProcessed); err != nil {
-		return errors.Wrap(err, "failed to register epochs_processed")
+	if err := registerer.Register(m.epochsProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register epochs_processed")
 	}
 
-	balancesLatestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.balancesLatestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "balances_latest_epoch",
 		Help:      "Latest epoch processed for validators balances",
 	})
-	if err := prometheus.Register(balancesLatestEpoch); err != nil {
-		return errors.Wrap(err, "failed to register balances_latest_epoch")
+	if err := registerer.Register(m.balancesLatestEpoch); err != nil {
+		return nil, errors.Wrap(err, "failed to register balances_latest_epoch")
 	}
 
-	balancesEpochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
+	m.balancesEpochsProcessed = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Name:      "balances_epochs_processed",
 		Help:      "Number of epochs processed",
 	})
-	if err := prometheus.Register(balancesEpochsProcessed); err != nil {
-		return errors.Wrap(err, "failed to register balances_epochs_processed")
+	if err := registerer.Register(m.balancesEpochsProcessed); err != nil {
+		return nil, errors.Wrap(err, "failed to register balances_epochs_processed")
+	}
+
+	m.lagEpochs = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_epochs",
+		Help:      "Number of epochs between the current epoch and the latest epoch processed",
+	}, func() float64 {
+		current := chainTime.CurrentEpoch()
+		latest := phase0.Epoch(m.highestEpoch.Load())
+		if latest >= current {
+			return 0
+		}
+		return float64(current - latest)
+	})
+	if err := registerer.Register(m.lagEpochs); err != nil {
+		return nil, errors.Wrap(err, "failed to register lag_epochs")
 	}
 
-	return nil
+	m.processingDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Time taken to process an epoch",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	})
+	if err := registerer.Register(m.processingDuration); err != nil {
+		return nil, errors.Wrap(err, "failed to register processing_duration_seconds")
+	}
+
+	return m, nil
 }
 
-func monitorEpochProcessed(epoch phase0.Epoch) {
-	if epochsProcessed != nil {
-		epochsProcessed.Inc()
-		if epoch > highestEpoch {
-			latestEpoch.Set(float64(epoch))
-			highestEpoch = epoch
+// monitorLatestEpoch sets the latest epoch without registering an
+// increase in epochs processed.  This does not usually need to be
+// called directly, as it is called as part of monitorEpochProcessed.
+func (s *Service) monitorLatestEpoch(epoch phase0.Epoch) {
+	s.metrics.highestEpoch.Store(uint64(epoch))
+	if s.metrics.latestEpoch != nil {
+		s.metrics.latestEpoch.Set(float64(epoch))
+	}
+}
+
+func (s *Service) monitorEpochProcessed(epoch phase0.Epoch) {
+	if s.metrics.epochsProcessed != nil {
+		s.metrics.epochsProcessed.Inc()
+		if epoch > phase0.Epoch(s.metrics.highestEpoch.Load()) {
+			s.monitorLatestEpoch(epoch)
 		}
 	}
 }
 
-func monitorBalancesEpochProcessed(epoch phase0.Epoch) {
-	if balancesEpochsProcessed != nil {
-		balancesEpochsProcessed.Inc()
-		if epoch > balancesHighestEpoch {
-			balancesLatestEpoch.Set(float64(epoch))
-			balancesHighestEpoch = epoch
+func (s *Service) monitorBalancesEpochProcessed(epoch phase0.Epoch) {
+	if s.metrics.balancesEpochsProcessed != nil {
+		s.metrics.balancesEpochsProcessed.Inc()
+		if epoch > s.metrics.balancesHighestEpoch {
+			s.metrics.balancesLatestEpoch.Set(float64(epoch))
+			s.metrics.balancesHighestEpoch = epoch
 		}
 	}
 }
+
+func (s *Service) monitorProcessingDuration(duration time.Duration) {
+	if s.metrics.processingDuration != nil {
+		s.metrics.processingDuration.Observe(duration.Seconds())
+	}
+}
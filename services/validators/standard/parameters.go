@@ -25,12 +25,14 @@ import (
 
 type parameters struct {
 	logLevel   zerolog.Level
+	network    string
 	monitor    metrics.Service
 	eth2Client eth2client.Service
 	chainDB    chaindb.Service
 	chainTime  chaintime.Service
 	balances   bool
 	startEpoch int64
+	window     uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -51,6 +53,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -93,6 +102,13 @@ func WithBalances(balances bool) Parameter {
 	})
 }
 
+// WithWindow sets the number of recent epochs for which validator balances are kept, with 0 keeping all epochs.
+func WithWindow(window uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.window = window
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -20,25 +20,25 @@ import (
 	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
+	log              zerolog.Logger
+	metrics          *serviceMetrics
 	eth2Client       eth2client.Service
 	chainDB          chaindb.Service
 	validatorsSetter chaindb.ValidatorsSetter
 	chainTime        chaintime.Service
 	balances         bool
 	activitySem      *semaphore.Weighted
+	window           uint64
 }
 
-// module-wide log.
-var log zerolog.Logger
-
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -47,9 +47,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	// Set logging.
-	log = zerologger.With().Str("service", "validators").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	log := util.NetworkServiceLogger(parameters.network, "validators", "standard", parameters.logLevel)
 
-	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+	svcMetrics, err := registerMetrics(ctx, parameters.network, parameters.monitor, parameters.chainTime)
+	if err != nil {
 		return nil, errors.New("failed to register metrics")
 	}
 
@@ -59,8 +60,11 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
+		log:              log,
+		metrics:          svcMetrics,
 		eth2Client:       parameters.eth2Client,
 		chainDB:          parameters.chainDB,
+		window:           parameters.window,
 		validatorsSetter: validatorsSetter,
 		chainTime:        parameters.chainTime,
 		balances:         parameters.balances,
@@ -77,51 +81,52 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		s.log.Debug().Msg("Another handler running")
 		return
 	}
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
 		s.activitySem.Release(1)
-		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
+		s.log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
+	s.monitorLatestEpoch(md.LatestEpoch)
 	if startEpoch >= 0 {
 		// Explicit requirement to start at a given epoch; update metadata accordingly.
 		ctx, cancel, err := s.chainDB.BeginTx(ctx)
 		if err != nil {
 			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to begin transaction to set start epoch")
+			s.log.Fatal().Err(err).Msg("Failed to begin transaction to set start epoch")
 		}
 		if err := s.setMetadata(ctx, md); err != nil {
 			cancel()
 			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to set metadata with start epoch")
+			s.log.Fatal().Err(err).Msg("Failed to set metadata with start epoch")
 		}
 		if err := s.chainDB.CommitTx(ctx); err != nil {
 			cancel()
 			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to commit transaction to set start epoch")
+			s.log.Fatal().Err(err).Msg("Failed to commit transaction to set start epoch")
 		}
 	}
 
-	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
+	s.log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 	currentEpoch := s.chainTime.CurrentEpoch()
 	if err := s.onEpochTransitionValidators(ctx, md, currentEpoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update to head; will retry")
+		s.log.Warn().Err(err).Msg("Failed to update to head; will retry")
 	}
 	if err := s.onEpochTransitionValidatorBalances(ctx, md, currentEpoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update validators")
+		s.log.Warn().Err(err).Msg("Failed to update validators")
 	}
 	s.activitySem.Release(1)
 
-	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Caught up")
+	s.log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Caught up")
 
 	// Set up the handler for new chain head updates.
 	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
 	}); err != nil {
-		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
+		s.log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
@@ -0,0 +1,40 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifier
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Mismatch is a difference between the data held in the database and that held by the beacon node.
+type Mismatch struct {
+	// Kind is the kind of data that differs: "block", "attestation" or "validator".
+	Kind string
+	// Subject identifies the data that differs, for example "slot 1234".
+	Subject string
+	// Detail describes the difference.
+	Detail string
+	// Repaired is true if the database has been updated to match the beacon node.
+	Repaired bool
+}
+
+// Service defines a verifier service.
+type Service interface {
+	// Verify compares a sample of the slots in the given range, and of the validators,
+	// with the beacon node, returning any mismatches found.
+	// Ranges are inclusive of start and exclusive of end.
+	Verify(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*Mismatch, error)
+}
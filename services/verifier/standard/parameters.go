@@ -0,0 +1,133 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+type parameters struct {
+	logLevel         zerolog.Level
+	network          string
+	eth2Client       eth2client.Service
+	chainDB          chaindb.Service
+	blocks           blocks.Service
+	slotSamples      int
+	validatorSamples int
+	repair           bool
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithNetwork sets the name of the network for which the module runs, if chaind indexes more than one.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithBlocks sets the blocks service for this module, used to repair blocks.
+func WithBlocks(blocks blocks.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blocks = blocks
+	})
+}
+
+// WithSlotSamples sets the number of slots to verify.
+func WithSlotSamples(samples int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotSamples = samples
+	})
+}
+
+// WithValidatorSamples sets the number of validators to verify.
+func WithValidatorSamples(samples int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorSamples = samples
+	})
+}
+
+// WithRepair sets whether mismatches are repaired.
+func WithRepair(repair bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.repair = repair
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:         zerolog.GlobalLevel(),
+		slotSamples:      32,
+		validatorSamples: 64,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.slotSamples < 0 {
+		return nil, errors.New("slot samples cannot be negative")
+	}
+	if parameters.validatorSamples < 0 {
+		return nil, errors.New("validator samples cannot be negative")
+	}
+	if parameters.repair && parameters.blocks == nil {
+		return nil, errors.New("no blocks specified for repair")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,106 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/blocks"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a verifier service.
+type Service struct {
+	log                       zerolog.Logger
+	signedBeaconBlockProvider eth2client.SignedBeaconBlockProvider
+	validatorsProvider        eth2client.ValidatorsProvider
+	chainDB                   chaindb.Service
+	blocksProvider            chaindb.BlocksProvider
+	blocksSetter              chaindb.BlocksSetter
+	attestationsProvider      chaindb.AttestationsProvider
+	dbValidatorsProvider      chaindb.ValidatorsProvider
+	validatorsSetter          chaindb.ValidatorsSetter
+	blocks                    blocks.Service
+	slotSamples               int
+	validatorSamples          int
+	repair                    bool
+}
+
+// New creates a new service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log := util.NetworkServiceLogger(parameters.network, "verifier", "standard", parameters.logLevel)
+
+	signedBeaconBlockProvider, isProvider := parameters.eth2Client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide signed beacon blocks")
+	}
+
+	validatorsProvider, isProvider := parameters.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide validators")
+	}
+
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	blocksSetter, isBlocksSetter := parameters.chainDB.(chaindb.BlocksSetter)
+	if !isBlocksSetter {
+		return nil, errors.New("chain DB does not support block setting")
+	}
+
+	attestationsProvider, isAttestationsProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isAttestationsProvider {
+		return nil, errors.New("chain DB does not support attestation providing")
+	}
+
+	dbValidatorsProvider, isValidatorsProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isValidatorsProvider {
+		return nil, errors.New("chain DB does not support validator providing")
+	}
+
+	validatorsSetter, isValidatorsSetter := parameters.chainDB.(chaindb.ValidatorsSetter)
+	if !isValidatorsSetter {
+		return nil, errors.New("chain DB does not support validator setting")
+	}
+
+	s := &Service{
+		log:                       log,
+		signedBeaconBlockProvider: signedBeaconBlockProvider,
+		validatorsProvider:        validatorsProvider,
+		chainDB:                   parameters.chainDB,
+		blocksProvider:            blocksProvider,
+		blocksSetter:              blocksSetter,
+		attestationsProvider:      attestationsProvider,
+		dbValidatorsProvider:      dbValidatorsProvider,
+		validatorsSetter:          validatorsSetter,
+		blocks:                    parameters.blocks,
+		slotSamples:               parameters.slotSamples,
+		validatorSamples:          parameters.validatorSamples,
+		repair:                    parameters.repair,
+	}
+
+	return s, nil
+}
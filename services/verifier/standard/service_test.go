@@ -0,0 +1,223 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockblocks "github.com/wealdtech/chaind/services/blocks/mock"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/verifier/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	eth2Client, err := mock.New(ctx)
+	require.NoError(t, err)
+	chainDB := mockchaindb.New()
+	blocks := mockblocks.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "SlotSamplesNegative",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithSlotSamples(-1),
+			},
+			err: "problem with parameters: slot samples cannot be negative",
+		},
+		{
+			name: "ValidatorSamplesNegative",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithValidatorSamples(-1),
+			},
+			err: "problem with parameters: validator samples cannot be negative",
+		},
+		{
+			name: "RepairBlocksMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithRepair(true),
+			},
+			err: "problem with parameters: no blocks specified for repair",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+			},
+		},
+		{
+			name: "GoodRepair",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithBlocks(blocks),
+				standard.WithRepair(true),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// blockProvider provides a block for every slot.
+type blockProvider struct {
+	*mock.Service
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (*blockProvider) SignedBeaconBlock(_ context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	slot, err := strconv.ParseUint(blockID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionPhase0,
+		Phase0: &phase0.SignedBeaconBlock{
+			Message: &phase0.BeaconBlock{
+				Slot: phase0.Slot(slot),
+				Body: &phase0.BeaconBlockBody{
+					ETH1Data: &phase0.ETH1Data{
+						BlockHash: make([]byte, 32),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	// The client has a block for every slot, and the mock database has none.
+	mockClient, err := mock.New(ctx)
+	require.NoError(t, err)
+	eth2Client := &blockProvider{Service: mockClient}
+
+	tests := []struct {
+		name       string
+		params     []standard.Parameter
+		startSlot  uint64
+		endSlot    uint64
+		mismatches int
+		repaired   bool
+		err        string
+	}{
+		{
+			name:      "EndBeforeStart",
+			startSlot: 10,
+			endSlot:   5,
+			err:       "end slot before start slot",
+		},
+		{
+			name:       "Sampled",
+			params:     []standard.Parameter{standard.WithSlotSamples(4)},
+			startSlot:  0,
+			endSlot:    100,
+			mismatches: 4,
+		},
+		{
+			name:       "AllSlots",
+			params:     []standard.Parameter{standard.WithSlotSamples(100)},
+			startSlot:  10,
+			endSlot:    20,
+			mismatches: 10,
+		},
+		{
+			name: "Repair",
+			params: []standard.Parameter{
+				standard.WithSlotSamples(5),
+				standard.WithBlocks(mockblocks.New()),
+				standard.WithRepair(true),
+			},
+			startSlot:  0,
+			endSlot:    5,
+			mismatches: 5,
+			repaired:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := append([]standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(mockchaindb.New()),
+			}, test.params...)
+			s, err := standard.New(ctx, params...)
+			require.NoError(t, err)
+
+			mismatches, err := s.Verify(ctx, phase0.Slot(test.startSlot), phase0.Slot(test.endSlot))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, mismatches, test.mismatches)
+			for _, mismatch := range mismatches {
+				require.Equal(t, "block", mismatch.Kind)
+				require.Equal(t, test.repaired, mismatch.Repaired)
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/verifier"
+)
+
+// Verify compares a sample of the slots in the given range, and of the validators,
+// with the beacon node, returning any mismatches found.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) Verify(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*verifier.Mismatch, error) {
+	if endSlot < startSlot {
+		return nil, errors.New("end slot before start slot")
+	}
+
+	mismatches := make([]*verifier.Mismatch, 0)
+	for _, slot := range sample(uint64(startSlot), uint64(endSlot), s.slotSamples) {
+		s.log.Trace().Uint64("slot", slot).Msg("Verifying slot")
+		slotMismatches, err := s.verifySlot(ctx, phase0.Slot(slot))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to verify slot %d", slot))
+		}
+		mismatches = append(mismatches, slotMismatches...)
+	}
+
+	validatorMismatches, err := s.verifyValidators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify validators")
+	}
+	mismatches = append(mismatches, validatorMismatches...)
+
+	return mismatches, nil
+}
+
+// verifySlot compares the block, and its attestations, for the given slot.
+func (s *Service) verifySlot(ctx context.Context, slot phase0.Slot) ([]*verifier.Mismatch, error) {
+	subject := fmt.Sprintf("slot %d", slot)
+
+	signedBlock, err := s.signedBeaconBlockProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain beacon block")
+	}
+	var root phase0.Root
+	if signedBlock != nil {
+		root, err = signedBlock.Root()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate block root")
+		}
+	}
+
+	dbBlocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain database blocks")
+	}
+
+	mismatches := make([]*verifier.Mismatch, 0)
+	var dbBlock *chaindb.Block
+	for _, block := range dbBlocks {
+		if signedBlock != nil && bytes.Equal(block.Root[:], root[:]) {
+			dbBlock = block
+			continue
+		}
+		if block.Canonical != nil && *block.Canonical {
+			mismatch := &verifier.Mismatch{
+				Kind:    "block",
+				Subject: subject,
+				Detail:  fmt.Sprintf("block %#x is canonical in the database but not on the beacon node", block.Root),
+			}
+			if s.repair {
+				if err := s.setCanonical(ctx, block, false); err != nil {
+					return nil, err
+				}
+				mismatch.Repaired = true
+			}
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+	if signedBlock == nil {
+		return mismatches, nil
+	}
+
+	if dbBlock == nil {
+		mismatch := &verifier.Mismatch{
+			Kind:    "block",
+			Subject: subject,
+			Detail:  fmt.Sprintf("block %#x is missing from the database", root),
+		}
+		if s.repair {
+			if err := s.repairBlock(ctx, signedBlock, root, nil); err != nil {
+				return nil, err
+			}
+			mismatch.Repaired = true
+		}
+		return append(mismatches, mismatch), nil
+	}
+
+	blockMismatches := make([]*verifier.Mismatch, 0)
+	canonical := dbBlock.Canonical
+	if dbBlock.Canonical != nil && !*dbBlock.Canonical {
+		blockMismatches = append(blockMismatches, &verifier.Mismatch{
+			Kind:    "block",
+			Subject: subject,
+			Detail:  fmt.Sprintf("block %#x is canonical on the beacon node but not in the database", root),
+		})
+		isCanonical := true
+		canonical = &isCanonical
+	}
+	details, err := compareBlock(dbBlock, signedBlock)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		blockMismatches = append(blockMismatches, &verifier.Mismatch{
+			Kind:    "block",
+			Subject: subject,
+			Detail:  detail,
+		})
+	}
+	details, err = s.compareAttestations(ctx, root, signedBlock)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		blockMismatches = append(blockMismatches, &verifier.Mismatch{
+			Kind:    "attestation",
+			Subject: subject,
+			Detail:  detail,
+		})
+	}
+
+	if len(blockMismatches) > 0 && s.repair {
+		if err := s.repairBlock(ctx, signedBlock, root, canonical); err != nil {
+			return nil, err
+		}
+		for _, mismatch := range blockMismatches {
+			mismatch.Repaired = true
+		}
+	}
+
+	return append(mismatches, blockMismatches...), nil
+}
+
+// compareBlock compares the header information of a database block with that from the beacon node.
+func compareBlock(dbBlock *chaindb.Block, signedBlock *spec.VersionedSignedBeaconBlock) ([]string, error) {
+	parentRoot, err := signedBlock.ParentRoot()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain parent root")
+	}
+	stateRoot, err := signedBlock.StateRoot()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain state root")
+	}
+	bodyRoot, err := signedBlock.BodyRoot()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain body root")
+	}
+
+	details := make([]string, 0)
+	if !bytes.Equal(dbBlock.ParentRoot[:], parentRoot[:]) {
+		details = append(details, fmt.Sprintf("parent root is %#x in the database but %#x on the beacon node", dbBlock.ParentRoot, parentRoot))
+	}
+	if !bytes.Equal(dbBlock.StateRoot[:], stateRoot[:]) {
+		details = append(details, fmt.Sprintf("state root is %#x in the database but %#x on the beacon node", dbBlock.StateRoot, stateRoot))
+	}
+	if !bytes.Equal(dbBlock.BodyRoot[:], bodyRoot[:]) {
+		details = append(details, fmt.Sprintf("body root is %#x in the database but %#x on the beacon node", dbBlock.BodyRoot, bodyRoot))
+	}
+
+	return details, nil
+}
+
+// compareAttestations compares the attestations in a database block with those from the beacon node.
+func (s *Service) compareAttestations(ctx context.Context,
+	root phase0.Root,
+	signedBlock *spec.VersionedSignedBeaconBlock,
+) (
+	[]string,
+	error,
+) {
+	attestations, err := signedBlock.Attestations()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	dbAttestations, err := s.attestationsProvider.AttestationsInBlock(ctx, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain database attestations")
+	}
+
+	details := make([]string, 0)
+	if len(dbAttestations) != len(attestations) {
+		details = append(details, fmt.Sprintf("block has %d attestations in the database but %d on the beacon node", len(dbAttestations), len(attestations)))
+	}
+
+	dbAttestationsByIndex := make(map[uint64]*chaindb.Attestation, len(dbAttestations))
+	for _, dbAttestation := range dbAttestations {
+		dbAttestationsByIndex[dbAttestation.InclusionIndex] = dbAttestation
+	}
+	for i, attestation := range attestations {
+		dbAttestation, exists := dbAttestationsByIndex[uint64(i)]
+		if !exists {
+			details = append(details, fmt.Sprintf("attestation %d is missing from the database", i))
+			continue
+		}
+		if fields := compareAttestation(dbAttestation, attestation); len(fields) > 0 {
+			details = append(details, fmt.Sprintf("attestation %d has a different %s in the database", i, strings.Join(fields, ", ")))
+		}
+	}
+
+	return details, nil
+}
+
+// compareAttestation returns the names of the fields that differ between a database attestation and that from the beacon node.
+func compareAttestation(dbAttestation *chaindb.Attestation, attestation *phase0.Attestation) []string {
+	fields := make([]string, 0)
+	if dbAttestation.Slot != attestation.Data.Slot {
+		fields = append(fields, "slot")
+	}
+	if dbAttestation.CommitteeIndex != attestation.Data.Index {
+		fields = append(fields, "committee index")
+	}
+	if !bytes.Equal(dbAttestation.AggregationBits, attestation.AggregationBits) {
+		fields = append(fields, "aggregation bits")
+	}
+	if !bytes.Equal(dbAttestation.BeaconBlockRoot[:], attestation.Data.BeaconBlockRoot[:]) {
+		fields = append(fields, "beacon block root")
+	}
+	if dbAttestation.SourceEpoch != attestation.Data.Source.Epoch ||
+		!bytes.Equal(dbAttestation.SourceRoot[:], attestation.Data.Source.Root[:]) {
+		fields = append(fields, "source")
+	}
+	if dbAttestation.TargetEpoch != attestation.Data.Target.Epoch ||
+		!bytes.Equal(dbAttestation.TargetRoot[:], attestation.Data.Target.Root[:]) {
+		fields = append(fields, "target")
+	}
+
+	return fields
+}
+
+// verifyValidators compares a sample of the validators in the database with those on the beacon node.
+func (s *Service) verifyValidators(ctx context.Context) ([]*verifier.Mismatch, error) {
+	if s.validatorSamples == 0 {
+		return nil, nil
+	}
+
+	dbValidators, err := s.dbValidatorsProvider.Validators(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain database validators")
+	}
+	if len(dbValidators) == 0 {
+		return nil, nil
+	}
+
+	picks := sample(0, uint64(len(dbValidators)), s.validatorSamples)
+	indices := make([]phase0.ValidatorIndex, len(picks))
+	dbValidatorsByIndex := make(map[phase0.ValidatorIndex]*chaindb.Validator, len(picks))
+	for i, pick := range picks {
+		indices[i] = dbValidators[pick].Index
+		dbValidatorsByIndex[dbValidators[pick].Index] = dbValidators[pick]
+	}
+
+	// Validators are updated from the head state, so compare with that.
+	validators, err := s.validatorsProvider.Validators(ctx, "head", indices)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	mismatches := make([]*verifier.Mismatch, 0)
+	for _, index := range indices {
+		subject := fmt.Sprintf("validator %d", index)
+		validator, exists := validators[index]
+		if !exists || validator.Validator == nil {
+			mismatches = append(mismatches, &verifier.Mismatch{
+				Kind:    "validator",
+				Subject: subject,
+				Detail:  "validator is in the database but not on the beacon node",
+			})
+			continue
+		}
+
+		details := compareValidator(dbValidatorsByIndex[index], validator.Validator)
+		if len(details) == 0 {
+			continue
+		}
+		repaired := false
+		if s.repair {
+			if err := s.repairValidator(ctx, index, validator.Validator); err != nil {
+				return nil, err
+			}
+			repaired = true
+		}
+		for _, detail := range details {
+			mismatches = append(mismatches, &verifier.Mismatch{
+				Kind:     "validator",
+				Subject:  subject,
+				Detail:   detail,
+				Repaired: repaired,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// compareValidator compares a database validator with that from the beacon node.
+// Effective balance is not compared, as it changes each epoch.
+func compareValidator(dbValidator *chaindb.Validator, validator *phase0.Validator) []string {
+	details := make([]string, 0)
+	if !bytes.Equal(dbValidator.PublicKey[:], validator.PublicKey[:]) {
+		details = append(details, fmt.Sprintf("public key is %#x in the database but %#x on the beacon node", dbValidator.PublicKey, validator.PublicKey))
+	}
+	if dbValidator.Slashed != validator.Slashed {
+		details = append(details, fmt.Sprintf("slashed is %t in the database but %t on the beacon node", dbValidator.Slashed, validator.Slashed))
+	}
+	if dbValidator.ActivationEligibilityEpoch != validator.ActivationEligibilityEpoch {
+		details = append(details, fmt.Sprintf("activation eligibility epoch is %d in the database but %d on the beacon node", dbValidator.ActivationEligibilityEpoch, validator.ActivationEligibilityEpoch))
+	}
+	if dbValidator.ActivationEpoch != validator.ActivationEpoch {
+		details = append(details, fmt.Sprintf("activation epoch is %d in the database but %d on the beacon node", dbValidator.ActivationEpoch, validator.ActivationEpoch))
+	}
+	if dbValidator.ExitEpoch != validator.ExitEpoch {
+		details = append(details, fmt.Sprintf("exit epoch is %d in the database but %d on the beacon node", dbValidator.ExitEpoch, validator.ExitEpoch))
+	}
+	if dbValidator.WithdrawableEpoch != validator.WithdrawableEpoch {
+		details = append(details, fmt.Sprintf("withdrawable epoch is %d in the database but %d on the beacon node", dbValidator.WithdrawableEpoch, validator.WithdrawableEpoch))
+	}
+
+	return details
+}
+
+// repairBlock stores the block from the beacon node, along with its contents, and sets its canonical state.
+func (s *Service) repairBlock(ctx context.Context,
+	signedBlock *spec.VersionedSignedBeaconBlock,
+	root phase0.Root,
+	canonical *bool,
+) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if err := s.blocks.OnBlock(ctx, signedBlock); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to store block")
+	}
+
+	// Storing the block clears its canonical state, so reinstate it.
+	if canonical != nil {
+		block, err := s.blocksProvider.BlockByRoot(ctx, root)
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to obtain stored block")
+		}
+		if block == nil {
+			cancel()
+			return errors.New("stored block not found")
+		}
+		block.Canonical = canonical
+		if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set canonical state of block")
+		}
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// setCanonical sets the canonical state of a database block.
+func (s *Service) setCanonical(ctx context.Context, block *chaindb.Block, canonical bool) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	block.Canonical = &canonical
+	if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set canonical state of block")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// repairValidator stores the validator from the beacon node.
+func (s *Service) repairValidator(ctx context.Context, index phase0.ValidatorIndex, validator *phase0.Validator) error {
+	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	dbValidator := &chaindb.Validator{
+		PublicKey:                  validator.PublicKey,
+		Index:                      index,
+		EffectiveBalance:           validator.EffectiveBalance,
+		Slashed:                    validator.Slashed,
+		ActivationEligibilityEpoch: validator.ActivationEligibilityEpoch,
+		ActivationEpoch:            validator.ActivationEpoch,
+		ExitEpoch:                  validator.ExitEpoch,
+		WithdrawableEpoch:          validator.WithdrawableEpoch,
+	}
+	if err := s.validatorsSetter.SetValidator(ctx, dbValidator); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator")
+	}
+
+	if err := s.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// sample returns up to n distinct values in the range [start, end), in increasing order.
+func sample(start uint64, end uint64, n int) []uint64 {
+	size := end - start
+	if uint64(n) >= size {
+		values := make([]uint64, 0, size)
+		for value := start; value < end; value++ {
+			values = append(values, value)
+		}
+		return values
+	}
+
+	// #nosec G404
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	picked := make(map[uint64]bool, n)
+	for len(picked) < n {
+		picked[start+uint64(rng.Int63n(int64(size)))] = true
+	}
+	values := make([]uint64, 0, n)
+	for value := range picked {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i int, j int) bool {
+		return values[i] < values[j]
+	})
+
+	return values
+}
@@ -0,0 +1,75 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// shutdown waits for in-flight work to complete, up to the given timeout.
+// It should be called after the main context has been cancelled, so that no new work is started.
+// Any transactions that are still active after the timeout are rolled back when the database
+// connections are closed.
+func shutdown(running []*runningServices, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	started := time.Now()
+	drained := make([]bool, len(running))
+	var wg sync.WaitGroup
+	for i := range running {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			drained[i] = drain(ctx, running[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range drained {
+		if !drained[i] {
+			return
+		}
+	}
+	log.Debug().Dur("elapsed", time.Since(started)).Msg("In-flight work complete")
+}
+
+// drain waits for in-flight work of the services to complete, until the context is done.
+// It returns false if database transactions did not complete.
+func drain(ctx context.Context, d *runningServices) bool {
+	log := d.network.logger()
+
+	if d.activitySem != nil {
+		// Acquiring the semaphore ensures that the blocks and finalizer modules have finished their
+		// current activity, including persisting their progress.
+		log.Trace().Msg("Waiting for blocks and finalizer to finish")
+		if err := d.activitySem.Acquire(ctx, 1); err != nil {
+			log.Warn().Msg("Timed out waiting for blocks and finalizer to finish")
+		}
+	}
+
+	if drainer, isDrainer := d.chainDB.(chaindb.Drainer); isDrainer {
+		log.Trace().Msg("Waiting for database transactions to complete")
+		if err := drainer.Drain(ctx); err != nil {
+			log.Warn().Err(err).Msg("Timed out waiting for database transactions to complete; they will be rolled back")
+			return false
+		}
+	}
+
+	return true
+}
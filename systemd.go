@@ -0,0 +1,149 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/util"
+)
+
+// notifySystemd sends a state notification to systemd, if running under systemd.
+func notifySystemd(state string) {
+	if _, err := util.SDNotify(state); err != nil {
+		log.Warn().Str("state", state).Err(err).Msg("Failed to notify systemd")
+	}
+}
+
+// watchdogEnabled returns true if the systemd watchdog is enabled for this process.
+func watchdogEnabled() bool {
+	interval, err := util.SDWatchdogInterval()
+	return err == nil && interval > 0
+}
+
+// watchdog tracks the progress of modules to decide if chaind is alive.
+type watchdog struct {
+	running      []*runningServices
+	stallTimeout time.Duration
+	// progress is the latest progress of each module, and progressed the time at which it changed.
+	// Both are keyed by network and module.
+	progress   map[string]phase0.Epoch
+	progressed map[string]time.Time
+}
+
+// startWatchdog sends watchdog notifications to systemd whilst chaind is alive, if the
+// systemd watchdog is enabled.  If notifications stop then systemd restarts chaind.
+func startWatchdog(ctx context.Context, running []*runningServices) {
+	interval, err := util.SDWatchdogInterval()
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid systemd watchdog configuration; watchdog not starting")
+		return
+	}
+	if interval == 0 {
+		log.Debug().Msg("Systemd watchdog not enabled; watchdog not starting")
+		return
+	}
+	for _, services := range running {
+		if services.health == nil {
+			log.Warn().Msg("No health service; watchdog not starting")
+			return
+		}
+	}
+
+	w := &watchdog{
+		running:      running,
+		stallTimeout: viper.GetDuration("systemd.stall-timeout"),
+		progress:     make(map[string]phase0.Epoch),
+		progressed:   make(map[string]time.Time),
+	}
+
+	go func() {
+		// Notify at half the interval, to allow for delays in checking health.
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		notifySystemd("WATCHDOG=1")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if w.alive(ctx, time.Now()) {
+					notifySystemd("WATCHDOG=1")
+				}
+			}
+		}
+	}()
+	log.Info().Dur("interval", interval).Msg("Started systemd watchdog")
+}
+
+// alive returns true if chaind is alive.
+// chaind is considered hung if a module is behind the chain and has not made progress within the
+// stall timeout, whilst the beacon node and database are healthy.  Problems with the beacon node or
+// database are not fixed by restarting chaind, so do not stop watchdog notifications.
+func (w *watchdog) alive(ctx context.Context, now time.Time) bool {
+	stalled := make([]string, 0)
+	for _, services := range w.running {
+		stalled = append(stalled, w.stalledModules(ctx, services, now)...)
+	}
+
+	if len(stalled) > 0 {
+		log.Error().Str("modules", strings.Join(stalled, ",")).Msg("Modules have stalled; stopping watchdog notifications")
+		return false
+	}
+
+	return true
+}
+
+// stalledModules returns the modules of the services that have stalled.
+func (w *watchdog) stalledModules(ctx context.Context, services *runningServices, now time.Time) []string {
+	report := services.health.Report(ctx)
+	currentEpoch := services.chainTime.CurrentEpoch()
+
+	dependenciesHealthy := true
+	stalled := make([]string, 0)
+	for _, component := range report.Components {
+		switch {
+		case component.Name == "beacon node" || component.Name == "database":
+			if !component.Healthy {
+				dependenciesHealthy = false
+			}
+		case component.Lag == nil:
+			// Progress of the module is unknown.
+		default:
+			name := component.Name
+			if services.network.name != "" {
+				name = fmt.Sprintf("%s/%s", services.network.name, component.Name)
+			}
+			progress := currentEpoch - phase0.Epoch(*component.Lag)
+			if previous, exists := w.progress[name]; !exists || progress > previous {
+				w.progress[name] = progress
+				w.progressed[name] = now
+			}
+			if !component.Healthy && now.Sub(w.progressed[name]) > w.stallTimeout {
+				stalled = append(stalled, name)
+			}
+		}
+	}
+
+	if !dependenciesHealthy {
+		return nil
+	}
+
+	return stalled
+}
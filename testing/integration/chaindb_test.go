@@ -0,0 +1,617 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// Each test writes to its own range of slots and validators so that tests do not interfere.
+
+// root returns a deterministic root for the given seed.
+func root(seed byte) phase0.Root {
+	var res phase0.Root
+	for i := range res {
+		res[i] = seed
+	}
+
+	return res
+}
+
+// setBlock writes a canonical block at the given slot, returning it.
+func setBlock(ctx context.Context, t *testing.T, s *postgresql.Service, slot phase0.Slot, seed byte) *chaindb.Block {
+	t.Helper()
+	canonical := true
+	block := &chaindb.Block{
+		Slot:            slot,
+		ProposerIndex:   1,
+		Root:            root(seed),
+		Graffiti:        []byte("integration"),
+		RANDAOReveal:    phase0.BLSSignature{seed},
+		BodyRoot:        root(seed + 1),
+		ParentRoot:      root(seed + 2),
+		StateRoot:       root(seed + 3),
+		Canonical:       &canonical,
+		ETH1BlockHash:   []byte{seed, 0x01},
+		ETH1DepositRoot: root(seed + 4),
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetBlock(ctx, block)
+	})
+
+	return block
+}
+
+func TestGenesis(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	genesis := &api.Genesis{
+		GenesisTime:           time.Unix(1606824023, 0),
+		GenesisValidatorsRoot: root(0x01),
+		GenesisForkVersion:    phase0.Version{0x00, 0x00, 0x00, 0x00},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetGenesis(ctx, genesis)
+	})
+
+	res, err := s.Genesis(ctx)
+	require.NoError(t, err)
+	require.Equal(t, genesis.GenesisTime.Unix(), res.GenesisTime.Unix())
+	require.Equal(t, genesis.GenesisValidatorsRoot, res.GenesisValidatorsRoot)
+	require.Equal(t, genesis.GenesisForkVersion, res.GenesisForkVersion)
+}
+
+func TestChainSpec(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetChainSpecValue(ctx, "SECONDS_PER_SLOT", 12*time.Second); err != nil {
+			return err
+		}
+		return s.SetChainSpecValue(ctx, "SLOTS_PER_EPOCH", uint64(32))
+	})
+
+	res, err := s.ChainSpecValue(ctx, "SECONDS_PER_SLOT")
+	require.NoError(t, err)
+	require.Equal(t, 12*time.Second, res)
+
+	res, err = s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	require.NoError(t, err)
+	require.Equal(t, uint64(32), res)
+}
+
+func TestForkSchedule(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	schedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x00},
+			Epoch:           0,
+		},
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x00},
+			CurrentVersion:  phase0.Version{0x01, 0x00, 0x00, 0x00},
+			Epoch:           74240,
+		},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetForkSchedule(ctx, schedule)
+	})
+
+	res, err := s.ForkSchedule(ctx)
+	require.NoError(t, err)
+	require.Equal(t, schedule, res)
+}
+
+func TestValidators(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	validator := &chaindb.Validator{
+		PublicKey:                  phase0.BLSPubKey{0x10, 0x01},
+		Index:                      1001,
+		EffectiveBalance:           32000000000,
+		ActivationEligibilityEpoch: 1,
+		ActivationEpoch:            2,
+		ExitEpoch:                  0xffffffffffffffff,
+		WithdrawableEpoch:          0xffffffffffffffff,
+	}
+	balance := &chaindb.ValidatorBalance{
+		Index:            validator.Index,
+		Epoch:            10,
+		Balance:          32000000123,
+		EffectiveBalance: 32000000000,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetValidator(ctx, validator); err != nil {
+			return err
+		}
+		return s.SetValidatorBalance(ctx, balance)
+	})
+
+	validators, err := s.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{validator.Index})
+	require.NoError(t, err)
+	require.Equal(t, validator, validators[validator.Index])
+
+	validatorsByPubKey, err := s.ValidatorsByPublicKey(ctx, []phase0.BLSPubKey{validator.PublicKey})
+	require.NoError(t, err)
+	require.Equal(t, validator, validatorsByPubKey[validator.PublicKey])
+
+	balances, err := s.ValidatorBalancesByIndexAndEpoch(ctx, []phase0.ValidatorIndex{validator.Index}, balance.Epoch)
+	require.NoError(t, err)
+	require.Equal(t, balance, balances[validator.Index])
+}
+
+func TestBlocks(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	canonical := true
+	block := &chaindb.Block{
+		Slot:             2000,
+		ProposerIndex:    2001,
+		Root:             root(0x20),
+		Graffiti:         []byte("integration"),
+		RANDAOReveal:     phase0.BLSSignature{0x20},
+		BodyRoot:         root(0x21),
+		ParentRoot:       root(0x22),
+		StateRoot:        root(0x23),
+		Canonical:        &canonical,
+		ETH1BlockHash:    []byte{0x20, 0x01},
+		ETH1DepositCount: 5,
+		ETH1DepositRoot:  root(0x24),
+		ExecutionPayload: &chaindb.ExecutionPayload{
+			ParentHash:    [32]byte{0x25},
+			FeeRecipient:  [20]byte{0x26},
+			StateRoot:     [32]byte{0x27},
+			ReceiptsRoot:  [32]byte{0x28},
+			LogsBloom:     [256]byte{0x29},
+			PrevRandao:    [32]byte{0x2a},
+			BlockNumber:   15537394,
+			GasLimit:      30000000,
+			GasUsed:       12000000,
+			Timestamp:     1663224179,
+			ExtraData:     []byte("extra"),
+			BaseFeePerGas: big.NewInt(1000000000),
+			BlockHash:     [32]byte{0x2b},
+		},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetBlock(ctx, block)
+	})
+
+	res, err := s.BlockByRoot(ctx, block.Root)
+	require.NoError(t, err)
+	require.Equal(t, block, res)
+
+	res, err = s.BlockByExecutionBlockHash(ctx, block.ExecutionPayload.BlockHash)
+	require.NoError(t, err)
+	require.Equal(t, block.Root, res.Root)
+
+	blocks, err := s.BlocksBySlot(ctx, block.Slot)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Equal(t, block, blocks[0])
+}
+
+func TestBeaconCommittees(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	committee := &chaindb.BeaconCommittee{
+		Slot:      3000,
+		Index:     1,
+		Committee: []phase0.ValidatorIndex{3001, 3002, 3003},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetBeaconCommittee(ctx, committee)
+	})
+
+	res, err := s.BeaconCommitteeBySlotAndIndex(ctx, committee.Slot, committee.Index)
+	require.NoError(t, err)
+	require.Equal(t, committee, res)
+}
+
+func TestProposerDuties(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	duty := &chaindb.ProposerDuty{
+		Slot:           4000,
+		ValidatorIndex: 4001,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetProposerDuty(ctx, duty)
+	})
+
+	res, err := s.ProposerDutiesForSlotRange(ctx, duty.Slot, duty.Slot+1)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ProposerDuty{duty}, res)
+}
+
+func TestMissedSlots(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	slot := phase0.Slot(4500)
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetMissedSlot(ctx, slot)
+	})
+
+	res, err := s.MissedSlots(ctx, slot, slot)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, slot, res[0].Slot)
+}
+
+func TestAttestations(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	block := setBlock(ctx, t, s, 5001, 0x50)
+	committee := &chaindb.BeaconCommittee{
+		Slot:      5000,
+		Index:     0,
+		Committee: []phase0.ValidatorIndex{5010, 5011, 5012, 5013},
+	}
+	canonical := true
+	attestation := &chaindb.Attestation{
+		InclusionSlot:      block.Slot,
+		InclusionBlockRoot: block.Root,
+		InclusionIndex:     0,
+		Slot:               committee.Slot,
+		CommitteeIndex:     committee.Index,
+		// Bits for the first and third committee members, with the bitlist length marker.
+		AggregationBits: []byte{0x15},
+		BeaconBlockRoot: root(0x51),
+		SourceEpoch:     155,
+		SourceRoot:      root(0x52),
+		TargetEpoch:     156,
+		TargetRoot:      root(0x53),
+		Canonical:       &canonical,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetBeaconCommittee(ctx, committee); err != nil {
+			return err
+		}
+		return s.SetAttestation(ctx, attestation)
+	})
+
+	res, err := s.AttestationsInBlock(ctx, block.Root)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, attestation.AggregationBits, res[0].AggregationBits)
+	require.Equal(t, []phase0.ValidatorIndex{5010, 5012}, res[0].AggregationIndices)
+	require.Equal(t, attestation.BeaconBlockRoot, res[0].BeaconBlockRoot)
+	require.Equal(t, attestation.SourceRoot, res[0].SourceRoot)
+	require.Equal(t, attestation.TargetRoot, res[0].TargetRoot)
+	require.Equal(t, attestation.Canonical, res[0].Canonical)
+
+	res, err = s.AttestationsForCommittee(ctx, committee.Slot, committee.Index)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+}
+
+func TestSyncCommittees(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	block := setBlock(ctx, t, s, 6000, 0x60)
+	syncCommittee := &chaindb.SyncCommittee{
+		Period:    6000,
+		Committee: []phase0.ValidatorIndex{6001, 6002, 6003, 6004},
+	}
+	syncAggregate := &chaindb.SyncAggregate{
+		InclusionSlot:      block.Slot,
+		InclusionBlockRoot: block.Root,
+		Bits:               []byte{0x05},
+		Indices:            []phase0.ValidatorIndex{6001, 6003},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetSyncCommittee(ctx, syncCommittee); err != nil {
+			return err
+		}
+		return s.SetSyncAggregate(ctx, syncAggregate)
+	})
+
+	committee, err := s.SyncCommittee(ctx, syncCommittee.Period)
+	require.NoError(t, err)
+	require.Equal(t, syncCommittee, committee)
+
+	aggregate, err := s.SyncAggregateForBlock(ctx, block.Root)
+	require.NoError(t, err)
+	require.Equal(t, syncAggregate.Bits, aggregate.Bits)
+	require.Equal(t, syncAggregate.Indices, aggregate.Indices)
+}
+
+func TestDeposits(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	block := setBlock(ctx, t, s, 7000, 0x70)
+	deposit := &chaindb.Deposit{
+		InclusionSlot:         block.Slot,
+		InclusionBlockRoot:    block.Root,
+		InclusionIndex:        0,
+		ValidatorPubKey:       phase0.BLSPubKey{0x70, 0x01},
+		WithdrawalCredentials: []byte{0x00, 0x70},
+		Amount:                32000000000,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetDeposit(ctx, deposit)
+	})
+
+	res, err := s.DepositsForSlotRange(ctx, block.Slot, block.Slot+1)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.Deposit{deposit}, res)
+}
+
+func TestETH1Deposits(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	deposit := &chaindb.ETH1Deposit{
+		ETH1BlockNumber:       7500,
+		ETH1BlockHash:         []byte{0x75, 0x01},
+		ETH1BlockTimestamp:    time.Unix(1606000000, 0),
+		ETH1TxHash:            []byte{0x75, 0x02},
+		ETH1LogIndex:          1,
+		ETH1Sender:            []byte{0x75, 0x03},
+		ETH1Recipient:         []byte{0x75, 0x04},
+		ETH1GasUsed:           50000,
+		ETH1GasPrice:          20000000000,
+		DepositIndex:          7500,
+		ValidatorPubKey:       phase0.BLSPubKey{0x75, 0x05},
+		WithdrawalCredentials: []byte{0x00, 0x75},
+		Signature:             phase0.BLSSignature{0x75, 0x06},
+		Amount:                32000000000,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetETH1Deposit(ctx, deposit)
+	})
+
+	res, err := s.ETH1DepositsByPublicKey(ctx, []phase0.BLSPubKey{deposit.ValidatorPubKey})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, deposit.ETH1BlockTimestamp.Unix(), res[0].ETH1BlockTimestamp.Unix())
+	res[0].ETH1BlockTimestamp = deposit.ETH1BlockTimestamp
+	require.Equal(t, deposit, res[0])
+}
+
+func TestVoluntaryExits(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	block := setBlock(ctx, t, s, 8000, 0x80)
+	voluntaryExit := &chaindb.VoluntaryExit{
+		InclusionSlot:      block.Slot,
+		InclusionBlockRoot: block.Root,
+		InclusionIndex:     0,
+		ValidatorIndex:     8001,
+		Epoch:              250,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetVoluntaryExit(ctx, voluntaryExit)
+	})
+
+	require.Equal(t, 1, queryCount(ctx, t, `
+      SELECT COUNT(*)
+      FROM t_voluntary_exits
+      WHERE f_inclusion_block_root = $1
+        AND f_validator_index = $2
+        AND f_epoch = $3`,
+		block.Root[:], voluntaryExit.ValidatorIndex, voluntaryExit.Epoch,
+	))
+}
+
+func TestSlashings(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	block := setBlock(ctx, t, s, 9000, 0x90)
+	attesterSlashing := &chaindb.AttesterSlashing{
+		InclusionSlot:               block.Slot,
+		InclusionBlockRoot:          block.Root,
+		InclusionIndex:              0,
+		Attestation1Indices:         []phase0.ValidatorIndex{9001},
+		Attestation1Slot:            8990,
+		Attestation1BeaconBlockRoot: root(0x91),
+		Attestation1SourceRoot:      root(0x92),
+		Attestation1TargetRoot:      root(0x93),
+		Attestation1Signature:       phase0.BLSSignature{0x94},
+		Attestation2Indices:         []phase0.ValidatorIndex{9001},
+		Attestation2Slot:            8990,
+		Attestation2BeaconBlockRoot: root(0x95),
+		Attestation2SourceRoot:      root(0x92),
+		Attestation2TargetRoot:      root(0x96),
+		Attestation2Signature:       phase0.BLSSignature{0x97},
+	}
+	proposerSlashing := &chaindb.ProposerSlashing{
+		InclusionSlot:        block.Slot,
+		InclusionBlockRoot:   block.Root,
+		InclusionIndex:       0,
+		Block1Root:           root(0x98),
+		Header1Slot:          8995,
+		Header1ProposerIndex: 9002,
+		Header1ParentRoot:    root(0x99),
+		Header1StateRoot:     root(0x9a),
+		Header1BodyRoot:      root(0x9b),
+		Header1Signature:     phase0.BLSSignature{0x9c},
+		Block2Root:           root(0x9d),
+		Header2Slot:          8995,
+		Header2ProposerIndex: 9002,
+		Header2ParentRoot:    root(0x99),
+		Header2StateRoot:     root(0x9e),
+		Header2BodyRoot:      root(0x9f),
+		Header2Signature:     phase0.BLSSignature{0xa0},
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetAttesterSlashing(ctx, attesterSlashing); err != nil {
+			return err
+		}
+		return s.SetProposerSlashing(ctx, proposerSlashing)
+	})
+
+	attesterSlashings, err := s.AttesterSlashingsForSlotRange(ctx, block.Slot, block.Slot+1)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.AttesterSlashing{attesterSlashing}, attesterSlashings)
+
+	proposerSlashings, err := s.ProposerSlashingsForSlotRange(ctx, block.Slot, block.Slot+1)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.ProposerSlashing{proposerSlashing}, proposerSlashings)
+}
+
+func TestSummaries(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	targetCorrect := true
+	headCorrect := false
+	inclusionDelay := 1
+	blockSummary := &chaindb.BlockSummary{
+		Slot:                 10000,
+		AttestationsForBlock: 64,
+		VotesForBlock:        8000,
+		ParentDistance:       1,
+	}
+	epochSummary := &chaindb.EpochSummary{
+		Epoch:                   312,
+		ActiveValidators:        100,
+		ActiveRealBalance:       3200000000000,
+		ActiveBalance:           3200000000000,
+		AttestingValidators:     90,
+		AttestingBalance:        2880000000000,
+		TargetCorrectValidators: 80,
+		TargetCorrectBalance:    2560000000000,
+		HeadCorrectValidators:   70,
+		HeadCorrectBalance:      2240000000000,
+		CanonicalBlocks:         32,
+	}
+	validatorEpochSummary := &chaindb.ValidatorEpochSummary{
+		Index:                       10001,
+		Epoch:                       312,
+		ProposerDuties:              1,
+		ProposalsIncluded:           1,
+		AttestationIncluded:         true,
+		AttestationTargetCorrect:    &targetCorrect,
+		AttestationHeadCorrect:      &headCorrect,
+		AttestationInclusionDelay:   &inclusionDelay,
+		SyncCommitteeParticipations: 30,
+		SyncCommitteeMisses:         2,
+	}
+	validatorDaySummary := &chaindb.ValidatorDaySummary{
+		Index:                       10001,
+		StartTimestamp:              time.Date(2020, 12, 2, 0, 0, 0, 0, time.UTC),
+		AttestationDuties:           225,
+		AttestationsIncluded:        224,
+		AttestationsInclusionDelay:  1.1,
+		SyncCommitteeParticipations: 30,
+	}
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetBlockSummary(ctx, blockSummary); err != nil {
+			return err
+		}
+		if err := s.SetEpochSummary(ctx, epochSummary); err != nil {
+			return err
+		}
+		if err := s.SetValidatorEpochSummary(ctx, validatorEpochSummary); err != nil {
+			return err
+		}
+		return s.SetValidatorDaySummaries(ctx, []*chaindb.ValidatorDaySummary{validatorDaySummary})
+	})
+
+	resBlockSummary, err := s.BlockSummaryForSlot(ctx, blockSummary.Slot)
+	require.NoError(t, err)
+	require.Equal(t, blockSummary, resBlockSummary)
+
+	participation, err := s.ParticipationForEpochRange(ctx, epochSummary.Epoch, epochSummary.Epoch)
+	require.NoError(t, err)
+	require.Len(t, participation, 1)
+	require.InDelta(t, 0.9, participation[0].ParticipationRate, 0.0001)
+
+	resValidatorEpochSummary, err := s.ValidatorSummaryForEpoch(ctx, validatorEpochSummary.Index, validatorEpochSummary.Epoch)
+	require.NoError(t, err)
+	require.Equal(t, validatorEpochSummary, resValidatorEpochSummary)
+
+	require.Equal(t, 1, queryCount(ctx, t, `
+      SELECT COUNT(*)
+      FROM t_validator_day_summaries
+      WHERE f_validator_index = $1
+        AND f_start_timestamp = $2
+        AND f_sync_committee_participations = $3`,
+		validatorDaySummary.Index, validatorDaySummary.StartTimestamp, validatorDaySummary.SyncCommitteeParticipations,
+	))
+}
+
+func TestValidatorMetrics(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	index := phase0.ValidatorIndex(11001)
+	epoch := phase0.Epoch(344)
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		if err := s.SetValidatorEffectiveness(ctx, []*chaindb.ValidatorEffectiveness{
+			{Index: index, Epoch: epoch, Effectiveness: 0.5, Percentile: 0.25},
+		}); err != nil {
+			return err
+		}
+		if err := s.SetValidatorIncome(ctx, []*chaindb.ValidatorIncome{
+			{Index: index, Epoch: epoch, Income: 12345, EffectiveBalance: 32000000000, Percentile: 0.75},
+		}); err != nil {
+			return err
+		}
+		return s.SetValidatorLabels(ctx, index, []string{"integration"})
+	})
+
+	effectiveness, err := s.ValidatorEffectiveness(ctx, []phase0.ValidatorIndex{index}, epoch, epoch)
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, effectiveness[index], 0.0001)
+
+	income, err := s.ValidatorIncome(ctx, []phase0.ValidatorIndex{index}, epoch, epoch)
+	require.NoError(t, err)
+	require.Contains(t, income, index)
+	require.Equal(t, int64(12345), income[index].Income)
+
+	labels, err := s.ValidatorLabels(ctx, []phase0.ValidatorIndex{index})
+	require.NoError(t, err)
+	require.Equal(t, []string{"integration"}, labels[index])
+}
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	s := newService(ctx, t)
+
+	setInTx(ctx, t, s, func(ctx context.Context) error {
+		return s.SetMetadata(ctx, "integration.test", []byte(`{"value":1}`))
+	})
+
+	res, err := s.Metadata(ctx, "integration.test")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value":1}`, string(res))
+}
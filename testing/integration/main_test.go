@@ -0,0 +1,202 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package integration_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+const (
+	// defaultImage is the Postgres image used if CHAIND_INTEGRATION_IMAGE is not set.
+	defaultImage = "postgres:14-alpine"
+	// readyTimeout is the time allowed for Postgres to accept connections.
+	readyTimeout = time.Minute
+)
+
+// connectionURL is the URL of the database used by the tests.
+var connectionURL string
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+
+	connectionURL = os.Getenv("CHAINDB_URL")
+	if connectionURL == "" {
+		containerID, url, err := startPostgres(ctx)
+		if containerID != "" {
+			defer stopPostgres(containerID)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start postgres: %v\n", err)
+			return 1
+		}
+		connectionURL = url
+	}
+
+	if err := upgrade(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to upgrade database: %v\n", err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+// startPostgres starts a Postgres container, returning its ID and connection URL once it accepts connections.
+func startPostgres(ctx context.Context) (string, string, error) {
+	image := os.Getenv("CHAIND_INTEGRATION_IMAGE")
+	if image == "" {
+		image = defaultImage
+	}
+
+	containerID, err := docker(ctx, "run", "--detach", "--rm",
+		"--env", "POSTGRES_USER=chain",
+		"--env", "POSTGRES_PASSWORD=chain",
+		"--env", "POSTGRES_DB=chain",
+		"--publish", "127.0.0.1::5432",
+		image,
+	)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to run container")
+	}
+
+	// Output is of the form "127.0.0.1:49153".
+	hostPort, err := docker(ctx, "port", containerID, "5432/tcp")
+	if err != nil {
+		return containerID, "", errors.Wrap(err, "failed to obtain container port")
+	}
+	hostPort = strings.Split(hostPort, "\n")[0]
+	url := fmt.Sprintf("postgres://chain:chain@%s/chain?sslmode=disable", hostPort)
+
+	if err := waitForPostgres(ctx, url); err != nil {
+		return containerID, "", err
+	}
+
+	return containerID, url, nil
+}
+
+// waitForPostgres waits until Postgres accepts connections.
+func waitForPostgres(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	for {
+		conn, err := pgx.Connect(ctx, url)
+		if err == nil {
+			err = conn.Ping(ctx)
+			conn.Close(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(err, "postgres did not become ready")
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// stopPostgres stops a Postgres container; it is removed automatically when stopped.
+func stopPostgres(containerID string) {
+	if _, err := docker(context.Background(), "stop", containerID); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to stop container %s: %v\n", containerID, err)
+	}
+}
+
+// docker runs a docker command, returning its trimmed output.
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.Wrap(err, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// upgrade brings the database schema up to date.
+func upgrade(ctx context.Context) error {
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(connectionURL),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Upgrade(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newService returns a chain database service for the test database.
+func newService(ctx context.Context, t *testing.T) *postgresql.Service {
+	t.Helper()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(connectionURL),
+	)
+	require.NoError(t, err)
+
+	return s
+}
+
+// setInTx runs the supplied function in a committed transaction.
+func setInTx(ctx context.Context, t *testing.T, s *postgresql.Service, fn func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	require.NoError(t, fn(ctx))
+	require.NoError(t, s.CommitTx(ctx))
+}
+
+// queryCount returns the result of a query that returns a single count.
+func queryCount(ctx context.Context, t *testing.T, query string, args ...interface{}) int {
+	t.Helper()
+	conn, err := pgx.Connect(ctx, connectionURL)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	var count int
+	require.NoError(t, conn.QueryRow(ctx, query, args...).Scan(&count))
+
+	return count
+}
@@ -0,0 +1,183 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Stats are the statistics for a run of the driver.
+type Stats struct {
+	Slots             uint64
+	Blocks            uint64
+	BeaconCommittees  uint64
+	ProposerDuties    uint64
+	Attestations      uint64
+	ValidatorBalances uint64
+	Duration          time.Duration
+}
+
+// Driver writes data from a generator to a chain database.
+type Driver struct {
+	generator              *Generator
+	chainDB                chaindb.Service
+	blocksSetter           chaindb.BlocksSetter
+	attestationsSetter     chaindb.AttestationsSetter
+	beaconCommitteesSetter chaindb.BeaconCommitteesSetter
+	proposerDutiesSetter   chaindb.ProposerDutiesSetter
+	validatorsSetter       chaindb.ValidatorsSetter
+}
+
+// NewDriver creates a new driver.
+func NewDriver(generator *Generator, chainDB chaindb.Service) (*Driver, error) {
+	if generator == nil {
+		return nil, errors.New("no generator specified")
+	}
+	if chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+
+	blocksSetter, isBlocksSetter := chainDB.(chaindb.BlocksSetter)
+	if !isBlocksSetter {
+		return nil, errors.New("chain DB does not support block setting")
+	}
+
+	attestationsSetter, isAttestationsSetter := chainDB.(chaindb.AttestationsSetter)
+	if !isAttestationsSetter {
+		return nil, errors.New("chain DB does not support attestation setting")
+	}
+
+	beaconCommitteesSetter, isBeaconCommitteesSetter := chainDB.(chaindb.BeaconCommitteesSetter)
+	if !isBeaconCommitteesSetter {
+		return nil, errors.New("chain DB does not support beacon committee setting")
+	}
+
+	proposerDutiesSetter, isProposerDutiesSetter := chainDB.(chaindb.ProposerDutiesSetter)
+	if !isProposerDutiesSetter {
+		return nil, errors.New("chain DB does not support proposer duty setting")
+	}
+
+	validatorsSetter, isValidatorsSetter := chainDB.(chaindb.ValidatorsSetter)
+	if !isValidatorsSetter {
+		return nil, errors.New("chain DB does not support validator setting")
+	}
+
+	return &Driver{
+		generator:              generator,
+		chainDB:                chainDB,
+		blocksSetter:           blocksSetter,
+		attestationsSetter:     attestationsSetter,
+		beaconCommitteesSetter: beaconCommitteesSetter,
+		proposerDutiesSetter:   proposerDutiesSetter,
+		validatorsSetter:       validatorsSetter,
+	}, nil
+}
+
+// SetValidators writes the validators of the generated chain in a single transaction.
+func (d *Driver) SetValidators(ctx context.Context) error {
+	ctx, cancel, err := d.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	for _, validator := range d.generator.Validators() {
+		if err := d.validatorsSetter.SetValidator(ctx, validator); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator")
+		}
+	}
+
+	if err := d.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// Run writes the generated chain for the given slot range, with each slot in its own transaction.
+// Validator balances are written at the start of each epoch.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will
+// write data for slots 2 and 3.
+func (d *Driver) Run(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (*Stats, error) {
+	stats := &Stats{}
+	started := time.Now()
+
+	for slot := startSlot; slot < endSlot; slot++ {
+		if err := d.runSlot(ctx, slot, stats); err != nil {
+			return nil, errors.Wrapf(err, "failed to write slot %d", slot)
+		}
+		stats.Slots++
+	}
+	stats.Duration = time.Since(started)
+
+	return stats, nil
+}
+
+// runSlot writes the generated data for a single slot.
+func (d *Driver) runSlot(ctx context.Context, slot phase0.Slot, stats *Stats) error {
+	ctx, cancel, err := d.chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+
+	if uint64(slot)%d.generator.SlotsPerEpoch() == 0 {
+		balances := d.generator.ValidatorBalances(phase0.Epoch(uint64(slot) / d.generator.SlotsPerEpoch()))
+		if err := d.validatorsSetter.SetValidatorBalances(ctx, balances); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set validator balances")
+		}
+		stats.ValidatorBalances += uint64(len(balances))
+	}
+
+	if err := d.proposerDutiesSetter.SetProposerDuty(ctx, d.generator.ProposerDuty(slot)); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set proposer duty")
+	}
+	stats.ProposerDuties++
+
+	for _, beaconCommittee := range d.generator.BeaconCommittees(slot) {
+		if err := d.beaconCommitteesSetter.SetBeaconCommittee(ctx, beaconCommittee); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set beacon committee")
+		}
+		stats.BeaconCommittees++
+	}
+
+	if err := d.blocksSetter.SetBlock(ctx, d.generator.Block(slot)); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set block")
+	}
+	stats.Blocks++
+
+	for _, attestation := range d.generator.Attestations(slot) {
+		if err := d.attestationsSetter.SetAttestation(ctx, attestation); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to set attestation")
+		}
+		stats.Attestations++
+	}
+
+	if err := d.chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/testing/loadgen"
+)
+
+// newDriver creates a driver against the database at CHAINDB_URL, skipping if it is not set.
+func newDriver(tb testing.TB, params ...loadgen.Parameter) *loadgen.Driver {
+	tb.Helper()
+
+	if os.Getenv("CHAINDB_URL") == "" {
+		tb.Skip("CHAINDB_URL not set")
+	}
+
+	ctx := context.Background()
+	chainDB, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(tb, err)
+	_, err = chainDB.Upgrade(ctx)
+	require.NoError(tb, err)
+
+	g, err := loadgen.New(params...)
+	require.NoError(tb, err)
+	driver, err := loadgen.NewDriver(g, chainDB)
+	require.NoError(tb, err)
+	require.NoError(tb, driver.SetValidators(ctx))
+
+	return driver
+}
+
+func TestDriver(t *testing.T) {
+	driver := newDriver(t, loadgen.WithValidators(1024))
+
+	stats, err := driver.Run(context.Background(), 0, 64)
+	require.NoError(t, err)
+	require.Equal(t, uint64(64), stats.Slots)
+	require.Equal(t, uint64(64), stats.Blocks)
+	require.Equal(t, uint64(2*1024), stats.ValidatorBalances)
+}
+
+func BenchmarkRun(b *testing.B) {
+	for _, validators := range []uint64{16384, 131072} {
+		b.Run(fmt.Sprintf("%dValidators", validators), func(b *testing.B) {
+			driver := newDriver(b, loadgen.WithValidators(validators))
+			ctx := context.Background()
+			b.ResetTimer()
+			stats, err := driver.Run(ctx, 0, phase0.Slot(b.N))
+			require.NoError(b, err)
+			b.ReportMetric(float64(stats.Attestations)/float64(b.N), "attestations/op")
+		})
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	g, err := loadgen.New()
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Block(phase0.Slot(i))
+		g.Attestations(phase0.Slot(i))
+	}
+}
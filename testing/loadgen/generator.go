@@ -0,0 +1,239 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadgen generates synthetic chains and writes them to a chain database,
+// to allow the performance of the storage layer to be measured.
+package loadgen
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// farFutureEpoch is the epoch used for events that have not happened.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// maxEffectiveBalance is the effective balance of all generated validators.
+const maxEffectiveBalance = phase0.Gwei(32000000000)
+
+// Generator generates a synthetic chain.
+// Every slot in the chain has a block, and every block contains an aggregate
+// attestation for each beacon committee of the previous slot.
+// Generation is deterministic, so the same data is returned for the same slot
+// or epoch regardless of the order in which it is requested.
+type Generator struct {
+	validators        uint64
+	slotsPerEpoch     uint64
+	committeesPerSlot uint64
+	participation     float64
+	seed              int64
+}
+
+// New creates a new generator.
+func New(params ...Parameter) (*Generator, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	return &Generator{
+		validators:        parameters.validators,
+		slotsPerEpoch:     parameters.slotsPerEpoch,
+		committeesPerSlot: parameters.committeesPerSlot,
+		participation:     parameters.participation,
+		seed:              parameters.seed,
+	}, nil
+}
+
+// SlotsPerEpoch returns the number of slots per epoch of the generated chain.
+func (g *Generator) SlotsPerEpoch() uint64 {
+	return g.slotsPerEpoch
+}
+
+// Validators returns the validators of the generated chain.
+func (g *Generator) Validators() []*chaindb.Validator {
+	validators := make([]*chaindb.Validator, g.validators)
+	for i := uint64(0); i < g.validators; i++ {
+		validator := &chaindb.Validator{
+			Index:             phase0.ValidatorIndex(i),
+			EffectiveBalance:  maxEffectiveBalance,
+			ExitEpoch:         farFutureEpoch,
+			WithdrawableEpoch: farFutureEpoch,
+		}
+		hash := g.hash("pubkey", i)
+		copy(validator.PublicKey[:], hash[:])
+		validators[i] = validator
+	}
+
+	return validators
+}
+
+// ValidatorBalances returns the validator balances for the given epoch.
+func (g *Generator) ValidatorBalances(epoch phase0.Epoch) []*chaindb.ValidatorBalance {
+	rnd := g.rand("balances", uint64(epoch))
+	balances := make([]*chaindb.ValidatorBalance, g.validators)
+	for i := uint64(0); i < g.validators; i++ {
+		balances[i] = &chaindb.ValidatorBalance{
+			Index:            phase0.ValidatorIndex(i),
+			Epoch:            epoch,
+			Balance:          maxEffectiveBalance + phase0.Gwei(epoch)*10000 + phase0.Gwei(rnd.Int63n(10000)),
+			EffectiveBalance: maxEffectiveBalance,
+		}
+	}
+
+	return balances
+}
+
+// BeaconCommittees returns the beacon committees for the given slot.
+func (g *Generator) BeaconCommittees(slot phase0.Slot) []*chaindb.BeaconCommittee {
+	epoch := uint64(slot) / g.slotsPerEpoch
+	shuffled := g.rand("shuffle", epoch).Perm(int(g.validators))
+
+	committeesPerEpoch := g.slotsPerEpoch * g.committeesPerSlot
+	committees := make([]*chaindb.BeaconCommittee, g.committeesPerSlot)
+	for i := uint64(0); i < g.committeesPerSlot; i++ {
+		// Split the shuffled validators evenly across all committees of the epoch.
+		position := (uint64(slot)%g.slotsPerEpoch)*g.committeesPerSlot + i
+		start := g.validators * position / committeesPerEpoch
+		end := g.validators * (position + 1) / committeesPerEpoch
+		committee := make([]phase0.ValidatorIndex, end-start)
+		for j := range committee {
+			committee[j] = phase0.ValidatorIndex(shuffled[start+uint64(j)])
+		}
+		committees[i] = &chaindb.BeaconCommittee{
+			Slot:      slot,
+			Index:     phase0.CommitteeIndex(i),
+			Committee: committee,
+		}
+	}
+
+	return committees
+}
+
+// ProposerDuty returns the proposer duty for the given slot.
+func (g *Generator) ProposerDuty(slot phase0.Slot) *chaindb.ProposerDuty {
+	return &chaindb.ProposerDuty{
+		Slot:           slot,
+		ValidatorIndex: g.proposerIndex(slot),
+	}
+}
+
+// Block returns the block for the given slot.
+func (g *Generator) Block(slot phase0.Slot) *chaindb.Block {
+	block := &chaindb.Block{
+		Slot:             slot,
+		ProposerIndex:    g.proposerIndex(slot),
+		Root:             g.blockRoot(slot),
+		Graffiti:         []byte("loadgen"),
+		ETH1BlockHash:    make([]byte, 32),
+		ETH1DepositCount: g.validators,
+	}
+	if slot > 0 {
+		block.ParentRoot = g.blockRoot(slot - 1)
+	}
+	hash := g.hash("randao", uint64(slot))
+	copy(block.RANDAOReveal[:], hash[:])
+	block.BodyRoot = g.hash("body", uint64(slot))
+	block.StateRoot = g.hash("state", uint64(slot))
+
+	return block
+}
+
+// Attestations returns the attestations included in the block for the given slot.
+func (g *Generator) Attestations(slot phase0.Slot) []*chaindb.Attestation {
+	if slot == 0 {
+		// Nothing to attest to.
+		return []*chaindb.Attestation{}
+	}
+
+	attestedSlot := slot - 1
+	epoch := phase0.Epoch(uint64(attestedSlot) / g.slotsPerEpoch)
+	targetRoot := g.blockRoot(phase0.Slot(uint64(epoch) * g.slotsPerEpoch))
+	sourceEpoch := phase0.Epoch(0)
+	if epoch > 0 {
+		sourceEpoch = epoch - 1
+	}
+	sourceRoot := g.blockRoot(phase0.Slot(uint64(sourceEpoch) * g.slotsPerEpoch))
+	rnd := g.rand("attestations", uint64(slot))
+
+	committees := g.BeaconCommittees(attestedSlot)
+	attestations := make([]*chaindb.Attestation, len(committees))
+	for i, committee := range committees {
+		aggregationBits, aggregationIndices := g.aggregation(rnd, committee.Committee)
+		attestations[i] = &chaindb.Attestation{
+			InclusionSlot:      slot,
+			InclusionBlockRoot: g.blockRoot(slot),
+			InclusionIndex:     uint64(i),
+			Slot:               attestedSlot,
+			CommitteeIndex:     committee.Index,
+			AggregationBits:    aggregationBits,
+			AggregationIndices: aggregationIndices,
+			BeaconBlockRoot:    g.blockRoot(attestedSlot),
+			SourceEpoch:        sourceEpoch,
+			SourceRoot:         sourceRoot,
+			TargetEpoch:        epoch,
+			TargetRoot:         targetRoot,
+		}
+	}
+
+	return attestations
+}
+
+// aggregation returns the SSZ bitlist and the indices of the committee members that took part in an attestation.
+func (g *Generator) aggregation(rnd *rand.Rand, committee []phase0.ValidatorIndex) ([]byte, []phase0.ValidatorIndex) {
+	// Bitlists have a trailing bit to mark their length.
+	bits := make([]byte, len(committee)/8+1)
+	bits[len(committee)/8] |= 1 << (len(committee) % 8)
+
+	indices := make([]phase0.ValidatorIndex, 0, len(committee))
+	for i, validatorIndex := range committee {
+		if rnd.Float64() < g.participation {
+			bits[i/8] |= 1 << (i % 8)
+			indices = append(indices, validatorIndex)
+		}
+	}
+
+	return bits, indices
+}
+
+// proposerIndex returns the index of the proposer for the given slot.
+func (g *Generator) proposerIndex(slot phase0.Slot) phase0.ValidatorIndex {
+	return phase0.ValidatorIndex(g.rand("proposer", uint64(slot)).Int63n(int64(g.validators)))
+}
+
+// blockRoot returns the root of the block for the given slot.
+func (g *Generator) blockRoot(slot phase0.Slot) phase0.Root {
+	return g.hash("block", uint64(slot))
+}
+
+// hash returns a deterministic hash for the given domain and value.
+func (g *Generator) hash(domain string, value uint64) phase0.Root {
+	data := make([]byte, 16+len(domain))
+	binary.LittleEndian.PutUint64(data[0:8], uint64(g.seed))
+	binary.LittleEndian.PutUint64(data[8:16], value)
+	copy(data[16:], domain)
+
+	return sha256.Sum256(data)
+}
+
+// rand returns a deterministic source of randomness for the given domain and value.
+func (g *Generator) rand(domain string, value uint64) *rand.Rand {
+	hash := g.hash(domain, value)
+	// #nosec G404
+	return rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(hash[:8]))))
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/testing/loadgen"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []loadgen.Parameter
+		err    string
+	}{
+		{
+			name: "Defaults",
+		},
+		{
+			name: "ValidatorsZero",
+			params: []loadgen.Parameter{
+				loadgen.WithValidators(0),
+			},
+			err: "problem with parameters: no validators specified",
+		},
+		{
+			name: "SlotsPerEpochZero",
+			params: []loadgen.Parameter{
+				loadgen.WithSlotsPerEpoch(0),
+			},
+			err: "problem with parameters: no slots per epoch specified",
+		},
+		{
+			name: "CommitteesPerSlotZero",
+			params: []loadgen.Parameter{
+				loadgen.WithCommitteesPerSlot(0),
+			},
+			err: "problem with parameters: no committees per slot specified",
+		},
+		{
+			name: "ValidatorsTooFew",
+			params: []loadgen.Parameter{
+				loadgen.WithValidators(64),
+				loadgen.WithSlotsPerEpoch(32),
+				loadgen.WithCommitteesPerSlot(4),
+			},
+			err: "problem with parameters: not enough validators to fill committees",
+		},
+		{
+			name: "ParticipationInvalid",
+			params: []loadgen.Parameter{
+				loadgen.WithParticipation(1.5),
+			},
+			err: "problem with parameters: participation must be between 0 and 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadgen.New(test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBeaconCommittees(t *testing.T) {
+	g, err := loadgen.New(
+		loadgen.WithValidators(1000),
+		loadgen.WithSlotsPerEpoch(8),
+		loadgen.WithCommitteesPerSlot(2),
+	)
+	require.NoError(t, err)
+
+	// Every validator should be in exactly one committee per epoch.
+	seen := make(map[phase0.ValidatorIndex]bool)
+	for slot := phase0.Slot(8); slot < 16; slot++ {
+		committees := g.BeaconCommittees(slot)
+		require.Len(t, committees, 2)
+		for _, committee := range committees {
+			require.Equal(t, slot, committee.Slot)
+			for _, validatorIndex := range committee.Committee {
+				require.False(t, seen[validatorIndex])
+				seen[validatorIndex] = true
+			}
+		}
+	}
+	require.Len(t, seen, 1000)
+}
+
+func TestDeterministic(t *testing.T) {
+	g1, err := loadgen.New(loadgen.WithSeed(5))
+	require.NoError(t, err)
+	g2, err := loadgen.New(loadgen.WithSeed(5))
+	require.NoError(t, err)
+	g3, err := loadgen.New(loadgen.WithSeed(6))
+	require.NoError(t, err)
+
+	assert.Equal(t, g1.Block(100), g2.Block(100))
+	assert.Equal(t, g1.Attestations(100), g2.Attestations(100))
+	assert.NotEqual(t, g1.Block(100).Root, g3.Block(100).Root)
+}
+
+func TestChain(t *testing.T) {
+	g, err := loadgen.New(
+		loadgen.WithValidators(1024),
+		loadgen.WithParticipation(1),
+	)
+	require.NoError(t, err)
+
+	require.Empty(t, g.Attestations(0))
+	for slot := phase0.Slot(1); slot < 40; slot++ {
+		block := g.Block(slot)
+		require.Equal(t, g.Block(slot-1).Root, block.ParentRoot)
+
+		committees := g.BeaconCommittees(slot - 1)
+		attestations := g.Attestations(slot)
+		require.Len(t, attestations, len(committees))
+		for i, attestation := range attestations {
+			require.Equal(t, block.Root, attestation.InclusionBlockRoot)
+			require.Equal(t, slot-1, attestation.Slot)
+			// Full participation, so all committee members are present.
+			require.Equal(t, committees[i].Committee, attestation.AggregationIndices)
+			require.Len(t, attestation.AggregationBits, len(committees[i].Committee)/8+1)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadgen
+
+import (
+	"errors"
+)
+
+type parameters struct {
+	validators        uint64
+	slotsPerEpoch     uint64
+	committeesPerSlot uint64
+	participation     float64
+	seed              int64
+}
+
+// Parameter is the interface for generator parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithValidators sets the number of validators in the generated chain.
+func WithValidators(validators uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validators = validators
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots per epoch in the generated chain.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithCommitteesPerSlot sets the number of beacon committees per slot in the generated chain.
+func WithCommitteesPerSlot(committeesPerSlot uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.committeesPerSlot = committeesPerSlot
+	})
+}
+
+// WithParticipation sets the proportion of committee members that attest, between 0 and 1.
+func WithParticipation(participation float64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.participation = participation
+	})
+}
+
+// WithSeed sets the seed for the generator.
+// Generators with the same parameters and seed generate the same chain.
+func WithSeed(seed int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.seed = seed
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		validators:        16384,
+		slotsPerEpoch:     32,
+		committeesPerSlot: 4,
+		participation:     0.95,
+		seed:              1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.validators == 0 {
+		return nil, errors.New("no validators specified")
+	}
+	if parameters.slotsPerEpoch == 0 {
+		return nil, errors.New("no slots per epoch specified")
+	}
+	if parameters.committeesPerSlot == 0 {
+		return nil, errors.New("no committees per slot specified")
+	}
+	if parameters.validators < parameters.slotsPerEpoch*parameters.committeesPerSlot {
+		return nil, errors.New("not enough validators to fill committees")
+	}
+	if parameters.participation < 0 || parameters.participation > 1 {
+		return nil, errors.New("participation must be between 0 and 1")
+	}
+
+	return &parameters, nil
+}
@@ -149,3 +149,33 @@ func NewBeaconCommitteeSubscriptionsSubmitter() eth2client.BeaconCommitteeSubscr
 func (*BeaconCommitteeSubscriptionsSubmitter) SubmitBeaconCommitteeSubscriptions(_ context.Context, _ []*api.BeaconCommitteeSubscription) error {
 	return nil
 }
+
+// NodeSyncingProvider is a mock for eth2client.NodeSyncingProvider.
+// It also implements eth2client.Service.
+type NodeSyncingProvider struct {
+	syncing bool
+}
+
+// NewNodeSyncingProvider returns a mock node syncing provider with the provided value.
+func NewNodeSyncingProvider(syncing bool) *NodeSyncingProvider {
+	return &NodeSyncingProvider{
+		syncing: syncing,
+	}
+}
+
+// Name is a mock.
+func (*NodeSyncingProvider) Name() string {
+	return "mock"
+}
+
+// Address is a mock.
+func (*NodeSyncingProvider) Address() string {
+	return "mock"
+}
+
+// NodeSyncing is a mock.
+func (m *NodeSyncingProvider) NodeSyncing(_ context.Context) (*api.SyncState, error) {
+	return &api.SyncState{
+		IsSyncing: m.syncing,
+	}, nil
+}
@@ -0,0 +1,300 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// SignedBeaconBlockProvider is a scriptable mock for eth2client.SignedBeaconBlockProvider.
+type SignedBeaconBlockProvider struct {
+	mu     sync.RWMutex
+	blocks map[string]*spec.VersionedSignedBeaconBlock
+	err    error
+}
+
+// NewSignedBeaconBlockProvider returns a mock signed beacon block provider with no blocks.
+func NewSignedBeaconBlockProvider() *SignedBeaconBlockProvider {
+	return &SignedBeaconBlockProvider{
+		blocks: make(map[string]*spec.VersionedSignedBeaconBlock),
+	}
+}
+
+// SetSignedBeaconBlock sets the block returned for the given block ID.
+func (m *SignedBeaconBlockProvider) SetSignedBeaconBlock(blockID string, block *spec.VersionedSignedBeaconBlock) {
+	m.mu.Lock()
+	m.blocks[blockID] = block
+	m.mu.Unlock()
+}
+
+// SetError sets the error returned by all calls; nil clears it.
+func (m *SignedBeaconBlockProvider) SetError(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// SignedBeaconBlock is a mock.
+// Unknown block IDs return nil without an error, as per a 404 from a beacon node.
+func (m *SignedBeaconBlockProvider) SignedBeaconBlock(_ context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.blocks[blockID], nil
+}
+
+// BeaconCommitteesProvider is a scriptable mock for eth2client.BeaconCommitteesProvider.
+type BeaconCommitteesProvider struct {
+	mu              sync.RWMutex
+	committees      map[string][]*api.BeaconCommittee
+	epochCommittees map[phase0.Epoch][]*api.BeaconCommittee
+	err             error
+}
+
+// NewBeaconCommitteesProvider returns a mock beacon committees provider with no committees.
+func NewBeaconCommitteesProvider() *BeaconCommitteesProvider {
+	return &BeaconCommitteesProvider{
+		committees:      make(map[string][]*api.BeaconCommittee),
+		epochCommittees: make(map[phase0.Epoch][]*api.BeaconCommittee),
+	}
+}
+
+// SetBeaconCommittees sets the committees returned for the given state ID.
+func (m *BeaconCommitteesProvider) SetBeaconCommittees(stateID string, committees []*api.BeaconCommittee) {
+	m.mu.Lock()
+	m.committees[stateID] = committees
+	m.mu.Unlock()
+}
+
+// SetBeaconCommitteesAtEpoch sets the committees returned for the given epoch, regardless of state ID.
+func (m *BeaconCommitteesProvider) SetBeaconCommitteesAtEpoch(epoch phase0.Epoch, committees []*api.BeaconCommittee) {
+	m.mu.Lock()
+	m.epochCommittees[epoch] = committees
+	m.mu.Unlock()
+}
+
+// SetError sets the error returned by all calls; nil clears it.
+func (m *BeaconCommitteesProvider) SetError(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// BeaconCommittees is a mock.
+func (m *BeaconCommitteesProvider) BeaconCommittees(_ context.Context, stateID string) ([]*api.BeaconCommittee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.committees[stateID], nil
+}
+
+// BeaconCommitteesAtEpoch is a mock.
+func (m *BeaconCommitteesProvider) BeaconCommitteesAtEpoch(_ context.Context, _ string, epoch phase0.Epoch) ([]*api.BeaconCommittee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.epochCommittees[epoch], nil
+}
+
+// ValidatorsProvider is a scriptable mock for eth2client.ValidatorsProvider.
+type ValidatorsProvider struct {
+	mu         sync.RWMutex
+	validators map[string]map[phase0.ValidatorIndex]*api.Validator
+	err        error
+}
+
+// NewValidatorsProvider returns a mock validators provider with no validators.
+func NewValidatorsProvider() *ValidatorsProvider {
+	return &ValidatorsProvider{
+		validators: make(map[string]map[phase0.ValidatorIndex]*api.Validator),
+	}
+}
+
+// SetValidators sets the validators returned for the given state ID.
+func (m *ValidatorsProvider) SetValidators(stateID string, validators []*api.Validator) {
+	stateValidators := make(map[phase0.ValidatorIndex]*api.Validator, len(validators))
+	for _, validator := range validators {
+		stateValidators[validator.Index] = validator
+	}
+	m.mu.Lock()
+	m.validators[stateID] = stateValidators
+	m.mu.Unlock()
+}
+
+// SetError sets the error returned by all calls; nil clears it.
+func (m *ValidatorsProvider) SetError(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// Validators is a mock.
+// If no indices are supplied all validators for the state are returned.
+func (m *ValidatorsProvider) Validators(_ context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	stateValidators := m.validators[stateID]
+	res := make(map[phase0.ValidatorIndex]*api.Validator)
+	if len(validatorIndices) == 0 {
+		for index, validator := range stateValidators {
+			res[index] = validator
+		}
+		return res, nil
+	}
+	for _, index := range validatorIndices {
+		if validator, exists := stateValidators[index]; exists {
+			res[index] = validator
+		}
+	}
+
+	return res, nil
+}
+
+// ValidatorsByPubKey is a mock.
+// If no public keys are supplied all validators for the state are returned.
+func (m *ValidatorsProvider) ValidatorsByPubKey(_ context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	pubKeys := make(map[phase0.BLSPubKey]bool, len(validatorPubKeys))
+	for _, pubKey := range validatorPubKeys {
+		pubKeys[pubKey] = true
+	}
+	res := make(map[phase0.ValidatorIndex]*api.Validator)
+	for index, validator := range m.validators[stateID] {
+		if len(pubKeys) > 0 && (validator.Validator == nil || !pubKeys[validator.Validator.PublicKey]) {
+			continue
+		}
+		res[index] = validator
+	}
+
+	return res, nil
+}
+
+// FinalityProvider is a scriptable mock for eth2client.FinalityProvider.
+type FinalityProvider struct {
+	mu       sync.RWMutex
+	finality map[string]*api.Finality
+	err      error
+}
+
+// NewFinalityProvider returns a mock finality provider with no finality information.
+func NewFinalityProvider() *FinalityProvider {
+	return &FinalityProvider{
+		finality: make(map[string]*api.Finality),
+	}
+}
+
+// SetFinality sets the finality returned for the given state ID.
+func (m *FinalityProvider) SetFinality(stateID string, finality *api.Finality) {
+	m.mu.Lock()
+	m.finality[stateID] = finality
+	m.mu.Unlock()
+}
+
+// SetError sets the error returned by all calls; nil clears it.
+func (m *FinalityProvider) SetError(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// Finality is a mock.
+func (m *FinalityProvider) Finality(_ context.Context, stateID string) (*api.Finality, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return m.finality[stateID], nil
+}
+
+// EventsProvider is a scriptable mock for eth2client.EventsProvider.
+// Handlers registered with Events receive events passed to SendEvent.
+type EventsProvider struct {
+	mu       sync.RWMutex
+	handlers map[string][]eth2client.EventHandlerFunc
+	err      error
+}
+
+// NewEventsProvider returns a mock events provider with no subscriptions.
+func NewEventsProvider() *EventsProvider {
+	return &EventsProvider{
+		handlers: make(map[string][]eth2client.EventHandlerFunc),
+	}
+}
+
+// SetError sets the error returned by calls to Events; nil clears it.
+func (m *EventsProvider) SetError(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// Events is a mock.
+func (m *EventsProvider) Events(_ context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+
+	for _, topic := range topics {
+		m.handlers[topic] = append(m.handlers[topic], handler)
+	}
+
+	return nil
+}
+
+// SendEvent synchronously delivers the event to all handlers subscribed to its topic.
+func (m *EventsProvider) SendEvent(event *api.Event) {
+	m.mu.RLock()
+	handlers := m.handlers[event.Topic]
+	m.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Subscriptions returns the number of handlers subscribed to the given topic.
+func (m *EventsProvider) Subscriptions(topic string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.handlers[topic])
+}
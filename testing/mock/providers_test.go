@@ -0,0 +1,145 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+func TestProviderInterfaces(t *testing.T) {
+	var _ eth2client.SignedBeaconBlockProvider = mock.NewSignedBeaconBlockProvider()
+	var _ eth2client.BeaconCommitteesProvider = mock.NewBeaconCommitteesProvider()
+	var _ eth2client.ValidatorsProvider = mock.NewValidatorsProvider()
+	var _ eth2client.FinalityProvider = mock.NewFinalityProvider()
+	var _ eth2client.EventsProvider = mock.NewEventsProvider()
+}
+
+func TestSignedBeaconBlockProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := mock.NewSignedBeaconBlockProvider()
+
+	block, err := provider.SignedBeaconBlock(ctx, "head")
+	require.NoError(t, err)
+	require.Nil(t, block)
+
+	expected := &spec.VersionedSignedBeaconBlock{Version: spec.DataVersionPhase0}
+	provider.SetSignedBeaconBlock("head", expected)
+	block, err = provider.SignedBeaconBlock(ctx, "head")
+	require.NoError(t, err)
+	require.Equal(t, expected, block)
+
+	provider.SetError(errors.New("mock error"))
+	_, err = provider.SignedBeaconBlock(ctx, "head")
+	require.EqualError(t, err, "mock error")
+
+	provider.SetError(nil)
+	_, err = provider.SignedBeaconBlock(ctx, "head")
+	require.NoError(t, err)
+}
+
+func TestBeaconCommitteesProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := mock.NewBeaconCommitteesProvider()
+
+	committees := []*api.BeaconCommittee{{Slot: 32, Index: 0, Validators: []phase0.ValidatorIndex{1, 2}}}
+	provider.SetBeaconCommittees("head", committees)
+	provider.SetBeaconCommitteesAtEpoch(1, committees)
+
+	res, err := provider.BeaconCommittees(ctx, "head")
+	require.NoError(t, err)
+	require.Equal(t, committees, res)
+
+	res, err = provider.BeaconCommitteesAtEpoch(ctx, "head", 1)
+	require.NoError(t, err)
+	require.Equal(t, committees, res)
+
+	res, err = provider.BeaconCommitteesAtEpoch(ctx, "head", 2)
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	provider.SetError(errors.New("mock error"))
+	_, err = provider.BeaconCommitteesAtEpoch(ctx, "head", 1)
+	require.EqualError(t, err, "mock error")
+}
+
+func TestValidatorsProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := mock.NewValidatorsProvider()
+
+	validators := []*api.Validator{
+		{Index: 1, Validator: &phase0.Validator{PublicKey: phase0.BLSPubKey{0x01}}},
+		{Index: 2, Validator: &phase0.Validator{PublicKey: phase0.BLSPubKey{0x02}}},
+	}
+	provider.SetValidators("head", validators)
+
+	res, err := provider.Validators(ctx, "head", nil)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	res, err = provider.Validators(ctx, "head", []phase0.ValidatorIndex{2, 3})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, validators[1], res[2])
+
+	res, err = provider.ValidatorsByPubKey(ctx, "head", []phase0.BLSPubKey{{0x01}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, validators[0], res[1])
+
+	provider.SetError(errors.New("mock error"))
+	_, err = provider.Validators(ctx, "head", nil)
+	require.EqualError(t, err, "mock error")
+}
+
+func TestFinalityProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := mock.NewFinalityProvider()
+
+	finality := &api.Finality{Finalized: &phase0.Checkpoint{Epoch: 5}}
+	provider.SetFinality("head", finality)
+
+	res, err := provider.Finality(ctx, "head")
+	require.NoError(t, err)
+	require.Equal(t, finality, res)
+
+	provider.SetError(errors.New("mock error"))
+	_, err = provider.Finality(ctx, "head")
+	require.EqualError(t, err, "mock error")
+}
+
+func TestEventsProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := mock.NewEventsProvider()
+
+	received := 0
+	require.NoError(t, provider.Events(ctx, []string{"head"}, func(_ *api.Event) { received++ }))
+	require.Equal(t, 1, provider.Subscriptions("head"))
+
+	provider.SendEvent(&api.Event{Topic: "head"})
+	provider.SendEvent(&api.Event{Topic: "block"})
+	require.Equal(t, 1, received)
+
+	provider.SetError(errors.New("mock error"))
+	require.EqualError(t, provider.Events(ctx, []string{"block"}, func(_ *api.Event) {}), "mock error")
+	require.Equal(t, 0, provider.Subscriptions("block"))
+}
@@ -0,0 +1,445 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// farFutureEpoch is the epoch of forks that are not scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// syncCommitteeSize is the number of members of a sync committee.
+const syncCommitteeSize = 512
+
+var (
+	genesisForkVersion   = phase0.Version{0x00, 0x00, 0x00, 0x00}
+	altairForkVersion    = phase0.Version{0x01, 0x00, 0x00, 0x00}
+	bellatrixForkVersion = phase0.Version{0x02, 0x00, 0x00, 0x00}
+)
+
+// chainBlock is a block in the simulated chain.
+type chainBlock struct {
+	root      phase0.Root
+	slot      phase0.Slot
+	parent    *chainBlock
+	stateRoot phase0.Root
+	bodyRoot  phase0.Root
+	// Execution block details, zero prior to Bellatrix.
+	executionBlockHash   phase0.Hash32
+	executionBlockNumber uint64
+	signed               *spec.VersionedSignedBeaconBlock
+}
+
+// Head returns the root of the head of the simulated chain.
+func (s *Service) Head() phase0.Root {
+	s.chainMu.RLock()
+	defer s.chainMu.RUnlock()
+
+	return s.head.root
+}
+
+// ProposeBlock adds a block at the given slot on top of the current head, and makes it the new head.
+// Slots between the current head and the new block are left empty.
+func (s *Service) ProposeBlock(slot phase0.Slot) (phase0.Root, error) {
+	s.chainMu.Lock()
+	if slot <= s.head.slot {
+		s.chainMu.Unlock()
+		return phase0.Root{}, fmt.Errorf("slot %d is not after head slot %d", slot, s.head.slot)
+	}
+	block, err := s.buildBlock(slot, s.head)
+	if err != nil {
+		s.chainMu.Unlock()
+		return phase0.Root{}, errors.Wrap(err, "failed to build block")
+	}
+	s.addBlock(block)
+	s.head = block
+	s.chainMu.Unlock()
+
+	if err := s.sendEvent("block", &api.BlockEvent{
+		Slot:  block.slot,
+		Block: block.root,
+	}); err != nil {
+		return phase0.Root{}, err
+	}
+	if err := s.sendEvent("head", s.headEvent(block)); err != nil {
+		return phase0.Root{}, err
+	}
+
+	return block.root, nil
+}
+
+// Reorg makes the block with the given root the head of the simulated chain.
+// The block can be an ancestor of the current head, in which case the chain is rewound and
+// later blocks become non-canonical, or on a branch previously abandoned by a reorg.
+// Blocks proposed after the reorg build on the new head.
+func (s *Service) Reorg(root phase0.Root) error {
+	s.chainMu.Lock()
+	newHead, exists := s.blocks[root]
+	if !exists {
+		s.chainMu.Unlock()
+		return fmt.Errorf("unknown block %#x", root)
+	}
+	if ancestorAtSlot(newHead, s.finalized.slot) != s.finalized {
+		s.chainMu.Unlock()
+		return errors.New("block does not descend from the finalized block")
+	}
+	oldHead := s.head
+	commonAncestor := commonAncestor(oldHead, newHead)
+	s.head = newHead
+	s.chainMu.Unlock()
+
+	if err := s.sendEvent("chain_reorg", &api.ChainReorgEvent{
+		Slot:         newHead.slot,
+		Depth:        uint64(oldHead.slot - commonAncestor.slot),
+		OldHeadBlock: oldHead.root,
+		NewHeadBlock: newHead.root,
+		OldHeadState: oldHead.stateRoot,
+		NewHeadState: newHead.stateRoot,
+		Epoch:        s.epochAtSlot(newHead.slot),
+	}); err != nil {
+		return err
+	}
+
+	return s.sendEvent("head", s.headEvent(newHead))
+}
+
+// Finalize finalizes the simulated chain at the given epoch.
+// The finalized block is the canonical block at or before the first slot of the epoch.
+func (s *Service) Finalize(epoch phase0.Epoch) error {
+	s.chainMu.Lock()
+	if epoch < s.finalizedEpoch {
+		s.chainMu.Unlock()
+		return fmt.Errorf("epoch %d is before finalized epoch %d", epoch, s.finalizedEpoch)
+	}
+	if s.firstSlotOfEpoch(epoch) > s.head.slot {
+		s.chainMu.Unlock()
+		return fmt.Errorf("epoch %d starts after head slot %d", epoch, s.head.slot)
+	}
+	s.finalized = ancestorAtSlot(s.head, s.firstSlotOfEpoch(epoch))
+	s.finalizedEpoch = epoch
+	finalized := s.finalized
+	s.chainMu.Unlock()
+
+	return s.sendEvent("finalized_checkpoint", &api.FinalizedCheckpointEvent{
+		Block: finalized.root,
+		State: finalized.stateRoot,
+		Epoch: epoch,
+	})
+}
+
+// headEvent returns the head event for the given block.
+func (s *Service) headEvent(block *chainBlock) *api.HeadEvent {
+	return &api.HeadEvent{
+		Slot:            block.slot,
+		Block:           block.root,
+		State:           block.stateRoot,
+		EpochTransition: uint64(block.slot)%s.slotsPerEpoch == 0,
+	}
+}
+
+// addBlock adds a block to the chain.
+// This assumes that the chain lock is held.
+func (s *Service) addBlock(block *chainBlock) {
+	s.blocks[block.root] = block
+	s.states[block.stateRoot] = block
+}
+
+// ancestorAtSlot returns the latest ancestor of the block, including the block itself, at or before the given slot.
+func ancestorAtSlot(block *chainBlock, slot phase0.Slot) *chainBlock {
+	for block.parent != nil && block.slot > slot {
+		block = block.parent
+	}
+
+	return block
+}
+
+// commonAncestor returns the latest block that is an ancestor of both blocks.
+func commonAncestor(block1 *chainBlock, block2 *chainBlock) *chainBlock {
+	for block1 != block2 {
+		if block1.slot >= block2.slot {
+			block1 = block1.parent
+		} else {
+			block2 = block2.parent
+		}
+	}
+
+	return block1
+}
+
+// canonicalBlockAtSlot returns the canonical block at the given slot, or nil if the slot is empty.
+// This assumes that the chain lock is held.
+func (s *Service) canonicalBlockAtSlot(slot phase0.Slot) *chainBlock {
+	if slot > s.head.slot {
+		return nil
+	}
+	block := ancestorAtSlot(s.head, slot)
+	if block.slot != slot {
+		return nil
+	}
+
+	return block
+}
+
+// isCanonical returns true if the block is part of the canonical chain.
+// This assumes that the chain lock is held.
+func (s *Service) isCanonical(block *chainBlock) bool {
+	return s.canonicalBlockAtSlot(block.slot) == block
+}
+
+// versionAtSlot returns the fork version of blocks at the given slot.
+func (s *Service) versionAtSlot(slot phase0.Slot) spec.DataVersion {
+	epoch := s.epochAtSlot(slot)
+	switch {
+	case epoch >= s.bellatrixForkEpoch:
+		return spec.DataVersionBellatrix
+	case epoch >= s.altairForkEpoch:
+		return spec.DataVersionAltair
+	default:
+		return spec.DataVersionPhase0
+	}
+}
+
+// committee returns the beacon committee for the given slot.
+// There is a single committee per slot, containing every validator whose index matches the slot within the epoch.
+func (s *Service) committee(slot phase0.Slot) []phase0.ValidatorIndex {
+	committee := make([]phase0.ValidatorIndex, 0, s.validators/s.slotsPerEpoch+1)
+	for i := uint64(slot) % s.slotsPerEpoch; i < s.validators; i += s.slotsPerEpoch {
+		committee = append(committee, phase0.ValidatorIndex(i))
+	}
+
+	return committee
+}
+
+// syncCommittee returns the sync committee, which is the same for all periods.
+func (s *Service) syncCommittee() []phase0.ValidatorIndex {
+	committee := make([]phase0.ValidatorIndex, syncCommitteeSize)
+	for i := range committee {
+		committee[i] = phase0.ValidatorIndex(uint64(i) % s.validators)
+	}
+
+	return committee
+}
+
+// proposer returns the proposer for the given slot.
+func (s *Service) proposer(slot phase0.Slot) phase0.ValidatorIndex {
+	return phase0.ValidatorIndex(uint64(slot) % s.validators)
+}
+
+// hash returns a deterministic hash of a root and a slot.
+func hash(root [32]byte, slot phase0.Slot) [32]byte {
+	data := make([]byte, 40)
+	copy(data, root[:])
+	binary.LittleEndian.PutUint64(data[32:], uint64(slot))
+
+	return sha256.Sum256(data)
+}
+
+// buildBlock builds a block at the given slot with the given parent.
+// This assumes that the chain lock is held.
+func (s *Service) buildBlock(slot phase0.Slot, parent *chainBlock) (*chainBlock, error) {
+	block := &chainBlock{
+		slot:   slot,
+		parent: parent,
+	}
+	var parentRoot phase0.Root
+	var parentStateRoot phase0.Root
+	if parent != nil {
+		parentRoot = parent.root
+		parentStateRoot = parent.stateRoot
+	}
+	block.stateRoot = hash(parentStateRoot, slot)
+
+	randaoReveal := phase0.BLSSignature{}
+	binary.LittleEndian.PutUint64(randaoReveal[:], uint64(slot))
+	eth1BlockHash := hash(phase0.Root{}, 0)
+	eth1Data := &phase0.ETH1Data{
+		DepositRoot:  phase0.Root{0x01},
+		DepositCount: s.validators,
+		BlockHash:    eth1BlockHash[:],
+	}
+	var graffiti [32]byte
+	copy(graffiti[:], "chaind simulator")
+	attestations := make([]*phase0.Attestation, 0, 1)
+	if parent != nil {
+		attestations = append(attestations, s.attestation(parent))
+	}
+
+	var err error
+	switch s.versionAtSlot(slot) {
+	case spec.DataVersionPhase0:
+		message := &phase0.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: s.proposer(slot),
+			ParentRoot:    parentRoot,
+			StateRoot:     block.stateRoot,
+			Body: &phase0.BeaconBlockBody{
+				RANDAOReveal:      randaoReveal,
+				ETH1Data:          eth1Data,
+				Graffiti:          graffiti,
+				ProposerSlashings: make([]*phase0.ProposerSlashing, 0),
+				AttesterSlashings: make([]*phase0.AttesterSlashing, 0),
+				Attestations:      attestations,
+				Deposits:          make([]*phase0.Deposit, 0),
+				VoluntaryExits:    make([]*phase0.SignedVoluntaryExit, 0),
+			},
+		}
+		if block.bodyRoot, err = message.Body.HashTreeRoot(); err != nil {
+			break
+		}
+		block.root, err = message.HashTreeRoot()
+		block.signed = &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionPhase0,
+			Phase0:  &phase0.SignedBeaconBlock{Message: message},
+		}
+	case spec.DataVersionAltair:
+		message := &altair.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: s.proposer(slot),
+			ParentRoot:    parentRoot,
+			StateRoot:     block.stateRoot,
+			Body: &altair.BeaconBlockBody{
+				RANDAOReveal:      randaoReveal,
+				ETH1Data:          eth1Data,
+				Graffiti:          graffiti,
+				ProposerSlashings: make([]*phase0.ProposerSlashing, 0),
+				AttesterSlashings: make([]*phase0.AttesterSlashing, 0),
+				Attestations:      attestations,
+				Deposits:          make([]*phase0.Deposit, 0),
+				VoluntaryExits:    make([]*phase0.SignedVoluntaryExit, 0),
+				SyncAggregate:     s.syncAggregate(),
+			},
+		}
+		if block.bodyRoot, err = message.Body.HashTreeRoot(); err != nil {
+			break
+		}
+		block.root, err = message.HashTreeRoot()
+		block.signed = &spec.VersionedSignedBeaconBlock{
+			Version: spec.DataVersionAltair,
+			Altair:  &altair.SignedBeaconBlock{Message: message},
+		}
+	case spec.DataVersionBellatrix:
+		if parent != nil {
+			block.executionBlockNumber = parent.executionBlockNumber + 1
+		}
+		block.executionBlockHash = hash(parentRoot, slot)
+		message := &bellatrix.BeaconBlock{
+			Slot:          slot,
+			ProposerIndex: s.proposer(slot),
+			ParentRoot:    parentRoot,
+			StateRoot:     block.stateRoot,
+			Body: &bellatrix.BeaconBlockBody{
+				RANDAOReveal:      randaoReveal,
+				ETH1Data:          eth1Data,
+				Graffiti:          graffiti,
+				ProposerSlashings: make([]*phase0.ProposerSlashing, 0),
+				AttesterSlashings: make([]*phase0.AttesterSlashing, 0),
+				Attestations:      attestations,
+				Deposits:          make([]*phase0.Deposit, 0),
+				VoluntaryExits:    make([]*phase0.SignedVoluntaryExit, 0),
+				SyncAggregate:     s.syncAggregate(),
+				ExecutionPayload:  s.executionPayload(block),
+			},
+		}
+		if block.bodyRoot, err = message.Body.HashTreeRoot(); err != nil {
+			break
+		}
+		block.root, err = message.HashTreeRoot()
+		block.signed = &spec.VersionedSignedBeaconBlock{
+			Version:   spec.DataVersionBellatrix,
+			Bellatrix: &bellatrix.SignedBeaconBlock{Message: message},
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate block root")
+	}
+
+	return block, nil
+}
+
+// attestation returns an attestation for the given block from all members of its slot's committee.
+// This assumes that the chain lock is held.
+func (s *Service) attestation(block *chainBlock) *phase0.Attestation {
+	committee := s.committee(block.slot)
+	// Bitlist with all committee members set, and the trailing length bit.
+	aggregationBits := make([]byte, len(committee)/8+1)
+	for i := 0; i <= len(committee); i++ {
+		aggregationBits[i/8] |= 1 << (i % 8)
+	}
+	epoch := s.epochAtSlot(block.slot)
+
+	return &phase0.Attestation{
+		AggregationBits: aggregationBits,
+		Data: &phase0.AttestationData{
+			Slot:            block.slot,
+			Index:           0,
+			BeaconBlockRoot: block.root,
+			Source: &phase0.Checkpoint{
+				Epoch: s.finalizedEpoch,
+				Root:  s.finalized.root,
+			},
+			Target: &phase0.Checkpoint{
+				Epoch: epoch,
+				Root:  ancestorAtSlot(block, s.firstSlotOfEpoch(epoch)).root,
+			},
+		},
+	}
+}
+
+// syncAggregate returns a sync aggregate in which all members of the sync committee participated.
+func (*Service) syncAggregate() *altair.SyncAggregate {
+	bits := make([]byte, syncCommitteeSize/8)
+	for i := range bits {
+		bits[i] = 0xff
+	}
+
+	return &altair.SyncAggregate{
+		SyncCommitteeBits: bits,
+	}
+}
+
+// executionPayload returns the execution payload for the given block.
+func (s *Service) executionPayload(block *chainBlock) *bellatrix.ExecutionPayload {
+	var parentHash phase0.Hash32
+	if block.parent != nil {
+		parentHash = block.parent.executionBlockHash
+	}
+	var baseFeePerGas [32]byte
+	// Little-endian 7 wei, as per the minimum base fee.
+	baseFeePerGas[0] = 0x07
+
+	return &bellatrix.ExecutionPayload{
+		ParentHash:    parentHash,
+		FeeRecipient:  bellatrix.ExecutionAddress{0x01},
+		StateRoot:     hash(block.stateRoot, block.slot),
+		PrevRandao:    hash(parentHash, block.slot),
+		BlockNumber:   block.executionBlockNumber,
+		GasLimit:      30000000,
+		Timestamp:     uint64(s.genesisTime.Add(time.Duration(block.slot) * s.slotDuration).Unix()),
+		ExtraData:     make([]byte, 0),
+		BaseFeePerGas: baseFeePerGas,
+		BlockHash:     block.executionBlockHash,
+		Transactions:  make([]bellatrix.Transaction, 0),
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// event is an event to be sent to subscribers.
+type event struct {
+	topic string
+	data  []byte
+}
+
+// subscriber is a client subscribed to events.
+type subscriber struct {
+	topics map[string]bool
+	events chan *event
+	done   chan struct{}
+}
+
+// sendEvent sends an event to all subscribers to its topic.
+// Events are delivered in order, and sendEvent blocks until subscribers have accepted them.
+func (s *Service) sendEvent(topic string, data interface{}) error {
+	eventData, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to marshal %s event", topic))
+	}
+	event := &event{
+		topic: topic,
+		data:  eventData,
+	}
+
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for subscriber := range s.subscribers {
+		if !subscriber.topics[topic] {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		case <-subscriber.done:
+		}
+	}
+
+	return nil
+}
+
+// handleEvents handles requests for the events stream.
+func (s *Service) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, isFlusher := w.(http.Flusher)
+	if !isFlusher {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	subscriber := &subscriber{
+		topics: make(map[string]bool),
+		events: make(chan *event, 64),
+		done:   make(chan struct{}),
+	}
+	for _, topics := range r.URL.Query()["topics"] {
+		for _, topic := range strings.Split(topics, ",") {
+			subscriber.topics[topic] = true
+		}
+	}
+	if len(subscriber.topics) == 0 {
+		http.Error(w, "no topics supplied", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	s.subscribersMu.Lock()
+	s.subscribers[subscriber] = true
+	s.subscribersMu.Unlock()
+	defer func() {
+		// Close done first so that any sender blocked on this subscriber releases the lock.
+		close(subscriber.done)
+		s.subscribersMu.Lock()
+		delete(s.subscribers, subscriber)
+		s.subscribersMu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-subscriber.events:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.topic, event.data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
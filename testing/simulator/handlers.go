@@ -0,0 +1,577 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// maxEffectiveBalance is the balance of every validator.
+const maxEffectiveBalance = phase0.Gwei(32000000000)
+
+// dataResponse is the standard beacon API response.
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+// versionedDataResponse is the beacon API response for data that depends on the fork.
+type versionedDataResponse struct {
+	Version string      `json:"version"`
+	Data    interface{} `json:"data"`
+}
+
+// dependentDataResponse is the beacon API response for duties.
+type dependentDataResponse struct {
+	DependentRoot string      `json:"dependent_root"`
+	Data          interface{} `json:"data"`
+}
+
+// rootResponse is the data of beacon API responses that contain only a root.
+type rootResponse struct {
+	Root string `json:"root"`
+}
+
+// errorResponse is the beacon API response for failed requests.
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// handler returns the handler for the beacon API.
+func (s *Service) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", s.handleGenesis)
+	mux.HandleFunc("/eth/v1/config/spec", s.handleSpec)
+	mux.HandleFunc("/eth/v1/config/deposit_contract", s.handleDepositContract)
+	mux.HandleFunc("/eth/v1/config/fork_schedule", s.handleForkSchedule)
+	mux.HandleFunc("/eth/v1/node/version", s.handleNodeVersion)
+	mux.HandleFunc("/eth/v1/node/syncing", s.handleNodeSyncing)
+	mux.HandleFunc("/eth/v1/events", s.handleEvents)
+	mux.HandleFunc("/eth/v1/beacon/blocks/", s.handleBlockV1)
+	mux.HandleFunc("/eth/v2/beacon/blocks/", s.handleBlockV2)
+	mux.HandleFunc("/eth/v1/beacon/headers/", s.handleHeader)
+	mux.HandleFunc("/eth/v1/beacon/states/", s.handleState)
+	mux.HandleFunc("/eth/v1/validator/duties/proposer/", s.handleProposerDuties)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Service) handleGenesis(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &dataResponse{Data: &api.Genesis{
+		GenesisTime:           s.genesisTime,
+		GenesisValidatorsRoot: hash(phase0.Root{}, phase0.Slot(s.validators)),
+		GenesisForkVersion:    genesisForkVersion,
+	}})
+}
+
+func (s *Service) handleSpec(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &dataResponse{Data: map[string]string{
+		"CONFIG_NAME":                      "simulator",
+		"PRESET_BASE":                      "mainnet",
+		"SLOTS_PER_EPOCH":                  fmt.Sprintf("%d", s.slotsPerEpoch),
+		"SECONDS_PER_SLOT":                 fmt.Sprintf("%d", int64(s.slotDuration.Seconds())),
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "256",
+		"SYNC_COMMITTEE_SIZE":              fmt.Sprintf("%d", syncCommitteeSize),
+		"MAX_COMMITTEES_PER_SLOT":          "1",
+		"MIN_ATTESTATION_INCLUSION_DELAY":  "1",
+		"MAX_EFFECTIVE_BALANCE":            fmt.Sprintf("%d", maxEffectiveBalance),
+		"EFFECTIVE_BALANCE_INCREMENT":      "1000000000",
+		"BASE_REWARD_FACTOR":               "64",
+		"GENESIS_FORK_VERSION":             fmt.Sprintf("%#x", genesisForkVersion),
+		"ALTAIR_FORK_VERSION":              fmt.Sprintf("%#x", altairForkVersion),
+		"ALTAIR_FORK_EPOCH":                fmt.Sprintf("%d", s.altairForkEpoch),
+		"BELLATRIX_FORK_VERSION":           fmt.Sprintf("%#x", bellatrixForkVersion),
+		"BELLATRIX_FORK_EPOCH":             fmt.Sprintf("%d", s.bellatrixForkEpoch),
+		"DEPOSIT_CHAIN_ID":                 "1",
+		"DEPOSIT_NETWORK_ID":               "1",
+		"DEPOSIT_CONTRACT_ADDRESS":         "0x00000000219ab540356cbb839cbe05303d7705fa",
+	}})
+}
+
+func (*Service) handleDepositContract(w http.ResponseWriter, _ *http.Request) {
+	address, _ := hex.DecodeString("00000000219ab540356cbb839cbe05303d7705fa")
+	writeJSON(w, http.StatusOK, &dataResponse{Data: &api.DepositContract{
+		ChainID: 1,
+		Address: address,
+	}})
+}
+
+func (s *Service) handleForkSchedule(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &dataResponse{Data: []*phase0.Fork{
+		{
+			PreviousVersion: genesisForkVersion,
+			CurrentVersion:  genesisForkVersion,
+			Epoch:           0,
+		},
+		{
+			PreviousVersion: genesisForkVersion,
+			CurrentVersion:  altairForkVersion,
+			Epoch:           s.altairForkEpoch,
+		},
+		{
+			PreviousVersion: altairForkVersion,
+			CurrentVersion:  bellatrixForkVersion,
+			Epoch:           s.bellatrixForkEpoch,
+		},
+	}})
+}
+
+func (*Service) handleNodeVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &dataResponse{Data: map[string]string{
+		"version": "chaind-simulator",
+	}})
+}
+
+func (s *Service) handleNodeSyncing(w http.ResponseWriter, _ *http.Request) {
+	s.chainMu.RLock()
+	headSlot := s.head.slot
+	s.chainMu.RUnlock()
+
+	writeJSON(w, http.StatusOK, &dataResponse{Data: &api.SyncState{
+		HeadSlot: headSlot,
+	}})
+}
+
+// handleBlockV1 handles requests for phase 0 blocks, and for block roots.
+func (s *Service) handleBlockV1(w http.ResponseWriter, r *http.Request) {
+	blockID := strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/blocks/")
+	blockID, wantRoot := trimSuffix(blockID, "/root")
+
+	s.chainMu.RLock()
+	block := s.blockByID(blockID)
+	s.chainMu.RUnlock()
+	if block == nil {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+
+	if wantRoot {
+		writeJSON(w, http.StatusOK, &dataResponse{Data: &rootResponse{Root: fmt.Sprintf("%#x", block.root)}})
+		return
+	}
+	if block.signed.Version != spec.DataVersionPhase0 {
+		writeError(w, http.StatusBadRequest, "block is not a phase 0 block")
+		return
+	}
+	writeJSON(w, http.StatusOK, &dataResponse{Data: block.signed.Phase0})
+}
+
+// handleBlockV2 handles requests for blocks of any fork.
+func (s *Service) handleBlockV2(w http.ResponseWriter, r *http.Request) {
+	blockID := strings.TrimPrefix(r.URL.Path, "/eth/v2/beacon/blocks/")
+
+	s.chainMu.RLock()
+	block := s.blockByID(blockID)
+	s.chainMu.RUnlock()
+	if block == nil {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+
+	if s.ssz && strings.Contains(r.Header.Get("Accept"), "application/octet-stream") {
+		writeBlockSSZ(w, block.signed)
+		return
+	}
+
+	res := &versionedDataResponse{
+		Version: strings.ToLower(block.signed.Version.String()),
+	}
+	switch block.signed.Version {
+	case spec.DataVersionPhase0:
+		res.Data = block.signed.Phase0
+	case spec.DataVersionAltair:
+		res.Data = block.signed.Altair
+	case spec.DataVersionBellatrix:
+		res.Data = block.signed.Bellatrix
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Service) handleHeader(w http.ResponseWriter, r *http.Request) {
+	blockID := strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/headers/")
+
+	s.chainMu.RLock()
+	block := s.blockByID(blockID)
+	var canonical bool
+	if block != nil {
+		canonical = s.isCanonical(block)
+	}
+	s.chainMu.RUnlock()
+	if block == nil {
+		writeError(w, http.StatusNotFound, "block not found")
+		return
+	}
+
+	var parentRoot phase0.Root
+	if block.parent != nil {
+		parentRoot = block.parent.root
+	}
+	writeJSON(w, http.StatusOK, &dataResponse{Data: &api.BeaconBlockHeader{
+		Root:      block.root,
+		Canonical: canonical,
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message: &phase0.BeaconBlockHeader{
+				Slot:          block.slot,
+				ProposerIndex: s.proposer(block.slot),
+				ParentRoot:    parentRoot,
+				StateRoot:     block.stateRoot,
+				BodyRoot:      block.bodyRoot,
+			},
+		},
+	}})
+}
+
+// handleState handles requests for information obtained from a state.
+func (s *Service) handleState(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/states/"), "/")
+	if len(parts) != 2 {
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+		return
+	}
+
+	s.chainMu.RLock()
+	defer s.chainMu.RUnlock()
+	slot, exists := s.slotByStateID(parts[0])
+	if !exists {
+		writeError(w, http.StatusNotFound, "state not found")
+		return
+	}
+
+	switch parts[1] {
+	case "root":
+		writeJSON(w, http.StatusOK, &dataResponse{Data: &rootResponse{Root: fmt.Sprintf("%#x", ancestorAtSlot(s.head, slot).stateRoot)}})
+	case "fork":
+		writeJSON(w, http.StatusOK, &dataResponse{Data: s.forkAtSlot(slot)})
+	case "finality_checkpoints":
+		// Finality is that of the current head, regardless of the state requested.
+		checkpoint := &phase0.Checkpoint{
+			Epoch: s.finalizedEpoch,
+			Root:  s.finalized.root,
+		}
+		writeJSON(w, http.StatusOK, &dataResponse{Data: &api.Finality{
+			Finalized:         checkpoint,
+			Justified:         checkpoint,
+			PreviousJustified: checkpoint,
+		}})
+	case "committees":
+		s.handleCommittees(w, r, slot)
+	case "sync_committees":
+		committee := s.syncCommittee()
+		aggregates := make([][]phase0.ValidatorIndex, 0, 4)
+		for i := 0; i < len(committee); i += len(committee) / 4 {
+			aggregates = append(aggregates, committee[i:i+len(committee)/4])
+		}
+		writeJSON(w, http.StatusOK, &dataResponse{Data: &api.SyncCommittee{
+			Validators:          committee,
+			ValidatorAggregates: aggregates,
+		}})
+	case "validators":
+		s.handleValidators(w, r)
+	case "validator_balances":
+		indices, err := s.validatorIndices(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		balances := make([]*api.ValidatorBalance, 0, len(indices))
+		for _, index := range indices {
+			balances = append(balances, &api.ValidatorBalance{
+				Index:   index,
+				Balance: maxEffectiveBalance,
+			})
+		}
+		writeJSON(w, http.StatusOK, &dataResponse{Data: balances})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+// handleCommittees handles requests for beacon committees.
+// This assumes that the chain lock is held.
+func (s *Service) handleCommittees(w http.ResponseWriter, r *http.Request, slot phase0.Slot) {
+	epoch := s.epochAtSlot(slot)
+	if epochStr := r.URL.Query().Get("epoch"); epochStr != "" {
+		tmp, err := strconv.ParseUint(epochStr, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid epoch")
+			return
+		}
+		epoch = phase0.Epoch(tmp)
+	}
+
+	committees := make([]*api.BeaconCommittee, 0, s.slotsPerEpoch)
+	for i := uint64(0); i < s.slotsPerEpoch; i++ {
+		committeeSlot := s.firstSlotOfEpoch(epoch) + phase0.Slot(i)
+		committees = append(committees, &api.BeaconCommittee{
+			Slot:       committeeSlot,
+			Index:      0,
+			Validators: s.committee(committeeSlot),
+		})
+	}
+	writeJSON(w, http.StatusOK, &dataResponse{Data: committees})
+}
+
+// handleValidators handles requests for validators.
+func (s *Service) handleValidators(w http.ResponseWriter, r *http.Request) {
+	indices, err := s.validatorIndices(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	withdrawalCredentials := make([]byte, 32)
+	validators := make([]*api.Validator, 0, len(indices))
+	for _, index := range indices {
+		validators = append(validators, &api.Validator{
+			Index:   index,
+			Balance: maxEffectiveBalance,
+			Status:  api.ValidatorStateActiveOngoing,
+			Validator: &phase0.Validator{
+				PublicKey:                  validatorPubKey(index),
+				WithdrawalCredentials:      withdrawalCredentials,
+				EffectiveBalance:           maxEffectiveBalance,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  farFutureEpoch,
+				WithdrawableEpoch:          farFutureEpoch,
+			},
+		})
+	}
+	writeJSON(w, http.StatusOK, &dataResponse{Data: validators})
+}
+
+// validatorIndices returns the indices of the validators requested by index or public key,
+// or all validators if none are requested.  Unknown validators are ignored.
+func (s *Service) validatorIndices(r *http.Request) ([]phase0.ValidatorIndex, error) {
+	ids := make([]string, 0)
+	for _, param := range r.URL.Query()["id"] {
+		ids = append(ids, strings.Split(param, ",")...)
+	}
+
+	if len(ids) == 0 {
+		indices := make([]phase0.ValidatorIndex, s.validators)
+		for i := range indices {
+			indices[i] = phase0.ValidatorIndex(i)
+		}
+		return indices, nil
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0, len(ids))
+	for _, id := range ids {
+		if strings.HasPrefix(id, "0x") {
+			data, err := hex.DecodeString(strings.TrimPrefix(id, "0x"))
+			if err != nil || len(data) != phase0.PublicKeyLength {
+				return nil, fmt.Errorf("invalid public key %s", id)
+			}
+			var pubKey phase0.BLSPubKey
+			copy(pubKey[:], data)
+			if index, exists := s.pubKeys[pubKey]; exists {
+				indices = append(indices, index)
+			}
+			continue
+		}
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator index %s", id)
+		}
+		if index < s.validators {
+			indices = append(indices, phase0.ValidatorIndex(index))
+		}
+	}
+
+	return indices, nil
+}
+
+func (s *Service) handleProposerDuties(w http.ResponseWriter, r *http.Request) {
+	tmp, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/eth/v1/validator/duties/proposer/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid epoch")
+		return
+	}
+	epoch := phase0.Epoch(tmp)
+
+	duties := make([]*api.ProposerDuty, 0, s.slotsPerEpoch)
+	for i := uint64(0); i < s.slotsPerEpoch; i++ {
+		slot := s.firstSlotOfEpoch(epoch) + phase0.Slot(i)
+		duties = append(duties, &api.ProposerDuty{
+			PubKey:         validatorPubKey(s.proposer(slot)),
+			Slot:           slot,
+			ValidatorIndex: s.proposer(slot),
+		})
+	}
+
+	s.chainMu.RLock()
+	dependentRoot := s.genesis.root
+	if epoch > 0 {
+		dependentRoot = ancestorAtSlot(s.head, s.firstSlotOfEpoch(epoch)-1).root
+	}
+	s.chainMu.RUnlock()
+
+	writeJSON(w, http.StatusOK, &dependentDataResponse{
+		DependentRoot: fmt.Sprintf("%#x", dependentRoot),
+		Data:          duties,
+	})
+}
+
+// blockByID returns the block for the given block ID, or nil if there is no such block.
+// Roots can refer to any block, other IDs refer to canonical blocks.
+// This assumes that the chain lock is held.
+func (s *Service) blockByID(blockID string) *chainBlock {
+	switch {
+	case blockID == "head":
+		return s.head
+	case blockID == "genesis":
+		return s.genesis
+	case blockID == "finalized":
+		return s.finalized
+	case strings.HasPrefix(blockID, "0x"):
+		root, err := parseRoot(blockID)
+		if err != nil {
+			return nil
+		}
+		return s.blocks[root]
+	default:
+		slot, err := strconv.ParseUint(blockID, 10, 64)
+		if err != nil {
+			return nil
+		}
+		return s.canonicalBlockAtSlot(phase0.Slot(slot))
+	}
+}
+
+// slotByStateID returns the slot for the given state ID.
+// This assumes that the chain lock is held.
+func (s *Service) slotByStateID(stateID string) (phase0.Slot, bool) {
+	switch {
+	case stateID == "head":
+		return s.head.slot, true
+	case stateID == "genesis":
+		return 0, true
+	case stateID == "finalized", stateID == "justified":
+		return s.finalized.slot, true
+	case strings.HasPrefix(stateID, "0x"):
+		root, err := parseRoot(stateID)
+		if err != nil {
+			return 0, false
+		}
+		block, exists := s.states[root]
+		if !exists {
+			return 0, false
+		}
+		return block.slot, true
+	default:
+		slot, err := strconv.ParseUint(stateID, 10, 64)
+		if err != nil || phase0.Slot(slot) > s.head.slot {
+			return 0, false
+		}
+		return phase0.Slot(slot), true
+	}
+}
+
+// forkAtSlot returns the fork in effect at the given slot.
+func (s *Service) forkAtSlot(slot phase0.Slot) *phase0.Fork {
+	switch s.versionAtSlot(slot) {
+	case spec.DataVersionBellatrix:
+		return &phase0.Fork{PreviousVersion: altairForkVersion, CurrentVersion: bellatrixForkVersion, Epoch: s.bellatrixForkEpoch}
+	case spec.DataVersionAltair:
+		return &phase0.Fork{PreviousVersion: genesisForkVersion, CurrentVersion: altairForkVersion, Epoch: s.altairForkEpoch}
+	default:
+		return &phase0.Fork{PreviousVersion: genesisForkVersion, CurrentVersion: genesisForkVersion, Epoch: 0}
+	}
+}
+
+// parseRoot parses a hex root.
+func parseRoot(input string) (phase0.Root, error) {
+	var root phase0.Root
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return root, err
+	}
+	if len(data) != len(root) {
+		return root, fmt.Errorf("invalid root length %d", len(data))
+	}
+	copy(root[:], data)
+
+	return root, nil
+}
+
+// trimSuffix trims the suffix from the input, returning true if it was present.
+func trimSuffix(input string, suffix string) (string, bool) {
+	if !strings.HasSuffix(input, suffix) {
+		return input, false
+	}
+
+	return strings.TrimSuffix(input, suffix), true
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// writeBlockSSZ writes a block as an SSZ response.
+func writeBlockSSZ(w http.ResponseWriter, block *spec.VersionedSignedBeaconBlock) {
+	var body []byte
+	var err error
+	switch block.Version {
+	case spec.DataVersionPhase0:
+		body, err = block.Phase0.MarshalSSZ()
+	case spec.DataVersionAltair:
+		body, err = block.Altair.MarshalSSZ()
+	case spec.DataVersionBellatrix:
+		body, err = block.Bellatrix.MarshalSSZ()
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to marshal response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Eth-Consensus-Version", strings.ToLower(block.Version.String()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(&errorResponse{
+		Code:    status,
+		Message: message,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
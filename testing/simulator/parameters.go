@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+type parameters struct {
+	listenAddress      string
+	validators         uint64
+	slotsPerEpoch      uint64
+	slotDuration       time.Duration
+	genesisTime        time.Time
+	altairForkEpoch    phase0.Epoch
+	bellatrixForkEpoch phase0.Epoch
+	ssz                bool
+}
+
+// Parameter is the interface for simulator parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithListenAddress sets the address on which the simulated beacon node listens.
+// The default address listens on a random local port.
+func WithListenAddress(listenAddress string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listenAddress = listenAddress
+	})
+}
+
+// WithValidators sets the number of validators in the simulated chain.
+func WithValidators(validators uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validators = validators
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots per epoch in the simulated chain.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithSlotDuration sets the duration of a slot in the simulated chain.
+func WithSlotDuration(slotDuration time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotDuration = slotDuration
+	})
+}
+
+// WithGenesisTime sets the genesis time of the simulated chain.
+func WithGenesisTime(genesisTime time.Time) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisTime = genesisTime
+	})
+}
+
+// WithAltairForkEpoch sets the epoch at which the simulated chain transitions to Altair.
+func WithAltairForkEpoch(epoch phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.altairForkEpoch = epoch
+	})
+}
+
+// WithBellatrixForkEpoch sets the epoch at which the simulated chain transitions to Bellatrix.
+func WithBellatrixForkEpoch(epoch phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bellatrixForkEpoch = epoch
+	})
+}
+
+// WithSSZ sets whether the simulated beacon node serves blocks in SSZ if requested.
+// By default it serves only JSON, as do some beacon nodes.
+func WithSSZ(ssz bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ssz = ssz
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		listenAddress:      "127.0.0.1:0",
+		validators:         64,
+		slotsPerEpoch:      32,
+		slotDuration:       12 * time.Second,
+		genesisTime:        time.Unix(1606824023, 0),
+		altairForkEpoch:    farFutureEpoch,
+		bellatrixForkEpoch: farFutureEpoch,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.listenAddress == "" {
+		return nil, errors.New("no listen address specified")
+	}
+	if parameters.slotsPerEpoch == 0 {
+		return nil, errors.New("no slots per epoch specified")
+	}
+	if parameters.validators < parameters.slotsPerEpoch {
+		return nil, errors.New("not enough validators to fill committees")
+	}
+	if parameters.slotDuration < time.Second || parameters.slotDuration%time.Second != 0 {
+		return nil, errors.New("slot duration must be a whole number of seconds")
+	}
+	if parameters.bellatrixForkEpoch < parameters.altairForkEpoch {
+		return nil, errors.New("bellatrix fork epoch cannot be before altair fork epoch")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,139 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// Service is a simulated beacon node.
+// It serves the subset of the beacon API consumed by chaind, for a chain that advances only
+// when instructed, so that ingestion can be tested deterministically.
+type Service struct {
+	listener           net.Listener
+	server             *http.Server
+	validators         uint64
+	slotsPerEpoch      uint64
+	slotDuration       time.Duration
+	genesisTime        time.Time
+	altairForkEpoch    phase0.Epoch
+	bellatrixForkEpoch phase0.Epoch
+	ssz                bool
+	pubKeys            map[phase0.BLSPubKey]phase0.ValidatorIndex
+
+	chainMu        sync.RWMutex
+	blocks         map[phase0.Root]*chainBlock
+	states         map[phase0.Root]*chainBlock
+	genesis        *chainBlock
+	head           *chainBlock
+	finalized      *chainBlock
+	finalizedEpoch phase0.Epoch
+
+	subscribersMu sync.Mutex
+	subscribers   map[*subscriber]bool
+}
+
+// New creates a new simulated beacon node with a genesis block, and starts serving requests.
+// The node stops when the context is done.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	s := &Service{
+		validators:         parameters.validators,
+		slotsPerEpoch:      parameters.slotsPerEpoch,
+		slotDuration:       parameters.slotDuration,
+		genesisTime:        parameters.genesisTime,
+		altairForkEpoch:    parameters.altairForkEpoch,
+		bellatrixForkEpoch: parameters.bellatrixForkEpoch,
+		ssz:                parameters.ssz,
+		pubKeys:            make(map[phase0.BLSPubKey]phase0.ValidatorIndex, parameters.validators),
+		blocks:             make(map[phase0.Root]*chainBlock),
+		states:             make(map[phase0.Root]*chainBlock),
+		subscribers:        make(map[*subscriber]bool),
+	}
+	for i := uint64(0); i < s.validators; i++ {
+		s.pubKeys[validatorPubKey(phase0.ValidatorIndex(i))] = phase0.ValidatorIndex(i)
+	}
+
+	genesis, err := s.buildBlock(0, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build genesis block")
+	}
+	s.addBlock(genesis)
+	s.genesis = genesis
+	s.head = genesis
+	s.finalized = genesis
+
+	s.listener, err = net.Listen("tcp", parameters.listenAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+	s.server = &http.Server{
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		// Serve only returns on error, which is reported to clients as failed requests.
+		_ = s.server.Serve(s.listener)
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = s.server.Close()
+	}()
+
+	return s, nil
+}
+
+// Address returns the address of the simulated beacon node, suitable for use by an HTTP client.
+func (s *Service) Address() string {
+	return fmt.Sprintf("http://%s", s.listener.Addr().String())
+}
+
+// EventSubscribers returns the number of clients currently subscribed to events.
+func (s *Service) EventSubscribers() int {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// validatorPubKey returns the deterministic public key of a validator.
+func validatorPubKey(index phase0.ValidatorIndex) phase0.BLSPubKey {
+	pubKey := phase0.BLSPubKey{0xa0}
+	binary.BigEndian.PutUint64(pubKey[1:9], uint64(index))
+
+	return pubKey
+}
+
+// epochAtSlot returns the epoch of the given slot.
+func (s *Service) epochAtSlot(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(uint64(slot) / s.slotsPerEpoch)
+}
+
+// firstSlotOfEpoch returns the first slot of the given epoch.
+func (s *Service) firstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(uint64(epoch) * s.slotsPerEpoch)
+}
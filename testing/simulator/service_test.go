@@ -0,0 +1,320 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"strings"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/http"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/testing/simulator"
+)
+
+// newClient creates a client for the simulator.  The client's context is never
+// cancelled, as go-eth2-client logs from a background goroutine on cancellation
+// and that races with the creation of clients in later tests.
+func newClient(t *testing.T, sim *simulator.Service) eth2client.Service {
+	t.Helper()
+	client, err := http.New(context.Background(),
+		http.WithLogLevel(zerolog.Disabled),
+		http.WithAddress(sim.Address()),
+		http.WithTimeout(5*time.Second),
+	)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []simulator.Parameter
+		err    string
+	}{
+		{
+			name: "Default",
+		},
+		{
+			name: "ValidatorsTooFew",
+			params: []simulator.Parameter{
+				simulator.WithValidators(16),
+			},
+			err: "problem with parameters: not enough validators to fill committees",
+		},
+		{
+			name: "SlotDurationFractional",
+			params: []simulator.Parameter{
+				simulator.WithSlotDuration(1500 * time.Millisecond),
+			},
+			err: "problem with parameters: slot duration must be a whole number of seconds",
+		},
+		{
+			name: "ForksOutOfOrder",
+			params: []simulator.Parameter{
+				simulator.WithAltairForkEpoch(2),
+				simulator.WithBellatrixForkEpoch(1),
+			},
+			err: "problem with parameters: bellatrix fork epoch cannot be before altair fork epoch",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			_, err := simulator.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStaticValues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx,
+		simulator.WithSlotsPerEpoch(8),
+		simulator.WithAltairForkEpoch(2),
+	)
+	require.NoError(t, err)
+	client := newClient(t, sim)
+
+	slotsPerEpoch, err := client.(eth2client.SlotsPerEpochProvider).SlotsPerEpoch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), slotsPerEpoch)
+
+	slotDuration, err := client.(eth2client.SlotDurationProvider).SlotDuration(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 12*time.Second, slotDuration)
+
+	schedule, err := client.(eth2client.ForkScheduleProvider).ForkSchedule(ctx)
+	require.NoError(t, err)
+	require.Len(t, schedule, 3)
+	require.Equal(t, phase0.Epoch(2), schedule[1].Epoch)
+}
+
+func TestForkTransitions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx,
+		simulator.WithSlotsPerEpoch(4),
+		simulator.WithValidators(16),
+		simulator.WithAltairForkEpoch(1),
+		simulator.WithBellatrixForkEpoch(2),
+	)
+	require.NoError(t, err)
+	client := newClient(t, sim)
+
+	roots := make(map[phase0.Slot]phase0.Root)
+	for slot := phase0.Slot(1); slot <= 10; slot++ {
+		roots[slot], err = sim.ProposeBlock(slot)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name    string
+		slot    phase0.Slot
+		version spec.DataVersion
+	}{
+		{
+			name:    "Phase0",
+			slot:    3,
+			version: spec.DataVersionPhase0,
+		},
+		{
+			name:    "Altair",
+			slot:    4,
+			version: spec.DataVersionAltair,
+		},
+		{
+			name:    "Bellatrix",
+			slot:    9,
+			version: spec.DataVersionBellatrix,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block, err := client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", test.slot))
+			require.NoError(t, err)
+			require.NotNil(t, block)
+			require.Equal(t, test.version, block.Version)
+			root, err := block.Root()
+			require.NoError(t, err)
+			require.Equal(t, roots[test.slot], root)
+			parentRoot, err := block.ParentRoot()
+			require.NoError(t, err)
+			require.Equal(t, roots[test.slot-1], parentRoot)
+		})
+	}
+}
+
+func TestReorg(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx)
+	require.NoError(t, err)
+	client := newClient(t, sim)
+	blockProvider := client.(eth2client.SignedBeaconBlockProvider)
+	headerProvider := client.(eth2client.BeaconBlockHeadersProvider)
+
+	root1, err := sim.ProposeBlock(1)
+	require.NoError(t, err)
+	root2, err := sim.ProposeBlock(2)
+	require.NoError(t, err)
+	_, err = sim.ProposeBlock(3)
+	require.NoError(t, err)
+
+	// Rewind to slot 1 and build a branch with slot 2 empty.
+	require.NoError(t, sim.Reorg(root1))
+	root3, err := sim.ProposeBlock(3)
+	require.NoError(t, err)
+	require.Equal(t, root3, sim.Head())
+
+	block, err := blockProvider.SignedBeaconBlock(ctx, "2")
+	require.NoError(t, err)
+	require.Nil(t, block)
+
+	header, err := headerProvider.BeaconBlockHeader(ctx, "3")
+	require.NoError(t, err)
+	require.Equal(t, root3, header.Root)
+	require.True(t, header.Canonical)
+
+	// The abandoned branch is still available by root, but not canonical.
+	header, err = headerProvider.BeaconBlockHeader(ctx, fmt.Sprintf("%#x", root2))
+	require.NoError(t, err)
+	require.False(t, header.Canonical)
+
+	// Return to the abandoned branch.
+	require.NoError(t, sim.Reorg(root2))
+	header, err = headerProvider.BeaconBlockHeader(ctx, "2")
+	require.NoError(t, err)
+	require.True(t, header.Canonical)
+
+	// Cannot reorg past finality.
+	require.NoError(t, sim.Finalize(0))
+	_, err = sim.ProposeBlock(32)
+	require.NoError(t, err)
+	require.NoError(t, sim.Finalize(1))
+	require.EqualError(t, sim.Reorg(root1), "block does not descend from the finalized block")
+}
+
+func TestDuties(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx,
+		simulator.WithSlotsPerEpoch(4),
+		simulator.WithValidators(16),
+	)
+	require.NoError(t, err)
+	client := newClient(t, sim)
+
+	committees, err := client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, "head")
+	require.NoError(t, err)
+	require.Len(t, committees, 4)
+	require.Equal(t, []phase0.ValidatorIndex{1, 5, 9, 13}, committees[1].Validators)
+
+	duties, err := client.(eth2client.ProposerDutiesProvider).ProposerDuties(ctx, 1, nil)
+	require.NoError(t, err)
+	require.Len(t, duties, 4)
+	require.Equal(t, phase0.ValidatorIndex(4), duties[0].ValidatorIndex)
+
+	validators, err := client.(eth2client.ValidatorsProvider).Validators(ctx, "head", []phase0.ValidatorIndex{3, 20})
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	require.Equal(t, api.ValidatorStateActiveOngoing, validators[3].Status)
+
+	finality, err := client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(0), finality.Finalized.Epoch)
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := simulator.New(ctx)
+	require.NoError(t, err)
+
+	// Read the stream directly, as the client's own subscriber reconnects in
+	// the background for the life of the process.
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, fmt.Sprintf("%s/eth/v1/events?topics=head&topics=chain_reorg", sim.Address()), nil)
+	require.NoError(t, err)
+	resp, err := nethttp.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, nethttp.StatusOK, resp.StatusCode)
+	require.Eventually(t, func() bool { return sim.EventSubscribers() == 1 }, 10*time.Second, 10*time.Millisecond)
+
+	scanner := bufio.NewScanner(resp.Body)
+	nextEvent := func() (string, []byte) {
+		var topic string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				topic = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				return topic, []byte(strings.TrimPrefix(line, "data: "))
+			}
+		}
+		require.NoError(t, scanner.Err())
+		require.Fail(t, "event stream closed")
+		return "", nil
+	}
+
+	root1, err := sim.ProposeBlock(1)
+	require.NoError(t, err)
+	topic, data := nextEvent()
+	require.Equal(t, "head", topic)
+	headEvent := &api.HeadEvent{}
+	require.NoError(t, json.Unmarshal(data, headEvent))
+	require.Equal(t, root1, headEvent.Block)
+
+	_, err = sim.ProposeBlock(2)
+	require.NoError(t, err)
+	nextEvent()
+	require.NoError(t, sim.Reorg(root1))
+	topic, data = nextEvent()
+	require.Equal(t, "chain_reorg", topic)
+	reorgEvent := &api.ChainReorgEvent{}
+	require.NoError(t, json.Unmarshal(data, reorgEvent))
+	require.Equal(t, uint64(1), reorgEvent.Depth)
+	topic, data = nextEvent()
+	require.Equal(t, "head", topic)
+	headEvent = &api.HeadEvent{}
+	require.NoError(t, json.Unmarshal(data, headEvent))
+	require.Equal(t, root1, headEvent.Block)
+}
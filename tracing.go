@@ -0,0 +1,80 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// initTracing initialises tracing, exporting spans over OTLP to the configured address.
+// The returned function flushes any outstanding spans, and should be called before exiting.
+func initTracing(ctx context.Context) (func(), error) {
+	address := viper.GetString("tracing-address")
+	if address == "" {
+		log.Debug().Msg("No tracing address supplied; tracing not enabled")
+		return func() {}, nil
+	}
+
+	opts := make([]otlptracehttp.Option, 0)
+	switch {
+	case strings.HasPrefix(address, "https://"):
+		opts = append(opts, otlptracehttp.WithEndpoint(strings.TrimPrefix(address, "https://")))
+	case strings.HasPrefix(address, "http://"):
+		opts = append(opts, otlptracehttp.WithEndpoint(strings.TrimPrefix(address, "http://")), otlptracehttp.WithInsecure())
+	default:
+		// Plain addresses are assumed to be a local collector.
+		opts = append(opts, otlptracehttp.WithEndpoint(address), otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(opts...))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create trace exporter")
+	}
+
+	sampleRatio := viper.GetFloat64("tracing-sample-ratio")
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, errors.New("tracing sample ratio must be between 0 and 1")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String("chaind"),
+			semconv.ServiceVersionKey.String(ReleaseVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	log.Info().Str("address", address).Float64("sample_ratio", sampleRatio).Msg("Tracing enabled")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to flush traces")
+		}
+	}, nil
+}
@@ -0,0 +1,62 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SecretResolver resolves values that may refer to secrets.
+type SecretResolver interface {
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// SetAuthHeaders sets the headers used to authenticate with a node on a request.
+// Header values and the bearer token may refer to secrets, which are resolved on
+// each request so that rotated values are used.  A JWT secret creates a fresh
+// token for each request.  The resolver can be nil if no values refer to secrets.
+func SetAuthHeaders(req *http.Request, resolver SecretResolver, headers map[string]string, bearerToken string, jwtSecret []byte) error {
+	resolve := func(value string) (string, error) {
+		if resolver == nil {
+			return value, nil
+		}
+		return resolver.Resolve(req.Context(), value)
+	}
+
+	for name, value := range headers {
+		resolved, err := resolve(value)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to resolve value of header %s", name))
+		}
+		req.Header.Set(name, resolved)
+	}
+
+	switch {
+	case bearerToken != "":
+		token, err := resolve(bearerToken)
+		if err != nil {
+			return errors.Wrap(err, "failed to resolve bearer token")
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	case jwtSecret != nil:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", JWT(jwtSecret, time.Now())))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// unixSocketPrefix is the prefix of node addresses that are unix domain sockets,
+// for example unix:///var/run/beacon.sock.
+const unixSocketPrefix = "unix://"
+
+// UnixSocketPath returns the path of the unix domain socket of a node address, or
+// an empty string if the address is not a unix domain socket.
+func UnixSocketPath(address string) string {
+	if !strings.HasPrefix(address, unixSocketPrefix) {
+		return ""
+	}
+
+	return strings.TrimPrefix(address, unixSocketPrefix)
+}
+
+// ConfigureTransport configures a transport to connect to a node address.  If the
+// address is a unix domain socket then all connections are made to the socket.
+// If a proxy URL is supplied, with a scheme of http, https or socks5, then
+// connections are made through the proxy.
+func ConfigureTransport(transport *http.Transport, address string, proxyURL string) error {
+	socketPath := UnixSocketPath(address)
+	if socketPath != "" && proxyURL != "" {
+		return errors.New("cannot connect to a unix domain socket through a proxy")
+	}
+
+	if socketPath != "" {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		transport.DialContext = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	}
+
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return errors.Wrap(err, "invalid proxy URL")
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return nil
+}
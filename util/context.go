@@ -0,0 +1,66 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// withoutCancel is a context that carries the values of its parent but is never cancelled.
+type withoutCancel struct {
+	parent context.Context
+}
+
+// WithoutCancel returns a context that carries the values of the parent but is not cancelled
+// when the parent is cancelled.  This allows work that must complete, such as persisting
+// progress, to continue whilst shutting down.
+func WithoutCancel(parent context.Context) context.Context {
+	return withoutCancel{parent: parent}
+}
+
+// Deadline returns no deadline.
+func (withoutCancel) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done returns a nil channel, as the context is never cancelled.
+func (withoutCancel) Done() <-chan struct{} {
+	return nil
+}
+
+// Err returns nil, as the context is never cancelled.
+func (withoutCancel) Err() error {
+	return nil
+}
+
+// Value returns the value from the parent context.
+func (c withoutCancel) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+// moduleKey is the context key for the module on whose behalf work is carried out.
+type moduleKey struct{}
+
+// WithModule returns a context that records the module on whose behalf work is carried out,
+// allowing shared services such as the chain database to attribute work to the module.
+func WithModule(parent context.Context, module string) context.Context {
+	return context.WithValue(parent, moduleKey{}, module)
+}
+
+// Module returns the module recorded in the context, or an empty string if there is none.
+func Module(ctx context.Context) string {
+	module, _ := ctx.Value(moduleKey{}).(string)
+	return module
+}
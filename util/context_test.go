@@ -0,0 +1,57 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+type testKey struct{}
+
+func TestWithoutCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), testKey{}, "value"))
+	ctx := util.WithoutCancel(parent)
+	cancel()
+
+	require.Error(t, parent.Err())
+	require.NoError(t, ctx.Err())
+	require.Nil(t, ctx.Done())
+	require.Equal(t, "value", ctx.Value(testKey{}))
+
+	// Contexts derived from the detached context can still be cancelled.
+	child, childCancel := context.WithCancel(ctx)
+	childCancel()
+	require.Error(t, child.Err())
+}
+
+func TestModule(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "", util.Module(ctx))
+
+	ctx = util.WithModule(ctx, "blocks")
+	require.Equal(t, "blocks", util.Module(ctx))
+
+	// The module is carried through derived contexts.
+	require.Equal(t, "blocks", util.Module(util.WithoutCancel(ctx)))
+	child, cancel := context.WithCancel(ctx)
+	defer cancel()
+	require.Equal(t, "blocks", util.Module(child))
+
+	// The module can be overridden.
+	require.Equal(t, "finalizer", util.Module(util.WithModule(ctx, "finalizer")))
+}
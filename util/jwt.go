@@ -0,0 +1,53 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jwtHeader is the encoded header of an HS256 JSON web token.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// ParseJWTSecret parses a hex-encoded JSON web token secret, as used to
+// authenticate with the engine API of Ethereum nodes.
+func ParseJWTSecret(data []byte) ([]byte, error) {
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid JWT secret")
+	}
+	if len(secret) < 32 {
+		return nil, errors.New("JWT secret must be at least 32 bytes")
+	}
+
+	return secret, nil
+}
+
+// JWT creates an HS256 JSON web token issued at the given time, signed with the
+// secret.
+func JWT(secret []byte, issuedAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"iat":%d}`, issuedAt.Unix())))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(jwtHeader + "." + payload))
+
+	return fmt.Sprintf("%s.%s.%s", jwtHeader, payload, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+}
@@ -16,49 +16,139 @@ package util
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"go.uber.org/atomic"
 )
 
+// serviceLevels are the log levels of services, keyed by service name.
+var serviceLevels sync.Map
+
+// serviceLoggers are the loggers of services, keyed by service name and implementation.
+var serviceLoggers sync.Map
+
+// levelSampler passes log events at or above a level that can be changed at runtime.
+type levelSampler struct {
+	level atomic.Int32
+}
+
+// Sample returns true if the event should be logged.
+func (s *levelSampler) Sample(lvl zerolog.Level) bool {
+	return lvl >= zerolog.Level(s.level.Load())
+}
+
+// ServiceLogger returns a logger for the given service and implementation.
+// The log level of the logger can be changed at runtime with SetServiceLogLevel().
+func ServiceLogger(service string, impl string, level zerolog.Level) zerolog.Logger {
+	return NetworkServiceLogger("", service, impl, level)
+}
+
+// NetworkServiceLogger returns a logger for the given service and implementation running
+// for the given network.  Each network has its own log level for the service, which can be
+// changed at runtime with SetServiceLogLevel() using the name from NetworkServiceName().
+// Repeated calls for the same network, service and implementation return the same logger.
+func NetworkServiceLogger(network string, service string, impl string, level zerolog.Level) zerolog.Logger {
+	name := NetworkServiceName(network, service)
+	sampler, _ := serviceLevels.LoadOrStore(name, &levelSampler{})
+	sampler.(*levelSampler).level.Store(int32(level))
+
+	key := fmt.Sprintf("%s/%s", name, impl)
+	if logger, exists := serviceLoggers.Load(key); exists {
+		return logger.(zerolog.Logger)
+	}
+	logCtx := zerologger.With()
+	if network != "" {
+		logCtx = logCtx.Str("network", network)
+	}
+	// The level of the logger itself is left at trace, with filtering carried out by the sampler.
+	logger, _ := serviceLoggers.LoadOrStore(key, logCtx.Str("service", service).Str("impl", impl).Logger().Level(zerolog.TraceLevel).Sample(sampler.(*levelSampler)))
+	return logger.(zerolog.Logger)
+}
+
+// NetworkServiceName returns the name of a service running for the given network, under
+// which its log level is held.  This is the service name if the network has no name.
+func NetworkServiceName(network string, service string) string {
+	if network == "" {
+		return service
+	}
+	return fmt.Sprintf("%s/%s", network, service)
+}
+
+// SetServiceLogLevel sets the log level for a service.
+// It returns false if the service does not have a logger.
+func SetServiceLogLevel(service string, level zerolog.Level) bool {
+	sampler, exists := serviceLevels.Load(service)
+	if !exists {
+		return false
+	}
+	sampler.(*levelSampler).level.Store(int32(level))
+	return true
+}
+
+// ServiceLogLevels returns the log levels for services, keyed by service name.
+func ServiceLogLevels() map[string]zerolog.Level {
+	levels := make(map[string]zerolog.Level)
+	serviceLevels.Range(func(key interface{}, value interface{}) bool {
+		levels[key.(string)] = zerolog.Level(value.(*levelSampler).level.Load())
+		return true
+	})
+	return levels
+}
+
 // LogLevel returns the best log level for the path.
 func LogLevel(path string) zerolog.Level {
+	return ConfigLogLevel(viper.GetViper(), path)
+}
+
+// ConfigLogLevel returns the best log level for the path in the given configuration.
+func ConfigLogLevel(config *viper.Viper, path string) zerolog.Level {
 	if path == "" {
-		return stringToLevel(viper.GetString("log-level"))
+		return stringToLevel(config.GetString("log-level"))
 	}
 
 	key := fmt.Sprintf("%s.log-level", path)
-	if viper.GetString(key) != "" {
-		return stringToLevel(viper.GetString(key))
+	if config.GetString(key) != "" {
+		return stringToLevel(config.GetString(key))
 	}
 	// Lop off the child and try again.
 	lastPeriod := strings.LastIndex(path, ".")
 	if lastPeriod == -1 {
-		return LogLevel("")
+		return ConfigLogLevel(config, "")
 	}
-	return LogLevel(path[0:lastPeriod])
+	return ConfigLogLevel(config, path[0:lastPeriod])
 }
 
 // stringtoLevel converts a string to a log level.
 // It returns the user-supplied level by default.
 func stringToLevel(input string) zerolog.Level {
+	level, err := ParseLogLevel(input)
+	if err != nil {
+		return zerologger.Logger.GetLevel()
+	}
+	return level
+}
+
+// ParseLogLevel parses a string to a log level.
+func ParseLogLevel(input string) (zerolog.Level, error) {
 	switch strings.ToLower(input) {
-	case "none":
-		return zerolog.Disabled
+	case "none", "disabled":
+		return zerolog.Disabled, nil
 	case "trace":
-		return zerolog.TraceLevel
+		return zerolog.TraceLevel, nil
 	case "debug":
-		return zerolog.DebugLevel
+		return zerolog.DebugLevel, nil
 	case "warn", "warning":
-		return zerolog.WarnLevel
+		return zerolog.WarnLevel, nil
 	case "info", "information":
-		return zerolog.InfoLevel
+		return zerolog.InfoLevel, nil
 	case "err", "error":
-		return zerolog.ErrorLevel
+		return zerolog.ErrorLevel, nil
 	case "fatal":
-		return zerolog.FatalLevel
+		return zerolog.FatalLevel, nil
 	default:
-		return zerologger.Logger.GetLevel()
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", input)
 	}
 }
@@ -0,0 +1,90 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestServiceLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	zerologger.Logger = zerolog.New(&buf)
+
+	require.False(t, util.SetServiceLogLevel("test", zerolog.DebugLevel))
+
+	log := util.ServiceLogger("test", "standard", zerolog.InfoLevel)
+	require.Equal(t, zerolog.InfoLevel, util.ServiceLogLevels()["test"])
+
+	log.Debug().Msg("hidden")
+	require.Empty(t, buf.String())
+
+	// Change the level of the existing logger.
+	require.True(t, util.SetServiceLogLevel("test", zerolog.DebugLevel))
+	require.Equal(t, zerolog.DebugLevel, util.ServiceLogLevels()["test"])
+	log.Debug().Msg("shown")
+	require.Contains(t, buf.String(), "shown")
+	require.Contains(t, buf.String(), `"service":"test"`)
+
+	// Child loggers follow the level of the service.
+	buf.Reset()
+	child := log.With().Str("child", "true").Logger()
+	require.True(t, util.SetServiceLogLevel("test", zerolog.Disabled))
+	child.Error().Msg("hidden")
+	require.Empty(t, buf.String())
+}
+
+func TestServiceLoggerRepeated(t *testing.T) {
+	var buf bytes.Buffer
+	zerologger.Logger = zerolog.New(&buf)
+
+	log1 := util.ServiceLogger("repeated", "standard", zerolog.InfoLevel)
+	log2 := util.ServiceLogger("repeated", "standard", zerolog.WarnLevel)
+	require.Equal(t, zerolog.WarnLevel, util.ServiceLogLevels()["repeated"])
+
+	// Both loggers follow the latest level.
+	log1.Info().Msg("hidden")
+	log2.Info().Msg("hidden")
+	require.Empty(t, buf.String())
+	log1.Warn().Msg("shown")
+	require.Contains(t, buf.String(), "shown")
+}
+
+func TestNetworkServiceLogger(t *testing.T) {
+	var buf bytes.Buffer
+	zerologger.Logger = zerolog.New(&buf)
+
+	log1 := util.NetworkServiceLogger("net1", "networked", "standard", zerolog.InfoLevel)
+	log2 := util.NetworkServiceLogger("net2", "networked", "standard", zerolog.WarnLevel)
+	require.Equal(t, zerolog.InfoLevel, util.ServiceLogLevels()["net1/networked"])
+	require.Equal(t, zerolog.WarnLevel, util.ServiceLogLevels()["net2/networked"])
+	_, exists := util.ServiceLogLevels()["networked"]
+	require.False(t, exists)
+
+	// Each network's logger follows its own level.
+	log2.Info().Msg("hidden")
+	require.Empty(t, buf.String())
+	log1.Info().Msg("shown")
+	require.Contains(t, buf.String(), `"network":"net1"`)
+
+	buf.Reset()
+	require.True(t, util.SetServiceLogLevel(util.NetworkServiceName("net2", "networked"), zerolog.InfoLevel))
+	log2.Info().Msg("shown")
+	require.Contains(t, buf.String(), `"network":"net2"`)
+}
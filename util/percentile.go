@@ -0,0 +1,45 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+)
+
+// PercentRanks provides the percent rank of each of the supplied values, in the same
+// order as the values.  The percent rank of a value is the proportion of the other values
+// that are lower than it, so the lowest value has a percent rank of 0 and the highest
+// value a percent rank of 1.  Equal values have the same percent rank.
+func PercentRanks(values []float64) []float64 {
+	ranks := make([]float64, len(values))
+	if len(values) < 2 {
+		return ranks
+	}
+
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	lower := 0
+	for i, index := range order {
+		if i > 0 && values[index] != values[order[i-1]] {
+			lower = i
+		}
+		ranks[index] = float64(lower) / float64(len(values)-1)
+	}
+
+	return ranks
+}
@@ -0,0 +1,65 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestPercentRanks(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		expected []float64
+	}{
+		{
+			name:     "Nil",
+			expected: []float64{},
+		},
+		{
+			name:     "Single",
+			values:   []float64{0.5},
+			expected: []float64{0},
+		},
+		{
+			name:     "Ordered",
+			values:   []float64{1, 2, 3, 4, 5},
+			expected: []float64{0, 0.25, 0.5, 0.75, 1},
+		},
+		{
+			name:     "Unordered",
+			values:   []float64{3, 1, 5, 2, 4},
+			expected: []float64{0.5, 0, 1, 0.25, 0.75},
+		},
+		{
+			name:     "Ties",
+			values:   []float64{1, 0, 1, 0.5, 1},
+			expected: []float64{0.5, 0, 0.5, 0.25, 0.5},
+		},
+		{
+			name:     "AllEqual",
+			values:   []float64{1, 1, 1},
+			expected: []float64{0, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, util.PercentRanks(test.values))
+		})
+	}
+}
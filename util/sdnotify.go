@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SDNotify sends a state notification to systemd, for example "READY=1".
+// It returns false if the process is not running under systemd with notifications enabled.
+func SDNotify(state string) (bool, error) {
+	addr := &net.UnixAddr{
+		Name: os.Getenv("NOTIFY_SOCKET"),
+		Net:  "unixgram",
+	}
+	if addr.Name == "" {
+		return false, nil
+	}
+
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to connect to notification socket")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, errors.Wrap(err, "failed to send notification")
+	}
+
+	return true, nil
+}
+
+// SDWatchdogInterval returns the interval within which systemd expects watchdog notifications.
+// It returns 0 if the systemd watchdog is not enabled for this process.
+func SDWatchdogInterval() (time.Duration, error) {
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	if usecStr == "" {
+		return 0, nil
+	}
+	usec, err := strconv.ParseUint(usecStr, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid watchdog interval")
+	}
+	if usec == 0 {
+		return 0, errors.New("watchdog interval cannot be 0")
+	}
+
+	// The watchdog may be intended for a different process.
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			return 0, errors.Wrap(err, "invalid watchdog PID")
+		}
+		if pid != os.Getpid() {
+			return 0, nil
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond, nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestSDNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	sent, err := util.SDNotify("READY=1")
+	require.NoError(t, err)
+	require.False(t, sent)
+
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	sent, err = util.SDNotify("READY=1")
+	require.NoError(t, err)
+	require.True(t, sent)
+
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func TestSDWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		usec     string
+		pid      string
+		interval time.Duration
+		err      string
+	}{
+		{
+			name: "Disabled",
+		},
+		{
+			name:     "Enabled",
+			usec:     "30000000",
+			interval: 30 * time.Second,
+		},
+		{
+			name:     "OurPID",
+			usec:     "30000000",
+			pid:      fmt.Sprintf("%d", os.Getpid()),
+			interval: 30 * time.Second,
+		},
+		{
+			name: "OtherPID",
+			usec: "30000000",
+			pid:  fmt.Sprintf("%d", os.Getpid()+1),
+		},
+		{
+			name: "Zero",
+			usec: "0",
+			err:  "watchdog interval cannot be 0",
+		},
+		{
+			name: "Invalid",
+			usec: "bad",
+			err:  `invalid watchdog interval: strconv.ParseUint: parsing "bad": invalid syntax`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", test.usec)
+			t.Setenv("WATCHDOG_PID", test.pid)
+			interval, err := util.SDWatchdogInterval()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.interval, interval)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// TLSConfig creates a client TLS configuration.  The client certificate and key
+// are supplied for mutual TLS, and the CA certificate for servers with certificates
+// that are not signed by the system roots; both are optional.
+func TLSConfig(serverName string, clientCert []byte, clientKey []byte, caCert []byte) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if clientCert != nil || clientKey != nil {
+		clientPair, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create client certificate")
+		}
+		config.Certificates = []tls.Certificate{clientPair}
+	}
+
+	if caCert != nil {
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to append root CA certificates")
+		}
+		config.RootCAs = rootCAs
+	}
+
+	return config, nil
+}
+
+// ReadTLSFiles reads the client certificate, client key and CA certificate from
+// their files.  Files that are not specified are returned as nil.
+func ReadTLSFiles(clientCertFile string, clientKeyFile string, caCertFile string) ([]byte, []byte, []byte, error) {
+	if (clientCertFile == "") != (clientKeyFile == "") {
+		return nil, nil, nil, errors.New("client certificate and key must be specified together")
+	}
+
+	files := []string{clientCertFile, clientKeyFile, caCertFile}
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		if file == "" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to read TLS file")
+		}
+		contents[i] = data
+	}
+
+	return contents[0], contents[1], contents[2], nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/testing/certs"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestTLSConfig(t *testing.T) {
+	testCerts := certs.Generate(t)
+
+	tests := []struct {
+		name         string
+		clientCert   []byte
+		clientKey    []byte
+		caCert       []byte
+		certificates int
+		rootCAs      bool
+		err          string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:         "Client",
+			clientCert:   testCerts.ClientCert,
+			clientKey:    testCerts.ClientKey,
+			certificates: 1,
+		},
+		{
+			name:    "CA",
+			caCert:  testCerts.CACert,
+			rootCAs: true,
+		},
+		{
+			name:         "ClientAndCA",
+			clientCert:   testCerts.ClientCert,
+			clientKey:    testCerts.ClientKey,
+			caCert:       testCerts.CACert,
+			certificates: 1,
+			rootCAs:      true,
+		},
+		{
+			name:       "ClientKeyMismatch",
+			clientCert: testCerts.ClientCert,
+			clientKey:  testCerts.ServerKey,
+			err:        "failed to create client certificate: tls: private key does not match public key",
+		},
+		{
+			name:   "CAInvalid",
+			caCert: []byte("invalid"),
+			err:    "failed to append root CA certificates",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := util.TLSConfig("localhost", test.clientCert, test.clientKey, test.caCert)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "localhost", config.ServerName)
+				require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+				require.Len(t, config.Certificates, test.certificates)
+				require.Equal(t, test.rootCAs, config.RootCAs != nil)
+			}
+		})
+	}
+}
+
+func TestReadTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, []byte("cert"), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, []byte("key"), 0o600))
+
+	clientCert, clientKey, caCert, err := util.ReadTLSFiles("", "", "")
+	require.NoError(t, err)
+	require.Nil(t, clientCert)
+	require.Nil(t, clientKey)
+	require.Nil(t, caCert)
+
+	clientCert, clientKey, caCert, err = util.ReadTLSFiles(certFile, keyFile, "")
+	require.NoError(t, err)
+	require.Equal(t, []byte("cert"), clientCert)
+	require.Equal(t, []byte("key"), clientKey)
+	require.Nil(t, caCert)
+
+	_, _, _, err = util.ReadTLSFiles(certFile, "", "")
+	require.EqualError(t, err, "client certificate and key must be specified together")
+
+	_, _, _, err = util.ReadTLSFiles("", "", filepath.Join(dir, "missing.crt"))
+	require.ErrorContains(t, err, "failed to read TLS file")
+}
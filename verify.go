@@ -0,0 +1,212 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/blocks"
+	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	auditchaindb "github.com/wealdtech/chaind/services/chaindb/audit"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/metrics"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	standardverifier "github.com/wealdtech/chaind/services/verifier/standard"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// verifyDatabase compares a sample of the data in the database for each network with that
+// from the beacon node, reporting and optionally repairing any mismatches.  It returns an
+// error if any mismatches remain.
+func verifyDatabase(ctx context.Context, monitor metrics.Service) error {
+	networks, err := configuredNetworks()
+	if err != nil {
+		return err
+	}
+
+	unrepaired := 0
+	for _, network := range networks {
+		prefix := ""
+		if network.name != "" {
+			prefix = network.name + "/"
+		}
+		networkUnrepaired, err := verifyNetwork(util.WithModule(ctx, "verify"), prefix, network, monitor)
+		if err != nil {
+			if network.name != "" {
+				return errors.Wrap(err, fmt.Sprintf("failed to verify network %q", network.name))
+			}
+			return err
+		}
+		unrepaired += networkUnrepaired
+	}
+
+	if unrepaired > 0 {
+		return fmt.Errorf("verification failed: %d mismatch(es) found", unrepaired)
+	}
+
+	return nil
+}
+
+// verifyNetwork verifies the database for a single network, returning the number of unrepaired mismatches.
+func verifyNetwork(ctx context.Context, prefix string, network *network, monitor metrics.Service) (int, error) {
+	config := network.config
+	cacheSvc, err := startCache(ctx, network)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to start cache service")
+	}
+	chainDB, err := startDatabase(ctx, network, cacheSvc, monitor)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkSchemaCurrent(ctx, chainDB); err != nil {
+		return 0, err
+	}
+
+	eth2Client, err := fetchClient(ctx, network, config.GetString("eth2client.address"), monitor)
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", config.GetString("eth2client.address")))
+	}
+
+	startSlot := phase0.Slot(0)
+	if config.GetInt64("verify.start-slot") >= 0 {
+		startSlot = phase0.Slot(config.GetInt64("verify.start-slot"))
+	}
+	var endSlot phase0.Slot
+	if config.GetInt64("verify.end-slot") >= 0 {
+		endSlot = phase0.Slot(config.GetInt64("verify.end-slot"))
+	} else {
+		// Only finalized blocks are marked canonical, so stop there to avoid reporting
+		// differences that will be resolved by the finalizer.
+		latestCanonicalSlot, err := chainDB.(chaindb.BlocksProvider).LatestCanonicalBlock(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to obtain latest canonical block")
+		}
+		endSlot = latestCanonicalSlot + 1
+	}
+
+	var blocksSvc blocks.Service
+	if config.GetBool("verify.repair") {
+		blocksSvc, err = startRepairBlocks(ctx, network, eth2Client, chainDB)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	verifier, err := standardverifier.New(ctx,
+		standardverifier.WithLogLevel(network.logLevel("verify")),
+		standardverifier.WithNetwork(network.name),
+		standardverifier.WithETH2Client(eth2Client),
+		standardverifier.WithChainDB(chainDB),
+		standardverifier.WithBlocks(blocksSvc),
+		standardverifier.WithSlotSamples(config.GetInt("verify.slots")),
+		standardverifier.WithValidatorSamples(config.GetInt("verify.validators")),
+		standardverifier.WithRepair(config.GetBool("verify.repair")),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create verifier")
+	}
+
+	fmt.Printf("Verifying %sslots %d to %d against %s\n", prefix, startSlot, endSlot, eth2Client.Address())
+	mismatches, err := verifier.Verify(ctx, startSlot, endSlot)
+	if err != nil {
+		return 0, err
+	}
+
+	unrepaired := 0
+	for _, mismatch := range mismatches {
+		status := "MISMATCH"
+		if mismatch.Repaired {
+			status = "REPAIRED"
+		} else {
+			unrepaired++
+		}
+		fmt.Printf("%-9s%s%s: %s\n", status, prefix, mismatch.Subject, mismatch.Detail)
+	}
+	if len(mismatches) == 0 {
+		fmt.Printf("No mismatches found for %sdatabase\n", prefix)
+	}
+
+	return unrepaired, nil
+}
+
+// checkSchemaCurrent ensures that the database schema does not require an upgrade, as
+// otherwise data may be missing because it has yet to be refetched.
+func checkSchemaCurrent(ctx context.Context, chainDB chaindb.Service) error {
+	postgresqlChainDB, isPostgreSQL := chainDB.(*postgresqlchaindb.Service)
+	if auditChainDB, isAudit := chainDB.(*auditchaindb.Service); isAudit {
+		postgresqlChainDB, isPostgreSQL = auditChainDB.Service, true
+	}
+	if !isPostgreSQL {
+		return nil
+	}
+
+	version, _, err := postgresqlChainDB.SchemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+	if version != postgresqlchaindb.RequiredSchemaVersion() {
+		return fmt.Errorf("database schema is at version %d but version %d is required; start chaind to upgrade it before verifying", version, postgresqlchaindb.RequiredSchemaVersion())
+	}
+
+	return nil
+}
+
+// startRepairBlocks starts a blocks service that is used to write repaired blocks, without
+// catching up or following the chain.
+func startRepairBlocks(ctx context.Context, network *network, eth2Client eth2client.Service, chainDB chaindb.Service) (blocks.Service, error) {
+	genesisProvider, err := fetchGenesisProvider(ctx, network, eth2Client)
+	if err != nil {
+		return nil, err
+	}
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(network.logLevel("chaintime")),
+		standardchaintime.WithNetwork(network.name),
+		standardchaintime.WithGenesisTimeProvider(genesisProvider),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+		standardchaintime.WithStoredForkScheduleProvider(chainDB.(chaindb.ForkScheduleProvider)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain time service")
+	}
+
+	// The blocks service only catches up and follows the chain if it can obtain the
+	// activity semaphore, so hold it for the lifetime of the command.
+	activitySem := semaphore.NewWeighted(1)
+	if !activitySem.TryAcquire(1) {
+		return nil, errors.New("failed to acquire activity semaphore")
+	}
+
+	blocksSvc, err := standardblocks.New(ctx,
+		standardblocks.WithLogLevel(network.logLevel("blocks")),
+		standardblocks.WithNetwork(network.name),
+		standardblocks.WithMonitor(&nullmetrics.Service{}),
+		standardblocks.WithETH2Client(eth2Client),
+		standardblocks.WithChainTime(chainTime),
+		standardblocks.WithChainDB(chainDB),
+		standardblocks.WithActivitySem(activitySem),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create blocks service")
+	}
+
+	return blocksSvc, nil
+}